@@ -1,13 +1,19 @@
 package main
 
 import (
-	"gonzo/pkg/cmd"
+	"github.com/andybarilla/gonzo/pkg/cmd"
 )
 
-// version is set at build time via -ldflags "-X main.version=..."
-var version = "dev"
+// version, commit and date are set at build time via
+// -ldflags "-X main.version=... -X main.commit=... -X main.date=..."
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
 
 func main() {
 	cmd.SetVersion(version)
+	cmd.SetBuildInfo(commit, date)
 	cmd.Execute()
 }
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// abortCmd asks a running gonzo process to stop
+var abortCmd = &cobra.Command{
+	Use:   "abort [run-id]",
+	Short: "Stop a running gonzo run after its current iteration",
+	Long: `Abort asks a gonzo run in progress in this repository to stop after its
+current iteration. Without a run ID the most recent running run is stopped.
+
+The run is not killed: it finishes the iteration in progress, then commits
+leftover changes, opens the pull request when enabled and records its state
+with the aborted status, as when it runs out of iterations.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeRunIDs,
+	SilenceUsage:      true,
+	RunE:              runAbort,
+}
+
+func init() {
+	rootCmd.AddCommand(abortCmd)
+}
+
+func runAbort(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	id := ""
+	if len(args) == 1 {
+		id = args[0]
+	}
+	state, err := gonzo.RequestStop(dir, id)
+	if err != nil {
+		return err
+	}
+
+	if state.PID != 0 {
+		cmd.Printf("Run %s (pid %d) will stop after its current iteration\n", state.ID, state.PID)
+	} else {
+		cmd.Printf("Run %s will stop after its current iteration\n", state.ID)
+	}
+	return nil
+}
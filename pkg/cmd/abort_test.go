@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAbort(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	if _, _, err := executeCommandC(rootCmd, "abort"); err == nil || !strings.Contains(err.Error(), "no running gonzo run") {
+		t.Errorf("expected an error without a running run, got %v", err)
+	}
+
+	state := &gonzo.RunState{ID: "20260101-000000-aaaaaa", Status: gonzo.RunStatusRunning, StartedAt: time.Now(), PID: 4242}
+	if err := state.Save(dir); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+
+	_, output, err := executeCommandC(rootCmd, "abort", state.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "Run 20260101-000000-aaaaaa (pid 4242) will stop") {
+		t.Errorf("unexpected output %q", output)
+	}
+	if _, err := os.Stat(filepath.Join(gonzo.RunDir(dir, state.ID), gonzo.StopFile)); err != nil {
+		t.Errorf("expected a stop request: %v", err)
+	}
+}
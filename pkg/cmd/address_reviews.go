@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"github.com/andybarilla/gonzo/pkg/github"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// reviewClient is the subset of the GitHub client used to address review comments.
+type reviewClient interface {
+	FindPullRequest(ctx context.Context, head string) (*forge.PullRequest, error)
+	CheckoutPullRequest(ctx context.Context, ref string) error
+	UnresolvedReviewComments(ctx context.Context, number int) ([]github.ReviewComment, error)
+	ReplyToReviewComment(ctx context.Context, number int, id int64, body string) error
+}
+
+// newReviewClient creates the GitHub client used by address-reviews. Replaceable for testing.
+var newReviewClient = func(dir string) reviewClient {
+	return github.New(dir)
+}
+
+// addressReviewsCmd iterates on the feedback left on an existing pull request
+var addressReviewsCmd = &cobra.Command{
+	Use:   "address-reviews <pr>",
+	Short: "Address the unresolved review comments on a pull request",
+	Long: `Address-reviews fetches the unresolved review comments on a pull request,
+identified by number, URL or branch, checks out its branch and runs the loop
+until the comments are addressed. The changes are pushed to the pull request
+and gonzo replies to each comment with what was done.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runAddressReviews,
+}
+
+func init() {
+	rootCmd.AddCommand(addressReviewsCmd)
+}
+
+func runAddressReviews(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	ctx := cmd.Context()
+	client := newReviewClient(dir)
+
+	pr, err := client.FindPullRequest(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to find pull request %s: %w", args[0], err)
+	}
+
+	comments, err := client.UnresolvedReviewComments(ctx, pr.Number)
+	if err != nil {
+		return fmt.Errorf("failed to fetch review comments: %w", err)
+	}
+	if len(comments) == 0 {
+		cmd.Printf("No unresolved review comments on pull request #%d\n", pr.Number)
+		return nil
+	}
+
+	prompt, err := gonzo.ReviewPrompt(pr, comments)
+	if err != nil {
+		return err
+	}
+
+	if err := client.CheckoutPullRequest(ctx, args[0]); err != nil {
+		return fmt.Errorf("failed to check out pull request #%d: %w", pr.Number, err)
+	}
+	cmd.Printf("Addressing %d review comment(s) on pull request #%d\n", len(comments), pr.Number)
+
+	// Work on the pull request's branch and push to it when done
+	config.ApplySettings(map[string]interface{}{
+		config.KeyNoBranch: true,
+		config.KeyPR:       true,
+	})
+
+	runner, err := buildRunner(cmd, nil, gonzo.WithPROnCurrentBranch(true))
+	if err != nil {
+		return err
+	}
+	response, err := runner.Generate(ctx, prompt)
+	if err != nil {
+		return err
+	}
+
+	replies, summary := gonzo.ParseReviewReplies(response)
+	for _, c := range comments {
+		reply, ok := replies[c.ID]
+		if !ok {
+			cmd.Printf("No reply for review comment %d\n", c.ID)
+			continue
+		}
+		if err := client.ReplyToReviewComment(ctx, pr.Number, c.ID, reply); err != nil {
+			cmd.Printf("Failed to reply to review comment %d: %v\n", c.ID, err)
+		}
+	}
+
+	cmd.Println(summary)
+	return nil
+}
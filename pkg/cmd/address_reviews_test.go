@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"github.com/andybarilla/gonzo/pkg/github"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// fakeReviewClient serves canned review comments and records replies.
+type fakeReviewClient struct {
+	comments   []github.ReviewComment
+	checkedOut string
+	replies    map[int64]string
+}
+
+func (f *fakeReviewClient) FindPullRequest(ctx context.Context, head string) (*forge.PullRequest, error) {
+	return &forge.PullRequest{Number: 7, Title: "Add login"}, nil
+}
+
+func (f *fakeReviewClient) CheckoutPullRequest(ctx context.Context, ref string) error {
+	f.checkedOut = ref
+	return nil
+}
+
+func (f *fakeReviewClient) UnresolvedReviewComments(ctx context.Context, number int) ([]github.ReviewComment, error) {
+	return f.comments, nil
+}
+
+func (f *fakeReviewClient) ReplyToReviewComment(ctx context.Context, number int, id int64, body string) error {
+	if f.replies == nil {
+		f.replies = map[int64]string{}
+	}
+	f.replies[id] = body
+	return nil
+}
+
+func TestAddressReviews(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	originalNewReviewClient := newReviewClient
+	defer func() {
+		newRunner = originalNewRunner
+		newReviewClient = originalNewReviewClient
+		viper.Reset()
+	}()
+
+	fake := &fakeReviewClient{comments: []github.ReviewComment{
+		{ID: 11, Path: "auth.go", Line: 3, Author: "octocat", Body: "Handle the error"},
+		{ID: 12, Author: "hubot", Body: "Add docs"},
+	}}
+	newReviewClient = func(dir string) reviewClient { return fake }
+
+	mock := &mockRunner{response: "Done.\n<reply id=\"11\">Returned the error.</reply>"}
+	newRunner = mockRunnerFactory(mock)
+
+	_, output, err := executeCommandC(rootCmd, "address-reviews", "7")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fake.checkedOut != "7" {
+		t.Errorf("expected pull request 7 to be checked out, got %q", fake.checkedOut)
+	}
+	if !strings.Contains(mock.capturedPrompt, "Handle the error") || !strings.Contains(mock.capturedPrompt, "Add docs") {
+		t.Errorf("expected the review comments in the prompt, got %q", mock.capturedPrompt)
+	}
+	if !mock.noBranch || !mock.pr {
+		t.Error("expected the run to stay on the pull request branch and push it")
+	}
+	onBranch := append([]gonzo.Option{gonzo.WithNoBranch(true), gonzo.WithPR(true)}, mock.opts...)
+	if err := gonzo.New(onBranch...).Validate(); err != nil {
+		t.Errorf("expected the run to be allowed to push to the pull request branch, got %v", err)
+	}
+	if len(fake.replies) != 1 || fake.replies[11] != "Returned the error." {
+		t.Errorf("expected a reply to comment 11 only, got %v", fake.replies)
+	}
+	if !strings.Contains(output, "No reply for review comment 12") {
+		t.Errorf("expected missing replies to be reported, got %q", output)
+	}
+	if strings.Contains(output, "<reply") {
+		t.Errorf("expected reply blocks to be removed from the output, got %q", output)
+	}
+}
+
+func TestAddressReviews_NoComments(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	originalNewReviewClient := newReviewClient
+	defer func() {
+		newRunner = originalNewRunner
+		newReviewClient = originalNewReviewClient
+		viper.Reset()
+	}()
+
+	fake := &fakeReviewClient{}
+	newReviewClient = func(dir string) reviewClient { return fake }
+
+	mock := &mockRunner{}
+	newRunner = mockRunnerFactory(mock)
+
+	_, output, err := executeCommandC(rootCmd, "address-reviews", "7")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mock.generateCalled || fake.checkedOut != "" {
+		t.Error("expected nothing to run without unresolved comments")
+	}
+	if !strings.Contains(output, "No unresolved review comments") {
+		t.Errorf("unexpected output %q", output)
+	}
+}
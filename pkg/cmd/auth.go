@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"github.com/andybarilla/gonzo/pkg/github"
+	"github.com/andybarilla/gonzo/pkg/gitlab"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"github.com/andybarilla/gonzo/pkg/keyring"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Keyring and forge access used by the auth commands. Replaceable for testing.
+var (
+	storeToken  = keyring.Set
+	removeToken = keyring.Delete
+	forgeToken  = gonzo.ForgeToken
+	verifyToken = func(ctx context.Context, dir string, name string, token string) error {
+		return gonzo.NewForgeClient(dir, name, token).AuthStatus(ctx)
+	}
+)
+
+// authCmd manages the tokens gonzo uses to open pull requests
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage the forge token used to open pull requests",
+	Long: `Auth stores, checks and removes the GitHub or GitLab access token gonzo uses
+to open pull requests and read issues. Tokens are kept in the system keyring
+(macOS Keychain or libsecret on Linux) under the forge's name.
+
+Without a stored token gonzo falls back to GH_TOKEN or GITHUB_TOKEN for GitHub
+and GITLAB_TOKEN for GitLab, then to the gh or glab CLI's own login.
+
+The forge is detected from the origin remote; use --forge to pick one.`,
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Store a forge token in the system keyring",
+	Long: `Login reads an access token from standard input, checks that the forge accepts
+it and stores it in the system keyring:
+
+  echo "$TOKEN" | gonzo auth login`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runAuthLogin,
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:          "status",
+	Short:        "Show which forge token is used and whether it is valid",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runAuthStatus,
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:          "logout",
+	Short:        "Remove the forge token from the system keyring",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runAuthLogout,
+}
+
+func init() {
+	authCmd.AddCommand(authLoginCmd, authStatusCmd, authLogoutCmd)
+	rootCmd.AddCommand(authCmd)
+}
+
+// authForge returns the working directory and the forge the auth commands act on.
+func authForge(cmd *cobra.Command) (string, string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	return dir, gonzo.ResolveForge(cmd.Context(), dir, config.GetForge()), nil
+}
+
+func runAuthLogin(cmd *cobra.Command, args []string) error {
+	dir, name, err := authForge(cmd)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Paste a %s token: ", name)
+	token, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return errors.New("no token provided on standard input")
+	}
+	cmd.Println()
+
+	if err := verifyToken(cmd.Context(), dir, name, token); err != nil {
+		return fmt.Errorf("%s rejected the token: %w", name, err)
+	}
+	if err := storeToken(cmd.Context(), name, token); err != nil {
+		return fmt.Errorf("failed to store token (set %s instead): %w", strings.Join(forge.TokenEnv[name], " or "), err)
+	}
+
+	cmd.Printf("Stored the %s token in the system keyring\n", name)
+	return nil
+}
+
+func runAuthStatus(cmd *cobra.Command, args []string) error {
+	dir, name, err := authForge(cmd)
+	if err != nil {
+		return err
+	}
+
+	token, source := forgeToken(cmd.Context(), name)
+	switch source {
+	case "":
+		cmd.Printf("%s: no token configured, using the %s CLI's own login\n", name, forgeCli(name))
+	case gonzo.TokenSourceKeyring:
+		cmd.Printf("%s: using the token from the system keyring\n", name)
+	default:
+		cmd.Printf("%s: using the token from %s\n", name, source)
+	}
+
+	if err := verifyToken(cmd.Context(), dir, name, token); err != nil {
+		return fmt.Errorf("not authenticated with %s: %w", name, err)
+	}
+	cmd.Printf("%s: authenticated\n", name)
+	return nil
+}
+
+func runAuthLogout(cmd *cobra.Command, args []string) error {
+	_, name, err := authForge(cmd)
+	if err != nil {
+		return err
+	}
+	if err := removeToken(cmd.Context(), name); err != nil {
+		return fmt.Errorf("failed to remove token: %w", err)
+	}
+	cmd.Printf("Removed the %s token from the system keyring\n", name)
+	return nil
+}
+
+// forgeCli returns the name of the CLI gonzo uses to talk to the forge.
+func forgeCli(name string) string {
+	if name == forge.GitLab {
+		return gitlab.GlabCli
+	}
+	return github.GhCli
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// fakeAuth replaces the keyring and token verification used by the auth commands.
+type fakeAuth struct {
+	stored   map[string]string
+	verified []string
+	rejected bool
+}
+
+func withFakeAuth(t *testing.T, fake *fakeAuth) {
+	t.Helper()
+	originalStore, originalRemove, originalForgeToken, originalVerify := storeToken, removeToken, forgeToken, verifyToken
+	t.Cleanup(func() {
+		storeToken, removeToken, forgeToken, verifyToken = originalStore, originalRemove, originalForgeToken, originalVerify
+		rootCmd.SetIn(nil)
+		viper.Reset()
+	})
+
+	if fake.stored == nil {
+		fake.stored = map[string]string{}
+	}
+	storeToken = func(ctx context.Context, account string, secret string) error {
+		fake.stored[account] = secret
+		return nil
+	}
+	removeToken = func(ctx context.Context, account string) error {
+		delete(fake.stored, account)
+		return nil
+	}
+	forgeToken = func(ctx context.Context, name string) (string, string) {
+		if token, ok := fake.stored[name]; ok {
+			return token, gonzo.TokenSourceKeyring
+		}
+		return "", ""
+	}
+	verifyToken = func(ctx context.Context, dir string, name string, token string) error {
+		fake.verified = append(fake.verified, name+":"+token)
+		if fake.rejected {
+			return errors.New("bad credentials")
+		}
+		return nil
+	}
+}
+
+func TestAuthLogin(t *testing.T) {
+	fake := &fakeAuth{}
+	withFakeAuth(t, fake)
+
+	rootCmd.SetIn(strings.NewReader("glpat-123\n"))
+	_, output, err := executeCommandC(rootCmd, "auth", "login", "--forge", "gitlab")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.stored["gitlab"] != "glpat-123" {
+		t.Errorf("expected the token to be stored for gitlab, got %v", fake.stored)
+	}
+	if len(fake.verified) != 1 || fake.verified[0] != "gitlab:glpat-123" {
+		t.Errorf("expected the token to be verified before storing, got %v", fake.verified)
+	}
+	if !strings.Contains(output, "Stored the gitlab token") {
+		t.Errorf("unexpected output %q", output)
+	}
+}
+
+func TestAuthLogin_Rejected(t *testing.T) {
+	fake := &fakeAuth{rejected: true}
+	withFakeAuth(t, fake)
+
+	rootCmd.SetIn(strings.NewReader("bad\n"))
+	_, _, err := executeCommandC(rootCmd, "auth", "login", "--forge", "github")
+	if err == nil || !strings.Contains(err.Error(), "rejected the token") {
+		t.Errorf("expected the token to be rejected, got %v", err)
+	}
+	if len(fake.stored) != 0 {
+		t.Errorf("expected nothing to be stored, got %v", fake.stored)
+	}
+
+	rootCmd.SetIn(strings.NewReader(""))
+	if _, _, err := executeCommandC(rootCmd, "auth", "login", "--forge", "github"); err == nil {
+		t.Error("expected error without a token")
+	}
+}
+
+func TestAuthStatusAndLogout(t *testing.T) {
+	fake := &fakeAuth{stored: map[string]string{"github": "ghp_abc"}}
+	withFakeAuth(t, fake)
+
+	_, output, err := executeCommandC(rootCmd, "auth", "status", "--forge", "github")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "github: using the token from the system keyring") || !strings.Contains(output, "github: authenticated") {
+		t.Errorf("unexpected output %q", output)
+	}
+
+	if _, _, err := executeCommandC(rootCmd, "auth", "logout", "--forge", "github"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fake.stored) != 0 {
+		t.Errorf("expected the token to be removed, got %v", fake.stored)
+	}
+
+	fake.rejected = true
+	_, output, err = executeCommandC(rootCmd, "auth", "status", "--forge", "github")
+	if err == nil {
+		t.Error("expected error when not authenticated")
+	}
+	if !strings.Contains(output, "using the gh CLI's own login") {
+		t.Errorf("unexpected output %q", output)
+	}
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var continueOnError bool
+
+// batchCmd runs the features listed in a YAML file one after the other
+var batchCmd = &cobra.Command{
+	Use:   "batch <tasks.yaml>",
+	Short: "Implement several features in sequence from a YAML file",
+	Long: `Batch runs the loop once for every task listed in a YAML file, in order:
+
+  tasks:
+    - name: login
+      feature: Add a login button to the header
+      model: claude-sonnet-4-5
+      max-iterations: 5
+      branch: feature/login
+    - feature: Document the login flow
+
+Only feature is required. model and max-iterations override the configured
+values for that task. With branch, the task runs on the named branch, created
+when it does not exist, instead of one named by the agent. Every task starts
+from the branch checked out when the batch started.
+
+A report with the outcome of every task is printed at the end. The batch stops
+at the first task that does not complete, unless --continue-on-error is given.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runBatch,
+}
+
+func init() {
+	batchCmd.Flags().BoolVar(
+		&continueOnError,
+		"continue-on-error", false,
+		"Run the remaining tasks when a task does not complete")
+
+	rootCmd.AddCommand(batchCmd)
+}
+
+func runBatch(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	batch, err := gonzo.LoadBatch(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Outside of a git repository there is nothing to return to between tasks
+	base, _ := gonzo.BatchBase(cmd.Context(), dir)
+
+	// The configured values the tasks fall back to, resolved before any task overrides them
+	cfg, err := loadRunConfig(cmd)
+	if err != nil {
+		return err
+	}
+	defaults := map[string]interface{}{
+		config.KeyModel:         cfg.Model,
+		config.KeyMaxIterations: cfg.MaxIterations,
+		config.KeyNoBranch:      cfg.NoBranch,
+	}
+
+	results := make([]gonzo.BatchResult, len(batch.Tasks))
+	stopped := false
+	for i, task := range batch.Tasks {
+		if stopped {
+			results[i] = gonzo.BatchResult{Task: task, Status: gonzo.BatchStatusSkipped}
+			continue
+		}
+
+		cmd.Printf("Task %d/%d: %s\n", i+1, len(batch.Tasks), task.Label())
+		results[i] = runBatchTask(cmd, dir, base, task, defaults)
+		if results[i].Failed() && !continueOnError {
+			stopped = true
+		}
+	}
+
+	printBatchReport(cmd, results)
+
+	failed := 0
+	for _, r := range results {
+		if r.Failed() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d task(s) did not complete", failed, len(results))
+	}
+	return nil
+}
+
+// runBatchTask runs a single task of a batch with its overrides applied on top of defaults.
+func runBatchTask(cmd *cobra.Command, dir string, base string, task gonzo.BatchTask, defaults map[string]interface{}) gonzo.BatchResult {
+	ctx := cmd.Context()
+	result := gonzo.BatchResult{Task: task, Status: gonzo.RunStatusFailed}
+	start := time.Now()
+
+	if base != "" || task.Branch != "" {
+		if err := gonzo.CheckoutBatchBranch(ctx, dir, task.Branch, base); err != nil {
+			result.Err = fmt.Errorf("failed to check out the task's branch: %w", err)
+			return result
+		}
+	}
+
+	settings := map[string]interface{}{}
+	for key, value := range defaults {
+		settings[key] = value
+	}
+	if task.Model != "" {
+		settings[config.KeyModel] = task.Model
+	}
+	if task.MaxIterations > 0 {
+		settings[config.KeyMaxIterations] = task.MaxIterations
+	}
+	if task.Branch != "" {
+		settings[config.KeyNoBranch] = true
+	}
+	config.ApplySettings(settings)
+
+	runner, err := buildRunner(cmd, nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	previous, _ := gonzo.LatestRunState(dir)
+	response, err := runner.Generate(ctx, task.Feature)
+	result.Duration = time.Since(start)
+
+	if err != nil {
+		result.Err = err
+	} else {
+		result.Status = gonzo.RunStatusCompleted
+		cmd.Println(response)
+	}
+
+	// Take the outcome from the run's record when one was written
+	if run, _ := gonzo.LatestRunState(dir); run != nil && (previous == nil || run.ID != previous.ID) {
+		result.RunID = run.ID
+		result.Status = run.Status
+		result.Branch = run.Branch
+		result.PRURL = run.PRURL
+		result.Iterations = run.Iterations
+	}
+	return result
+}
+
+// printBatchReport prints the outcome of every task of a batch.
+func printBatchReport(cmd *cobra.Command, results []gonzo.BatchResult) {
+	cmd.Println()
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TASK\tSTATUS\tRUN\tITERATIONS\tDURATION\tBRANCH\tPULL REQUEST")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			r.Task.Label(), r.Status, orDash(r.RunID), r.Iterations, r.Duration.Round(time.Second), orDash(r.Branch), orDash(r.PRURL))
+	}
+	_ = w.Flush()
+
+	for i, r := range results {
+		if r.Err != nil {
+			cmd.Printf("Task %d (%s) failed: %v\n", i+1, r.Task.Label(), r.Err)
+		}
+	}
+}
+
+// orDash returns s, or "-" when it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+const testBatch = `tasks:
+  - name: login
+    feature: Add a login button
+    model: claude-sonnet-4-5
+    max-iterations: 3
+  - feature: Break the build
+  - feature: Document the login flow
+`
+
+// batchRunnerFactory records the model and max-iterations of every run and fails the
+// runs of the "Break the build" feature.
+func batchRunnerFactory(calls *[]string) func(dir string, cfg *config.Config, settings map[string]interface{}, issue *forge.Issue, opts ...gonzo.Option) gonzo.Runner {
+	return func(dir string, cfg *config.Config, settings map[string]interface{}, issue *forge.Issue, opts ...gonzo.Option) gonzo.Runner {
+		return &batchRunner{calls: calls, model: cfg.Model, maxIter: cfg.MaxIterations}
+	}
+}
+
+type batchRunner struct {
+	mockRunner
+	calls   *[]string
+	model   string
+	maxIter int
+}
+
+func (r *batchRunner) Generate(ctx context.Context, prompt string) (string, error) {
+	*r.calls = append(*r.calls, fmt.Sprintf("%s %s %d", prompt, r.model, r.maxIter))
+	if prompt == "Break the build" {
+		return "", errors.New("build broken")
+	}
+	return "done", nil
+}
+
+func writeBatch(t *testing.T) string {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("tasks.yaml", []byte(testBatch), 0644); err != nil {
+		t.Fatalf("failed to write tasks.yaml: %v", err)
+	}
+	return "tasks.yaml"
+}
+
+func TestBatch_StopsAtFailure(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	defer func() {
+		newRunner = originalNewRunner
+		viper.Reset()
+	}()
+
+	t.Setenv("GONZO_MAX_ITERATIONS", "8")
+	var calls []string
+	newRunner = batchRunnerFactory(&calls)
+
+	_, output, err := executeCommandC(rootCmd, "batch", writeBatch(t))
+	if err == nil || !strings.Contains(err.Error(), "2 of 3 task(s) did not complete") {
+		t.Errorf("expected the failed and skipped tasks to be reported, got %v", err)
+	}
+
+	want := []string{"Add a login button claude-sonnet-4-5 3", "Break the build claude-opus-4-5 8"}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected runs %q, got %q", want, calls)
+	}
+	for _, s := range []string{"login", "completed", "failed", "skipped", "build broken"} {
+		if !strings.Contains(output, s) {
+			t.Errorf("expected %q in the report, got %q", s, output)
+		}
+	}
+}
+
+func TestBatch_ContinueOnError(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	defer func() {
+		newRunner = originalNewRunner
+		continueOnError = false
+		viper.Reset()
+	}()
+
+	var calls []string
+	newRunner = batchRunnerFactory(&calls)
+
+	_, output, err := executeCommandC(rootCmd, "batch", "--continue-on-error", writeBatch(t))
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 task(s) did not complete") {
+		t.Errorf("expected the failed task to be reported, got %v", err)
+	}
+	if len(calls) != 3 {
+		t.Errorf("expected every task to run, got %q", calls)
+	}
+	if strings.Contains(output, "skipped") {
+		t.Errorf("expected no skipped task, got %q", output)
+	}
+}
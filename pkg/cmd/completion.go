@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// completionCmd prints shell completion scripts
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish|powershell",
+	Short: "Generate the shell completion script",
+	Long: `Completion prints a completion script for the given shell. Besides commands
+and flags, it completes model, forge and CI names, and run IDs from .gonzo/runs/
+for show, rollback and rerun.
+
+To load completions in the current shell session:
+
+  source <(gonzo completion bash)
+  source <(gonzo completion zsh)
+  gonzo completion fish | source
+  gonzo completion powershell | Out-String | Invoke-Expression
+
+To load them in every new session, write the script to your shell's completion
+directory, e.g. gonzo completion bash > /etc/bash_completion.d/gonzo`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	SilenceUsage:          true,
+	RunE:                  runCompletion,
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	switch args[0] {
+	case "bash":
+		return cmd.Root().GenBashCompletionV2(out, true)
+	case "zsh":
+		return cmd.Root().GenZshCompletion(out)
+	case "fish":
+		return cmd.Root().GenFishCompletion(out, true)
+	default:
+		return cmd.Root().GenPowerShellCompletionWithDesc(out)
+	}
+}
+
+// completeValues returns a completion function offering a fixed set of flag values.
+func completeValues(values ...string) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeRunIDs completes the first argument with the IDs of the runs recorded in the
+// current directory, most recent first, described by their status and feature.
+func completeRunIDs(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	states, err := gonzo.ListRunStates(dir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var completions []cobra.Completion
+	for _, s := range slices.Backward(states) {
+		if strings.HasPrefix(s.ID, toComplete) {
+			completions = append(completions, cobra.CompletionWithDesc(s.ID, s.Status+": "+firstLine(s.Feature)))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// firstLine returns the first line of s.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// completeRunIDsOrFiles completes run IDs like completeRunIDs, falling back to file names
+// (such as shared manifests) when no run ID matches.
+func completeRunIDsOrFiles(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	completions, directive := completeRunIDs(cmd, args, toComplete)
+	if len(args) == 0 && directive == cobra.ShellCompDirectiveNoFileComp {
+		directive = cobra.ShellCompDirectiveDefault
+	}
+	return completions, directive
+}
+
+// completeProfiles completes the names of the profiles defined in the config files.
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if err := loadConfig(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return config.Profiles(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePlaybooks completes the names of the playbooks available in the working directory.
+func completePlaybooks(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return gonzo.Playbooks(dir), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLanguages completes the names of the languages available in the working directory,
+// and the auto and none settings.
+func completeLanguages(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return append([]string{gonzo.LanguageAuto, gonzo.LanguageNone}, gonzo.Languages(dir)...), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompletion_Flags(t *testing.T) {
+	_, output, err := executeCommandC(rootCmd, "__complete", "--model", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{gonzo.ClaudeHaiku, gonzo.ClaudeSonnet, gonzo.ClaudeOpus} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q to be completed, got %q", want, output)
+		}
+	}
+
+	_, output, err = executeCommandC(rootCmd, "__complete", "--forge", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "gitlab") {
+		t.Errorf("expected forge names to be completed, got %q", output)
+	}
+}
+
+func TestCompletion_RunIDs(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	start := time.Now()
+	for i, id := range []string{"20260101-000000-aaaaaa", "20260102-000000-bbbbbb"} {
+		run := &gonzo.RunState{ID: id, Feature: "feature " + id + "\nmore", Status: gonzo.RunStatusCompleted, StartedAt: start.Add(time.Duration(i) * time.Hour)}
+		if err := run.Save(dir); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+
+	_, output, err := executeCommandC(rootCmd, "__complete", "show", "2026")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	newest := strings.Index(output, "20260102-000000-bbbbbb\tcompleted: feature 20260102-000000-bbbbbb\n")
+	oldest := strings.Index(output, "20260101-000000-aaaaaa\t")
+	if newest < 0 || oldest < 0 || newest > oldest {
+		t.Errorf("expected both runs, most recent first, got %q", output)
+	}
+
+	_, output, err = executeCommandC(rootCmd, "__complete", "show", "20260101-000000-aaaaaa", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(output, "2026") {
+		t.Errorf("expected no completions after the run ID, got %q", output)
+	}
+}
+
+func TestCompletion_Script(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		_, output, err := executeCommandC(rootCmd, "completion", shell)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", shell, err)
+		}
+		if !strings.Contains(output, "__complete") {
+			t.Errorf("expected a %s completion script, got %q", shell, output[:min(len(output), 200)])
+		}
+	}
+
+	if _, _, err := executeCommandC(rootCmd, "completion", "tcsh"); err == nil {
+		t.Error("expected error for an unsupported shell")
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"github.com/andybarilla/gonzo/pkg/config"
+
+	"github.com/spf13/cobra"
+)
+
+// configCmd groups the commands about the config file
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with the gonzo config file",
+	Args:  cobra.NoArgs,
+}
+
+// configSchemaCmd prints the JSON Schema of the config file
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print a JSON Schema for the config file",
+	Long: `Schema prints a JSON Schema describing gonzo.yaml, including the profiles
+section, so editors can validate and complete it. With the YAML language server
+(used by the VS Code YAML extension), save it and reference it from the file:
+
+  gonzo config schema > .gonzo/gonzo.schema.json
+
+  # yaml-language-server: $schema=.gonzo/gonzo.schema.json
+  model: claude-sonnet-4-5`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schema, err := config.JSONSchema()
+		if err != nil {
+			return err
+		}
+		// Written to stdout, unlike messages, so that it can be redirected to a file
+		_, err = cmd.OutOrStdout().Write(schema)
+		return err
+	},
+}
+
+// configSetCmd writes a value to the config file
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>...",
+	Short: "Set a key in the config file",
+	Long: `Set writes a key to the most specific config file loaded, or to ./gonzo.yaml
+when there is none, in the format the file is in (YAML, TOML or JSON). List keys
+take every value given; keys of profiles are set as profiles.<name>.<key>:
+
+  gonzo config set max-iterations 15
+  gonzo config set pr-labels gonzo needs-review
+  gonzo config set profiles.cheap.model claude-haiku-4-5`,
+	Args: cobra.MinimumNArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeConfigKeys(cmd, args, toComplete)
+	},
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := config.WritePath()
+		if err := config.WriteValue(path, args[0], args[1:]); err != nil {
+			return err
+		}
+		cmd.Printf("Set %s in %s\n", args[0], path)
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSchemaCmd, configSetCmd)
+	rootCmd.AddCommand(configCmd)
+}
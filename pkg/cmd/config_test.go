@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestConfigSchema(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	_, output, err := executeCommandC(rootCmd, "config", "schema")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !json.Valid([]byte(output)) {
+		t.Fatalf("expected a JSON document, got %q", output)
+	}
+	if !strings.Contains(output, `"max-iterations"`) || !strings.Contains(output, "Gonzo <gonzo@barilla.you>") {
+		t.Errorf("unexpected schema %q", output)
+	}
+}
+
+func TestConfigSet(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer viper.Reset()
+
+	if err := os.WriteFile("gonzo.json", []byte(`{"model": "sonnet"}`), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	_, output, err := executeCommandC(rootCmd, "config", "set", "max-iterations", "15")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "Set max-iterations in") || !strings.Contains(output, "gonzo.json") {
+		t.Errorf("unexpected output %q", output)
+	}
+
+	data, err := os.ReadFile("gonzo.json")
+	if err != nil {
+		t.Fatalf("failed to read config file: %v", err)
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatalf("expected the file to stay JSON: %v", err)
+	}
+	if settings["max-iterations"] != float64(15) || settings["model"] != "sonnet" {
+		t.Errorf("unexpected settings %v", settings)
+	}
+}
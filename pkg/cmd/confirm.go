@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Values of the --confirm flag.
+const (
+	// confirmInteractive asks whether to merge the changes of the run once it is over.
+	confirmInteractive = "interactive"
+	// confirmCI only writes the changes of the run to a patch, for CI to publish as an artifact.
+	confirmCI = "ci"
+)
+
+var confirm string
+
+func init() {
+	rootCmd.Flags().StringVar(
+		&confirm,
+		"confirm", "",
+		"Run in a separate worktree and ask before merging its changes into the current branch; with ci, only write them to a patch")
+	rootCmd.Flags().Lookup("confirm").NoOptDefVal = confirmInteractive
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("confirm", completeValues(confirmInteractive, confirmCI)))
+}
+
+// runConfirmed runs the loop on feature in a worktree of the repository, writes its changes to
+// the patch artifact of the run and, interactively, shows them and merges them into the current
+// branch once approved. The branch is left alone until then, whatever the run does.
+func runConfirmed(cmd *cobra.Command, feature string) error {
+	if confirm != confirmInteractive && confirm != confirmCI {
+		return &gonzo.ExitError{Code: gonzo.ExitPreflight, Err: fmt.Errorf("invalid --confirm %q: expected %s or %s", confirm, confirmInteractive, confirmCI)}
+	}
+	if config.GetSandbox() == gonzo.SandboxDocker {
+		return &gonzo.ExitError{Code: gonzo.ExitPreflight, Err: errors.New("--confirm cannot run in the docker sandbox, which only mounts the repository")}
+	}
+	repo, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	// Cleaned up even when the run was interrupted
+	ctx := context.WithoutCancel(cmd.Context())
+	wt, err := gonzo.NewWorktree(ctx, repo)
+	if err != nil {
+		return &gonzo.ExitError{Code: gonzo.ExitPreflight, Err: err}
+	}
+	// Kept when its changes could not be written to the patch, so as not to lose them
+	keep := false
+	defer func() {
+		if keep {
+			cmd.Printf("The changes are kept in the worktree %s\n", wt.Dir)
+			return
+		}
+		gonzo.Swallow(wt.Remove(ctx))
+	}()
+
+	// The changes reach the branch through the merge, not a branch or pull request of the run
+	config.ApplySettings(map[string]interface{}{config.KeyNoBranch: true, config.KeyPR: false})
+	runner, err := buildRunnerIn(cmd, wt.Dir, nil)
+	if err != nil {
+		return &gonzo.ExitError{Code: gonzo.ExitPreflight, Err: err}
+	}
+	runErr := runFeature(cmd, runner, wt.Dir, feature, os.Stdout)
+	if err := wt.KeepArtifacts(); err != nil {
+		log.Print(err)
+	}
+
+	changed, err := wt.HasChanges(ctx)
+	if err != nil {
+		keep = true
+		return errors.Join(runErr, err)
+	}
+	if !changed {
+		cmd.Println("The run made no changes")
+		return runErr
+	}
+	// A run that failed leaves its changes uncommitted
+	if err := wt.CommitAll(ctx, "gonzo: uncommitted changes of the run"); err != nil {
+		keep = true
+		return errors.Join(runErr, err)
+	}
+	patchFile, err := writePatch(ctx, wt)
+	if err != nil {
+		keep = true
+		return errors.Join(runErr, err)
+	}
+	if runErr != nil || confirm == confirmCI {
+		cmd.Printf("Changes written to %s\n", patchFile)
+		return runErr
+	}
+
+	diff, err := wt.Diff(ctx)
+	if err != nil {
+		return err
+	}
+	cmd.Printf("%s\n\nMerge these changes into the current branch? [y/N] ", diff)
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return &gonzo.ExitError{Code: gonzo.ExitAborted, Err: fmt.Errorf("changes declined, kept in %s", patchFile)}
+	}
+	if err := wt.Merge(ctx); err != nil {
+		return fmt.Errorf("%w; the changes are kept in %s", err, patchFile)
+	}
+	cmd.Println("Merged the changes into the current branch")
+	return nil
+}
+
+// writePatch writes the changes made in the worktree to the patch artifact, in the repository, of
+// the run made in it, and returns its path relative to the repository.
+func writePatch(ctx context.Context, wt *gonzo.Worktree) (string, error) {
+	patch, err := wt.Patch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to write the changes: %w", err)
+	}
+	path := filepath.Join(gonzo.GonzoDir, gonzo.PatchArtifact)
+	if run, err := gonzo.LatestRunState(wt.Dir); err == nil {
+		path, _ = filepath.Rel(wt.Repo, filepath.Join(gonzo.RunDir(wt.Repo, run.ID), gonzo.PatchArtifact))
+	}
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(wt.Repo, path)), 0755); err != nil {
+		return "", fmt.Errorf("failed to write the changes: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(wt.Repo, path), []byte(patch), 0644); err != nil {
+		return "", fmt.Errorf("failed to write the changes: %w", err)
+	}
+	return path, nil
+}
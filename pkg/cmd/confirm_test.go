@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"github.com/andybarilla/gonzo/pkg/gonzo/gonzotest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// initConfirmRepo creates a git repository with one commit as the working directory of the test.
+func initConfirmRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("Skipping test - git is not available")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+		{"commit", "-q", "--allow-empty", "-m", "initial commit"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+	return dir
+}
+
+// committingRunner returns a runner factory whose runs commit feature.go in the repository they
+// work on, and records the directory and configuration of the run.
+func committingRunner(t *testing.T, runDir *string, cfg **config.Config) func(string, *config.Config, map[string]interface{}, *forge.Issue, ...gonzo.Option) gonzo.Runner {
+	return func(dir string, c *config.Config, settings map[string]interface{}, issue *forge.Issue, opts ...gonzo.Option) gonzo.Runner {
+		*runDir, *cfg = dir, c
+		return &gonzotest.FakeRunner{GenerateFunc: func(ctx context.Context, feature string) (string, error) {
+			if err := os.WriteFile(filepath.Join(dir, "feature.go"), []byte("package feature\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for _, args := range [][]string{{"add", "feature.go"}, {"commit", "-q", "-m", "add feature"}} {
+				if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+					t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+				}
+			}
+			return "Done", nil
+		}}
+	}
+}
+
+func TestRunClaudePrompt_Confirm(t *testing.T) {
+	// Save originals and restore after test
+	originalNewRunner := newRunner
+	originalExit := exit
+	defer func() {
+		newRunner = originalNewRunner
+		exit = originalExit
+		confirm = ""
+		rootCmd.Flags().Lookup("confirm").Changed = false
+		flag := rootCmd.PersistentFlags().Lookup("pr")
+		_ = flag.Value.Set(flag.DefValue)
+		flag.Changed = false
+		rootCmd.SetIn(nil)
+		viper.Reset()
+	}()
+
+	tests := []struct {
+		name       string
+		args       []string
+		answer     string
+		wantCode   int
+		wantMerged bool
+		want       string
+	}{
+		{"approved", []string{"--confirm"}, "y\n", 0, true, "Merged the changes into the current branch"},
+		{"declined", []string{"--confirm"}, "n\n", gonzo.ExitAborted, false, "+package feature"},
+		{"ci", []string{"--confirm=ci"}, "", 0, false, "Changes written to " + filepath.Join(gonzo.GonzoDir, gonzo.PatchArtifact)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := initConfirmRepo(t)
+			var runDir string
+			var cfg *config.Config
+			newRunner = committingRunner(t, &runDir, &cfg)
+			code := 0
+			exit = func(c int) { code = c }
+			rootCmd.SetIn(strings.NewReader(tt.answer))
+
+			_, output, err := executeCommandC(rootCmd, append(tt.args, "--pr", "add a feature")...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if runDir == "" || runDir == dir {
+				t.Errorf("expected the run to happen in a worktree, got %q", runDir)
+			}
+			if !cfg.NoBranch || cfg.PR {
+				t.Errorf("expected the run to make no branch or pull request, got no-branch %v and pr %v", cfg.NoBranch, cfg.PR)
+			}
+			if code != tt.wantCode {
+				t.Errorf("expected exit code %d, got %d", tt.wantCode, code)
+			}
+			if !strings.Contains(output, tt.want) {
+				t.Errorf("expected output to contain %q, got %q", tt.want, output)
+			}
+			_, err = os.Stat(filepath.Join(dir, "feature.go"))
+			if merged := err == nil; merged != tt.wantMerged {
+				t.Errorf("expected merged %v, got %v", tt.wantMerged, merged)
+			}
+			if _, err := os.Stat(runDir); !os.IsNotExist(err) {
+				t.Errorf("expected the worktree to be removed, got %v", err)
+			}
+			if !tt.wantMerged {
+				patch, err := os.ReadFile(filepath.Join(dir, gonzo.GonzoDir, gonzo.PatchArtifact))
+				if err != nil || !strings.Contains(string(patch), "Subject: [PATCH] add feature") {
+					t.Errorf("expected the changes to be kept in a patch, got %q (%v)", patch, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRunClaudePrompt_ConfirmFailedRun(t *testing.T) {
+	// Save originals and restore after test
+	originalNewRunner := newRunner
+	originalExit := exit
+	defer func() {
+		newRunner = originalNewRunner
+		exit = originalExit
+		confirm = ""
+		rootCmd.Flags().Lookup("confirm").Changed = false
+		viper.Reset()
+	}()
+
+	dir := initConfirmRepo(t)
+	newRunner = func(runDir string, c *config.Config, settings map[string]interface{}, issue *forge.Issue, opts ...gonzo.Option) gonzo.Runner {
+		return &gonzotest.FakeRunner{GenerateFunc: func(ctx context.Context, feature string) (string, error) {
+			if err := os.WriteFile(filepath.Join(runDir, "feature.go"), []byte("package feature\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			return "", errors.New("claude failed")
+		}}
+	}
+	code := 0
+	exit = func(c int) { code = c }
+
+	_, output, err := executeCommandC(rootCmd, "--confirm", "add a feature")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if code == 0 {
+		t.Error("expected the failed run to exit with an error")
+	}
+	if !strings.Contains(output, "Changes written to") {
+		t.Errorf("expected the uncommitted changes to be written to a patch, got %q", output)
+	}
+	patch, err := os.ReadFile(filepath.Join(dir, gonzo.GonzoDir, gonzo.PatchArtifact))
+	if err != nil || !strings.Contains(string(patch), "+package feature") {
+		t.Errorf("expected the uncommitted changes in the patch, got %q (%v)", patch, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "feature.go")); !os.IsNotExist(err) {
+		t.Errorf("expected the changes of a failed run not to be merged, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// docsCmd groups the commands generating the CLI reference
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generate the CLI reference as man pages or markdown",
+	Long: `Docs writes the reference of every command and flag, one file per command,
+for shipping with distribution packages or publishing next to the README.`,
+	Args: cobra.NoArgs,
+}
+
+// docsManCmd writes a man page per command
+var docsManCmd = &cobra.Command{
+	Use:   "man [dir]",
+	Short: "Generate man pages for all commands",
+	Long: `Man writes a section 1 man page for every command to dir, ./man by default,
+e.g. gonzo.1 and gonzo-show.1.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := docsDir(args, "man")
+		if err != nil {
+			return err
+		}
+		header := &doc.GenManHeader{Title: "GONZO", Section: "1", Source: "gonzo " + cmd.Root().Version}
+		if err := doc.GenManTree(cmd.Root(), header, dir); err != nil {
+			return fmt.Errorf("failed to generate man pages: %w", err)
+		}
+		cmd.Printf("Wrote man pages to %s\n", dir)
+		return nil
+	},
+}
+
+// docsMarkdownCmd writes a markdown page per command
+var docsMarkdownCmd = &cobra.Command{
+	Use:   "markdown [dir]",
+	Short: "Generate the markdown CLI reference for all commands",
+	Long: `Markdown writes a markdown page for every command to dir, ./docs/cli by
+default, e.g. gonzo.md and gonzo_show.md, linked to each other.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := docsDir(args, "docs/cli")
+		if err != nil {
+			return err
+		}
+		if err := doc.GenMarkdownTree(cmd.Root(), dir); err != nil {
+			return fmt.Errorf("failed to generate markdown reference: %w", err)
+		}
+		cmd.Printf("Wrote markdown reference to %s\n", dir)
+		return nil
+	},
+}
+
+func init() {
+	// Leave out the "Auto generated by spf13/cobra" footer and its date
+	rootCmd.DisableAutoGenTag = true
+
+	docsCmd.AddCommand(docsManCmd)
+	docsCmd.AddCommand(docsMarkdownCmd)
+	rootCmd.AddCommand(docsCmd)
+}
+
+// docsDir returns the directory to write the reference to, created when missing.
+func docsDir(args []string, fallback string) (string, error) {
+	dir := fallback
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return dir, nil
+}
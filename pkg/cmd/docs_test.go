@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDocs(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, _, err := executeCommandC(rootCmd, "docs", "man", filepath.Join(dir, "man")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	page, err := os.ReadFile(filepath.Join(dir, "man", "gonzo-show.1"))
+	if err != nil {
+		t.Fatalf("expected a man page for show: %v", err)
+	}
+	if !strings.Contains(string(page), `.TH "GONZO" "1"`) {
+		t.Errorf("unexpected man page header in %q", page)
+	}
+
+	if _, _, err := executeCommandC(rootCmd, "docs", "markdown", filepath.Join(dir, "md")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	page, err = os.ReadFile(filepath.Join(dir, "md", "gonzo.md"))
+	if err != nil {
+		t.Fatalf("expected a markdown page for gonzo: %v", err)
+	}
+	if !strings.Contains(string(page), "[gonzo show](gonzo_show.md)") {
+		t.Errorf("expected a link to the show page in %q", page)
+	}
+	if strings.Contains(string(page), "Auto generated") {
+		t.Errorf("expected no generation footer in %q", page)
+	}
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Check outcomes reported by doctor.
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// checkResult is the outcome of a single doctor check.
+type checkResult struct {
+	name   string
+	status string
+	detail string
+	// fix suggests how to resolve a warning or failure.
+	fix string
+}
+
+// Environment probes used by doctor. Replaceable for testing.
+var (
+	lookPath        = exec.LookPath
+	claudeVersion   = gonzo.ClaudeVersion
+	claudeLoggedIn  = hasClaudeCredentials
+	forgeAuthStatus = func(ctx context.Context, dir string, name string) (string, error) {
+		f := gonzo.OpenForge(ctx, dir, name)
+		return f.Name(), f.AuthStatus(ctx)
+	}
+)
+
+// configErr is the error from loading the configuration, which doctor reports instead of failing on.
+var configErr error
+
+// doctorCmd diagnoses problems with the environment gonzo runs in
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that gonzo's environment is set up correctly",
+	Long: `Doctor checks everything a run depends on: the configuration file, the Claude
+CLI and its login, the git repository, the forge credentials used to open pull
+requests and the prompt templates. Each problem comes with a suggested fix.
+
+Doctor exits with a non-zero status when a check that would make runs fail
+does not pass; warnings do not affect the exit status.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	// Load the configuration without failing, so a broken config file can be reported
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		configErr = initConfig(cmd, args)
+		return nil
+	},
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	ctx := cmd.Context()
+
+	// Check the rest against the defaults when the configuration cannot be decoded
+	cfg, err := config.Load()
+	if err != nil {
+		if configErr == nil {
+			configErr = err
+		}
+		cfg = config.Default()
+	}
+
+	var results []checkResult
+	results = append(results, checkConfig())
+	results = append(results, checkClaude(ctx)...)
+	results = append(results, checkGit(ctx, dir)...)
+	results = append(results, checkForge(ctx, dir, cfg))
+	results = append(results, checkTemplates(dir, cfg))
+
+	failed := 0
+	for _, r := range results {
+		cmd.Printf("[%-4s] %s: %s\n", r.status, r.name, r.detail)
+		if r.status != checkOK && r.fix != "" {
+			cmd.Printf("       Fix: %s\n", r.fix)
+		}
+		if r.status == checkFail {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+func checkConfig() checkResult {
+	r := checkResult{name: "Configuration", status: checkOK}
+	switch {
+	case configErr != nil:
+		r.status, r.detail = checkFail, configErr.Error()
+		r.fix = "correct the configuration file or the --set overrides"
+	case len(config.ConfigFiles()) > 0:
+		var paths []string
+		for _, f := range config.ConfigFiles() {
+			paths = append(paths, f.Path)
+		}
+		r.detail = "loaded " + strings.Join(paths, ", ")
+	default:
+		r.detail = "no configuration file found, using defaults"
+	}
+	return r
+}
+
+func checkClaude(ctx context.Context) []checkResult {
+	cli := checkResult{name: "Claude CLI", status: checkOK}
+	if _, err := lookPath(gonzo.ClaudeCodeCli); err != nil {
+		cli.status, cli.detail = checkFail, gonzo.ClaudeCodeCli+" was not found in PATH"
+		cli.fix = "install Claude Code: npm install -g @anthropic-ai/claude-code"
+		return []checkResult{cli}
+	}
+
+	version, err := claudeVersion(ctx)
+	if err == nil {
+		err = gonzo.CheckClaudeVersion(version)
+	}
+	if err != nil {
+		cli.status, cli.detail = checkFail, err.Error()
+		cli.fix = fmt.Sprintf("update Claude Code to %s or later with `claude update`", gonzo.MinClaudeVersion)
+	} else {
+		cli.detail = version
+	}
+
+	login := checkResult{name: "Claude login", status: checkOK, detail: "credentials found"}
+	if !claudeLoggedIn() {
+		login.status, login.detail = checkWarn, "no Claude credentials found"
+		login.fix = "run `claude` once and log in, or set ANTHROPIC_API_KEY"
+	}
+	return []checkResult{cli, login}
+}
+
+func checkGit(ctx context.Context, dir string) []checkResult {
+	repo := checkResult{name: "Git repository", status: checkOK}
+	if _, err := lookPath("git"); err != nil {
+		repo.status, repo.detail, repo.fix = checkFail, "git was not found in PATH", "install git"
+		return []checkResult{repo}
+	}
+
+	changes, err := gonzo.UncommittedChanges(ctx, dir)
+	if err != nil {
+		repo.status, repo.detail = checkFail, dir+" is not inside a git repository"
+		repo.fix = "run gonzo from a git repository, or create one with `git init`"
+		return []checkResult{repo}
+	}
+	repo.detail = "found"
+
+	tree := checkResult{name: "Working tree", status: checkOK, detail: "clean"}
+	if len(changes) > 0 {
+		tree.status, tree.detail = checkWarn, fmt.Sprintf("%d uncommitted change(s)", len(changes))
+		tree.fix = "commit or stash them so they are not mixed into gonzo's commits"
+	}
+	return []checkResult{repo, tree}
+}
+
+func checkForge(ctx context.Context, dir string, cfg *config.Config) checkResult {
+	r := checkResult{name: "Forge credentials", status: checkOK}
+	name, err := forgeAuthStatus(ctx, dir, cfg.Forge)
+	if err == nil {
+		r.detail = name + " authenticated"
+		return r
+	}
+
+	// Credentials are only needed when pull requests are opened
+	r.status = checkWarn
+	if cfg.PR {
+		r.status = checkFail
+	}
+	r.detail = fmt.Sprintf("not authenticated with %s: %v", name, err)
+	r.fix = fmt.Sprintf("run `gonzo auth login` or set %s, or disable pull requests with pr: false",
+		strings.Join(forge.TokenEnv[name], " or "))
+	return r
+}
+
+func checkTemplates(dir string, cfg *config.Config) checkResult {
+	r := checkResult{name: "Templates", status: checkOK, detail: "valid"}
+	if err := gonzo.ValidateTemplates(dir, readCommitTemplate(cfg.CommitTemplate)); err != nil {
+		r.status, r.detail = checkFail, strings.ReplaceAll(err.Error(), "\n", "; ")
+		r.fix = "correct the template syntax (see https://pkg.go.dev/text/template)"
+	}
+	return r
+}
+
+// hasClaudeCredentials reports whether Claude Code can authenticate: with an API key or OAuth
+// token in the environment, or with a login stored by `claude` in the home directory.
+func hasClaudeCredentials() bool {
+	for _, env := range []string{"ANTHROPIC_API_KEY", "CLAUDE_CODE_OAUTH_TOKEN"} {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(home, ".claude", ".credentials.json")); err == nil {
+		return true
+	}
+	// On macOS the credentials live in the Keychain; the account is recorded in ~/.claude.json
+	data, err := os.ReadFile(filepath.Join(home, ".claude.json"))
+	return err == nil && strings.Contains(string(data), `"oauthAccount"`)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// withDoctorProbes replaces the environment probes used by doctor for the duration of the test.
+func withDoctorProbes(t *testing.T, version string, loggedIn bool, authErr error) {
+	t.Helper()
+	originalLookPath, originalVersion, originalLoggedIn, originalAuth := lookPath, claudeVersion, claudeLoggedIn, forgeAuthStatus
+	t.Cleanup(func() {
+		lookPath, claudeVersion, claudeLoggedIn, forgeAuthStatus = originalLookPath, originalVersion, originalLoggedIn, originalAuth
+		viper.Reset()
+	})
+
+	lookPath = func(file string) (string, error) {
+		if file == "git" {
+			return exec.LookPath(file)
+		}
+		return "/usr/bin/" + file, nil
+	}
+	claudeVersion = func(ctx context.Context) (string, error) { return version, nil }
+	claudeLoggedIn = func() bool { return loggedIn }
+	forgeAuthStatus = func(ctx context.Context, dir string, name string) (string, error) { return "github", authErr }
+}
+
+func TestDoctor(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("Skipping test - git is not available")
+	}
+	withDoctorProbes(t, "2.0.14 (Claude Code)", false, nil)
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	gitIn(t, dir, "init", "-q", "-b", "main")
+
+	_, output, err := executeCommandC(rootCmd, "doctor")
+	if err != nil {
+		t.Fatalf("expected warnings not to fail doctor, got %v\n%s", err, output)
+	}
+	for _, want := range []string{
+		"[ok  ] Claude CLI: 2.0.14 (Claude Code)",
+		"[warn] Claude login: no Claude credentials found",
+		"Fix: run `claude` once and log in",
+		"[ok  ] Git repository: found",
+		"[ok  ] Forge credentials: github authenticated",
+		"[ok  ] Templates: valid",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got %q", want, output)
+		}
+	}
+}
+
+func TestDoctor_Failures(t *testing.T) {
+	withDoctorProbes(t, "0.2.0 (Claude Code)", true, errors.New("bad credentials"))
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("GONZO_PR", "true")
+
+	_, output, err := executeCommandC(rootCmd, "doctor")
+	if err == nil || !strings.Contains(err.Error(), "3 check(s) failed") {
+		t.Errorf("expected three failed checks, got %v\n%s", err, output)
+	}
+	for _, want := range []string{
+		"[fail] Claude CLI: Claude CLI 0.2.0 (Claude Code) is older",
+		"[fail] Git repository:",
+		"[fail] Forge credentials: not authenticated with github: bad credentials",
+		"gonzo auth login",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got %q", want, output)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+
+	"github.com/spf13/cobra"
+)
+
+// Values of the --dry-run flag.
+const (
+	// dryRunPrompts shows the prompts and Claude CLI call of the run, without invoking Claude.
+	dryRunPrompts = "prompts"
+	// dryRunPlan also runs a read-only planning iteration proposing the change.
+	dryRunPlan = "plan"
+)
+
+var dryRun string
+
+func init() {
+	rootCmd.Flags().StringVar(
+		&dryRun,
+		"dry-run", "",
+		"Show the prompts and the Claude CLI call of the run without changing the repository; with plan, also propose the change in a read-only iteration")
+	rootCmd.Flags().Lookup("dry-run").NoOptDefVal = dryRunPrompts
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("dry-run", completeValues(dryRunPrompts, dryRunPlan)))
+}
+
+// runDryRun prints what the run of feature would send to Claude Code, as JSON with the JSON
+// output, without changing the repository.
+func runDryRun(cmd *cobra.Command, feature string) error {
+	if dryRun != dryRunPrompts && dryRun != dryRunPlan {
+		return &gonzo.ExitError{Code: gonzo.ExitPreflight, Err: fmt.Errorf("invalid --dry-run %q: expected %s or %s", dryRun, dryRunPrompts, dryRunPlan)}
+	}
+	runner, err := buildRunner(cmd, nil)
+	if err != nil {
+		return err
+	}
+	dryRunner, ok := gonzo.RunnerAs[gonzo.DryRunner](runner)
+	if !ok {
+		return errors.New("the configured runner does not support dry runs")
+	}
+
+	d, err := dryRunner.DryRun(cmd.Context(), feature, dryRun == dryRunPlan)
+	if err != nil {
+		return err
+	}
+
+	if config.GetOutput() == gonzo.OutputJSON {
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode the dry run: %w", err)
+		}
+		cmd.Println(string(data))
+		return nil
+	}
+	cmd.Printf("=== System prompt ===\n%s\n\n", d.SystemPrompt)
+	cmd.Printf("=== Prompt ===\n%s\n\n", d.Prompt)
+	if d.WrapUpPrompt != "" {
+		cmd.Printf("=== Wrap-up prompt ===\n%s\n\n", d.WrapUpPrompt)
+	}
+	cmd.Printf("=== Claude CLI call ===\n%s\n", d.Command())
+	if d.Plan != "" {
+		cmd.Printf("\n=== Proposed change ===\n%s", d.Plan)
+	}
+	return nil
+}
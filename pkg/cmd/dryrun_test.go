@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestDryRun(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	defer func() {
+		newRunner = originalNewRunner
+		dryRun = ""
+		rootCmd.Flags().Lookup("dry-run").Changed = false
+		viper.Reset()
+	}()
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantPlan bool
+		want     []string
+	}{
+		{"prompts", []string{"--dry-run", "add a login button"}, false, []string{"=== System prompt ===\nsystem", "claude --print \"add a login button\""}},
+		{"plan", []string{"--dry-run=plan", "add a login button"}, true, []string{"=== Proposed change ===\n## Summary"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockRunner{response: "## Summary\nAdd the button.\n"}
+			newRunner = mockRunnerFactory(mock)
+
+			_, output, err := executeCommandC(rootCmd, tt.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mock.generateCalled || !mock.dryRunCalled {
+				t.Fatal("expected a dry run instead of a run")
+			}
+			if mock.dryRunPlan != tt.wantPlan {
+				t.Errorf("expected plan %v, got %v", tt.wantPlan, mock.dryRunPlan)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected output to contain %q, got %q", want, output)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// estimateCmd projects the cost and duration of a run without running it
+var estimateCmd = &cobra.Command{
+	Use:   "estimate [flags] feature",
+	Short: "Estimate the cost and duration of a run without running it",
+	Long: `Estimate renders the prompts a run would send to Claude Code, approximates
+their size in tokens and projects a cost and duration range for the configured
+model and max-iterations, from finishing in one light iteration to using every
+iteration heavily. Claude Code is not invoked.
+
+Agent sessions vary widely with the task and repository, so treat the range as
+an order of magnitude rather than a quote.`,
+	Args:         cobra.ArbitraryArgs,
+	SilenceUsage: true,
+	RunE:         runEstimate,
+}
+
+func init() {
+	rootCmd.AddCommand(estimateCmd)
+}
+
+func runEstimate(cmd *cobra.Command, args []string) error {
+	feature, fm, err := readFeature(args)
+	if err != nil {
+		return err
+	}
+	if feature == "" {
+		return errors.New("no feature given to estimate")
+	}
+	config.ApplySettings(frontMatterSettings(fm))
+
+	runner, err := buildRunner(cmd, nil)
+	if err != nil {
+		return err
+	}
+	estimator, ok := gonzo.RunnerAs[gonzo.Estimator](runner)
+	if !ok {
+		return errors.New("the configured runner does not support estimates")
+	}
+
+	e, err := estimator.Estimate(cmd.Context(), feature)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Model:          %s\n", e.Model)
+	if e.WrapUpIterations > 0 {
+		cmd.Printf("Iterations:     %d to %d (%d wrap-up)\n", e.MinIterations, e.MaxIterations, e.WrapUpIterations)
+	} else {
+		cmd.Printf("Iterations:     %d to %d\n", e.MinIterations, e.MaxIterations)
+	}
+	cmd.Printf("Prompt tokens:  ~%d system + ~%d feature per iteration (budget %d)\n", e.SystemPromptTokens, e.FeatureTokens, e.PromptBudget)
+	if len(e.Truncated) > 0 {
+		cmd.Printf("Truncated:      %s, to fit the prompt budget\n", strings.Join(e.Truncated, ", "))
+	}
+	if tokens := e.SystemPromptTokens + e.FeatureTokens; tokens > e.PromptBudget {
+		cmd.Printf("Warning:        the prompt is over its budget of %d tokens\n", e.PromptBudget)
+	}
+	if e.Pricing != nil {
+		cmd.Printf("Estimated cost: $%.2f to $%.2f ($%.2f / $%.2f per million input / output tokens)\n",
+			e.MinCost, e.MaxCost, e.Pricing.Input, e.Pricing.Output)
+	} else {
+		cmd.Printf("Estimated cost: unknown, no pricing for %s\n", e.Model)
+	}
+	cmd.Printf("Estimated time: %s to %s\n", e.MinDuration.Round(time.Minute), e.MaxDuration.Round(time.Minute))
+	return nil
+}
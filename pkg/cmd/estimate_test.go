@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestEstimate(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	defer func() {
+		newRunner = originalNewRunner
+		viper.Reset()
+	}()
+
+	t.Setenv("GONZO_MAX_ITERATIONS", "7")
+	mock := &mockRunner{}
+	newRunner = mockRunnerFactory(mock)
+
+	_, output, err := executeCommandC(rootCmd, "estimate", "add a login button")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mock.generateCalled {
+		t.Error("expected no run")
+	}
+	if mock.capturedPrompt != "add a login button" {
+		t.Errorf("expected the feature to be estimated, got %q", mock.capturedPrompt)
+	}
+	for _, want := range []string{"Iterations:     1 to 7", "Estimated cost: unknown"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got %q", want, output)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// explainCmd shows the effective configuration
+var explainCmd = &cobra.Command{
+	Use:   "explain [key...]",
+	Short: "Show the effective configuration and where each value comes from",
+	Long: `Explain prints the effective value of every config key, or of the given keys,
+along with where it came from: a --set override, a flag, an environment
+variable, the user or project config file or the default. The config files
+are listed first, in the order they are merged.
+
+Flags, environment variables and --set overrides given to explain are taken
+into account, so it shows what a run with the same options would use.`,
+	ValidArgsFunction: completeConfigKeys,
+	SilenceUsage:      true,
+	RunE:              runExplain,
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	settings := config.Explain(cmd)
+	keys := make([]string, len(args))
+	for i, key := range args {
+		keys[i] = strings.ToLower(key)
+		if !slices.ContainsFunc(settings, func(s config.Setting) bool { return s.Key == keys[i] }) {
+			return fmt.Errorf("unknown config key %q", key)
+		}
+	}
+
+	if files := config.ConfigFiles(); len(files) > 0 {
+		cmd.Println("Config files:")
+		for _, f := range files {
+			cmd.Printf("  %-8s %s\n", f.Name, f.Path)
+		}
+		cmd.Println()
+	} else {
+		cmd.Print("Config files: none\n\n")
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, s := range settings {
+		if len(keys) > 0 && !slices.Contains(keys, s.Key) {
+			continue
+		}
+		value := s.Value
+		if s.Key == config.KeyModel {
+			// The model flag is resolved the way runs resolve it
+			value = resolveModel(cmd)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, formatSetting(value), s.Source)
+	}
+	return w.Flush()
+}
+
+// formatSetting formats a config value for display.
+func formatSetting(value interface{}) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, ",")
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, p := range v {
+			parts[i] = fmt.Sprint(p)
+		}
+		return strings.Join(parts, ",")
+	case string:
+		if v == "" {
+			return `""`
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// completeConfigKeys completes the names of the known config keys.
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if err := loadConfig(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var keys []cobra.Completion
+	for _, s := range config.Explain(cmd) {
+		keys = append(keys, s.Key)
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestExplain(t *testing.T) {
+	defer viper.Reset()
+	t.Chdir(t.TempDir())
+	t.Setenv("GONZO_MODEL", "claude-haiku-4-5")
+
+	_, output, err := executeCommandC(rootCmd, "explain", "model", "max-iterations")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"Config files: none", "claude-haiku-4-5", "env GONZO_MODEL", "max-iterations"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in the output, got %q", want, output)
+		}
+	}
+	if strings.Contains(output, "commit-author") {
+		t.Errorf("expected only the given keys, got %q", output)
+	}
+
+	if _, _, err := executeCommandC(rootCmd, "explain", "nope"); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
+
+func TestExplain_Profile(t *testing.T) {
+	defer viper.Reset()
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("gonzo.yaml", []byte("profiles:\n  cheap:\n    max-iterations: 5\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Setenv("GONZO_PROFILE", "cheap")
+
+	_, output, err := executeCommandC(rootCmd, "explain", "max-iterations")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "5") || !strings.Contains(output, "profile cheap") {
+		t.Errorf("expected max-iterations from the profile, got %q", output)
+	}
+
+	t.Setenv("GONZO_PROFILE", "fancy")
+	if _, _, err := executeCommandC(rootCmd, "explain"); err == nil || !strings.Contains(err.Error(), "available: cheap") {
+		t.Errorf("expected an unknown profile error, got %v", err)
+	}
+}
+
+func TestExplain_ConfigFile(t *testing.T) {
+	defer viper.Reset()
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("ci.yaml", []byte("max-iterations: 3\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Setenv("GONZO_CONFIG", "ci.yaml")
+
+	_, output, err := executeCommandC(rootCmd, "explain", "max-iterations")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "explicit config ") || !strings.Contains(output, "ci.yaml") {
+		t.Errorf("expected max-iterations from ci.yaml, got %q", output)
+	}
+
+	t.Setenv("GONZO_CONFIG", "missing.yaml")
+	if _, _, err := executeCommandC(rootCmd, "explain"); err == nil {
+		t.Error("expected an error for a missing config file")
+	}
+}
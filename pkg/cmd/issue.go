@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var issueComments bool
+
+// fetchIssue loads an issue from the configured forge. Replaceable for testing.
+var fetchIssue = func(ctx context.Context, dir string, ref string) (*forge.Issue, error) {
+	return gonzo.OpenForge(ctx, dir, config.GetForge()).GetIssue(ctx, ref)
+}
+
+// issueCmd runs gonzo on an issue
+var issueCmd = &cobra.Command{
+	Use:   "issue <number|url>",
+	Short: "Implement an issue from GitHub or GitLab",
+	Long: `Issue fetches an issue from the forge hosting the repository, using the gh or
+glab CLI, and uses its title and body as the feature. Use --comments to include
+the discussion on the issue as well.
+
+The pull request opened for the run links back to the issue, and a comment
+with the outcome of the run is posted on the issue when it finishes.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runIssue,
+}
+
+func init() {
+	issueCmd.Flags().BoolVar(
+		&issueComments,
+		"comments", false,
+		"Include the issue's comments in the feature")
+
+	rootCmd.AddCommand(issueCmd)
+}
+
+func runIssue(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	issue, err := fetchIssue(cmd.Context(), dir, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to fetch issue %s: %w", args[0], err)
+	}
+
+	runner, err := buildRunner(cmd, issue)
+	if err != nil {
+		return err
+	}
+	return runFeature(cmd, runner, dir, issueFeature(issue, issueComments), cmd.OutOrStderr())
+}
+
+// issueFeature builds the feature description from an issue.
+func issueFeature(issue *forge.Issue, withComments bool) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(issue.Title))
+	if body := strings.TrimSpace(issue.Body); body != "" {
+		b.WriteString("\n\n" + body)
+	}
+
+	if withComments && len(issue.Comments) > 0 {
+		b.WriteString("\n\n## Comments\n")
+		for _, c := range issue.Comments {
+			fmt.Fprintf(&b, "\n@%s wrote:\n%s\n", c.Author.Login, strings.TrimSpace(c.Body))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n\n(Issue #%d: %s)", issue.Number, issue.URL)
+	return strings.TrimSpace(b.String())
+}
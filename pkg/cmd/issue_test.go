@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func testIssue() *forge.Issue {
+	issue := &forge.Issue{
+		Number: 12,
+		Title:  "Add a login button",
+		Body:   "Users need to log in from the header.",
+		URL:    "https://github.com/o/r/issues/12",
+	}
+	comment := forge.IssueComment{Body: "Use the existing auth service."}
+	comment.Author.Login = "octocat"
+	issue.Comments = []forge.IssueComment{comment}
+	return issue
+}
+
+func TestIssueFeature(t *testing.T) {
+	feature := issueFeature(testIssue(), false)
+	if !strings.HasPrefix(feature, "Add a login button\n\nUsers need to log in from the header.") {
+		t.Errorf("expected title and body first, got %q", feature)
+	}
+	if !strings.Contains(feature, "https://github.com/o/r/issues/12") {
+		t.Errorf("expected the issue URL, got %q", feature)
+	}
+	if strings.Contains(feature, "octocat") {
+		t.Errorf("expected comments to be left out, got %q", feature)
+	}
+
+	feature = issueFeature(testIssue(), true)
+	if !strings.Contains(feature, "@octocat wrote:\nUse the existing auth service.") {
+		t.Errorf("expected comments to be included, got %q", feature)
+	}
+}
+
+func TestIssue(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	originalFetchIssue := fetchIssue
+	defer func() {
+		newRunner = originalNewRunner
+		fetchIssue = originalFetchIssue
+		viper.Reset()
+	}()
+
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	var fetched string
+	fetchIssue = func(ctx context.Context, dir string, ref string) (*forge.Issue, error) {
+		fetched = ref
+		return testIssue(), nil
+	}
+
+	_, output, err := executeCommandC(rootCmd, "issue", "12")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fetched != "12" {
+		t.Errorf("expected issue 12 to be fetched, got %q", fetched)
+	}
+	if !strings.HasPrefix(mock.capturedPrompt, "Add a login button") {
+		t.Errorf("expected the issue as feature, got %q", mock.capturedPrompt)
+	}
+	if mock.issue == nil || mock.issue.Number != 12 {
+		t.Errorf("expected the runner to be linked to issue 12, got %+v", mock.issue)
+	}
+	if !strings.Contains(output, "mocked response") {
+		t.Errorf("expected the response in the output, got %q", output)
+	}
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var logsFollow bool
+
+// logsPollInterval is how often a followed log is checked for new entries. Replaceable for testing.
+var logsPollInterval = 500 * time.Millisecond
+
+// logsCmd prints the log of a run
+var logsCmd = &cobra.Command{
+	Use:   "logs [run-id]",
+	Short: "Print or follow the log of a run",
+	Long: `Logs prints the log of a run, as recorded in .gonzo/runs/<run-id>/log.jsonl.
+Without a run ID the most recent run is shown.
+
+With -f the log is followed until the run finishes, so a long run can be
+checked on from another terminal or over SSH.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeRunIDs,
+	SilenceUsage:      true,
+	RunE:              runLogs,
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(
+		&logsFollow,
+		"follow", "f", false,
+		"Keep printing new entries until the run finishes")
+
+	rootCmd.AddCommand(logsCmd)
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	var state *gonzo.RunState
+	if len(args) == 1 {
+		state, err = gonzo.ResolveRunState(dir, args[0])
+	} else {
+		state, err = gonzo.LatestRunState(dir)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var offset int64
+	for {
+		// Check the status first so no entry written before the run finished is missed
+		running := state.Status == gonzo.RunStatusRunning
+		if logsFollow && running {
+			if state, err = gonzo.LoadRunState(dir, state.ID); err != nil {
+				return err
+			}
+			running = state.Status == gonzo.RunStatusRunning
+		}
+
+		var entries []gonzo.LogEntry
+		entries, offset, err = gonzo.ReadRunLog(dir, state.ID, offset)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			cmd.Println(e.String())
+		}
+
+		if !logsFollow || !running {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(logsPollInterval):
+		}
+	}
+}
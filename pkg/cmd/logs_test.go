@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeRunLog saves a run with the given status and appends lines to its log.
+func writeRunLog(t *testing.T, dir string, state *gonzo.RunState, lines ...string) {
+	t.Helper()
+	if err := state.Save(dir); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	f, err := os.OpenFile(filepath.Join(gonzo.RunDir(dir, state.ID), gonzo.LogArtifact), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	defer f.Close()
+	for _, line := range lines {
+		if _, err := f.WriteString(line + "\n"); err != nil {
+			t.Fatalf("failed to write log: %v", err)
+		}
+	}
+}
+
+func TestLogs(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	state := &gonzo.RunState{ID: "20260101-000000-aaaaaa", Status: gonzo.RunStatusCompleted, StartedAt: time.Now()}
+	writeRunLog(t, dir, state, `{"time":"2026-01-01T10:11:12Z","level":"info","msg":"Starting Gonzo"}`)
+
+	_, output, err := executeCommandC(rootCmd, "logs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "Starting Gonzo") {
+		t.Errorf("expected the log, got %q", output)
+	}
+}
+
+func TestLogs_Follow(t *testing.T) {
+	// Save original and restore after test
+	originalInterval := logsPollInterval
+	defer func() {
+		logsPollInterval = originalInterval
+		logsFollow = false
+	}()
+	logsPollInterval = 10 * time.Millisecond
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	state := &gonzo.RunState{ID: "20260101-000000-aaaaaa", Status: gonzo.RunStatusRunning, StartedAt: time.Now()}
+	writeRunLog(t, dir, state, `{"time":"2026-01-01T10:11:12Z","level":"info","msg":"Iteration 1 of 3"}`)
+
+	// The run carries on in "another process" and finishes
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		f, err := os.OpenFile(filepath.Join(gonzo.RunDir(dir, state.ID), gonzo.LogArtifact), os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			t.Errorf("failed to open log: %v", err)
+			return
+		}
+		_, _ = f.WriteString(`{"time":"2026-01-01T10:21:12Z","level":"info","msg":"Task completed!"}` + "\n")
+		_ = f.Close()
+		state.Status = gonzo.RunStatusCompleted
+		if err := state.Save(dir); err != nil {
+			t.Errorf("Save() returned error: %v", err)
+		}
+	}()
+
+	_, output, err := executeCommandC(rootCmd, "logs", "-f", state.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "Iteration 1 of 3") || !strings.Contains(output, "Task completed!") {
+		t.Errorf("expected the log to be followed until the run finished, got %q", output)
+	}
+}
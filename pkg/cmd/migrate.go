@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateKeepLegacy bool
+
+// migrateStateCmd converts legacy state files to their current format
+var migrateStateCmd = &cobra.Command{
+	Use:   "migrate-state",
+	Short: "Convert the legacy .gonzo/progress.txt into the structured progress format",
+	Long: `Migrate-state converts the free-form .gonzo/progress.txt into the versioned
+.gonzo/progress.md. The Codebase Patterns section is preserved, all other notes
+are carried over verbatim, and references to the old path in CLAUDE.md,
+AGENTS.md and .gitignore files are updated.
+
+The legacy file is removed afterwards unless --keep-legacy is given.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runMigrateState,
+}
+
+func init() {
+	migrateStateCmd.Flags().BoolVar(
+		&migrateKeepLegacy,
+		"keep-legacy", false,
+		"Keep the legacy progress.txt after migrating")
+
+	rootCmd.AddCommand(migrateStateCmd)
+}
+
+func runMigrateState(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	result, err := gonzo.MigrateProgress(dir, gonzo.MigrateOptions{KeepLegacy: migrateKeepLegacy})
+	if errors.Is(err, gonzo.ErrAlreadyMigrated) {
+		cmd.Println("Nothing to do: " + err.Error())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Migrated %s to %s\n", result.From, result.To)
+	for _, ref := range result.UpdatedRefs {
+		cmd.Printf("  Updated references in %s\n", ref)
+	}
+	if result.RemovedOriginal {
+		cmd.Printf("  Removed %s\n", result.From)
+	}
+	return nil
+}
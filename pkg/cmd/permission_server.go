@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var permissionServerSocket string
+
+// permissionServerCmd is the MCP server the Claude CLI asks for permission in the proxy
+// permission mode. Gonzo passes it to the CLI itself, it is not meant to be run by hand.
+var permissionServerCmd = &cobra.Command{
+	Use:    "permission-server",
+	Short:  "Relay the permission prompts of the Claude CLI to the run in progress",
+	Args:   cobra.NoArgs,
+	Hidden: true,
+	// The server speaks MCP on stdio and has no use for the configuration
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return gonzo.ServePermissionPrompts(cmd.Context(), os.Stdin, os.Stdout, permissionServerSocket)
+	},
+}
+
+func init() {
+	permissionServerCmd.Flags().StringVar(
+		&permissionServerSocket,
+		"socket", "",
+		"Unix socket of the permission proxy of the run")
+	_ = permissionServerCmd.MarkFlagRequired("socket")
+
+	rootCmd.AddCommand(permissionServerCmd)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"errors"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// planCmd writes an implementation plan without changing the repository
+var planCmd = &cobra.Command{
+	Use:   "plan [flags] feature",
+	Short: "Write an implementation plan for a feature without changing any code",
+	Long: `Plan runs a single planning pass of Claude Code, which may read the repository
+but not change it, and writes the resulting plan to .gonzo/plan.md so the
+approach can be reviewed before a full run.
+
+The feature is given like for gonzo itself: as arguments, a file path or on
+stdin. Once the plan looks right, implement it with: gonzo .gonzo/plan.md`,
+	Args:         cobra.ArbitraryArgs,
+	SilenceUsage: true,
+	RunE:         runPlan,
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+}
+
+func runPlan(cmd *cobra.Command, args []string) error {
+	feature, fm, err := readFeature(args)
+	if err != nil {
+		return err
+	}
+	if feature == "" {
+		return errors.New("no feature given to plan")
+	}
+	config.ApplySettings(frontMatterSettings(fm))
+
+	runner, err := buildRunner(cmd, nil)
+	if err != nil {
+		return err
+	}
+	planner, ok := gonzo.RunnerAs[gonzo.Planner](runner)
+	if !ok {
+		return errors.New("the configured runner does not support planning")
+	}
+
+	plan, err := planner.Plan(cmd.Context(), feature)
+	if err != nil {
+		return err
+	}
+
+	cmd.Print(plan)
+	cmd.Printf("\nPlan written to %s\n", filepath.Join(gonzo.GonzoDir, gonzo.PlanFile))
+	return nil
+}
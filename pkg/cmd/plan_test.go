@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestPlan(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	defer func() {
+		newRunner = originalNewRunner
+		viper.Reset()
+	}()
+
+	mock := &mockRunner{response: "## Summary\nAdd the button to the header.\n"}
+	newRunner = mockRunnerFactory(mock)
+
+	_, output, err := executeCommandC(rootCmd, "plan", "add", "a", "login", "button")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !mock.planCalled || mock.generateCalled {
+		t.Error("expected a planning pass and no run")
+	}
+	if mock.capturedPrompt != "add a login button" {
+		t.Errorf("expected the feature to be planned, got %q", mock.capturedPrompt)
+	}
+	if !strings.Contains(output, "Add the button to the header.") || !strings.Contains(output, "Plan written to .gonzo/plan.md") {
+		t.Errorf("unexpected output %q", output)
+	}
+}
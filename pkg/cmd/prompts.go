@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var promptsForce bool
+
+// promptsCmd groups the commands inspecting the embedded prompt templates
+var promptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "Inspect and export gonzo's prompt templates",
+	Long: `Prompts lists the prompt templates embedded in gonzo, exports them into
+.gonzo/prompts/ for local editing, and diffs the local copies against the
+embedded versions, e.g. to pick up changes after upgrading gonzo.
+
+Runs use the local copy of a template in .gonzo/prompts/ instead of the
+embedded version when there is one. Lint checks the local copies before a run
+does.`,
+}
+
+var promptsListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the embedded prompt templates",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runPromptsList,
+}
+
+var promptsExportCmd = &cobra.Command{
+	Use:   "export [template...]",
+	Short: "Copy embedded prompt templates into .gonzo/prompts",
+	Long: `Export copies the named prompt templates, or all of them, into
+.gonzo/prompts/ for local editing. Existing copies are kept unless --force is
+given.`,
+	ValidArgsFunction: completePromptNames,
+	SilenceUsage:      true,
+	RunE:              runPromptsExport,
+}
+
+var promptsDiffCmd = &cobra.Command{
+	Use:   "diff [template...]",
+	Short: "Diff the local prompt templates against the embedded versions",
+	Long: `Diff prints a unified diff from the embedded version of each named template,
+or of every template with a local copy in .gonzo/prompts/, to the local copy.`,
+	ValidArgsFunction: completePromptNames,
+	SilenceUsage:      true,
+	RunE:              runPromptsDiff,
+}
+
+var promptsLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check the local prompt templates",
+	Long: `Lint parses each local prompt template in .gonzo/prompts/, checks that it
+references only the fields of the data gonzo renders it with, and renders it
+with sample data, so that a broken template fails now rather than in the middle
+of a run. Local copies of templates gonzo does not read are reported too.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runPromptsLint,
+}
+
+func init() {
+	promptsExportCmd.Flags().BoolVar(
+		&promptsForce,
+		"force", false,
+		"Replace existing local copies")
+
+	promptsCmd.AddCommand(promptsListCmd, promptsExportCmd, promptsDiffCmd, promptsLintCmd)
+	rootCmd.AddCommand(promptsCmd)
+}
+
+func runPromptsList(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	prompts, err := gonzo.ListPrompts(dir)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEMPLATE\tLOCAL COPY\tUSED FOR")
+	for _, p := range prompts {
+		local := "-"
+		if p.Local != "" {
+			local = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.Name, local, p.Description)
+	}
+	return w.Flush()
+}
+
+func runPromptsExport(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	written, err := gonzo.ExportPrompts(dir, args, promptsForce)
+	for _, path := range written {
+		cmd.Printf("Exported %s\n", path)
+	}
+	return err
+}
+
+func runPromptsDiff(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	names := args
+	if len(names) == 0 {
+		prompts, err := gonzo.ListPrompts(dir)
+		if err != nil {
+			return err
+		}
+		for _, p := range prompts {
+			if p.Local != "" {
+				names = append(names, p.Name)
+			}
+		}
+		if len(names) == 0 {
+			cmd.Println("No local prompt templates in " + gonzo.PromptsPath(dir))
+			return nil
+		}
+	}
+
+	for _, name := range names {
+		diff, err := gonzo.DiffPrompt(dir, name)
+		if err != nil {
+			return err
+		}
+		if diff == "" {
+			cmd.Printf("%s is identical to the embedded version\n", name)
+			continue
+		}
+		cmd.Print(diff)
+	}
+	return nil
+}
+
+func runPromptsLint(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	lints, err := gonzo.LintPrompts(dir)
+	if err != nil {
+		return err
+	}
+	if len(lints) == 0 {
+		cmd.Println("No local prompt templates in " + gonzo.PromptsPath(dir))
+		return nil
+	}
+
+	failed := 0
+	for _, lint := range lints {
+		switch {
+		case lint.Err != nil:
+			failed++
+			cmd.Printf("%s: %v\n", lint.Name, lint.Err)
+		case lint.Warning != "":
+			cmd.Printf("%s: warning: %s\n", lint.Name, lint.Warning)
+		default:
+			cmd.Printf("%s: ok\n", lint.Name)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d prompt templates are invalid", failed, len(lints))
+	}
+	return nil
+}
+
+// completePromptNames completes the names of the embedded prompt templates.
+func completePromptNames(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	prompts, err := gonzo.ListPrompts("")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var completions []cobra.Completion
+	for _, p := range prompts {
+		completions = append(completions, cobra.CompletionWithDesc(p.Name, p.Description))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
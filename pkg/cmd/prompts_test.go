@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrompts(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	_, output, err := executeCommandC(rootCmd, "prompts", "export", "system_prompt.tmpl")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	local := filepath.Join(gonzo.PromptsPath(dir), "system_prompt.tmpl")
+	if !strings.Contains(output, "Exported "+local) {
+		t.Errorf("unexpected output %q", output)
+	}
+
+	_, output, err = executeCommandC(rootCmd, "prompts", "list")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "system_prompt.tmpl") || !strings.Contains(output, "yes") {
+		t.Errorf("expected the local copy to be listed, got %q", output)
+	}
+
+	if err := os.WriteFile(local, []byte("# Custom instructions\n"), 0644); err != nil {
+		t.Fatalf("failed to edit copy: %v", err)
+	}
+	_, output, err = executeCommandC(rootCmd, "prompts", "diff")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "+# Custom instructions") || !strings.Contains(output, "-# Gonzo Programming Agent Instructions") {
+		t.Errorf("expected a diff of the local copy, got %q", output)
+	}
+}
+
+func TestPromptsLint(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	_, output, err := executeCommandC(rootCmd, "prompts", "lint")
+	if err != nil || !strings.Contains(output, "No local prompt templates") {
+		t.Errorf("expected no templates to lint, got %q, %v", output, err)
+	}
+
+	if _, _, err := executeCommandC(rootCmd, "prompts", "export", "wrap_up.tmpl", "plan.tmpl"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, output, err = executeCommandC(rootCmd, "prompts", "lint")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "plan.tmpl: ok") || !strings.Contains(output, "wrap_up.tmpl: ok") {
+		t.Errorf("expected both templates to be ok, got %q", output)
+	}
+
+	local := filepath.Join(gonzo.PromptsPath(dir), "plan.tmpl")
+	if err := os.WriteFile(local, []byte("{{ .Feature }}\n"), 0644); err != nil {
+		t.Fatalf("failed to edit copy: %v", err)
+	}
+	_, output, err = executeCommandC(rootCmd, "prompts", "lint")
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 prompt templates are invalid") {
+		t.Errorf("expected lint to fail, got %v", err)
+	}
+	if !strings.Contains(output, "unknown field .Feature") {
+		t.Errorf("expected the unknown field to be reported, got %q", output)
+	}
+}
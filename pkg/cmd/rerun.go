@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// rerunCmd replays a previous run from its manifest
+var rerunCmd = &cobra.Command{
+	Use:   "rerun <manifest|run-id>",
+	Short: "Replay a previous run with identical settings on a fresh branch",
+	Long: `Rerun reads the manifest written at the start of a run and replays it: the
+same feature, model and resolved configuration, starting from the same base
+commit on a fresh gonzo/rerun-<run-id> branch.
+
+The argument is either the path to a manifest.json file or the ID of a run
+recorded in this repository. The working tree must be clean. A warning is
+printed when the installed gonzo or Claude CLI version differs from the one
+recorded in the manifest.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRunIDsOrFiles,
+	SilenceUsage:      true,
+	RunE:              runRerun,
+}
+
+func init() {
+	rootCmd.AddCommand(rerunCmd)
+}
+
+func runRerun(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	manifest, err := gonzo.LoadManifest(dir, args[0])
+	if err != nil {
+		return err
+	}
+	if err := manifest.Verify(); err != nil {
+		return err
+	}
+
+	if manifest.GonzoVersion != gonzo.Version {
+		cmd.Printf("Warning: run %s used gonzo %s, this is gonzo %s\n", manifest.RunID, manifest.GonzoVersion, gonzo.Version)
+	}
+
+	if manifest.ClaudeVersion != "" {
+		if v, err := gonzo.ClaudeVersion(cmd.Context()); err == nil && v != manifest.ClaudeVersion {
+			cmd.Printf("Warning: run %s used Claude CLI %s, installed is %s\n", manifest.RunID, manifest.ClaudeVersion, v)
+		}
+	}
+
+	branch, err := gonzo.PrepareRerun(cmd.Context(), dir, manifest)
+	if err != nil {
+		return err
+	}
+	cmd.Printf("Replaying run %s on branch %s\n", manifest.RunID, branch)
+
+	// The manifest wins over flags, env and config files; the fresh branch is already checked out
+	config.ApplySettings(manifest.Config)
+	config.ApplySettings(map[string]interface{}{
+		config.KeyModel:    manifest.Model,
+		config.KeyNoBranch: true,
+	})
+
+	runner, err := buildRunner(cmd, nil)
+	if err != nil {
+		return err
+	}
+	return runFeature(cmd, runner, dir, manifest.Feature, cmd.OutOrStderr())
+}
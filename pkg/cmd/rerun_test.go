@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"encoding/json"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// gitIn runs git in dir and fails the test on error.
+func gitIn(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestRerun(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("Skipping test - git is not available")
+	}
+
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	defer func() {
+		newRunner = originalNewRunner
+		viper.Reset()
+	}()
+
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	gitIn(t, dir, "init", "-q", "-b", "main")
+	gitIn(t, dir, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial")
+	base := gitIn(t, dir, "rev-parse", "HEAD")
+
+	manifest := gonzo.Manifest{
+		RunID:        "20260101-000000-abcd",
+		Feature:      "add a login button",
+		SpecHash:     gonzo.SpecHash("add a login button"),
+		Model:        gonzo.ClaudeHaiku,
+		Config:       map[string]interface{}{"max-iterations": 4, "pr-labels": []string{"gonzo"}},
+		GonzoVersion: gonzo.Version,
+		BaseCommit:   base,
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to encode manifest: %v", err)
+	}
+	// Keep the manifest outside the repository so the working tree stays clean
+	manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	_, output, err := executeCommandC(rootCmd, "rerun", manifestPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mock.capturedPrompt != "add a login button" {
+		t.Errorf("expected the manifest feature, got %q", mock.capturedPrompt)
+	}
+	if mock.model != gonzo.ClaudeHaiku || mock.maxIterations != 4 {
+		t.Errorf("expected manifest settings, got model %q and %d iterations", mock.model, mock.maxIterations)
+	}
+	if len(mock.prOptions.Labels) != 1 || mock.prOptions.Labels[0] != "gonzo" {
+		t.Errorf("expected manifest PR labels, got %v", mock.prOptions.Labels)
+	}
+	if !mock.noBranch {
+		t.Error("expected the rerun to stay on the fresh branch")
+	}
+	if got := gitIn(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); got != gonzo.RerunBranch(&manifest) {
+		t.Errorf("expected to be on %s, got %s", gonzo.RerunBranch(&manifest), got)
+	}
+	if !strings.Contains(output, "mocked response") {
+		t.Errorf("expected the response in the output, got %q", output)
+	}
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var rollbackDeleteBranch bool
+var rollbackRevert bool
+
+// rollbackCmd undoes the changes made by a previous run
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [run-id]",
+	Short: "Undo everything a gonzo run did to the repository",
+	Long: `Rollback restores the repository to the commit that was checked out when a
+run started. Without a run ID the most recent run is rolled back.
+
+By default the commits of the run are dropped: the starting branch is checked
+out again when the run created its own branch, or else hard reset to where the
+run started. Use --revert to add revert commits on the run's branch instead
+(useful when it was already pushed), or --delete-branch to also remove the
+branch the run created.
+
+Rollback refuses to run on uncommitted changes, from another branch than the
+run's, or when commits were made on the run's branch after it.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeRunIDs,
+	SilenceUsage:      true,
+	RunE:              runRollback,
+}
+
+func init() {
+	rollbackCmd.Flags().BoolVar(
+		&rollbackDeleteBranch,
+		"delete-branch", false,
+		"Delete the branch created by the run")
+
+	rollbackCmd.Flags().BoolVar(
+		&rollbackRevert,
+		"revert", false,
+		"Create revert commits instead of resetting the branch")
+
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	var state *gonzo.RunState
+	if len(args) == 1 {
+		state, err = gonzo.ResolveRunState(dir, args[0])
+	} else {
+		state, err = gonzo.LatestRunState(dir)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = gonzo.Rollback(cmd.Context(), dir, state, gonzo.RollbackOptions{
+		DeleteBranch: rollbackDeleteBranch,
+		Revert:       rollbackRevert,
+	})
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Rolled back run %s to %s\n", state.ID, state.StartSHA)
+	return nil
+}
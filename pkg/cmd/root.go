@@ -2,12 +2,21 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"gonzo/pkg/config"
-	"gonzo/pkg/gonzo"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -31,14 +40,75 @@ var llmModelNames = map[LLMModel][]string{
 var llmModel = ModelClaudeOpus
 var maxIterations int
 var quiet bool
+var verbose int
 var noBranch bool
+var branchRunID bool
 var noNewTests bool
 var pr bool
 var commitAuthor string
-
-// newRunner creates a new gonzo.Runner. Replaceable for testing.
-var newRunner = func(model string, quiet bool, maxIter int, noBranch bool, noNewTests bool, pr bool, commitAuthor string) gonzo.Runner {
-	return gonzo.New().WithModel(model).WithQuiet(quiet).WithMaxIterations(maxIter).WithNoBranch(noBranch).WithNoNewTests(noNewTests).WithPR(pr).WithCommitAuthor(commitAuthor)
+var conventionalCommits bool
+var commitTemplate string
+var wrapUpIterations int
+var setOverrides []string
+var templateVars []string
+var playbookName string
+var languageName string
+var outputFormat string
+var logFormat string
+var logLevel string
+var noRepoMap bool
+var transcripts bool
+var noColor bool
+var junitReport string
+var outputFile string
+var reportFile string
+var prDraft bool
+var prLabels []string
+var prReviewers []string
+var prAssignees []string
+var prComment bool
+var forgeName string
+var ciMode string
+var profileName string
+var configPath string
+var workDir string
+var refreshConfig bool
+var strictConfig bool
+var completionSignal string
+var completion string
+var completionPattern string
+var iterationTimeout time.Duration
+var heartbeat time.Duration
+var sessionMode string
+var maxDuration time.Duration
+var retries int
+var maxOutputTokens int
+var thinkingBudget int
+var maxCost float64
+var rateLimitWait time.Duration
+var protectedPaths []string
+var writeScope []string
+var permissionAllow []string
+var permissionAsk []string
+var permissionDeny []string
+var envSet []string
+var envDeny []string
+var permissionMode string
+var sandbox string
+var sandboxImage string
+var allowedTools []string
+var mcpConfig []string
+var agents []string
+var claudeArgs []string
+var verifyCommands []string
+var hooksBeforeRun []string
+var hooksAfterIteration []string
+var hooksAfterRun []string
+
+// newRunner creates a new gonzo.Runner working on the repository in dir, the current directory
+// when empty, applying opts after the configuration. Replaceable for testing.
+var newRunner = func(dir string, cfg *config.Config, settings map[string]interface{}, issue *forge.Issue, opts ...gonzo.Option) gonzo.Runner {
+	return gonzo.New(append([]gonzo.Option{gonzo.WithWorkingDir(dir), gonzo.WithConfig(cfg), gonzo.WithSettings(settings), gonzo.WithIssue(issue)}, opts...)...)
 }
 
 // rootCmd represents the base command when called without any subcommands
@@ -54,36 +124,75 @@ The feature can be specified as:
   - A path to a file containing the feature: gonzo feature.txt
   - Via stdin: echo "add a login button" | gonzo
 
+A feature file may start with front matter setting model, max-iterations,
+branch-name or playbook for its run, between two --- lines.
+
 Configuration can be provided via:
-  - Command-line flags (highest priority)
+  - Front matter of the feature file (highest priority)
+  - Command-line flags, including --set key=value overrides
   - Environment variables (GONZO_ prefix, e.g., GONZO_MODEL, GONZO_MAX_ITERATIONS)
-  - Config file (~/.gonzo.yaml, ~/.config/gonzo/gonzo.yaml, or ./gonzo.yaml)
-  - Default values (lowest priority)`,
+  - A profile from the profiles section of the config files, selected with --profile
+  - Project config files (./gonzo.yaml, then ./.gonzo/config.yaml)
+  - User config file (~/.config/gonzo/gonzo.yaml or ~/gonzo.yaml), or only the
+    file given with --config or GONZO_CONFIG
+  - Default values (lowest priority)
+
+Config files may also be TOML or JSON, e.g. gonzo.toml or .gonzo/config.json.`,
 	Args:              cobra.ArbitraryArgs,
 	PersistentPreRunE: initConfig,
 	Run:               runClaudePrompt,
 }
 
 // SetVersion sets the version string for the root command.
-// This enables the --version flag.
+// This enables the --version flag and records the version in run manifests.
 func SetVersion(v string) {
 	rootCmd.Version = v
+	gonzo.Version = v
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handleInterrupts(cancel)
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
-		os.Exit(1)
+		exit(gonzo.ExitCode(err))
+	}
+}
+
+// interruptGrace is how long gonzo waits after an interrupt for the command to stop the Claude
+// CLI and the tools it spawned, and to record the run, before exiting anyway.
+const interruptGrace = 10 * time.Second
+
+// handleInterrupts cancels the context of the command on the first interrupt or SIGTERM, which
+// kills the Claude CLI call in progress along with its process group, and exits on the second or
+// once interruptGrace has passed.
+func handleInterrupts(cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	cancel()
+	select {
+	case <-signals:
+	case <-time.After(interruptGrace):
 	}
+	exit(gonzo.ExitAborted)
 }
 
+// exit ends the process with the given code, replaced in tests.
+var exit = os.Exit
+
 // initConfig initializes Viper configuration and binds flags.
 // This is called as PersistentPreRunE to ensure config is loaded before the command runs.
 func initConfig(cmd *cobra.Command, args []string) error {
+	if err := changeWorkDir(); err != nil {
+		return err
+	}
+
 	// Initialize Viper with defaults, config file, and env vars
-	if err := config.Init(); err != nil {
+	if err := loadConfig(); err != nil {
 		return err
 	}
 
@@ -92,10 +201,70 @@ func initConfig(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Apply the selected profile on top of the config files
+	if err := config.ApplyProfile(viper.GetString(config.KeyProfile)); err != nil {
+		return err
+	}
+
+	// Apply --set overrides on top of everything else
+	if err := config.ApplyOverrides(setOverrides); err != nil {
+		return err
+	}
+	if err := config.ApplyVars(templateVars); err != nil {
+		return err
+	}
+	config.ApplyPlaybook(viper.GetString(config.KeyPlaybook))
+
+	if err := config.Validate(); err != nil {
+		if viper.GetBool(config.KeyStrictConfig) {
+			return fmt.Errorf("invalid configuration (pass --strict-config=false to ignore):\n%w", err)
+		}
+		cmd.PrintErrf("Warning: ignoring invalid configuration:\n%v\n", err)
+	}
+
+	return nil
+}
+
+// changeWorkDir moves to the directory given with -C, so that gonzo works on it, with its config
+// files, as if started there. The directory is made absolute for the config to be reloaded.
+func changeWorkDir() error {
+	if workDir == "" {
+		return nil
+	}
+	abs, err := filepath.Abs(workDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", workDir, err)
+	}
+	if err := os.Chdir(abs); err != nil {
+		return fmt.Errorf("failed to change to %s: %w", workDir, err)
+	}
+	workDir = abs
 	return nil
 }
 
+// loadConfig initializes Viper from the config file named with --config, or the ones found.
+func loadConfig() error {
+	config.SetConfigFile(configPath)
+	config.SetRefreshConfig(refreshConfig)
+	return config.Init()
+}
+
 func init() {
+	rootCmd.PersistentFlags().StringVarP(
+		&workDir,
+		"cwd", "C", "",
+		"Work on the repository in this directory instead of the current directory")
+
+	rootCmd.PersistentFlags().StringVar(
+		&configPath,
+		"config", "",
+		"Use this config file instead of searching for one (also GONZO_CONFIG)")
+
+	rootCmd.PersistentFlags().BoolVar(
+		&refreshConfig,
+		"refresh-config", false,
+		"Download the config files extended by URL again instead of using the cached copies")
+
 	rootCmd.PersistentFlags().VarP(
 		enumflag.New(&llmModel, "model", llmModelNames, enumflag.EnumCaseInsensitive),
 		"model", "m",
@@ -111,13 +280,23 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(
 		&quiet,
 		"quiet", "q", config.DefaultQuiet,
-		"Disable output messages")
+		"Print only the final result, without output messages or the live output of Claude Code")
+
+	rootCmd.PersistentFlags().CountVarP(
+		&verbose,
+		"verbose", "v",
+		"Print more about the run: -v for the banners and summaries of the iterations (default), -vv to also print the rendered prompts and the Claude CLI commands")
 
 	rootCmd.PersistentFlags().BoolVar(
 		&noBranch,
 		"no-branch", config.DefaultNoBranch,
 		"Skip creating a new git branch for the changes")
 
+	rootCmd.PersistentFlags().BoolVar(
+		&branchRunID,
+		"branch-run-id", config.DefaultBranchRunID,
+		"End the name of the branch of the run with its short run ID")
+
 	rootCmd.PersistentFlags().BoolVar(
 		&noNewTests,
 		"no-new-tests", config.DefaultNoNewTests,
@@ -132,9 +311,414 @@ func init() {
 		&commitAuthor,
 		"commit-author", "a", config.DefaultCommitAuthor,
 		"Override the default commit author (format: 'Name <email>')")
+
+	rootCmd.PersistentFlags().BoolVar(
+		&conventionalCommits,
+		"conventional-commits", config.DefaultConventionalCommits,
+		"Use Conventional Commits messages for commits made by gonzo")
+
+	rootCmd.PersistentFlags().StringVar(
+		&commitTemplate,
+		"commit-template", config.DefaultCommitTemplate,
+		"Commit message template (Go text/template, inline or a file path)")
+
+	rootCmd.PersistentFlags().IntVar(
+		&wrapUpIterations,
+		"wrap-up-iterations", config.DefaultWrapUpIterations,
+		"Number of final iterations reserved for wrapping up when the task is not complete")
+
+	rootCmd.PersistentFlags().BoolVar(
+		&prDraft,
+		"pr-draft", config.DefaultPRDraft,
+		"Open the pull request as a draft")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&prLabels,
+		"pr-label", nil,
+		"Label to add to the pull request (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&prReviewers,
+		"pr-reviewer", nil,
+		"User or team to request a review from (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&prAssignees,
+		"pr-assignee", nil,
+		"User to assign the pull request to (repeatable)")
+
+	rootCmd.PersistentFlags().BoolVar(
+		&prComment,
+		"pr-comment", config.DefaultPRComment,
+		"Post a summary of the run as a comment on the pull request")
+
+	rootCmd.PersistentFlags().StringVar(
+		&forgeName,
+		"forge", config.DefaultForge,
+		fmt.Sprintf("Forge to open pull requests on (options: %s, %s, %s)", forge.Auto, forge.GitHub, forge.GitLab))
+
+	rootCmd.PersistentFlags().StringVar(
+		&ciMode,
+		"ci", config.DefaultCI,
+		fmt.Sprintf("CI integration to report to (options: %s, %s, %s)", gonzo.CIAuto, gonzo.CIGitHub, gonzo.CINone))
+
+	rootCmd.PersistentFlags().StringVar(
+		&profileName,
+		"profile", config.DefaultProfile,
+		"Apply a named profile from the profiles section of the config file")
+
+	rootCmd.PersistentFlags().BoolVar(
+		&strictConfig,
+		"strict-config", config.DefaultStrictConfig,
+		"Fail on unknown config keys and invalid values instead of warning")
+
+	rootCmd.PersistentFlags().StringVar(
+		&completionSignal,
+		"completion-signal", config.DefaultCompletionSignal,
+		"Marker Claude replies with once the task is complete")
+
+	rootCmd.PersistentFlags().StringVar(
+		&completion,
+		"completion", config.DefaultCompletion,
+		"Tell the task is complete when Claude replies with the completion signal (marker), its reply matches --completion-pattern (regex), or two iterations in a row change nothing (quiescence)")
+
+	rootCmd.PersistentFlags().StringVar(
+		&completionPattern,
+		"completion-pattern", config.DefaultCompletionPattern,
+		"Regular expression Claude's reply matches once the task is complete, with --completion regex")
+
+	rootCmd.PersistentFlags().DurationVar(
+		&iterationTimeout,
+		"iteration-timeout", config.DefaultIterationTimeout,
+		"Maximum time a single iteration may take, e.g. 10m (0 for no limit)")
+
+	rootCmd.PersistentFlags().DurationVar(
+		&maxDuration,
+		"max-duration", config.DefaultMaxDuration,
+		"Maximum time the whole run may take, e.g. 1h (0 for no limit)")
+
+	rootCmd.PersistentFlags().DurationVar(
+		&heartbeat,
+		"heartbeat", config.DefaultHeartbeat,
+		"Print a line at this interval while Claude runs, e.g. 5m, for CI systems that kill silent jobs (0 for none)")
+
+	rootCmd.PersistentFlags().StringVar(
+		&sessionMode,
+		"session-mode", config.DefaultSessionMode,
+		"Start each iteration in a new Claude session (fresh) or resume the session of the previous one (continue)")
+
+	rootCmd.PersistentFlags().IntVar(
+		&retries,
+		"retries", config.DefaultRetries,
+		"Number of times a failed Claude call is retried")
+
+	rootCmd.PersistentFlags().IntVar(
+		&maxOutputTokens,
+		"max-output-tokens", config.DefaultMaxOutputTokens,
+		"Number of tokens a response of the model may reach (0 for the default of the Claude CLI)")
+
+	rootCmd.PersistentFlags().IntVar(
+		&thinkingBudget,
+		"thinking-budget", config.DefaultThinkingBudget,
+		"Number of tokens the model may think for before responding, at least 1024 (0 for the default of the Claude CLI)")
+
+	rootCmd.PersistentFlags().DurationVar(
+		&rateLimitWait,
+		"rate-limit-wait", config.DefaultRateLimitWait,
+		"Time an iteration waits in total for rate limits to lift before failing (0 to fail right away)")
+
+	rootCmd.PersistentFlags().Float64Var(
+		&maxCost,
+		"max-cost", config.DefaultMaxCost,
+		"Cost in US dollars the whole run may reach, checked as Claude works, e.g. 5 (0 for no limit)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&protectedPaths,
+		"protected-path", nil,
+		"Glob of files Claude must not change, e.g. '.github/**'; the run fails if Claude edits one (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&writeScope,
+		"write-scope", nil,
+		"Glob of the only files Claude may change, e.g. 'pkg/'; changes to others are reverted after each iteration (repeatable)")
+
+	rootCmd.PersistentFlags().StringVar(
+		&permissionMode,
+		"permission-mode", config.DefaultPermissionMode,
+		"Permission mode of Claude: default, acceptEdits, plan, bypassPermissions to skip all permission checks (sandboxes only), or proxy to decide them with the permission policy")
+
+	rootCmd.PersistentFlags().StringVar(
+		&sandbox,
+		"sandbox", config.DefaultSandbox,
+		"Run the loop on the host (none) or in a Docker container holding only the repository and the Claude credentials (docker)")
+
+	rootCmd.PersistentFlags().StringVar(
+		&sandboxImage,
+		"sandbox-image", config.DefaultSandboxImage,
+		"Image of the Docker sandbox, with the Claude CLI, git and the tools of the repository")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&permissionAllow,
+		"permission-allow", nil,
+		"Tool allowed without asking in the proxy permission mode, e.g. 'Edit(src/**)' (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&permissionAsk,
+		"permission-ask", nil,
+		"Tool asked about on the terminal in the proxy permission mode, e.g. 'Bash' (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&permissionDeny,
+		"permission-deny", nil,
+		"Tool denied in the proxy permission mode, e.g. 'Bash(rm:*)' (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&allowedTools,
+		"allowed-tool", nil,
+		"Tool Claude may use on top of the permission mode, e.g. 'Bash(git:*)' (repeatable, replaces the defaults)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&mcpConfig,
+		"mcp-config", nil,
+		"JSON file of MCP servers Claude may use, as taken by the Claude CLI (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&agents,
+		"agent", nil,
+		"Subagent Claude may delegate to: the name of an agent in .claude/agents, an agent file or a directory of them (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&claudeArgs,
+		"claude-arg", nil,
+		"Extra argument passed to every Claude CLI call, e.g. --claude-arg=--fallback-model=sonnet (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&envSet,
+		"env", nil,
+		"Variable set in the environment of Claude, as NAME=value, e.g. 'HTTPS_PROXY=http://proxy:3128' (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&envDeny,
+		"env-deny", nil,
+		"Variable of gonzo's environment kept from Claude, as a name or a glob such as '*_TOKEN' (repeatable, replaces the defaults)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&verifyCommands,
+		"verify", nil,
+		"Command that must pass before the task is considered complete (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&hooksBeforeRun,
+		"hook-before-run", nil,
+		"Command to run before the first iteration; the run fails if it fails (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&hooksAfterIteration,
+		"hook-after-iteration", nil,
+		"Command to run after every iteration (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&hooksAfterRun,
+		"hook-after-run", nil,
+		"Command to run once the run is over (repeatable)")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&setOverrides,
+		"set", nil,
+		"Override any config key for this run (format: key=value, repeatable)")
+
+	rootCmd.PersistentFlags().StringVar(
+		&playbookName,
+		"playbook", config.DefaultPlaybook,
+		"Playbook tuning the system prompt and defaults to the task: bugfix, feature, refactor, docs or migration")
+
+	rootCmd.PersistentFlags().StringVar(
+		&languageName,
+		"language", config.DefaultLanguage,
+		"Language whose toolchain guidance is added to the system prompt, e.g. go, node or python; auto to detect it, none to leave it out")
+
+	rootCmd.PersistentFlags().StringVar(
+		&outputFormat,
+		"output", config.DefaultOutput,
+		"Format of the output: text, or json for a summary of the run on stdout")
+
+	rootCmd.PersistentFlags().StringVar(
+		&logFormat,
+		"log-format", config.DefaultLogFormat,
+		"Format of the log on the console and in .gonzo/logs: text or json")
+
+	rootCmd.PersistentFlags().StringVar(
+		&logLevel,
+		"log-level", config.DefaultLogLevel,
+		"Least severe level of the messages logged: debug, info, warn or error")
+
+	rootCmd.PersistentFlags().StringArrayVar(
+		&templateVars,
+		"var", nil,
+		"Set a variable of the prompt templates, available as .Vars.<name> (format: name=value, repeatable)")
+
+	rootCmd.PersistentFlags().BoolVar(
+		&noRepoMap,
+		"no-repomap", config.DefaultNoRepoMap,
+		"Leave the map of the repository out of the system prompt")
+
+	rootCmd.PersistentFlags().BoolVar(
+		&transcripts,
+		"transcripts", config.DefaultTranscripts,
+		"Record the system prompt, prompt and output of each iteration, with secrets redacted, in .gonzo/transcripts")
+
+	rootCmd.PersistentFlags().BoolVar(
+		&noColor,
+		"no-color", config.DefaultNoColor,
+		"Disable colors, also disabled by NO_COLOR and when the output is not a terminal")
+
+	rootCmd.PersistentFlags().StringVar(
+		&junitReport,
+		"junit", config.DefaultJUnit,
+		"Write a JUnit XML report of the run and its verification commands to this path, for CI test reports")
+
+	rootCmd.PersistentFlags().StringVar(
+		&outputFile,
+		"output-file", config.DefaultOutputFile,
+		"Write the final response of the run to this path instead of stdout")
+
+	rootCmd.PersistentFlags().StringVar(
+		&reportFile,
+		"report-file", config.DefaultReportFile,
+		"Write the JSON summary of the run to this path")
+
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("model", completeValues(gonzo.ClaudeHaiku, gonzo.ClaudeSonnet, gonzo.ClaudeOpus)))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("forge", completeValues(forge.Auto, forge.GitHub, forge.GitLab)))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("ci", completeValues(gonzo.CIAuto, gonzo.CIGitHub, gonzo.CINone)))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("playbook", completePlaybooks))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("language", completeLanguages))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("sandbox", completeValues(gonzo.SandboxNone, gonzo.SandboxDocker)))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("output", completeValues(gonzo.OutputText, gonzo.OutputJSON)))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("log-format", completeValues(gonzo.LogFormatText, gonzo.LogFormatJSON)))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("log-level", completeValues("debug", "info", "warn", "error")))
 }
 
 func runClaudePrompt(cmd *cobra.Command, args []string) {
+	feature, fm, err := readFeature(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if feature == "" {
+		_ = cmd.Help()
+		return
+	}
+	if err := applyFrontMatter(cmd, fm); err != nil {
+		log.Fatal(err)
+	}
+	if dryRun != "" {
+		if err := runDryRun(cmd, feature); err != nil {
+			log.Print(err)
+			exit(gonzo.ExitCode(err))
+		}
+		return
+	}
+	if confirm != "" {
+		if err := runConfirmed(cmd, feature); err != nil {
+			log.Print(err)
+			exit(gonzo.ExitCode(err))
+		}
+		return
+	}
+	if config.GetSandbox() == gonzo.SandboxDocker {
+		if err := runInSandbox(cmd, feature); err != nil {
+			log.Print(err)
+			exit(gonzo.ExitCode(err))
+		}
+		return
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("failed to get current working directory: %v", err)
+	}
+	runner, err := buildRunner(cmd, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := runFeature(cmd, runner, dir, feature, os.Stdout); err != nil {
+		log.Print(err)
+		exit(gonzo.ExitCode(err))
+	}
+}
+
+// runFeature runs the feature on the repository in dir, where the runner works, and prints its
+// final response to w, or the summary of the run as JSON on stdout with the JSON output, whether
+// it succeeded or not. The response and summary are written to the output and report files as
+// well, when set. It returns the error of the run or, for a run that ended without completing, an
+// *gonzo.ExitError with the exit code of its outcome.
+func runFeature(cmd *cobra.Command, runner gonzo.Runner, dir string, feature string, w io.Writer) error {
+	previous, _ := gonzo.LatestRunState(dir)
+	response, runErr := runner.Generate(cmd.Context(), feature)
+	run := newRunState(dir, previous)
+	if err := writeResults(cmd, w, response, gonzo.NewRunSummary(dir, feature, run, response, runErr)); err != nil {
+		return errors.Join(runErr, err)
+	}
+	if runErr == nil && run != nil {
+		return gonzo.RunOutcome(run)
+	}
+	return runErr
+}
+
+// newRunState returns the state of the latest run in dir when it is not previous, i.e. the run
+// that has just been made, or nil.
+func newRunState(dir string, previous *gonzo.RunState) *gonzo.RunState {
+	if latest, _ := gonzo.LatestRunState(dir); latest != nil && (previous == nil || latest.ID != previous.ID) {
+		return latest
+	}
+	return nil
+}
+
+// writeResults prints the summary of the run as JSON on stdout with the JSON output, or else the
+// response to w unless it goes to the output file. It writes the response to the output file and
+// the summary to the report file, when set.
+func writeResults(cmd *cobra.Command, w io.Writer, response string, summary *gonzo.RunSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the run summary: %w", err)
+	}
+	outputFile, reportFile := config.GetOutputFile(), config.GetReportFile()
+
+	if config.GetOutput() == gonzo.OutputJSON {
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	} else if outputFile == "" && response != "" {
+		fmt.Fprintln(w, response)
+	}
+	if outputFile != "" {
+		if err := writeResultFile(outputFile, response); err != nil {
+			return err
+		}
+	}
+	if reportFile != "" {
+		if err := writeResultFile(reportFile, string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeResultFile writes content to path, creating its directory.
+func writeResultFile(path string, content string) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory of %s: %w", path, err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// readFeature returns the feature given as arguments, read from the file named by a single
+// argument, or piped on stdin. It is empty when no feature was given. The front matter of a
+// feature file is returned apart from the feature, and is nil otherwise.
+func readFeature(args []string) (string, *gonzo.FrontMatter, error) {
 	var feature string
 
 	// Check if stdin is a pipe (has data)
@@ -146,7 +730,11 @@ func runClaudePrompt(cmd *cobra.Command, args []string) {
 		// Check if feature is a single argument that looks like a file path
 		if len(args) == 1 {
 			if content, err := readFeatureFromFile(args[0]); err == nil {
-				feature = content
+				fm, feature, err := gonzo.ParseFrontMatter(content)
+				if err != nil {
+					return "", nil, fmt.Errorf("%s: %w", args[0], err)
+				}
+				return feature, fm, nil
 			}
 		}
 	} else if stdinIsPipe {
@@ -157,39 +745,110 @@ func runClaudePrompt(cmd *cobra.Command, args []string) {
 		}
 		feature = strings.Join(lines, "\n")
 	}
+	return feature, nil, nil
+}
 
-	if feature == "" {
-		_ = cmd.Help()
-		return
+// frontMatterSettings returns the config settings overridden by the front matter of a feature.
+// A branch named by the front matter replaces the one the agent would create.
+func frontMatterSettings(fm *gonzo.FrontMatter) map[string]interface{} {
+	settings := map[string]interface{}{}
+	if fm == nil {
+		return settings
 	}
+	if fm.Model != "" {
+		settings[config.KeyModel] = fm.Model
+	}
+	if fm.MaxIterations > 0 {
+		settings[config.KeyMaxIterations] = fm.MaxIterations
+	}
+	if fm.Playbook != "" {
+		settings[config.KeyPlaybook] = fm.Playbook
+	}
+	if fm.BranchName != "" {
+		settings[config.KeyNoBranch] = true
+	}
+	return settings
+}
 
-	// Get config values from Viper (which already merged flag, env, and config file values)
-	// For the model, check if the flag was explicitly set; otherwise use Viper's value
+// applyFrontMatter applies the front matter of the feature to run on top of every other
+// configuration source, and checks out the branch it names, created when it does not exist.
+func applyFrontMatter(cmd *cobra.Command, fm *gonzo.FrontMatter) error {
+	config.ApplySettings(frontMatterSettings(fm))
+	if fm == nil || fm.BranchName == "" {
+		return nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	if err := gonzo.CheckoutBatchBranch(cmd.Context(), dir, fm.BranchName, ""); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", fm.BranchName, err)
+	}
+	return nil
+}
+
+// buildRunner creates the runner from the resolved configuration.
+// The issue, when not nil, is the issue the run implements.
+func buildRunner(cmd *cobra.Command, issue *forge.Issue, opts ...gonzo.Option) (gonzo.Runner, error) {
+	return buildRunnerIn(cmd, workDir, issue, opts...)
+}
+
+// buildRunnerIn is buildRunner for a run on the repository in dir, e.g. a worktree of the one
+// gonzo was started in.
+func buildRunnerIn(cmd *cobra.Command, dir string, issue *forge.Issue, opts ...gonzo.Option) (gonzo.Runner, error) {
+	cfg, err := loadRunConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record the model actually used, which may come from the flag rather than Viper, and none
+	// of the secrets, which the run manifest is no place for
+	settings := config.SettingsWithoutSecrets()
+	settings[config.KeyModel] = cfg.Model
+
+	cfg.CommitTemplate = readCommitTemplate(cfg.CommitTemplate)
+	runner := newRunner(dir, cfg, settings, issue, opts...)
+	if v, ok := gonzo.RunnerAs[gonzo.Validator](runner); ok {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid configuration:\n%w", err)
+		}
+	}
+	return runner, nil
+}
+
+// loadRunConfig returns the resolved configuration, with the model resolved like runs resolve it
+// and the defaults of the playbook selected last, e.g. by the front matter of the feature.
+func loadRunConfig(cmd *cobra.Command) (*config.Config, error) {
+	config.ApplyPlaybook(config.GetPlaybook())
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Model = resolveModel(cmd)
+	return cfg, nil
+}
+
+// resolveModel returns the model to use. The enum flag is not bound to Viper, so it wins
+// when explicitly set; otherwise Viper's value is used.
+func resolveModel(cmd *cobra.Command) string {
 	modelValue := llmModelNames[llmModel][0]
-	if !cmd.Flags().Changed(config.KeyModel) {
-		// Flag wasn't explicitly set, check Viper (env var or config file)
+	if !cmd.Flags().Changed(config.KeyModel) || config.IsOverridden(config.KeyModel) {
+		// Flag wasn't explicitly set or was overridden with --set, check Viper
 		viperModel := viper.GetString(config.KeyModel)
 		if viperModel != "" {
 			modelValue = viperModel
 		}
 	}
+	return modelValue
+}
 
-	runner := newRunner(
-		modelValue,
-		viper.GetBool(config.KeyQuiet),
-		viper.GetInt(config.KeyMaxIterations),
-		viper.GetBool(config.KeyNoBranch),
-		viper.GetBool(config.KeyNoNewTests),
-		viper.GetBool(config.KeyPR),
-		viper.GetString(config.KeyCommitAuthor),
-	)
-
-	response, err := runner.Generate(cmd.Context(), feature)
-	if err != nil {
-		log.Fatal(err)
+// readCommitTemplate returns the commit template, reading it from a file when value names one.
+func readCommitTemplate(value string) string {
+	if content, err := readFeatureFromFile(value); err == nil {
+		return content
 	}
-
-	fmt.Println(response)
+	return value
 }
 
 // readFeatureFromFile attempts to read feature content from a file.
@@ -6,14 +6,20 @@ package cmd
 import (
 	"bytes"
 	"context"
-	"gonzo/pkg/gonzo"
+	"encoding/json"
+	"errors"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // mockRunner implements gonzo.Runner for testing.
@@ -25,11 +31,25 @@ type mockRunner struct {
 	noNewTests    bool
 	pr            bool
 	commitAuthor  string
+	conventional  bool
+	commitTmpl    string
+	wrapUpIter    int
+	prOptions     gonzo.PROptions
+	settings      map[string]interface{}
+	issue         *forge.Issue
+	forge         string
+	ci            string
+	vars          map[string]string
+	playbook      string
+	opts          []gonzo.Option
 	response      string
 	err           error
 	// Captured values
 	capturedPrompt string
 	generateCalled bool
+	planCalled     bool
+	dryRunCalled   bool
+	dryRunPlan     bool
 }
 
 func (m *mockRunner) Generate(ctx context.Context, prompt string) (string, error) {
@@ -38,16 +58,58 @@ func (m *mockRunner) Generate(ctx context.Context, prompt string) (string, error
 	return m.response, m.err
 }
 
+func (m *mockRunner) Plan(ctx context.Context, feature string) (string, error) {
+	m.capturedPrompt = feature
+	m.planCalled = true
+	return m.response, m.err
+}
+
+func (m *mockRunner) DryRun(ctx context.Context, feature string, plan bool) (*gonzo.DryRun, error) {
+	m.capturedPrompt = feature
+	m.dryRunCalled = true
+	m.dryRunPlan = plan
+	d := &gonzo.DryRun{Model: m.model, SystemPrompt: "system", Prompt: feature, Args: []string{"--print", feature}}
+	if plan {
+		d.Plan = m.response
+	}
+	return d, m.err
+}
+
+func (m *mockRunner) Estimate(ctx context.Context, feature string) (*gonzo.Estimate, error) {
+	m.capturedPrompt = feature
+	return &gonzo.Estimate{Model: m.model, MinIterations: 1, MaxIterations: m.maxIterations}, m.err
+}
+
 // mockRunnerFactory creates a factory function that returns a mock runner and captures options.
-func mockRunnerFactory(mock *mockRunner) func(model string, quiet bool, maxIter int, noBranch bool, noNewTests bool, pr bool, commitAuthor string) gonzo.Runner {
-	return func(model string, quiet bool, maxIter int, noBranch bool, noNewTests bool, pr bool, commitAuthor string) gonzo.Runner {
-		mock.model = model
-		mock.quiet = quiet
-		mock.maxIterations = maxIter
-		mock.noBranch = noBranch
-		mock.noNewTests = noNewTests
-		mock.pr = pr
-		mock.commitAuthor = commitAuthor
+func mockRunnerFactory(mock *mockRunner) func(dir string, cfg *config.Config, settings map[string]interface{}, issue *forge.Issue, opts ...gonzo.Option) gonzo.Runner {
+	return func(dir string, cfg *config.Config, settings map[string]interface{}, issue *forge.Issue, opts ...gonzo.Option) gonzo.Runner {
+		mock.opts = opts
+		mock.model = cfg.Model
+		mock.quiet = cfg.Quiet
+		mock.maxIterations = cfg.MaxIterations
+		mock.noBranch = cfg.NoBranch
+		mock.noNewTests = cfg.NoNewTests
+		mock.pr = cfg.PR
+		mock.commitAuthor = cfg.CommitAuthor
+		mock.conventional = cfg.ConventionalCommits
+		mock.commitTmpl = cfg.CommitTemplate
+		mock.wrapUpIter = cfg.WrapUpIterations
+		mock.prOptions = gonzo.PROptions{
+			Draft:     cfg.PRDraft,
+			Labels:    cfg.PRLabels,
+			Reviewers: cfg.PRReviewers,
+			Assignees: cfg.PRAssignees,
+			Comment:   cfg.PRComment,
+
+			CloseKeyword:     cfg.PRCloseKeyword,
+			TitleIssuePrefix: cfg.PRTitleIssuePrefix,
+		}
+		mock.settings = settings
+		mock.issue = issue
+		mock.forge = cfg.Forge
+		mock.ci = cfg.CI
+		mock.vars = cfg.Vars
+		mock.playbook = cfg.Playbook
 		return mock
 	}
 }
@@ -989,6 +1051,244 @@ func TestRunClaudePrompt_CommitAuthorFlagShort(t *testing.T) {
 	}
 }
 
+func TestRunClaudePrompt_ConventionalCommitsFlag(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	originalConventionalCommits := conventionalCommits
+	defer func() {
+		newRunner = originalNewRunner
+		conventionalCommits = originalConventionalCommits
+	}()
+
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	// Capture stdout
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	_, _, err := executeCommandC(rootCmd, "--conventional-commits=false", "test prompt")
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mock.conventional {
+		t.Errorf("expected conventionalCommits false, got %v", mock.conventional)
+	}
+}
+
+func TestRunClaudePrompt_CommitTemplateFile(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	originalCommitTemplate := commitTemplate
+	defer func() {
+		newRunner = originalNewRunner
+		commitTemplate = originalCommitTemplate
+	}()
+
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	tmplPath := filepath.Join(t.TempDir(), "commit.tmpl")
+	if err := os.WriteFile(tmplPath, []byte("{{ .Type }}: {{ .Subject }}\n"), 0644); err != nil {
+		t.Fatalf("failed to create template file: %v", err)
+	}
+
+	// Capture stdout
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	_, _, err := executeCommandC(rootCmd, "--commit-template", tmplPath, "test prompt")
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mock.commitTmpl != "{{ .Type }}: {{ .Subject }}" {
+		t.Errorf("expected commit template to be read from file, got %q", mock.commitTmpl)
+	}
+}
+
+func TestRunClaudePrompt_WrapUpIterationsFlag(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	originalWrapUpIterations := wrapUpIterations
+	defer func() {
+		newRunner = originalNewRunner
+		wrapUpIterations = originalWrapUpIterations
+	}()
+
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	// Capture stdout
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	_, _, err := executeCommandC(rootCmd, "--wrap-up-iterations", "3", "test prompt")
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mock.wrapUpIter != 3 {
+		t.Errorf("expected wrapUpIterations 3, got %d", mock.wrapUpIter)
+	}
+}
+
+func TestRunClaudePrompt_ForgeAndCIFlags(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	originalForgeName, originalCIMode := forgeName, ciMode
+	defer func() {
+		newRunner = originalNewRunner
+		forgeName, ciMode = originalForgeName, originalCIMode
+	}()
+
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	// Capture stdout
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	_, _, err := executeCommandC(rootCmd, "--forge", "gitlab", "--ci=github", "test prompt")
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mock.forge != forge.GitLab {
+		t.Errorf("expected forge %q, got %q", forge.GitLab, mock.forge)
+	}
+	if mock.ci != gonzo.CIGitHub {
+		t.Errorf("expected CI %q, got %q", gonzo.CIGitHub, mock.ci)
+	}
+}
+
+func TestRunClaudePrompt_SetOverrides(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	originalSetOverrides := setOverrides
+	originalModel := llmModel
+	defer func() {
+		newRunner = originalNewRunner
+		setOverrides = originalSetOverrides
+		llmModel = originalModel
+		viper.Reset()
+	}()
+
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	// Capture stdout
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	_, _, err := executeCommandC(rootCmd, "-m", "claude-sonnet-4-5", "--set", "model=claude-haiku-4-5", "--set", "max-iterations=7", "test prompt")
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mock.maxIterations != 7 {
+		t.Errorf("expected maxIterations 7 from --set, got %d", mock.maxIterations)
+	}
+	if mock.model != "claude-haiku-4-5" {
+		t.Errorf("expected --set to take precedence over --model, got %q", mock.model)
+	}
+}
+
+func TestRunClaudePrompt_PROptionsFlags(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	originalPRDraft, originalPRLabels, originalPRReviewers, originalPRAssignees := prDraft, prLabels, prReviewers, prAssignees
+	originalPRComment := prComment
+	defer func() {
+		newRunner = originalNewRunner
+		prDraft, prLabels, prReviewers, prAssignees = originalPRDraft, originalPRLabels, originalPRReviewers, originalPRAssignees
+		prComment = originalPRComment
+	}()
+
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	// Capture stdout
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	_, _, err := executeCommandC(rootCmd,
+		"--pr-draft",
+		"--pr-label", "gonzo", "--pr-label", "needs-triage",
+		"--pr-reviewer", "octocat",
+		"--pr-assignee", "hubot",
+		"--pr-comment",
+		"test prompt")
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !mock.prOptions.Draft {
+		t.Error("expected draft pull request")
+	}
+	if strings.Join(mock.prOptions.Labels, ",") != "gonzo,needs-triage" {
+		t.Errorf("expected labels [gonzo needs-triage], got %v", mock.prOptions.Labels)
+	}
+	if strings.Join(mock.prOptions.Reviewers, ",") != "octocat" {
+		t.Errorf("expected reviewers [octocat], got %v", mock.prOptions.Reviewers)
+	}
+	if strings.Join(mock.prOptions.Assignees, ",") != "hubot" {
+		t.Errorf("expected assignees [hubot], got %v", mock.prOptions.Assignees)
+	}
+	if !mock.prOptions.Comment {
+		t.Error("expected a pull request comment")
+	}
+}
+
 func TestRunClaudePrompt_WithFeatureFile(t *testing.T) {
 	// Save original and restore after test
 	originalNewRunner := newRunner
@@ -1176,6 +1476,89 @@ func TestRunClaudePrompt_DirectoryNotReadAsFile(t *testing.T) {
 	}
 }
 
+func TestRunClaudePrompt_Vars(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	originalTemplateVars := templateVars
+	defer func() {
+		newRunner = originalNewRunner
+		templateVars = originalTemplateVars
+		viper.Reset()
+	}()
+
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("gonzo.yaml", []byte("vars:\n  project: gonzo\n  language: Go\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	if _, _, err := executeCommandC(rootCmd, "--var", "language=Rust", "--var", "style=https://example.com/style", "test prompt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"project": "gonzo", "language": "Rust", "style": "https://example.com/style"}
+	if !reflect.DeepEqual(mock.vars, want) {
+		t.Errorf("expected vars %v, got %v", want, mock.vars)
+	}
+}
+
+func TestRunClaudePrompt_Playbook(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	originalPlaybookName := playbookName
+	defer func() {
+		newRunner = originalNewRunner
+		playbookName = originalPlaybookName
+		viper.Reset()
+	}()
+
+	t.Chdir(t.TempDir())
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+	// An earlier --no-new-tests=false would win over the playbook default
+	noNewTestsFlag := rootCmd.PersistentFlags().Lookup("no-new-tests")
+	noNewTestsFlag.Changed = false
+	_ = noNewTestsFlag.Value.Set("false")
+
+	if _, _, err := executeCommandC(rootCmd, "--playbook", "docs", "document the flags"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mock.playbook != "docs" || !mock.noNewTests {
+		t.Errorf("expected the docs playbook without new tests, got playbook %q and no-new-tests %v", mock.playbook, mock.noNewTests)
+	}
+}
+
+func TestRunClaudePrompt_FrontMatter(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	defer func() {
+		newRunner = originalNewRunner
+		viper.Reset()
+	}()
+
+	t.Chdir(t.TempDir())
+	featureFile := "feature.md"
+	content := "---\nmodel: claude-sonnet-4-5\nmax-iterations: 3\n---\nAdd a login button\n"
+	if err := os.WriteFile(featureFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write feature file: %v", err)
+	}
+
+	var calls []string
+	newRunner = batchRunnerFactory(&calls)
+
+	// The front matter wins over flags
+	if _, _, err := executeCommandC(rootCmd, "--model", "claude-haiku-4-5", "--max-iterations", "7", featureFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "Add a login button claude-sonnet-4-5 3"
+	if len(calls) != 1 || calls[0] != want {
+		t.Errorf("expected run %q, got %q", want, calls)
+	}
+}
+
 func TestReadFeatureFromFile(t *testing.T) {
 	t.Run("reads regular file", func(t *testing.T) {
 		tmpDir := t.TempDir()
@@ -1211,3 +1594,154 @@ func TestReadFeatureFromFile(t *testing.T) {
 		}
 	})
 }
+
+func TestInitConfig_StrictConfig(t *testing.T) {
+	defer viper.Reset()
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("gonzo.yaml", []byte("max_iterations: 5\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	_, _, err := executeCommandC(rootCmd, "explain", "model")
+	if err == nil || !strings.Contains(err.Error(), `did you mean "max-iterations"?`) {
+		t.Fatalf("expected an unknown key error, got %v", err)
+	}
+
+	t.Setenv("GONZO_STRICT_CONFIG", "false")
+	_, output, err := executeCommandC(rootCmd, "explain", "model")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "Warning: ignoring invalid configuration") {
+		t.Errorf("expected a warning, got %q", output)
+	}
+}
+
+func TestRunClaudePrompt_OutputJSON(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	defer func() {
+		newRunner = originalNewRunner
+		outputFlag := rootCmd.PersistentFlags().Lookup("output")
+		_ = outputFlag.Value.Set(config.DefaultOutput)
+		outputFlag.Changed = false
+		viper.Reset()
+	}()
+
+	t.Chdir(t.TempDir())
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	_, output, err := executeCommandC(rootCmd, "--output", "json", "add a login button")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var summary gonzo.RunSummary
+	if err := json.Unmarshal([]byte(output), &summary); err != nil {
+		t.Fatalf("expected a JSON summary on stdout, got %q: %v", output, err)
+	}
+	if summary.Status != gonzo.RunStatusCompleted || summary.Response != "mocked response" || summary.Feature != "add a login button" {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
+
+func TestRunClaudePrompt_ExitCode(t *testing.T) {
+	// Save originals and restore after test
+	originalNewRunner := newRunner
+	originalExit := exit
+	defer func() {
+		newRunner = originalNewRunner
+		exit = originalExit
+		viper.Reset()
+	}()
+
+	t.Chdir(t.TempDir())
+	mock := &mockRunner{err: &gonzo.ExitError{Code: gonzo.ExitCLIFailure, Err: errors.New("Claude CLI call failed at iteration 1")}}
+	newRunner = mockRunnerFactory(mock)
+	code := -1
+	exit = func(c int) { code = c }
+
+	if _, _, err := executeCommandC(rootCmd, "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != gonzo.ExitCLIFailure {
+		t.Errorf("expected exit code %d, got %d", gonzo.ExitCLIFailure, code)
+	}
+}
+
+func TestRunClaudePrompt_OutputAndReportFiles(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	defer func() {
+		newRunner = originalNewRunner
+		for _, name := range []string{"output-file", "report-file"} {
+			flag := rootCmd.PersistentFlags().Lookup(name)
+			_ = flag.Value.Set("")
+			flag.Changed = false
+		}
+		viper.Reset()
+	}()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	if _, _, err := executeCommandC(rootCmd, "--output-file", "out/response.txt", "--report-file", "report.json", "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	response, err := os.ReadFile(filepath.Join(dir, "out", "response.txt"))
+	if err != nil || string(response) != "mocked response\n" {
+		t.Errorf("expected the response in the output file, got %q (%v)", response, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "report.json"))
+	if err != nil {
+		t.Fatalf("expected a report file: %v", err)
+	}
+	var summary gonzo.RunSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("expected a JSON summary in the report file, got %q: %v", data, err)
+	}
+	if summary.Status != gonzo.RunStatusCompleted || summary.Response != "mocked response" {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
+
+func TestRunClaudePrompt_WorkDir(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	defer func() {
+		newRunner = originalNewRunner
+		flag := rootCmd.PersistentFlags().Lookup("cwd")
+		_ = flag.Value.Set("")
+		flag.Changed = false
+		viper.Reset()
+	}()
+
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "gonzo.yaml"), []byte("max-iterations: 7\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Chdir(t.TempDir())
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	if _, _, err := executeCommandC(rootCmd, "-C", repo, "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mock.maxIterations != 7 {
+		t.Errorf("expected the config of the repository in -C to be used, got %d iterations", mock.maxIterations)
+	}
+	if dir, _ := os.Getwd(); !sameDir(dir, repo) {
+		t.Errorf("expected to work in %s, got %s", repo, dir)
+	}
+}
+
+// sameDir reports whether a and b are the same directory, through symlinks.
+func sameDir(a string, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// sandboxCommand returns the command running gonzo with args in the sandbox. Replaceable for
+// testing.
+var sandboxCommand = func(ctx context.Context, sandbox gonzo.DockerSandbox, args []string) (*exec.Cmd, error) {
+	return sandbox.Command(ctx, args)
+}
+
+// sandboxDropped are the config keys left out of the configuration of a sandboxed run, besides the
+// secrets: the sections already applied on the host.
+var sandboxDropped = []string{config.KeyExtends, config.ProfilesKey, config.KeySandboxImage}
+
+// runInSandbox runs the loop on feature in the Docker sandbox, with the configuration resolved on
+// the host. Nothing leaves the container but the changes to the repository: the pull request is
+// left to open from the host, which holds the forge credentials.
+func runInSandbox(cmd *cobra.Command, feature string) error {
+	ctx := cmd.Context()
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	env, err := sandboxCredentials(ctx)
+	if err != nil {
+		return &gonzo.ExitError{Code: gonzo.ExitPreflight, Err: err}
+	}
+	env = append(env, gitIdentity(ctx, dir)...)
+
+	cfg, err := loadRunConfig(cmd)
+	if err != nil {
+		return &gonzo.ExitError{Code: gonzo.ExitPreflight, Err: err}
+	}
+	tmp, err := os.MkdirTemp("", "gonzo-sandbox-")
+	if err != nil {
+		return fmt.Errorf("failed to write the configuration of the sandbox: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	configFile := filepath.Join(tmp, "gonzo.yaml")
+	if err := writeSandboxConfig(configFile, cfg.Model); err != nil {
+		return &gonzo.ExitError{Code: gonzo.ExitPreflight, Err: err}
+	}
+
+	sandbox := gonzo.DockerSandbox{
+		Image:  cfg.SandboxImage,
+		Dir:    dir,
+		Config: configFile,
+		Env:    env,
+		TTY:    isCharDevice(os.Stdin) && isCharDevice(os.Stdout),
+	}
+	// The binary of the host only runs in the container when built for Linux
+	if executable, err := os.Executable(); err == nil && runtime.GOOS == "linux" {
+		sandbox.Gonzo = executable
+	}
+
+	c, err := sandboxCommand(ctx, sandbox, []string{"--", feature})
+	if err != nil {
+		return &gonzo.ExitError{Code: gonzo.ExitPreflight, Err: err}
+	}
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err = c.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() < 125:
+		// gonzo reported the outcome of the run in the container already
+		return &gonzo.ExitError{Code: exitErr.ExitCode(), Err: errors.New("the run in the sandbox did not complete")}
+	}
+	return &gonzo.ExitError{Code: gonzo.ExitPreflight, Err: fmt.Errorf("failed to run the docker sandbox with image %s: %w", sandbox.Image, err)}
+}
+
+// sandboxCredentials returns the variables handing the sandbox the Claude API key from the
+// keyring, unless it is in the environment, which the sandbox is given as is. The Claude login of
+// the host lives in its home directory, out of the container's reach.
+func sandboxCredentials(ctx context.Context) ([]string, error) {
+	for _, name := range gonzo.SandboxEnv {
+		if os.Getenv(name) != "" {
+			return nil, nil
+		}
+	}
+	if key, _ := gonzo.Secret(ctx, gonzo.SecretAnthropicAPIKey); key != "" {
+		return []string{"ANTHROPIC_API_KEY=" + key}, nil
+	}
+	return nil, fmt.Errorf("the docker sandbox needs a Claude API key: set %s or store it with `gonzo secrets set %s`",
+		strings.Join(gonzo.SandboxEnv, " or "), gonzo.SecretAnthropicAPIKey)
+}
+
+// gitIdentity returns the variables giving the commits made in the sandbox the git identity of
+// the host, which lives in its git config.
+func gitIdentity(ctx context.Context, dir string) []string {
+	var env []string
+	for key, names := range map[string][]string{
+		"user.name":  {"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"},
+		"user.email": {"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"},
+	} {
+		out, err := exec.CommandContext(ctx, "git", "-C", dir, "config", key).Output()
+		if value := strings.TrimSpace(string(out)); err == nil && value != "" {
+			for _, name := range names {
+				env = append(env, name+"="+value)
+			}
+		}
+	}
+	return env
+}
+
+// writeSandboxConfig writes the configuration resolved on the host to path, for the run in the
+// sandbox: with model, the sandbox turned off inside it and no pull request.
+func writeSandboxConfig(path string, model string) error {
+	settings := config.SettingsWithoutSecrets()
+	for _, key := range sandboxDropped {
+		delete(settings, key)
+	}
+	settings[config.KeyModel] = model
+	settings[config.KeySandbox] = gonzo.SandboxNone
+	settings[config.KeyPR] = false
+	settings[config.KeyProfile] = ""
+
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to write the configuration of the sandbox: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write the configuration of the sandbox: %w", err)
+	}
+	return nil
+}
+
+// isCharDevice reports whether f is a terminal.
+func isCharDevice(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestRunClaudePrompt_Sandbox(t *testing.T) {
+	// Save originals and restore after test
+	originalNewRunner := newRunner
+	originalSandboxCommand := sandboxCommand
+	originalExit := exit
+	defer func() {
+		newRunner = originalNewRunner
+		sandboxCommand = originalSandboxCommand
+		exit = originalExit
+		for _, name := range []string{"sandbox", "sandbox-image", "max-iterations"} {
+			flag := rootCmd.PersistentFlags().Lookup(name)
+			_ = flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}
+		viper.Reset()
+	}()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("ANTHROPIC_API_KEY", "sk-test")
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	var sandbox gonzo.DockerSandbox
+	var args []string
+	var settings string
+	sandboxCommand = func(ctx context.Context, s gonzo.DockerSandbox, a []string) (*exec.Cmd, error) {
+		sandbox, args = s, a
+		data, err := os.ReadFile(s.Config)
+		if err != nil {
+			t.Fatalf("failed to read the configuration of the sandbox: %v", err)
+		}
+		settings = string(data)
+		return exec.Command("sh", "-c", "exit 2"), nil
+	}
+	code := -1
+	exit = func(c int) { code = c }
+
+	if _, _, err := executeCommandC(rootCmd, "--sandbox", "docker", "--sandbox-image", "golang:1.25", "--max-iterations", "3", "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mock.capturedPrompt != "" {
+		t.Error("expected the loop not to run on the host")
+	}
+	if sandbox.Dir != dir || sandbox.Image != "golang:1.25" {
+		t.Errorf("unexpected sandbox %+v", sandbox)
+	}
+	if !slices.Equal(args, []string{"--", "add a login button"}) {
+		t.Errorf("expected the feature as the argument of gonzo in the sandbox, got %q", args)
+	}
+	for _, want := range []string{config.KeyMaxIterations + ": 3", config.KeySandbox + ": none", config.KeyPR + ": false"} {
+		if !strings.Contains(settings, want) {
+			t.Errorf("expected %q in the configuration of the sandbox, got:\n%s", want, settings)
+		}
+	}
+	if strings.Contains(settings, config.KeySandboxImage) {
+		t.Errorf("expected no sandbox image in the configuration of the sandbox, got:\n%s", settings)
+	}
+	if code != 2 {
+		t.Errorf("expected the exit code of gonzo in the sandbox, got %d", code)
+	}
+	if _, err := os.Stat(sandbox.Config); !os.IsNotExist(err) {
+		t.Error("expected the configuration of the sandbox to be removed")
+	}
+}
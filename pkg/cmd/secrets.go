@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// lookupSecret reads a secret from the keyring or the environment. Replaceable for testing.
+var lookupSecret = gonzo.Secret
+
+// secretsCmd manages the secrets gonzo reads from the system keyring
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage the tokens, API keys and URLs kept in the system keyring",
+	Long: `Secrets stores and removes the credentials gonzo uses in the system keyring
+(macOS Keychain or libsecret on Linux), so they never need to be written to
+gonzo.yaml or a shell profile:
+
+  github, gitlab      forge tokens (also GH_TOKEN, GITHUB_TOKEN, GITLAB_TOKEN)
+  anthropic-api-key   API key for the Claude CLI (also ANTHROPIC_API_KEY)
+  webhook-url         URL run notifications are posted to (also GONZO_WEBHOOK_URL)
+  smtp-password       password of the SMTP server of notification emails (also GONZO_SMTP_PASSWORD)
+
+A secret stored in the keyring takes precedence over its environment variables.`,
+	Args: cobra.NoArgs,
+}
+
+var secretsListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "Show where each secret is read from, without its value",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		for _, name := range gonzo.Secrets() {
+			_, source := lookupSecret(cmd.Context(), name)
+			switch source {
+			case "":
+				source = "not set"
+			case gonzo.TokenSourceKeyring:
+				source = "system keyring"
+			default:
+				source = "env " + source
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\n", name, source)
+		}
+		return w.Flush()
+	},
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Store a secret in the system keyring",
+	Long: `Set reads the value of a secret from standard input and stores it in the
+system keyring:
+
+  echo "$ANTHROPIC_API_KEY" | gonzo secrets set anthropic-api-key`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSecrets,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := checkSecretName(name); err != nil {
+			return err
+		}
+
+		cmd.Printf("Paste the value of %s: ", name)
+		value, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return errors.New("no value provided on standard input")
+		}
+		cmd.Println()
+
+		if err := storeToken(cmd.Context(), name, value); err != nil {
+			return fmt.Errorf("failed to store %s (set %s instead): %w", name, strings.Join(gonzo.SecretEnv[name], " or "), err)
+		}
+		cmd.Printf("Stored %s in the system keyring\n", name)
+		return nil
+	},
+}
+
+var secretsDeleteCmd = &cobra.Command{
+	Use:               "delete <name>",
+	Short:             "Remove a secret from the system keyring",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSecrets,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := checkSecretName(name); err != nil {
+			return err
+		}
+		if err := removeToken(cmd.Context(), name); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+		cmd.Printf("Removed %s from the system keyring\n", name)
+		return nil
+	},
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsListCmd, secretsSetCmd, secretsDeleteCmd)
+	rootCmd.AddCommand(secretsCmd)
+}
+
+// checkSecretName rejects names of secrets gonzo does not read.
+func checkSecretName(name string) error {
+	if !gonzo.IsSecret(name) {
+		return fmt.Errorf("unknown secret %q (known: %s)", name, strings.Join(gonzo.Secrets(), ", "))
+	}
+	return nil
+}
+
+// completeSecrets completes the names of the secrets gonzo reads.
+func completeSecrets(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return gonzo.Secrets(), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"strings"
+	"testing"
+)
+
+func TestSecretsSetAndDelete(t *testing.T) {
+	fake := &fakeAuth{}
+	withFakeAuth(t, fake)
+
+	rootCmd.SetIn(strings.NewReader("sk-123\n"))
+	_, output, err := executeCommandC(rootCmd, "secrets", "set", gonzo.SecretAnthropicAPIKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.stored[gonzo.SecretAnthropicAPIKey] != "sk-123" {
+		t.Errorf("expected the secret to be stored, got %v", fake.stored)
+	}
+	if !strings.Contains(output, "Stored anthropic-api-key in the system keyring") {
+		t.Errorf("unexpected output %q", output)
+	}
+
+	if _, _, err := executeCommandC(rootCmd, "secrets", "delete", gonzo.SecretAnthropicAPIKey); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := fake.stored[gonzo.SecretAnthropicAPIKey]; ok {
+		t.Error("expected the secret to be removed")
+	}
+}
+
+func TestSecretsSet_UnknownSecret(t *testing.T) {
+	withFakeAuth(t, &fakeAuth{})
+
+	rootCmd.SetIn(strings.NewReader("hunter2\n"))
+	_, _, err := executeCommandC(rootCmd, "secrets", "set", "password")
+	if err == nil || !strings.Contains(err.Error(), `unknown secret "password"`) {
+		t.Errorf("expected an unknown secret error, got %v", err)
+	}
+}
+
+func TestSecretsList(t *testing.T) {
+	withFakeAuth(t, &fakeAuth{})
+	original := lookupSecret
+	t.Cleanup(func() { lookupSecret = original })
+	lookupSecret = func(ctx context.Context, name string) (string, string) {
+		switch name {
+		case gonzo.SecretAnthropicAPIKey:
+			return "sk-123", gonzo.TokenSourceKeyring
+		case "github":
+			return "ghp-123", "GH_TOKEN"
+		}
+		return "", ""
+	}
+
+	_, output, err := executeCommandC(rootCmd, "secrets", "list")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"anthropic-api-key  system keyring", "github             env GH_TOKEN", "webhook-url        not set"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in %q", want, output)
+		}
+	}
+	if strings.Contains(output, "sk-123") || strings.Contains(output, "ghp-123") {
+		t.Errorf("expected the values to stay hidden, got %q", output)
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var showIteration int
+
+// showCmd prints the transcript of a previous run
+var showCmd = &cobra.Command{
+	Use:   "show [run-id]",
+	Short: "Show the transcript of a gonzo run",
+	Long: `Show prints a readable transcript of a recorded run: for every iteration the
+prompt sent to Claude, its response, the changes made to the repository and the
+output of the verification commands. Without a run ID the most recent run is
+shown. Use --iteration to show a single iteration.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeRunIDs,
+	SilenceUsage:      true,
+	RunE:              runShow,
+}
+
+func init() {
+	showCmd.Flags().IntVar(
+		&showIteration,
+		"iteration", 0,
+		"Show only the given iteration")
+
+	rootCmd.AddCommand(showCmd)
+}
+
+func runShow(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	var state *gonzo.RunState
+	if len(args) == 1 {
+		state, err = gonzo.ResolveRunState(dir, args[0])
+	} else {
+		state, err = gonzo.LatestRunState(dir)
+	}
+	if err != nil {
+		return err
+	}
+
+	iterations, err := gonzo.LoadIterations(dir, state)
+	if err != nil {
+		return err
+	}
+
+	transcript, err := gonzo.RenderTranscript(state, iterations, showIteration)
+	if err != nil {
+		return err
+	}
+	cmd.Print(transcript)
+	return nil
+}
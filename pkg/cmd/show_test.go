@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShow(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	defer func() { showIteration = 0 }()
+
+	run := &gonzo.RunState{ID: "run-1", Feature: "add login", Status: gonzo.RunStatusCompleted, Iterations: 2, StartedAt: time.Now()}
+	if err := run.Save(dir); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	for i, response := range []string{"added the form", "wrapped up"} {
+		if err := run.SaveIterationArtifact(dir, i+1, gonzo.IterationResponseArtifact, []byte(response)); err != nil {
+			t.Fatalf("SaveIterationArtifact() returned error: %v", err)
+		}
+	}
+
+	_, output, err := executeCommandC(rootCmd, "show")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "Run run-1") || !strings.Contains(output, "added the form") || !strings.Contains(output, "wrapped up") {
+		t.Errorf("expected the latest run's transcript, got %q", output)
+	}
+
+	_, output, err = executeCommandC(rootCmd, "show", "run-1", "--iteration", "2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(output, "added the form") || !strings.Contains(output, "wrapped up") {
+		t.Errorf("expected only iteration 2, got %q", output)
+	}
+
+	if _, _, err := executeCommandC(rootCmd, "show", "missing"); err == nil {
+		t.Error("expected error for an unknown run")
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var statsJSON bool
+var statsCSV bool
+
+// statsCmd aggregates metrics across recorded runs
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Print metrics aggregated across the runs recorded in this repository",
+	Long: `Stats aggregates the finished runs recorded in .gonzo/runs: how many
+completed, the success rate, the average number of iterations to completion,
+the average duration and the average cost, over all runs and per model.
+
+Costs cover the runs that recorded one. Use --json or --csv to export the
+metrics.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runStats,
+}
+
+func init() {
+	statsCmd.Flags().BoolVar(
+		&statsJSON,
+		"json", false,
+		"Print the metrics as JSON")
+
+	statsCmd.Flags().BoolVar(
+		&statsCSV,
+		"csv", false,
+		"Print the metrics as CSV")
+
+	statsCmd.MarkFlagsMutuallyExclusive("json", "csv")
+	rootCmd.AddCommand(statsCmd)
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	states, err := gonzo.ListRunStates(dir)
+	if err != nil {
+		return err
+	}
+	stats := gonzo.ComputeStats(states)
+
+	switch {
+	case statsJSON:
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode stats: %w", err)
+		}
+		cmd.Println(string(data))
+	case statsCSV:
+		w := csv.NewWriter(cmd.OutOrStdout())
+		_ = w.Write([]string{"model", "runs", "completed", "incomplete", "failed", "aborted", "rolled_back", "success_rate",
+			"avg_iterations_to_completion", "avg_duration_seconds", "costed_runs", "total_cost_usd", "avg_cost_usd"})
+		for _, s := range stats {
+			_ = w.Write([]string{s.Model, strconv.Itoa(s.Runs), strconv.Itoa(s.Completed), strconv.Itoa(s.Incomplete),
+				strconv.Itoa(s.Failed), strconv.Itoa(s.Aborted), strconv.Itoa(s.RolledBack), formatFloat(s.SuccessRate),
+				formatFloat(s.AvgIterationsToCompletion), formatFloat(s.AvgDurationSeconds), strconv.Itoa(s.CostedRuns),
+				formatFloat(s.TotalCost), formatFloat(s.AvgCost)})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return fmt.Errorf("failed to write stats: %w", err)
+		}
+	default:
+		if stats[0].Runs == 0 {
+			cmd.Println("No finished runs recorded in this repository")
+			return nil
+		}
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MODEL\tRUNS\tCOMPLETED\tSUCCESS\tAVG ITERATIONS\tAVG DURATION\tAVG COST")
+		for _, s := range stats {
+			avgCost := "-"
+			if s.CostedRuns > 0 {
+				avgCost = fmt.Sprintf("$%.2f", s.AvgCost)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%.0f%%\t%.1f\t%s\t%s\n",
+				s.Model, s.Runs, s.Completed, s.SuccessRate*100, s.AvgIterationsToCompletion,
+				(time.Duration(s.AvgDurationSeconds) * time.Second).Round(time.Second), avgCost)
+		}
+		_ = w.Flush()
+	}
+	return nil
+}
+
+// formatFloat formats a metric for CSV output.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	// Restore flags after test
+	defer func() {
+		statsJSON = false
+		statsCSV = false
+		statsCmd.Flags().Lookup("json").Changed = false
+		statsCmd.Flags().Lookup("csv").Changed = false
+	}()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	_, output, err := executeCommandC(rootCmd, "stats")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "No finished runs") {
+		t.Errorf("unexpected output %q", output)
+	}
+
+	finishedAt := time.Now()
+	for i, status := range []string{gonzo.RunStatusCompleted, gonzo.RunStatusFailed} {
+		state := &gonzo.RunState{ID: "20260101-00000" + string(rune('0'+i)) + "-aaaaaa", Model: gonzo.ClaudeSonnet, Status: status,
+			Iterations: 3, StartedAt: finishedAt.Add(-time.Minute), FinishedAt: &finishedAt}
+		if err := state.Save(dir); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+
+	_, output, err = executeCommandC(rootCmd, "stats")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "claude-sonnet-4-5") || !strings.Contains(output, "50%") {
+		t.Errorf("expected a table with the success rate, got %q", output)
+	}
+
+	_, output, err = executeCommandC(rootCmd, "stats", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var stats []gonzo.Stats
+	if err := json.Unmarshal([]byte(output), &stats); err != nil {
+		t.Fatalf("expected JSON output, got %q: %v", output, err)
+	}
+	if len(stats) != 2 || stats[0].Runs != 2 || stats[0].Completed != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	// Flags stay set between executions of the same command
+	statsJSON = false
+	statsCmd.Flags().Lookup("json").Changed = false
+
+	_, output, err = executeCommandC(rootCmd, "stats", "--csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "model,runs,completed") || !strings.HasPrefix(lines[1], "all,2,1,") {
+		t.Errorf("unexpected CSV output %q", output)
+	}
+}
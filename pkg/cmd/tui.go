@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+// tuiLogLines is the number of log lines kept for display.
+const tuiLogLines = 200
+
+// tuiCmd runs the loop behind an interactive terminal UI
+var tuiCmd = &cobra.Command{
+	Use:   "tui [flags] feature",
+	Short: "Run gonzo with an interactive terminal UI",
+	Long: `Tui runs the loop like gonzo does, behind a terminal UI showing the iteration
+progress, the changes made so far, the cost when known, the log and Claude's
+latest response.
+
+Keys:
+  p       pause before the next iteration, or resume
+  a       stop after the current iteration
+  +       add an iteration to the budget
+  y / n   approve or decline opening the pull request, when asked
+  q       quit once the run is over
+  ctrl+c  cancel the run; press again to quit right away`,
+	Args:         cobra.ArbitraryArgs,
+	SilenceUsage: true,
+	RunE:         runTUI,
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	feature, fm, err := readFeature(args)
+	if err != nil {
+		return err
+	}
+	if feature == "" {
+		return errors.New("no feature given")
+	}
+	if err := applyFrontMatter(cmd, fm); err != nil {
+		return err
+	}
+
+	// The UI owns the terminal: the run reports through events only
+	config.ApplySettings(map[string]interface{}{config.KeyQuiet: true})
+	cfg, err := loadRunConfig(cmd)
+	if err != nil {
+		return err
+	}
+	runner, err := buildRunner(cmd, nil)
+	if err != nil {
+		return err
+	}
+	observer, ok := gonzo.RunnerAs[gonzo.Observer](runner)
+	if !ok {
+		return errors.New("the configured runner cannot be observed")
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	control := gonzo.NewControl()
+	control.RequirePRApproval = cfg.PR
+
+	model := newTUIModel(feature, cfg.MaxIterations, control, cancel)
+	program := tea.NewProgram(model, tea.WithAltScreen(), tea.WithOutput(cmd.OutOrStdout()))
+
+	observer.Observe(func(e gonzo.Event) { program.Send(tuiEventMsg(e)) }, control)
+	go func() {
+		response, err := runner.Generate(ctx, feature)
+		program.Send(tuiDoneMsg{response: response, err: err})
+	}()
+
+	final, err := program.Run()
+	if err != nil {
+		return fmt.Errorf("terminal UI failed: %w", err)
+	}
+
+	m := final.(*tuiModel)
+	if m.err != nil {
+		return m.err
+	}
+	cmd.Println(m.response)
+	return nil
+}
+
+// tuiEventMsg delivers a run event to the UI.
+type tuiEventMsg gonzo.Event
+
+// tuiDoneMsg reports that the run returned.
+type tuiDoneMsg struct {
+	response string
+	err      error
+}
+
+// tuiModel is the state of the terminal UI.
+type tuiModel struct {
+	feature string
+	control *gonzo.Control
+	cancel  context.CancelFunc
+
+	runID         string
+	iteration     int
+	maxIterations int
+	extension     int
+	status        string
+	diffStat      string
+	costUSD       float64
+	logs          []string
+	output        string
+
+	stopping         bool
+	awaitingApproval bool
+	prTitle          string
+	interrupted      bool
+
+	done     bool
+	response string
+	err      error
+
+	width  int
+	height int
+}
+
+func newTUIModel(feature string, maxIterations int, control *gonzo.Control, cancel context.CancelFunc) *tuiModel {
+	return &tuiModel{
+		feature:       feature,
+		control:       control,
+		cancel:        cancel,
+		maxIterations: maxIterations,
+		status:        "starting",
+		width:         80,
+		height:        24,
+	}
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+	case tuiEventMsg:
+		m.handleEvent(gonzo.Event(msg))
+
+	case tuiDoneMsg:
+		m.done = true
+		m.awaitingApproval = false
+		m.response, m.err = msg.response, msg.err
+		if m.err != nil && m.status == "running" {
+			m.status = gonzo.RunStatusFailed
+		}
+		if m.interrupted {
+			return m, tea.Quit
+		}
+
+	case tea.KeyMsg:
+		return m, m.handleKey(msg.String())
+	}
+	return m, nil
+}
+
+// handleEvent updates the model from a run event.
+func (m *tuiModel) handleEvent(e gonzo.Event) {
+	switch e.Type {
+	case gonzo.EventRunStart:
+		m.runID = e.RunID
+		m.maxIterations = e.MaxIterations
+		m.status = "running"
+	case gonzo.EventIterationStart:
+		m.iteration = e.Iteration
+		m.maxIterations = e.MaxIterations
+		m.extension = 0
+	case gonzo.EventIterationEnd:
+		m.output = e.Output
+		m.diffStat = e.DiffStat
+		m.costUSD = e.CostUSD
+	case gonzo.EventLog:
+		m.logs = append(m.logs, e.Message)
+		if len(m.logs) > tuiLogLines {
+			m.logs = m.logs[len(m.logs)-tuiLogLines:]
+		}
+	case gonzo.EventPRApproval:
+		m.awaitingApproval = true
+		m.prTitle = e.Message
+	case gonzo.EventRunEnd:
+		m.status = e.Status
+		m.costUSD = e.CostUSD
+		m.awaitingApproval = false
+	}
+}
+
+// handleKey applies a key binding.
+func (m *tuiModel) handleKey(key string) tea.Cmd {
+	switch key {
+	case "ctrl+c":
+		if m.done || m.interrupted {
+			return tea.Quit
+		}
+		m.interrupted = true
+		m.cancel()
+	case "q":
+		if m.done {
+			return tea.Quit
+		}
+	case "p":
+		if m.done {
+			break
+		}
+		if m.control.Paused() {
+			m.control.Resume()
+		} else {
+			m.control.Pause()
+		}
+	case "a":
+		if !m.done {
+			m.control.Stop()
+			m.stopping = true
+		}
+	case "+":
+		if !m.done {
+			m.control.Extend(1)
+			m.extension++
+		}
+	case "y", "n":
+		if m.awaitingApproval {
+			m.control.Approve(key == "y")
+			m.awaitingApproval = false
+		}
+	}
+	return nil
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+
+	status := m.status
+	switch {
+	case m.interrupted && !m.done:
+		status = "cancelling"
+	case m.stopping && !m.done:
+		status = "stopping after this iteration"
+	case m.control.Paused() && !m.done:
+		status = "paused before the next iteration"
+	}
+	fmt.Fprintf(&b, "gonzo  %s  %s\n", m.runID, status)
+	fmt.Fprintf(&b, "Feature: %s\n\n", truncate(firstLine(m.feature), m.width-9))
+
+	fmt.Fprintf(&b, "Iteration %d/%d", m.iteration, m.maxIterations+m.extension)
+	if m.extension > 0 {
+		fmt.Fprintf(&b, " (+%d)", m.extension)
+	}
+	fmt.Fprintf(&b, "  %s\n", progressBar(m.iteration, m.maxIterations+m.extension, 30))
+	fmt.Fprintf(&b, "Changes: %s\n", orDash(m.diffStat))
+	if m.costUSD > 0 {
+		fmt.Fprintf(&b, "Cost:    $%.2f\n", m.costUSD)
+	} else {
+		b.WriteString("Cost:    not reported\n")
+	}
+
+	// Split the remaining height between the log and Claude's output
+	available := max(m.height-12, 4)
+	logHeight := available / 2
+	outputHeight := available - logHeight
+
+	b.WriteString("\n-- Log --\n")
+	for _, line := range tail(m.logs, logHeight) {
+		b.WriteString(truncate(line, m.width) + "\n")
+	}
+	b.WriteString("\n-- Claude --\n")
+	for _, line := range tail(strings.Split(strings.TrimSpace(m.output), "\n"), outputHeight) {
+		b.WriteString(truncate(line, m.width) + "\n")
+	}
+
+	b.WriteString("\n")
+	switch {
+	case m.awaitingApproval:
+		fmt.Fprintf(&b, "Open the pull request for %q? [y] yes  [n] no\n", truncate(m.prTitle, m.width-40))
+	case m.done:
+		b.WriteString("[q] quit\n")
+	default:
+		b.WriteString("[p] pause/resume  [a] abort  [+] add iteration  [ctrl+c] cancel\n")
+	}
+	return b.String()
+}
+
+// progressBar renders done out of total as a bar of the given width.
+func progressBar(done int, total int, width int) string {
+	if total <= 0 {
+		return "[" + strings.Repeat("-", width) + "]"
+	}
+	filled := min(done*width/total, width)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
+}
+
+// tail returns the last n lines.
+func tail(lines []string, n int) []string {
+	if len(lines) > n {
+		return lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// truncate shortens s to at most width runes.
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if width <= 0 || len(r) <= width {
+		return s
+	}
+	if width == 1 {
+		return "…"
+	}
+	return string(r[:width-1]) + "…"
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func key(s string) tea.KeyMsg {
+	if s == "ctrl+c" {
+		return tea.KeyMsg{Type: tea.KeyCtrlC}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestTUIModel(t *testing.T) {
+	control := gonzo.NewControl()
+	m := newTUIModel("Add a login button\n\nDetails", 5, control, func() {})
+
+	m.Update(tuiEventMsg{Type: gonzo.EventRunStart, RunID: "20260101-000000-aaaaaa", MaxIterations: 5})
+	m.Update(tuiEventMsg{Type: gonzo.EventIterationStart, Iteration: 2, MaxIterations: 5})
+	m.Update(tuiEventMsg{Type: gonzo.EventLog, Message: "Iteration 2 of 5"})
+	m.Update(tuiEventMsg{Type: gonzo.EventIterationEnd, Iteration: 2, Output: "Added the button", DiffStat: "1 file changed, 3 insertions(+)"})
+
+	view := m.View()
+	for _, want := range []string{"20260101-000000-aaaaaa  running", "Feature: Add a login button", "Iteration 2/5", "1 file changed", "Iteration 2 of 5", "Added the button"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the view, got:\n%s", want, view)
+		}
+	}
+
+	m.Update(key("p"))
+	if !control.Paused() || !strings.Contains(m.View(), "paused") {
+		t.Error("expected p to pause the run")
+	}
+	m.Update(key("p"))
+	if control.Paused() {
+		t.Error("expected p to resume the run")
+	}
+
+	m.Update(key("+"))
+	if !strings.Contains(m.View(), "Iteration 2/6 (+1)") {
+		t.Errorf("expected the extension to be shown, got:\n%s", m.View())
+	}
+
+	m.Update(tuiEventMsg{Type: gonzo.EventPRApproval, Message: "Add a login button"})
+	if !strings.Contains(m.View(), "Open the pull request") {
+		t.Errorf("expected an approval prompt, got:\n%s", m.View())
+	}
+	m.Update(key("y"))
+	if m.awaitingApproval {
+		t.Error("expected y to answer the approval")
+	}
+
+	m.Update(tuiEventMsg{Type: gonzo.EventRunEnd, Status: gonzo.RunStatusCompleted})
+	m.Update(tuiDoneMsg{response: "Done"})
+	if _, cmd := m.Update(key("q")); cmd == nil {
+		t.Error("expected q to quit once the run is over")
+	}
+}
+
+func TestTUIModel_Interrupt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := newTUIModel("feature", 5, gonzo.NewControl(), cancel)
+
+	if _, cmd := m.Update(key("q")); cmd != nil {
+		t.Error("expected q to be ignored while the run is in progress")
+	}
+	if _, cmd := m.Update(key("ctrl+c")); cmd != nil || ctx.Err() == nil {
+		t.Error("expected ctrl+c to cancel the run and wait for it")
+	}
+	if _, cmd := m.Update(tuiDoneMsg{err: errors.New("cancelled")}); cmd == nil {
+		t.Error("expected the UI to quit once the cancelled run returns")
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+// Build metadata set by SetBuildInfo.
+var buildCommit, buildDate string
+
+var versionJSON bool
+
+// buildInfo describes the gonzo binary and the Claude CLI it drives.
+type buildInfo struct {
+	Version       string `json:"version"`
+	Commit        string `json:"commit,omitempty"`
+	Date          string `json:"date,omitempty"`
+	GoVersion     string `json:"go_version"`
+	Platform      string `json:"platform"`
+	ClaudeVersion string `json:"claude_version,omitempty"`
+}
+
+// SetBuildInfo records the commit and build date of the binary, shown by `gonzo version`.
+// When empty they are taken from the VCS information embedded by the Go toolchain, if any.
+func SetBuildInfo(commit string, date string) {
+	buildCommit = commit
+	buildDate = date
+}
+
+// versionCmd prints build metadata
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print gonzo's version and build information",
+	Long: `Version prints the gonzo version, the commit and date it was built from, the
+Go version and platform, and the version of the Claude CLI found in PATH. Use
+--json for tooling.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runVersion,
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(
+		&versionJSON,
+		"json", false,
+		"Print the build information as JSON")
+
+	rootCmd.AddCommand(versionCmd)
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	info := buildInfo{
+		Version:   gonzo.Version,
+		Commit:    buildCommit,
+		Date:      buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+	}
+	// A missing Claude CLI is reported as an empty version
+	info.ClaudeVersion, _ = claudeVersion(cmd.Context())
+
+	if versionJSON {
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode build information: %w", err)
+		}
+		cmd.Println(string(data))
+		return nil
+	}
+
+	cmd.Printf("gonzo %s\n", info.Version)
+	if info.Commit != "" {
+		cmd.Printf("  commit:   %s\n", info.Commit)
+	}
+	if info.Date != "" {
+		cmd.Printf("  built:    %s\n", info.Date)
+	}
+	cmd.Printf("  go:       %s\n", info.GoVersion)
+	cmd.Printf("  platform: %s\n", info.Platform)
+	if info.ClaudeVersion != "" {
+		cmd.Printf("  claude:   %s\n", info.ClaudeVersion)
+	} else {
+		cmd.Printf("  claude:   not found\n")
+	}
+	return nil
+}
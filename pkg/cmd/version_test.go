@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	originalClaudeVersion := claudeVersion
+	defer func() {
+		claudeVersion = originalClaudeVersion
+		versionJSON = false
+		SetBuildInfo("", "")
+	}()
+
+	claudeVersion = func(ctx context.Context) (string, error) { return "2.0.14 (Claude Code)", nil }
+	SetBuildInfo("abc1234", "2026-02-01T20:26:13Z")
+
+	_, output, err := executeCommandC(rootCmd, "version", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var info buildInfo
+	if err := json.Unmarshal([]byte(output), &info); err != nil {
+		t.Fatalf("expected JSON output, got %q: %v", output, err)
+	}
+	want := buildInfo{
+		Version:       gonzo.Version,
+		Commit:        "abc1234",
+		Date:          "2026-02-01T20:26:13Z",
+		GoVersion:     runtime.Version(),
+		Platform:      runtime.GOOS + "/" + runtime.GOARCH,
+		ClaudeVersion: "2.0.14 (Claude Code)",
+	}
+	if info != want {
+		t.Errorf("expected %+v, got %+v", want, info)
+	}
+
+	versionJSON = false
+	claudeVersion = func(ctx context.Context) (string, error) { return "", errors.New("not found") }
+	_, output, err = executeCommandC(rootCmd, "version", "--json=false")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"gonzo " + gonzo.Version, "commit:   abc1234", "claude:   not found"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got %q", want, output)
+		}
+	}
+}
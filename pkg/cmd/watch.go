@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var watchInterval time.Duration
+var watchOnce bool
+
+// watchCmd processes feature files dropped in a directory
+var watchCmd = &cobra.Command{
+	Use:   "watch <dir>",
+	Short: "Implement the feature files dropped in a directory, as a job queue",
+	Long: `Watch polls a directory for feature files and runs the loop in the current
+repository for each of them, oldest first. Processed files are moved to the
+done subdirectory, or to the failed subdirectory next to a .error file holding
+the error.
+
+Hidden files are ignored: to avoid picking up a file that is still being
+written, write it under a hidden name and rename it once complete. Every run
+starts from the branch checked out when watching started.
+
+Changes to the config files apply to the runs started after them, for the
+budget and playbook keys (max-iterations, wrap-up-iterations, iteration-timeout,
+max-duration, retries and playbook). Changes to other keys are reported and
+take effect once watch restarts.
+
+Watch runs until interrupted, or until the files present are processed with
+--once.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runWatch,
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(
+		&watchInterval,
+		"interval", 5*time.Second,
+		"How often to check the directory for new feature files")
+
+	watchCmd.Flags().BoolVar(
+		&watchOnce,
+		"once", false,
+		"Process the feature files present and exit")
+
+	rootCmd.AddCommand(watchCmd)
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	queueDir := args[0]
+	if info, err := os.Stat(queueDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", queueDir)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Outside of a git repository there is nothing to return to between runs
+	base, _ := gonzo.BatchBase(ctx, dir)
+
+	defaults, err := watchDefaults(cmd)
+	if err != nil {
+		return err
+	}
+
+	if !watchOnce {
+		cmd.Printf("Watching %s for feature files\n", queueDir)
+	}
+	for {
+		if config.Changed() && reloadWatchConfig(cmd) {
+			if defaults, err = watchDefaults(cmd); err != nil {
+				return err
+			}
+		}
+
+		paths, err := gonzo.PendingFeatureFiles(queueDir)
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err := runFeatureFile(ctx, cmd, dir, base, path, defaults); err != nil {
+				return err
+			}
+		}
+
+		if watchOnce {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(watchInterval):
+		}
+	}
+}
+
+// watchDefaults returns the configured values the front matter of the feature files override,
+// restored after every file.
+func watchDefaults(cmd *cobra.Command) (map[string]interface{}, error) {
+	cfg, err := loadRunConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		config.KeyModel:         cfg.Model,
+		config.KeyMaxIterations: cfg.MaxIterations,
+		config.KeyNoBranch:      cfg.NoBranch,
+		config.KeyPlaybook:      cfg.Playbook,
+	}, nil
+}
+
+// reloadWatchConfig applies the changes made to the config files since they were loaded to
+// the next runs, as far as config.Reload allows, and reports whether it did.
+func reloadWatchConfig(cmd *cobra.Command) bool {
+	applied, ignored, err := config.Reload(func() error { return initConfig(cmd, nil) })
+	if err != nil {
+		cmd.Printf("Keeping the current configuration: %v\n", err)
+		return false
+	}
+	if len(applied) > 0 {
+		cmd.Printf("Reloaded the configuration: %s\n", strings.Join(applied, ", "))
+	}
+	if len(ignored) > 0 {
+		cmd.Printf("Ignoring changes to %s until watch restarts\n", strings.Join(ignored, ", "))
+	}
+	return true
+}
+
+// runFeatureFile runs the loop for a feature file of the queue, with its front matter applied
+// on top of defaults, and moves it out of the way.
+// Only failures to move the file are returned, since they would make watch process it again.
+func runFeatureFile(ctx context.Context, cmd *cobra.Command, dir string, base string, path string, defaults map[string]interface{}) error {
+	cmd.Printf("Running %s\n", path)
+
+	var runErr error
+	var fm *gonzo.FrontMatter
+	feature, err := readFeatureFromFile(path)
+	if err == nil {
+		fm, feature, err = gonzo.ParseFrontMatter(feature)
+	}
+	if fm == nil {
+		fm = &gonzo.FrontMatter{}
+	}
+	switch {
+	case err != nil:
+		runErr = fmt.Errorf("failed to read feature file: %w", err)
+	case feature == "":
+		runErr = errors.New("feature file is empty")
+	case base != "" || fm.BranchName != "":
+		if err := gonzo.CheckoutBatchBranch(ctx, dir, fm.BranchName, base); err != nil {
+			runErr = fmt.Errorf("failed to check out the feature's branch: %w", err)
+		}
+	}
+
+	settings := map[string]interface{}{}
+	for key, value := range defaults {
+		settings[key] = value
+	}
+	for key, value := range frontMatterSettings(fm) {
+		settings[key] = value
+	}
+	config.ApplySettings(settings)
+	defer config.ApplySettings(defaults)
+
+	if runErr == nil {
+		var runner gonzo.Runner
+		runner, runErr = buildRunner(cmd, nil)
+		if runErr == nil {
+			var response string
+			response, runErr = runner.Generate(ctx, feature)
+			if runErr == nil {
+				cmd.Println(response)
+			}
+		}
+	}
+
+	target, err := gonzo.FinishFeatureFile(path, runErr)
+	if err != nil {
+		return err
+	}
+	if runErr != nil {
+		cmd.Printf("Failed %s: %v\n", target, runErr)
+	} else {
+		cmd.Printf("Done %s\n", target)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestWatch_Once(t *testing.T) {
+	// Save original and restore after test
+	originalNewRunner := newRunner
+	defer func() {
+		newRunner = originalNewRunner
+		watchOnce = false
+		viper.Reset()
+	}()
+
+	t.Chdir(t.TempDir())
+	queue := "queue"
+	if err := os.Mkdir(queue, 0755); err != nil {
+		t.Fatalf("failed to create queue: %v", err)
+	}
+	for name, content := range map[string]string{"login.md": "Add a login button", "empty.md": "", ".partial.md": "Not yet"} {
+		if err := os.WriteFile(filepath.Join(queue, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	mock := &mockRunner{response: "mocked response"}
+	newRunner = mockRunnerFactory(mock)
+
+	_, output, err := executeCommandC(rootCmd, "watch", "--once", queue)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mock.capturedPrompt != "Add a login button" {
+		t.Errorf("expected the feature file to be run, got %q", mock.capturedPrompt)
+	}
+	for _, path := range []string{"done/login.md", "failed/empty.md", "failed/empty.md.error", ".partial.md"} {
+		if _, err := os.Stat(filepath.Join(queue, path)); err != nil {
+			t.Errorf("expected %s in the queue: %v", path, err)
+		}
+	}
+	if !strings.Contains(output, "mocked response") || !strings.Contains(output, "feature file is empty") {
+		t.Errorf("unexpected output %q", output)
+	}
+}
@@ -1,16 +1,26 @@
 // Package config provides configuration management for gonzo using Viper.
 // It supports configuration from multiple sources with the following precedence:
-// 1. Command-line flags (highest priority)
+// 1. Command-line flags, with --set key=value overrides taking precedence (highest priority)
 // 2. Environment variables (GONZO_ prefix)
-// 3. Configuration file (~/.gonzo.yaml or ./gonzo.yaml)
-// 4. Default values (lowest priority)
+// 3. The profile selected with --profile, from the profiles section of the configuration files
+// 4. Project configuration files (./gonzo.yaml, then ./.gonzo/config.yaml)
+// 5. User configuration file ($XDG_CONFIG_HOME/gonzo/gonzo.yaml or ~/gonzo.yaml)
+// 6. Default values (lowest priority)
+//
+// Config files may be YAML, TOML or JSON, e.g. gonzo.toml. A config file named with --config
+// or GONZO_CONFIG replaces the user and project files. A config file may extend another one,
+// named by path or URL with the extends key, which is loaded right before it.
+// Load returns the resolved configuration as a typed Config.
 package config
 
 import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -25,6 +35,32 @@ const (
 
 	// ConfigType is the default config file type
 	ConfigType = "yaml"
+
+	// ConfigEnvVar names the environment variable holding the path of the config file to use
+	ConfigEnvVar = EnvPrefix + "_CONFIG"
+
+	// ProjectConfigName is the path of the project configuration file inside .gonzo, relative to
+	// the project root and without extension
+	ProjectConfigName = ".gonzo/config"
+
+	// ProjectConfigFile is the path of the project configuration file, relative to the project root
+	ProjectConfigFile = ProjectConfigName + "." + ConfigType
+)
+
+// ConfigTypes are the config file formats read, by extension, in the order they are looked for
+// when files of several formats share a name.
+var ConfigTypes = []string{"yaml", "yml", "toml", "json"}
+
+// Names of the configuration layers, from the least to the most specific.
+const (
+	LayerUser    = "user"
+	LayerProject = "project"
+
+	// LayerExplicit is the config file named with SetConfigFile or GONZO_CONFIG, loaded alone.
+	LayerExplicit = "explicit"
+
+	// LayerExtends is a config file named by the extends key of another, loaded right before it.
+	LayerExtends = "extends"
 )
 
 // Config keys
@@ -32,12 +68,133 @@ const (
 	KeyModel         = "model"
 	KeyMaxIterations = "max-iterations"
 	KeyQuiet         = "quiet"
+	KeyVerbose       = "verbose"
 	KeyNoBranch      = "no-branch"
 	KeyNoNewTests    = "no-new-tests"
 	KeyPR            = "pr"
 	KeyCommitAuthor  = "commit-author"
+
+	KeyConventionalCommits = "conventional-commits"
+	KeyCommitTemplate      = "commit-template"
+	KeyWrapUpIterations    = "wrap-up-iterations"
+	KeyPRDraft             = "pr-draft"
+	KeyPRLabels            = "pr-labels"
+	KeyPRReviewers         = "pr-reviewers"
+	KeyPRAssignees         = "pr-assignees"
+	KeyPRComment           = "pr-comment"
+	KeyPRCloseKeyword      = "pr-close-keyword"
+	KeyPRTitleIssuePrefix  = "pr-title-issue-prefix"
+	KeyForge               = "forge"
+	KeyCI                  = "ci"
+	KeyProfile             = "profile"
+	KeyStrictConfig        = "strict-config"
+	KeyPlaybook            = "playbook"
+	KeyLanguage            = "language"
+	KeyOutput              = "output"
+	KeyLogFormat           = "log-format"
+	KeyLogLevel            = "log-level"
+	KeyExtends             = "extends"
+	KeyVars                = "vars"
+	KeyNoRepoMap           = "no-repomap"
+	KeyTranscripts         = "transcripts"
+	KeyNoColor             = "no-color"
+	KeyJUnit               = "junit"
+	KeyOutputFile          = "output-file"
+	KeyReportFile          = "report-file"
+	KeyBranchRunID         = "branch-run-id"
+	KeyPermissionMode      = "permission-mode"
+	KeySandbox             = "sandbox"
+	KeySandboxImage        = "sandbox-image"
+	KeyAllowedTools        = "allowed-tools"
+	KeyAgents              = "agents"
+	KeyMCPConfig           = "mcp-config"
+	KeyClaudeArgs          = "claude-args"
+
+	// Loop controls
+	KeyCompletionSignal    = "completion-signal"
+	KeyCompletion          = "completion"
+	KeyCompletionPattern   = "completion-pattern"
+	KeyIterationTimeout    = "iteration-timeout"
+	KeyMaxDuration         = "max-duration"
+	KeyHeartbeat           = "heartbeat"
+	KeySessionMode         = "session-mode"
+	KeyRetries             = "retries"
+	KeyMaxOutputTokens     = "max-output-tokens"
+	KeyThinkingBudget      = "thinking-budget"
+	KeyRateLimitWait       = "rate-limit-wait"
+	KeyMaxCost             = "max-cost"
+	KeyProtectedPaths      = "protected-paths"
+	KeyWriteScope          = "write-scope"
+	KeyVerify              = "verify"
+	KeyHooksBeforeRun      = "hooks.before-run"
+	KeyHooksAfterIteration = "hooks.after-iteration"
+	KeyHooksAfterRun       = "hooks.after-run"
+
+	// Policy of the permission proxy
+	KeyPermissionPolicyAllow = "permission-policy.allow"
+	KeyPermissionPolicyAsk   = "permission-policy.ask"
+	KeyPermissionPolicyDeny  = "permission-policy.deny"
+
+	// Environment of the Claude CLI
+	KeyEnvAllow = "env.allow"
+	KeyEnvDeny  = "env.deny"
+	KeyEnvSet   = "env.set"
+
+	// Project guidance files
+	KeyGuidanceMode    = "guidance.mode"
+	KeyGuidanceFiles   = "guidance.files"
+	KeyGuidanceMaxSize = "guidance.max-size"
+
+	// Prompt budget
+	KeyPromptMaxTokens = "prompt.max-tokens"
+	KeyPromptTruncate  = "prompt.truncate"
+
+	// Notifications
+	KeyNotificationsWebhookURL    = "notifications.webhook-url"
+	KeyNotificationsEvents        = "notifications.events"
+	KeyNotificationsEmailTo       = "notifications.email.to"
+	KeyNotificationsEmailFrom     = "notifications.email.from"
+	KeyNotificationsEmailSMTP     = "notifications.email.smtp"
+	KeyNotificationsEmailUsername = "notifications.email.username"
 )
 
+// ProfilesKey is the config section holding the named profiles selected with --profile.
+const ProfilesKey = "profiles"
+
+// flagKeys are the config keys set by the flag of the same name.
+var flagKeys = []string{KeyModel, KeyMaxIterations, KeyQuiet, KeyVerbose, KeyNoBranch, KeyNoNewTests, KeyPR, KeyCommitAuthor,
+	KeyConventionalCommits, KeyCommitTemplate, KeyWrapUpIterations, KeyPRDraft, KeyPRComment, KeyForge, KeyCI, KeyProfile, KeyStrictConfig,
+	KeyCompletionSignal, KeyCompletion, KeyCompletionPattern, KeyIterationTimeout, KeyMaxDuration, KeyRetries, KeyPlaybook, KeyLanguage, KeyOutput, KeyLogFormat, KeyLogLevel, KeyNoRepoMap, KeyTranscripts, KeyNoColor, KeyJUnit,
+	KeyOutputFile, KeyReportFile, KeyHeartbeat, KeyBranchRunID, KeyPermissionMode, KeySessionMode, KeyMaxCost, KeyRateLimitWait,
+	KeyMaxOutputTokens, KeyThinkingBudget, KeySandbox, KeySandboxImage}
+
+// listFlags maps list-valued config keys to their repeatable, singular flag names.
+var listFlags = map[string]string{
+	KeyPRLabels:    "pr-label",
+	KeyPRReviewers: "pr-reviewer",
+	KeyPRAssignees: "pr-assignee",
+
+	KeyAllowedTools: "allowed-tool",
+	KeyMCPConfig:    "mcp-config",
+	KeyAgents:       "agent",
+	KeyClaudeArgs:   "claude-arg",
+
+	KeyProtectedPaths: "protected-path",
+	KeyWriteScope:     "write-scope",
+
+	KeyVerify:              "verify",
+	KeyHooksBeforeRun:      "hook-before-run",
+	KeyHooksAfterIteration: "hook-after-iteration",
+	KeyHooksAfterRun:       "hook-after-run",
+
+	KeyPermissionPolicyAllow: "permission-allow",
+	KeyPermissionPolicyAsk:   "permission-ask",
+	KeyPermissionPolicyDeny:  "permission-deny",
+
+	KeyEnvDeny: "env-deny",
+	KeyEnvSet:  "env",
+}
+
 // Deprecated: Use KeyNoNewTests instead
 const KeyTests = "tests"
 
@@ -49,12 +206,87 @@ const (
 	DefaultModel         = "claude-opus-4-5"
 	DefaultMaxIterations = 10
 	DefaultQuiet         = false
+	DefaultVerbose       = 1
 	DefaultNoBranch      = false
 	DefaultNoNewTests    = false
 	DefaultPR            = true
 	DefaultCommitAuthor  = "Gonzo <gonzo@barilla.you>"
+
+	DefaultConventionalCommits = true
+	DefaultCommitTemplate      = ""
+	DefaultWrapUpIterations    = 1
+	DefaultPRDraft             = false
+	DefaultPRComment           = false
+	DefaultPRCloseKeyword      = "Closes"
+	DefaultPRTitleIssuePrefix  = false
+	DefaultForge               = "auto"
+	DefaultCI                  = "auto"
+	DefaultProfile             = ""
+	DefaultStrictConfig        = true
+	DefaultPlaybook            = ""
+	DefaultLanguage            = "auto"
+	DefaultOutput              = "text"
+	DefaultLogFormat           = "text"
+	DefaultLogLevel            = "info"
+	DefaultNoRepoMap           = false
+	DefaultTranscripts         = false
+	DefaultNoColor             = false
+	DefaultJUnit               = ""
+	DefaultOutputFile          = ""
+	DefaultReportFile          = ""
+	DefaultBranchRunID         = false
+	DefaultPermissionMode      = "acceptEdits"
+	DefaultSandbox             = "none"
+	DefaultSandboxImage        = "gonzo-sandbox"
+
+	DefaultCompletionSignal  = "<promise>COMPLETE</promise>"
+	DefaultCompletion        = "marker"
+	DefaultCompletionPattern = ""
+	DefaultIterationTimeout  = time.Duration(0)
+	DefaultMaxDuration       = time.Duration(0)
+	DefaultHeartbeat         = time.Duration(0)
+	DefaultSessionMode       = "fresh"
+	DefaultRetries           = 0
+	DefaultMaxOutputTokens   = 0
+	DefaultThinkingBudget    = 0
+	DefaultRateLimitWait     = 30 * time.Minute
+	DefaultMaxCost           = 0.0
+
+	DefaultGuidanceMode    = "reference"
+	DefaultGuidanceMaxSize = 16 * 1024
+
+	DefaultPromptMaxTokens = 0
+
+	DefaultNotificationsWebhookURL    = ""
+	DefaultNotificationsEmailFrom     = ""
+	DefaultNotificationsEmailSMTP     = ""
+	DefaultNotificationsEmailUsername = ""
 )
 
+// DefaultGuidanceFiles are the project guidance files looked for in the repository.
+var DefaultGuidanceFiles = []string{"CLAUDE.md", "AGENTS.md", "CONTRIBUTING.md"}
+
+// DefaultPromptTruncate are the sections of the system prompt truncated, in order, to fit the
+// prompt budget.
+var DefaultPromptTruncate = []string{"repomap", "guidance"}
+
+// DefaultEnvDeny are the variables of gonzo's environment kept from the Claude CLI: the
+// secrets gonzo reads for itself but the Anthropic API key, as gonzo.DefaultEnvDeny.
+var DefaultEnvDeny = []string{"GH_TOKEN", "GITHUB_TOKEN", "GITLAB_TOKEN", "GONZO_SMTP_PASSWORD", "GONZO_WEBHOOK_URL"}
+
+// DefaultAllowedTools are the tools Claude may use on top of the permission mode: git, and the
+// toolchains of the languages gonzo has guidance for.
+var DefaultAllowedTools = []string{
+	"Bash(git:*)",
+	"Bash(go:*)", "Bash(gofmt:*)",
+	"Bash(npm:*)", "Bash(npx:*)", "Bash(yarn:*)", "Bash(pnpm:*)",
+	"Bash(python:*)", "Bash(python3:*)", "Bash(pytest:*)", "Bash(uv:*)", "Bash(poetry:*)", "Bash(ruff:*)",
+	"Bash(make:*)",
+}
+
+// DefaultNotificationsEvents are the run events notifications are sent for.
+var DefaultNotificationsEvents = []string{"complete", "fail", "budget"}
+
 // Deprecated: Use DefaultNoNewTests instead
 const DefaultTests = true
 
@@ -62,54 +294,281 @@ const DefaultTests = true
 // This should be called before cobra.Command.Execute() to ensure configuration
 // is loaded before flags are parsed.
 func Init() error {
+	overridden = map[string]bool{}
+	profile, profileKeys = "", nil
+	playbook, playbookKeys = "", nil
+
 	// Set default values
 	viper.SetDefault(KeyModel, DefaultModel)
 	viper.SetDefault(KeyMaxIterations, DefaultMaxIterations)
 	viper.SetDefault(KeyQuiet, DefaultQuiet)
+	viper.SetDefault(KeyVerbose, DefaultVerbose)
 	viper.SetDefault(KeyNoBranch, DefaultNoBranch)
 	viper.SetDefault(KeyNoNewTests, DefaultNoNewTests)
 	viper.SetDefault(KeyPR, DefaultPR)
 	viper.SetDefault(KeyCommitAuthor, DefaultCommitAuthor)
+	viper.SetDefault(KeyConventionalCommits, DefaultConventionalCommits)
+	viper.SetDefault(KeyCommitTemplate, DefaultCommitTemplate)
+	viper.SetDefault(KeyWrapUpIterations, DefaultWrapUpIterations)
+	viper.SetDefault(KeyPRDraft, DefaultPRDraft)
+	viper.SetDefault(KeyPRLabels, []string{})
+	viper.SetDefault(KeyPRReviewers, []string{})
+	viper.SetDefault(KeyPRAssignees, []string{})
+	viper.SetDefault(KeyPRComment, DefaultPRComment)
+	viper.SetDefault(KeyPRCloseKeyword, DefaultPRCloseKeyword)
+	viper.SetDefault(KeyPRTitleIssuePrefix, DefaultPRTitleIssuePrefix)
+	viper.SetDefault(KeyForge, DefaultForge)
+	viper.SetDefault(KeyCI, DefaultCI)
+	viper.SetDefault(KeyProfile, DefaultProfile)
+	viper.SetDefault(KeyStrictConfig, DefaultStrictConfig)
+	viper.SetDefault(KeyPlaybook, DefaultPlaybook)
+	viper.SetDefault(KeyLanguage, DefaultLanguage)
+	viper.SetDefault(KeyOutput, DefaultOutput)
+	viper.SetDefault(KeyLogFormat, DefaultLogFormat)
+	viper.SetDefault(KeyLogLevel, DefaultLogLevel)
+	viper.SetDefault(KeyVars, map[string]string{})
+	viper.SetDefault(KeyNoRepoMap, DefaultNoRepoMap)
+	viper.SetDefault(KeyTranscripts, DefaultTranscripts)
+	viper.SetDefault(KeyNoColor, DefaultNoColor)
+	viper.SetDefault(KeyJUnit, DefaultJUnit)
+	viper.SetDefault(KeyOutputFile, DefaultOutputFile)
+	viper.SetDefault(KeyReportFile, DefaultReportFile)
+	viper.SetDefault(KeyBranchRunID, DefaultBranchRunID)
+	viper.SetDefault(KeyPermissionMode, DefaultPermissionMode)
+	viper.SetDefault(KeySandbox, DefaultSandbox)
+	viper.SetDefault(KeySandboxImage, DefaultSandboxImage)
+	viper.SetDefault(KeyAllowedTools, slices.Clone(DefaultAllowedTools))
+	viper.SetDefault(KeyAgents, []string{})
+	viper.SetDefault(KeyMCPConfig, []string{})
+	viper.SetDefault(KeyClaudeArgs, []string{})
+	viper.SetDefault(KeyCompletionSignal, DefaultCompletionSignal)
+	viper.SetDefault(KeyCompletion, DefaultCompletion)
+	viper.SetDefault(KeyCompletionPattern, DefaultCompletionPattern)
+	viper.SetDefault(KeyIterationTimeout, DefaultIterationTimeout)
+	viper.SetDefault(KeyMaxDuration, DefaultMaxDuration)
+	viper.SetDefault(KeyHeartbeat, DefaultHeartbeat)
+	viper.SetDefault(KeySessionMode, DefaultSessionMode)
+	viper.SetDefault(KeyRetries, DefaultRetries)
+	viper.SetDefault(KeyMaxOutputTokens, DefaultMaxOutputTokens)
+	viper.SetDefault(KeyThinkingBudget, DefaultThinkingBudget)
+	viper.SetDefault(KeyRateLimitWait, DefaultRateLimitWait)
+	viper.SetDefault(KeyMaxCost, DefaultMaxCost)
+	viper.SetDefault(KeyProtectedPaths, []string{})
+	viper.SetDefault(KeyWriteScope, []string{})
+	viper.SetDefault(KeyVerify, []string{})
+	viper.SetDefault(KeyHooksBeforeRun, []string{})
+	viper.SetDefault(KeyHooksAfterIteration, []string{})
+	viper.SetDefault(KeyHooksAfterRun, []string{})
+	viper.SetDefault(KeyPermissionPolicyAllow, []string{})
+	viper.SetDefault(KeyPermissionPolicyAsk, []string{})
+	viper.SetDefault(KeyPermissionPolicyDeny, []string{})
+	viper.SetDefault(KeyEnvAllow, []string{})
+	viper.SetDefault(KeyEnvDeny, slices.Clone(DefaultEnvDeny))
+	viper.SetDefault(KeyEnvSet, []string{})
+	viper.SetDefault(KeyGuidanceMode, DefaultGuidanceMode)
+	viper.SetDefault(KeyGuidanceFiles, slices.Clone(DefaultGuidanceFiles))
+	viper.SetDefault(KeyGuidanceMaxSize, DefaultGuidanceMaxSize)
+	viper.SetDefault(KeyPromptMaxTokens, DefaultPromptMaxTokens)
+	viper.SetDefault(KeyPromptTruncate, slices.Clone(DefaultPromptTruncate))
+	viper.SetDefault(KeyNotificationsWebhookURL, DefaultNotificationsWebhookURL)
+	viper.SetDefault(KeyNotificationsEvents, slices.Clone(DefaultNotificationsEvents))
+	viper.SetDefault(KeyNotificationsEmailTo, []string{})
+	viper.SetDefault(KeyNotificationsEmailFrom, DefaultNotificationsEmailFrom)
+	viper.SetDefault(KeyNotificationsEmailSMTP, DefaultNotificationsEmailSMTP)
+	viper.SetDefault(KeyNotificationsEmailUsername, DefaultNotificationsEmailUsername)
 
-	// Set config file name and type
-	viper.SetConfigName(ConfigName)
-	viper.SetConfigType(ConfigType)
-
-	// Add config search paths
-	// 1. Current directory
-	viper.AddConfigPath(".")
-
-	// 2. Home directory
-	if home, err := os.UserHomeDir(); err == nil {
-		viper.AddConfigPath(home)
-		// Also check ~/.config/gonzo/
-		viper.AddConfigPath(filepath.Join(home, ".config", "gonzo"))
+	// Merge the configuration files found, the most specific last
+	layers = nil
+	found, err := configLayers()
+	if err != nil {
+		return err
 	}
-
-	// Read config file if it exists (ignore error if not found)
-	if err := viper.ReadInConfig(); err != nil {
-		// Only return error if it's not a "file not found" error
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return fmt.Errorf("error reading config file: %w", err)
+	for _, layer := range found {
+		if err := mergeLayer(layer, map[string]bool{layer.Path: true}); err != nil {
+			return err
 		}
 	}
 
 	// Set up environment variables
 	viper.SetEnvPrefix(EnvPrefix)
-	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
 	viper.AutomaticEnv()
 
 	return nil
 }
 
+// ConfigLayer is a configuration file merged into the configuration. The Path of a file
+// extended by URL is the URL.
+type ConfigLayer struct {
+	Name string
+	Path string
+
+	// file is where the layer is read from when it is not Path, e.g. the cached copy of a URL
+	file    string
+	keys    map[string]bool
+	modTime time.Time
+}
+
+// readPath returns the path of the file the layer is read from.
+func (l ConfigLayer) readPath() string {
+	if l.file != "" {
+		return l.file
+	}
+	return l.Path
+}
+
+// explicitFile is the config file set with SetConfigFile.
+var explicitFile string
+
+// SetConfigFile makes Init load the config file at path instead of searching for the user and
+// project ones. It takes precedence over the GONZO_CONFIG environment variable; an empty path
+// restores the search.
+func SetConfigFile(path string) {
+	explicitFile = path
+}
+
+// layers are the configuration files loaded by Init, from the least to the most specific.
+var layers []ConfigLayer
+
+// configLayers returns the configuration files to load, from the least to the most specific:
+// the first user configuration file found, then the project ones that exist. An explicit config
+// file is loaded alone, and must exist.
+func configLayers() ([]ConfigLayer, error) {
+	path := explicitFile
+	if path == "" {
+		path = os.Getenv(ConfigEnvVar)
+	}
+	if path != "" {
+		if !isFile(path) {
+			return nil, fmt.Errorf("config file %s does not exist", path)
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		return []ConfigLayer{{Name: LayerExplicit, Path: path}}, nil
+	}
+
+	var user []string
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	home, err := os.UserHomeDir()
+	if configHome == "" && err == nil {
+		configHome = filepath.Join(home, ".config")
+	}
+	if configHome != "" {
+		user = append(user, filepath.Join(configHome, "gonzo", ConfigName))
+	}
+	if err == nil {
+		user = append(user, filepath.Join(home, ConfigName))
+	}
+
+	var found []ConfigLayer
+	for _, name := range user {
+		if path := findConfigFile(name); path != "" {
+			found = append(found, ConfigLayer{Name: LayerUser, Path: path})
+			break
+		}
+	}
+	for _, name := range []string{ConfigName, ProjectConfigName} {
+		path := findConfigFile(name)
+		// Running from the home directory, ./gonzo.yaml is the user configuration file
+		if path == "" || slices.ContainsFunc(found, func(l ConfigLayer) bool { return sameFile(l.Path, path) }) {
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		found = append(found, ConfigLayer{Name: LayerProject, Path: path})
+	}
+	return found, nil
+}
+
+// findConfigFile returns the config file named name with the first of ConfigTypes as
+// extension that exists, or "" when there is none.
+func findConfigFile(name string) string {
+	for _, ext := range ConfigTypes {
+		if path := name + "." + ext; isFile(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+// configType returns the format of a config file from its extension. Files without a known
+// extension are read as YAML.
+func configType(path string) string {
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")); ext {
+	case "toml", "json":
+		return ext
+	default:
+		return ConfigType
+	}
+}
+
+// mergeLayer reads a configuration file and merges it on top of the layers read before it,
+// after the file it extends, if any. Seen holds the files already on the chain of extends.
+func mergeLayer(layer ConfigLayer, seen map[string]bool) error {
+	v := viper.New()
+	v.SetConfigFile(layer.readPath())
+	v.SetConfigType(configType(layer.readPath()))
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("error reading config file %s: %w", layer.Path, err)
+	}
+
+	if base := v.GetString(KeyExtends); base != "" {
+		parent, err := extendedLayer(layer, base)
+		if err != nil {
+			return err
+		}
+		if seen[parent.Path] {
+			return fmt.Errorf("config file %s extends %s, which extends it back", layer.Path, parent.Path)
+		}
+		seen[parent.Path] = true
+		if err := mergeLayer(parent, seen); err != nil {
+			return err
+		}
+	}
+
+	if err := viper.MergeConfigMap(v.AllSettings()); err != nil {
+		return fmt.Errorf("error merging config file %s: %w", layer.Path, err)
+	}
+
+	layer.keys = map[string]bool{}
+	for _, key := range v.AllKeys() {
+		layer.keys[key] = true
+	}
+	if info, err := os.Stat(layer.readPath()); err == nil {
+		layer.modTime = info.ModTime()
+	}
+	layers = append(layers, layer)
+	return nil
+}
+
+// isFile reports whether path exists and is a regular file.
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// sameFile reports whether two paths name the same file.
+func sameFile(a string, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
 // BindFlags binds Cobra flags to Viper configuration.
 // This should be called in the cobra command's PersistentPreRunE or PreRunE
-// after flags have been defined but before they are used.
+// after flags have been defined but before they are used. The flags are looked up
+// on the root command so that subcommands can bind them as well.
 func BindFlags(cmd *cobra.Command) error {
-	flags := []string{KeyModel, KeyMaxIterations, KeyQuiet, KeyNoBranch, KeyNoNewTests, KeyPR, KeyCommitAuthor}
+	for _, flag := range flagKeys {
+		if err := viper.BindPFlag(flag, cmd.Root().PersistentFlags().Lookup(flag)); err != nil {
+			return fmt.Errorf("error binding flag %s: %w", flag, err)
+		}
+	}
 
-	for _, flag := range flags {
-		if err := viper.BindPFlag(flag, cmd.PersistentFlags().Lookup(flag)); err != nil {
+	for key, flag := range listFlags {
+		if err := viper.BindPFlag(key, cmd.Root().PersistentFlags().Lookup(flag)); err != nil {
 			return fmt.Errorf("error binding flag %s: %w", flag, err)
 		}
 	}
@@ -117,6 +576,135 @@ func BindFlags(cmd *cobra.Command) error {
 	return nil
 }
 
+// profile is the profile applied by ApplyProfile, and profileKeys the keys it sets.
+var profile string
+var profileKeys map[string]bool
+
+// ApplyProfile merges the settings of the named profile, from the profiles section of the
+// config files, on top of the config files. Environment variables and flags still take
+// precedence. An empty name applies no profile.
+//
+//	profiles:
+//	  cheap:
+//	    model: claude-haiku-4-5
+//	    max-iterations: 5
+//	    pr: false
+func ApplyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	sub := viper.Sub(ProfilesKey + "." + name)
+	if sub == nil {
+		if names := Profiles(); len(names) > 0 {
+			return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(names, ", "))
+		}
+		return fmt.Errorf("unknown profile %q: no profiles are configured", name)
+	}
+
+	if err := viper.MergeConfigMap(sub.AllSettings()); err != nil {
+		return fmt.Errorf("error applying profile %s: %w", name, err)
+	}
+	profile = name
+	profileKeys = map[string]bool{}
+	for _, key := range sub.AllKeys() {
+		profileKeys[key] = true
+	}
+	return nil
+}
+
+// Profiles returns the names of the configured profiles, sorted.
+func Profiles() []string {
+	var names []string
+	for name := range viper.GetStringMap(ProfilesKey) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// overridden records the keys set through ApplyOverrides.
+var overridden = map[string]bool{}
+
+// ApplyOverrides applies "key=value" pairs (from the --set flag) on top of every other
+// configuration source for this invocation. Keys may address nested values with dots,
+// e.g. "gates.test-command=make check".
+func ApplyOverrides(pairs []string) error {
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return fmt.Errorf("invalid override %q: expected key=value", pair)
+		}
+		viper.Set(key, value)
+		overridden[key] = true
+	}
+	return nil
+}
+
+// playbookDefaults are the defaults each playbook changes, by playbook name.
+var playbookDefaults = map[string]map[string]interface{}{
+	"docs":      {KeyNoNewTests: true},
+	"migration": {KeyMaxIterations: 20, KeyWrapUpIterations: 2},
+}
+
+// playbook is the playbook applied by ApplyPlaybook, and playbookKeys the keys whose defaults it changed.
+var (
+	playbook     string
+	playbookKeys []string
+)
+
+// ApplyPlaybook changes the defaults of the keys the named playbook adjusts, e.g. the docs
+// playbook does not add tests, and restores the ones changed by the playbook applied before.
+// Any other configuration source still overrides them.
+func ApplyPlaybook(name string) {
+	defaults := defaultValues()
+	for _, key := range playbookKeys {
+		viper.SetDefault(key, defaults[key])
+	}
+
+	playbook, playbookKeys = name, nil
+	for key, value := range playbookDefaults[name] {
+		viper.SetDefault(key, value)
+		playbookKeys = append(playbookKeys, key)
+	}
+}
+
+// ApplyVars applies "name=value" pairs (from the --var flag) to the variables of the prompt
+// templates, on top of the vars section of the configuration files.
+func ApplyVars(pairs []string) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+	// Viper does not merge a map set here with the one of the config files, so merge them first
+	vars := GetVars()
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" || strings.Contains(name, ".") {
+			return fmt.Errorf("invalid variable %q: expected name=value", pair)
+		}
+		vars[name] = value
+	}
+	viper.Set(KeyVars, vars)
+	overridden[KeyVars] = true
+	return nil
+}
+
+// ApplySettings applies a map of resolved settings, such as those recorded in a run manifest,
+// on top of every other configuration source. Keys are treated like ApplyOverrides keys.
+func ApplySettings(settings map[string]interface{}) {
+	for key, value := range settings {
+		key = strings.ToLower(key)
+		viper.Set(key, value)
+		overridden[key] = true
+	}
+}
+
+// IsOverridden returns whether key was set through ApplyOverrides.
+func IsOverridden(key string) bool {
+	return overridden[strings.ToLower(key)]
+}
+
 // GetModel returns the configured model name
 func GetModel() string {
 	return viper.GetString(KeyModel)
@@ -127,6 +715,12 @@ func GetMaxIterations() int {
 	return viper.GetInt(KeyMaxIterations)
 }
 
+// GetVerbose returns the verbosity of a run: 1 for the banners and summaries of the
+// iterations, 2 to also print the rendered prompts, the Claude CLI commands and their timing
+func GetVerbose() int {
+	return viper.GetInt(KeyVerbose)
+}
+
 // GetQuiet returns whether quiet mode is enabled
 func GetQuiet() bool {
 	return viper.GetBool(KeyQuiet)
@@ -137,6 +731,52 @@ func GetNoBranch() bool {
 	return viper.GetBool(KeyNoBranch)
 }
 
+// GetBranchRunID returns whether the branch names of runs end with their short run ID
+func GetBranchRunID() bool {
+	return viper.GetBool(KeyBranchRunID)
+}
+
+// GetPermissionMode returns the permission mode of the Claude CLI calls
+func GetPermissionMode() string {
+	return viper.GetString(KeyPermissionMode)
+}
+
+// GetSandbox returns where the loop runs: on the host (none) or in a Docker container (docker)
+func GetSandbox() string {
+	return viper.GetString(KeySandbox)
+}
+
+// GetSandboxImage returns the image of the Docker sandbox
+func GetSandboxImage() string {
+	return viper.GetString(KeySandboxImage)
+}
+
+// GetAllowedTools returns the tools Claude may use on top of the permission mode
+func GetAllowedTools() []string {
+	return viper.GetStringSlice(KeyAllowedTools)
+}
+
+// GetPermissionPolicy returns the rules allowing, asking about and denying the tools Claude asks
+// to use in the proxy permission mode
+func GetPermissionPolicy() (allow []string, ask []string, deny []string) {
+	return viper.GetStringSlice(KeyPermissionPolicyAllow), viper.GetStringSlice(KeyPermissionPolicyAsk), viper.GetStringSlice(KeyPermissionPolicyDeny)
+}
+
+// GetAgents returns the subagents forwarded to the Claude CLI
+func GetAgents() []string {
+	return viper.GetStringSlice(KeyAgents)
+}
+
+// GetMCPConfig returns the MCP server configurations forwarded to the Claude CLI
+func GetMCPConfig() []string {
+	return viper.GetStringSlice(KeyMCPConfig)
+}
+
+// GetClaudeArgs returns the extra arguments passed to every Claude CLI call
+func GetClaudeArgs() []string {
+	return viper.GetStringSlice(KeyClaudeArgs)
+}
+
 // GetNoNewTests returns whether new test creation should be skipped
 func GetNoNewTests() bool {
 	return viper.GetBool(KeyNoNewTests)
@@ -152,12 +792,399 @@ func GetCommitAuthor() string {
 	return viper.GetString(KeyCommitAuthor)
 }
 
-// ConfigFileUsed returns the config file path if one was found and loaded
+// GetConventionalCommits returns whether commits made by gonzo follow Conventional Commits
+func GetConventionalCommits() bool {
+	return viper.GetBool(KeyConventionalCommits)
+}
+
+// GetCommitTemplate returns the configured commit message template (inline or a file path)
+func GetCommitTemplate() string {
+	return viper.GetString(KeyCommitTemplate)
+}
+
+// GetWrapUpIterations returns the number of final iterations reserved for wrap-up
+func GetWrapUpIterations() int {
+	return viper.GetInt(KeyWrapUpIterations)
+}
+
+// GetPRDraft returns whether pull requests are opened as drafts
+func GetPRDraft() bool {
+	return viper.GetBool(KeyPRDraft)
+}
+
+// GetPRLabels returns the labels to add to pull requests
+func GetPRLabels() []string {
+	return viper.GetStringSlice(KeyPRLabels)
+}
+
+// GetPRReviewers returns the reviewers to request on pull requests
+func GetPRReviewers() []string {
+	return viper.GetStringSlice(KeyPRReviewers)
+}
+
+// GetPRAssignees returns the users to assign to pull requests
+func GetPRAssignees() []string {
+	return viper.GetStringSlice(KeyPRAssignees)
+}
+
+// GetPRComment returns whether a run summary is posted as a pull request comment
+func GetPRComment() bool {
+	return viper.GetBool(KeyPRComment)
+}
+
+// GetPRCloseKeyword returns the keyword placed before linked issues in pull request bodies
+func GetPRCloseKeyword() string {
+	return viper.GetString(KeyPRCloseKeyword)
+}
+
+// GetPRTitleIssuePrefix returns whether pull request titles are prefixed with linked issue numbers
+func GetPRTitleIssuePrefix() bool {
+	return viper.GetBool(KeyPRTitleIssuePrefix)
+}
+
+// GetForge returns the forge pull requests are opened on (auto, github or gitlab)
+func GetForge() string {
+	return viper.GetString(KeyForge)
+}
+
+// GetCI returns the CI integration (auto, github or none)
+func GetCI() string {
+	return viper.GetString(KeyCI)
+}
+
+// GetPlaybook returns the playbook selected for the run, if any
+func GetPlaybook() string {
+	return viper.GetString(KeyPlaybook)
+}
+
+// GetLanguage returns the language whose toolchain guidance is added to the system prompt, auto
+// to detect it or none
+func GetLanguage() string {
+	return viper.GetString(KeyLanguage)
+}
+
+// GetOutput returns the format of the output of a run (text or json)
+func GetOutput() string {
+	return viper.GetString(KeyOutput)
+}
+
+// GetLogFormat returns the format of the log of a run (text or json)
+func GetLogFormat() string {
+	return viper.GetString(KeyLogFormat)
+}
+
+// GetLogLevel returns the least severe level of the messages logged (debug, info, warn or error)
+func GetLogLevel() string {
+	return viper.GetString(KeyLogLevel)
+}
+
+// GetVars returns the variables of the prompt templates, by lower-case name
+func GetVars() map[string]string {
+	return viper.GetStringMapString(KeyVars)
+}
+
+// GetTranscripts returns whether the transcript of each iteration is recorded in .gonzo/transcripts
+func GetTranscripts() bool {
+	return viper.GetBool(KeyTranscripts)
+}
+
+// GetNoColor returns whether the colors of the console are disabled
+func GetNoColor() bool {
+	return viper.GetBool(KeyNoColor)
+}
+
+// GetJUnit returns the path of the JUnit XML report of a run, or "" for none
+func GetJUnit() string {
+	return viper.GetString(KeyJUnit)
+}
+
+// GetOutputFile returns the path the final response of a run is written to instead of stdout,
+// or "" for stdout
+func GetOutputFile() string {
+	return viper.GetString(KeyOutputFile)
+}
+
+// GetReportFile returns the path the JSON summary of a run is written to, or "" for none
+func GetReportFile() string {
+	return viper.GetString(KeyReportFile)
+}
+
+// GetNoRepoMap returns whether the repository map is left out of the system prompt
+func GetNoRepoMap() bool {
+	return viper.GetBool(KeyNoRepoMap)
+}
+
+// GetCompletionSignal returns the marker the agent replies with once the task is complete
+func GetCompletionSignal() string {
+	return viper.GetString(KeyCompletionSignal)
+}
+
+// GetCompletion returns the name of the detector telling when the task is complete: marker,
+// regex or quiescence
+func GetCompletion() string {
+	return viper.GetString(KeyCompletion)
+}
+
+// GetCompletionPattern returns the pattern Claude's reply matches once the task is complete,
+// with the regex completion detector
+func GetCompletionPattern() string {
+	return viper.GetString(KeyCompletionPattern)
+}
+
+// GetHeartbeat returns the interval of the lines printed while a Claude CLI call runs, or zero
+// for none
+func GetHeartbeat() time.Duration {
+	return viper.GetDuration(KeyHeartbeat)
+}
+
+// GetSessionMode returns whether iterations start a new Claude session (fresh) or resume the
+// session of the previous one (continue)
+func GetSessionMode() string {
+	return viper.GetString(KeySessionMode)
+}
+
+// GetIterationTimeout returns how long a single iteration may take, or zero for no limit
+func GetIterationTimeout() time.Duration {
+	return viper.GetDuration(KeyIterationTimeout)
+}
+
+// GetMaxDuration returns how long a run may take, or zero for no limit
+func GetMaxDuration() time.Duration {
+	return viper.GetDuration(KeyMaxDuration)
+}
+
+// GetRetries returns how many times a failed Claude CLI call is retried
+func GetRetries() int {
+	return viper.GetInt(KeyRetries)
+}
+
+// GetMaxOutputTokens returns the number of tokens a response of the model may reach, or zero
+// for the default of the Claude CLI
+func GetMaxOutputTokens() int {
+	return viper.GetInt(KeyMaxOutputTokens)
+}
+
+// GetThinkingBudget returns the number of tokens the model may think for, or zero for the
+// default of the Claude CLI
+func GetThinkingBudget() int {
+	return viper.GetInt(KeyThinkingBudget)
+}
+
+// GetRateLimitWait returns how long an iteration waits in total for rate limits to lift, or
+// zero to fail rate limited calls like any other
+func GetRateLimitWait() time.Duration {
+	return viper.GetDuration(KeyRateLimitWait)
+}
+
+// GetMaxCost returns the cost in US dollars a run may reach, or zero for no limit
+func GetMaxCost() float64 {
+	return viper.GetFloat64(KeyMaxCost)
+}
+
+// GetProtectedPaths returns the globs of the files Claude must not change
+func GetProtectedPaths() []string {
+	return viper.GetStringSlice(KeyProtectedPaths)
+}
+
+// GetWriteScope returns the globs of the files Claude may change, any file when empty
+func GetWriteScope() []string {
+	return viper.GetStringSlice(KeyWriteScope)
+}
+
+// GetVerify returns the commands that must pass before a run is considered complete
+func GetVerify() []string {
+	return commandList(viper.GetViper(), KeyVerify)
+}
+
+// GetHooksBeforeRun returns the commands run before the first iteration
+func GetHooksBeforeRun() []string {
+	return commandList(viper.GetViper(), KeyHooksBeforeRun)
+}
+
+// GetHooksAfterIteration returns the commands run after every iteration
+func GetHooksAfterIteration() []string {
+	return commandList(viper.GetViper(), KeyHooksAfterIteration)
+}
+
+// GetHooksAfterRun returns the commands run once the run is over
+func GetHooksAfterRun() []string {
+	return commandList(viper.GetViper(), KeyHooksAfterRun)
+}
+
+// GetEnvAllow returns the variables of gonzo's environment the Claude CLI inherits, all of
+// them when empty
+func GetEnvAllow() []string {
+	return viper.GetStringSlice(KeyEnvAllow)
+}
+
+// GetEnvDeny returns the variables of gonzo's environment kept from the Claude CLI
+func GetEnvDeny() []string {
+	return viper.GetStringSlice(KeyEnvDeny)
+}
+
+// GetEnvSet returns the NAME=value variables set in the environment of the Claude CLI
+func GetEnvSet() []string {
+	return commandList(viper.GetViper(), KeyEnvSet)
+}
+
+// GetGuidanceMode returns how project guidance files are added to the system prompt
+// (inline, reference or off)
+func GetGuidanceMode() string {
+	return viper.GetString(KeyGuidanceMode)
+}
+
+// GetGuidanceFiles returns the project guidance files looked for in the repository
+func GetGuidanceFiles() []string {
+	return viper.GetStringSlice(KeyGuidanceFiles)
+}
+
+// GetGuidanceMaxSize returns the size in bytes above which a guidance file is referenced
+// instead of inlined
+func GetGuidanceMaxSize() int {
+	return viper.GetInt(KeyGuidanceMaxSize)
+}
+
+// GetPromptMaxTokens returns the budget of the system prompt and feature in estimated tokens,
+// or zero for a quarter of the model's context window
+func GetPromptMaxTokens() int {
+	return viper.GetInt(KeyPromptMaxTokens)
+}
+
+// GetPromptTruncate returns the sections of the system prompt truncated, in order, to fit the
+// prompt budget
+func GetPromptTruncate() []string {
+	return viper.GetStringSlice(KeyPromptTruncate)
+}
+
+// GetNotificationsWebhookURL returns the webhook run notifications are posted to, if set in
+// the config rather than as the webhook-url secret
+func GetNotificationsWebhookURL() string {
+	return viper.GetString(KeyNotificationsWebhookURL)
+}
+
+// GetNotificationsEvents returns the run events notifications are sent for
+func GetNotificationsEvents() []string {
+	return viper.GetStringSlice(KeyNotificationsEvents)
+}
+
+// GetNotificationsEmailTo returns the recipients of notification emails
+func GetNotificationsEmailTo() []string {
+	return viper.GetStringSlice(KeyNotificationsEmailTo)
+}
+
+// GetNotificationsEmailFrom returns the sender of notification emails
+func GetNotificationsEmailFrom() string {
+	return viper.GetString(KeyNotificationsEmailFrom)
+}
+
+// GetNotificationsEmailSMTP returns the address, host:port, of the SMTP server notification
+// emails are sent through
+func GetNotificationsEmailSMTP() string {
+	return viper.GetString(KeyNotificationsEmailSMTP)
+}
+
+// GetNotificationsEmailUsername returns the user authenticating to the SMTP server, if any
+func GetNotificationsEmailUsername() string {
+	return viper.GetString(KeyNotificationsEmailUsername)
+}
+
+// commandList returns the shell commands held by a list-valued key. Unlike with other lists,
+// a single string, e.g. from an environment variable, is one command rather than a list of words.
+func commandList(v *viper.Viper, key string) []string {
+	if s, ok := v.Get(key).(string); ok {
+		if strings.TrimSpace(s) == "" {
+			return []string{}
+		}
+		return []string{s}
+	}
+	return v.GetStringSlice(key)
+}
+
+// ConfigFileUsed returns the path of the most specific config file loaded, if any
 func ConfigFileUsed() string {
-	return viper.ConfigFileUsed()
+	if len(layers) == 0 {
+		return ""
+	}
+	return layers[len(layers)-1].Path
+}
+
+// ConfigFiles returns the config files loaded, from the least to the most specific
+func ConfigFiles() []ConfigLayer {
+	return slices.Clone(layers)
 }
 
 // AllSettings returns all settings as a map
 func AllSettings() map[string]interface{} {
 	return viper.AllSettings()
 }
+
+// SecretSections are the config sections holding secrets, such as the webhook URL and SMTP
+// credentials of the notifications, left out of the configuration gonzo writes down.
+var SecretSections = []string{"notifications"}
+
+// SettingsWithoutSecrets returns all settings as a map, without the SecretSections.
+func SettingsWithoutSecrets() map[string]interface{} {
+	settings := AllSettings()
+	for _, key := range SecretSections {
+		delete(settings, key)
+	}
+	return settings
+}
+
+// Setting is the effective value of a config key and where it came from.
+type Setting struct {
+	Key    string
+	Value  interface{}
+	Source string
+}
+
+// Explain returns the effective value of every known config key, sorted by key, along with
+// its source: "--set", "flag --<name>", "env GONZO_<NAME>", "profile <name>", "<layer> config <path>",
+// "playbook <name>" or "default".
+// Flags are looked up on cmd's root command.
+func Explain(cmd *cobra.Command) []Setting {
+	flagNames := map[string]string{}
+	for _, key := range flagKeys {
+		flagNames[key] = key
+	}
+	for key, flag := range listFlags {
+		flagNames[key] = flag
+	}
+
+	keys := viper.AllKeys()
+	sort.Strings(keys)
+
+	settings := make([]Setting, 0, len(keys))
+	for _, key := range keys {
+		settings = append(settings, Setting{Key: key, Value: viper.Get(key), Source: source(cmd, key, flagNames[key])})
+	}
+	return settings
+}
+
+// source returns where the effective value of key comes from, following Viper's precedence.
+func source(cmd *cobra.Command, key string, flagName string) string {
+	if IsOverridden(key) {
+		return "--set"
+	}
+	if flagName != "" {
+		if flag := cmd.Root().PersistentFlags().Lookup(flagName); flag != nil && flag.Changed {
+			return "flag --" + flagName
+		}
+	}
+	env := EnvPrefix + "_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+	if _, ok := os.LookupEnv(env); ok {
+		return "env " + env
+	}
+	if profileKeys[key] {
+		return "profile " + profile
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		if layers[i].keys[key] {
+			return layers[i].Name + " config " + layers[i].Path
+		}
+	}
+	if slices.Contains(playbookKeys, key) {
+		return "playbook " + playbook
+	}
+	return "default"
+}
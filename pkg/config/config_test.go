@@ -3,7 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -30,10 +33,29 @@ func TestInit_DefaultValues(t *testing.T) {
 		{KeyModel, DefaultModel, func() interface{} { return GetModel() }},
 		{KeyMaxIterations, DefaultMaxIterations, func() interface{} { return GetMaxIterations() }},
 		{KeyQuiet, DefaultQuiet, func() interface{} { return GetQuiet() }},
+		{KeyVerbose, DefaultVerbose, func() interface{} { return GetVerbose() }},
 		{KeyNoBranch, DefaultNoBranch, func() interface{} { return GetNoBranch() }},
 		{KeyNoNewTests, DefaultNoNewTests, func() interface{} { return GetNoNewTests() }},
 		{KeyPR, DefaultPR, func() interface{} { return GetPR() }},
 		{KeyCommitAuthor, DefaultCommitAuthor, func() interface{} { return GetCommitAuthor() }},
+		{KeyConventionalCommits, DefaultConventionalCommits, func() interface{} { return GetConventionalCommits() }},
+		{KeyCommitTemplate, DefaultCommitTemplate, func() interface{} { return GetCommitTemplate() }},
+		{KeyWrapUpIterations, DefaultWrapUpIterations, func() interface{} { return GetWrapUpIterations() }},
+		{KeyPRComment, DefaultPRComment, func() interface{} { return GetPRComment() }},
+		{KeyPRCloseKeyword, DefaultPRCloseKeyword, func() interface{} { return GetPRCloseKeyword() }},
+		{KeyPRTitleIssuePrefix, DefaultPRTitleIssuePrefix, func() interface{} { return GetPRTitleIssuePrefix() }},
+		{KeyForge, DefaultForge, func() interface{} { return GetForge() }},
+		{KeyCI, DefaultCI, func() interface{} { return GetCI() }},
+		{KeyCompletionSignal, DefaultCompletionSignal, func() interface{} { return GetCompletionSignal() }},
+		{KeyCompletion, DefaultCompletion, func() interface{} { return GetCompletion() }},
+		{KeyCompletionPattern, DefaultCompletionPattern, func() interface{} { return GetCompletionPattern() }},
+		{KeyIterationTimeout, DefaultIterationTimeout, func() interface{} { return GetIterationTimeout() }},
+		{KeyMaxDuration, DefaultMaxDuration, func() interface{} { return GetMaxDuration() }},
+		{KeyRetries, DefaultRetries, func() interface{} { return GetRetries() }},
+		{KeyMaxCost, DefaultMaxCost, func() interface{} { return GetMaxCost() }},
+		{KeyRateLimitWait, DefaultRateLimitWait, func() interface{} { return GetRateLimitWait() }},
+		{KeySandbox, DefaultSandbox, func() interface{} { return GetSandbox() }},
+		{KeySandboxImage, DefaultSandboxImage, func() interface{} { return GetSandboxImage() }},
 	}
 
 	for _, tt := range tests {
@@ -58,6 +80,18 @@ func TestInit_EnvironmentVariables(t *testing.T) {
 		"GONZO_NO_NEW_TESTS":   "true",
 		"GONZO_PR":             "true",
 		"GONZO_COMMIT_AUTHOR":  "Test Author <test@example.com>",
+
+		"GONZO_CONVENTIONAL_COMMITS":  "false",
+		"GONZO_COMMIT_TEMPLATE":       "{{ .Subject }}",
+		"GONZO_WRAP_UP_ITERATIONS":    "2",
+		"GONZO_PR_COMMENT":            "true",
+		"GONZO_PR_CLOSE_KEYWORD":      "Fixes",
+		"GONZO_PR_TITLE_ISSUE_PREFIX": "true",
+		"GONZO_FORGE":                 "gitlab",
+		"GONZO_CI":                    "github",
+		"GONZO_ITERATION_TIMEOUT":     "15m",
+		"GONZO_RETRIES":               "2",
+		"GONZO_HOOKS_BEFORE_RUN":      "make deps",
 	}
 
 	for k, v := range envVars {
@@ -86,6 +120,17 @@ func TestInit_EnvironmentVariables(t *testing.T) {
 		{"no-new-tests", true, func() interface{} { return GetNoNewTests() }},
 		{"pr", true, func() interface{} { return GetPR() }},
 		{"commit-author", "Test Author <test@example.com>", func() interface{} { return GetCommitAuthor() }},
+		{"conventional-commits", false, func() interface{} { return GetConventionalCommits() }},
+		{"commit-template", "{{ .Subject }}", func() interface{} { return GetCommitTemplate() }},
+		{"wrap-up-iterations", 2, func() interface{} { return GetWrapUpIterations() }},
+		{"pr-comment", true, func() interface{} { return GetPRComment() }},
+		{"pr-close-keyword", "Fixes", func() interface{} { return GetPRCloseKeyword() }},
+		{"pr-title-issue-prefix", true, func() interface{} { return GetPRTitleIssuePrefix() }},
+		{"forge", "gitlab", func() interface{} { return GetForge() }},
+		{"ci", "github", func() interface{} { return GetCI() }},
+		{"iteration-timeout", 15 * time.Minute, func() interface{} { return GetIterationTimeout() }},
+		{"retries", 2, func() interface{} { return GetRetries() }},
+		{"hooks.before-run", "make deps", func() interface{} { return strings.Join(GetHooksBeforeRun(), ",") }},
 	}
 
 	for _, tt := range tests {
@@ -205,14 +250,73 @@ func TestBindFlags(t *testing.T) {
 	cmd.PersistentFlags().String(KeyModel, DefaultModel, "model")
 	cmd.PersistentFlags().Int(KeyMaxIterations, DefaultMaxIterations, "max iterations")
 	cmd.PersistentFlags().Bool(KeyQuiet, DefaultQuiet, "quiet mode")
+	cmd.PersistentFlags().CountP(KeyVerbose, "v", "verbosity")
 	cmd.PersistentFlags().Bool(KeyNoBranch, DefaultNoBranch, "no-branch")
 	cmd.PersistentFlags().Bool(KeyNoNewTests, DefaultNoNewTests, "no-new-tests")
 	cmd.PersistentFlags().Bool(KeyPR, DefaultPR, "pr")
 	cmd.PersistentFlags().String(KeyCommitAuthor, DefaultCommitAuthor, "commit author")
+	cmd.PersistentFlags().Bool(KeyConventionalCommits, DefaultConventionalCommits, "conventional commits")
+	cmd.PersistentFlags().String(KeyCommitTemplate, DefaultCommitTemplate, "commit template")
+	cmd.PersistentFlags().Int(KeyWrapUpIterations, DefaultWrapUpIterations, "wrap-up iterations")
+	cmd.PersistentFlags().Bool(KeyPRDraft, DefaultPRDraft, "draft pr")
+	cmd.PersistentFlags().Bool(KeyPRComment, DefaultPRComment, "pr comment")
+	cmd.PersistentFlags().String(KeyForge, DefaultForge, "forge")
+	cmd.PersistentFlags().String(KeyCI, DefaultCI, "ci")
+	cmd.PersistentFlags().String(KeyProfile, DefaultProfile, "profile")
+	cmd.PersistentFlags().Bool(KeyStrictConfig, DefaultStrictConfig, "strict config")
+	cmd.PersistentFlags().StringArray("pr-label", nil, "pr label")
+	cmd.PersistentFlags().StringArray("pr-reviewer", nil, "pr reviewer")
+	cmd.PersistentFlags().StringArray("pr-assignee", nil, "pr assignee")
+	cmd.PersistentFlags().String(KeyCompletionSignal, DefaultCompletionSignal, "completion signal")
+	cmd.PersistentFlags().String(KeyCompletion, DefaultCompletion, "completion")
+	cmd.PersistentFlags().String(KeyCompletionPattern, DefaultCompletionPattern, "completion pattern")
+	cmd.PersistentFlags().Duration(KeyIterationTimeout, DefaultIterationTimeout, "iteration timeout")
+	cmd.PersistentFlags().Duration(KeyMaxDuration, DefaultMaxDuration, "max duration")
+	cmd.PersistentFlags().Duration(KeyHeartbeat, DefaultHeartbeat, "heartbeat")
+	cmd.PersistentFlags().String(KeySessionMode, DefaultSessionMode, "session mode")
+	cmd.PersistentFlags().Bool(KeyBranchRunID, DefaultBranchRunID, "branch run id")
+	cmd.PersistentFlags().String(KeyPermissionMode, DefaultPermissionMode, "permission mode")
+	cmd.PersistentFlags().String(KeySandbox, DefaultSandbox, "sandbox")
+	cmd.PersistentFlags().String(KeySandboxImage, DefaultSandboxImage, "sandbox image")
+	cmd.PersistentFlags().StringArray("allowed-tool", nil, "allowed tool")
+	cmd.PersistentFlags().StringArray(KeyMCPConfig, nil, "mcp config")
+	cmd.PersistentFlags().StringArray("claude-arg", nil, "claude arg")
+	cmd.PersistentFlags().StringArray("agent", nil, "agent")
+	cmd.PersistentFlags().Int(KeyRetries, DefaultRetries, "retries")
+	cmd.PersistentFlags().Int(KeyMaxOutputTokens, DefaultMaxOutputTokens, "max output tokens")
+	cmd.PersistentFlags().Int(KeyThinkingBudget, DefaultThinkingBudget, "thinking budget")
+	cmd.PersistentFlags().Float64(KeyMaxCost, DefaultMaxCost, "max cost")
+	cmd.PersistentFlags().Duration(KeyRateLimitWait, DefaultRateLimitWait, "rate limit wait")
+	cmd.PersistentFlags().StringArray("protected-path", nil, "protected path")
+	cmd.PersistentFlags().StringArray("write-scope", nil, "write scope")
+	cmd.PersistentFlags().StringArray("env", nil, "env")
+	cmd.PersistentFlags().StringArray("env-deny", nil, "env deny")
+	cmd.PersistentFlags().StringArray("permission-allow", nil, "permission allow")
+	cmd.PersistentFlags().StringArray("permission-ask", nil, "permission ask")
+	cmd.PersistentFlags().StringArray("permission-deny", nil, "permission deny")
+	cmd.PersistentFlags().String(KeyPlaybook, DefaultPlaybook, "playbook")
+	cmd.PersistentFlags().String(KeyLanguage, DefaultLanguage, "language")
+	cmd.PersistentFlags().String(KeyOutput, DefaultOutput, "output")
+	cmd.PersistentFlags().String(KeyLogFormat, DefaultLogFormat, "log format")
+	cmd.PersistentFlags().String(KeyLogLevel, DefaultLogLevel, "log level")
+	cmd.PersistentFlags().Bool(KeyTranscripts, DefaultTranscripts, "transcripts")
+	cmd.PersistentFlags().Bool(KeyNoColor, DefaultNoColor, "no color")
+	cmd.PersistentFlags().String(KeyJUnit, DefaultJUnit, "junit")
+	cmd.PersistentFlags().String(KeyOutputFile, DefaultOutputFile, "output file")
+	cmd.PersistentFlags().String(KeyReportFile, DefaultReportFile, "report file")
+	cmd.PersistentFlags().Bool(KeyNoRepoMap, DefaultNoRepoMap, "no repository map")
+	cmd.PersistentFlags().StringArray("verify", nil, "verify")
+	cmd.PersistentFlags().StringArray("hook-before-run", nil, "before-run hook")
+	cmd.PersistentFlags().StringArray("hook-after-iteration", nil, "after-iteration hook")
+	cmd.PersistentFlags().StringArray("hook-after-run", nil, "after-run hook")
 
 	// Set a flag value
 	cmd.PersistentFlags().Set(KeyModel, "claude-haiku-4-5")
 	cmd.PersistentFlags().Set(KeyMaxIterations, "42")
+	cmd.PersistentFlags().Set("pr-label", "gonzo")
+	cmd.PersistentFlags().Set("pr-label", "bot")
+	cmd.PersistentFlags().Set(KeyIterationTimeout, "10m")
+	cmd.PersistentFlags().Set("hook-after-run", "make notify")
 
 	err := Init()
 	if err != nil {
@@ -231,6 +335,15 @@ func TestBindFlags(t *testing.T) {
 	if got := viper.GetInt(KeyMaxIterations); got != 42 {
 		t.Errorf("expected max-iterations from flag binding, got %v", got)
 	}
+	if got := GetPRLabels(); len(got) != 2 || got[0] != "gonzo" || got[1] != "bot" {
+		t.Errorf("expected pr-labels from repeatable flag binding, got %v", got)
+	}
+	if got := GetIterationTimeout(); got != 10*time.Minute {
+		t.Errorf("expected iteration-timeout from flag binding, got %v", got)
+	}
+	if got := GetHooksAfterRun(); len(got) != 1 || got[0] != "make notify" {
+		t.Errorf("expected hooks.after-run from repeatable flag binding, got %v", got)
+	}
 }
 
 func TestAllSettings(t *testing.T) {
@@ -252,6 +365,25 @@ func TestAllSettings(t *testing.T) {
 	}
 }
 
+func TestSettingsWithoutSecrets(t *testing.T) {
+	resetViper()
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+	viper.Set(KeyNotificationsWebhookURL, "https://hooks.example.com/secret")
+
+	settings := SettingsWithoutSecrets()
+	if _, ok := settings["notifications"]; ok {
+		t.Errorf("expected the notifications to be left out, got %v", settings["notifications"])
+	}
+	if _, ok := settings[KeyModel]; !ok {
+		t.Errorf("expected key %q in SettingsWithoutSecrets()", KeyModel)
+	}
+	if _, ok := AllSettings()["notifications"]; !ok {
+		t.Error("expected AllSettings() to be left as it was")
+	}
+}
+
 func TestInit_NoConfigFile(t *testing.T) {
 	resetViper()
 
@@ -272,3 +404,375 @@ func TestInit_NoConfigFile(t *testing.T) {
 		t.Errorf("expected default model, got %v", got)
 	}
 }
+
+func TestApplyOverrides(t *testing.T) {
+	resetViper()
+
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+
+	err := ApplyOverrides([]string{"max-iterations=7", "Model=claude-haiku-4-5", "gates.test-command=make check"})
+	if err != nil {
+		t.Fatalf("ApplyOverrides() returned error: %v", err)
+	}
+
+	if got := GetMaxIterations(); got != 7 {
+		t.Errorf("expected max-iterations 7, got %v", got)
+	}
+	if got := GetModel(); got != "claude-haiku-4-5" {
+		t.Errorf("expected model override, got %v", got)
+	}
+	if got := viper.GetString("gates.test-command"); got != "make check" {
+		t.Errorf("expected nested override, got %q", got)
+	}
+	if !IsOverridden(KeyModel) || IsOverridden(KeyQuiet) {
+		t.Error("expected only the overridden keys to be reported")
+	}
+}
+
+func TestApplyPlaybook(t *testing.T) {
+	resetViper()
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("gonzo.yaml", []byte("max-iterations: 8\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+
+	ApplyPlaybook("migration")
+	if got := GetWrapUpIterations(); got != 2 {
+		t.Errorf("expected the migration playbook's wrap-up-iterations, got %d", got)
+	}
+	if got := GetMaxIterations(); got != 8 {
+		t.Errorf("expected the config file to override the playbook's max-iterations, got %d", got)
+	}
+	cmd := &cobra.Command{Use: "test"}
+	for _, s := range Explain(cmd) {
+		if s.Key == KeyWrapUpIterations && s.Source != "playbook migration" {
+			t.Errorf("expected wrap-up-iterations from the playbook, got %q", s.Source)
+		}
+	}
+
+	ApplyPlaybook("docs")
+	if !GetNoNewTests() || GetWrapUpIterations() != DefaultWrapUpIterations {
+		t.Errorf("expected only the docs playbook's defaults, got no-new-tests %v and wrap-up-iterations %d", GetNoNewTests(), GetWrapUpIterations())
+	}
+	ApplyPlaybook("")
+	if GetNoNewTests() {
+		t.Error("expected the defaults to be restored without a playbook")
+	}
+}
+
+func TestApplyVars(t *testing.T) {
+	resetViper()
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("gonzo.yaml", []byte("vars:\n  project: gonzo\n  language: Go\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+
+	if err := ApplyVars([]string{"Language=Rust", "docs=https://example.com/style?x=1"}); err != nil {
+		t.Fatalf("ApplyVars() returned error: %v", err)
+	}
+	want := map[string]string{"project": "gonzo", "language": "Rust", "docs": "https://example.com/style?x=1"}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Vars, want) || !reflect.DeepEqual(GetVars(), want) {
+		t.Errorf("expected vars %v, got %v and %v", want, cfg.Vars, GetVars())
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("Validate() returned error: %v", err)
+	}
+
+	for _, pair := range []string{"project", "=x", "a.b=c"} {
+		if err := ApplyVars([]string{pair}); err == nil {
+			t.Errorf("expected an error for %q", pair)
+		}
+	}
+}
+
+func TestApplySettings(t *testing.T) {
+	resetViper()
+
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+
+	// Values decoded from JSON arrive as float64 and []interface{}
+	ApplySettings(map[string]interface{}{
+		KeyMaxIterations: float64(4),
+		KeyPRLabels:      []interface{}{"gonzo", "bot"},
+	})
+
+	if got := GetMaxIterations(); got != 4 {
+		t.Errorf("expected max-iterations 4, got %v", got)
+	}
+	if got := GetPRLabels(); len(got) != 2 || got[1] != "bot" {
+		t.Errorf("expected labels [gonzo bot], got %v", got)
+	}
+	if !IsOverridden(KeyMaxIterations) {
+		t.Error("expected applied settings to be reported as overridden")
+	}
+}
+
+func TestApplyOverrides_Invalid(t *testing.T) {
+	resetViper()
+
+	for _, pair := range []string{"no-equals-sign", "=value"} {
+		if err := ApplyOverrides([]string{pair}); err == nil {
+			t.Errorf("expected error for %q", pair)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	resetViper()
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "gonzo.yaml")
+	if err := os.WriteFile(configPath, []byte("model: claude-sonnet-4-5\nmax-iterations: 15\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Chdir(tmpDir)
+	t.Setenv("GONZO_MAX_ITERATIONS", "20")
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.PersistentFlags().Bool(KeyQuiet, DefaultQuiet, "quiet mode")
+	cmd.PersistentFlags().StringArray("pr-label", nil, "pr label")
+	cmd.PersistentFlags().Set("pr-label", "gonzo")
+
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+	if err := viper.BindPFlag(KeyPRLabels, cmd.PersistentFlags().Lookup("pr-label")); err != nil {
+		t.Fatalf("failed to bind flag: %v", err)
+	}
+	if err := ApplyOverrides([]string{"forge=gitlab"}); err != nil {
+		t.Fatalf("ApplyOverrides() returned error: %v", err)
+	}
+
+	sources := map[string]string{}
+	for _, s := range Explain(cmd) {
+		sources[s.Key] = s.Source
+	}
+
+	expected := map[string]string{
+		KeyModel:         "project config " + ConfigFileUsed(),
+		KeyMaxIterations: "env GONZO_MAX_ITERATIONS",
+		KeyPRLabels:      "flag --pr-label",
+		KeyForge:         "--set",
+		KeyQuiet:         "default",
+	}
+	for key, want := range expected {
+		if got := sources[key]; got != want {
+			t.Errorf("source of %s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestInit_LayeredConfigFiles(t *testing.T) {
+	resetViper()
+
+	configHome := t.TempDir()
+	userConfig := filepath.Join(configHome, "gonzo", "gonzo.yaml")
+	if err := os.MkdirAll(filepath.Dir(userConfig), 0755); err != nil {
+		t.Fatalf("failed to create user config directory: %v", err)
+	}
+	if err := os.WriteFile(userConfig, []byte("model: claude-haiku-4-5\nmax-iterations: 5\npr: false\n"), 0644); err != nil {
+		t.Fatalf("failed to write user config: %v", err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	projectDir := t.TempDir()
+	t.Chdir(projectDir)
+	if err := os.WriteFile("gonzo.yaml", []byte("max-iterations: 15\nforge: gitlab\n"), 0644); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+	if err := os.MkdirAll(".gonzo", 0755); err != nil {
+		t.Fatalf("failed to create .gonzo: %v", err)
+	}
+	if err := os.WriteFile(ProjectConfigFile, []byte("max-iterations: 20\n"), 0644); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+
+	if got := GetModel(); got != "claude-haiku-4-5" {
+		t.Errorf("expected model from the user config, got %v", got)
+	}
+	if got := GetPR(); got {
+		t.Errorf("expected pr from the user config, got %v", got)
+	}
+	if got := GetForge(); got != "gitlab" {
+		t.Errorf("expected forge from ./gonzo.yaml, got %v", got)
+	}
+	if got := GetMaxIterations(); got != 20 {
+		t.Errorf("expected max-iterations from %s, got %v", ProjectConfigFile, got)
+	}
+
+	files := ConfigFiles()
+	if len(files) != 3 || files[0].Name != LayerUser || files[2].Name != LayerProject {
+		t.Fatalf("unexpected config files %+v", files)
+	}
+	if ConfigFileUsed() != files[2].Path {
+		t.Errorf("expected ConfigFileUsed() to return %s, got %s", files[2].Path, ConfigFileUsed())
+	}
+
+	sources := map[string]string{}
+	for _, s := range Explain(&cobra.Command{Use: "test"}) {
+		sources[s.Key] = s.Source
+	}
+	if want := "user config " + userConfig; sources[KeyModel] != want {
+		t.Errorf("source of model = %q, want %q", sources[KeyModel], want)
+	}
+	if want := "project config " + files[2].Path; sources[KeyMaxIterations] != want {
+		t.Errorf("source of max-iterations = %q, want %q", sources[KeyMaxIterations], want)
+	}
+}
+
+func TestInit_TOMLAndJSONConfigFiles(t *testing.T) {
+	resetViper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	t.Chdir(t.TempDir())
+	tomlContent := `model = "claude-haiku-4-5"
+max-iterations = 7
+pr-labels = ["gonzo"]
+
+[hooks]
+after-run = ["make notify"]
+`
+	if err := os.WriteFile("gonzo.toml", []byte(tomlContent), 0644); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+	if err := os.MkdirAll(".gonzo", 0755); err != nil {
+		t.Fatalf("failed to create .gonzo: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(".gonzo", "config.json"), []byte(`{"max-iterations": 9}`), 0644); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+	if got := GetModel(); got != "claude-haiku-4-5" {
+		t.Errorf("expected model from gonzo.toml, got %v", got)
+	}
+	if got := GetHooksAfterRun(); len(got) != 1 || got[0] != "make notify" {
+		t.Errorf("expected the hooks table from gonzo.toml, got %v", got)
+	}
+	if got := GetMaxIterations(); got != 9 {
+		t.Errorf("expected max-iterations from .gonzo/config.json, got %v", got)
+	}
+	if files := ConfigFiles(); len(files) != 2 || filepath.Base(files[0].Path) != "gonzo.toml" {
+		t.Errorf("unexpected config files %+v", files)
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	resetViper()
+
+	t.Chdir(t.TempDir())
+	configContent := `model: claude-sonnet-4-5
+max-iterations: 10
+profiles:
+  cheap:
+    model: claude-haiku-4-5
+    max-iterations: 5
+    pr: false
+  shipit:
+    model: claude-opus-4-5
+    max-iterations: 25
+`
+	if err := os.WriteFile("gonzo.yaml", []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Setenv("GONZO_MAX_ITERATIONS", "7")
+
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+	if got := Profiles(); len(got) != 2 || got[0] != "cheap" || got[1] != "shipit" {
+		t.Errorf("unexpected profiles %v", got)
+	}
+	if err := ApplyProfile("cheap"); err != nil {
+		t.Fatalf("ApplyProfile() returned error: %v", err)
+	}
+
+	if got := GetModel(); got != "claude-haiku-4-5" {
+		t.Errorf("expected model from the profile, got %v", got)
+	}
+	if got := GetPR(); got {
+		t.Errorf("expected pr from the profile, got %v", got)
+	}
+	if got := GetMaxIterations(); got != 7 {
+		t.Errorf("expected the environment to override the profile, got %v", got)
+	}
+
+	sources := map[string]string{}
+	for _, s := range Explain(&cobra.Command{Use: "test"}) {
+		sources[s.Key] = s.Source
+	}
+	if sources[KeyModel] != "profile cheap" {
+		t.Errorf("source of model = %q, want %q", sources[KeyModel], "profile cheap")
+	}
+
+	if err := ApplyProfile("fancy"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
+
+func TestInit_ExplicitConfigFile(t *testing.T) {
+	resetViper()
+
+	projectDir := t.TempDir()
+	t.Chdir(projectDir)
+	if err := os.WriteFile("gonzo.yaml", []byte("model: claude-sonnet-4-5\nforge: gitlab\n"), 0644); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+	explicit := filepath.Join(t.TempDir(), "ci.yaml")
+	if err := os.WriteFile(explicit, []byte("model: claude-haiku-4-5\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	t.Setenv(ConfigEnvVar, explicit)
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+	if got := GetModel(); got != "claude-haiku-4-5" {
+		t.Errorf("expected model from %s, got %v", explicit, got)
+	}
+	if got := GetForge(); got != DefaultForge {
+		t.Errorf("expected the project config to be skipped, got forge %v", got)
+	}
+	if files := ConfigFiles(); len(files) != 1 || files[0].Name != LayerExplicit || files[0].Path != explicit {
+		t.Errorf("unexpected config files %+v", files)
+	}
+
+	// SetConfigFile takes precedence over the environment variable
+	SetConfigFile("gonzo.yaml")
+	defer SetConfigFile("")
+	resetViper()
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+	if got := GetModel(); got != "claude-sonnet-4-5" {
+		t.Errorf("expected model from gonzo.yaml, got %v", got)
+	}
+
+	SetConfigFile(filepath.Join(projectDir, "missing.yaml"))
+	if err := Init(); err == nil {
+		t.Error("expected an error for a missing config file")
+	}
+}
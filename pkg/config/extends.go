@@ -0,0 +1,142 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExtendsCacheTTL is how long a config file extended by URL is used from the cache before it
+// is downloaded again.
+const ExtendsCacheTTL = 24 * time.Hour
+
+// extendsTimeout bounds the download of a config file extended by URL.
+const extendsTimeout = 10 * time.Second
+
+// extendsClient downloads the config files extended by URL, over https only: a config file runs
+// commands, through its hooks and verification. Replaceable for testing.
+var extendsClient = &http.Client{
+	Timeout: extendsTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "https" {
+			return fmt.Errorf("refused the redirect to %s, which is not https", req.URL)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	},
+}
+
+// refreshExtends is set with SetRefreshConfig.
+var refreshExtends bool
+
+// SetRefreshConfig makes Init download the config files extended by URL again, instead of
+// using the copies cached within ExtendsCacheTTL.
+func SetRefreshConfig(refresh bool) {
+	refreshExtends = refresh
+}
+
+// isURL reports whether the location of a config file is an http or https URL. Only the https
+// ones are downloaded.
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// extendedLayer returns the layer of the config file extended by layer: base is a URL, or a
+// path relative to the file extending it. Config files extended by URL are read from the cache.
+func extendedLayer(layer ConfigLayer, base string) (ConfigLayer, error) {
+	if isURL(layer.Path) && !isURL(base) {
+		from, err := url.Parse(layer.Path)
+		if err != nil {
+			return ConfigLayer{}, err
+		}
+		ref, err := url.Parse(base)
+		if err != nil {
+			return ConfigLayer{}, fmt.Errorf("invalid extends %q: %w", base, err)
+		}
+		base = from.ResolveReference(ref).String()
+	}
+
+	if isURL(base) {
+		if !strings.HasPrefix(base, "https://") {
+			return ConfigLayer{}, fmt.Errorf("config file %s extends %s: only https URLs are supported", layer.Path, base)
+		}
+		file, err := fetchExtends(base)
+		if err != nil {
+			return ConfigLayer{}, err
+		}
+		return ConfigLayer{Name: LayerExtends, Path: base, file: file}, nil
+	}
+
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(filepath.Dir(layer.Path), base)
+	}
+	if !isFile(base) {
+		return ConfigLayer{}, fmt.Errorf("config file %s extends %s, which does not exist", layer.Path, base)
+	}
+	return ConfigLayer{Name: LayerExtends, Path: base}, nil
+}
+
+// fetchExtends returns the cached copy of the config file at rawURL, downloading it when it is
+// missing, older than ExtendsCacheTTL or a refresh was asked for. A stale copy is used when
+// the download fails.
+func fetchExtends(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid extends %q: %w", rawURL, err)
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the cache directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(rawURL))
+	file := filepath.Join(cacheDir, "gonzo", "extends", hex.EncodeToString(sum[:8])+"."+configType(path.Base(u.Path)))
+
+	info, statErr := os.Stat(file)
+	if statErr == nil && !refreshExtends && time.Since(info.ModTime()) < ExtendsCacheTTL {
+		return file, nil
+	}
+
+	data, err := download(rawURL)
+	if err != nil {
+		if statErr == nil {
+			return file, nil
+		}
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return "", fmt.Errorf("failed to create the config cache: %w", err)
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to cache %s: %w", rawURL, err)
+	}
+	return file, nil
+}
+
+// download returns the body of a successful GET of rawURL.
+func download(rawURL string) ([]byte, error) {
+	resp, err := extendsClient.Get(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download config file %s: %w", rawURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download config file %s: %s", rawURL, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download config file %s: %w", rawURL, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInit_ExtendsPath(t *testing.T) {
+	resetViper()
+	defer SetConfigFile("")
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "shared"), 0755); err != nil {
+		t.Fatalf("failed to create shared directory: %v", err)
+	}
+	base := filepath.Join(dir, "shared", "base.toml")
+	if err := os.WriteFile(base, []byte("model = \"claude-haiku-4-5\"\nmax-iterations = 5\nverify = [\"make test\"]\n"), 0644); err != nil {
+		t.Fatalf("failed to write base config: %v", err)
+	}
+	local := filepath.Join(dir, "gonzo.yaml")
+	if err := os.WriteFile(local, []byte("extends: shared/base.toml\nmax-iterations: 8\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	SetConfigFile(local)
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+	if got := GetModel(); got != "claude-haiku-4-5" {
+		t.Errorf("expected model from the extended file, got %v", got)
+	}
+	if got := GetMaxIterations(); got != 8 {
+		t.Errorf("expected max-iterations from the extending file, got %v", got)
+	}
+	if got := GetVerify(); len(got) != 1 || got[0] != "make test" {
+		t.Errorf("expected verify from the extended file, got %v", got)
+	}
+	if files := ConfigFiles(); len(files) != 2 || files[0].Name != LayerExtends || files[0].Path != base {
+		t.Errorf("unexpected config files %+v", files)
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("Validate() returned error: %v", err)
+	}
+
+	// A file extending itself through another one
+	if err := os.WriteFile(base, []byte("extends = \"../gonzo.yaml\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write base config: %v", err)
+	}
+	resetViper()
+	if err := Init(); err == nil || !strings.Contains(err.Error(), "extends it back") {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+
+	if err := os.WriteFile(local, []byte("extends: missing.yaml\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	resetViper()
+	if err := Init(); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected an error for a missing extended file, got %v", err)
+	}
+}
+
+func TestInit_ExtendsURL(t *testing.T) {
+	resetViper()
+	defer SetConfigFile("")
+	defer SetRefreshConfig(false)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	model := "claude-haiku-4-5"
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case model == "":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case r.URL.Path == "/team/gonzo.yaml":
+			_, _ = w.Write([]byte("extends: base.yaml\nmodel: " + model + "\n"))
+		case r.URL.Path == "/team/base.yaml":
+			_, _ = w.Write([]byte("pr-draft: true\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	originalClient := extendsClient
+	defer func() { extendsClient = originalClient }()
+	extendsClient = server.Client()
+
+	local := filepath.Join(t.TempDir(), "gonzo.yaml")
+	if err := os.WriteFile(local, []byte("extends: "+server.URL+"/team/gonzo.yaml\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	SetConfigFile(local)
+
+	load := func() {
+		t.Helper()
+		resetViper()
+		if err := Init(); err != nil {
+			t.Fatalf("Init() returned error: %v", err)
+		}
+	}
+
+	load()
+	if got := GetModel(); got != "claude-haiku-4-5" {
+		t.Errorf("expected model from the URL, got %v", got)
+	}
+	if got := GetPRDraft(); !got {
+		t.Error("expected pr-draft from the file the URL extends")
+	}
+	if files := ConfigFiles(); len(files) != 3 || files[1].Path != server.URL+"/team/gonzo.yaml" {
+		t.Errorf("unexpected config files %+v", files)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 downloads, got %d", requests)
+	}
+
+	// Cached copies are used until a refresh is asked for
+	model = "claude-sonnet-4-5"
+	load()
+	if got := GetModel(); got != "claude-haiku-4-5" || requests != 2 {
+		t.Errorf("expected the cached model without downloads, got %v after %d downloads", got, requests)
+	}
+	SetRefreshConfig(true)
+	load()
+	if got := GetModel(); got != "claude-sonnet-4-5" || requests != 4 {
+		t.Errorf("expected the refreshed model, got %v after %d downloads", got, requests)
+	}
+
+	// A failed refresh falls back to the cached copies
+	model = ""
+	load()
+	if got := GetModel(); got != "claude-sonnet-4-5" {
+		t.Errorf("expected the cached model, got %v", got)
+	}
+}
+
+func TestInit_ExtendsHTTP(t *testing.T) {
+	resetViper()
+	defer SetConfigFile("")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte("model: claude-haiku-4-5\n"))
+	}))
+	defer server.Close()
+
+	local := filepath.Join(t.TempDir(), "gonzo.yaml")
+	if err := os.WriteFile(local, []byte("extends: "+server.URL+"/team/gonzo.yaml\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	SetConfigFile(local)
+
+	if err := Init(); err == nil || !strings.Contains(err.Error(), "only https URLs are supported") {
+		t.Errorf("expected plain http to be refused, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("expected no downloads, got %d", requests)
+	}
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config is the resolved configuration, with a field for every config key.
+type Config struct {
+	Model         string `mapstructure:"model"`
+	MaxIterations int    `mapstructure:"max-iterations"`
+	Quiet         bool   `mapstructure:"quiet"`
+	Verbose       int    `mapstructure:"verbose"`
+	NoBranch      bool   `mapstructure:"no-branch"`
+	NoNewTests    bool   `mapstructure:"no-new-tests"`
+	PR            bool   `mapstructure:"pr"`
+	CommitAuthor  string `mapstructure:"commit-author"`
+
+	ConventionalCommits bool     `mapstructure:"conventional-commits"`
+	CommitTemplate      string   `mapstructure:"commit-template"`
+	WrapUpIterations    int      `mapstructure:"wrap-up-iterations"`
+	PRDraft             bool     `mapstructure:"pr-draft"`
+	PRLabels            []string `mapstructure:"pr-labels"`
+	PRReviewers         []string `mapstructure:"pr-reviewers"`
+	PRAssignees         []string `mapstructure:"pr-assignees"`
+	PRComment           bool     `mapstructure:"pr-comment"`
+	PRCloseKeyword      string   `mapstructure:"pr-close-keyword"`
+	PRTitleIssuePrefix  bool     `mapstructure:"pr-title-issue-prefix"`
+	Forge               string   `mapstructure:"forge"`
+	CI                  string   `mapstructure:"ci"`
+	Profile             string   `mapstructure:"profile"`
+	StrictConfig        bool     `mapstructure:"strict-config"`
+	Playbook            string   `mapstructure:"playbook"`
+	Language            string   `mapstructure:"language"`
+	Output              string   `mapstructure:"output"`
+	LogFormat           string   `mapstructure:"log-format"`
+	LogLevel            string   `mapstructure:"log-level"`
+	NoRepoMap           bool     `mapstructure:"no-repomap"`
+	Transcripts         bool     `mapstructure:"transcripts"`
+	NoColor             bool     `mapstructure:"no-color"`
+	JUnit               string   `mapstructure:"junit"`
+	OutputFile          string   `mapstructure:"output-file"`
+	ReportFile          string   `mapstructure:"report-file"`
+	BranchRunID         bool     `mapstructure:"branch-run-id"`
+	PermissionMode      string   `mapstructure:"permission-mode"`
+	Sandbox             string   `mapstructure:"sandbox"`
+	SandboxImage        string   `mapstructure:"sandbox-image"`
+	AllowedTools        []string `mapstructure:"allowed-tools"`
+	MCPConfig           []string `mapstructure:"mcp-config"`
+	Agents              []string `mapstructure:"agents"`
+	ClaudeArgs          []string `mapstructure:"claude-args"`
+
+	CompletionSignal  string           `mapstructure:"completion-signal"`
+	Completion        string           `mapstructure:"completion"`
+	CompletionPattern string           `mapstructure:"completion-pattern"`
+	IterationTimeout  time.Duration    `mapstructure:"iteration-timeout"`
+	MaxDuration       time.Duration    `mapstructure:"max-duration"`
+	Heartbeat         time.Duration    `mapstructure:"heartbeat"`
+	SessionMode       string           `mapstructure:"session-mode"`
+	Retries           int              `mapstructure:"retries"`
+	MaxOutputTokens   int              `mapstructure:"max-output-tokens"`
+	ThinkingBudget    int              `mapstructure:"thinking-budget"`
+	RateLimitWait     time.Duration    `mapstructure:"rate-limit-wait"`
+	MaxCost           float64          `mapstructure:"max-cost"`
+	ProtectedPaths    []string         `mapstructure:"protected-paths"`
+	WriteScope        []string         `mapstructure:"write-scope"`
+	Verify            []string         `mapstructure:"verify"`
+	Hooks             Hooks            `mapstructure:"hooks"`
+	PermissionPolicy  PermissionPolicy `mapstructure:"permission-policy"`
+	Env               Env              `mapstructure:"env"`
+	Guidance          Guidance         `mapstructure:"guidance"`
+	Prompt            Prompt           `mapstructure:"prompt"`
+	Notifications     Notifications    `mapstructure:"notifications"`
+
+	Vars map[string]string `mapstructure:"vars"`
+}
+
+// Hooks are the shell commands run at points of a run, from the hooks section.
+type Hooks struct {
+	BeforeRun      []string `mapstructure:"before-run"`
+	AfterIteration []string `mapstructure:"after-iteration"`
+	AfterRun       []string `mapstructure:"after-run"`
+}
+
+// PermissionPolicy decides the permission prompts of the proxy permission mode, from the
+// permission-policy section.
+type PermissionPolicy struct {
+	Allow []string `mapstructure:"allow"`
+	Ask   []string `mapstructure:"ask"`
+	Deny  []string `mapstructure:"deny"`
+}
+
+// Env controls the environment of the Claude CLI, from the env section.
+type Env struct {
+	Allow []string `mapstructure:"allow"`
+	Deny  []string `mapstructure:"deny"`
+	Set   []string `mapstructure:"set"`
+}
+
+// Guidance selects the project guidance files added to the system prompt, from the guidance section.
+type Guidance struct {
+	Mode    string   `mapstructure:"mode"`
+	Files   []string `mapstructure:"files"`
+	MaxSize int      `mapstructure:"max-size"`
+}
+
+// Prompt bounds the size of the system prompt, from the prompt section.
+type Prompt struct {
+	MaxTokens int      `mapstructure:"max-tokens"`
+	Truncate  []string `mapstructure:"truncate"`
+}
+
+// Notifications selects where and when runs are notified, from the notifications section.
+type Notifications struct {
+	WebhookURL string   `mapstructure:"webhook-url"`
+	Email      Email    `mapstructure:"email"`
+	Events     []string `mapstructure:"events"`
+}
+
+// Email selects the recipients of notification emails and the SMTP server they are sent through.
+type Email struct {
+	To       []string `mapstructure:"to"`
+	From     string   `mapstructure:"from"`
+	SMTP     string   `mapstructure:"smtp"`
+	Username string   `mapstructure:"username"`
+}
+
+// Default returns the configuration made of the default values only, for programs
+// embedding gonzo without reading config files, environment variables or flags.
+func Default() *Config {
+	return &Config{
+		Model:         DefaultModel,
+		MaxIterations: DefaultMaxIterations,
+		Quiet:         DefaultQuiet,
+		Verbose:       DefaultVerbose,
+		NoBranch:      DefaultNoBranch,
+		NoNewTests:    DefaultNoNewTests,
+		PR:            DefaultPR,
+		CommitAuthor:  DefaultCommitAuthor,
+
+		ConventionalCommits: DefaultConventionalCommits,
+		CommitTemplate:      DefaultCommitTemplate,
+		WrapUpIterations:    DefaultWrapUpIterations,
+		PRDraft:             DefaultPRDraft,
+		PRLabels:            []string{},
+		PRReviewers:         []string{},
+		PRAssignees:         []string{},
+		PRComment:           DefaultPRComment,
+		PRCloseKeyword:      DefaultPRCloseKeyword,
+		PRTitleIssuePrefix:  DefaultPRTitleIssuePrefix,
+		Forge:               DefaultForge,
+		CI:                  DefaultCI,
+		Profile:             DefaultProfile,
+		StrictConfig:        DefaultStrictConfig,
+		Playbook:            DefaultPlaybook,
+		Language:            DefaultLanguage,
+		Output:              DefaultOutput,
+		LogFormat:           DefaultLogFormat,
+		LogLevel:            DefaultLogLevel,
+		NoRepoMap:           DefaultNoRepoMap,
+		Transcripts:         DefaultTranscripts,
+		NoColor:             DefaultNoColor,
+		JUnit:               DefaultJUnit,
+		OutputFile:          DefaultOutputFile,
+		ReportFile:          DefaultReportFile,
+		BranchRunID:         DefaultBranchRunID,
+		PermissionMode:      DefaultPermissionMode,
+		Sandbox:             DefaultSandbox,
+		SandboxImage:        DefaultSandboxImage,
+		AllowedTools:        slices.Clone(DefaultAllowedTools),
+		MCPConfig:           []string{},
+		Agents:              []string{},
+		ClaudeArgs:          []string{},
+
+		CompletionSignal:  DefaultCompletionSignal,
+		Completion:        DefaultCompletion,
+		CompletionPattern: DefaultCompletionPattern,
+		IterationTimeout:  DefaultIterationTimeout,
+		MaxDuration:       DefaultMaxDuration,
+		Heartbeat:         DefaultHeartbeat,
+		SessionMode:       DefaultSessionMode,
+		Retries:           DefaultRetries,
+		MaxOutputTokens:   DefaultMaxOutputTokens,
+		ThinkingBudget:    DefaultThinkingBudget,
+		RateLimitWait:     DefaultRateLimitWait,
+		MaxCost:           DefaultMaxCost,
+		ProtectedPaths:    []string{},
+		WriteScope:        []string{},
+		Verify:            []string{},
+		Hooks: Hooks{
+			BeforeRun:      []string{},
+			AfterIteration: []string{},
+			AfterRun:       []string{},
+		},
+		PermissionPolicy: PermissionPolicy{
+			Allow: []string{},
+			Ask:   []string{},
+			Deny:  []string{},
+		},
+		Env: Env{
+			Allow: []string{},
+			Deny:  slices.Clone(DefaultEnvDeny),
+			Set:   []string{},
+		},
+		Guidance: Guidance{
+			Mode:    DefaultGuidanceMode,
+			Files:   slices.Clone(DefaultGuidanceFiles),
+			MaxSize: DefaultGuidanceMaxSize,
+		},
+		Prompt: Prompt{
+			MaxTokens: DefaultPromptMaxTokens,
+			Truncate:  slices.Clone(DefaultPromptTruncate),
+		},
+		Notifications: Notifications{
+			WebhookURL: DefaultNotificationsWebhookURL,
+			Email: Email{
+				To:       []string{},
+				From:     DefaultNotificationsEmailFrom,
+				SMTP:     DefaultNotificationsEmailSMTP,
+				Username: DefaultNotificationsEmailUsername,
+			},
+			Events: slices.Clone(DefaultNotificationsEvents),
+		},
+
+		Vars: map[string]string{},
+	}
+}
+
+// Load returns the configuration resolved by Init, BindFlags, ApplyProfile and the overrides
+// applied since, as a typed struct.
+func Load() (*Config, error) {
+	return LoadFrom(viper.GetViper())
+}
+
+// LoadFrom returns the configuration held by v. Programs and tests that keep their own
+// Viper instance use it instead of Load, which reads the global one.
+func LoadFrom(v *viper.Viper) (*Config, error) {
+	c := Default()
+	if err := v.Unmarshal(c); err != nil {
+		return nil, fmt.Errorf("failed to decode configuration: %w", err)
+	}
+	c.Vars = v.GetStringMapString(KeyVars)
+	c.Verify = commandList(v, KeyVerify)
+	c.Hooks.BeforeRun = commandList(v, KeyHooksBeforeRun)
+	c.Hooks.AfterIteration = commandList(v, KeyHooksAfterIteration)
+	c.Hooks.AfterRun = commandList(v, KeyHooksAfterRun)
+	c.Env.Set = commandList(v, KeyEnvSet)
+	return c, nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestLoad(t *testing.T) {
+	resetViper()
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("gonzo.yaml", []byte("max-iterations: 15\npr-labels: [gonzo, bot]\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Setenv("GONZO_PR_DRAFT", "true")
+
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+	if err := ApplyOverrides([]string{"forge=gitlab"}); err != nil {
+		t.Fatalf("ApplyOverrides() returned error: %v", err)
+	}
+
+	c, err := Load()
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if c.MaxIterations != 15 {
+		t.Errorf("expected max-iterations from the config file, got %d", c.MaxIterations)
+	}
+	if len(c.PRLabels) != 2 || c.PRLabels[1] != "bot" {
+		t.Errorf("expected pr-labels from the config file, got %v", c.PRLabels)
+	}
+	if !c.PRDraft {
+		t.Error("expected pr-draft from the environment")
+	}
+	if c.Forge != "gitlab" {
+		t.Errorf("expected forge from --set, got %q", c.Forge)
+	}
+	if c.CompletionSignal != DefaultCompletionSignal || c.IterationTimeout != DefaultIterationTimeout {
+		t.Errorf("expected the loop control defaults, got %+v", c)
+	}
+	if c.Model != DefaultModel || c.CommitAuthor != DefaultCommitAuthor {
+		t.Errorf("expected defaults for the other keys, got %+v", c)
+	}
+}
+
+func TestLoadFrom(t *testing.T) {
+	v := viper.New()
+	v.Set(KeyModel, "claude-haiku-4-5")
+	v.Set(KeyWrapUpIterations, "3")
+
+	c, err := LoadFrom(v)
+	if err != nil {
+		t.Fatalf("LoadFrom() returned error: %v", err)
+	}
+	if c.Model != "claude-haiku-4-5" || c.WrapUpIterations != 3 {
+		t.Errorf("expected the instance's values, got %+v", c)
+	}
+	if c.MaxIterations != DefaultMaxIterations {
+		t.Errorf("expected the default max-iterations, got %d", c.MaxIterations)
+	}
+
+	v.Set(KeyMaxDuration, "1h30m")
+	v.Set(KeyHooksAfterIteration, []string{"make lint"})
+	c, err = LoadFrom(v)
+	if err != nil {
+		t.Fatalf("LoadFrom() returned error: %v", err)
+	}
+	if c.MaxDuration != 90*time.Minute {
+		t.Errorf("expected max-duration to be decoded, got %v", c.MaxDuration)
+	}
+	if len(c.Hooks.AfterIteration) != 1 || c.Hooks.AfterIteration[0] != "make lint" {
+		t.Errorf("expected the nested hooks to be decoded, got %+v", c.Hooks)
+	}
+
+	v.Set(KeyMaxIterations, "many")
+	if _, err := LoadFrom(v); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+// ReloadableKeys are the keys whose changes Reload applies: the budgets and tuning of a run,
+// which only affect the runs started after the change.
+var ReloadableKeys = []string{KeyMaxIterations, KeyWrapUpIterations, KeyIterationTimeout, KeyMaxDuration, KeyRetries, KeyPlaybook}
+
+// Changed reports whether the config files changed since Init loaded them: one of them was
+// modified or removed, or a config file appeared where Init looks for them.
+func Changed() bool {
+	found, err := configLayers()
+	if err != nil {
+		return true
+	}
+	var loaded []string
+	for _, layer := range layers {
+		if layer.Name != LayerExtends {
+			loaded = append(loaded, layer.Path)
+		}
+	}
+	if len(found) != len(loaded) {
+		return true
+	}
+	for i, layer := range found {
+		if layer.Path != loaded[i] {
+			return true
+		}
+	}
+
+	for _, layer := range layers {
+		info, err := os.Stat(layer.readPath())
+		if err != nil || !info.ModTime().Equal(layer.modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reload resets the configuration and loads it again with load, which calls Init and applies
+// whatever the program applies on top of it. Only the ReloadableKeys take their new values:
+// every other key keeps its previous one until the program restarts. Reload returns the keys
+// whose new value was applied and the ones whose change was ignored, sorted. When load fails,
+// the previous configuration is kept.
+func Reload(load func() error) (applied []string, ignored []string, err error) {
+	previous := map[string]interface{}{}
+	for _, key := range viper.AllKeys() {
+		previous[key] = viper.Get(key)
+	}
+	previousLayers := layers
+
+	viper.Reset()
+	if err := load(); err != nil {
+		viper.Reset()
+		for key, value := range previous {
+			viper.Set(key, value)
+		}
+		// Keep the files' new state, so that the same failure is not reported again
+		layers = reloadedLayers(previousLayers)
+		return nil, nil, err
+	}
+
+	keys := map[string]bool{}
+	for _, key := range viper.AllKeys() {
+		keys[key] = true
+	}
+	for key := range previous {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		value := viper.Get(key)
+		if reflect.DeepEqual(value, previous[key]) {
+			continue
+		}
+		if slices.Contains(ReloadableKeys, key) {
+			applied = append(applied, key)
+			continue
+		}
+		ignored = append(ignored, key)
+		viper.Set(key, previous[key])
+	}
+	return applied, ignored, nil
+}
+
+// reloadedLayers returns the layers with the current modification times of their files.
+func reloadedLayers(previous []ConfigLayer) []ConfigLayer {
+	reloaded := make([]ConfigLayer, len(previous))
+	for i, layer := range previous {
+		if info, err := os.Stat(layer.readPath()); err == nil {
+			layer.modTime = info.ModTime()
+		}
+		reloaded[i] = layer
+	}
+	return reloaded
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	resetViper()
+	t.Chdir(t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	write := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile("gonzo.yaml", []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+		if err := os.Chtimes("gonzo.yaml", modTime, modTime); err != nil {
+			t.Fatalf("failed to set the modification time: %v", err)
+		}
+	}
+
+	start := time.Now().Add(-time.Hour)
+	write("model: claude-haiku-4-5\nmax-iterations: 5\n", start)
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+	if Changed() {
+		t.Error("expected the config files to be unchanged after Init")
+	}
+
+	write("model: claude-sonnet-4-5\nmax-iterations: 8\nplaybook: docs\n", start.Add(time.Minute))
+	if !Changed() {
+		t.Fatal("expected the modified config file to be noticed")
+	}
+	applied, ignored, err := Reload(Init)
+	if err != nil {
+		t.Fatalf("Reload() returned error: %v", err)
+	}
+	if !slices.Equal(applied, []string{KeyMaxIterations, KeyPlaybook}) || !slices.Equal(ignored, []string{KeyModel}) {
+		t.Errorf("unexpected applied keys %v and ignored keys %v", applied, ignored)
+	}
+	if GetMaxIterations() != 8 || GetPlaybook() != "docs" || GetModel() != "claude-haiku-4-5" {
+		t.Errorf("expected the new budget and playbook with the previous model, got %d, %q and %q", GetMaxIterations(), GetPlaybook(), GetModel())
+	}
+	if Changed() {
+		t.Error("expected the config files to be unchanged after Reload")
+	}
+
+	// A broken file keeps the current configuration
+	write("max-iterations: [\n", start.Add(2*time.Minute))
+	if _, _, err := Reload(Init); err == nil || !strings.Contains(err.Error(), "gonzo.yaml") {
+		t.Errorf("expected an error for the broken file, got %v", err)
+	}
+	if GetMaxIterations() != 8 {
+		t.Errorf("expected the previous max-iterations, got %d", GetMaxIterations())
+	}
+	if Changed() {
+		t.Error("expected the broken file not to be reported again")
+	}
+
+	if err := os.Remove("gonzo.yaml"); err != nil {
+		t.Fatalf("failed to remove config file: %v", err)
+	}
+	if !Changed() {
+		t.Error("expected the removed config file to be noticed")
+	}
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONSchema returns a JSON Schema (draft-07) describing the config file, including the
+// profiles section, for editors to validate and complete gonzo.yaml with.
+func JSONSchema() ([]byte, error) {
+	properties := schemaProperties()
+
+	profile := map[string]interface{}{
+		"type":                 "object",
+		"description":          "Settings applied by the profile",
+		"additionalProperties": false,
+		"properties":           withoutKey(properties, KeyProfile),
+	}
+	properties[ProfilesKey] = map[string]interface{}{
+		"type":                 "object",
+		"description":          "Named profiles, selected with --profile",
+		"additionalProperties": profile,
+	}
+
+	schema := map[string]interface{}{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"title":                "gonzo configuration",
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties":           properties,
+	}
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// schemaProperties returns the JSON Schema of every known key.
+func schemaProperties() map[string]interface{} {
+	defaults := defaultValues()
+
+	properties := map[string]interface{}{}
+	for key, spec := range keySpecs {
+		p := map[string]interface{}{"description": spec.description}
+		switch spec.kind {
+		case kindBool:
+			p["type"] = "boolean"
+		case kindInt:
+			p["type"] = "integer"
+			p["minimum"] = spec.min
+		case kindFloat:
+			p["type"] = "number"
+			p["minimum"] = 0
+		case kindList:
+			// A single value is accepted as a list of one
+			p["type"] = []string{"array", "string"}
+			items := map[string]interface{}{"type": "string"}
+			if len(spec.values) > 0 {
+				items["enum"] = spec.values
+			}
+			p["items"] = items
+		case kindDuration:
+			p["type"] = "string"
+			p["pattern"] = durationPattern
+		case kindMap:
+			p["type"] = "object"
+			p["additionalProperties"] = map[string]interface{}{"type": []string{"string", "number", "boolean"}}
+		default:
+			p["type"] = "string"
+			if len(spec.values) > 0 {
+				p["enum"] = spec.values
+			}
+		}
+		if key == KeyModel {
+			p["pattern"] = "^(" + strings.TrimSuffix(strings.TrimPrefix(modelPattern.String(), "^"), "$") + "|" + strings.Join(modelAliases, "|") + ")$"
+		}
+		if spec.deprecated {
+			p["deprecated"] = true
+		}
+		if value, ok := defaults[key]; ok {
+			p["default"] = value
+		}
+		section, name, nested := strings.Cut(key, ".")
+		if !nested {
+			properties[key] = p
+			continue
+		}
+		if _, ok := properties[section]; !ok {
+			properties[section] = map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties":           map[string]interface{}{},
+			}
+		}
+		properties[section].(map[string]interface{})["properties"].(map[string]interface{})[name] = p
+	}
+	return properties
+}
+
+// durationPattern matches the durations accepted by time.ParseDuration, e.g. 90s or 1h30m.
+const durationPattern = `^(0|([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`
+
+// defaultValues returns the default value of every key of Config, by key. Keys of nested
+// sections are dotted, e.g. hooks.before-run.
+func defaultValues() map[string]interface{} {
+	values := map[string]interface{}{}
+	addDefaultValues(values, "", reflect.ValueOf(*Default()))
+	return values
+}
+
+// addDefaultValues adds the values of the fields of v to values, under prefix.
+func addDefaultValues(values map[string]interface{}, prefix string, v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		key := v.Type().Field(i).Tag.Get("mapstructure")
+		if key == "" {
+			continue
+		}
+		switch field := v.Field(i).Interface().(type) {
+		case time.Duration:
+			values[prefix+key] = field.String()
+		default:
+			if v.Field(i).Kind() == reflect.Struct {
+				addDefaultValues(values, prefix+key+".", v.Field(i))
+				continue
+			}
+			values[prefix+key] = field
+		}
+	}
+}
+
+// withoutKey returns a copy of properties without key.
+func withoutKey(properties map[string]interface{}, key string) map[string]interface{} {
+	copied := make(map[string]interface{}, len(properties))
+	for k, v := range properties {
+		if k != key {
+			copied[k] = v
+		}
+	}
+	return copied
+}
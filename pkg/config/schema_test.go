@@ -0,0 +1,78 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema() returned error: %v", err)
+	}
+
+	var schema struct {
+		AdditionalProperties bool `json:"additionalProperties"`
+		Properties           map[string]struct {
+			Type                 interface{}            `json:"type"`
+			Default              interface{}            `json:"default"`
+			Minimum              *int                   `json:"minimum"`
+			Enum                 []string               `json:"enum"`
+			Pattern              string                 `json:"pattern"`
+			Properties           map[string]interface{} `json:"properties"`
+			AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("expected valid JSON: %v", err)
+	}
+
+	if schema.AdditionalProperties {
+		t.Error("expected unknown keys to be rejected")
+	}
+	for key := range keySpecs {
+		if section, name, nested := strings.Cut(key, "."); nested {
+			if _, ok := schema.Properties[section].Properties[name]; !ok {
+				t.Errorf("expected a nested property for %s", key)
+			}
+			continue
+		}
+		if _, ok := schema.Properties[key]; !ok {
+			t.Errorf("expected a property for %s", key)
+		}
+	}
+
+	timeout := schema.Properties[KeyIterationTimeout]
+	if timeout.Type != "string" || timeout.Default != "0s" || timeout.Pattern == "" {
+		t.Errorf("unexpected iteration-timeout schema %+v", timeout)
+	}
+	if got := string(schema.Properties["hooks"].AdditionalProperties); got != "false" {
+		t.Errorf("expected unknown hooks to be rejected, got %s", got)
+	}
+
+	maxIterations := schema.Properties[KeyMaxIterations]
+	if maxIterations.Type != "integer" || maxIterations.Minimum == nil || *maxIterations.Minimum != 1 {
+		t.Errorf("unexpected max-iterations schema %+v", maxIterations)
+	}
+	if maxIterations.Default != float64(DefaultMaxIterations) {
+		t.Errorf("expected default %d, got %v", DefaultMaxIterations, maxIterations.Default)
+	}
+	if forge := schema.Properties[KeyForge]; len(forge.Enum) != 3 {
+		t.Errorf("expected the forge values to be listed, got %v", forge.Enum)
+	}
+
+	var profile struct {
+		Properties map[string]interface{} `json:"properties"`
+	}
+	if err := json.Unmarshal(schema.Properties[ProfilesKey].AdditionalProperties, &profile); err != nil {
+		t.Fatalf("expected profiles to be described: %v", err)
+	}
+	profiles := profile.Properties
+	if _, ok := profiles[KeyModel]; !ok {
+		t.Error("expected profiles to accept the config keys")
+	}
+	if _, ok := profiles[KeyProfile]; ok {
+		t.Error("expected profiles not to select another profile")
+	}
+}
@@ -0,0 +1,535 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+// valueKind is the type of value a config key holds.
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindBool
+	kindInt
+	kindFloat
+	kindList
+	kindDuration
+	kindMap
+)
+
+// keySpec describes the values a config key accepts.
+type keySpec struct {
+	kind valueKind
+	// min is the smallest value accepted by an integer key.
+	min int
+	// values lists the accepted values of a string key, or of the items of a list key, when
+	// restricted.
+	values []string
+	// check validates a string value further.
+	check func(string) error
+	// description documents the key in the JSON Schema.
+	description string
+	// deprecated marks keys that are still accepted but no longer used.
+	deprecated bool
+}
+
+// keySpecs lists the keys accepted in config files, and in the profiles they define.
+var keySpecs = map[string]keySpec{
+	KeyModel:         {kind: kindString, check: CheckModel, description: "Language model to use, e.g. claude-sonnet-4-5"},
+	KeyMaxIterations: {kind: kindInt, min: 1, description: "Maximum number of agentic iterations before stopping"},
+	KeyQuiet:         {kind: kindBool, description: "Print only the final result, without output messages or the live output of Claude Code"},
+	KeyVerbose:       {kind: kindInt, min: 1, description: "Verbosity: 1 for the banners and summaries of the iterations, 2 to also print the rendered prompts and the Claude CLI commands"},
+	KeyNoBranch:      {kind: kindBool, description: "Skip creating a new git branch for the changes"},
+	KeyBranchRunID:   {kind: kindBool, description: "End the names of the branches of runs with their short run ID"},
+	KeyNoNewTests:    {kind: kindBool, description: "Skip implementing new tests for the feature"},
+	KeyPR:            {kind: kindBool, description: "Create a pull request if one does not already exist for the branch"},
+	KeyCommitAuthor:  {kind: kindString, description: "Author of the commits made by gonzo (format: 'Name <email>')"},
+
+	KeyConventionalCommits:   {kind: kindBool, description: "Use Conventional Commits messages for commits made by gonzo"},
+	KeyCommitTemplate:        {kind: kindString, description: "Commit message template (Go text/template, inline or a file path)"},
+	KeyWrapUpIterations:      {kind: kindInt, min: 0, description: "Number of final iterations reserved for wrapping up an unfinished task"},
+	KeyPRDraft:               {kind: kindBool, description: "Open pull requests as drafts"},
+	KeyPRLabels:              {kind: kindList, description: "Labels to add to pull requests"},
+	KeyPRReviewers:           {kind: kindList, description: "Users or teams to request reviews from"},
+	KeyPRAssignees:           {kind: kindList, description: "Users to assign pull requests to"},
+	KeyPRComment:             {kind: kindBool, description: "Post a summary of each run as a comment on its pull request"},
+	KeyPRCloseKeyword:        {kind: kindString, description: "Keyword placed before linked issues in pull request bodies; empty leaves them out"},
+	KeyPRTitleIssuePrefix:    {kind: kindBool, description: "Prefix pull request titles with the linked issues"},
+	KeyForge:                 {kind: kindString, values: []string{"auto", "github", "gitlab"}, description: "Forge to open pull requests on"},
+	KeyCI:                    {kind: kindString, values: []string{"auto", "github", "none"}, description: "CI integration to report to"},
+	KeyProfile:               {kind: kindString, description: "Profile from the profiles section applied by default"},
+	KeyStrictConfig:          {kind: kindBool, description: "Fail on unknown config keys and invalid values instead of warning"},
+	KeyPlaybook:              {kind: kindString, description: "Playbook tuning the system prompt and defaults to the type of task, e.g. bugfix, feature, refactor, docs or migration"},
+	KeyLanguage:              {kind: kindString, check: checkNotEmpty, description: "Language whose toolchain guidance is added to the system prompt, e.g. go, node or python; auto to detect it, none to leave it out"},
+	KeyOutput:                {kind: kindString, values: []string{"text", "json"}, description: "Format of the output of a run: text, or a JSON summary of the run on stdout"},
+	KeyLogFormat:             {kind: kindString, values: []string{"text", "json"}, description: "Format of the log of a run on the console and in .gonzo/logs: text or json"},
+	KeyLogLevel:              {kind: kindString, values: []string{"debug", "info", "warn", "error"}, description: "Least severe level of the messages logged: debug, info, warn or error"},
+	KeyExtends:               {kind: kindString, description: "Path, relative to this file, or URL of a shared config file this one extends"},
+	KeyNoRepoMap:             {kind: kindBool, description: "Leave the map of the repository out of the system prompt"},
+	KeyOutputFile:            {kind: kindString, description: "Path the final response of a run is written to instead of stdout"},
+	KeyReportFile:            {kind: kindString, description: "Path the JSON summary of a run is written to"},
+	KeyJUnit:                 {kind: kindString, description: "Path of a JUnit XML report of the run and its verification commands, for CI test reports"},
+	KeyNoColor:               {kind: kindBool, description: "Disable the colors of the console, also disabled by NO_COLOR and when the output is not a terminal"},
+	KeyTranscripts:           {kind: kindBool, description: "Record the system prompt, prompt and output of each iteration, with secrets redacted, in .gonzo/transcripts/<run-id>"},
+	KeyVars:                  {kind: kindMap, description: "Variables available to the prompt templates as .Vars, by lower-case name"},
+	KeyCompletionSignal:      {kind: kindString, check: checkNotEmpty, description: "Marker Claude replies with once the task is complete"},
+	KeyCompletion:            {kind: kindString, values: []string{"marker", "regex", "quiescence"}, description: "How gonzo tells the task is complete: Claude replies with the completion signal (marker), its reply matches completion-pattern (regex), or two iterations in a row change nothing (quiescence)"},
+	KeyCompletionPattern:     {kind: kindString, check: checkRegexp, description: "Regular expression Claude's reply matches once the task is complete, with the regex completion"},
+	KeyIterationTimeout:      {kind: kindDuration, description: "Maximum time a single iteration may take, e.g. 10m; 0 for no limit"},
+	KeyMaxDuration:           {kind: kindDuration, description: "Maximum time the whole run may take, e.g. 1h; 0 for no limit"},
+	KeyHeartbeat:             {kind: kindDuration, description: "Interval of the lines printed while a Claude call runs, e.g. 5m, for CI systems killing silent jobs; 0 for none"},
+	KeySessionMode:           {kind: kindString, values: []string{"fresh", "continue"}, description: "Whether each iteration starts a new Claude session (fresh) or resumes the session of the previous one (continue)"},
+	KeyRetries:               {kind: kindInt, min: 0, description: "Number of times a failed Claude call is retried"},
+	KeyMaxOutputTokens:       {kind: kindInt, min: 0, description: "Number of tokens a response of the model may reach; 0 for the default of the Claude CLI"},
+	KeyThinkingBudget:        {kind: kindInt, min: 0, description: "Number of tokens the model may think for before responding, at least 1024 and below max-output-tokens; 0 for the default of the Claude CLI"},
+	KeyRateLimitWait:         {kind: kindDuration, description: "Time an iteration waits in total for the rate limits of the API to lift, retrying after the delay asked for; 0 to fail rate limited calls like any other"},
+	KeyMaxCost:               {kind: kindFloat, description: "Cost in US dollars the whole run may reach, checked as Claude works; 0 for no limit"},
+	KeyProtectedPaths:        {kind: kindList, description: "Globs of the files Claude must not change, relative to the repository, e.g. .github/** or go.sum"},
+	KeyWriteScope:            {kind: kindList, description: "Globs of the only files Claude may change, relative to the repository, e.g. pkg/; changes to others are reverted after each iteration"},
+	KeyPermissionMode:        {kind: kindString, values: []string{"default", "acceptEdits", "plan", "bypassPermissions", "proxy"}, description: "Permission mode of the Claude CLI; bypassPermissions skips all permission checks, for sandboxes only, and proxy has gonzo decide the permission prompts"},
+	KeySandbox:               {kind: kindString, values: []string{"none", "docker"}, description: "Where the loop runs: on the host (none) or in a Docker container holding only the repository and the Claude credentials (docker)"},
+	KeySandboxImage:          {kind: kindString, check: checkNotEmpty, description: "Image of the Docker sandbox, with the Claude CLI, git and the tools of the repository"},
+	KeyMCPConfig:             {kind: kindList, description: "MCP server configurations forwarded to the Claude CLI: JSON files with an mcpServers object, or such objects inline"},
+	KeyClaudeArgs:            {kind: kindList, description: "Extra arguments passed to every Claude CLI call after gonzo's own, e.g. flags gonzo does not support yet"},
+	KeyAgents:                {kind: kindList, description: "Subagents forwarded to the Claude CLI: names of agents in .claude/agents, agent files or directories of them, or inline JSON"},
+	KeyAllowedTools:          {kind: kindList, description: "Tools Claude may use on top of the permission mode, e.g. Bash(git:*) or WebFetch"},
+	KeyVerify:                {kind: kindList, description: "Commands that must pass before the task is considered complete"},
+	KeyHooksBeforeRun:        {kind: kindList, description: "Commands run before the first iteration; the run fails if one fails"},
+	KeyHooksAfterIteration:   {kind: kindList, description: "Commands run after every iteration"},
+	KeyHooksAfterRun:         {kind: kindList, description: "Commands run once the run is over"},
+	KeyPermissionPolicyAllow: {kind: kindList, description: "Tools allowed without asking in the proxy permission mode, e.g. Edit(src/**) or Bash(go test:*)"},
+	KeyPermissionPolicyAsk:   {kind: kindList, description: "Tools asked about on the terminal in the proxy permission mode, as are the tools no rule matches"},
+	KeyPermissionPolicyDeny:  {kind: kindList, description: "Tools denied in the proxy permission mode, whatever the other rules"},
+	KeyEnvAllow:              {kind: kindList, description: "Variables of gonzo's environment the Claude CLI inherits, as names or globs such as AWS_*; all of them when empty"},
+	KeyEnvDeny:               {kind: kindList, description: "Variables of gonzo's environment kept from the Claude CLI, as names or globs such as *_TOKEN"},
+	KeyEnvSet:                {kind: kindList, check: checkEnvVar, description: "Variables set in the environment of the Claude CLI, as NAME=value; $VAR in values expands to gonzo's environment"},
+	KeyGuidanceMode:          {kind: kindString, values: []string{"inline", "reference", "off"}, description: "How project guidance files are added to the system prompt"},
+	KeyGuidanceFiles:         {kind: kindList, description: "Project guidance files looked for in the repository, e.g. CLAUDE.md"},
+	KeyGuidanceMaxSize:       {kind: kindInt, min: 0, description: "Size in bytes above which a guidance file is referenced instead of inlined"},
+	KeyPromptMaxTokens:       {kind: kindInt, min: 0, description: "Budget of the system prompt and feature in estimated tokens; 0 for a quarter of the model's context window"},
+	KeyPromptTruncate:        {kind: kindList, values: []string{"repomap", "guidance", "vars"}, description: "Sections of the system prompt truncated, in order, to fit the prompt budget"},
+
+	KeyNotificationsWebhookURL:    {kind: kindString, description: "Slack, Discord or other incoming webhook the start and outcome of runs are posted to; also the webhook-url secret"},
+	KeyNotificationsEvents:        {kind: kindList, values: []string{"start", "complete", "fail", "budget"}, description: "Run events notified: start, complete, fail and budget (max duration reached)"},
+	KeyNotificationsEmailTo:       {kind: kindList, description: "Recipients of notification emails, sent with the markdown report of the run attached"},
+	KeyNotificationsEmailFrom:     {kind: kindString, description: "Sender of notification emails; gonzo@<smtp host> by default"},
+	KeyNotificationsEmailSMTP:     {kind: kindString, description: "Address of the SMTP server notification emails are sent through, host:port"},
+	KeyNotificationsEmailUsername: {kind: kindString, description: "User authenticating to the SMTP server, with the smtp-password secret"},
+
+	// Deprecated keys, still accepted so that older config files load
+	KeyTests:  {kind: kindBool, deprecated: true, description: "Deprecated: use no-new-tests"},
+	KeyBranch: {kind: kindBool, deprecated: true, description: "Deprecated: use no-branch"},
+}
+
+// modelPattern matches full Claude model names, such as claude-sonnet-4-5.
+var modelPattern = regexp.MustCompile(`^claude-[a-z0-9][a-z0-9.-]*$`)
+
+// modelAliases are the short model names accepted by the Claude CLI.
+var modelAliases = []string{"haiku", "sonnet", "opus"}
+
+// CheckModel rejects values that do not name a Claude model.
+func CheckModel(model string) error {
+	if modelPattern.MatchString(model) || slices.Contains(modelAliases, model) {
+		return nil
+	}
+	return fmt.Errorf("unknown model %q: expected a Claude model such as claude-sonnet-4-5, or one of %s",
+		model, strings.Join(modelAliases, ", "))
+}
+
+// checkNotEmpty rejects empty values.
+func checkNotEmpty(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("must not be empty")
+	}
+	return nil
+}
+
+// checkRegexp rejects invalid regular expressions.
+func checkRegexp(value string) error {
+	_, err := regexp.Compile(value)
+	return err
+}
+
+// checkEnvVar checks a NAME=value environment variable.
+func checkEnvVar(value string) error {
+	name, _, found := strings.Cut(value, "=")
+	if !found || strings.TrimSpace(name) == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("expected NAME=value, got %q", value)
+	}
+	return nil
+}
+
+// isSection returns whether name groups nested keys, such as hooks for hooks.before-run.
+func isSection(name string) bool {
+	for key := range keySpecs {
+		if strings.HasPrefix(key, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the config files loaded by Init for unknown keys and invalid values,
+// reporting them with their file and line, e.g. "gonzo.yaml:3: unknown key "max_iterations"
+// (did you mean "max-iterations"?)". When the files are valid, the effective values, which may
+// come from environment variables, flags or --set overrides, are checked as well.
+// Keys outside of the known ones are only rejected in config files.
+func Validate() error {
+	var errs []error
+	for _, layer := range layers {
+		errs = append(errs, validateFile(layer.readPath(), layer.Path)...)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	keys := make([]string, 0, len(keySpecs))
+	for key := range keySpecs {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if !viper.IsSet(key) {
+			continue
+		}
+		if err := checkValue(keySpecs[key], viper.Get(key)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateFile checks the keys and values of a config file read from file, reported as path.
+func validateFile(file string, path string) []error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return []error{fmt.Errorf("failed to read config file: %w", err)}
+	}
+	var doc yaml.Node
+	if configType(file) == "toml" {
+		err = tomlNode(data, &doc)
+	} else {
+		// JSON is read as YAML, which it is a subset of
+		err = yaml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return []error{fmt.Errorf("%s: %w", path, err)}
+	}
+	if len(doc.Content) == 0 {
+		// An empty file
+		return nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return []error{fmt.Errorf("%s: expected a mapping of config keys", location(path, root.Line))}
+	}
+
+	var errs []error
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if strings.ToLower(key.Value) != ProfilesKey {
+			errs = append(errs, validateEntry(path, "", "", key, value)...)
+			continue
+		}
+
+		if value.Kind != yaml.MappingNode {
+			errs = append(errs, fmt.Errorf("%s: %s: expected a mapping of profile names to settings", location(path, value.Line), ProfilesKey))
+			continue
+		}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			name, settings := value.Content[j], value.Content[j+1]
+			prefix := ProfilesKey + "." + name.Value + "."
+			if settings.Kind != yaml.MappingNode {
+				errs = append(errs, fmt.Errorf("%s: %s: expected a mapping of config keys", location(path, settings.Line), strings.TrimSuffix(prefix, ".")))
+				continue
+			}
+			for k := 0; k+1 < len(settings.Content); k += 2 {
+				switch strings.ToLower(settings.Content[k].Value) {
+				case KeyProfile:
+					errs = append(errs, fmt.Errorf("%s: %s%s: profiles cannot select another profile", location(path, settings.Content[k].Line), prefix, KeyProfile))
+					continue
+				case KeyExtends:
+					errs = append(errs, fmt.Errorf("%s: %s%s: profiles cannot extend a config file", location(path, settings.Content[k].Line), prefix, KeyExtends))
+					continue
+				}
+				errs = append(errs, validateEntry(path, prefix, "", settings.Content[k], settings.Content[k+1])...)
+			}
+		}
+	}
+	return errs
+}
+
+// tomlNode decodes a TOML document into a YAML node, to be checked like YAML documents.
+// The lines of its nodes are left at zero, since they would not match the TOML file.
+func tomlNode(data []byte, doc *yaml.Node) error {
+	var settings map[string]interface{}
+	if err := toml.Unmarshal(data, &settings); err != nil {
+		return err
+	}
+	if err := doc.Encode(settings); err != nil {
+		return err
+	}
+	// Encode leaves doc as the mapping, without the document node around it
+	*doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{clearLines(doc)}}
+	return nil
+}
+
+// clearLines copies node with the lines of the copy and its children set to zero.
+func clearLines(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Line, copied.Column = 0, 0
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = clearLines(child)
+	}
+	return &copied
+}
+
+// location returns the position of a node of a config file for messages: the path and line,
+// or only the path when the line is not known.
+func location(path string, line int) string {
+	if line == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s:%d", path, line)
+}
+
+// validateEntry checks a key of a config file and its value. The prefix locates the key in
+// messages, e.g. within a profile, and section is the dotted path of the mapping holding it.
+func validateEntry(path string, prefix string, section string, key *yaml.Node, value *yaml.Node) []error {
+	name := section + strings.ToLower(key.Value)
+	spec, ok := keySpecs[name]
+	if !ok && isSection(name) {
+		if value.Kind != yaml.MappingNode {
+			return []error{fmt.Errorf("%s: %s%s: expected a mapping of config keys", location(path, value.Line), prefix, name)}
+		}
+		var errs []error
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			errs = append(errs, validateEntry(path, prefix, name+".", value.Content[i], value.Content[i+1])...)
+		}
+		return errs
+	}
+	if !ok {
+		msg := fmt.Sprintf("%s: unknown key %q", location(path, key.Line), prefix+section+key.Value)
+		if suggestion := suggestKey(name); suggestion != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", prefix+suggestion)
+		}
+		return []error{errors.New(msg)}
+	}
+
+	if err := checkNode(spec, value); err != nil {
+		return []error{fmt.Errorf("%s: %s%s: %w", location(path, value.Line), prefix, name, err)}
+	}
+	return nil
+}
+
+// checkNode checks a value of a config file against the spec of its key.
+func checkNode(spec keySpec, value *yaml.Node) error {
+	if spec.kind == kindMap {
+		if value.Kind != yaml.MappingNode {
+			return errors.New("expected a mapping of names to values")
+		}
+		for i := 1; i < len(value.Content); i += 2 {
+			if value.Content[i].Kind != yaml.ScalarNode {
+				return fmt.Errorf("%s: expected a single value", value.Content[i-1].Value)
+			}
+		}
+		return nil
+	}
+	if spec.kind == kindList {
+		switch value.Kind {
+		case yaml.SequenceNode:
+			for _, item := range value.Content {
+				if item.Kind != yaml.ScalarNode {
+					return errors.New("expected a list of values")
+				}
+				if err := checkItem(spec, item.Value); err != nil {
+					return err
+				}
+			}
+			return nil
+		case yaml.ScalarNode:
+			return checkItem(spec, value.Value)
+		default:
+			return errors.New("expected a list of values")
+		}
+	}
+
+	if value.Kind != yaml.ScalarNode {
+		return errors.New("expected a single value")
+	}
+	switch spec.kind {
+	case kindBool:
+		if value.Tag != "!!bool" {
+			return fmt.Errorf("expected true or false, got %q", value.Value)
+		}
+	case kindInt:
+		if value.Tag != "!!int" {
+			return fmt.Errorf("expected a whole number, got %q", value.Value)
+		}
+	case kindFloat:
+		if value.Tag != "!!int" && value.Tag != "!!float" {
+			return fmt.Errorf("expected a number, got %q", value.Value)
+		}
+	}
+	return checkValue(spec, value.Value)
+}
+
+// checkValue checks a value, as read from any configuration source, against the spec of its key.
+func checkValue(spec keySpec, value interface{}) error {
+	switch spec.kind {
+	case kindBool:
+		if _, ok := value.(bool); ok {
+			return nil
+		}
+		if _, err := strconv.ParseBool(fmt.Sprint(value)); err != nil {
+			return fmt.Errorf("expected true or false, got %q", fmt.Sprint(value))
+		}
+	case kindInt:
+		n, err := toInt(value)
+		if err != nil {
+			return fmt.Errorf("expected a whole number, got %q", fmt.Sprint(value))
+		}
+		if n < spec.min {
+			return fmt.Errorf("must be at least %d, got %d", spec.min, n)
+		}
+	case kindFloat:
+		f, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", fmt.Sprint(value))
+		}
+		if f < 0 {
+			return fmt.Errorf("must not be negative, got %v", f)
+		}
+	case kindList:
+		var items []string
+		switch v := value.(type) {
+		case []string:
+			items = v
+		case []interface{}:
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+		default:
+			// A single string, e.g. from an environment variable, holds space-separated items
+			items = strings.Fields(fmt.Sprint(v))
+		}
+		for _, item := range items {
+			if err := checkItem(spec, item); err != nil {
+				return err
+			}
+		}
+	case kindMap:
+		switch value.(type) {
+		case map[string]interface{}, map[string]string:
+		default:
+			return fmt.Errorf("expected a mapping of names to values, got %q", fmt.Sprint(value))
+		}
+	case kindDuration:
+		d, err := toDuration(value)
+		if err != nil {
+			return fmt.Errorf("expected a duration such as 90s, 10m or 1h, got %q", fmt.Sprint(value))
+		}
+		if d < 0 {
+			return fmt.Errorf("must not be negative, got %s", d)
+		}
+	case kindString:
+		s := fmt.Sprint(value)
+		if len(spec.values) > 0 && !slices.Contains(spec.values, s) {
+			return fmt.Errorf("expected one of %s, got %q", strings.Join(spec.values, ", "), s)
+		}
+		if spec.check != nil {
+			return spec.check(s)
+		}
+	}
+	return nil
+}
+
+// checkItem checks an item of a list key against the values it accepts, if restricted, and
+// its check, if any.
+func checkItem(spec keySpec, item string) error {
+	if len(spec.values) > 0 && !slices.Contains(spec.values, item) {
+		return fmt.Errorf("expected items among %s, got %q", strings.Join(spec.values, ", "), item)
+	}
+	if spec.check != nil {
+		return spec.check(item)
+	}
+	return nil
+}
+
+// toInt converts a configuration value to an int.
+func toInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case uint64:
+		return int(v), nil
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("not a whole number: %v", v)
+		}
+		return int(v), nil
+	default:
+		return strconv.Atoi(strings.TrimSpace(fmt.Sprint(v)))
+	}
+}
+
+// toDuration converts a configuration value to a duration. Numbers other than 0 are
+// rejected, since a unit is needed to read them.
+func toDuration(value interface{}) (time.Duration, error) {
+	if d, ok := value.(time.Duration); ok {
+		return d, nil
+	}
+	return time.ParseDuration(strings.TrimSpace(fmt.Sprint(value)))
+}
+
+// suggestKey returns the known key closest to an unknown one, or "" when none is close.
+func suggestKey(key string) string {
+	normalized := strings.NewReplacer("_", "-", " ", "-").Replace(key)
+	if _, ok := keySpecs[normalized]; ok {
+		return normalized
+	}
+
+	best, bestDistance := "", 3
+	for known := range keySpecs {
+		if d := editDistance(normalized, known); d < bestDistance || (d == bestDistance && known < best) {
+			best, bestDistance = known, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
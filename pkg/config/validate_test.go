@@ -0,0 +1,186 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	resetViper()
+	t.Chdir(t.TempDir())
+
+	configContent := `model: gpt-4
+max_iterations: 5
+wrap-up-iterations: -1
+pr: maybe
+forge: bitbucket
+pr-labels: [gonzo, bot]
+profiles:
+  cheap:
+    model: claude-haiku-4-5
+    max-iteration: 3
+`
+	if err := os.WriteFile("gonzo.yaml", []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+
+	err := Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		`gonzo.yaml:1: model: unknown model "gpt-4"`,
+		`gonzo.yaml:2: unknown key "max_iterations" (did you mean "max-iterations"?)`,
+		`gonzo.yaml:3: wrap-up-iterations: must be at least 0, got -1`,
+		`gonzo.yaml:4: pr: expected true or false, got "maybe"`,
+		`gonzo.yaml:5: forge: expected one of auto, github, gitlab, got "bitbucket"`,
+		`gonzo.yaml:10: unknown key "profiles.cheap.max-iteration" (did you mean "profiles.cheap.max-iterations"?)`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err.Error())
+		}
+	}
+	if strings.Contains(err.Error(), "pr-labels") {
+		t.Errorf("expected pr-labels to be valid, got %q", err.Error())
+	}
+}
+
+func TestValidate_NestedKeys(t *testing.T) {
+	resetViper()
+	t.Chdir(t.TempDir())
+
+	configContent := `iteration-timeout: 10
+max-duration: 1h
+hooks:
+  before-run: make deps
+  after-itteration: [make lint]
+prompt:
+  truncate: [repomap, history]
+profiles:
+  ci:
+    hooks: make notify
+    max-cost: lots
+max-cost: 2.5
+env:
+  set: [HTTPS_PROXY=http://proxy:3128, NO_VALUE]
+`
+	if err := os.WriteFile("gonzo.yaml", []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+
+	err := Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		`gonzo.yaml:1: iteration-timeout: expected a duration such as 90s, 10m or 1h, got "10"`,
+		`gonzo.yaml:5: unknown key "hooks.after-itteration" (did you mean "hooks.after-iteration"?)`,
+		`gonzo.yaml:7: prompt.truncate: expected items among repomap, guidance, vars, got "history"`,
+		`gonzo.yaml:10: profiles.ci.hooks: expected a mapping of config keys`,
+		`gonzo.yaml:11: profiles.ci.max-cost: expected a number, got "lots"`,
+		`gonzo.yaml:14: env.set: expected NAME=value, got "NO_VALUE"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err.Error())
+		}
+	}
+	for _, valid := range []string{"max-duration", "before-run", "gonzo.yaml:12"} {
+		if strings.Contains(err.Error(), valid) {
+			t.Errorf("expected %s to be valid, got %q", valid, err.Error())
+		}
+	}
+}
+
+func TestValidate_TOMLAndJSON(t *testing.T) {
+	resetViper()
+	t.Chdir(t.TempDir())
+
+	if err := os.WriteFile("gonzo.toml", []byte("max_iterations = 5\npr = \"maybe\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+	err := Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	// TOML files are reported without line numbers
+	for _, want := range []string{
+		`gonzo.toml: unknown key "max_iterations" (did you mean "max-iterations"?)`,
+		`gonzo.toml: pr: expected true or false, got "maybe"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err.Error())
+		}
+	}
+
+	resetViper()
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("gonzo.json", []byte("{\n  \"model\": \"sonnet\",\n  \"retries\": -1\n}\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+	err = Validate()
+	if err == nil || !strings.Contains(err.Error(), "gonzo.json:3: retries: must be at least 0, got -1") {
+		t.Errorf("expected a line-numbered error for the JSON file, got %v", err)
+	}
+}
+
+func TestValidate_EffectiveValues(t *testing.T) {
+	resetViper()
+	t.Chdir(t.TempDir())
+	t.Setenv("GONZO_MAX_ITERATIONS", "-3")
+
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+	if err := ApplyOverrides([]string{"ci=jenkins", "gates.test-command=make check"}); err != nil {
+		t.Fatalf("ApplyOverrides() returned error: %v", err)
+	}
+
+	err := Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{"max-iterations: must be at least 1, got -3", `ci: expected one of auto, github, none, got "jenkins"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err.Error())
+		}
+	}
+	if strings.Contains(err.Error(), "gates") {
+		t.Errorf("expected keys given with --set to be accepted, got %q", err.Error())
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	resetViper()
+	t.Chdir(t.TempDir())
+
+	configContent := `model: sonnet
+max-iterations: 15
+pr-reviewers: octocat
+profiles:
+  shipit:
+    model: claude-opus-4-5
+    pr: true
+`
+	if err := os.WriteFile("gonzo.yaml", []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := Init(); err != nil {
+		t.Fatalf("Init() returned error: %v", err)
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
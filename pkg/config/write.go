@@ -0,0 +1,190 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+// WritePath returns the config file values are written to: the most specific one loaded by
+// Init, or ./gonzo.yaml when none was.
+func WritePath() string {
+	if path := ConfigFileUsed(); path != "" {
+		return path
+	}
+	return ConfigName + "." + ConfigType
+}
+
+// WriteValue sets key to the value given by args in the config file at path, creating it when
+// missing. The file is written back in its own format: YAML files keep their comments and key
+// order, TOML and JSON files are rewritten. List keys take every arg as an item, vars takes
+// name=value args, other keys exactly one. Keys of profiles are given as profiles.<name>.<key>.
+func WriteValue(path string, key string, args []string) error {
+	key = strings.ToLower(key)
+	spec, err := writableSpec(key)
+	if err != nil {
+		return err
+	}
+	value, err := parseValue(spec, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	if err := checkValue(spec, value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	if configType(path) == ConfigType {
+		return writeYAMLValue(path, key, value)
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType(configType(path))
+	if isFile(path) {
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("error reading config file %s: %w", path, err)
+		}
+	}
+	v.Set(key, value)
+	if err := v.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("error writing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// writableSpec returns the spec of a key that can be written to a config file.
+func writableSpec(key string) (keySpec, error) {
+	name := key
+	if strings.HasPrefix(key, ProfilesKey+".") {
+		parts := strings.SplitN(key, ".", 3)
+		if len(parts) < 3 || parts[1] == "" {
+			return keySpec{}, fmt.Errorf("invalid key %q: expected %s.<name>.<key>", key, ProfilesKey)
+		}
+		switch parts[2] {
+		case KeyProfile:
+			return keySpec{}, fmt.Errorf("%s: profiles cannot select another profile", key)
+		case KeyExtends:
+			return keySpec{}, fmt.Errorf("%s: profiles cannot extend a config file", key)
+		}
+		name = parts[2]
+	}
+
+	spec, ok := keySpecs[name]
+	if !ok {
+		msg := fmt.Sprintf("unknown key %q", key)
+		if suggestion := suggestKey(name); suggestion != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", strings.TrimSuffix(key, name)+suggestion)
+		}
+		return keySpec{}, errors.New(msg)
+	}
+	return spec, nil
+}
+
+// parseValue converts command-line arguments to a value of the kind of spec.
+func parseValue(spec keySpec, args []string) (interface{}, error) {
+	if spec.kind == kindList {
+		return args, nil
+	}
+	if spec.kind == kindMap {
+		values := map[string]string{}
+		for _, arg := range args {
+			name, value, ok := strings.Cut(arg, "=")
+			if !ok || name == "" {
+				return nil, fmt.Errorf("expected name=value, got %q", arg)
+			}
+			values[strings.ToLower(name)] = value
+		}
+		return values, nil
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected a single value, got %d", len(args))
+	}
+	switch spec.kind {
+	case kindBool:
+		b, err := strconv.ParseBool(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", args[0])
+		}
+		return b, nil
+	case kindInt:
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("expected a whole number, got %q", args[0])
+		}
+		return n, nil
+	case kindFloat:
+		f, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", args[0])
+		}
+		return f, nil
+	default:
+		return args[0], nil
+	}
+}
+
+// writeYAMLValue sets a dotted key in a YAML config file, leaving the rest of it as it is.
+func writeYAMLValue(path string, key string, value interface{}) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+
+	var valueNode yaml.Node
+	if err := valueNode.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	if err := setYAMLNode(doc.Content[0], strings.Split(key, "."), &valueNode); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// setYAMLNode sets the value at the path of keys in a mapping, adding the mappings on the way
+// when missing. A replaced value keeps its comment.
+func setYAMLNode(mapping *yaml.Node, keys []string, value *yaml.Node) error {
+	if mapping.Kind != yaml.MappingNode {
+		return errors.New("expected a mapping of config keys")
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.ToLower(mapping.Content[i].Value) != keys[0] {
+			continue
+		}
+		if len(keys) > 1 {
+			return setYAMLNode(mapping.Content[i+1], keys[1:], value)
+		}
+		value.LineComment = mapping.Content[i+1].LineComment
+		mapping.Content[i+1] = value
+		return nil
+	}
+
+	for _, key := range keys[:len(keys)-1] {
+		child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		mapping = child
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: keys[len(keys)-1]}, value)
+	return nil
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestWriteValue_YAML(t *testing.T) {
+	t.Chdir(t.TempDir())
+	original := `# Team settings
+model: sonnet # keep it cheap
+pr: true
+`
+	if err := os.WriteFile("gonzo.yaml", []byte(original), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	for _, set := range [][]string{
+		{"model", "opus"},
+		{"max-iterations", "15"},
+		{"pr-labels", "gonzo", "bot"},
+		{"hooks.after-run", "make notify"},
+		{"profiles.cheap.model", "haiku"},
+	} {
+		if err := WriteValue("gonzo.yaml", set[0], set[1:]); err != nil {
+			t.Fatalf("WriteValue(%v) returned error: %v", set, err)
+		}
+	}
+
+	data, err := os.ReadFile("gonzo.yaml")
+	if err != nil {
+		t.Fatalf("failed to read config file: %v", err)
+	}
+	expected := `# Team settings
+model: opus # keep it cheap
+pr: true
+max-iterations: 15
+pr-labels:
+  - gonzo
+  - bot
+hooks:
+  after-run:
+    - make notify
+profiles:
+  cheap:
+    model: haiku
+`
+	if string(data) != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, string(data))
+	}
+}
+
+func TestWriteValue_TOMLAndJSON(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("gonzo.toml", []byte("model = \"sonnet\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	for _, path := range []string{"gonzo.toml", "gonzo.json"} {
+		if err := WriteValue(path, "max-iterations", []string{"12"}); err != nil {
+			t.Fatalf("WriteValue(%s) returned error: %v", path, err)
+		}
+
+		v := viper.New()
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			t.Fatalf("expected %s to be readable: %v", path, err)
+		}
+		if got := v.GetInt(KeyMaxIterations); got != 12 {
+			t.Errorf("expected max-iterations 12 in %s, got %d", path, got)
+		}
+	}
+
+	data, _ := os.ReadFile("gonzo.toml")
+	if !strings.Contains(string(data), "model = 'sonnet'") && !strings.Contains(string(data), `model = "sonnet"`) {
+		t.Errorf("expected the existing keys to be kept, got %q", string(data))
+	}
+}
+
+func TestWriteValue_Invalid(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	tests := []struct {
+		key  string
+		args []string
+		want string
+	}{
+		{"max_iterations", []string{"5"}, `unknown key "max_iterations" (did you mean "max-iterations"?)`},
+		{"max-iterations", []string{"0"}, "max-iterations: must be at least 1, got 0"},
+		{"pr", []string{"maybe"}, `pr: expected true or false, got "maybe"`},
+		{"model", []string{"a", "b"}, "model: expected a single value, got 2"},
+		{"profiles.cheap.profile", []string{"x"}, "profiles cannot select another profile"},
+	}
+	for _, tt := range tests {
+		err := WriteValue("gonzo.yaml", tt.key, tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("WriteValue(%s) = %v, want %q", tt.key, err, tt.want)
+		}
+	}
+	if _, err := os.Stat("gonzo.yaml"); err == nil {
+		t.Error("expected no file to be written for invalid values")
+	}
+}
@@ -0,0 +1,96 @@
+// Package forge defines the interface gonzo uses to talk to code hosting services
+// (GitHub, GitLab) and the types shared by their implementations.
+package forge
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Supported forges.
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+// Auto selects the forge from the origin remote URL.
+const Auto = "auto"
+
+// TokenEnv lists, per forge, the environment variables its CLI reads an access token from,
+// in order of precedence.
+var TokenEnv = map[string][]string{
+	GitHub: {"GH_TOKEN", "GITHUB_TOKEN"},
+	GitLab: {"GITLAB_TOKEN"},
+}
+
+// ErrNotFound is returned when the requested resource does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Forge is a code hosting service gonzo opens pull requests on and reads issues from.
+// GitLab merge requests are pull requests in this interface.
+type Forge interface {
+	// Name returns the forge identifier, e.g. GitHub.
+	Name() string
+	// AuthStatus returns an error describing the problem when the forge cannot be accessed
+	// with the configured credentials.
+	AuthStatus(ctx context.Context) error
+	// FindPullRequest returns the open pull request for the head branch, or ErrNotFound.
+	FindPullRequest(ctx context.Context, head string) (*PullRequest, error)
+	// CreatePullRequest opens a pull request and returns it.
+	CreatePullRequest(ctx context.Context, opts PullRequestOptions) (*PullRequest, error)
+	// CommentOnPullRequest adds a comment to the pull request identified by ref, a number, URL or branch.
+	CommentOnPullRequest(ctx context.Context, ref string, body string) error
+	// DefaultBranch returns the name of the repository's default branch.
+	DefaultBranch(ctx context.Context) (string, error)
+	// GetIssue returns the issue identified by ref, a number or URL, including its comments.
+	GetIssue(ctx context.Context, ref string) (*Issue, error)
+	// CommentOnIssue adds a comment to the issue with the given number.
+	CommentOnIssue(ctx context.Context, number int, body string) error
+}
+
+// PullRequest is the subset of pull request fields gonzo uses.
+type PullRequest struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+	State  string `json:"state"`
+	Title  string `json:"title"`
+}
+
+// PullRequestOptions describes a pull request to create.
+type PullRequestOptions struct {
+	Title     string
+	Body      string
+	Base      string
+	Head      string
+	Draft     bool
+	Labels    []string
+	Reviewers []string
+	Assignees []string
+}
+
+// Issue is the subset of issue fields gonzo uses.
+type Issue struct {
+	Number   int            `json:"number"`
+	Title    string         `json:"title"`
+	Body     string         `json:"body"`
+	URL      string         `json:"url"`
+	Comments []IssueComment `json:"comments"`
+}
+
+// IssueComment is a comment on an issue.
+type IssueComment struct {
+	Author struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Body string `json:"body"`
+}
+
+// Detect returns the forge hosting the repository with the given remote URL.
+// Hosts that do not look like GitLab are assumed to be GitHub.
+func Detect(remoteURL string) string {
+	if strings.Contains(strings.ToLower(remoteURL), "gitlab") {
+		return GitLab
+	}
+	return GitHub
+}
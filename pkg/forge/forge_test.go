@@ -0,0 +1,23 @@
+package forge
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"git@github.com:o/r.git", GitHub},
+		{"https://github.com/o/r", GitHub},
+		{"git@gitlab.com:o/r.git", GitLab},
+		{"https://gitlab.example.com/group/sub/r.git", GitLab},
+		{"https://git.example.com/o/r.git", GitHub},
+		{"", GitHub},
+	}
+
+	for _, tt := range tests {
+		if got := Detect(tt.url); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Package github talks to GitHub on behalf of gonzo by shelling out to the gh CLI,
+// which takes care of authentication and GitHub Enterprise hosts.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// GhCli is the name of the GitHub CLI executable.
+const GhCli = "gh"
+
+// commandContext is a variable that wraps exec.CommandContext for testing.
+var commandContext = exec.CommandContext
+
+// Client runs gh commands against the repository checked out in Dir. It implements forge.Forge.
+type Client struct {
+	Dir string
+	// Token, when set, is passed to gh as GH_TOKEN instead of its own stored login.
+	Token string
+}
+
+// ReviewComment is the first comment of an unresolved review thread on a pull request.
+type ReviewComment struct {
+	ID     int64
+	Path   string
+	Line   int
+	Author string
+	Body   string
+}
+
+var _ forge.Forge = (*Client)(nil)
+
+// New returns a Client for the repository in dir.
+func New(dir string) *Client {
+	return &Client{Dir: dir}
+}
+
+// gh runs a gh subcommand and returns its trimmed stdout.
+// On failure the returned error includes gh's stderr.
+func (c *Client) gh(ctx context.Context, args ...string) (string, error) {
+	cmd := commandContext(ctx, GhCli, args...)
+	cmd.Dir = c.Dir
+	if c.Token != "" {
+		cmd.Env = append(cmd.Environ(), "GH_TOKEN="+c.Token)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		name := strings.Join(args[:min(2, len(args))], " ")
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "no pull requests found") {
+			return "", fmt.Errorf("gh %s: %w: %s", name, forge.ErrNotFound, msg)
+		}
+		if msg == "" {
+			return "", fmt.Errorf("gh %s: %w", name, err)
+		}
+		return "", fmt.Errorf("gh %s: %w: %s", name, err, msg)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Name returns forge.GitHub.
+func (c *Client) Name() string {
+	return forge.GitHub
+}
+
+// AuthStatus returns an error when gh is not logged in or its token is invalid.
+func (c *Client) AuthStatus(ctx context.Context) error {
+	_, err := c.gh(ctx, "auth", "status")
+	return err
+}
+
+// FindPullRequest returns the open pull request for the head branch, or forge.ErrNotFound.
+// A pull request number or URL may be passed instead of a branch.
+func (c *Client) FindPullRequest(ctx context.Context, head string) (*forge.PullRequest, error) {
+	out, err := c.gh(ctx, "pr", "view", head, "--json", "number,url,state,title")
+	if err != nil {
+		return nil, err
+	}
+
+	var pr forge.PullRequest
+	if err := json.Unmarshal([]byte(out), &pr); err != nil {
+		return nil, fmt.Errorf("failed to decode pull request: %w", err)
+	}
+	if pr.State != "" && pr.State != "OPEN" {
+		return nil, forge.ErrNotFound
+	}
+	return &pr, nil
+}
+
+// CreatePullRequest opens a pull request and returns it.
+func (c *Client) CreatePullRequest(ctx context.Context, opts forge.PullRequestOptions) (*forge.PullRequest, error) {
+	args := []string{"pr", "create", "--title", opts.Title, "--body", opts.Body}
+	if opts.Base != "" {
+		args = append(args, "--base", opts.Base)
+	}
+	if opts.Head != "" {
+		args = append(args, "--head", opts.Head)
+	}
+	if opts.Draft {
+		args = append(args, "--draft")
+	}
+	for _, label := range opts.Labels {
+		args = append(args, "--label", label)
+	}
+	for _, reviewer := range opts.Reviewers {
+		args = append(args, "--reviewer", reviewer)
+	}
+	for _, assignee := range opts.Assignees {
+		args = append(args, "--assignee", assignee)
+	}
+
+	out, err := c.gh(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// gh prints the URL of the new pull request as the last line
+	lines := strings.Split(out, "\n")
+	url := strings.TrimSpace(lines[len(lines)-1])
+	return &forge.PullRequest{
+		Number: pullRequestNumber(url),
+		URL:    url,
+		State:  "OPEN",
+		Title:  opts.Title,
+	}, nil
+}
+
+// pullRequestNumber extracts the number from a pull request URL, or returns 0.
+func pullRequestNumber(url string) int {
+	i := strings.LastIndex(url, "/pull/")
+	if i < 0 {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(url[i+len("/pull/"):], "/"))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// CommentOnPullRequest adds a comment to the pull request identified by ref, a number, URL or branch.
+func (c *Client) CommentOnPullRequest(ctx context.Context, ref string, body string) error {
+	_, err := c.gh(ctx, "pr", "comment", ref, "--body", body)
+	return err
+}
+
+// DefaultBranch returns the name of the repository's default branch.
+func (c *Client) DefaultBranch(ctx context.Context) (string, error) {
+	return c.gh(ctx, "repo", "view", "--json", "defaultBranchRef", "--jq", ".defaultBranchRef.name")
+}
+
+// GetIssue returns the issue identified by ref, which is either a number or a URL, including its comments.
+func (c *Client) GetIssue(ctx context.Context, ref string) (*forge.Issue, error) {
+	out, err := c.gh(ctx, "issue", "view", ref, "--json", "number,title,body,url,comments")
+	if err != nil {
+		return nil, err
+	}
+
+	var issue forge.Issue
+	if err := json.Unmarshal([]byte(out), &issue); err != nil {
+		return nil, fmt.Errorf("failed to decode issue: %w", err)
+	}
+	return &issue, nil
+}
+
+// CommentOnIssue adds a comment to the issue with the given number.
+func (c *Client) CommentOnIssue(ctx context.Context, number int, body string) error {
+	_, err := c.gh(ctx, "issue", "comment", strconv.Itoa(number), "--body", body)
+	return err
+}
+
+// reviewThreadsQuery fetches the review threads of a pull request with the first comment of each.
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100) {
+        nodes {
+          isResolved
+          comments(first: 1) {
+            nodes { databaseId path line body author { login } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// UnresolvedReviewComments returns the comment starting each unresolved review thread on the
+// pull request with the given number.
+func (c *Client) UnresolvedReviewComments(ctx context.Context, number int) ([]ReviewComment, error) {
+	out, err := c.gh(ctx, "api", "graphql",
+		"-F", "owner={owner}", "-F", "name={repo}", "-F", "number="+strconv.Itoa(number),
+		"-f", "query="+reviewThreadsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data struct {
+			Repository struct {
+				PullRequest struct {
+					ReviewThreads struct {
+						Nodes []struct {
+							IsResolved bool `json:"isResolved"`
+							Comments   struct {
+								Nodes []struct {
+									DatabaseID int64  `json:"databaseId"`
+									Path       string `json:"path"`
+									Line       int    `json:"line"`
+									Body       string `json:"body"`
+									Author     struct {
+										Login string `json:"login"`
+									} `json:"author"`
+								} `json:"nodes"`
+							} `json:"comments"`
+						} `json:"nodes"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode review threads: %w", err)
+	}
+
+	var comments []ReviewComment
+	for _, thread := range resp.Data.Repository.PullRequest.ReviewThreads.Nodes {
+		if thread.IsResolved || len(thread.Comments.Nodes) == 0 {
+			continue
+		}
+		first := thread.Comments.Nodes[0]
+		comments = append(comments, ReviewComment{
+			ID:     first.DatabaseID,
+			Path:   first.Path,
+			Line:   first.Line,
+			Author: first.Author.Login,
+			Body:   first.Body,
+		})
+	}
+	return comments, nil
+}
+
+// ReplyToReviewComment replies in the thread of the review comment with the given ID.
+func (c *Client) ReplyToReviewComment(ctx context.Context, number int, id int64, body string) error {
+	endpoint := fmt.Sprintf("repos/{owner}/{repo}/pulls/%d/comments/%d/replies", number, id)
+	_, err := c.gh(ctx, "api", "--method", "POST", endpoint, "-f", "body="+body)
+	return err
+}
+
+// CheckoutPullRequest checks out the head branch of the pull request identified by ref.
+func (c *Client) CheckoutPullRequest(ctx context.Context, ref string) error {
+	_, err := c.gh(ctx, "pr", "checkout", ref)
+	return err
+}
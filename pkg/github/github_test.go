@@ -0,0 +1,238 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mockCommandContext creates a mock exec.Cmd that calls TestHelperProcess instead of gh.
+// The invoked arguments are recorded in args.
+func mockCommandContext(stdout string, stderr string, exitCode int, args *[]string) func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+	return func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		if args != nil {
+			*args = append([]string{name}, arg...)
+		}
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cs = append(cs, arg...)
+		cmd := exec.CommandContext(ctx, os.Args[0], cs...)
+		cmd.Env = []string{
+			"GO_WANT_HELPER_PROCESS=1",
+			fmt.Sprintf("GO_HELPER_STDOUT=%s", stdout),
+			fmt.Sprintf("GO_HELPER_STDERR=%s", stderr),
+			fmt.Sprintf("GO_HELPER_EXIT_CODE=%d", exitCode),
+		}
+		return cmd
+	}
+}
+
+// TestHelperProcess is not a real test. It's used as a mock process for exec.Command tests.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	exitCode := 0
+	fmt.Sscanf(os.Getenv("GO_HELPER_EXIT_CODE"), "%d", &exitCode)
+	fmt.Fprint(os.Stdout, os.Getenv("GO_HELPER_STDOUT"))
+	fmt.Fprint(os.Stderr, os.Getenv("GO_HELPER_STDERR"))
+	os.Exit(exitCode)
+}
+
+func TestCreatePullRequest(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var args []string
+	commandContext = mockCommandContext("Creating pull request\nhttps://github.com/o/r/pull/42\n", "", 0, &args)
+
+	pr, err := New(t.TempDir()).CreatePullRequest(context.Background(), forge.PullRequestOptions{
+		Title:     "feat: add login",
+		Body:      "body",
+		Base:      "main",
+		Head:      "add-login",
+		Draft:     true,
+		Labels:    []string{"gonzo", "triage"},
+		Reviewers: []string{"octocat"},
+		Assignees: []string{"hubot"},
+	})
+	if err != nil {
+		t.Fatalf("CreatePullRequest() returned error: %v", err)
+	}
+	if pr.URL != "https://github.com/o/r/pull/42" || pr.Number != 42 {
+		t.Errorf("expected PR #42 with URL, got %+v", pr)
+	}
+
+	got := strings.Join(args, " ")
+	for _, want := range []string{"gh pr create", "--title feat: add login", "--base main", "--head add-login",
+		"--draft", "--label gonzo --label triage", "--reviewer octocat", "--assignee hubot"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in gh arguments, got %q", want, got)
+		}
+	}
+}
+
+func TestFindPullRequest(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	commandContext = mockCommandContext(`{"number":7,"url":"https://github.com/o/r/pull/7","state":"OPEN","title":"t"}`, "", 0, nil)
+	pr, err := New(t.TempDir()).FindPullRequest(context.Background(), "branch")
+	if err != nil {
+		t.Fatalf("FindPullRequest() returned error: %v", err)
+	}
+	if pr.Number != 7 {
+		t.Errorf("expected PR #7, got %+v", pr)
+	}
+
+	commandContext = mockCommandContext(`{"number":7,"url":"u","state":"MERGED"}`, "", 0, nil)
+	if _, err := New(t.TempDir()).FindPullRequest(context.Background(), "branch"); !errors.Is(err, forge.ErrNotFound) {
+		t.Errorf("expected forge.ErrNotFound for a merged PR, got %v", err)
+	}
+
+	commandContext = mockCommandContext("", `no pull requests found for branch "branch"`, 1, nil)
+	if _, err := New(t.TempDir()).FindPullRequest(context.Background(), "branch"); !errors.Is(err, forge.ErrNotFound) {
+		t.Errorf("expected forge.ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetIssue(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var args []string
+	commandContext = mockCommandContext(`{"number":12,"title":"Add login","body":"Users need to log in","url":"https://github.com/o/r/issues/12",
+		"comments":[{"author":{"login":"octocat"},"body":"Use OAuth"}]}`, "", 0, &args)
+
+	issue, err := New(t.TempDir()).GetIssue(context.Background(), "https://github.com/o/r/issues/12")
+	if err != nil {
+		t.Fatalf("GetIssue() returned error: %v", err)
+	}
+	if issue.Number != 12 || issue.Title != "Add login" || issue.Body != "Users need to log in" {
+		t.Errorf("unexpected issue: %+v", issue)
+	}
+	if len(issue.Comments) != 1 || issue.Comments[0].Author.Login != "octocat" || issue.Comments[0].Body != "Use OAuth" {
+		t.Errorf("unexpected comments: %+v", issue.Comments)
+	}
+	if got := strings.Join(args, " "); !strings.HasPrefix(got, "gh issue view https://github.com/o/r/issues/12 --json") {
+		t.Errorf("unexpected gh arguments %q", got)
+	}
+}
+
+func TestCommentOnIssue(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var args []string
+	commandContext = mockCommandContext("https://github.com/o/r/issues/12#issuecomment-1\n", "", 0, &args)
+
+	if err := New(t.TempDir()).CommentOnIssue(context.Background(), 12, "done"); err != nil {
+		t.Fatalf("CommentOnIssue() returned error: %v", err)
+	}
+	if got := strings.Join(args, " "); got != "gh issue comment 12 --body done" {
+		t.Errorf("unexpected gh arguments %q", got)
+	}
+}
+
+func TestCommentOnPullRequest(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var args []string
+	commandContext = mockCommandContext("", "", 0, &args)
+
+	if err := New(t.TempDir()).CommentOnPullRequest(context.Background(), "https://github.com/o/r/pull/7", "summary"); err != nil {
+		t.Fatalf("CommentOnPullRequest() returned error: %v", err)
+	}
+	if got := strings.Join(args, " "); got != "gh pr comment https://github.com/o/r/pull/7 --body summary" {
+		t.Errorf("unexpected gh arguments %q", got)
+	}
+}
+
+func TestUnresolvedReviewComments(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var args []string
+	commandContext = mockCommandContext(`{"data":{"repository":{"pullRequest":{"reviewThreads":{"nodes":[
+		{"isResolved":true,"comments":{"nodes":[{"databaseId":1,"path":"a.go","line":3,"body":"done already","author":{"login":"octocat"}}]}},
+		{"isResolved":false,"comments":{"nodes":[{"databaseId":2,"path":"b.go","line":7,"body":"rename this","author":{"login":"hubot"}}]}}
+	]}}}}}`, "", 0, &args)
+
+	comments, err := New(t.TempDir()).UnresolvedReviewComments(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("UnresolvedReviewComments() returned error: %v", err)
+	}
+	if len(comments) != 1 {
+		t.Fatalf("expected only the unresolved comment, got %+v", comments)
+	}
+	want := ReviewComment{ID: 2, Path: "b.go", Line: 7, Author: "hubot", Body: "rename this"}
+	if comments[0] != want {
+		t.Errorf("expected %+v, got %+v", want, comments[0])
+	}
+	if got := strings.Join(args, " "); !strings.Contains(got, "api graphql") || !strings.Contains(got, "number=7") {
+		t.Errorf("unexpected gh arguments %q", got)
+	}
+}
+
+func TestReplyToReviewComment(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var args []string
+	commandContext = mockCommandContext("{}", "", 0, &args)
+
+	if err := New(t.TempDir()).ReplyToReviewComment(context.Background(), 7, 2, "Renamed"); err != nil {
+		t.Fatalf("ReplyToReviewComment() returned error: %v", err)
+	}
+	if got := strings.Join(args, " "); got != "gh api --method POST repos/{owner}/{repo}/pulls/7/comments/2/replies -f body=Renamed" {
+		t.Errorf("unexpected gh arguments %q", got)
+	}
+}
+
+func TestGhErrorIncludesStderr(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	commandContext = mockCommandContext("", "authentication required", 1, nil)
+	_, err := New(t.TempDir()).DefaultBranch(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "authentication required") {
+		t.Errorf("expected error to include stderr, got %v", err)
+	}
+}
+
+func TestAuthStatus(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var args []string
+	commandContext = mockCommandContext("", "You are not logged into any GitHub hosts", 1, &args)
+	err := New(t.TempDir()).AuthStatus(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "not logged into any GitHub hosts") {
+		t.Errorf("expected gh's message in the error, got %v", err)
+	}
+	if strings.Join(args, " ") != "gh auth status" {
+		t.Errorf("unexpected gh arguments %v", args)
+	}
+
+	// A configured token is passed to gh in its environment
+	var cmd *exec.Cmd
+	mock := mockCommandContext("", "", 0, nil)
+	commandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		cmd = mock(ctx, name, arg...)
+		return cmd
+	}
+	c := New(t.TempDir())
+	c.Token = "ghp_test"
+	if err := c.AuthStatus(context.Background()); err != nil {
+		t.Fatalf("AuthStatus() returned error: %v", err)
+	}
+	if !slices.Contains(cmd.Env, "GH_TOKEN=ghp_test") {
+		t.Errorf("expected GH_TOKEN in the environment, got %v", cmd.Env)
+	}
+}
@@ -0,0 +1,237 @@
+// Package gitlab talks to GitLab on behalf of gonzo by shelling out to the glab CLI,
+// which takes care of authentication and self-managed instances.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// GlabCli is the name of the GitLab CLI executable.
+const GlabCli = "glab"
+
+// commandContext is a variable that wraps exec.CommandContext for testing.
+var commandContext = exec.CommandContext
+
+// Client runs glab commands against the repository checked out in Dir. It implements forge.Forge,
+// mapping merge requests to pull requests and notes to comments.
+type Client struct {
+	Dir string
+	// Token, when set, is passed to glab as GITLAB_TOKEN instead of its own stored login.
+	Token string
+}
+
+var _ forge.Forge = (*Client)(nil)
+
+// mergeRequest is the subset of merge request fields returned by glab.
+type mergeRequest struct {
+	IID    int    `json:"iid"`
+	WebURL string `json:"web_url"`
+	State  string `json:"state"`
+	Title  string `json:"title"`
+}
+
+// New returns a Client for the repository in dir.
+func New(dir string) *Client {
+	return &Client{Dir: dir}
+}
+
+// glab runs a glab subcommand and returns its trimmed stdout.
+// On failure the returned error includes glab's stderr.
+func (c *Client) glab(ctx context.Context, args ...string) (string, error) {
+	cmd := commandContext(ctx, GlabCli, args...)
+	cmd.Dir = c.Dir
+	if c.Token != "" {
+		cmd.Env = append(cmd.Environ(), "GITLAB_TOKEN="+c.Token)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		name := strings.Join(args[:min(2, len(args))], " ")
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "404") {
+			return "", fmt.Errorf("glab %s: %w: %s", name, forge.ErrNotFound, msg)
+		}
+		if msg == "" {
+			return "", fmt.Errorf("glab %s: %w", name, err)
+		}
+		return "", fmt.Errorf("glab %s: %w: %s", name, err, msg)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Name returns forge.GitLab.
+func (c *Client) Name() string {
+	return forge.GitLab
+}
+
+// AuthStatus returns an error when glab is not logged in or its token is invalid.
+func (c *Client) AuthStatus(ctx context.Context) error {
+	_, err := c.glab(ctx, "auth", "status")
+	return err
+}
+
+// FindPullRequest returns the open merge request for the head branch, or forge.ErrNotFound.
+func (c *Client) FindPullRequest(ctx context.Context, head string) (*forge.PullRequest, error) {
+	out, err := c.glab(ctx, "mr", "list", "--source-branch", head, "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	var mrs []mergeRequest
+	if err := json.Unmarshal([]byte(out), &mrs); err != nil {
+		return nil, fmt.Errorf("failed to decode merge requests: %w", err)
+	}
+	for _, mr := range mrs {
+		if mr.State == "opened" {
+			return &forge.PullRequest{Number: mr.IID, URL: mr.WebURL, State: "OPEN", Title: mr.Title}, nil
+		}
+	}
+	return nil, forge.ErrNotFound
+}
+
+// CreatePullRequest opens a merge request and returns it.
+func (c *Client) CreatePullRequest(ctx context.Context, opts forge.PullRequestOptions) (*forge.PullRequest, error) {
+	args := []string{"mr", "create", "--yes", "--title", opts.Title, "--description", opts.Body}
+	if opts.Base != "" {
+		args = append(args, "--target-branch", opts.Base)
+	}
+	if opts.Head != "" {
+		args = append(args, "--source-branch", opts.Head)
+	}
+	if opts.Draft {
+		args = append(args, "--draft")
+	}
+	for _, label := range opts.Labels {
+		args = append(args, "--label", label)
+	}
+	for _, reviewer := range opts.Reviewers {
+		args = append(args, "--reviewer", reviewer)
+	}
+	for _, assignee := range opts.Assignees {
+		args = append(args, "--assignee", assignee)
+	}
+
+	out, err := c.glab(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// glab prints the URL of the new merge request as the last line
+	lines := strings.Split(out, "\n")
+	url := strings.TrimSpace(lines[len(lines)-1])
+	return &forge.PullRequest{
+		Number: refNumber(url, "/merge_requests/"),
+		URL:    url,
+		State:  "OPEN",
+		Title:  opts.Title,
+	}, nil
+}
+
+// CommentOnPullRequest adds a note to the merge request identified by ref, a number, URL or branch.
+func (c *Client) CommentOnPullRequest(ctx context.Context, ref string, body string) error {
+	if n := refNumber(ref, "/merge_requests/"); n != 0 {
+		ref = strconv.Itoa(n)
+	}
+	_, err := c.glab(ctx, "mr", "note", ref, "--message", body)
+	return err
+}
+
+// DefaultBranch returns the name of the project's default branch.
+func (c *Client) DefaultBranch(ctx context.Context) (string, error) {
+	out, err := c.glab(ctx, "repo", "view", "--output", "json")
+	if err != nil {
+		return "", err
+	}
+
+	var project struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := json.Unmarshal([]byte(out), &project); err != nil {
+		return "", fmt.Errorf("failed to decode project: %w", err)
+	}
+	return project.DefaultBranch, nil
+}
+
+// GetIssue returns the issue identified by ref, which is either a number or a URL, including its notes.
+func (c *Client) GetIssue(ctx context.Context, ref string) (*forge.Issue, error) {
+	number, err := strconv.Atoi(ref)
+	if err != nil {
+		if number = refNumber(ref, "/issues/"); number == 0 {
+			return nil, fmt.Errorf("invalid issue reference %q", ref)
+		}
+	}
+
+	out, err := c.glab(ctx, "api", fmt.Sprintf("projects/:id/issues/%d", number))
+	if err != nil {
+		return nil, err
+	}
+	var issue struct {
+		IID         int    `json:"iid"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		WebURL      string `json:"web_url"`
+	}
+	if err := json.Unmarshal([]byte(out), &issue); err != nil {
+		return nil, fmt.Errorf("failed to decode issue: %w", err)
+	}
+
+	out, err = c.glab(ctx, "api", fmt.Sprintf("projects/:id/issues/%d/notes?sort=asc&per_page=100", number))
+	if err != nil {
+		return nil, err
+	}
+	var notes []struct {
+		Body   string `json:"body"`
+		System bool   `json:"system"`
+		Author struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	}
+	if err := json.Unmarshal([]byte(out), &notes); err != nil {
+		return nil, fmt.Errorf("failed to decode issue notes: %w", err)
+	}
+
+	result := &forge.Issue{Number: issue.IID, Title: issue.Title, Body: issue.Description, URL: issue.WebURL}
+	for _, n := range notes {
+		// System notes record events such as label changes, not discussion
+		if n.System {
+			continue
+		}
+		comment := forge.IssueComment{Body: n.Body}
+		comment.Author.Login = n.Author.Username
+		result.Comments = append(result.Comments, comment)
+	}
+	return result, nil
+}
+
+// CommentOnIssue adds a note to the issue with the given number.
+func (c *Client) CommentOnIssue(ctx context.Context, number int, body string) error {
+	_, err := c.glab(ctx, "issue", "note", strconv.Itoa(number), "--message", body)
+	return err
+}
+
+// refNumber extracts the number following marker in a GitLab URL, or returns 0.
+func refNumber(url string, marker string) int {
+	i := strings.LastIndex(url, marker)
+	if i < 0 {
+		return 0
+	}
+	rest := url[i+len(marker):]
+	if j := strings.IndexAny(rest, "/#?"); j >= 0 {
+		rest = rest[:j]
+	}
+	n, err := strconv.Atoi(rest)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,185 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// mockCommandContext creates a mock exec.Cmd that calls TestHelperProcess instead of glab.
+// Successive calls print successive entries of stdouts; the arguments of every call are recorded in calls.
+func mockCommandContext(stdouts []string, stderr string, exitCode int, calls *[]string) func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+	n := 0
+	return func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		if calls != nil {
+			*calls = append(*calls, strings.Join(append([]string{name}, arg...), " "))
+		}
+		stdout := ""
+		if n < len(stdouts) {
+			stdout = stdouts[n]
+		}
+		n++
+
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cs = append(cs, arg...)
+		cmd := exec.CommandContext(ctx, os.Args[0], cs...)
+		cmd.Env = []string{
+			"GO_WANT_HELPER_PROCESS=1",
+			fmt.Sprintf("GO_HELPER_STDOUT=%s", stdout),
+			fmt.Sprintf("GO_HELPER_STDERR=%s", stderr),
+			fmt.Sprintf("GO_HELPER_EXIT_CODE=%d", exitCode),
+		}
+		return cmd
+	}
+}
+
+// TestHelperProcess is not a real test. It's used as a mock process for exec.Command tests.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	exitCode := 0
+	fmt.Sscanf(os.Getenv("GO_HELPER_EXIT_CODE"), "%d", &exitCode)
+	fmt.Fprint(os.Stdout, os.Getenv("GO_HELPER_STDOUT"))
+	fmt.Fprint(os.Stderr, os.Getenv("GO_HELPER_STDERR"))
+	os.Exit(exitCode)
+}
+
+func TestCreatePullRequest(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var calls []string
+	commandContext = mockCommandContext([]string{"Creating merge request\nhttps://gitlab.com/o/r/-/merge_requests/42\n"}, "", 0, &calls)
+
+	pr, err := New(t.TempDir()).CreatePullRequest(context.Background(), forge.PullRequestOptions{
+		Title:  "feat: add login",
+		Body:   "body",
+		Base:   "main",
+		Head:   "add-login",
+		Draft:  true,
+		Labels: []string{"gonzo"},
+	})
+	if err != nil {
+		t.Fatalf("CreatePullRequest() returned error: %v", err)
+	}
+	if pr.Number != 42 || pr.URL != "https://gitlab.com/o/r/-/merge_requests/42" {
+		t.Errorf("expected MR !42 with URL, got %+v", pr)
+	}
+	for _, want := range []string{"glab mr create --yes", "--description body", "--target-branch main", "--source-branch add-login", "--draft", "--label gonzo"} {
+		if !strings.Contains(calls[0], want) {
+			t.Errorf("expected %q in glab arguments, got %q", want, calls[0])
+		}
+	}
+}
+
+func TestFindPullRequest(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	commandContext = mockCommandContext([]string{`[{"iid":7,"web_url":"https://gitlab.com/o/r/-/merge_requests/7","state":"opened","title":"t"}]`}, "", 0, nil)
+	pr, err := New(t.TempDir()).FindPullRequest(context.Background(), "branch")
+	if err != nil {
+		t.Fatalf("FindPullRequest() returned error: %v", err)
+	}
+	if pr.Number != 7 || pr.State != "OPEN" {
+		t.Errorf("expected open MR !7, got %+v", pr)
+	}
+
+	commandContext = mockCommandContext([]string{`[]`}, "", 0, nil)
+	if _, err := New(t.TempDir()).FindPullRequest(context.Background(), "branch"); !errors.Is(err, forge.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetIssue(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var calls []string
+	commandContext = mockCommandContext([]string{
+		`{"iid":12,"title":"Add login","description":"Users need to log in","web_url":"https://gitlab.com/o/r/-/issues/12"}`,
+		`[{"body":"added label","system":true,"author":{"username":"bot"}},{"body":"Use OAuth","system":false,"author":{"username":"alice"}}]`,
+	}, "", 0, &calls)
+
+	issue, err := New(t.TempDir()).GetIssue(context.Background(), "https://gitlab.com/o/r/-/issues/12")
+	if err != nil {
+		t.Fatalf("GetIssue() returned error: %v", err)
+	}
+	if issue.Number != 12 || issue.Title != "Add login" || issue.Body != "Users need to log in" {
+		t.Errorf("unexpected issue: %+v", issue)
+	}
+	if len(issue.Comments) != 1 || issue.Comments[0].Author.Login != "alice" {
+		t.Errorf("expected only the non-system note, got %+v", issue.Comments)
+	}
+	if calls[0] != "glab api projects/:id/issues/12" {
+		t.Errorf("unexpected glab arguments %q", calls[0])
+	}
+
+	if _, err := New(t.TempDir()).GetIssue(context.Background(), "not-an-issue"); err == nil {
+		t.Error("expected error for an invalid issue reference")
+	}
+}
+
+func TestNotes(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var calls []string
+	commandContext = mockCommandContext(nil, "", 0, &calls)
+
+	c := New(t.TempDir())
+	if err := c.CommentOnIssue(context.Background(), 12, "done"); err != nil {
+		t.Fatalf("CommentOnIssue() returned error: %v", err)
+	}
+	if err := c.CommentOnPullRequest(context.Background(), "https://gitlab.com/o/r/-/merge_requests/7", "summary"); err != nil {
+		t.Fatalf("CommentOnPullRequest() returned error: %v", err)
+	}
+
+	if calls[0] != "glab issue note 12 --message done" {
+		t.Errorf("unexpected glab arguments %q", calls[0])
+	}
+	if calls[1] != "glab mr note 7 --message summary" {
+		t.Errorf("unexpected glab arguments %q", calls[1])
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	commandContext = mockCommandContext([]string{`{"default_branch":"trunk"}`}, "", 0, nil)
+	branch, err := New(t.TempDir()).DefaultBranch(context.Background())
+	if err != nil {
+		t.Fatalf("DefaultBranch() returned error: %v", err)
+	}
+	if branch != "trunk" {
+		t.Errorf("expected trunk, got %q", branch)
+	}
+}
+
+func TestAuthStatus(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var calls []string
+	commandContext = mockCommandContext(nil, "", 0, &calls)
+	c := New(t.TempDir())
+	c.Token = "glpat-test"
+	if err := c.AuthStatus(context.Background()); err != nil {
+		t.Fatalf("AuthStatus() returned error: %v", err)
+	}
+	if calls[0] != "glab auth status" {
+		t.Errorf("unexpected glab arguments %q", calls[0])
+	}
+
+	commandContext = mockCommandContext(nil, "No token provided", 1, nil)
+	if err := New(t.TempDir()).AuthStatus(context.Background()); err == nil || !strings.Contains(err.Error(), "No token provided") {
+		t.Errorf("expected glab's message in the error, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package gonzo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// StopFile is the file in a run's directory asking the run to stop after its current iteration.
+const StopFile = "stop"
+
+// ErrNoRunningRun is returned when no recorded run is running.
+var ErrNoRunningRun = errors.New("no running gonzo run")
+
+// RequestStop asks the run with the given ID, or the most recent running run when id is empty,
+// to stop after its current iteration. The run finalizes its state and pull request as when
+// it runs out of iterations, with the aborted status.
+func RequestStop(dir string, id string) (*RunState, error) {
+	var state *RunState
+	if id != "" {
+		s, err := ResolveRunState(dir, id)
+		if err != nil {
+			return nil, err
+		}
+		if s.Status != RunStatusRunning {
+			return nil, fmt.Errorf("run %s is not running (status: %s)", s.ID, s.Status)
+		}
+		state = s
+	} else {
+		states, err := ListRunStates(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range slices.Backward(states) {
+			if s.Status == RunStatusRunning {
+				state = s
+				break
+			}
+		}
+		if state == nil {
+			return nil, ErrNoRunningRun
+		}
+	}
+
+	if err := state.SaveArtifact(dir, StopFile, []byte(time.Now().Format(time.RFC3339)+"\n")); err != nil {
+		return nil, fmt.Errorf("failed to request stop: %w", err)
+	}
+	return state, nil
+}
+
+// stopRequested reports whether `gonzo abort` asked the run to stop, consuming the request.
+func (s *RunState) stopRequested(dir string) bool {
+	path := filepath.Join(RunDir(dir, s.ID), StopFile)
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+	Swallow(os.Remove(path))
+	return true
+}
@@ -0,0 +1,90 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRequestStop(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := RequestStop(dir, ""); !errors.Is(err, ErrNoRunningRun) {
+		t.Errorf("expected ErrNoRunningRun, got %v", err)
+	}
+
+	done := &RunState{ID: "20260101-000000-aaaaaa", Status: RunStatusCompleted, StartedAt: time.Now().Add(-time.Hour)}
+	running := &RunState{ID: "20260101-010000-bbbbbb", Status: RunStatusRunning, StartedAt: time.Now()}
+	for _, s := range []*RunState{done, running} {
+		if err := s.Save(dir); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+
+	if _, err := RequestStop(dir, done.ID); err == nil {
+		t.Error("expected an error for a finished run")
+	}
+
+	state, err := RequestStop(dir, "")
+	if err != nil {
+		t.Fatalf("RequestStop() returned error: %v", err)
+	}
+	if state.ID != running.ID {
+		t.Errorf("expected the running run to be stopped, got %s", state.ID)
+	}
+
+	if !running.stopRequested(dir) {
+		t.Error("expected a stop request")
+	}
+	if running.stopRequested(dir) {
+		t.Error("expected the stop request to be consumed")
+	}
+}
+
+func TestGenerate_Abort(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+
+	// Abort from "another terminal" while the first iteration runs
+	calls := 0
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if !slices.Contains(args, "--print") {
+			return mockCommandContext("1.0.0", 0)(ctx, name, args...)
+		}
+		calls++
+		if _, err := RequestStop(dir, ""); err != nil {
+			t.Errorf("RequestStop() returned error: %v", err)
+		}
+		return mockCommandContext("still working", 0)(ctx, name, args...)
+	}
+
+	result, err := New(WithDir(dir)).WithQuiet(true).WithMaxIterations(5).Generate(context.Background(), "add a login button")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected the run to stop after one iteration, got %d", calls)
+	}
+	if result != "still working" {
+		t.Errorf("expected the last output, got %q", result)
+	}
+
+	state, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("LatestRunState() returned error: %v", err)
+	}
+	if state.Status != RunStatusAborted || state.PID != os.Getpid() {
+		t.Errorf("expected an aborted run recording its pid, got %+v", state)
+	}
+	if _, err := os.Stat(filepath.Join(RunDir(dir, state.ID), StopFile)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the stop request to be consumed, got %v", err)
+	}
+}
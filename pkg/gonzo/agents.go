@@ -0,0 +1,194 @@
+package gonzo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// agentsDir is where the Claude CLI looks up the subagents of a project, and of the user under
+// their home directory.
+const agentsDir = ".claude/agents"
+
+// Agent is a subagent Claude may delegate parts of the task to with its Task tool, such as a
+// tester or a reviewer, with its own prompt and tools.
+type Agent struct {
+	Name        string   `json:"-"`
+	Description string   `json:"description"`
+	Prompt      string   `json:"prompt"`
+	Tools       []string `json:"tools,omitempty"`
+	Model       string   `json:"model,omitempty"`
+}
+
+// WithAgents forwards subagents to every Claude CLI call of a run. Each entry is the name of an
+// agent defined in .claude/agents of the repository or of the home directory, the path of an
+// agent file or of a directory of them, relative to the repository, or a JSON object of agents
+// inline, in the format of the --agents flag of the Claude CLI.
+func WithAgents(agents []string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.agents = agents
+	}
+}
+
+// WithAgents applies the WithAgents option to cc.
+//
+// Deprecated: Pass WithAgents to New instead.
+func (cc *ClaudeConfig) WithAgents(agents []string) *ClaudeConfig {
+	WithAgents(agents)(cc)
+	return cc
+}
+
+// loadAgents reads the subagents of the run from the repository in dir, failing on entries that
+// cannot be found or read.
+func (cc *ClaudeConfig) loadAgents(dir string) ([]Agent, error) {
+	var agents []Agent
+	for _, entry := range cc.agents {
+		loaded, err := resolveAgents(dir, entry)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range loaded {
+			// A later entry defining the same agent replaces it
+			agents = slices.DeleteFunc(agents, func(b Agent) bool { return b.Name == a.Name })
+			agents = append(agents, a)
+		}
+	}
+	return agents, nil
+}
+
+// resolveAgents returns the agents an entry of WithAgents defines.
+func resolveAgents(dir string, entry string) ([]Agent, error) {
+	if strings.HasPrefix(strings.TrimSpace(entry), "{") {
+		var defined map[string]Agent
+		if err := json.Unmarshal([]byte(entry), &defined); err != nil {
+			return nil, fmt.Errorf("invalid agents %s: %w", firstLine(entry), err)
+		}
+		var agents []Agent
+		for name, a := range defined {
+			a.Name = name
+			agents = append(agents, a)
+		}
+		slices.SortFunc(agents, func(a, b Agent) int { return strings.Compare(a.Name, b.Name) })
+		return agents, nil
+	}
+
+	if !strings.ContainsRune(entry, '/') && !strings.HasSuffix(entry, ".md") {
+		candidates := []string{filepath.Join(dir, agentsDir, entry+".md")}
+		if home, err := os.UserHomeDir(); err == nil {
+			candidates = append(candidates, filepath.Join(home, agentsDir, entry+".md"))
+		}
+		for _, path := range candidates {
+			if _, err := os.Stat(path); err == nil {
+				a, err := readAgentFile(path)
+				return []Agent{a}, err
+			}
+		}
+		// Else it may be a directory of agents
+		if _, err := os.Stat(filepath.Join(dir, entry)); err != nil {
+			return nil, fmt.Errorf("agent %s not found in %s", entry, agentsDir)
+		}
+	}
+
+	path := entry
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent: %w", err)
+	}
+	if !info.IsDir() {
+		a, err := readAgentFile(path)
+		return []Agent{a}, err
+	}
+	files, err := filepath.Glob(filepath.Join(path, "*.md"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agents: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no agent files in %s", entry)
+	}
+	var agents []Agent
+	for _, file := range files {
+		a, err := readAgentFile(file)
+		if err != nil {
+			return nil, err
+		}
+		agents = append(agents, a)
+	}
+	return agents, nil
+}
+
+// readAgentFile reads an agent file of the Claude CLI: its prompt, after a front matter with its
+// name, description and optionally its tools and model. The name defaults to the file name.
+func readAgentFile(path string) (Agent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Agent{}, fmt.Errorf("failed to read agent: %w", err)
+	}
+	front, body, ok, err := splitFrontMatter(string(data))
+	if err == nil && !ok {
+		err = errors.New("no front matter")
+	}
+	if err != nil {
+		return Agent{}, fmt.Errorf("invalid agent %s: %w", path, err)
+	}
+	var fm struct {
+		Name        string    `yaml:"name"`
+		Description string    `yaml:"description"`
+		Tools       yaml.Node `yaml:"tools"`
+		Model       string    `yaml:"model"`
+	}
+	if err := yaml.Unmarshal([]byte(front), &fm); err != nil {
+		return Agent{}, fmt.Errorf("invalid agent %s: %w", path, err)
+	}
+	a := Agent{Name: fm.Name, Description: fm.Description, Prompt: body, Model: fm.Model}
+	if a.Name == "" {
+		a.Name = strings.TrimSuffix(filepath.Base(path), ".md")
+	}
+	// Tools are a comma-separated list or a YAML one
+	switch fm.Tools.Kind {
+	case yaml.ScalarNode:
+		for _, tool := range strings.Split(fm.Tools.Value, ",") {
+			if tool = strings.TrimSpace(tool); tool != "" {
+				a.Tools = append(a.Tools, tool)
+			}
+		}
+	case yaml.SequenceNode:
+		if err := fm.Tools.Decode(&a.Tools); err != nil {
+			return Agent{}, fmt.Errorf("invalid agent %s: %w", path, err)
+		}
+	}
+	if a.Description == "" {
+		return Agent{}, fmt.Errorf("invalid agent %s: no description", path)
+	}
+	return a, nil
+}
+
+// agentsArgs returns the flags of the Claude CLI defining the subagents of the run, if any.
+func (cc *ClaudeConfig) agentsArgs() []string {
+	if len(cc.subagents) == 0 {
+		return nil
+	}
+	defined := make(map[string]Agent, len(cc.subagents))
+	for _, a := range cc.subagents {
+		defined[a.Name] = a
+	}
+	data, _ := json.Marshal(defined)
+	return []string{"--agents", string(data)}
+}
+
+// agentNames returns the names of the agents.
+func agentNames(agents []Agent) []string {
+	names := make([]string, len(agents))
+	for i, a := range agents {
+		names[i] = a.Name
+	}
+	return names
+}
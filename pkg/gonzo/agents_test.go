@@ -0,0 +1,131 @@
+package gonzo
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testAgentFile = `---
+name: tester
+description: Writes and runs the tests of a change
+tools: Read, Grep, Bash
+model: sonnet
+---
+You write tests.
+`
+
+// writeAgent writes an agent file under dir.
+func writeAgent(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create agents directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write agent: %v", err)
+	}
+}
+
+func TestLoadAgents(t *testing.T) {
+	dir, home := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	writeAgent(t, filepath.Join(dir, agentsDir), "tester.md", testAgentFile)
+	writeAgent(t, filepath.Join(home, agentsDir), "reviewer.md", "---\ndescription: Reviews changes\ntools: [Read, Grep]\n---\nYou review.\n")
+	writeAgent(t, filepath.Join(dir, "agents"), "docs.md", "---\ndescription: Writes docs\n---\nYou write docs.\n")
+	writeAgent(t, filepath.Join(dir, "agents"), "broken.txt", "not an agent")
+	writeAgent(t, dir, "nofront.md", "You do things.\n")
+
+	tests := []struct {
+		name    string
+		agents  []string
+		want    []string
+		wantErr string
+	}{
+		{"none", nil, nil, ""},
+		{"project agent", []string{"tester"}, []string{"tester"}, ""},
+		{"user agent", []string{"reviewer"}, []string{"reviewer"}, ""},
+		{"directory", []string{"agents"}, []string{"docs"}, ""},
+		{"file", []string{"agents/docs.md"}, []string{"docs"}, ""},
+		{"inline", []string{`{"linter":{"description":"Lints","prompt":"You lint."}}`}, []string{"linter"}, ""},
+		{"deduplicated", []string{"tester", ".claude/agents/tester.md"}, []string{"tester"}, ""},
+		{"missing agent", []string{"deployer"}, nil, "agent deployer not found"},
+		{"no front matter", []string{"nofront.md"}, nil, "no front matter"},
+		{"invalid inline", []string{`{"linter":`}, nil, "invalid agents"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agents, err := New().WithAgents(tt.agents).loadAgents(dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := agentNames(agents); !slices.Equal(got, tt.want) && len(got)+len(tt.want) > 0 {
+				t.Errorf("loadAgents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	agents, err := New().WithAgents([]string{"tester", "reviewer"}).loadAgents(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tester := agents[0]; tester.Prompt != "You write tests." || !slices.Equal(tester.Tools, []string{"Read", "Grep", "Bash"}) || tester.Model != "sonnet" {
+		t.Errorf("unexpected tester agent: %+v", tester)
+	}
+	if reviewer := agents[1]; !slices.Equal(reviewer.Tools, []string{"Read", "Grep"}) {
+		t.Errorf("expected the tools of a YAML list, got %+v", reviewer)
+	}
+}
+
+func TestGenerate_Agents(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	writeAgent(t, filepath.Join(dir, agentsDir), "tester.md", testAgentFile)
+	var calls [][]string
+	mock := mockCommandContext("Done "+DefaultCompletionSignal, 0)
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if slices.Contains(args, "--print") {
+			calls = append(calls, args)
+		}
+		return mock(ctx, name, args...)
+	}
+
+	if _, err := New(WithDir(dir)).WithQuiet(true).WithAgents([]string{"tester"}).Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("expected one Claude call, got %d", len(calls))
+	}
+	i := slices.Index(calls[0], "--agents")
+	if i < 0 {
+		t.Fatalf("expected the agents to be forwarded, got %q", calls[0])
+	}
+	var agents map[string]Agent
+	if err := json.Unmarshal([]byte(calls[0][i+1]), &agents); err != nil {
+		t.Fatalf("invalid agents: %v", err)
+	}
+	if agents["tester"].Prompt != "You write tests." {
+		t.Errorf("expected the tester agent, got %+v", agents)
+	}
+	if !strings.Contains(strings.Join(calls[0], " "), "- `tester`: Writes and runs the tests of a change") {
+		t.Error("expected the agents to be listed in the system prompt")
+	}
+
+	_, err := New(WithDir(dir)).WithQuiet(true).WithAgents([]string{"deployer"}).Generate(context.Background(), "add a login button")
+	if ExitCode(err) != ExitPreflight {
+		t.Errorf("expected a missing agent to fail the preflight, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package gonzo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// BatchStatusSkipped is the status of the tasks left out after a failed task, next to the run statuses.
+const BatchStatusSkipped = "skipped"
+
+// Batch is a list of features to implement one after the other, read from a YAML file:
+//
+//	tasks:
+//	  - name: login
+//	    feature: Add a login button to the header
+//	    model: claude-sonnet-4-5
+//	    max-iterations: 5
+//	    branch: feature/login
+//	  - feature: Document the login flow
+type Batch struct {
+	Tasks []BatchTask `yaml:"tasks"`
+}
+
+// BatchTask is a single feature of a batch, with optional overrides of the configured
+// model and max-iterations. When Branch is set, the task runs on that branch instead of
+// one named by the agent.
+type BatchTask struct {
+	Name          string `yaml:"name,omitempty"`
+	Feature       string `yaml:"feature"`
+	Model         string `yaml:"model,omitempty"`
+	MaxIterations int    `yaml:"max-iterations,omitempty"`
+	Branch        string `yaml:"branch,omitempty"`
+}
+
+// BatchResult is the outcome of a batch task, as shown in the batch report.
+type BatchResult struct {
+	Task       BatchTask
+	RunID      string
+	Status     string
+	Branch     string
+	PRURL      string
+	Iterations int
+	Duration   time.Duration
+	Err        error
+}
+
+// Failed reports whether the task did not complete.
+func (r BatchResult) Failed() bool {
+	return r.Status != RunStatusCompleted
+}
+
+// Label returns the task's name, or the first line of its feature when it has none.
+func (t BatchTask) Label() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return firstLine(t.Feature)
+}
+
+// LoadBatch reads and validates a batch file.
+func LoadBatch(path string) (*Batch, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var b Batch
+	if err := decoder.Decode(&b); err != nil {
+		return nil, fmt.Errorf("failed to parse batch file %s: %w", path, err)
+	}
+	if len(b.Tasks) == 0 {
+		return nil, fmt.Errorf("batch file %s has no tasks", path)
+	}
+
+	var errs []error
+	for i, t := range b.Tasks {
+		if strings.TrimSpace(t.Feature) == "" {
+			errs = append(errs, fmt.Errorf("task %d has no feature", i+1))
+		}
+		if t.MaxIterations < 0 {
+			errs = append(errs, fmt.Errorf("task %d has a negative max-iterations", i+1))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid batch file %s: %w", path, err)
+	}
+	return &b, nil
+}
+
+// CheckoutBatchBranch checks out the branch a batch task runs on, starting from base.
+// An empty branch checks out base itself, so every task of a batch starts from the same commit.
+// An existing branch is checked out as it is.
+func CheckoutBatchBranch(ctx context.Context, dir string, branch string, base string) error {
+	if branch == "" {
+		if base == "" {
+			return nil
+		}
+		_, err := git(ctx, dir, "checkout", "--quiet", base)
+		return err
+	}
+
+	if _, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		_, err := git(ctx, dir, "checkout", "--quiet", branch)
+		return err
+	}
+
+	args := []string{"checkout", "--quiet", "-b", branch}
+	if base != "" {
+		args = append(args, base)
+	}
+	_, err := git(ctx, dir, args...)
+	return err
+}
+
+// BatchBase returns what the tasks of a batch started in dir start from:
+// the checked out branch, or the current commit when HEAD is detached.
+func BatchBase(ctx context.Context, dir string) (string, error) {
+	branch, err := currentBranch(ctx, dir)
+	if err != nil || branch != "" {
+		return branch, err
+	}
+	return headSHA(ctx, dir)
+}
@@ -0,0 +1,71 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadBatch(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "tasks.yaml")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write batch file: %v", err)
+		}
+		return path
+	}
+
+	b, err := LoadBatch(write("tasks:\n  - feature: Add login\n    branch: login\n  - name: docs\n    feature: |\n      Document login\n      in the README\n"))
+	if err != nil {
+		t.Fatalf("LoadBatch() returned error: %v", err)
+	}
+	if len(b.Tasks) != 2 || b.Tasks[0].Branch != "login" {
+		t.Errorf("unexpected tasks %+v", b.Tasks)
+	}
+	if b.Tasks[0].Label() != "Add login" || b.Tasks[1].Label() != "docs" {
+		t.Errorf("unexpected labels %q and %q", b.Tasks[0].Label(), b.Tasks[1].Label())
+	}
+
+	tests := map[string]string{
+		"tasks: []\n":                            "has no tasks",
+		"tasks:\n  - name: empty\n":              "task 1 has no feature",
+		"tasks:\n  - feature: x\n    modle: y\n": "field modle not found",
+	}
+	for content, want := range tests {
+		if _, err := LoadBatch(write(content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadBatch(%q) error = %v, want %q", content, err, want)
+		}
+	}
+}
+
+func TestCheckoutBatchBranch(t *testing.T) {
+	dir := initGitRepo(t)
+	ctx := context.Background()
+
+	base, err := BatchBase(ctx, dir)
+	if err != nil || base != "main" {
+		t.Fatalf("BatchBase() = %q, %v, want main", base, err)
+	}
+
+	if err := CheckoutBatchBranch(ctx, dir, "login", base); err != nil {
+		t.Fatalf("CheckoutBatchBranch() returned error: %v", err)
+	}
+	writeAndCommit(t, dir, "login.txt", "login\n", "add login")
+
+	// Back to the base for the next task, then to the existing branch again
+	if err := CheckoutBatchBranch(ctx, dir, "", base); err != nil {
+		t.Fatalf("CheckoutBatchBranch() returned error: %v", err)
+	}
+	if branch := runGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); branch != "main" {
+		t.Errorf("expected main to be checked out, got %q", branch)
+	}
+	if err := CheckoutBatchBranch(ctx, dir, "login", base); err != nil {
+		t.Fatalf("CheckoutBatchBranch() returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "login.txt")); err != nil {
+		t.Errorf("expected the existing branch to be checked out as it is: %v", err)
+	}
+}
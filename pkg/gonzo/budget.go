@@ -0,0 +1,160 @@
+package gonzo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Sections of the project context in the system prompt that can be truncated to fit the
+// prompt budget.
+const (
+	// SectionRepoMap is the repository map, truncated from its last directories.
+	SectionRepoMap = "repomap"
+	// SectionGuidance is the project guidance, whose inlined files are only referenced instead,
+	// starting from the last one.
+	SectionGuidance = "guidance"
+	// SectionVars is the project context made of the template variables, left out entirely.
+	SectionVars = "vars"
+)
+
+// DefaultContextWindow is the context window, in tokens, of models missing from ContextWindows.
+const DefaultContextWindow = 200_000
+
+// ContextWindows lists the context window of the supported models, in tokens.
+var ContextWindows = map[string]int{
+	ClaudeHaiku:  200_000,
+	ClaudeSonnet: 200_000,
+	ClaudeOpus:   200_000,
+}
+
+// promptBudgetShare is the share of the model's context window the system prompt and the
+// feature may take by default: the rest is left to Claude Code and the work of the iteration.
+const promptBudgetShare = 4
+
+// promptBudgetWarnPercent is the share of the prompt budget above which a run warns that the
+// prompt is approaching it.
+const promptBudgetWarnPercent = 80
+
+// truncatedRepoMap ends a repository map truncated to fit the prompt budget.
+const truncatedRepoMap = "- ... (truncated to fit the prompt budget)"
+
+// PromptBudget bounds the size of the system prompt and feature of each iteration.
+type PromptBudget struct {
+	// MaxTokens is the budget, in estimated tokens. Zero sets it to a quarter of the model's
+	// context window.
+	MaxTokens int
+	// Truncate lists the sections of the project context truncated, in order, until the
+	// prompt fits the budget: SectionRepoMap, SectionGuidance or SectionVars.
+	Truncate []string
+}
+
+// ContextWindow returns the context window of model, in tokens.
+func ContextWindow(model string) int {
+	if window, ok := ContextWindows[model]; ok {
+		return window
+	}
+	return DefaultContextWindow
+}
+
+// maxPromptTokens returns the prompt budget of the run, in estimated tokens.
+func (cc *ClaudeConfig) maxPromptTokens() int {
+	if cc.promptBudget.MaxTokens > 0 {
+		return cc.promptBudget.MaxTokens
+	}
+	return ContextWindow(cc.model) / promptBudgetShare
+}
+
+// fitSystemPrompt renders the system prompt, truncating the sections of the project context
+// listed by the prompt budget, in order, until the system prompt and the feature fit the
+// budget. It returns the sections that were truncated, and the estimated tokens taken by the
+// system prompt and the feature.
+func (cc *ClaudeConfig) fitSystemPrompt(progressFile string, feature string) (string, []string, int, error) {
+	pc := cc.promptContext()
+	prompt, err := cc.renderSystemPrompt(progressFile, pc)
+	if err != nil {
+		return "", nil, 0, err
+	}
+
+	maxTokens := cc.maxPromptTokens()
+	tokens := EstimateTokens(prompt) + EstimateTokens(feature)
+	var truncated []string
+	for _, section := range cc.promptBudget.Truncate {
+		if tokens <= maxTokens {
+			break
+		}
+		excess := tokens - maxTokens
+		switch section {
+		case SectionRepoMap:
+			if pc.RepoMap == "" {
+				continue
+			}
+			pc.RepoMap = truncateRepoMap(pc.RepoMap, excess)
+		case SectionGuidance:
+			if !slices.ContainsFunc(pc.Guidance, func(f GuidanceFile) bool { return f.Content != "" }) {
+				continue
+			}
+			pc.Guidance = referenceGuidance(pc.Guidance, excess)
+		case SectionVars:
+			if len(pc.Vars) == 0 {
+				continue
+			}
+			pc.Vars = nil
+		default:
+			return "", nil, 0, fmt.Errorf("unknown prompt section %q (known: %s, %s, %s)", section, SectionRepoMap, SectionGuidance, SectionVars)
+		}
+
+		if prompt, err = cc.renderSystemPrompt(progressFile, pc); err != nil {
+			return "", nil, 0, err
+		}
+		tokens = EstimateTokens(prompt) + EstimateTokens(feature)
+		truncated = append(truncated, section)
+	}
+	return prompt, truncated, tokens, nil
+}
+
+// logPromptBudget reports the sections truncated to fit the prompt budget, and warns when the
+// prompt is over or approaching it.
+func (cc *ClaudeConfig) logPromptBudget(truncated []string, tokens int) {
+	maxTokens := cc.maxPromptTokens()
+	if len(truncated) > 0 {
+		cc.logInfo("  Truncated to fit the prompt budget: %s", strings.Join(truncated, ", "))
+	}
+	switch {
+	case tokens > maxTokens:
+		cc.logWarn("  Warning: the prompt takes about %d tokens, over its budget of %d", tokens, maxTokens)
+	case tokens*100 > maxTokens*promptBudgetWarnPercent:
+		cc.logWarn("  Warning: the prompt takes about %d tokens, approaching its budget of %d", tokens, maxTokens)
+	}
+}
+
+// truncateRepoMap removes the last lines of a repository map until about excess tokens are
+// saved. The map is left out when nothing of it would remain.
+func truncateRepoMap(repoMap string, excess int) string {
+	lines := strings.Split(repoMap, "\n")
+	// Count characters rather than tokens, which EstimateTokens rounds up line by line
+	saved := -len(truncatedRepoMap) - 1
+	for len(lines) > 0 && saved < excess*4 {
+		saved += len(lines[len(lines)-1]) + 1
+		lines = lines[:len(lines)-1]
+	}
+	if strings.TrimSpace(strings.Join(lines, "")) == "" {
+		return ""
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")) + "\n" + truncatedRepoMap
+}
+
+// referenceGuidance references the inlined guidance files instead, from the last one, until
+// about excess tokens are saved.
+func referenceGuidance(files []GuidanceFile, excess int) []GuidanceFile {
+	files = append([]GuidanceFile(nil), files...)
+	saved := 0
+	for i := len(files) - 1; i >= 0 && saved < excess; i-- {
+		if files[i].Content == "" {
+			continue
+		}
+		saved += EstimateTokens(files[i].Content)
+		files[i].Content = ""
+	}
+	return files
+}
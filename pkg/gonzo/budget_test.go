@@ -0,0 +1,104 @@
+package gonzo
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContextWindow(t *testing.T) {
+	if got := ContextWindow(ClaudeSonnet); got != 200_000 {
+		t.Errorf("expected a context window of 200000 tokens for %s, got %d", ClaudeSonnet, got)
+	}
+	if got := ContextWindow("claude-future-9"); got != DefaultContextWindow {
+		t.Errorf("expected the default context window for an unknown model, got %d", got)
+	}
+	if got := New().WithModel(ClaudeOpus).maxPromptTokens(); got != 50_000 {
+		t.Errorf("expected a default budget of a quarter of the context window, got %d", got)
+	}
+}
+
+func TestFitSystemPrompt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "CLAUDE.md"), []byte(strings.Repeat("Use tabs. ", 400)), 0644); err != nil {
+		t.Fatalf("failed to write CLAUDE.md: %v", err)
+	}
+
+	var lines []string
+	for i := 0; i < 200; i++ {
+		lines = append(lines, "- `pkg/module/` (3 files): Package module does something useful.")
+	}
+	cc := New(WithWorkingDir(dir)).WithGuidance(GuidanceOptions{Mode: GuidanceInline, Files: []string{"CLAUDE.md"}})
+	cc.repoMap = strings.Join(lines, "\n")
+
+	full, truncated, tokens, err := cc.fitSystemPrompt(".gonzo/progress.md", "add a feature")
+	if err != nil {
+		t.Fatalf("fitSystemPrompt() returned error: %v", err)
+	}
+	if len(truncated) > 0 {
+		t.Errorf("expected nothing to be truncated within the default budget, got %v", truncated)
+	}
+
+	// The repository map is truncated first, then the guidance files are only referenced
+	cc.WithPromptBudget(PromptBudget{MaxTokens: tokens - 1000, Truncate: []string{SectionRepoMap, SectionGuidance}})
+	prompt, truncated, fitted, err := cc.fitSystemPrompt(".gonzo/progress.md", "add a feature")
+	if err != nil {
+		t.Fatalf("fitSystemPrompt() returned error: %v", err)
+	}
+	if len(truncated) != 1 || truncated[0] != SectionRepoMap || fitted > tokens-1000 {
+		t.Errorf("expected only the repository map to be truncated to fit, got %v and %d tokens", truncated, fitted)
+	}
+	if !strings.Contains(prompt, truncatedRepoMap) || !strings.Contains(prompt, "Use tabs.") || len(prompt) >= len(full) {
+		t.Errorf("expected a truncated repository map and the inlined guidance, got %q", prompt)
+	}
+
+	cc.WithPromptBudget(PromptBudget{MaxTokens: 2000, Truncate: []string{SectionRepoMap, SectionGuidance}})
+	prompt, truncated, _, err = cc.fitSystemPrompt(".gonzo/progress.md", "add a feature")
+	if err != nil {
+		t.Fatalf("fitSystemPrompt() returned error: %v", err)
+	}
+	if len(truncated) != 2 || strings.Contains(prompt, "Use tabs.") || !strings.Contains(prompt, "- Read `CLAUDE.md`") {
+		t.Errorf("expected the guidance to be referenced once the repository map is truncated, got %v", truncated)
+	}
+
+	cc.WithPromptBudget(PromptBudget{MaxTokens: 1000, Truncate: []string{"history"}})
+	if _, _, _, err := cc.fitSystemPrompt(".gonzo/progress.md", "add a feature"); err == nil {
+		t.Error("expected an error for an unknown section")
+	}
+}
+
+func TestLogPromptBudget(t *testing.T) {
+	cc := New().WithPromptBudget(PromptBudget{MaxTokens: 1000})
+	tests := map[int]string{
+		500:  "",
+		900:  "approaching its budget of 1000",
+		1200: "over its budget of 1000",
+	}
+	for tokens, want := range tests {
+		out := captureStdout(t, func() { cc.logPromptBudget(nil, tokens) })
+		if want == "" && out != "" || !strings.Contains(out, want) {
+			t.Errorf("expected %q for %d tokens, got %q", want, tokens, out)
+		}
+	}
+}
+
+// captureStdout returns what f prints to the standard output.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	f()
+	os.Stdout = stdout
+	Swallow(w.Close())
+
+	var b bytes.Buffer
+	_, _ = io.Copy(&b, r)
+	return b.String()
+}
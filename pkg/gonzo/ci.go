@@ -0,0 +1,126 @@
+package gonzo
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// Supported CI integrations.
+const (
+	CIAuto   = "auto"
+	CINone   = "none"
+	CIGitHub = "github"
+)
+
+// ResolveCI returns the CI integration to use: name itself, or the one detected from the
+// environment when name is empty or CIAuto. An empty result means no CI integration.
+func ResolveCI(name string) string {
+	switch name {
+	case "", CIAuto:
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			return CIGitHub
+		}
+		return ""
+	case CINone:
+		return ""
+	}
+	return name
+}
+
+// ciSummaryData holds the values available to the CI job summary template.
+type ciSummaryData struct {
+	Run           *RunState
+	MaxIterations int
+	Commits       []string
+}
+
+// annotationOutputLines bounds the lines of output quoted in the annotation of a failed
+// verification command.
+const annotationOutputLines = 20
+
+// reportCI publishes the outcome of the run to the CI system: a job summary, step outputs, an
+// annotation when the run did not complete and one per verification command that failed last.
+func (cc *ClaudeConfig) reportCI(ctx context.Context, dir string, run *RunState) {
+	if cc.ci != CIGitHub {
+		return
+	}
+
+	var commits []string
+	if run.StartSHA != "" {
+		commits, _ = commitSubjects(ctx, dir, run.StartSHA)
+	}
+
+	summary, err := renderCISummary(cc.promptSet(), ciSummaryData{Run: run, MaxIterations: cc.maxIterations, Commits: commits})
+	if err != nil {
+		cc.logWarn("Failed to render job summary: %v", err)
+	} else if err := appendToEnvFile("GITHUB_STEP_SUMMARY", summary); err != nil {
+		cc.logWarn("Failed to write job summary: %v", err)
+	}
+
+	outputs := fmt.Sprintf("run-id=%s\nstatus=%s\nbranch=%s\npr-url=%s\niterations=%d\n",
+		run.ID, run.Status, run.Branch, run.PRURL, run.Iterations)
+	if err := appendToEnvFile("GITHUB_OUTPUT", outputs); err != nil {
+		cc.logWarn("Failed to set step outputs: %v", err)
+	}
+
+	switch run.Status {
+	case RunStatusFailed:
+		fmt.Fprintf(cc.logWriter(), "::error title=gonzo::Run %s failed at iteration %d\n", run.ID, run.Iterations)
+	case RunStatusIncomplete:
+		fmt.Fprintf(cc.logWriter(), "::warning title=gonzo::Run %s stopped after %d iteration(s) without completing the task\n", run.ID, run.Iterations)
+	}
+	for _, result := range cc.verification {
+		if result.Passed {
+			continue
+		}
+		message := "Verification failed: " + result.Command
+		if output := strings.TrimSpace(result.Output); output != "" {
+			if lines := strings.Split(output, "\n"); len(lines) > annotationOutputLines {
+				output = "...\n" + strings.Join(lines[len(lines)-annotationOutputLines:], "\n")
+			}
+			message += "\n" + output
+		}
+		fmt.Fprintf(cc.logWriter(), "::error title=gonzo verification::%s\n", escapeWorkflowData(message))
+	}
+}
+
+// escapeWorkflowData escapes the message of a GitHub Actions workflow command, which ends at the
+// first line break.
+func escapeWorkflowData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func renderCISummary(prompts fs.FS, data ciSummaryData) (string, error) {
+	t, err := template.ParseFS(prompts, "ci_summary.tmpl")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse job summary template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to execute job summary template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// appendToEnvFile appends content to the file named by the environment variable,
+// which is how GitHub Actions collects job summaries and step outputs.
+func appendToEnvFile(env string, content string) error {
+	path := os.Getenv(env)
+	if path == "" {
+		return fmt.Errorf("%s is not set", env)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() { Swallow(f.Close()) }()
+
+	_, err = f.WriteString(content)
+	return err
+}
@@ -0,0 +1,116 @@
+package gonzo
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveCI(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	if got := ResolveCI(CIAuto); got != CIGitHub {
+		t.Errorf("expected GitHub Actions to be detected, got %q", got)
+	}
+	if got := ResolveCI(CINone); got != "" {
+		t.Errorf("expected CI to be disabled, got %q", got)
+	}
+
+	t.Setenv("GITHUB_ACTIONS", "")
+	if got := ResolveCI(""); got != "" {
+		t.Errorf("expected no CI outside GitHub Actions, got %q", got)
+	}
+	if got := ResolveCI(CIGitHub); got != CIGitHub {
+		t.Errorf("expected the explicit CI to be kept, got %q", got)
+	}
+}
+
+func TestReportCI(t *testing.T) {
+	dir := t.TempDir()
+	summaryFile := filepath.Join(dir, "summary.md")
+	outputFile := filepath.Join(dir, "output")
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryFile)
+	t.Setenv("GITHUB_OUTPUT", outputFile)
+
+	run := &RunState{
+		ID:         "run-1",
+		Feature:    "add a login button",
+		Status:     RunStatusIncomplete,
+		Iterations: 3,
+		Branch:     "add-login",
+		PRURL:      "https://github.com/o/r/pull/1",
+	}
+	cc := New().WithCI(CIGitHub).WithMaxIterations(3)
+
+	// Capture the workflow commands printed to stdout
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cc.reportCI(context.Background(), dir, run)
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+	var stdout bytes.Buffer
+	_, _ = io.Copy(&stdout, r)
+
+	summary, err := os.ReadFile(summaryFile)
+	if err != nil {
+		t.Fatalf("expected a job summary: %v", err)
+	}
+	for _, want := range []string{"run `run-1`", "| Status | incomplete |", "| Iterations | 3 of 3 |", "`add-login`", "add a login button"} {
+		if !strings.Contains(string(summary), want) {
+			t.Errorf("expected summary to contain %q, got %q", want, summary)
+		}
+	}
+
+	outputs, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("expected step outputs: %v", err)
+	}
+	for _, want := range []string{"branch=add-login\n", "pr-url=https://github.com/o/r/pull/1\n", "iterations=3\n"} {
+		if !strings.Contains(string(outputs), want) {
+			t.Errorf("expected outputs to contain %q, got %q", want, outputs)
+		}
+	}
+
+	if !strings.HasPrefix(stdout.String(), "::warning title=gonzo::") {
+		t.Errorf("expected a warning annotation, got %q", stdout.String())
+	}
+}
+
+func TestReportCI_VerificationAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GITHUB_STEP_SUMMARY", filepath.Join(dir, "summary.md"))
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(dir, "output"))
+
+	run := &RunState{ID: "run-1", Feature: "add a login button", Status: RunStatusIncomplete, Iterations: 3}
+	cc := New().WithCI(CIGitHub).WithMaxIterations(3)
+	cc.verification = []VerificationResult{
+		{Command: "go vet ./...", Passed: true},
+		{Command: "go test ./...", Output: "--- FAIL: TestLogin\r\n100% broken\n", Passed: false},
+	}
+
+	// Capture the workflow commands printed to stdout
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cc.reportCI(context.Background(), dir, run)
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+	var stdout bytes.Buffer
+	_, _ = io.Copy(&stdout, r)
+
+	want := "::error title=gonzo verification::Verification failed: go test ./...%0A--- FAIL: TestLogin%0D%0A100%25 broken\n"
+	if !strings.Contains(stdout.String(), want) {
+		t.Errorf("expected an annotation of the failed command %q, got %q", want, stdout.String())
+	}
+	if strings.Contains(stdout.String(), "go vet") {
+		t.Errorf("expected no annotation of the passed command, got %q", stdout.String())
+	}
+}
@@ -1,15 +1,21 @@
 package gonzo
 
 import (
+	"bytes"
 	"context"
 	"embed"
 	"errors"
 	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
-	"text/template"
 	"time"
 )
 
@@ -23,12 +29,27 @@ const ClaudeOpus = "claude-opus-4-5"
 
 const DefaultOptClaudeModel = ClaudeOpus
 const DefaultOptQuiet = false
+const DefaultVerbosity = 1
 const DefaultMaxIterations = 10
 const DefaultNoBranch = false
 const DefaultNoNewTests = false
 const DefaultPR = false
 const DefaultCommitAuthor = "Gonzo <gonzo@barilla.you>"
 const DefaultCompletionSignal = "<promise>COMPLETE</promise>"
+const DefaultConventionalCommits = true
+const DefaultWrapUpIterations = 1
+const DefaultNoRepoMap = false
+const DefaultTranscripts = false
+const DefaultNoColor = false
+
+// Session modes of the iterations of a run.
+const (
+	// SessionFresh starts every iteration in a new Claude session, carrying over only the
+	// progress log and the repository.
+	SessionFresh = "fresh"
+	// SessionContinue resumes the Claude session of the previous iteration, sharing its context.
+	SessionContinue = "continue"
+)
 
 //go:embed prompts
 var promptLib embed.FS
@@ -38,188 +59,1800 @@ type Runner interface {
 	Generate(ctx context.Context, feature string) (string, error)
 }
 
+// ClaudeConfig is the configuration of the runs of gonzo, built by New. Its runs keep their state
+// in a copy of it, so that one ClaudeConfig can carry out several Generate, Plan and Estimate
+// calls at once, as long as it is not configured further meanwhile.
 type ClaudeConfig struct {
+	// dir is the repository gonzo works on, the current directory when empty
+	dir              string
 	model            string
 	quiet            bool
+	verbosity        int
 	maxIterations    int
 	noBranch         bool
+	branchRunID      bool
 	noNewTests       bool
 	pr               bool
+	currentPR        bool // allows pr with noBranch, pushing to the pull request of the current branch
 	commitAuthor     string
 	completionSignal string
+	// completion names the completion detector, with the pattern of CompletionRegex, unless
+	// detector is set
+	completion        string
+	completionPattern string
+	detector          CompletionDetector
+
+	conventionalCommits bool
+	commitTemplate      string
+	wrapUpIterations    int
+	prOptions           PROptions
+	settings            map[string]interface{}
+	issue               *forge.Issue
+	forge               string
+	ci                  string
+	iterationTimeout    time.Duration
+	heartbeat           time.Duration
+	sessionMode         string
+	maxDuration         time.Duration
+	retries             int
+	verifyCommands      []string
+	hooks               Hooks
+	notifications       Notifications
+	permissions         Permissions
+	mcpConfig           []string
+	agents              []string
+	guards              Guards
+	env                 Env
+	claudeArgs          []string
+	rateLimitWait       time.Duration
+	tokenLimits         TokenLimits
+
+	// prompts holds the local prompt templates looked up before the embedded ones
+	prompts fs.FS
+	// promptFS holds the prompt templates used in place of the embedded ones
+	promptFS fs.FS
+	// writeScope are the globs of the files Claude may change, any file when empty
+	writeScope []string
+	vars       map[string]string
+	playbook   string
+	language   string
+	// output is the format of the output of the run, OutputText or OutputJSON
+	output   string
+	guidance GuidanceOptions
+	// noRepoMap leaves the repository map out of the system prompt, and repoMap is the map of
+	// the run in progress
+	noRepoMap    bool
+	repoMap      string
+	promptBudget PromptBudget
+
+	// claudeEnvVars are added to the environment of the Claude CLI, once claudeEnvResolved
+	claudeEnvVars     []string
+	claudeEnvResolved bool
+
+	// transcripts records the transcript of each iteration, with secrets redacted from the
+	// values in secrets, read once
+	transcripts     bool
+	secrets         []string
+	secretsResolved bool
+
+	// runID is the ID of the run in progress, if any, recorded in its structured logs
+	runID string
+	// dirtyAtStart are the paths with uncommitted changes when the run in progress started, left
+	// out of the commit of its leftovers, or nil when they could not be listed
+	dirtyAtStart map[string]bool
+	// sessionID is the Claude CLI session of the last call, resumed by the next in SessionContinue mode
+	sessionID string
+	// guard checks the iteration in progress against the guards, when set
+	guard *iterationGuard
+	// cli is the Claude CLI in use, once checkClaudeCLI told its version
+	cli *claudeCLI
+	// subagents are the agents of the run in progress, read from agents
+	subagents []Agent
+	// progress is the store of the progress log, the file of the repository when nil
+	progress ProgressStore
+	// proxy decides the permission prompts of the run in progress in PermissionModeProxy
+	proxy *permissionProxy
+	// runLog is the log of the run in progress, if any, and logFile its copy in LogsDir
+	runLog  *os.File
+	logFile *os.File
+	logging LogOptions
+	// out is where the progress of the run is printed instead of the console, and log the logger
+	// its messages are sent to instead, when set
+	out io.Writer
+	log *slog.Logger
+	// status is the status line of the run in progress, when shown
+	status *statusLine
+	// noColor disables the colors of the console, otherwise used on terminals
+	noColor bool
+	// junitReport is the path of the JUnit report written at the end of the run, if any, and
+	// verification the results of the last verification of the run
+	junitReport  string
+	verification []VerificationResult
+
+	events   func(Event)
+	listener Events
+	control  *Control
 }
 
+// Option configures a ClaudeConfig, passed to New.
 type Option func(*ClaudeConfig)
 
-func New() *ClaudeConfig {
-	return &ClaudeConfig{
+// New returns the configuration of runs with the defaults of gonzo, and the options applied in
+// order, e.g. New(WithModel(ClaudeSonnet), WithMaxIterations(5)).
+func New(opts ...Option) *ClaudeConfig {
+	cc := &ClaudeConfig{
 		model:            DefaultOptClaudeModel,
 		quiet:            DefaultOptQuiet,
+		verbosity:        DefaultVerbosity,
 		maxIterations:    DefaultMaxIterations,
 		noBranch:         DefaultNoBranch,
 		noNewTests:       DefaultNoNewTests,
 		pr:               DefaultPR,
 		commitAuthor:     DefaultCommitAuthor,
 		completionSignal: DefaultCompletionSignal,
+		sessionMode:      SessionFresh,
+		rateLimitWait:    DefaultRateLimitWait,
+
+		conventionalCommits: DefaultConventionalCommits,
+		wrapUpIterations:    DefaultWrapUpIterations,
+		prOptions:           PROptions{CloseKeyword: DefaultCloseKeyword},
+		guidance:            GuidanceOptions{Mode: GuidanceOff},
+		noRepoMap:           DefaultNoRepoMap,
+		promptBudget:        PromptBudget{Truncate: []string{SectionRepoMap, SectionGuidance}},
+		notifications:       Notifications{Events: DefaultNotifyEvents},
+		permissions:         Permissions{Mode: DefaultPermissionMode, AllowedTools: slices.Clone(DefaultAllowedTools)},
+	}
+	cc.apply(opts...)
+	return cc
+}
+
+// apply applies the options to cc in order.
+func (cc *ClaudeConfig) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(cc)
+	}
+}
+
+// WithWorkingDir works on the repository in dir instead of the current directory, so that one
+// process can run loops on several repositories: the progress log, run state and artifacts are
+// kept there, and the Claude CLI, hooks and verification commands run there.
+func WithWorkingDir(dir string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.dir = dir
 	}
 }
 
+// WithDir is an alias of WithWorkingDir.
+func WithDir(dir string) Option {
+	return WithWorkingDir(dir)
+}
+
+// WithDir applies the WithWorkingDir option to cc.
+//
+// Deprecated: Pass WithWorkingDir to New instead.
+func (cc *ClaudeConfig) WithDir(dir string) *ClaudeConfig {
+	WithDir(dir)(cc)
+	return cc
+}
+
+// workDir returns the directory of the repository gonzo works on.
+func (cc *ClaudeConfig) workDir() (string, error) {
+	if cc.dir != "" {
+		return cc.dir, nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	return dir, nil
+}
+
+// WithModel selects the Claude model of the iterations, e.g. ClaudeSonnet.
+func WithModel(model string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.model = model
+	}
+}
+
+// WithModel applies the WithModel option to cc.
+//
+// Deprecated: Pass WithModel to New instead.
 func (cc *ClaudeConfig) WithModel(model string) *ClaudeConfig {
-	cc.model = model
+	WithModel(model)(cc)
 	return cc
 }
 
+// WithQuiet prints nothing about the run on the console; its log files still record it.
+func WithQuiet(quiet bool) Option {
+	return func(cc *ClaudeConfig) {
+		cc.quiet = quiet
+	}
+}
+
+// WithQuiet applies the WithQuiet option to cc.
+//
+// Deprecated: Pass WithQuiet to New instead.
 func (cc *ClaudeConfig) WithQuiet(quiet bool) *ClaudeConfig {
-	cc.quiet = quiet
+	WithQuiet(quiet)(cc)
+	return cc
+}
+
+// WithVerbosity sets how much is printed about the run: 1 for the banners and summaries of the
+// iterations, VerbosityDebug to also print the rendered prompts, the Claude CLI commands and
+// their timing.
+func WithVerbosity(verbosity int) Option {
+	return func(cc *ClaudeConfig) {
+		cc.verbosity = verbosity
+	}
+}
+
+// WithVerbosity applies the WithVerbosity option to cc.
+//
+// Deprecated: Pass WithVerbosity to New instead.
+func (cc *ClaudeConfig) WithVerbosity(verbosity int) *ClaudeConfig {
+	WithVerbosity(verbosity)(cc)
 	return cc
 }
 
+// WithMaxIterations sets the number of iterations the run may take to complete the task.
+func WithMaxIterations(maxIterations int) Option {
+	return func(cc *ClaudeConfig) {
+		cc.maxIterations = maxIterations
+	}
+}
+
+// WithMaxIterations applies the WithMaxIterations option to cc.
+//
+// Deprecated: Pass WithMaxIterations to New instead.
 func (cc *ClaudeConfig) WithMaxIterations(maxIterations int) *ClaudeConfig {
-	cc.maxIterations = maxIterations
+	WithMaxIterations(maxIterations)(cc)
 	return cc
 }
 
+// WithNoBranch has Claude work on the current branch instead of creating one for the task.
+func WithNoBranch(noBranch bool) Option {
+	return func(cc *ClaudeConfig) {
+		cc.noBranch = noBranch
+	}
+}
+
+// WithNoBranch applies the WithNoBranch option to cc.
+//
+// Deprecated: Pass WithNoBranch to New instead.
 func (cc *ClaudeConfig) WithNoBranch(noBranch bool) *ClaudeConfig {
-	cc.noBranch = noBranch
+	WithNoBranch(noBranch)(cc)
+	return cc
+}
+
+// WithBranchRunID has Claude end the name of the branch it creates with the short ID of the run,
+// so branches of runs of the same task do not collide and tell which run made them.
+func WithBranchRunID(branchRunID bool) Option {
+	return func(cc *ClaudeConfig) {
+		cc.branchRunID = branchRunID
+	}
+}
+
+// WithBranchRunID applies the WithBranchRunID option to cc.
+//
+// Deprecated: Pass WithBranchRunID to New instead.
+func (cc *ClaudeConfig) WithBranchRunID(branchRunID bool) *ClaudeConfig {
+	WithBranchRunID(branchRunID)(cc)
 	return cc
 }
 
+// WithNoNewTests has Claude leave out new tests for the feature.
+func WithNoNewTests(noNewTests bool) Option {
+	return func(cc *ClaudeConfig) {
+		cc.noNewTests = noNewTests
+	}
+}
+
+// WithNoNewTests applies the WithNoNewTests option to cc.
+//
+// Deprecated: Pass WithNoNewTests to New instead.
 func (cc *ClaudeConfig) WithNoNewTests(noNewTests bool) *ClaudeConfig {
-	cc.noNewTests = noNewTests
+	WithNoNewTests(noNewTests)(cc)
 	return cc
 }
 
+// WithPR opens a pull request for the branch of the run, unless one exists.
+func WithPR(pr bool) Option {
+	return func(cc *ClaudeConfig) {
+		cc.pr = pr
+	}
+}
+
+// WithPR applies the WithPR option to cc.
+//
+// Deprecated: Pass WithPR to New instead.
 func (cc *ClaudeConfig) WithPR(pr bool) *ClaudeConfig {
-	cc.pr = pr
+	WithPR(pr)(cc)
 	return cc
 }
 
+// WithPROnCurrentBranch lets a run without a branch of its own push to the pull request of the
+// current branch, as addressing its review comments does. Otherwise WithPR needs the branch of the
+// run, and Validate refuses it along with WithNoBranch.
+func WithPROnCurrentBranch(onCurrentBranch bool) Option {
+	return func(cc *ClaudeConfig) {
+		cc.currentPR = onCurrentBranch
+	}
+}
+
+// WithCommitAuthor sets the author of the commits, as 'Name <email>'.
+func WithCommitAuthor(commitAuthor string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.commitAuthor = commitAuthor
+	}
+}
+
+// WithCommitAuthor applies the WithCommitAuthor option to cc.
+//
+// Deprecated: Pass WithCommitAuthor to New instead.
 func (cc *ClaudeConfig) WithCommitAuthor(commitAuthor string) *ClaudeConfig {
-	cc.commitAuthor = commitAuthor
+	WithCommitAuthor(commitAuthor)(cc)
+	return cc
+}
+
+// WithConventionalCommits toggles Conventional Commits formatting for commits made by gonzo itself.
+func WithConventionalCommits(conventionalCommits bool) Option {
+	return func(cc *ClaudeConfig) {
+		cc.conventionalCommits = conventionalCommits
+	}
+}
+
+// WithConventionalCommits applies the WithConventionalCommits option to cc.
+//
+// Deprecated: Pass WithConventionalCommits to New instead.
+func (cc *ClaudeConfig) WithConventionalCommits(conventionalCommits bool) *ClaudeConfig {
+	WithConventionalCommits(conventionalCommits)(cc)
+	return cc
+}
+
+// WithCommitTemplate sets the text/template used to render commit messages made by gonzo itself.
+// See CommitMessage for the available fields.
+func WithCommitTemplate(commitTemplate string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.commitTemplate = commitTemplate
+	}
+}
+
+// WithCommitTemplate applies the WithCommitTemplate option to cc.
+//
+// Deprecated: Pass WithCommitTemplate to New instead.
+func (cc *ClaudeConfig) WithCommitTemplate(commitTemplate string) *ClaudeConfig {
+	WithCommitTemplate(commitTemplate)(cc)
+	return cc
+}
+
+// WithWrapUpIterations reserves the final iterations of the budget for wrapping up
+// (docs, progress file, commit, PR) when the task has not completed by then.
+func WithWrapUpIterations(wrapUpIterations int) Option {
+	return func(cc *ClaudeConfig) {
+		cc.wrapUpIterations = wrapUpIterations
+	}
+}
+
+// WithWrapUpIterations applies the WithWrapUpIterations option to cc.
+//
+// Deprecated: Pass WithWrapUpIterations to New instead.
+func (cc *ClaudeConfig) WithWrapUpIterations(wrapUpIterations int) *ClaudeConfig {
+	WithWrapUpIterations(wrapUpIterations)(cc)
+	return cc
+}
+
+// WithPROptions sets the draft status, labels, reviewers and assignees of pull requests opened by gonzo.
+func WithPROptions(prOptions PROptions) Option {
+	return func(cc *ClaudeConfig) {
+		cc.prOptions = prOptions
+	}
+}
+
+// WithPROptions applies the WithPROptions option to cc.
+//
+// Deprecated: Pass WithPROptions to New instead.
+func (cc *ClaudeConfig) WithPROptions(prOptions PROptions) *ClaudeConfig {
+	WithPROptions(prOptions)(cc)
+	return cc
+}
+
+// WithSettings sets the resolved configuration recorded in the run manifest,
+// so `gonzo rerun` can replay the run with identical settings.
+func WithSettings(settings map[string]interface{}) Option {
+	return func(cc *ClaudeConfig) {
+		cc.settings = settings
+	}
+}
+
+// WithSettings applies the WithSettings option to cc.
+//
+// Deprecated: Pass WithSettings to New instead.
+func (cc *ClaudeConfig) WithSettings(settings map[string]interface{}) *ClaudeConfig {
+	WithSettings(settings)(cc)
+	return cc
+}
+
+// WithIssue links the run to the issue it implements: the pull request body
+// references it and the outcome of the run is posted back as an issue comment.
+func WithIssue(issue *forge.Issue) Option {
+	return func(cc *ClaudeConfig) {
+		cc.issue = issue
+	}
+}
+
+// WithIssue applies the WithIssue option to cc.
+//
+// Deprecated: Pass WithIssue to New instead.
+func (cc *ClaudeConfig) WithIssue(issue *forge.Issue) *ClaudeConfig {
+	WithIssue(issue)(cc)
+	return cc
+}
+
+// WithForge selects the forge pull requests are opened on (forge.GitHub or forge.GitLab).
+// An empty name or forge.Auto detects it from the origin remote URL.
+func WithForge(name string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.forge = name
+	}
+}
+
+// WithForge applies the WithForge option to cc.
+//
+// Deprecated: Pass WithForge to New instead.
+func (cc *ClaudeConfig) WithForge(name string) *ClaudeConfig {
+	WithForge(name)(cc)
+	return cc
+}
+
+// WithCI enables reporting to a CI system (CIGitHub). An empty name or CIAuto detects
+// it from the environment, CINone disables it.
+func WithCI(name string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.ci = ResolveCI(name)
+	}
+}
+
+// WithCI applies the WithCI option to cc.
+//
+// Deprecated: Pass WithCI to New instead.
+func (cc *ClaudeConfig) WithCI(name string) *ClaudeConfig {
+	WithCI(name)(cc)
+	return cc
+}
+
+// WithCompletionSignal sets the marker the agent replies with once the task is complete.
+func WithCompletionSignal(completionSignal string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.completionSignal = completionSignal
+	}
+}
+
+// WithCompletionSignal applies the WithCompletionSignal option to cc.
+//
+// Deprecated: Pass WithCompletionSignal to New instead.
+func (cc *ClaudeConfig) WithCompletionSignal(completionSignal string) *ClaudeConfig {
+	WithCompletionSignal(completionSignal)(cc)
+	return cc
+}
+
+// WithHeartbeat prints a line at the given interval while a Claude CLI call runs, for CI systems
+// that kill jobs printing nothing for a while. Zero disables it.
+func WithHeartbeat(heartbeat time.Duration) Option {
+	return func(cc *ClaudeConfig) {
+		cc.heartbeat = heartbeat
+	}
+}
+
+// WithHeartbeat applies the WithHeartbeat option to cc.
+//
+// Deprecated: Pass WithHeartbeat to New instead.
+func (cc *ClaudeConfig) WithHeartbeat(heartbeat time.Duration) *ClaudeConfig {
+	WithHeartbeat(heartbeat)(cc)
+	return cc
+}
+
+// WithIterationTimeout limits how long a single Claude CLI call may take. Zero disables the limit.
+func WithIterationTimeout(iterationTimeout time.Duration) Option {
+	return func(cc *ClaudeConfig) {
+		cc.iterationTimeout = iterationTimeout
+	}
+}
+
+// WithIterationTimeout applies the WithIterationTimeout option to cc.
+//
+// Deprecated: Pass WithIterationTimeout to New instead.
+func (cc *ClaudeConfig) WithIterationTimeout(iterationTimeout time.Duration) *ClaudeConfig {
+	WithIterationTimeout(iterationTimeout)(cc)
+	return cc
+}
+
+// WithMaxDuration limits how long the whole run may take: once it is over, the run stops as
+// incomplete, like when the iteration budget is used up. Zero disables the limit.
+func WithMaxDuration(maxDuration time.Duration) Option {
+	return func(cc *ClaudeConfig) {
+		cc.maxDuration = maxDuration
+	}
+}
+
+// WithMaxDuration applies the WithMaxDuration option to cc.
+//
+// Deprecated: Pass WithMaxDuration to New instead.
+func (cc *ClaudeConfig) WithMaxDuration(maxDuration time.Duration) *ClaudeConfig {
+	WithMaxDuration(maxDuration)(cc)
+	return cc
+}
+
+// WithRetries sets how many times a failed or timed out Claude CLI call is retried before the run fails.
+func WithRetries(retries int) Option {
+	return func(cc *ClaudeConfig) {
+		cc.retries = retries
+	}
+}
+
+// WithRetries applies the WithRetries option to cc.
+//
+// Deprecated: Pass WithRetries to New instead.
+func (cc *ClaudeConfig) WithRetries(retries int) *ClaudeConfig {
+	WithRetries(retries)(cc)
+	return cc
+}
+
+// WithVerifyCommands sets shell commands that must pass once the agent reports completion.
+// When one fails, its output is handed back to the agent in the next iteration.
+func WithVerifyCommands(verifyCommands []string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.verifyCommands = verifyCommands
+	}
+}
+
+// WithVerifyCommands applies the WithVerifyCommands option to cc.
+//
+// Deprecated: Pass WithVerifyCommands to New instead.
+func (cc *ClaudeConfig) WithVerifyCommands(verifyCommands []string) *ClaudeConfig {
+	WithVerifyCommands(verifyCommands)(cc)
+	return cc
+}
+
+// WithHooks sets the shell commands run before the run, after every iteration and after the run.
+func WithHooks(hooks Hooks) Option {
+	return func(cc *ClaudeConfig) {
+		cc.hooks = hooks
+	}
+}
+
+// WithHooks applies the WithHooks option to cc.
+//
+// Deprecated: Pass WithHooks to New instead.
+func (cc *ClaudeConfig) WithHooks(hooks Hooks) *ClaudeConfig {
+	WithHooks(hooks)(cc)
+	return cc
+}
+
+// WithPrompts sets the file system local prompt templates are looked up in, by name, before
+// the embedded ones. By default they are looked up in the PromptsPath of the working directory.
+func WithPrompts(prompts fs.FS) Option {
+	return func(cc *ClaudeConfig) {
+		cc.prompts = prompts
+	}
+}
+
+// WithPrompts applies the WithPrompts option to cc.
+//
+// Deprecated: Pass WithPrompts to New instead.
+func (cc *ClaudeConfig) WithPrompts(prompts fs.FS) *ClaudeConfig {
+	WithPrompts(prompts)(cc)
+	return cc
+}
+
+// WithPromptFS replaces the embedded prompt templates with the ones in prompts, by name at its
+// root, e.g. "system_prompt.tmpl". Local prompt templates are still looked up first.
+func WithPromptFS(prompts fs.FS) Option {
+	return func(cc *ClaudeConfig) {
+		cc.promptFS = prompts
+	}
+}
+
+// WithVars sets the variables available to the system prompt and progress templates as .Vars.
+func WithVars(vars map[string]string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.vars = vars
+	}
+}
+
+// WithVars applies the WithVars option to cc.
+//
+// Deprecated: Pass WithVars to New instead.
+func (cc *ClaudeConfig) WithVars(vars map[string]string) *ClaudeConfig {
+	WithVars(vars)(cc)
+	return cc
+}
+
+// WithLogging sets the format and level of the log of the run.
+func WithLogging(logging LogOptions) Option {
+	return func(cc *ClaudeConfig) {
+		cc.logging = logging
+	}
+}
+
+// WithLogging applies the WithLogging option to cc.
+//
+// Deprecated: Pass WithLogging to New instead.
+func (cc *ClaudeConfig) WithLogging(logging LogOptions) *ClaudeConfig {
+	WithLogging(logging)(cc)
+	return cc
+}
+
+// WithOutputFormat sets the format of the output of the run. With OutputJSON, the progress of
+// the run is printed to stderr so that stdout is left to the summary of the run.
+func WithOutputFormat(output string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.output = output
+	}
+}
+
+// WithOutput applies the WithOutputFormat option to cc.
+//
+// Deprecated: Pass WithOutputFormat to New instead.
+func (cc *ClaudeConfig) WithOutput(output string) *ClaudeConfig {
+	WithOutputFormat(output)(cc)
+	return cc
+}
+
+// WithLanguage selects the language whose toolchain guidance is added to the system prompt, by
+// name: LanguageAuto detects it from the repository, LanguageNone leaves it out.
+func WithLanguage(language string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.language = language
+	}
+}
+
+// WithLanguage applies the WithLanguage option to cc.
+//
+// Deprecated: Pass WithLanguage to New instead.
+func (cc *ClaudeConfig) WithLanguage(language string) *ClaudeConfig {
+	WithLanguage(language)(cc)
+	return cc
+}
+
+// WithPlaybook selects the playbook whose guidance is added to the system prompt, by name.
+// An empty name selects none.
+func WithPlaybook(playbook string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.playbook = playbook
+	}
+}
+
+// WithPlaybook applies the WithPlaybook option to cc.
+//
+// Deprecated: Pass WithPlaybook to New instead.
+func (cc *ClaudeConfig) WithPlaybook(playbook string) *ClaudeConfig {
+	WithPlaybook(playbook)(cc)
+	return cc
+}
+
+// WithSessionMode selects whether each iteration starts a new Claude session, SessionFresh, or
+// resumes the session of the previous one, SessionContinue.
+func WithSessionMode(sessionMode string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.sessionMode = sessionMode
+	}
+}
+
+// WithSessionMode applies the WithSessionMode option to cc.
+//
+// Deprecated: Pass WithSessionMode to New instead.
+func (cc *ClaudeConfig) WithSessionMode(sessionMode string) *ClaudeConfig {
+	WithSessionMode(sessionMode)(cc)
+	return cc
+}
+
+// WithNotifications posts the start and outcome of runs to a chat webhook.
+func WithNotifications(notifications Notifications) Option {
+	return func(cc *ClaudeConfig) {
+		cc.notifications = notifications
+	}
+}
+
+// WithNotifications applies the WithNotifications option to cc.
+//
+// Deprecated: Pass WithNotifications to New instead.
+func (cc *ClaudeConfig) WithNotifications(notifications Notifications) *ClaudeConfig {
+	WithNotifications(notifications)(cc)
+	return cc
+}
+
+// WithPermissions selects the tools Claude may use in the iterations of a run.
+func WithPermissions(permissions Permissions) Option {
+	return func(cc *ClaudeConfig) {
+		cc.permissions = permissions
+	}
+}
+
+// WithPermissions applies the WithPermissions option to cc.
+//
+// Deprecated: Pass WithPermissions to New instead.
+func (cc *ClaudeConfig) WithPermissions(permissions Permissions) *ClaudeConfig {
+	WithPermissions(permissions)(cc)
+	return cc
+}
+
+// WithClaudeArgs sets extra arguments passed to every Claude CLI call, after the ones gonzo
+// passes, e.g. flags of newer CLIs gonzo does not support yet.
+func WithClaudeArgs(claudeArgs []string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.claudeArgs = claudeArgs
+	}
+}
+
+// WithClaudeArgs applies the WithClaudeArgs option to cc.
+//
+// Deprecated: Pass WithClaudeArgs to New instead.
+func (cc *ClaudeConfig) WithClaudeArgs(claudeArgs []string) *ClaudeConfig {
+	WithClaudeArgs(claudeArgs)(cc)
+	return cc
+}
+
+// WithGuidance selects the project guidance files added to the system prompt, and whether
+// their content or only a reference to them is added.
+func WithGuidance(guidance GuidanceOptions) Option {
+	return func(cc *ClaudeConfig) {
+		cc.guidance = guidance
+	}
+}
+
+// WithGuidance applies the WithGuidance option to cc.
+//
+// Deprecated: Pass WithGuidance to New instead.
+func (cc *ClaudeConfig) WithGuidance(guidance GuidanceOptions) *ClaudeConfig {
+	WithGuidance(guidance)(cc)
+	return cc
+}
+
+// WithNoColor disables the colors of the messages printed about the run, which are otherwise
+// used on terminals unless the NO_COLOR environment variable is set.
+func WithNoColor(noColor bool) Option {
+	return func(cc *ClaudeConfig) {
+		cc.noColor = noColor
+	}
+}
+
+// WithNoColor applies the WithNoColor option to cc.
+//
+// Deprecated: Pass WithNoColor to New instead.
+func (cc *ClaudeConfig) WithNoColor(noColor bool) *ClaudeConfig {
+	WithNoColor(noColor)(cc)
+	return cc
+}
+
+// WithJUnitReport writes a JUnit XML report of the outcome of each run and of its last
+// verification to path, relative to the repository unless absolute.
+func WithJUnitReport(path string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.junitReport = path
+	}
+}
+
+// WithJUnitReport applies the WithJUnitReport option to cc.
+//
+// Deprecated: Pass WithJUnitReport to New instead.
+func (cc *ClaudeConfig) WithJUnitReport(path string) *ClaudeConfig {
+	WithJUnitReport(path)(cc)
+	return cc
+}
+
+// WithTranscripts records the exact system prompt, prompt and output of each iteration in
+// .gonzo/transcripts/<run-id>/, with secrets redacted.
+func WithTranscripts(transcripts bool) Option {
+	return func(cc *ClaudeConfig) {
+		cc.transcripts = transcripts
+	}
+}
+
+// WithTranscripts applies the WithTranscripts option to cc.
+//
+// Deprecated: Pass WithTranscripts to New instead.
+func (cc *ClaudeConfig) WithTranscripts(transcripts bool) *ClaudeConfig {
+	WithTranscripts(transcripts)(cc)
+	return cc
+}
+
+// WithNoRepoMap leaves out of the system prompt the map of the repository otherwise generated
+// before the first iteration.
+func WithNoRepoMap(noRepoMap bool) Option {
+	return func(cc *ClaudeConfig) {
+		cc.noRepoMap = noRepoMap
+	}
+}
+
+// WithNoRepoMap applies the WithNoRepoMap option to cc.
+//
+// Deprecated: Pass WithNoRepoMap to New instead.
+func (cc *ClaudeConfig) WithNoRepoMap(noRepoMap bool) *ClaudeConfig {
+	WithNoRepoMap(noRepoMap)(cc)
+	return cc
+}
+
+// WithPromptBudget bounds the size of the system prompt and feature, and selects the sections
+// of the project context truncated to fit.
+func WithPromptBudget(promptBudget PromptBudget) Option {
+	return func(cc *ClaudeConfig) {
+		cc.promptBudget = promptBudget
+	}
+}
+
+// WithPromptBudget applies the WithPromptBudget option to cc.
+//
+// Deprecated: Pass WithPromptBudget to New instead.
+func (cc *ClaudeConfig) WithPromptBudget(promptBudget PromptBudget) *ClaudeConfig {
+	WithPromptBudget(promptBudget)(cc)
+	return cc
+}
+
+// WithConfig applies a resolved configuration, as returned by config.Load, in one option.
+// The commit template is used as is: reading it from a file is left to the caller.
+func WithConfig(c *config.Config) Option {
+	return func(cc *ClaudeConfig) {
+		cc.apply(
+			WithModel(c.Model), WithQuiet(c.Quiet), WithVerbosity(c.Verbose), WithMaxIterations(c.MaxIterations), WithNoBranch(c.NoBranch), WithBranchRunID(c.BranchRunID),
+			WithNoNewTests(c.NoNewTests), WithPR(c.PR), WithCommitAuthor(c.CommitAuthor),
+			WithConventionalCommits(c.ConventionalCommits), WithCommitTemplate(c.CommitTemplate), WithWrapUpIterations(c.WrapUpIterations),
+			WithPROptions(PROptions{
+				Draft:     c.PRDraft,
+				Labels:    c.PRLabels,
+				Reviewers: c.PRReviewers,
+				Assignees: c.PRAssignees,
+				Comment:   c.PRComment,
+
+				CloseKeyword:     c.PRCloseKeyword,
+				TitleIssuePrefix: c.PRTitleIssuePrefix,
+			}),
+			WithForge(c.Forge), WithCI(c.CI),
+			WithCompletionSignal(c.CompletionSignal), WithIterationTimeout(c.IterationTimeout), WithMaxDuration(c.MaxDuration), WithHeartbeat(c.Heartbeat), WithSessionMode(c.SessionMode),
+			WithRetries(c.Retries), WithVerifyCommands(c.Verify), WithVars(c.Vars), WithPlaybook(c.Playbook), WithLanguage(c.Language), WithOutputFormat(c.Output), WithNoRepoMap(c.NoRepoMap), WithTranscripts(c.Transcripts), WithNoColor(c.NoColor), WithJUnitReport(c.JUnit),
+			WithHooks(Hooks{
+				BeforeRun:      c.Hooks.BeforeRun,
+				AfterIteration: c.Hooks.AfterIteration,
+				AfterRun:       c.Hooks.AfterRun,
+			}),
+			WithGuidance(GuidanceOptions{
+				Mode:    c.Guidance.Mode,
+				Files:   c.Guidance.Files,
+				MaxSize: c.Guidance.MaxSize,
+			}),
+			WithPromptBudget(PromptBudget{
+				MaxTokens: c.Prompt.MaxTokens,
+				Truncate:  c.Prompt.Truncate,
+			}),
+			WithLogging(LogOptions{
+				Format: c.LogFormat,
+				Level:  c.LogLevel,
+			}),
+			WithNotifications(Notifications{
+				WebhookURL: c.Notifications.WebhookURL,
+				Email: EmailOptions{
+					To:       c.Notifications.Email.To,
+					From:     c.Notifications.Email.From,
+					SMTP:     c.Notifications.Email.SMTP,
+					Username: c.Notifications.Email.Username,
+				},
+				Events: c.Notifications.Events,
+			}),
+			WithPermissions(Permissions{
+				Mode:         c.PermissionMode,
+				AllowedTools: c.AllowedTools,
+				Policy: PermissionPolicy{
+					Allow: c.PermissionPolicy.Allow,
+					Ask:   c.PermissionPolicy.Ask,
+					Deny:  c.PermissionPolicy.Deny,
+				},
+			}),
+			WithMCPConfig(c.MCPConfig),
+			WithAgents(c.Agents),
+			WithGuards(Guards{
+				ProtectedPaths: c.ProtectedPaths,
+				MaxCost:        c.MaxCost,
+			}),
+			WithWriteScope(c.WriteScope),
+			WithEnv(Env{
+				Allow: c.Env.Allow,
+				Deny:  c.Env.Deny,
+				Set:   c.Env.Set,
+			}),
+			WithClaudeArgs(c.ClaudeArgs),
+			WithCompletion(c.Completion, c.CompletionPattern),
+			WithRateLimitWait(c.RateLimitWait),
+			WithTokenLimits(TokenLimits{
+				MaxOutputTokens: c.MaxOutputTokens,
+				ThinkingBudget:  c.ThinkingBudget,
+			}),
+		)
+	}
+}
+
+// WithConfig applies the WithConfig option to cc.
+//
+// Deprecated: Pass WithConfig to New instead.
+func (cc *ClaudeConfig) WithConfig(c *config.Config) *ClaudeConfig {
+	WithConfig(c)(cc)
 	return cc
 }
 
 // Generate sends a prompt to the Claude API and returns the generated response.
 func (cc *ClaudeConfig) Generate(ctx context.Context, feature string) (string, error) {
-	systemPromptTmpl, err := template.ParseFS(promptLib, "prompts/system_prompt.tmpl")
+	out, err := cc.session().generate(ctx, feature)
+	if err != nil && cc.listener != nil {
+		cc.listener.OnError(err)
+	}
+	return out, err
+}
+
+// session returns the copy of cc a run keeps its state in.
+func (cc *ClaudeConfig) session() *ClaudeConfig {
+	s := *cc
+	return &s
+}
+
+// generate runs the loop of Generate.
+func (cc *ClaudeConfig) generate(ctx context.Context, feature string) (string, error) {
+	dir, err := cc.workDir()
 	if err != nil {
-		return "", fmt.Errorf("failed to parse system prompt template: %w", err)
+		return "", err
+	}
+	progressFile := progressFilePath(dir)
+	if err := cc.Validate(); err != nil {
+		return "", withExitCode(ExitPreflight, err)
+	}
+	if err := cc.checkMCPConfig(dir); err != nil {
+		return "", withExitCode(ExitPreflight, err)
+	}
+	detector, err := cc.newCompletionDetector()
+	if err != nil {
+		return "", withExitCode(ExitPreflight, err)
+	}
+	if cc.subagents, err = cc.loadAgents(dir); err != nil {
+		return "", withExitCode(ExitPreflight, err)
 	}
+	if err := cc.checkClaudeCLI(ctx); err != nil {
+		return "", withExitCode(ExitPreflight, err)
+	}
+	// The ID of the run is known before it starts, for the branch name asked in the system prompt
+	cc.runID = NewRunID()
+	cc.sessionID = ""
 
-	var systemPromptBuf strings.Builder
-	err = systemPromptTmpl.Execute(&systemPromptBuf, struct {
-		Branch       bool
-		Tests        bool
-		PR           bool
-		CommitAuthor string
-	}{
-		Branch:       !cc.noBranch,   // Branch is enabled when noBranch is false
-		Tests:        !cc.noNewTests, // Tests is enabled when noNewTests is false
-		PR:           cc.pr,
-		CommitAuthor: cc.commitAuthor,
-	})
+	if cc.pr {
+		if err := cc.checkForgeAuth(ctx, dir); err != nil {
+			return "", withExitCode(ExitPreflight, err)
+		}
+	}
+
+	proxy, err := cc.startPermissionProxy(dir)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute system prompt template: %w", err)
+		return "", withExitCode(ExitPreflight, err)
+	}
+	if proxy != nil {
+		cc.proxy = proxy
+		defer func() {
+			cc.swallow(proxy.Close())
+			cc.proxy = nil
+		}()
+	}
+
+	cc.loadRepoMap(ctx, dir)
+	systemPrompt, truncated, promptTokens, err := cc.fitSystemPrompt(progressFile, feature)
+	if err != nil {
+		return "", withExitCode(ExitPreflight, err)
+	}
+
+	_, err = os.Stat(filepath.Join(dir, progressFile))
+	legacyProgress := err == nil && filepath.Base(progressFile) == LegacyProgressFile
+
+	err = cc.ensureProgressFileExists(ctx, dir)
+	if err != nil {
+		return "", withExitCode(ExitPreflight, fmt.Errorf("failed to ensure progress file exists: %w", err))
+	}
+
+	run, err := cc.startRun(ctx, dir, feature)
+	if err != nil {
+		return "", fmt.Errorf("failed to record run state: %w", err)
+	}
+
+	maxIterations := cc.maxIterations
+	cc.emit(Event{Type: EventRunStart, RunID: run.ID, MaxIterations: maxIterations})
+
+	var deadline time.Time
+	if cc.maxDuration > 0 {
+		deadline = run.StartedAt.Add(cc.maxDuration)
 	}
-	systemPrompt := systemPromptBuf.String()
 
 	cc.logInfo("Starting Gonzo")
 	cc.logInfo("  Model: %s", cc.model)
-	cc.logInfo("  Max Iterations: %d", cc.maxIterations)
+	cc.logInfo("  Max Iterations: %d", maxIterations)
+	cc.logInfo("  Permission Mode: %s", cc.permissions.mode())
+	if cc.maxDuration > 0 {
+		cc.logInfo("  Max Duration: %s", cc.maxDuration)
+	}
+	if cc.guards.MaxCost > 0 {
+		cc.logInfo("  Max Cost: $%.2f", cc.guards.MaxCost)
+	}
+	if cc.tokenLimits.MaxOutputTokens > 0 {
+		cc.logInfo("  Max Output Tokens: %d", cc.tokenLimits.MaxOutputTokens)
+	}
+	if cc.tokenLimits.ThinkingBudget > 0 {
+		cc.logInfo("  Thinking Budget: %d tokens", cc.tokenLimits.ThinkingBudget)
+	}
+	if len(cc.guards.ProtectedPaths) > 0 {
+		cc.logInfo("  Protected Paths: %s", strings.Join(cc.guards.ProtectedPaths, ", "))
+	}
+	if len(cc.writeScope) > 0 {
+		cc.logInfo("  Write Scope: %s", strings.Join(cc.writeScope, ", "))
+	}
+	if len(cc.subagents) > 0 {
+		cc.logInfo("  Agents: %s", strings.Join(agentNames(cc.subagents), ", "))
+	}
+	if cc.detector == nil && cc.completion != "" && cc.completion != CompletionMarker {
+		cc.logInfo("  Completion: %s", cc.completion)
+	}
+	if cc.sessionMode == SessionContinue {
+		cc.logInfo("  Session: continued across iterations")
+	}
+	if reserved := cc.reservedWrapUpIterations(); reserved > 0 {
+		cc.logInfo("  Wrap-up Iterations: %d", reserved)
+	}
+	cc.logInfo("  Run ID: %s", run.ID)
+	cc.logDebug("System prompt:\n%s", systemPrompt)
+	cc.logPromptBudget(truncated, promptTokens)
+	if legacyProgress {
+		cc.logInfo("  Note: %s uses the legacy progress format; run `gonzo migrate-state` to upgrade", progressFile)
+	}
 
-	err = cc.ensureProgressFileExists()
-	if err != nil {
-		return "", fmt.Errorf("failed to ensure progress file exists: %w", err)
+	if err := cc.runHooks(ctx, dir, run, "before-run", cc.hooks.BeforeRun, 0, RunStatusRunning); err != nil {
+		cc.finishRun(ctx, dir, run, RunStatusFailed)
+		return "", withExitCode(ExitPreflight, err)
 	}
+	cc.notify(ctx, dir, run, NotifyStart)
 
 	var out string
 
-	for i := 1; i <= cc.maxIterations; i++ {
-		cc.logInfo("===============================================================")
-		cc.logInfo("  Iteration %d of %d", i, cc.maxIterations)
-		cc.logInfo("===============================================================")
+	cc.startStatusLine()
+	workIterations := maxIterations - cc.reservedWrapUpIterations()
+	limit := fmt.Sprintf("max iterations %d", maxIterations)
+	// verifyFailure holds the output of the verification commands that failed in the last iteration
+	verifyFailure := ""
+	// outOfScope are the files outside the write scope changed, and reverted, in the last iteration
+	var outOfScope []string
+
+	for i := 1; i <= maxIterations; i++ {
+		if err := cc.control.waitIfPaused(ctx); err != nil {
+			cc.finishRun(ctx, dir, run, RunStatusFailed)
+			return "", err
+		}
+
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			limit = fmt.Sprintf("max duration %s", cc.maxDuration)
+			run.StopReason = StopReasonMaxDuration
+			break
+		}
+		if cc.guards.MaxCost > 0 && run.CostUSD >= cc.guards.MaxCost {
+			limit = fmt.Sprintf("max cost $%.2f", cc.guards.MaxCost)
+			run.StopReason = StopReasonMaxCost
+			break
+		}
+
+		iterationStart := time.Now()
+		cc.logIterationStart(i, maxIterations, i > workIterations, iterationStart)
+		cc.emit(Event{Type: EventIterationStart, RunID: run.ID, Iteration: i, MaxIterations: maxIterations})
+
+		prompt := feature
+		switch {
+		case i > workIterations:
+			prompt, err = cc.wrapUpPrompt(feature, progressFile, i-workIterations)
+		case verifyFailure != "":
+			prompt, err = cc.verifyFailedPrompt(feature, progressFile, verifyFailure)
+		}
+		if err == nil && len(outOfScope) > 0 {
+			var note string
+			if note, err = cc.writeScopePrompt(outOfScope); err == nil {
+				prompt = note + "\n\n" + prompt
+			}
+		}
+		if err != nil {
+			cc.finishRun(ctx, dir, run, RunStatusFailed)
+			return "", err
+		}
+		verifyFailure = ""
+		outOfScope = nil
 
+		cc.logDebug("Prompt:\n%s", prompt)
 		var outBytes []byte
 
-		outBytes, err = cc.callClaudeCLI(
+		run.Iterations = i
+		iterationSHA := ""
+		if run.StartSHA != "" {
+			var err error
+			iterationSHA, err = headSHA(ctx, dir)
+			cc.swallow(err)
+		}
+		// The files already changed before the iteration are out of the write scope's reach
+		scopeSHA, dirty := iterationSHA, map[string]bool(nil)
+		if len(cc.writeScope) > 0 && scopeSHA != "" {
+			var dirtyErr error
+			if dirty, dirtyErr = dirtyPaths(ctx, dir); dirtyErr != nil {
+				cc.logWarn("Failed to list the uncommitted changes, the write scope is not enforced this iteration: %v", dirtyErr)
+				scopeSHA = ""
+			}
+		}
+		stream := cc.iterationStream(i)
+		var usage Usage
+		stopHeartbeat := cc.startHeartbeat(i, iterationStart)
+		cc.guard = cc.newIterationGuard(run)
+		outBytes, usage, err = cc.callClaude(
 			ctx,
+			deadline,
 			systemPrompt,
-			feature)
+			prompt,
+			stream)
+		cc.guard = nil
+		stopHeartbeat()
+		if stream != nil {
+			cc.swallow(stream.Close())
+		}
+		if cc.status != nil {
+			cc.status.endIteration()
+		}
+		iterationEnd := time.Now()
+		cc.logTimed(slog.LevelInfo, fmt.Sprintf("  Iteration %d finished at %s, after %s", i, iterationEnd.Format(timeOfDay), roundDuration(iterationEnd.Sub(iterationStart))),
+			iterationStart, iterationEnd, slog.Int("iteration", i))
+		run.addUsage(usage)
+		if cc.sessionID != "" {
+			run.SessionID = cc.sessionID
+		}
+		if !usage.IsZero() {
+			cc.logInfo("  Usage: %s", usage)
+		}
+		cc.recordIteration(ctx, dir, run, i, iterationSHA, prompt, string(outBytes), usage, err)
+		cc.recordTranscript(ctx, dir, run, i, systemPrompt, prompt, string(outBytes))
+		cc.recordProgress(ctx, dir, progressFile, run, i, iterationSHA, string(outBytes))
+		changes := ""
+		if run.StartSHA != "" {
+			changes, _ = diffStat(ctx, dir, run.StartSHA)
+		}
+		if changes != "" {
+			cc.logStyled(styleDiffStat, "  Changes: %s", changes)
+		}
+		cc.emitIterationEnd(run, i, maxIterations, string(outBytes), changes)
+		if errors.Is(err, errMaxDuration) {
+			cc.logStyled(styleFailure, "Stopped iteration %d of %d: max duration %s reached", i, maxIterations, cc.maxDuration)
+			limit = fmt.Sprintf("max duration %s", cc.maxDuration)
+			run.StopReason = StopReasonMaxDuration
+			break
+		}
+		if errors.Is(err, errMaxCost) {
+			cc.logStyled(styleFailure, "Stopped iteration %d of %d: max cost $%.2f reached", i, maxIterations, cc.guards.MaxCost)
+			limit = fmt.Sprintf("max cost $%.2f", cc.guards.MaxCost)
+			run.StopReason = StopReasonMaxCost
+			break
+		}
+		var protected *ProtectedPathError
+		if errors.As(err, &protected) {
+			cc.logStyled(styleFailure, "Stopped iteration %d of %d: %v", i, maxIterations, err)
+			cc.finishRun(ctx, dir, run, RunStatusFailed)
+			return "", fmt.Errorf("stopped iteration %d: %w", i, err)
+		}
+		if err != nil && ctx.Err() != nil {
+			cc.logStyled(styleFailure, "Interrupted iteration %d of %d", i, maxIterations)
+			cc.finishRun(ctx, dir, run, RunStatusAborted)
+			return "", fmt.Errorf("interrupted at iteration %d: %w", i, ctx.Err())
+		}
 		if err != nil {
+			cc.finishRun(ctx, dir, run, RunStatusFailed)
 			//noinspection GoErrorStringFormatInspection
-			return "", fmt.Errorf("Claude CLI call failed at iteration %d: %w", i, err)
+			return "", withExitCode(ExitCLIFailure, fmt.Errorf("Claude CLI call failed at iteration %d: %w", i, err))
+		}
+
+		reverted, err := cc.enforceWriteScope(ctx, dir, scopeSHA, dirty)
+		if err != nil {
+			cc.logWarn("Failed to revert the changes outside the write scope: %v", err)
+		}
+		if len(reverted) > 0 {
+			cc.logWarn("Reverted changes outside the write scope: %s", strings.Join(reverted, ", "))
+			outOfScope = reverted
+		}
+
+		if err := cc.runHooks(ctx, dir, run, "after-iteration", cc.hooks.AfterIteration, i, RunStatusRunning); err != nil {
+			cc.logInfo("%v", err)
 		}
 
 		out = string(outBytes)
-		if strings.Contains(out, "") {
-			cc.logInfo("Task completed!")
-			cc.logInfo("Completed at iteration %d of %d", i, cc.maxIterations)
-			break
+		if detector.Detect(out, cc.repoState(ctx, dir, i, iterationSHA)) {
+			report, passed := cc.verify(ctx, dir, run, i)
+			if passed {
+				cc.logStyled(styleSuccess, "Task completed!")
+				cc.logInfo("Completed at iteration %d of %d", i, maxIterations)
+				cc.endRun(ctx, dir, run, out, RunStatusCompleted)
+				return StripControlMarkers(out, cc.completionSignal), nil
+			}
+			cc.logInfo("Task reported complete at iteration %d of %d, but verification failed", i, maxIterations)
+			verifyFailure = report
+		}
+
+		if i < maxIterations && (run.stopRequested(dir) || cc.control.stopRequested()) {
+			cc.logInfo("Stopping after iteration %d of %d: abort requested", i, maxIterations)
+			cc.endRun(ctx, dir, run, out, RunStatusAborted)
+			return StripControlMarkers(out, cc.completionSignal), nil
+		}
+
+		if n := cc.control.takeExtension(); n > 0 {
+			maxIterations += n
+			workIterations += n
+			limit = fmt.Sprintf("max iterations %d", maxIterations)
+			cc.logInfo("Extended the run to %d iterations", maxIterations)
 		}
 	}
 
+	if run.StopReason == "" {
+		run.StopReason = StopReasonMaxIterations
+	}
+	cc.endRun(ctx, dir, run, out, RunStatusIncomplete)
 	if len(out) == 0 {
-		cc.logInfo("Reached max iterations %d without completion signal", cc.maxIterations)
-		return "", fmt.Errorf("reached max iterations %d without completion signal", cc.maxIterations)
+		cc.logStyled(styleFailure, "Reached %s without completion signal", limit)
+		code := ExitIncomplete
+		if run.StopReason == StopReasonMaxDuration || run.StopReason == StopReasonMaxCost {
+			code = ExitBudgetExceeded
+		}
+		return "", withExitCode(code, fmt.Errorf("reached %s without completion signal", limit))
 	}
-	return out, err
+	return StripControlMarkers(out, cc.completionSignal), nil
 }
 
-func (cc *ClaudeConfig) callClaudeCLI(ctx context.Context, systemPrompt string, prompt string) ([]byte, error) {
-	cmd := commandContext(
-		ctx,
-		ClaudeCodeCli,
-		"--dangerously-skip-permissions",
-		"--print",
-		"--model",
-		cc.model,
-		"--system-prompt",
-		systemPrompt,
-		prompt)
-	return cmd.Output()
+// systemPrompt renders the system prompt passed to every iteration.
+func (cc *ClaudeConfig) systemPrompt(progressFile string) (string, error) {
+	return cc.renderSystemPrompt(progressFile, cc.promptContext())
 }
 
-func (cc *ClaudeConfig) ensureProgressFileExists() error {
-	dir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current working directory: %w", err)
+// promptContext is the context about the project injected into the system prompt, which
+// fitSystemPrompt truncates to fit the prompt budget.
+type promptContext struct {
+	Vars     map[string]string
+	Guidance []GuidanceFile
+	RepoMap  string
+}
+
+// promptContext returns the full context injected into the system prompt.
+func (cc *ClaudeConfig) promptContext() promptContext {
+	pc := promptContext{Vars: cc.vars, RepoMap: cc.repoMap}
+	if dir, err := cc.workDir(); err == nil {
+		pc.Guidance = cc.guidanceFiles(dir)
 	}
+	return pc
+}
 
-	gonzoDir := filepath.Join(dir, ".gonzo")
-	progressFile := filepath.Join(gonzoDir, "progress.txt")
+// systemPromptData is the data of the system prompt, playbook and language templates.
+type systemPromptData struct {
+	Branch           bool
+	BranchSuffix     string
+	Tests            bool
+	PR               bool
+	CommitAuthor     string
+	ProgressFile     string
+	CompletionSignal string
+	Vars             map[string]string
+	Playbook         string
+	Language         string
+	Guidance         []GuidanceFile
+	RepoMap          string
+	Agents           []Agent
+	WriteScope       []string
+}
 
-	if _, err := os.Stat(progressFile); errors.Is(err, os.ErrNotExist) {
-		// Ensure .gonzo directory exists
-		if err := os.MkdirAll(gonzoDir, 0755); err != nil {
-			return fmt.Errorf("failed to create .gonzo directory: %w", err)
+// renderSystemPrompt renders the system prompt with the given project context.
+func (cc *ClaudeConfig) renderSystemPrompt(progressFile string, pc promptContext) (string, error) {
+	t, err := cc.parsePrompt("system_prompt.tmpl")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse system prompt template: %w", err)
+	}
+
+	data := systemPromptData{
+		Branch:           !cc.noBranch,   // Branch is enabled when noBranch is false
+		Tests:            !cc.noNewTests, // Tests is enabled when noNewTests is false
+		PR:               cc.pr,
+		CommitAuthor:     cc.commitAuthor,
+		ProgressFile:     filepath.ToSlash(progressFile),
+		CompletionSignal: cc.completionSignal,
+		Vars:             pc.Vars,
+		Guidance:         pc.Guidance,
+		RepoMap:          pc.RepoMap,
+		Agents:           cc.subagents,
+		WriteScope:       cc.writeScope,
+	}
+	if cc.branchRunID && cc.runID != "" {
+		data.BranchSuffix = "-" + ShortRunID(cc.runID)
+	}
+	if data.Playbook, err = cc.playbookPrompt(data); err != nil {
+		return "", err
+	}
+	if dir, err := cc.workDir(); err == nil {
+		if data.Language, err = cc.languagePrompt(dir, data); err != nil {
+			return "", err
 		}
+	}
 
-		t, err := template.ParseFS(promptLib, "prompts/progress.tmpl")
+	var b strings.Builder
+	err = t.Execute(&b, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute system prompt template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// loadRepoMap generates the repository map of dir added to the system prompt, unless disabled.
+// A map that cannot be generated is left out.
+func (cc *ClaudeConfig) loadRepoMap(ctx context.Context, dir string) {
+	cc.repoMap = ""
+	if cc.noRepoMap {
+		return
+	}
+	repoMap, err := RepoMap(ctx, dir)
+	if err != nil {
+		cc.logInfo("Note: no repository map: %v", err)
+		return
+	}
+	cc.repoMap = repoMap
+}
+
+// reservedWrapUpIterations returns how many iterations are reserved for wrap-up.
+// At least one iteration is always left for the actual work.
+func (cc *ClaudeConfig) reservedWrapUpIterations() int {
+	if cc.wrapUpIterations <= 0 || cc.wrapUpIterations >= cc.maxIterations {
+		return 0
+	}
+	return cc.wrapUpIterations
+}
+
+// wrapUpData is the data of the wrap-up template.
+type wrapUpData struct {
+	Feature          string
+	Iteration        int
+	WrapUpIterations int
+	Tests            bool
+	PR               bool
+	CommitAuthor     string
+	ProgressFile     string
+}
+
+// wrapUpPrompt renders the prompt used for the reserved wrap-up iterations.
+func (cc *ClaudeConfig) wrapUpPrompt(feature string, progressFile string, iteration int) (string, error) {
+	t, err := cc.parsePrompt("wrap_up.tmpl")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse wrap-up template: %w", err)
+	}
+
+	var b strings.Builder
+	err = t.Execute(&b, wrapUpData{
+		Feature:          feature,
+		Iteration:        iteration,
+		WrapUpIterations: cc.reservedWrapUpIterations(),
+		Tests:            !cc.noNewTests,
+		PR:               cc.pr,
+		CommitAuthor:     cc.commitAuthor,
+		ProgressFile:     filepath.ToSlash(progressFile),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute wrap-up template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// startRun records the state of the repository and the run manifest before the first iteration,
+// so the run can later be rolled back with `gonzo rollback` or replayed with `gonzo rerun`.
+// Outside a git repository the starting commit is simply left empty.
+func (cc *ClaudeConfig) startRun(ctx context.Context, dir string, feature string) (*RunState, error) {
+	if cc.runID == "" {
+		cc.runID = NewRunID()
+	}
+	run := &RunState{
+		ID:        cc.runID,
+		Feature:   feature,
+		Model:     cc.model,
+		Status:    RunStatusRunning,
+		StartedAt: time.Now(),
+		PID:       os.Getpid(),
+	}
+
+	cc.dirtyAtStart = nil
+	if sha, err := headSHA(ctx, dir); err == nil {
+		run.StartSHA = sha
+		var branchErr, dirtyErr error
+		run.StartBranch, branchErr = currentBranch(ctx, dir)
+		cc.dirtyAtStart, dirtyErr = dirtyPaths(ctx, dir)
+		cc.swallow(errors.Join(branchErr, dirtyErr))
+	}
+
+	if err := run.Save(dir); err != nil {
+		return nil, err
+	}
+	if err := cc.writeManifest(ctx, dir, run); err != nil {
+		return nil, err
+	}
+
+	// The run goes on without a log file if it cannot be opened
+	if f, err := openRunLog(dir, run); err == nil {
+		cc.runLog = f
+	} else {
+		cc.swallow(err)
+	}
+	cc.verification = nil
+	if f, err := openLogFile(dir, run); err == nil {
+		cc.logFile = f
+	} else {
+		cc.swallow(err)
+	}
+	return run, nil
+}
+
+// endRun finalizes a run that did not fail: it commits leftover changes, opens the pull
+// request when enabled, comments on the originating issue, saves the raw output and
+// records the final state.
+func (cc *ClaudeConfig) endRun(ctx context.Context, dir string, run *RunState, out string, status string) {
+	run.Status = status
+	cc.commitLeftovers(ctx, dir, run, run.Feature)
+	if cc.pr && run.StartSHA != "" && !cc.approvePullRequest(ctx, run) {
+		cc.logInfo("Skipped pull request: not approved")
+	} else if cc.pr && run.StartSHA != "" {
+		url, err := cc.openPullRequest(ctx, dir, run, out)
 		if err != nil {
-			return fmt.Errorf("failed to read progress template: %w", err)
+			cc.logInfo("Skipped pull request: %v", err)
+		} else {
+			run.PRURL = url
+			cc.logInfo("Pull request: %s", url)
+			if cc.prOptions.Comment {
+				if err := cc.commentOnPullRequest(ctx, dir, run, out, url); err != nil {
+					cc.logWarn("Failed to comment on pull request: %v", err)
+				}
+			}
+		}
+	}
+	if cc.issue != nil {
+		if err := cc.commentOnIssue(ctx, dir, run); err != nil {
+			cc.logWarn("Failed to comment on issue #%d: %v", cc.issue.Number, err)
+		}
+	}
+	cc.swallow(run.SaveArtifact(dir, RawOutputArtifact, []byte(out)))
+	cc.finishRun(ctx, dir, run, status)
+}
+
+// commitLeftovers commits changes the agent did not commit itself, so the branch is left reviewable.
+// The files that had uncommitted changes when the run started are left as they are.
+func (cc *ClaudeConfig) commitLeftovers(ctx context.Context, dir string, run *RunState, feature string) {
+	if run.StartSHA == "" {
+		return
+	}
+	if cc.dirtyAtStart == nil {
+		cc.logWarn("Left the uncommitted changes uncommitted: the changes before the run could not be listed")
+		return
+	}
+	sha, err := cc.commitPending(ctx, dir, feature, cc.dirtyAtStart)
+	if err != nil {
+		cc.logWarn("Failed to commit uncommitted changes: %v", err)
+		return
+	}
+	if sha != "" {
+		cc.logInfo("Committed uncommitted changes as %s", sha)
+	}
+}
+
+// finishRun records where the run left the repository along with its final status.
+func (cc *ClaudeConfig) finishRun(ctx context.Context, dir string, run *RunState, status string) {
+	cc.stopStatusLine()
+	// Record the final state even when the run was cancelled
+	ctx = context.WithoutCancel(ctx)
+	if run.StartSHA != "" {
+		var shaErr, branchErr error
+		run.EndSHA, shaErr = headSHA(ctx, dir)
+		run.Branch, branchErr = currentBranch(ctx, dir)
+		cc.swallow(errors.Join(shaErr, branchErr))
+	}
+	if err := cc.runHooks(ctx, dir, run, "after-run", cc.hooks.AfterRun, run.Iterations, status); err != nil {
+		cc.logInfo("%v", err)
+	}
+	// Log before the final state is saved, so followers of the log see it
+	cc.logRunSummary(ctx, dir, run, status)
+	slog.New(cc.fileHandler()).Info(fmt.Sprintf("Run %s %s", run.ID, status))
+	cc.swallow(run.finish(dir, status))
+	cc.writeJUnitReport(dir, run)
+	cc.reportCI(ctx, dir, run)
+	cc.notify(ctx, dir, run, notifyEvent(run))
+	cc.emit(Event{Type: EventRunEnd, RunID: run.ID, Iteration: run.Iterations, Status: status, CostUSD: run.CostUSD})
+	if cc.runLog != nil {
+		err := cc.runLog.Close()
+		cc.runLog = nil
+		cc.swallow(err)
+	}
+	if cc.logFile != nil {
+		err := cc.logFile.Close()
+		cc.logFile = nil
+		cc.swallow(err)
+	}
+	cc.runID = ""
+}
+
+// errMaxDuration reports that the run used up its max duration.
+var errMaxDuration = errors.New("max duration reached")
+
+// processWaitDelay is how long a Claude CLI call that was stopped, or that exited, waits for the
+// processes it spawned to close its output before giving up on them.
+const processWaitDelay = 5 * time.Second
+
+// retryDelay is the pause before the first retry of a failed Claude CLI call; it doubles with
+// every further retry. It is a variable for testing.
+var retryDelay = 5 * time.Second
+
+// callClaude calls the Claude CLI for an iteration, retrying failed calls up to the configured
+// number of times, and rate limited calls once the limits lift, within the rate limit wait. It
+// returns errMaxDuration once the deadline of the run is reached. The output of the CLI is
+// streamed to stream as well, unless it is nil.
+func (cc *ClaudeConfig) callClaude(ctx context.Context, deadline time.Time, systemPrompt string, prompt string, stream io.Writer) ([]byte, Usage, error) {
+	delay, limitDelay := retryDelay, rateLimitDelay
+	// waited is the time spent waiting for rate limits to lift, not counted as retries
+	var waited time.Duration
+	// Failed attempts are paid for too
+	var usage Usage
+	for attempt := 0; ; attempt++ {
+		out, attemptUsage, err := cc.callClaudeOnce(ctx, deadline, systemPrompt, prompt, stream)
+		usage.Add(attemptUsage)
+		if err == nil || ctx.Err() != nil || errors.Is(err, errMaxDuration) || isGuardError(err) {
+			return out, usage, err
+		}
+
+		var limited *RateLimitError
+		if errors.As(err, &limited) {
+			wait := limited.RetryAfter
+			if wait <= 0 {
+				wait = limitDelay
+				limitDelay = min(2*limitDelay, maxRateLimitDelay)
+			}
+			if waited+wait <= cc.rateLimitWait {
+				if !deadline.IsZero() && time.Now().Add(wait).After(deadline) {
+					return out, usage, errMaxDuration
+				}
+				cc.logWarn("Claude CLI call rate limited; waiting %s before retrying (%s of %s waited so far)", wait, waited, cc.rateLimitWait)
+				select {
+				case <-ctx.Done():
+					return out, usage, ctx.Err()
+				case <-time.After(wait):
+				}
+				waited += wait
+				attempt--
+				continue
+			}
+			if cc.rateLimitWait > 0 {
+				cc.logWarn("Claude CLI call still rate limited after waiting %s, the most allowed by rate-limit-wait", waited)
+			}
+		}
+
+		if attempt >= cc.retries {
+			return out, usage, err
+		}
+
+		cc.logWarn("Claude CLI call failed: %v; retrying in %s (%d of %d)", err, delay, attempt+1, cc.retries)
+		select {
+		case <-ctx.Done():
+			return out, usage, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// callClaudeOnce calls the Claude CLI within the iteration timeout and the deadline of the run.
+func (cc *ClaudeConfig) callClaudeOnce(ctx context.Context, deadline time.Time, systemPrompt string, prompt string, stream io.Writer) ([]byte, Usage, error) {
+	callCtx := ctx
+	if !deadline.IsZero() {
+		if !time.Now().Before(deadline) {
+			return nil, Usage{}, errMaxDuration
 		}
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithDeadline(callCtx, deadline)
+		defer cancel()
+	}
+	if cc.iterationTimeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(callCtx, cc.iterationTimeout)
+		defer cancel()
+	}
+
+	out, usage, err := cc.callClaudeCLI(callCtx, systemPrompt, prompt, stream)
+	if err != nil && ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return out, usage, errMaxDuration
+		}
+		return out, usage, fmt.Errorf("timed out after %s: %w", cc.iterationTimeout, err)
+	}
+	return out, usage, err
+}
+
+func (cc *ClaudeConfig) callClaudeCLI(ctx context.Context, systemPrompt string, prompt string, stream io.Writer) ([]byte, Usage, error) {
+	args, err := cc.permissions.args()
+	if err != nil {
+		return nil, Usage{}, err
+	}
+	if cc.sessionMode == SessionContinue && cc.sessionID != "" {
+		args = append(args, "--resume", cc.sessionID)
+	}
+	return cc.execClaudeCLI(ctx, args, systemPrompt, prompt, stream)
+}
+
+// execClaudeCLI runs the Claude CLI in print mode with the given permission and session flags and
+// the MCP servers and extra arguments, and returns its final response and the usage it reports. The session of the
+// call is kept for the next. When stream is not nil, what Claude replies and the
+// errors of the CLI are also written to it as they come. A failed call returns a *CLIError with
+// what the CLI wrote to stderr.
+func (cc *ClaudeConfig) execClaudeCLI(ctx context.Context, flags []string, systemPrompt string, prompt string, stream io.Writer) ([]byte, Usage, error) {
+	args := cc.cliArgs(flags, systemPrompt, prompt)
+	cc.logDebug("  Running: %s", formatCommand(ClaudeCodeCli, args))
+	// Canceled to stop the CLI as soon as a guard fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := commandContext(ctx, ClaudeCodeCli, args...)
+	cmd.Dir = cc.dir
+	// Canceling kills the tools the CLI spawned too, and does not wait long for the ones
+	// holding its output open
+	setProcessGroup(cmd)
+	cmd.WaitDelay = processWaitDelay
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	// The temporary files of the call are removed with it, however it ends
+	if tmp, err := os.MkdirTemp("", "gonzo-claude-"); err == nil {
+		defer func() { cc.swallow(os.RemoveAll(tmp)) }()
+		cmd.Env = append(cmd.Env, "TMPDIR="+tmp)
+	}
+	cmd.Env = cc.claudeEnviron(ctx, cmd.Env)
 
-		f, err := os.Create(progressFile)
+	var out, stderr bytes.Buffer
+	dir, _ := cc.workDir()
+	events := newCLIStreamWriter(stream, dir)
+	events.onToolUse = func(t toolUse) error {
+		slog.New(cc.fileHandler()).Debug("  Tool: "+t.String(), slog.String("tool", t.Name))
+		if cc.guard == nil {
+			return nil
+		}
+		err := cc.guard.checkToolUse(t)
 		if err != nil {
-			return fmt.Errorf("failed to create progress file: %w", err)
-		}
-		defer func() { Swallow(f.Close()) }()
-		err = t.ExecuteTemplate(f, "progress.tmpl", struct {
-			Now    time.Time
-			Branch bool
-		}{
-			Now:    time.Now(),
-			Branch: !cc.noBranch, // Branch is enabled when noBranch is false
-		})
+			cancel()
+		}
+		return err
+	}
+	events.onUsage = func(u Usage) error {
+		if cc.guard == nil {
+			return nil
+		}
+		err := cc.guard.checkUsage(u)
 		if err != nil {
-			return fmt.Errorf("failed to write to progress file: %w", err)
+			cancel()
+		}
+		return err
+	}
+	cmd.Stdout, cmd.Stderr = io.MultiWriter(&out, events), &stderr
+	if stream != nil {
+		cmd.Stderr = io.MultiWriter(&stderr, stream)
+	}
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		// The CLI may have exited before its tools did
+		cc.swallow(killProcessGroup(cmd))
+	}
+	cc.swallow(events.Flush())
+	response, usage, session := parseCLIOutput(out.Bytes())
+	if session != "" {
+		cc.sessionID = session
+	}
+	if events.err != nil {
+		// Stopped before its result: the usage of its messages is all there is
+		if usage.IsZero() {
+			response = nil
+			usage = events.usage
+			if p, ok := Pricing[cc.model]; ok {
+				usage.CostUSD = p.usageCost(usage)
+			}
 		}
+		return response, usage, events.err
 	}
-	return nil
+	// A call refused by the API may also exit successfully, with an error result
+	errResult := cliErrorResult(out.Bytes())
+	if limited, retryAfter := detectRateLimit(stderr.String() + "\n" + errResult); limited && (err != nil || errResult != "") {
+		if err == nil {
+			err = errors.New(firstLine(errResult))
+		}
+		return response, usage, &RateLimitError{Err: &CLIError{Err: err, Stderr: stderr.String()}, RetryAfter: retryAfter}
+	}
+	if err != nil {
+		return response, usage, &CLIError{Err: err, Stderr: stderr.String()}
+	}
+	return response, usage, nil
+}
+
+// cliArgs returns the arguments of the Claude CLI call of execClaudeCLI.
+func (cc *ClaudeConfig) cliArgs(flags []string, systemPrompt string, prompt string) []string {
+	args := append(slices.Clone(flags), cc.mcpArgs()...)
+	args = append(args, cc.agentsArgs()...)
+	args = append(args,
+		"--print",
+		"--model",
+		cc.model,
+		"--output-format",
+		cliOutputStreamJSON,
+		// Print mode requires --verbose to stream the messages
+		"--verbose",
+		cc.cli.systemPromptFlag(),
+		systemPrompt)
+	// After gonzo's own flags, so that they can override them
+	args = append(args, cc.claudeArgs...)
+	return append(args, prompt)
+}
+
+// stderrExcerptLines bounds the lines of stderr quoted in the error of a failed Claude CLI call.
+const stderrExcerptLines = 10
+
+// stderrExcerptSize bounds the size, in bytes, of the stderr quoted in the error of a failed
+// Claude CLI call.
+const stderrExcerptSize = 1000
+
+// CLIError is the error of a failed Claude CLI call, along with what the CLI wrote to stderr.
+type CLIError struct {
+	Err    error
+	Stderr string
+}
+
+// Error returns the error of the call followed by the last lines the CLI wrote to stderr.
+func (e *CLIError) Error() string {
+	excerpt := strings.TrimSpace(e.Stderr)
+	if excerpt == "" {
+		return e.Err.Error()
+	}
+	if lines := strings.Split(excerpt, "\n"); len(lines) > stderrExcerptLines {
+		excerpt = "...\n" + strings.Join(lines[len(lines)-stderrExcerptLines:], "\n")
+	}
+	if len(excerpt) > stderrExcerptSize {
+		excerpt = "..." + excerpt[len(excerpt)-stderrExcerptSize:]
+	}
+	return fmt.Sprintf("%v: %s", e.Err, excerpt)
+}
+
+func (e *CLIError) Unwrap() error {
+	return e.Err
+}
+
+// progressData is the data of the progress template.
+type progressData struct {
+	Now    time.Time
+	Branch bool
+	Vars   map[string]string
 }
 
-func (cc *ClaudeConfig) logInfo(format string, args ...interface{}) {
-	if !cc.quiet {
-		fmt.Printf(format+"\n", args...)
+// ensureProgressFileExists creates the progress log of the repository in dir from its template,
+// unless it exists.
+func (cc *ClaudeConfig) ensureProgressFileExists(ctx context.Context, dir string) error {
+	t, err := cc.parsePrompt("progress.tmpl")
+	if err != nil {
+		return fmt.Errorf("failed to read progress template: %w", err)
+	}
+
+	var b strings.Builder
+	err = t.ExecuteTemplate(&b, "progress.tmpl", progressData{
+		Now:    time.Now(),
+		Branch: !cc.noBranch, // Branch is enabled when noBranch is false
+		Vars:   cc.vars,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render progress template: %w", err)
+	}
+
+	// A new log starts from the template converted to the structured format
+	initial := ParseLegacyProgress(b.String())
+	if IsStructuredProgress(b.String()) {
+		if initial, err = ParseProgress(b.String()); err != nil {
+			return fmt.Errorf("failed to parse progress template: %w", err)
+		}
 	}
+	return cc.progressStore(dir).EnsureExists(ctx, initial)
 }
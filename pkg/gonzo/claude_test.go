@@ -3,10 +3,16 @@ package gonzo
 import (
 	"context"
 	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 // mockCommandContext creates a mock exec.Cmd that calls TestHelperProcess instead of the real command.
@@ -25,6 +31,15 @@ func mockCommandContext(response string, exitCode int) func(ctx context.Context,
 	}
 }
 
+// mockEchoPromptCommandContext creates a mock exec.Cmd whose output is the prompt it was given.
+func mockEchoPromptCommandContext() func(ctx context.Context, name string, args ...string) *exec.Cmd {
+	return func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		cmd := mockCommandContext("", 0)(ctx, name, args...)
+		cmd.Env = append(cmd.Env, "GO_HELPER_ECHO_PROMPT=1")
+		return cmd
+	}
+}
+
 // TestHelperProcess is not a real test. It's used as a mock process for exec.Command tests.
 func TestHelperProcess(t *testing.T) {
 	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
@@ -36,7 +51,23 @@ func TestHelperProcess(t *testing.T) {
 	if exitCodeStr != "" {
 		fmt.Sscanf(exitCodeStr, "%d", &exitCode)
 	}
+	// Answer `claude --version` with a supported version, whatever the mocked response
+	if len(os.Args) == 5 && os.Args[4] == "--version" {
+		version := os.Getenv("GO_HELPER_CLAUDE_VERSION")
+		if version == "" {
+			version = "2.0.14 (Claude Code)"
+		}
+		fmt.Println(version)
+		os.Exit(0)
+	}
+	if os.Getenv("GO_HELPER_ECHO_PROMPT") == "1" {
+		response = os.Args[len(os.Args)-1]
+	}
+	if d, err := time.ParseDuration(os.Getenv("GO_HELPER_SLEEP")); err == nil {
+		time.Sleep(d)
+	}
 	fmt.Print(response)
+	fmt.Fprint(os.Stderr, os.Getenv("GO_HELPER_STDERR"))
 	os.Exit(exitCode)
 }
 
@@ -62,68 +93,53 @@ func TestClaudeConstants(t *testing.T) {
 }
 
 func TestEnsureProgressFileExists_CreatesFile(t *testing.T) {
-	// Create a temp directory and change to it
-	tmpDir, err := os.MkdirTemp("", "gonzo-test-*")
-	if err != nil {
-		t.Fatalf("failed to create temp directory: %v", err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	// Save current directory and change to temp
-	originalDir, err := os.Getwd()
-	if err != nil {
-		t.Fatalf("failed to get current directory: %v", err)
-	}
-	defer os.Chdir(originalDir)
+	tmpDir := t.TempDir()
 
-	if err := os.Chdir(tmpDir); err != nil {
-		t.Fatalf("failed to change to temp directory: %v", err)
-	}
-
-	// Verify .gonzo/progress.txt doesn't exist initially
+	// Verify .gonzo/progress.md doesn't exist initially
 	gonzoDir := filepath.Join(tmpDir, ".gonzo")
-	progressPath := filepath.Join(gonzoDir, "progress.txt")
+	progressPath := filepath.Join(gonzoDir, "progress.md")
 	if _, err := os.Stat(progressPath); !os.IsNotExist(err) {
-		t.Fatal(".gonzo/progress.txt should not exist before test")
+		t.Fatal(".gonzo/progress.md should not exist before test")
 	}
 
-	// Call the function - note: this will fail if promptLib isn't properly embedded
-	cc := New()
-	err = cc.ensureProgressFileExists()
-
-	// The function may fail due to embed.FS not being initialized in test context
-	// This is expected behavior - the embed directive requires the prompts directory
-	if err != nil {
-		t.Skipf("Skipping test - embed.FS not available in test context: %v", err)
+	cc := New(WithDir(tmpDir))
+	if err := cc.ensureProgressFileExists(context.Background(), tmpDir); err != nil {
+		t.Fatalf("ensureProgressFileExists() returned error: %v", err)
 	}
 
-	// If we get here, verify the .gonzo directory and file were created
+	// Verify the .gonzo directory and file were created
 	if _, err := os.Stat(gonzoDir); os.IsNotExist(err) {
 		t.Error(".gonzo directory should have been created")
 	}
-	if _, err := os.Stat(progressPath); os.IsNotExist(err) {
-		t.Error(".gonzo/progress.txt should have been created")
+	content, err := os.ReadFile(progressPath)
+	if err != nil {
+		t.Fatalf(".gonzo/progress.md should have been created: %v", err)
+	}
+	if !IsStructuredProgress(string(content)) {
+		t.Errorf(".gonzo/progress.md should be in the structured format, got %q", string(content))
 	}
 }
 
-func TestEnsureProgressFileExists_ExistingFile(t *testing.T) {
-	// Create a temp directory and change to it
-	tmpDir, err := os.MkdirTemp("", "gonzo-test-*")
-	if err != nil {
-		t.Fatalf("failed to create temp directory: %v", err)
+func TestEnsureProgressFileExists_PromptFS(t *testing.T) {
+	tmpDir := t.TempDir()
+	prompts := fstest.MapFS{"progress.tmpl": {Data: []byte("# Progress\n\nCustom notes for {{.Vars.team}}\n")}}
+
+	cc := New(WithDir(tmpDir), WithPromptFS(prompts), WithVars(map[string]string{"team": "core"}))
+	if err := cc.ensureProgressFileExists(context.Background(), tmpDir); err != nil {
+		t.Fatalf("ensureProgressFileExists() returned error: %v", err)
 	}
-	defer os.RemoveAll(tmpDir)
 
-	// Save current directory and change to temp
-	originalDir, err := os.Getwd()
+	content, err := os.ReadFile(filepath.Join(tmpDir, ".gonzo", "progress.md"))
 	if err != nil {
-		t.Fatalf("failed to get current directory: %v", err)
+		t.Fatalf(".gonzo/progress.md should have been created: %v", err)
 	}
-	defer os.Chdir(originalDir)
-
-	if err := os.Chdir(tmpDir); err != nil {
-		t.Fatalf("failed to change to temp directory: %v", err)
+	if !strings.Contains(string(content), "Custom notes for core") {
+		t.Errorf("expected the progress log from the custom template, got %q", string(content))
 	}
+}
+
+func TestEnsureProgressFileExists_ExistingFile(t *testing.T) {
+	tmpDir := t.TempDir()
 
 	// Create the .gonzo directory and an existing progress.txt with custom content
 	gonzoDir := filepath.Join(tmpDir, ".gonzo")
@@ -136,11 +152,9 @@ func TestEnsureProgressFileExists_ExistingFile(t *testing.T) {
 		t.Fatalf("failed to create existing .gonzo/progress.txt: %v", err)
 	}
 
-	// Call the function
-	cc := New()
-	err = cc.ensureProgressFileExists()
-	if err != nil {
-		t.Skipf("Skipping test - embed.FS not available in test context: %v", err)
+	cc := New(WithDir(tmpDir))
+	if err := cc.ensureProgressFileExists(context.Background(), tmpDir); err != nil {
+		t.Fatalf("ensureProgressFileExists() returned error: %v", err)
 	}
 
 	// Verify the existing file was not overwritten
@@ -155,13 +169,16 @@ func TestEnsureProgressFileExists_ExistingFile(t *testing.T) {
 }
 
 func TestGenerate_CLINotFound(t *testing.T) {
+	// Work on a throwaway directory so run state and commits stay out of the repository
+	dir := t.TempDir()
+
 	// Test behavior when claude CLI is not available
 	if _, err := exec.LookPath(ClaudeCodeCli); err == nil {
 		t.Skip("Skipping test - claude CLI is available on this system")
 	}
 
 	ctx := context.Background()
-	cc := New().WithModel(ClaudeSonnet).WithQuiet(true)
+	cc := New(WithDir(dir)).WithModel(ClaudeSonnet).WithQuiet(true)
 	_, err := cc.Generate(ctx, "test prompt")
 
 	// Should fail because claude CLI is not found (or embed.FS issue)
@@ -171,6 +188,9 @@ func TestGenerate_CLINotFound(t *testing.T) {
 }
 
 func TestGenerate_WithContext(t *testing.T) {
+	// Work on a throwaway directory so run state and commits stay out of the repository
+	dir := t.TempDir()
+
 	// Save original and restore after test
 	originalCommandContext := commandContext
 	defer func() { commandContext = originalCommandContext }()
@@ -184,7 +204,7 @@ func TestGenerate_WithContext(t *testing.T) {
 
 	// The function should handle the cancelled context gracefully
 	// The implementation uses exec.CommandContext to respect context cancellation
-	cc := New().WithModel(ClaudeSonnet).WithQuiet(true)
+	cc := New(WithDir(dir)).WithModel(ClaudeSonnet).WithQuiet(true)
 	_, err := cc.Generate(ctx, "test prompt")
 
 	// With a cancelled context, we expect an error (context cancelled)
@@ -193,6 +213,9 @@ func TestGenerate_WithContext(t *testing.T) {
 }
 
 func TestGenerate_ModelPassthrough(t *testing.T) {
+	// Work on a throwaway directory so run state and commits stay out of the repository
+	dir := t.TempDir()
+
 	// Save original and restore after test
 	originalCommandContext := commandContext
 	defer func() { commandContext = originalCommandContext }()
@@ -209,7 +232,7 @@ func TestGenerate_ModelPassthrough(t *testing.T) {
 	for _, model := range models {
 		t.Run(model, func(t *testing.T) {
 			ctx := context.Background()
-			cc := New().WithModel(model).WithQuiet(true)
+			cc := New(WithDir(dir)).WithModel(model).WithQuiet(true)
 			result, err := cc.Generate(ctx, "test")
 			if err != nil {
 				t.Errorf("unexpected error for model %s: %v", model, err)
@@ -222,6 +245,9 @@ func TestGenerate_ModelPassthrough(t *testing.T) {
 }
 
 func TestGenerate_ReturnsOutput(t *testing.T) {
+	// Work on a throwaway directory so run state and commits stay out of the repository
+	dir := t.TempDir()
+
 	// Save original and restore after test
 	originalCommandContext := commandContext
 	defer func() { commandContext = originalCommandContext }()
@@ -230,7 +256,7 @@ func TestGenerate_ReturnsOutput(t *testing.T) {
 	commandContext = mockCommandContext(expectedResponse, 0)
 
 	ctx := context.Background()
-	cc := New().WithModel(ClaudeSonnet).WithQuiet(true)
+	cc := New(WithDir(dir)).WithModel(ClaudeSonnet).WithQuiet(true)
 	result, err := cc.Generate(ctx, "test prompt")
 
 	if err != nil {
@@ -241,7 +267,93 @@ func TestGenerate_ReturnsOutput(t *testing.T) {
 	}
 }
 
+func TestGenerate_StripsCompletionSignal(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+
+	rawResponse := "Feature implemented\n" + DefaultCompletionSignal + "\n"
+	commandContext = mockCommandContext(rawResponse, 0)
+
+	cc := New(WithDir(dir)).WithModel(ClaudeSonnet).WithQuiet(true)
+	result, err := cc.Generate(context.Background(), "test prompt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "Feature implemented" {
+		t.Errorf("expected completion signal to be stripped, got %q", result)
+	}
+
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("expected run state to be recorded: %v", err)
+	}
+	if run.Status != RunStatusCompleted || run.Iterations != 1 {
+		t.Errorf("expected run to complete at iteration 1, got status %q at iteration %d", run.Status, run.Iterations)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(RunDir(dir, run.ID), RawOutputArtifact))
+	if err != nil {
+		t.Fatalf("expected raw output artifact: %v", err)
+	}
+	if string(raw) != rawResponse {
+		t.Errorf("expected raw artifact %q, got %q", rawResponse, string(raw))
+	}
+}
+
+func TestGenerate_WrapUpIterations(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockEchoPromptCommandContext()
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithMaxIterations(3).WithWrapUpIterations(1)
+	result, err := cc.Generate(context.Background(), "add a login button")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The final iteration's output is the prompt it received
+	if !strings.Contains(result, "Wrap-Up Iteration") {
+		t.Errorf("expected the last iteration to use the wrap-up prompt, got %q", result)
+	}
+	if !strings.Contains(result, "add a login button") {
+		t.Errorf("expected the wrap-up prompt to include the original task, got %q", result)
+	}
+}
+
+func TestReservedWrapUpIterations(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxIter  int
+		wrapUp   int
+		expected int
+	}{
+		{"default", 10, DefaultWrapUpIterations, 1},
+		{"disabled", 10, 0, 0},
+		{"several", 10, 3, 3},
+		{"leaves room for work", 2, 2, 0},
+		{"single iteration", 1, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := New().WithMaxIterations(tt.maxIter).WithWrapUpIterations(tt.wrapUp)
+			if got := cc.reservedWrapUpIterations(); got != tt.expected {
+				t.Errorf("expected %d reserved iterations, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
 func TestGenerate_HandlesError(t *testing.T) {
+	// Work on a throwaway directory so run state and commits stay out of the repository
+	dir := t.TempDir()
+
 	// Save original and restore after test
 	originalCommandContext := commandContext
 	defer func() { commandContext = originalCommandContext }()
@@ -250,7 +362,7 @@ func TestGenerate_HandlesError(t *testing.T) {
 	commandContext = mockCommandContext("error output", 1)
 
 	ctx := context.Background()
-	cc := New().WithModel(ClaudeSonnet).WithQuiet(true)
+	cc := New(WithDir(dir)).WithModel(ClaudeSonnet).WithQuiet(true)
 	_, err := cc.Generate(ctx, "test prompt")
 
 	if err == nil {
@@ -258,6 +370,180 @@ func TestGenerate_HandlesError(t *testing.T) {
 	}
 }
 
+func TestGenerate_CompletionSignal(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("Feature implemented\nALL DONE\n", 0)
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithCompletionSignal("ALL DONE")
+	result, err := cc.Generate(context.Background(), "test prompt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "Feature implemented" {
+		t.Errorf("expected the custom completion signal to end the run and be stripped, got %q", result)
+	}
+
+	systemPrompt, err := cc.systemPrompt(ProgressFile)
+	if err != nil {
+		t.Fatalf("systemPrompt() returned error: %v", err)
+	}
+	if !strings.Contains(systemPrompt, "ALL DONE") || strings.Contains(systemPrompt, DefaultCompletionSignal) {
+		t.Errorf("expected the system prompt to ask for the custom completion signal, got %q", systemPrompt)
+	}
+}
+
+func TestGenerate_Retries(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	originalRetryDelay := retryDelay
+	defer func() { commandContext, retryDelay = originalCommandContext, originalRetryDelay }()
+
+	dir := t.TempDir()
+	retryDelay = time.Millisecond
+	calls := 0
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if slices.Contains(args, "--print") {
+			calls++
+		}
+		return mockCommandContext("error output", 1)(ctx, name, args...)
+	}
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithRetries(2)
+	if _, err := cc.Generate(context.Background(), "test prompt"); err == nil {
+		t.Error("expected an error once the retries are used up")
+	}
+	if calls != 3 {
+		t.Errorf("expected the call to be tried 3 times, got %d", calls)
+	}
+}
+
+func TestGenerate_SessionMode(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	var calls [][]string
+	mock := mockCommandContext(`{"type":"result","result":"still working","session_id":"session-1"}`, 0)
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if slices.Contains(args, "--print") {
+			calls = append(calls, args)
+		}
+		return mock(ctx, name, args...)
+	}
+
+	for _, tt := range []struct {
+		mode   string
+		resume bool
+	}{
+		{SessionFresh, false},
+		{SessionContinue, true},
+	} {
+		calls = nil
+		if _, err := New(WithWorkingDir(dir)).WithQuiet(true).WithMaxIterations(2).WithSessionMode(tt.mode).Generate(context.Background(), "test prompt"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(calls) != 2 {
+			t.Fatalf("expected 2 Claude calls in %s mode, got %d", tt.mode, len(calls))
+		}
+		if slices.Contains(calls[0], "--resume") {
+			t.Errorf("expected the first iteration to start a new session in %s mode, got %q", tt.mode, calls[0])
+		}
+		if resumed := strings.Contains(strings.Join(calls[1], " "), "--resume session-1"); resumed != tt.resume {
+			t.Errorf("expected resume %v of the session in %s mode, got %q", tt.resume, tt.mode, calls[1])
+		}
+
+		run, err := LatestRunState(dir)
+		if err != nil {
+			t.Fatalf("LatestRunState() returned error: %v", err)
+		}
+		if run.SessionID != "session-1" {
+			t.Errorf("expected the session to be recorded, got %q", run.SessionID)
+		}
+	}
+}
+
+func TestGenerate_IterationTimeout(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		cmd := mockCommandContext("too late", 0)(ctx, name, args...)
+		if slices.Contains(args, "--print") {
+			cmd.Env = append(cmd.Env, "GO_HELPER_SLEEP=10s")
+		}
+		return cmd
+	}
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithIterationTimeout(100 * time.Millisecond)
+	_, err := cc.Generate(context.Background(), "test prompt")
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("expected the iteration to time out, got %v", err)
+	}
+}
+
+func TestGenerate_Interrupted(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		cmd := mockCommandContext("too late", 0)(ctx, name, args...)
+		if slices.Contains(args, "--print") {
+			cmd.Env = append(cmd.Env, "GO_HELPER_SLEEP=10s")
+		}
+		return cmd
+	}
+
+	// As on an interrupt
+	ctx, cancel := context.WithCancel(context.Background())
+	defer time.AfterFunc(200*time.Millisecond, cancel).Stop()
+	_, err := New(WithDir(dir)).WithQuiet(true).Generate(ctx, "test prompt")
+	if code := ExitCode(err); code != ExitAborted {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitAborted, code, err)
+	}
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("expected run state to be recorded: %v", err)
+	}
+	if run.Status != RunStatusAborted {
+		t.Errorf("expected an aborted run, got status %q", run.Status)
+	}
+}
+
+func TestGenerate_MaxDuration(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("still working", 0)
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithMaxDuration(time.Nanosecond)
+	_, err := cc.Generate(context.Background(), "test prompt")
+	if err == nil || err.Error() != "reached max duration 1ns without completion signal" {
+		t.Errorf("expected the run to stop at its max duration, got %v", err)
+	}
+	if code := ExitCode(err); code != ExitBudgetExceeded {
+		t.Errorf("expected exit code %d, got %d", ExitBudgetExceeded, code)
+	}
+
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("expected run state to be recorded: %v", err)
+	}
+	if run.Status != RunStatusIncomplete || run.Iterations != 0 || run.StopReason != StopReasonMaxDuration {
+		t.Errorf("expected an incomplete run without iterations, got status %q (%s) at iteration %d", run.Status, run.StopReason, run.Iterations)
+	}
+}
+
 func TestWithPR(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -308,6 +594,55 @@ func TestWithCommitAuthor(t *testing.T) {
 	}
 }
 
+func TestWithConfig(t *testing.T) {
+	c := config.Default()
+	c.Model = ClaudeHaiku
+	c.MaxIterations = 4
+	c.PRLabels = []string{"gonzo"}
+	c.PRCloseKeyword = "Fixes"
+	c.CI = CIGitHub
+
+	cc := New(WithConfig(c))
+	if cc.model != ClaudeHaiku || cc.maxIterations != 4 || cc.ci != CIGitHub {
+		t.Errorf("expected the config to be applied, got model %q, max iterations %d, ci %q", cc.model, cc.maxIterations, cc.ci)
+	}
+	if len(cc.prOptions.Labels) != 1 || cc.prOptions.CloseKeyword != "Fixes" {
+		t.Errorf("expected the pull request options to be applied, got %+v", cc.prOptions)
+	}
+	if cc.pr != config.DefaultPR {
+		t.Errorf("expected pr %v from the config defaults, got %v", config.DefaultPR, cc.pr)
+	}
+
+	c.IterationTimeout = time.Minute
+	c.Hooks.AfterRun = []string{"make notify"}
+	// The deprecated builder method applies the same option
+	cc = New().WithConfig(c)
+	if cc.iterationTimeout != time.Minute || cc.completionSignal != DefaultCompletionSignal {
+		t.Errorf("expected the loop controls to be applied, got timeout %s, signal %q", cc.iterationTimeout, cc.completionSignal)
+	}
+	if len(cc.hooks.AfterRun) != 1 || cc.hooks.AfterRun[0] != "make notify" {
+		t.Errorf("expected the hooks to be applied, got %+v", cc.hooks)
+	}
+}
+
+func TestNew_Options(t *testing.T) {
+	cc := New(WithModel(ClaudeSonnet), WithMaxIterations(3), WithRetries(2), WithMaxIterations(5))
+	if cc.model != ClaudeSonnet || cc.retries != 2 {
+		t.Errorf("expected the options to be applied, got model %q, retries %d", cc.model, cc.retries)
+	}
+	if cc.maxIterations != 5 {
+		t.Errorf("expected the last option to win, got max iterations %d", cc.maxIterations)
+	}
+	if cc.commitAuthor != DefaultCommitAuthor || cc.permissions.mode() != DefaultPermissionMode {
+		t.Errorf("expected the defaults to be kept, got author %q, permission mode %q", cc.commitAuthor, cc.permissions.mode())
+	}
+
+	built := New().WithModel(ClaudeSonnet).WithMaxIterations(5).WithRetries(2)
+	if built.model != cc.model || built.maxIterations != cc.maxIterations || built.retries != cc.retries {
+		t.Errorf("expected the builder methods to match the options, got %+v", built)
+	}
+}
+
 func TestDefaultCommitAuthor(t *testing.T) {
 	cc := New()
 	if cc.commitAuthor != DefaultCommitAuthor {
@@ -318,3 +653,86 @@ func TestDefaultCommitAuthor(t *testing.T) {
 		t.Errorf("expected default commitAuthor to be %q, got %q", expectedDefault, cc.commitAuthor)
 	}
 }
+
+func TestGenerate_WithDir(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	repo := t.TempDir()
+	dir := t.TempDir()
+	var claudeCmd *exec.Cmd
+	mock := mockCommandContext("Done "+DefaultCompletionSignal, 0)
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		cmd := mock(ctx, name, args...)
+		if slices.Contains(args, "--print") {
+			claudeCmd = cmd
+		}
+		return cmd
+	}
+
+	if _, err := New(WithDir(dir)).WithQuiet(true).WithDir(repo).Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claudeCmd == nil || claudeCmd.Dir != repo {
+		t.Errorf("expected Claude to run in %s, got %+v", repo, claudeCmd)
+	}
+	if _, err := os.Stat(filepath.Join(repo, GonzoDir, ProgressFile)); err != nil {
+		t.Errorf("expected the progress log in the repository: %v", err)
+	}
+	if _, err := LatestRunState(repo); err != nil {
+		t.Errorf("expected the run to be recorded in the repository: %v", err)
+	}
+}
+
+func TestExecClaudeCLI_ClaudeArgs(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var args []string
+	mock := mockCommandContext("ok", 0)
+	commandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		args = arg
+		return mock(ctx, name, arg...)
+	}
+
+	cc := New().WithClaudeArgs([]string{"--fallback-model", "claude-sonnet-4-5", "--model=claude-haiku-4-5"})
+	if _, _, err := cc.callClaudeCLI(context.Background(), "system", "prompt", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// After gonzo's flags, so they win, and before the prompt
+	joined := strings.Join(args, " ")
+	if !strings.HasSuffix(joined, "--system-prompt system --fallback-model claude-sonnet-4-5 --model=claude-haiku-4-5 prompt") {
+		t.Errorf("expected the extra arguments before the prompt, got %q", joined)
+	}
+}
+
+func TestGenerate_Concurrent(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("Done "+DefaultCompletionSignal, 0)
+
+	cc := New(WithDir(dir), WithQuiet(true))
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = cc.Generate(context.Background(), "add a login button")
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if cc.runID != "" || cc.cli != nil {
+		t.Error("expected the runs to leave the configuration as it was")
+	}
+}
@@ -0,0 +1,92 @@
+package gonzo
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+)
+
+// ANSI escape sequences coloring the console.
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiFaint  = "\033[2m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiCyan   = "\033[36m"
+)
+
+// styleKey is the attribute of a log record naming its style on the console. It is left out of
+// the log files.
+const styleKey = "style"
+
+// Styles of the messages printed on the console.
+const (
+	styleBanner   = "banner"
+	styleSuccess  = "success"
+	styleFailure  = "failure"
+	styleDiffStat = "diffstat"
+)
+
+var (
+	insertionsPattern = regexp.MustCompile(`\d+ insertions?\(\+\)`)
+	deletionsPattern  = regexp.MustCompile(`\d+ deletions?\(-\)`)
+)
+
+// isTerminal reports whether f is a terminal.
+var isTerminal = func(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// colorEnabled returns whether messages about the run are colored: only on a terminal, and
+// unless disabled with WithNoColor, the NO_COLOR environment variable or TERM=dumb.
+func (cc *ClaudeConfig) colorEnabled() bool {
+	if cc.noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || cc.logging.Format == LogFormatJSON {
+		return false
+	}
+	f, ok := cc.logWriter().(*os.File)
+	return ok && isTerminal(f)
+}
+
+// colorize returns a message in the colors of its style, or of its level when it has none:
+// warnings in yellow, errors in red and debug messages faint.
+func colorize(msg string, style string, level slog.Level) string {
+	switch style {
+	case styleBanner:
+		return ansiBold + ansiCyan + msg + ansiReset
+	case styleSuccess:
+		return ansiGreen + "✓ " + msg + ansiReset
+	case styleFailure:
+		return ansiRed + "✗ " + msg + ansiReset
+	case styleDiffStat:
+		msg = insertionsPattern.ReplaceAllString(msg, ansiGreen+"$0"+ansiReset)
+		return deletionsPattern.ReplaceAllString(msg, ansiRed+"$0"+ansiReset)
+	}
+	switch {
+	case level >= slog.LevelError:
+		return ansiRed + msg + ansiReset
+	case level >= slog.LevelWarn:
+		return ansiYellow + msg + ansiReset
+	case level < slog.LevelInfo:
+		return ansiFaint + msg + ansiReset
+	}
+	return msg
+}
+
+// dropStyle leaves the style of records out of the log files.
+func dropStyle(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == styleKey {
+		return slog.Attr{}
+	}
+	return a
+}
+
+// logStyled is logInfo for a message printed in a style on the console.
+func (cc *ClaudeConfig) logStyled(style string, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	cc.logger().Info(msg, slog.String(styleKey, style))
+	cc.emit(Event{Type: EventLog, Message: msg})
+}
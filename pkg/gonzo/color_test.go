@@ -0,0 +1,64 @@
+package gonzo
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestColorize(t *testing.T) {
+	tests := []struct {
+		msg   string
+		style string
+		level slog.Level
+		want  string
+	}{
+		{"Task completed!", styleSuccess, slog.LevelInfo, ansiGreen + "✓ Task completed!" + ansiReset},
+		{"Reached max iterations 10", styleFailure, slog.LevelInfo, ansiRed + "✗ Reached max iterations 10" + ansiReset},
+		{"  Changes: 2 files changed, 10 insertions(+), 1 deletion(-)", styleDiffStat, slog.LevelInfo,
+			"  Changes: 2 files changed, " + ansiGreen + "10 insertions(+)" + ansiReset + ", " + ansiRed + "1 deletion(-)" + ansiReset},
+		{"Verification failed", "", slog.LevelWarn, ansiYellow + "Verification failed" + ansiReset},
+		{"Run ID: 1", "", slog.LevelInfo, "Run ID: 1"},
+	}
+	for _, tt := range tests {
+		if got := colorize(tt.msg, tt.style, tt.level); got != tt.want {
+			t.Errorf("colorize(%q, %q) = %q, want %q", tt.msg, tt.style, got, tt.want)
+		}
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	originalIsTerminal := isTerminal
+	defer func() { isTerminal = originalIsTerminal }()
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm-256color")
+
+	isTerminal = func(*os.File) bool { return false }
+	if New().colorEnabled() {
+		t.Error("expected no colors when stdout is not a terminal")
+	}
+
+	isTerminal = func(*os.File) bool { return true }
+	if !New().colorEnabled() {
+		t.Error("expected colors on a terminal")
+	}
+	if New().WithNoColor(true).colorEnabled() {
+		t.Error("expected no colors with WithNoColor")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if New().colorEnabled() {
+		t.Error("expected no colors with NO_COLOR")
+	}
+}
+
+func TestConsoleHandler_Color(t *testing.T) {
+	var b bytes.Buffer
+	logger := slog.New(&consoleHandler{w: &b, level: slog.LevelInfo, color: true})
+	logger.Info("  Iteration 1 of 10", slog.String(styleKey, styleBanner))
+
+	want := ansiBold + ansiCyan + "  Iteration 1 of 10" + ansiReset + "\n"
+	if b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
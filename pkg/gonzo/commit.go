@@ -0,0 +1,211 @@
+package gonzo
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultCommitTemplate renders a Conventional Commits header from a CommitMessage.
+const DefaultCommitTemplate = "{{ .Type }}{{ if .Scope }}({{ .Scope }}){{ end }}: {{ .Subject }}"
+
+// maxCommitSubjectLen is the maximum length of the subject part of a generated commit header.
+const maxCommitSubjectLen = 50
+
+// CommitMessage holds the values available to commit message templates.
+type CommitMessage struct {
+	Type    string
+	Scope   string
+	Subject string
+	Feature string
+	Files   []string
+}
+
+// featureTypeKeywords maps words in the feature text to Conventional Commit types, checked in order.
+var featureTypeKeywords = []struct {
+	commitType string
+	pattern    *regexp.Regexp
+}{
+	{"fix", regexp.MustCompile(`(?i)\b(fix(es|ed)?|bug|crash(es)?|broken|regression|error)\b`)},
+	{"docs", regexp.MustCompile(`(?i)\b(docs?|documentation|readme)\b`)},
+	{"test", regexp.MustCompile(`(?i)\b(tests?|coverage)\b`)},
+	{"refactor", regexp.MustCompile(`(?i)\b(refactor(ing)?|restructure|clean ?up)\b`)},
+	{"perf", regexp.MustCompile(`(?i)\b(perf(ormance)?|speed up|faster|optimi[sz]e)\b`)},
+	{"ci", regexp.MustCompile(`(?i)\b(ci|workflows?|pipeline)\b`)},
+	{"build", regexp.MustCompile(`(?i)\b(build|dependenc(y|ies)|go\.mod|makefile)\b`)},
+}
+
+// InferCommitMessage derives the Conventional Commit type, scope and subject from the
+// changed files and the feature text. The diff takes precedence over the feature text
+// when every changed file points at the same type (e.g. only markdown files means docs).
+func InferCommitMessage(feature string, files []string) CommitMessage {
+	msg := CommitMessage{
+		Type:    inferTypeFromFiles(files),
+		Scope:   inferScope(files),
+		Subject: commitSubject(feature),
+		Feature: feature,
+		Files:   files,
+	}
+
+	if msg.Type == "" {
+		msg.Type = "feat"
+		for _, kw := range featureTypeKeywords {
+			if kw.pattern.MatchString(feature) {
+				msg.Type = kw.commitType
+				break
+			}
+		}
+	}
+	return msg
+}
+
+// inferTypeFromFiles returns a commit type when all files agree on one, otherwise an empty string.
+func inferTypeFromFiles(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	commitType := ""
+	for _, f := range files {
+		t := fileCommitType(f)
+		if t == "" || (commitType != "" && t != commitType) {
+			return ""
+		}
+		commitType = t
+	}
+	return commitType
+}
+
+func fileCommitType(file string) string {
+	base := path.Base(file)
+	switch {
+	case strings.HasPrefix(file, ".github/workflows/") || base == ".gitlab-ci.yml":
+		return "ci"
+	case strings.HasSuffix(base, "_test.go") || strings.Contains(base, ".test.") || strings.Contains(base, ".spec."):
+		return "test"
+	case strings.HasSuffix(base, ".md") || strings.HasPrefix(file, "docs/"):
+		return "docs"
+	case base == "go.mod" || base == "go.sum" || base == "Makefile" || base == "package.json":
+		return "build"
+	}
+	return ""
+}
+
+// inferScope returns the name of the deepest directory shared by all files,
+// or an empty string when the changes span the repository root.
+func inferScope(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	common := strings.Split(path.Dir(files[0]), "/")
+	for _, f := range files[1:] {
+		parts := strings.Split(path.Dir(f), "/")
+		n := 0
+		for n < len(common) && n < len(parts) && common[n] == parts[n] {
+			n++
+		}
+		common = common[:n]
+	}
+
+	if len(common) == 0 || common[0] == "." {
+		return ""
+	}
+	return strings.TrimPrefix(common[len(common)-1], ".")
+}
+
+// commitSubject turns the first line of the feature text into a commit subject:
+// lower-case first letter, no trailing period, truncated to fit the subject line.
+func commitSubject(feature string) string {
+	subject := strings.TrimSpace(feature)
+	if i := strings.IndexByte(subject, '\n'); i >= 0 {
+		subject = strings.TrimSpace(subject[:i])
+	}
+	subject = strings.TrimRight(subject, ".")
+
+	if r, size := utf8.DecodeRuneInString(subject); r != utf8.RuneError {
+		subject = string(unicode.ToLower(r)) + subject[size:]
+	}
+
+	if runes := []rune(subject); len(runes) > maxCommitSubjectLen {
+		subject = strings.TrimSpace(string(runes[:maxCommitSubjectLen-3])) + "..."
+	}
+	return subject
+}
+
+// RenderCommitMessage renders msg with the given text/template. An empty template
+// uses DefaultCommitTemplate.
+func RenderCommitMessage(tmpl string, msg CommitMessage) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultCommitTemplate
+	}
+
+	t, err := template.New("commit").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse commit template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, msg); err != nil {
+		return "", fmt.Errorf("failed to execute commit template: %w", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// commitMessage builds the message for a commit made by gonzo itself.
+func (cc *ClaudeConfig) commitMessage(feature string, files []string) (string, error) {
+	if !cc.conventionalCommits {
+		return "gonzo: " + commitSubject(feature), nil
+	}
+	return RenderCommitMessage(cc.commitTemplate, InferCommitMessage(feature, files))
+}
+
+// commitPending commits any changes the agent left uncommitted, using the configured author,
+// except to the paths in skip, e.g. the user's changes from before the run. Whatever else the
+// index holds stays out of the commit. It returns the new commit SHA, or an empty string when
+// there was nothing to commit.
+func (cc *ClaudeConfig) commitPending(ctx context.Context, dir string, feature string, skip map[string]bool) (string, error) {
+	// The paths are relative to the repository, whichever of its directories gonzo runs in
+	dir, err := repoRoot(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	dirty, err := dirtyPaths(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	var paths []string
+	for path := range dirty {
+		if !skip[path] {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return "", nil
+	}
+	slices.Sort(paths)
+
+	if _, err := git(ctx, dir, append([]string{"add", "--all", "--"}, paths...)...); err != nil {
+		return "", err
+	}
+
+	msg, err := cc.commitMessage(feature, paths)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"commit", "--quiet", "-m", msg}
+	if cc.commitAuthor != "" {
+		args = append(args, "--author="+cc.commitAuthor)
+	}
+	if _, err := git(ctx, dir, append(append(args, "--"), paths...)...); err != nil {
+		return "", err
+	}
+	return headSHA(ctx, dir)
+}
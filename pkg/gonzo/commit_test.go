@@ -0,0 +1,185 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInferCommitMessage(t *testing.T) {
+	tests := []struct {
+		name          string
+		feature       string
+		files         []string
+		expectedType  string
+		expectedScope string
+	}{
+		{"feature default", "Add a login button", []string{"pkg/ui/button.go", "pkg/ui/login.go"}, "feat", "ui"},
+		{"fix from feature text", "Fix crash when config is missing", []string{"pkg/config/config.go"}, "fix", "config"},
+		{"docs from files", "Explain the install options", []string{"README.md", "docs/install.md"}, "docs", ""},
+		{"tests from files", "Cover the parser", []string{"pkg/parser/parser_test.go"}, "test", "parser"},
+		{"ci from files", "Run tests on push", []string{".github/workflows/test.yml"}, "ci", "workflows"},
+		{"mixed files fall back to text", "Refactor the runner", []string{"pkg/gonzo/claude.go", "README.md"}, "refactor", ""},
+		{"root files have no scope", "Add a feature", []string{"main.go"}, "feat", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := InferCommitMessage(tt.feature, tt.files)
+			if msg.Type != tt.expectedType {
+				t.Errorf("expected type %q, got %q", tt.expectedType, msg.Type)
+			}
+			if msg.Scope != tt.expectedScope {
+				t.Errorf("expected scope %q, got %q", tt.expectedScope, msg.Scope)
+			}
+		})
+	}
+}
+
+func TestCommitSubject(t *testing.T) {
+	if got := commitSubject("Add a login button.\nWith more details"); got != "add a login button" {
+		t.Errorf("expected first line without trailing period, got %q", got)
+	}
+
+	long := commitSubject(strings.Repeat("word ", 30))
+	if len([]rune(long)) > maxCommitSubjectLen || !strings.HasSuffix(long, "...") {
+		t.Errorf("expected long subject to be truncated, got %q", long)
+	}
+}
+
+func TestRenderCommitMessage(t *testing.T) {
+	msg := CommitMessage{Type: "feat", Scope: "ui", Subject: "add a login button"}
+
+	got, err := RenderCommitMessage("", msg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "feat(ui): add a login button" {
+		t.Errorf("expected default conventional header, got %q", got)
+	}
+
+	got, err = RenderCommitMessage("[{{ .Type }}] {{ .Subject }}", msg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "[feat] add a login button" {
+		t.Errorf("expected custom template to be used, got %q", got)
+	}
+
+	if _, err := RenderCommitMessage("{{ .Missing", msg); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
+
+func TestCommitPending(t *testing.T) {
+	dir := initGitRepo(t)
+	ctx := context.Background()
+	cc := New().WithCommitAuthor("Gonzo Test <gonzo@example.com>")
+
+	sha, err := cc.commitPending(ctx, dir, "Nothing to do", nil)
+	if err != nil {
+		t.Fatalf("commitPending() returned error: %v", err)
+	}
+	if sha != "" {
+		t.Errorf("expected no commit for a clean tree, got %s", sha)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "pkg", "auth"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pkg", "auth", "login.go"), []byte("package auth\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	sha, err = cc.commitPending(ctx, dir, "Add login support", nil)
+	if err != nil {
+		t.Fatalf("commitPending() returned error: %v", err)
+	}
+	if sha == "" {
+		t.Fatal("expected a commit for uncommitted changes")
+	}
+
+	if subject := runGit(t, dir, "log", "-1", "--format=%s"); subject != "feat(auth): add login support" {
+		t.Errorf("expected conventional commit subject, got %q", subject)
+	}
+	if author := runGit(t, dir, "log", "-1", "--format=%an <%ae>"); author != "Gonzo Test <gonzo@example.com>" {
+		t.Errorf("expected configured author, got %q", author)
+	}
+}
+
+func TestCommitPending_PlainMessage(t *testing.T) {
+	dir := initGitRepo(t)
+	cc := New().WithConventionalCommits(false)
+
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := cc.commitPending(context.Background(), dir, "Write some notes", nil); err != nil {
+		t.Fatalf("commitPending() returned error: %v", err)
+	}
+
+	if subject := runGit(t, dir, "log", "-1", "--format=%s"); subject != "gonzo: write some notes" {
+		t.Errorf("expected plain commit subject, got %q", subject)
+	}
+}
+
+func TestCommitPending_SkipsChangesBeforeRun(t *testing.T) {
+	dir := initGitRepo(t)
+	ctx := context.Background()
+	writeAndCommit(t, dir, "config.go", "package main\n", "add config")
+
+	// The user's work: a modified file, a staged one and an untracked one
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("edited\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.go"), []byte("package config\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	runGit(t, dir, "add", "config.go")
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	before, err := dirtyPaths(ctx, dir)
+	if err != nil {
+		t.Fatalf("dirtyPaths() returned error: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "login.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := New().commitPending(ctx, dir, "Add login support", before); err != nil {
+		t.Fatalf("commitPending() returned error: %v", err)
+	}
+
+	if files := runGit(t, dir, "show", "--name-only", "--format=", "HEAD"); files != "login.go" {
+		t.Errorf("expected only login.go to be committed, got %q", files)
+	}
+	status := runGit(t, dir, "status", "--porcelain")
+	for _, want := range []string{"M README.md", "M  config.go", "?? notes.txt"} {
+		if !strings.Contains(status, want) {
+			t.Errorf("expected %q to be left as it was, got status %q", want, status)
+		}
+	}
+}
+
+func TestCommitPending_Subdirectory(t *testing.T) {
+	dir := initGitRepo(t)
+	sub := filepath.Join(dir, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	for _, name := range []string{"sub/login.go", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package sub\n"), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	if _, err := New().commitPending(context.Background(), sub, "Add login support", nil); err != nil {
+		t.Fatalf("commitPending() returned error: %v", err)
+	}
+	if files := runGit(t, dir, "show", "--name-only", "--format=", "HEAD"); files != "notes.txt\nsub/login.go" {
+		t.Errorf("expected both files to be committed, got %q", files)
+	}
+}
@@ -0,0 +1,116 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// MinClaudeVersion is the oldest Claude CLI version supporting the flags gonzo passes to it.
+const MinClaudeVersion = "1.0.0"
+
+// TestedClaudeMajorVersion is the newest major version of the Claude CLI gonzo is known to work
+// with. Runs with a newer one go on with a warning, as its flags may have changed.
+const TestedClaudeMajorVersion = 2
+
+// systemPromptVersion is the first Claude CLI version replacing its own system prompt with
+// --system-prompt in print mode. Older versions are given gonzo's system prompt with
+// --append-system-prompt instead, on top of their own.
+const systemPromptVersion = "2.0.0"
+
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)`)
+
+// parseVersion extracts the major, minor and patch numbers from a version string
+// such as "2.0.14 (Claude Code)".
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	m := versionPattern.FindStringSubmatch(v)
+	if m == nil {
+		return parts, false
+	}
+	for i := range parts {
+		parts[i], _ = strconv.Atoi(m[i+1])
+	}
+	return parts, true
+}
+
+// versionBefore reports whether the parsed version is older than the version string want.
+func versionBefore(got [3]int, want string) bool {
+	w, _ := parseVersion(want)
+	return slices.Compare(got[:], w[:]) < 0
+}
+
+// CheckClaudeVersion returns an error when the version reported by `claude --version`
+// is older than MinClaudeVersion or cannot be parsed.
+func CheckClaudeVersion(version string) error {
+	got, ok := parseVersion(version)
+	if !ok {
+		return fmt.Errorf("unrecognized Claude CLI version %q", version)
+	}
+	if versionBefore(got, MinClaudeVersion) {
+		return fmt.Errorf("Claude CLI %s is older than the minimum supported version %s", version, MinClaudeVersion)
+	}
+	return nil
+}
+
+// claudeCLI is the Claude CLI gonzo runs, and the flags its version supports.
+type claudeCLI struct {
+	// version is the version reported by `claude --version`, empty when it could not be told.
+	version string
+	// systemPrompt is set when the CLI accepts --system-prompt.
+	systemPrompt bool
+}
+
+// newClaudeCLI returns the Claude CLI of the given version. A version that cannot be parsed is
+// assumed to support the latest flags.
+func newClaudeCLI(version string) *claudeCLI {
+	cli := &claudeCLI{version: version, systemPrompt: true}
+	if got, ok := parseVersion(version); ok {
+		cli.systemPrompt = !versionBefore(got, systemPromptVersion)
+	}
+	return cli
+}
+
+// systemPromptFlag returns the flag gonzo's system prompt is passed with.
+func (c *claudeCLI) systemPromptFlag() string {
+	if c != nil && !c.systemPrompt {
+		return "--append-system-prompt"
+	}
+	return "--system-prompt"
+}
+
+// checkClaudeCLI runs `claude --version` once, before the first call to the Claude CLI, so
+// the flags passed to it suit its version. It fails when the CLI is missing or older than
+// MinClaudeVersion, and warns when the version cannot be told or is newer than the versions
+// gonzo is tested with.
+func (cc *ClaudeConfig) checkClaudeCLI(ctx context.Context) error {
+	if cc.cli != nil {
+		return nil
+	}
+	version, err := ClaudeVersion(ctx)
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%s was not found in PATH; install Claude Code with `npm install -g @anthropic-ai/claude-code`", ClaudeCodeCli)
+	}
+	got, ok := parseVersion(version)
+	switch {
+	case err != nil:
+		cc.logWarn("Could not tell the version of the Claude CLI: %v; assuming it supports the latest flags", err)
+		version = ""
+	case !ok:
+		cc.logWarn("Could not tell the version of the Claude CLI from %q; assuming it supports the latest flags", firstLine(version))
+		version = ""
+	case versionBefore(got, MinClaudeVersion):
+		return fmt.Errorf("Claude CLI %s is older than the minimum supported version %s; update it with `claude update`", version, MinClaudeVersion)
+	case got[0] > TestedClaudeMajorVersion:
+		cc.logWarn("Claude CLI %s is newer than the versions gonzo is tested with (%d.x); if calls fail, check for a gonzo update", version, TestedClaudeMajorVersion)
+	}
+	cc.cli = newClaudeCLI(version)
+	if !cc.cli.systemPrompt {
+		cc.logDebug("  Claude CLI %s predates --system-prompt; using --append-system-prompt", version)
+	}
+	return nil
+}
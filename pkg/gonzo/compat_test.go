@@ -0,0 +1,94 @@
+package gonzo
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckClaudeVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{"2.0.14 (Claude Code)", false},
+		{"1.0.0", false},
+		{"1.2.3", false},
+		{"0.2.125 (Claude Code)", true},
+		{"0.9.9", true},
+		{"unknown", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if err := CheckClaudeVersion(tt.version); (err != nil) != tt.wantErr {
+				t.Errorf("CheckClaudeVersion(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClaudeCLI_SystemPromptFlag(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"2.0.14 (Claude Code)", "--system-prompt"},
+		{"1.0.88 (Claude Code)", "--append-system-prompt"},
+		{"", "--system-prompt"},
+	}
+	for _, tt := range tests {
+		if got := newClaudeCLI(tt.version).systemPromptFlag(); got != tt.want {
+			t.Errorf("systemPromptFlag() for %q = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+	var unknown *claudeCLI
+	if got := unknown.systemPromptFlag(); got != "--system-prompt" {
+		t.Errorf("expected --system-prompt before the version is told, got %q", got)
+	}
+}
+
+func TestGenerate_ClaudeVersion(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	generate := func(version string) ([]string, string, error) {
+		var calls []string
+		mock := mockCommandContext("Done "+DefaultCompletionSignal, 0)
+		commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+			if slices.Contains(args, "--print") {
+				calls = append(calls, strings.Join(args, " "))
+			}
+			cmd := mock(ctx, name, args...)
+			cmd.Env = append(cmd.Env, "GO_HELPER_CLAUDE_VERSION="+version)
+			return cmd
+		}
+		var err error
+		output := captureStdout(t, func() {
+			_, err = New(WithDir(dir)).Generate(context.Background(), "add a login button")
+		})
+		return calls, output, err
+	}
+
+	calls, _, err := generate("0.2.125 (Claude Code)")
+	if ExitCode(err) != ExitPreflight || !strings.Contains(err.Error(), "older than the minimum supported version") || len(calls) != 0 {
+		t.Errorf("expected an old CLI to fail the preflight, got %v after %d calls", err, len(calls))
+	}
+
+	calls, _, err = generate("1.0.88 (Claude Code)")
+	if err != nil || len(calls) != 1 || !strings.Contains(calls[0], "--append-system-prompt") {
+		t.Errorf("expected the system prompt to be appended with a 1.x CLI, got %v, %q", err, calls)
+	}
+
+	calls, output, err := generate("3.1.0 (Claude Code)")
+	if err != nil || len(calls) != 1 || !strings.Contains(calls[0], " --system-prompt ") {
+		t.Errorf("expected a newer CLI to be called with --system-prompt, got %v, %q", err, calls)
+	}
+	if !strings.Contains(output, "Claude CLI 3.1.0 (Claude Code) is newer than the versions gonzo is tested with (2.x)") {
+		t.Errorf("expected a warning about the untested version, got %s", output)
+	}
+}
@@ -0,0 +1,147 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Completion detectors, telling when the task of a run is complete.
+const (
+	// CompletionMarker completes the task once Claude replies with the completion signal.
+	CompletionMarker = "marker"
+	// CompletionRegex completes the task once Claude's reply matches a pattern.
+	CompletionRegex = "regex"
+	// CompletionQuiescence completes the task once iterations in a row leave the repository as it was.
+	CompletionQuiescence = "quiescence"
+)
+
+// DefaultQuietIterations is the number of iterations in a row changing nothing after which
+// CompletionQuiescence completes the task.
+const DefaultQuietIterations = 2
+
+// RepoState is the state of the repository after an iteration, as a CompletionDetector sees it.
+type RepoState struct {
+	Dir       string
+	Iteration int
+	// StartSHA is HEAD when the iteration started and HeadSHA when it ended, both empty outside
+	// of a git repository.
+	StartSHA string
+	HeadSHA  string
+	// Uncommitted are the `git status --porcelain` lines of the changes left uncommitted, but
+	// those of GonzoDir.
+	Uncommitted []string
+}
+
+// Changed reports whether the iteration made commits or left changes.
+func (s RepoState) Changed() bool {
+	return s.HeadSHA != s.StartSHA || len(s.Uncommitted) > 0
+}
+
+// CompletionDetector tells from Claude's reply and the state of the repository whether the task
+// is complete after an iteration. The verification commands still have to pass for the run to
+// complete.
+type CompletionDetector interface {
+	Detect(output string, repo RepoState) bool
+}
+
+// MarkerDetector is the CompletionDetector of CompletionMarker.
+type MarkerDetector struct {
+	Marker string
+}
+
+// Detect reports whether output contains the marker.
+func (d MarkerDetector) Detect(output string, _ RepoState) bool {
+	return strings.Contains(output, d.Marker)
+}
+
+// RegexDetector is the CompletionDetector of CompletionRegex.
+type RegexDetector struct {
+	Pattern *regexp.Regexp
+}
+
+// Detect reports whether output matches the pattern.
+func (d RegexDetector) Detect(output string, _ RepoState) bool {
+	return d.Pattern.MatchString(output)
+}
+
+// QuiescenceDetector is the CompletionDetector of CompletionQuiescence: Claude has nothing left
+// to do once Iterations in a row neither commit nor leave changes. It never detects completion
+// outside of a git repository.
+type QuiescenceDetector struct {
+	Iterations int
+
+	quiet int
+}
+
+// Detect counts the iterations in a row that changed nothing.
+func (d *QuiescenceDetector) Detect(_ string, repo RepoState) bool {
+	if repo.HeadSHA == "" || repo.Changed() {
+		d.quiet = 0
+		return false
+	}
+	d.quiet++
+	return d.quiet >= max(d.Iterations, 1)
+}
+
+// WithCompletion selects the CompletionDetector of the runs by name, CompletionMarker by default,
+// with the pattern of CompletionRegex.
+func WithCompletion(detector string, pattern string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.completion = detector
+		cc.completionPattern = pattern
+	}
+}
+
+// WithCompletionDetector tells when the task is complete with detector instead of the one
+// selected by WithCompletion.
+func WithCompletionDetector(detector CompletionDetector) Option {
+	return func(cc *ClaudeConfig) {
+		cc.detector = detector
+	}
+}
+
+// newCompletionDetector returns the CompletionDetector of a run.
+func (cc *ClaudeConfig) newCompletionDetector() (CompletionDetector, error) {
+	if cc.detector != nil {
+		return cc.detector, nil
+	}
+	switch cc.completion {
+	case "", CompletionMarker:
+		return MarkerDetector{Marker: cc.completionSignal}, nil
+	case CompletionRegex:
+		if cc.completionPattern == "" {
+			return nil, errors.New("the regex completion detector needs a completion pattern")
+		}
+		pattern, err := regexp.Compile(cc.completionPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid completion pattern: %w", err)
+		}
+		return RegexDetector{Pattern: pattern}, nil
+	case CompletionQuiescence:
+		return &QuiescenceDetector{Iterations: DefaultQuietIterations}, nil
+	}
+	return nil, fmt.Errorf("unknown completion detector %q", cc.completion)
+}
+
+// repoState returns the state of the repository in dir after an iteration started at startSHA.
+func (cc *ClaudeConfig) repoState(ctx context.Context, dir string, iteration int, startSHA string) RepoState {
+	state := RepoState{Dir: dir, Iteration: iteration, StartSHA: startSHA}
+	if startSHA == "" {
+		return state
+	}
+	var err error
+	state.HeadSHA, err = headSHA(ctx, dir)
+	cc.swallow(err)
+	changes, _ := UncommittedChanges(ctx, dir)
+	for _, line := range changes {
+		// Gonzo records the iteration in the progress log itself
+		if len(line) > 3 && !strings.HasPrefix(filepath.ToSlash(line[3:]), GonzoDir+"/") {
+			state.Uncommitted = append(state.Uncommitted, line)
+		}
+	}
+	return state
+}
@@ -0,0 +1,86 @@
+package gonzo
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompletionDetectors(t *testing.T) {
+	marker := MarkerDetector{Marker: DefaultCompletionSignal}
+	if !marker.Detect("Done "+DefaultCompletionSignal, RepoState{}) || marker.Detect("still working", RepoState{}) {
+		t.Error("expected the marker detector to look for the marker")
+	}
+	regex := RegexDetector{Pattern: regexp.MustCompile(`(?i)all tests pass`)}
+	if !regex.Detect("All tests pass now", RepoState{}) || regex.Detect("2 tests fail", RepoState{}) {
+		t.Error("expected the regex detector to match the pattern")
+	}
+
+	quiet := RepoState{StartSHA: "abc", HeadSHA: "abc"}
+	committed := RepoState{StartSHA: "abc", HeadSHA: "def"}
+	dirty := RepoState{StartSHA: "abc", HeadSHA: "abc", Uncommitted: []string{" M main.go"}}
+	quiescence := &QuiescenceDetector{Iterations: 2}
+	var got []bool
+	for _, repo := range []RepoState{quiet, committed, quiet, dirty, quiet, quiet} {
+		got = append(got, quiescence.Detect("", repo))
+	}
+	if want := []bool{false, false, false, false, false, true}; !slices.Equal(got, want) {
+		t.Errorf("expected quiescence after two quiet iterations in a row, got %v", got)
+	}
+	if (&QuiescenceDetector{Iterations: 1}).Detect("", RepoState{}) {
+		t.Error("expected no quiescence outside of a git repository")
+	}
+}
+
+func TestNewCompletionDetector(t *testing.T) {
+	tests := []struct {
+		name    string
+		cc      *ClaudeConfig
+		wantErr string
+	}{
+		{"marker by default", New(), ""},
+		{"regex", New(WithCompletion(CompletionRegex, `done`)), ""},
+		{"regex without pattern", New(WithCompletion(CompletionRegex, "")), "needs a completion pattern"},
+		{"invalid pattern", New(WithCompletion(CompletionRegex, `(`)), "invalid completion pattern"},
+		{"unknown", New(WithCompletion("vibes", "")), "unknown completion detector"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.cc.newCompletionDetector()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerate_Completion(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	commandContext = mockCommandContext("All tests passed", 0)
+	cc := New(WithDir(t.TempDir()), WithQuiet(true), WithCompletion(CompletionRegex, `tests passed`))
+	if _, err := cc.Generate(context.Background(), "fix the tests"); err != nil {
+		t.Fatalf("expected the reply to complete the task, got %v", err)
+	}
+
+	dir := initGitRepo(t)
+	commandContext = mockCommandContext("Nothing left to change", 0)
+	cc = New(WithDir(dir), WithQuiet(true), WithMaxIterations(5), WithCompletion(CompletionQuiescence, ""))
+	if _, err := cc.Generate(context.Background(), "tidy up"); err != nil {
+		t.Fatalf("expected quiet iterations to complete the task, got %v", err)
+	}
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("LatestRunState() returned error: %v", err)
+	}
+	if run.Status != RunStatusCompleted || run.Iterations != DefaultQuietIterations {
+		t.Errorf("expected completion after %d iterations, got %s after %d", DefaultQuietIterations, run.Status, run.Iterations)
+	}
+}
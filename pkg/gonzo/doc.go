@@ -0,0 +1,15 @@
+// Package gonzo runs the gonzo loop: it calls the Claude CLI iteration after iteration on a
+// repository until the feature is implemented, its verification passes, or a limit is reached.
+//
+// This package, along with gonzotest for testing programs that use it, is the supported Go API
+// of gonzo. The other packages of the module back the gonzo command and may change without
+// notice.
+//
+// New builds a ClaudeConfig from functional options, such as WithWorkingDir, WithModel and
+// WithMaxIterations. Its Generate method runs the loop; Plan and Estimate write a plan and
+// project the cost of a run without changing the repository. Programs observe runs through
+// WithEvents, keep the progress log elsewhere with WithProgressStore, and wrap a Runner with
+// Middleware, e.g. Retry or Budget, using Chain.
+//
+// The Claude CLI must be installed and logged in for runs to call Claude.
+package gonzo
@@ -0,0 +1,96 @@
+package gonzo
+
+import (
+	"context"
+	"strings"
+)
+
+// editingTools are the tools of the Claude CLI changing files, disallowed in the planning
+// iteration of a dry run.
+var editingTools = []string{"Edit", "MultiEdit", "Write", "NotebookEdit"}
+
+// DryRunner shows what a run would do without changing the repository.
+type DryRunner interface {
+	DryRun(ctx context.Context, feature string, plan bool) (*DryRun, error)
+}
+
+var _ DryRunner = (*ClaudeConfig)(nil)
+
+// DryRun is what a run of a feature would send to Claude Code.
+type DryRun struct {
+	Model string `json:"model"`
+	// SystemPrompt and Prompt are the prompts of the first iteration, and WrapUpPrompt the prompt
+	// of the first wrap-up iteration, if any.
+	SystemPrompt string `json:"system_prompt"`
+	Prompt       string `json:"prompt"`
+	WrapUpPrompt string `json:"wrap_up_prompt,omitempty"`
+	// Args are the arguments of the Claude CLI call of the first iteration.
+	Args []string `json:"args"`
+	// Plan is the change Claude proposes in the planning iteration, when one ran.
+	Plan string `json:"plan,omitempty"`
+}
+
+// Command returns the Claude CLI call of the first iteration, quoted for a shell.
+func (d *DryRun) Command() string {
+	return formatCommand(ClaudeCodeCli, d.Args)
+}
+
+// DryRun renders the prompts and Claude CLI arguments of the first iteration of a run without
+// changing the repository: no branch, progress log or run state is created. With plan, it also
+// runs a single iteration in the plan permission mode with the editing tools disallowed, which
+// replies with the change Claude proposes.
+func (cc *ClaudeConfig) DryRun(ctx context.Context, feature string, plan bool) (*DryRun, error) {
+	return cc.session().dryRun(ctx, feature, plan)
+}
+
+// dryRun renders the run of DryRun.
+func (cc *ClaudeConfig) dryRun(ctx context.Context, feature string, plan bool) (*DryRun, error) {
+	dir, err := cc.workDir()
+	if err != nil {
+		return nil, err
+	}
+	progressFile := progressFilePath(dir)
+	if err := cc.Validate(); err != nil {
+		return nil, withExitCode(ExitPreflight, err)
+	}
+	if err := cc.checkMCPConfig(dir); err != nil {
+		return nil, withExitCode(ExitPreflight, err)
+	}
+	if cc.subagents, err = cc.loadAgents(dir); err != nil {
+		return nil, withExitCode(ExitPreflight, err)
+	}
+	// The flags of the call depend on the version of the CLI
+	if err := cc.checkClaudeCLI(ctx); err != nil {
+		return nil, withExitCode(ExitPreflight, err)
+	}
+	cc.runID = NewRunID()
+
+	cc.loadRepoMap(ctx, dir)
+	systemPrompt, _, _, err := cc.fitSystemPrompt(progressFile, feature)
+	if err != nil {
+		return nil, withExitCode(ExitPreflight, err)
+	}
+	flags, err := cc.permissions.args()
+	if err != nil {
+		return nil, withExitCode(ExitPreflight, err)
+	}
+
+	d := &DryRun{
+		Model:        cc.model,
+		SystemPrompt: systemPrompt,
+		Prompt:       feature,
+		Args:         cc.cliArgs(flags, systemPrompt, feature),
+	}
+	if cc.reservedWrapUpIterations() > 0 {
+		if d.WrapUpPrompt, err = cc.wrapUpPrompt(feature, progressFile, 1); err != nil {
+			return nil, err
+		}
+	}
+	if plan {
+		flags := []string{"--permission-mode", PermissionModePlan, "--disallowedTools", strings.Join(editingTools, ",")}
+		if d.Plan, err = cc.runPlanning(ctx, dir, flags, feature); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,107 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		if !slices.Equal(arg, []string{"--version"}) {
+			t.Fatalf("expected Claude not to be invoked, got %q", arg)
+		}
+		return mockCommandContext("", 0)(ctx, name, arg...)
+	}
+
+	d, err := New(WithDir(dir), WithQuiet(true), WithNoRepoMap(true)).DryRun(context.Background(), "add a login button", false)
+	if err != nil {
+		t.Fatalf("DryRun() returned error: %v", err)
+	}
+	if d.Prompt != "add a login button" || !strings.Contains(d.SystemPrompt, "## Your Tasks in Order") {
+		t.Errorf("expected the prompts of the first iteration, got %q and %q", d.Prompt, d.SystemPrompt)
+	}
+	if i := slices.Index(d.Args, d.SystemPrompt); i < 1 || d.Args[i-1] != "--system-prompt" || d.Args[len(d.Args)-1] != d.Prompt {
+		t.Errorf("expected the arguments to pass the prompts, got %q", d.Args)
+	}
+	if !strings.HasPrefix(d.Command(), ClaudeCodeCli+" --permission-mode acceptEdits") {
+		t.Errorf("expected the command to use the permission mode of the run, got %q", d.Command())
+	}
+	if d.Plan != "" {
+		t.Errorf("expected no plan, got %q", d.Plan)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, GonzoDir)); !os.IsNotExist(err) {
+		t.Errorf("expected the dry run not to change the repository, got %v", err)
+	}
+}
+
+func TestDryRun_ClaudeVersion(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	dryRun := func(version string) (*DryRun, error) {
+		commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+			cmd := mockCommandContext("", 0)(ctx, name, args...)
+			cmd.Env = append(cmd.Env, "GO_HELPER_CLAUDE_VERSION="+version)
+			return cmd
+		}
+		return New(WithDir(dir), WithQuiet(true), WithNoRepoMap(true)).DryRun(context.Background(), "add a login button", false)
+	}
+
+	d, err := dryRun("1.0.100 (Claude Code)")
+	if err != nil {
+		t.Fatalf("DryRun() returned error: %v", err)
+	}
+	if i := slices.Index(d.Args, d.SystemPrompt); i < 1 || d.Args[i-1] != "--append-system-prompt" {
+		t.Errorf("expected the system prompt appended for a CLI predating --system-prompt, got %q", d.Args)
+	}
+
+	if _, err := dryRun("0.2.9 (Claude Code)"); ExitCode(err) != ExitPreflight {
+		t.Errorf("expected a CLI older than the minimum version to fail the dry run with exit code %d, got %v", ExitPreflight, err)
+	}
+}
+
+func TestDryRun_Plan(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	var args []string
+	mock := mockCommandContext("## Summary\nAdd the button.\n", 0)
+	commandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		args = arg
+		return mock(ctx, name, arg...)
+	}
+
+	d, err := New(WithDir(dir), WithQuiet(true), WithNoRepoMap(true)).DryRun(context.Background(), "add a login button", true)
+	if err != nil {
+		t.Fatalf("DryRun() returned error: %v", err)
+	}
+	if d.Plan != "## Summary\nAdd the button.\n" {
+		t.Errorf("unexpected plan %q", d.Plan)
+	}
+	joined := strings.Join(args, " ")
+	if !strings.HasPrefix(joined, "--permission-mode plan --disallowedTools Edit,MultiEdit,Write,NotebookEdit --print") {
+		t.Errorf("expected a read-only invocation, got %q", joined)
+	}
+
+	if _, err := os.Stat(PlanFilePath(dir)); !os.IsNotExist(err) {
+		t.Errorf("expected the plan not to be written, got %v", err)
+	}
+	if _, err := LatestRunState(dir); err == nil {
+		t.Error("expected the dry run not to record a run")
+	}
+}
@@ -0,0 +1,133 @@
+package gonzo
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// sendMail is a variable that wraps smtp.SendMail for testing.
+var sendMail = smtp.SendMail
+
+// defaultSMTPPort is the port of SMTP servers given without one.
+const defaultSMTPPort = "25"
+
+// EmailOptions selects the recipients of notification emails and the SMTP server they are sent
+// through. Emails are sent when both To and SMTP are set.
+type EmailOptions struct {
+	To   []string
+	From string
+	// SMTP is the address of the server, host:port, the port defaulting to 25.
+	SMTP string
+	// Username authenticates to the server, with the smtp-password secret, when set.
+	Username string
+}
+
+// enabled reports whether notification emails are sent.
+func (o EmailOptions) enabled() bool {
+	return len(o.To) > 0 && o.SMTP != ""
+}
+
+// sendNotificationEmail emails the notification of an event of the run with its report, the
+// markdown summary of the run, attached.
+func (cc *ClaudeConfig) sendNotificationEmail(ctx context.Context, dir string, run *RunState, message string) error {
+	options := cc.notifications.Email
+	addr := options.SMTP
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, defaultSMTPPort)
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	from := options.From
+	if from == "" {
+		from = "gonzo@" + host
+	}
+
+	var commits []string
+	if run.StartSHA != "" {
+		commits, _ = commitSubjects(ctx, dir, run.StartSHA)
+	}
+	report, err := renderCISummary(cc.promptSet(), ciSummaryData{Run: run, MaxIterations: cc.maxIterations, Commits: commits})
+	if err != nil {
+		return err
+	}
+	msg, err := notificationEmail(from, options.To, message, "gonzo-run-"+run.ID+".md", report)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if options.Username != "" {
+		password, _ := Secret(ctx, SecretSMTPPassword)
+		auth = smtp.PlainAuth("", options.Username, password, host)
+	}
+	if err := sendMail(addr, auth, from, options.To, msg); err != nil {
+		return fmt.Errorf("failed to send email through %s: %w", addr, err)
+	}
+	return nil
+}
+
+// notificationEmail returns a MIME message with the notification as its body, its first line
+// as its subject, and the report attached as a markdown file.
+func notificationEmail(from string, to []string, message string, reportName string, report string) ([]byte, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	text, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeBase64(text, message+"\n"); err != nil {
+		return nil, err
+	}
+
+	attachment, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType("text/markdown", map[string]string{"charset": "utf-8", "name": reportName})},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": reportName})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeBase64(attachment, report); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	subject := strings.TrimSpace(strings.ReplaceAll(firstLine(message), "\r", ""))
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", w.Boundary())
+	b.Write(body.Bytes())
+	return b.Bytes(), nil
+}
+
+// writeBase64 writes s base64-encoded, in lines of 76 characters as MIME requires.
+func writeBase64(w io.Writer, s string) error {
+	encoded := base64.StdEncoding.EncodeToString([]byte(s))
+	for len(encoded) > 0 {
+		n := min(76, len(encoded))
+		if _, err := fmt.Fprintf(w, "%s\r\n", encoded[:n]); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
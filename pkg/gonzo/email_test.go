@@ -0,0 +1,79 @@
+package gonzo
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+func TestGenerate_NotificationEmail(t *testing.T) {
+	// Save originals and restore after test
+	originalCommandContext := commandContext
+	originalSendMail := sendMail
+	defer func() {
+		commandContext = originalCommandContext
+		sendMail = originalSendMail
+	}()
+
+	var addr, from string
+	var to []string
+	var msg []byte
+	sendMail = func(a string, auth smtp.Auth, f string, t []string, m []byte) error {
+		addr, from, to, msg = a, f, t, m
+		return nil
+	}
+	dir := t.TempDir()
+	commandContext = mockCommandContext("Done "+DefaultCompletionSignal, 0)
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithNotifications(Notifications{
+		Email:  EmailOptions{To: []string{"team@example.com"}, SMTP: "smtp.example.com"},
+		Events: DefaultNotifyEvents,
+	})
+	if _, err := cc.Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if addr != "smtp.example.com:25" || from != "gonzo@smtp.example.com" || len(to) != 1 || to[0] != "team@example.com" {
+		t.Fatalf("unexpected email envelope: %s from %s to %v", addr, from, to)
+	}
+	m, err := mail.ReadMessage(strings.NewReader(string(msg)))
+	if err != nil {
+		t.Fatalf("failed to parse email: %v", err)
+	}
+	if subject := m.Header.Get("Subject"); !strings.Contains(subject, "completed after 1 iteration(s): add a login button") {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	_, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+	r := multipart.NewReader(m.Body, params["boundary"])
+	var parts []string
+	var report string
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read email part: %v", err)
+		}
+		data, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		parts = append(parts, part.FileName())
+		if part.FileName() != "" {
+			report = string(data)
+		}
+	}
+	if len(parts) != 2 || !strings.HasPrefix(parts[1], "gonzo-run-") || !strings.HasSuffix(parts[1], ".md") {
+		t.Fatalf("expected a body and a markdown attachment, got %q", parts)
+	}
+	if !strings.Contains(report, "| Status | completed |") || !strings.Contains(report, "add a login button") {
+		t.Errorf("unexpected report %q", report)
+	}
+}
@@ -0,0 +1,120 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultEnvDeny are the variables of gonzo's environment kept from the Claude CLI unless
+// configured otherwise: the secrets gonzo reads for itself, sorted. The Anthropic API key is
+// passed, as the CLI authenticates with it.
+var DefaultEnvDeny = defaultEnvDeny()
+
+// defaultEnvDeny returns the variables of SecretEnv but ANTHROPIC_API_KEY, sorted.
+func defaultEnvDeny() []string {
+	var deny []string
+	for _, envs := range SecretEnv {
+		for _, env := range envs {
+			if env != "ANTHROPIC_API_KEY" {
+				deny = append(deny, env)
+			}
+		}
+	}
+	slices.Sort(deny)
+	return deny
+}
+
+// essentialEnv are the variables the Claude CLI inherits whatever Env.Allow lists, as it needs
+// them to run and to authenticate. Env.Deny still applies to them.
+var essentialEnv = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "TMPDIR", "TZ", "LANG", "LC_*", "ANTHROPIC_*", "CLAUDE_*"}
+
+// Env controls the environment of the Claude CLI, rather than having it inherit gonzo's as is.
+type Env struct {
+	// Allow lists the variables of gonzo's environment the CLI inherits, as names or globs such
+	// as "AWS_*", on top of essentialEnv. All of them are inherited when it is empty.
+	Allow []string
+	// Deny lists the variables of gonzo's environment kept from the CLI, as names or globs such
+	// as "*_TOKEN", whether allowed or not. DefaultEnvDeny when nil.
+	Deny []string
+	// Set lists NAME=value variables set in the environment of the CLI, overriding inherited
+	// ones. $VAR and ${VAR} in values expand to gonzo's environment.
+	Set []string
+}
+
+// WithEnv controls the environment of the Claude CLI.
+func WithEnv(env Env) Option {
+	return func(cc *ClaudeConfig) {
+		cc.env = env
+	}
+}
+
+// WithEnv applies the WithEnv option to cc.
+//
+// Deprecated: Pass WithEnv to New instead.
+func (cc *ClaudeConfig) WithEnv(env Env) *ClaudeConfig {
+	WithEnv(env)(cc)
+	return cc
+}
+
+// deny returns the variables kept from the CLI, DefaultEnvDeny when Deny is not set.
+func (e Env) deny() []string {
+	if e.Deny == nil {
+		return DefaultEnvDeny
+	}
+	return e.Deny
+}
+
+// inherits reports whether the CLI inherits the variable of gonzo's environment.
+func (e Env) inherits(name string) bool {
+	if matchEnv(e.deny(), name) {
+		return false
+	}
+	return len(e.Allow) == 0 || matchEnv(e.Allow, name) || matchEnv(essentialEnv, name)
+}
+
+// matchEnv reports whether the variable name matches one of the names or globs.
+func matchEnv(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	})
+}
+
+// environ returns the environment of the CLI from base, the environment it would inherit, and
+// the names of the variables of base it does not inherit.
+func (e Env) environ(base []string) (env []string, withheld []string) {
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		if e.inherits(name) {
+			env = append(env, kv)
+		} else {
+			withheld = append(withheld, name)
+		}
+	}
+	for _, kv := range e.Set {
+		name, value, _ := strings.Cut(kv, "=")
+		// The last value of a variable wins
+		env = append(env, name+"="+os.ExpandEnv(value))
+	}
+	return env, withheld
+}
+
+// claudeEnviron returns the environment the Claude CLI runs with, from base, the environment
+// it would inherit: the variables it inherits and the ones set for it, the token limits, and
+// the API key from the keyring when none is set and it is not denied.
+func (cc *ClaudeConfig) claudeEnviron(ctx context.Context, base []string) []string {
+	env, withheld := cc.env.environ(base)
+	env = append(env, cc.tokenLimits.environ()...)
+	if len(withheld) > 0 {
+		cc.logDebug("  Environment: withheld %s", strings.Join(withheld, ", "))
+	}
+	if !matchEnv(cc.env.deny(), "ANTHROPIC_API_KEY") && !slices.ContainsFunc(cc.env.Set, func(kv string) bool {
+		return strings.HasPrefix(kv, "ANTHROPIC_API_KEY=")
+	}) {
+		env = append(env, cc.claudeEnv(ctx)...)
+	}
+	return env
+}
@@ -0,0 +1,86 @@
+package gonzo
+
+import (
+	"context"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"os/exec"
+	"slices"
+	"testing"
+)
+
+func TestEnv_Environ(t *testing.T) {
+	t.Setenv("GONZO_TEST_PROXY", "http://proxy:3128")
+	base := []string{"PATH=/usr/bin", "HOME=/home/dev", "ANTHROPIC_API_KEY=sk-env", "GITHUB_TOKEN=ghp_secret", "AWS_PROFILE=dev", "GONZO_WEBHOOK_URL=https://hooks.example.com", "EDITOR=vim"}
+
+	tests := []struct {
+		name         string
+		env          Env
+		want         []string
+		wantWithheld []string
+	}{
+		{"default", Env{}, []string{"PATH=/usr/bin", "HOME=/home/dev", "ANTHROPIC_API_KEY=sk-env", "AWS_PROFILE=dev", "EDITOR=vim"}, []string{"GITHUB_TOKEN", "GONZO_WEBHOOK_URL"}},
+		{"deny", Env{Deny: []string{"*_TOKEN"}}, []string{"PATH=/usr/bin", "HOME=/home/dev", "ANTHROPIC_API_KEY=sk-env", "AWS_PROFILE=dev", "GONZO_WEBHOOK_URL=https://hooks.example.com", "EDITOR=vim"}, []string{"GITHUB_TOKEN"}},
+		{"allow", Env{Allow: []string{"AWS_*"}}, []string{"PATH=/usr/bin", "HOME=/home/dev", "ANTHROPIC_API_KEY=sk-env", "AWS_PROFILE=dev"}, []string{"GITHUB_TOKEN", "GONZO_WEBHOOK_URL", "EDITOR"}},
+		{"set", Env{Allow: []string{"NONE"}, Deny: []string{"ANTHROPIC_API_KEY"}, Set: []string{"HTTPS_PROXY=$GONZO_TEST_PROXY", "HOME=/tmp"}},
+			[]string{"PATH=/usr/bin", "HOME=/home/dev", "HTTPS_PROXY=http://proxy:3128", "HOME=/tmp"}, []string{"ANTHROPIC_API_KEY", "GITHUB_TOKEN", "AWS_PROFILE", "GONZO_WEBHOOK_URL", "EDITOR"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, withheld := tt.env.environ(base)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("environ() = %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(withheld, tt.wantWithheld) {
+				t.Errorf("withheld %q, want %q", withheld, tt.wantWithheld)
+			}
+		})
+	}
+}
+
+func TestDefaultEnvDeny(t *testing.T) {
+	want := []string{"GH_TOKEN", "GITHUB_TOKEN", "GITLAB_TOKEN", "GONZO_SMTP_PASSWORD", "GONZO_WEBHOOK_URL"}
+	if !slices.Equal(DefaultEnvDeny, want) {
+		t.Errorf("DefaultEnvDeny = %q, want %q", DefaultEnvDeny, want)
+	}
+	if !slices.Equal(config.DefaultEnvDeny, DefaultEnvDeny) {
+		t.Errorf("config.DefaultEnvDeny = %q, want %q", config.DefaultEnvDeny, DefaultEnvDeny)
+	}
+}
+
+func TestExecClaudeCLI_Env(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var cmd *exec.Cmd
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		cmd = mockCommandContext("ok", 0)(ctx, name, args...)
+		cmd.Env = append(cmd.Env, "GITHUB_TOKEN=ghp_secret")
+		return cmd
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	withKeyring(t, map[string]string{SecretAnthropicAPIKey: "sk-keyring"})
+
+	cc := New().WithEnv(Env{Deny: []string{"GITHUB_TOKEN"}, Set: []string{"HTTPS_PROXY=http://proxy:3128"}})
+	if _, _, err := cc.callClaudeCLI(context.Background(), "system", "prompt", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if slices.Contains(cmd.Env, "GITHUB_TOKEN=ghp_secret") {
+		t.Errorf("expected GITHUB_TOKEN to be withheld, got %v", cmd.Env)
+	}
+	for _, want := range []string{"HTTPS_PROXY=http://proxy:3128", "ANTHROPIC_API_KEY=sk-keyring", "GO_WANT_HELPER_PROCESS=1"} {
+		if !slices.Contains(cmd.Env, want) {
+			t.Errorf("expected %s in the environment, got %v", want, cmd.Env)
+		}
+	}
+
+	// A denied API key is not read from the keyring either
+	cc = New().WithEnv(Env{Deny: []string{"ANTHROPIC_*"}})
+	if _, _, err := cc.callClaudeCLI(context.Background(), "system", "prompt", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if slices.Contains(cmd.Env, "ANTHROPIC_API_KEY=sk-keyring") {
+		t.Errorf("expected the denied API key to be left out, got %v", cmd.Env)
+	}
+}
@@ -0,0 +1,125 @@
+package gonzo
+
+import (
+	"context"
+	"time"
+)
+
+// ModelPricing is the price of a model in US dollars per million tokens.
+type ModelPricing struct {
+	Input  float64
+	Output float64
+}
+
+// Pricing lists the list prices of the supported models.
+var Pricing = map[string]ModelPricing{
+	ClaudeHaiku:  {Input: 1, Output: 5},
+	ClaudeSonnet: {Input: 3, Output: 15},
+	ClaudeOpus:   {Input: 5, Output: 25},
+}
+
+// Assumed usage of a single iteration on top of gonzo's own prompts: Claude Code's instructions
+// and tool definitions, the files it reads and the output of the commands it runs. Agent
+// sessions vary widely with the task and repository, hence the wide ranges.
+const (
+	iterationInputTokensLow   = 30_000
+	iterationInputTokensHigh  = 300_000
+	iterationOutputTokensLow  = 2_000
+	iterationOutputTokensHigh = 20_000
+	iterationDurationLow      = time.Minute
+	iterationDurationHigh     = 10 * time.Minute
+)
+
+// Estimator projects the cost and duration of a run without running it.
+type Estimator interface {
+	Estimate(ctx context.Context, feature string) (*Estimate, error)
+}
+
+var _ Estimator = (*ClaudeConfig)(nil)
+
+// Estimate is the projected range of a run's cost and duration, from completing in a single
+// light iteration to using every iteration heavily.
+type Estimate struct {
+	Model            string
+	MinIterations    int
+	MaxIterations    int
+	WrapUpIterations int
+	// SystemPromptTokens, FeatureTokens and WrapUpPromptTokens approximate the size of gonzo's
+	// rendered prompts; the wrap-up prompt replaces the feature in wrap-up iterations.
+	SystemPromptTokens int
+	FeatureTokens      int
+	WrapUpPromptTokens int
+	// PromptBudget is the budget of the system prompt and feature in tokens, and Truncated the
+	// sections of the system prompt truncated to fit it.
+	PromptBudget int
+	Truncated    []string
+	// Pricing is nil when the model's prices are unknown, leaving the cost at zero.
+	Pricing     *ModelPricing
+	MinCost     float64
+	MaxCost     float64
+	MinDuration time.Duration
+	MaxDuration time.Duration
+}
+
+// EstimateTokens approximates the number of tokens in text at about four characters per token.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// Estimate renders the prompts a run would use and projects its cost and duration for the
+// configured model and iterations, without invoking Claude Code.
+func (cc *ClaudeConfig) Estimate(ctx context.Context, feature string) (*Estimate, error) {
+	return cc.session().estimate(ctx, feature)
+}
+
+// estimate projects the cost and duration of Estimate.
+func (cc *ClaudeConfig) estimate(ctx context.Context, feature string) (*Estimate, error) {
+	dir, err := cc.workDir()
+	if err != nil {
+		return nil, err
+	}
+	progressFile := progressFilePath(dir)
+
+	cc.loadRepoMap(ctx, dir)
+	systemPrompt, truncated, _, err := cc.fitSystemPrompt(progressFile, feature)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Estimate{
+		Model:              cc.model,
+		MinIterations:      1,
+		MaxIterations:      cc.maxIterations,
+		WrapUpIterations:   cc.reservedWrapUpIterations(),
+		SystemPromptTokens: EstimateTokens(systemPrompt),
+		FeatureTokens:      EstimateTokens(feature),
+		PromptBudget:       cc.maxPromptTokens(),
+		Truncated:          truncated,
+	}
+	if e.WrapUpIterations > 0 {
+		wrapUp, err := cc.wrapUpPrompt(feature, progressFile, 1)
+		if err != nil {
+			return nil, err
+		}
+		e.WrapUpPromptTokens = EstimateTokens(wrapUp)
+	}
+
+	workIterations := e.MaxIterations - e.WrapUpIterations
+	minPrompt := e.SystemPromptTokens + e.FeatureTokens
+	maxPrompt := workIterations*(e.SystemPromptTokens+e.FeatureTokens) +
+		e.WrapUpIterations*(e.SystemPromptTokens+e.WrapUpPromptTokens)
+
+	if pricing, ok := Pricing[cc.model]; ok {
+		e.Pricing = &pricing
+		e.MinCost = pricing.cost(minPrompt+iterationInputTokensLow, iterationOutputTokensLow)
+		e.MaxCost = pricing.cost(maxPrompt+e.MaxIterations*iterationInputTokensHigh, e.MaxIterations*iterationOutputTokensHigh)
+	}
+	e.MinDuration = iterationDurationLow
+	e.MaxDuration = time.Duration(e.MaxIterations) * iterationDurationHigh
+	return e, nil
+}
+
+// cost returns the price in US dollars of the given token usage.
+func (p ModelPricing) cost(inputTokens int, outputTokens int) float64 {
+	return (float64(inputTokens)*p.Input + float64(outputTokens)*p.Output) / 1_000_000
+}
@@ -0,0 +1,49 @@
+package gonzo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	for text, want := range map[string]int{"": 0, "abc": 1, "abcd": 1, "abcde": 2} {
+		if got := EstimateTokens(text); got != want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	dir := t.TempDir()
+
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+	commandContext = mockCommandContext("", 1)
+
+	e, err := New(WithDir(dir)).WithModel(ClaudeSonnet).WithMaxIterations(5).WithWrapUpIterations(1).Estimate(context.Background(), "add a login button")
+	if err != nil {
+		t.Fatalf("Estimate() returned error: %v", err)
+	}
+
+	if e.MinIterations != 1 || e.MaxIterations != 5 || e.WrapUpIterations != 1 {
+		t.Errorf("unexpected iterations %+v", e)
+	}
+	if e.SystemPromptTokens == 0 || e.WrapUpPromptTokens == 0 || e.FeatureTokens != EstimateTokens("add a login button") {
+		t.Errorf("expected rendered prompt sizes, got %+v", e)
+	}
+	if e.Pricing == nil || e.MinCost <= 0 || e.MaxCost <= e.MinCost {
+		t.Errorf("expected a cost range, got %+v", e)
+	}
+	if e.MinDuration != iterationDurationLow || e.MaxDuration != 5*iterationDurationHigh {
+		t.Errorf("unexpected duration range %s to %s", e.MinDuration, e.MaxDuration)
+	}
+
+	e, err = New(WithDir(dir)).WithModel("claude-unknown").Estimate(context.Background(), "add a login button")
+	if err != nil {
+		t.Fatalf("Estimate() returned error: %v", err)
+	}
+	if e.Pricing != nil || e.MaxCost != 0 {
+		t.Errorf("expected no cost for a model without pricing, got %+v", e)
+	}
+}
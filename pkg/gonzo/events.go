@@ -0,0 +1,240 @@
+package gonzo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EventType identifies what happened in an Event.
+type EventType string
+
+// Event types reported while a run progresses.
+const (
+	EventRunStart       EventType = "run-start"
+	EventIterationStart EventType = "iteration-start"
+	EventIterationEnd   EventType = "iteration-end"
+	EventLog            EventType = "log"
+	EventPRApproval     EventType = "pr-approval"
+	EventRunEnd         EventType = "run-end"
+)
+
+// Event reports the progress of a run to a front end such as `gonzo tui`.
+// Only the fields relevant to its Type are set.
+type Event struct {
+	Type          EventType
+	Time          time.Time
+	RunID         string
+	Iteration     int
+	MaxIterations int
+	// Output is Claude's response, on EventIterationEnd.
+	Output string
+	// DiffStat summarizes the changes made since the run started, on EventIterationEnd.
+	DiffStat string
+	// Message is the log line on EventLog, or the pull request title on EventPRApproval.
+	Message string
+	// Status is the final run status, on EventRunEnd.
+	Status string
+	// CostUSD is the cost of the run so far, when the Claude CLI reported it.
+	CostUSD float64
+}
+
+// Observer is implemented by runners that report their progress as events and can be steered
+// through a Control while they run. Either argument may be nil.
+type Observer interface {
+	Observe(handler func(Event), control *Control)
+}
+
+// Observe reports the progress of the next runs to handler and lets control steer them.
+func (cc *ClaudeConfig) Observe(handler func(Event), control *Control) {
+	cc.events = handler
+	cc.control = control
+}
+
+// Events is notified as Generate progresses, for programs embedding gonzo that observe the
+// loop without parsing its output. Its methods are called on the goroutine of the run, which
+// waits for them to return.
+type Events interface {
+	// OnRunStart is called once the run is recorded, before its first iteration.
+	OnRunStart(e Event)
+	// OnIterationStart is called before each iteration calls Claude.
+	OnIterationStart(e Event)
+	// OnIterationEnd is called after each iteration, with Claude's response and the changes so far.
+	OnIterationEnd(e Event)
+	// OnCompletion is called when the run ends, with its final status in Event.Status.
+	OnCompletion(e Event)
+	// OnError is called when Generate fails, after OnCompletion when the run had started.
+	OnError(err error)
+}
+
+// WithEvents notifies events of the progress of every run.
+func WithEvents(events Events) Option {
+	return func(cc *ClaudeConfig) {
+		cc.listener = events
+	}
+}
+
+// emit reports an event to the handler and the listener, if any.
+func (cc *ClaudeConfig) emit(e Event) {
+	if cc.events == nil && cc.listener == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if cc.events != nil {
+		cc.events(e)
+	}
+	if cc.listener == nil {
+		return
+	}
+	switch e.Type {
+	case EventRunStart:
+		cc.listener.OnRunStart(e)
+	case EventIterationStart:
+		cc.listener.OnIterationStart(e)
+	case EventIterationEnd:
+		cc.listener.OnIterationEnd(e)
+	case EventRunEnd:
+		cc.listener.OnCompletion(e)
+	}
+}
+
+// Control steers a run in progress from another goroutine: it pauses the run between
+// iterations, stops it after the current iteration, extends its iteration budget and
+// approves the pull request. A nil Control steers nothing.
+type Control struct {
+	// RequirePRApproval makes the run wait for Approve before opening the pull request.
+	RequirePRApproval bool
+
+	mu       sync.Mutex
+	paused   bool
+	resumed  chan struct{}
+	stop     bool
+	extend   int
+	approval chan bool
+}
+
+// NewControl returns a Control for a run.
+func NewControl() *Control {
+	return &Control{approval: make(chan bool, 1)}
+}
+
+// Pause holds the run before its next iteration until Resume is called.
+func (c *Control) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		c.paused = true
+		c.resumed = make(chan struct{})
+	}
+}
+
+// Resume lets a paused run carry on.
+func (c *Control) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		c.paused = false
+		close(c.resumed)
+	}
+}
+
+// Paused reports whether the run is held before its next iteration.
+func (c *Control) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// Stop asks the run to stop after its current iteration, as `gonzo abort` does.
+func (c *Control) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stop = true
+}
+
+// Extend adds n iterations to the run's budget.
+func (c *Control) Extend(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.extend += n
+}
+
+// Approve answers the pending pull request approval, if any.
+func (c *Control) Approve(approved bool) {
+	select {
+	case c.approval <- approved:
+	default:
+	}
+}
+
+// waitIfPaused blocks while the run is paused.
+func (c *Control) waitIfPaused(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	paused, resumed := c.paused, c.resumed
+	c.mu.Unlock()
+	if !paused {
+		return nil
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stopRequested reports whether Stop was called.
+func (c *Control) stopRequested() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stop
+}
+
+// takeExtension returns the iterations added since the last call.
+func (c *Control) takeExtension() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.extend
+	c.extend = 0
+	return n
+}
+
+// needsApproval reports whether the pull request must be approved before it is opened.
+func (c *Control) needsApproval() bool {
+	return c != nil && c.RequirePRApproval
+}
+
+// awaitApproval waits for Approve, declining when ctx is done.
+func (c *Control) awaitApproval(ctx context.Context) bool {
+	select {
+	case approved := <-c.approval:
+		return approved
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// emitIterationEnd reports the end of an iteration with the changes made so far.
+func (cc *ClaudeConfig) emitIterationEnd(run *RunState, iteration int, maxIterations int, output string, diffStat string) {
+	cc.emit(Event{Type: EventIterationEnd, RunID: run.ID, Iteration: iteration, MaxIterations: maxIterations, Output: output, CostUSD: run.CostUSD, DiffStat: diffStat})
+}
+
+// approvePullRequest asks the front end to approve the pull request when the Control requires it.
+func (cc *ClaudeConfig) approvePullRequest(ctx context.Context, run *RunState) bool {
+	if !cc.control.needsApproval() {
+		return true
+	}
+	cc.emit(Event{Type: EventPRApproval, RunID: run.ID, Message: firstLine(run.Feature)})
+	return cc.control.awaitApproval(ctx)
+}
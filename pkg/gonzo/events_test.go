@@ -0,0 +1,175 @@
+package gonzo
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventRecorder collects the events of a run.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *eventRecorder) handle(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *eventRecorder) types() []EventType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []EventType
+	for _, e := range r.events {
+		if e.Type != EventLog {
+			types = append(types, e.Type)
+		}
+	}
+	return types
+}
+
+func (r *eventRecorder) logged(msg string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.ContainsFunc(r.events, func(e Event) bool { return e.Type == EventLog && e.Message == msg })
+}
+
+func TestGenerate_Events(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("still working", 0)
+
+	rec := &eventRecorder{}
+	control := NewControl()
+	cc := New(WithDir(dir)).WithQuiet(true).WithMaxIterations(1).WithWrapUpIterations(0)
+	cc.Observe(func(e Event) {
+		rec.handle(e)
+		// Add an iteration from "the UI" while the first one runs
+		if e.Type == EventIterationStart && e.Iteration == 1 {
+			control.Extend(1)
+		}
+	}, control)
+
+	if _, err := cc.Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []EventType{EventRunStart, EventIterationStart, EventIterationEnd, EventIterationStart, EventIterationEnd, EventRunEnd}
+	if got := rec.types(); !slices.Equal(got, want) {
+		t.Errorf("expected events %v, got %v", want, got)
+	}
+	if !rec.logged("Extended the run to 2 iterations") {
+		t.Error("expected the extension to be logged")
+	}
+	last := rec.events[len(rec.events)-1]
+	if last.Status != RunStatusIncomplete || last.Iteration != 2 {
+		t.Errorf("unexpected run end %+v", last)
+	}
+}
+
+// listenerRecorder records the calls of an Events listener.
+type listenerRecorder struct {
+	calls []string
+	err   error
+	end   Event
+}
+
+func (r *listenerRecorder) OnRunStart(e Event)       { r.calls = append(r.calls, "run-start") }
+func (r *listenerRecorder) OnIterationStart(e Event) { r.calls = append(r.calls, "iteration-start") }
+func (r *listenerRecorder) OnIterationEnd(e Event)   { r.calls = append(r.calls, "iteration-end") }
+func (r *listenerRecorder) OnCompletion(e Event) {
+	r.calls = append(r.calls, "completion")
+	r.end = e
+}
+func (r *listenerRecorder) OnError(err error) {
+	r.calls = append(r.calls, "error")
+	r.err = err
+}
+
+func TestGenerate_Listener(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("", 0)
+
+	rec := &listenerRecorder{}
+	cc := New(WithWorkingDir(dir), WithQuiet(true), WithMaxIterations(2), WithWrapUpIterations(0), WithEvents(rec))
+	_, err := cc.Generate(context.Background(), "add a login button")
+	if err == nil {
+		t.Fatal("expected the run to be incomplete")
+	}
+
+	want := []string{"run-start", "iteration-start", "iteration-end", "iteration-start", "iteration-end", "completion", "error"}
+	if !slices.Equal(rec.calls, want) {
+		t.Errorf("expected calls %v, got %v", want, rec.calls)
+	}
+	if rec.end.Status != RunStatusIncomplete || rec.end.Iteration != 2 {
+		t.Errorf("unexpected completion %+v", rec.end)
+	}
+	if rec.err != err {
+		t.Errorf("expected the error of Generate, got %v", rec.err)
+	}
+}
+
+func TestControl_Pause(t *testing.T) {
+	c := NewControl()
+	c.Pause()
+	if !c.Paused() {
+		t.Fatal("expected the control to be paused")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.waitIfPaused(ctx); err == nil {
+		t.Error("expected to wait until the context is done")
+	}
+
+	go c.Resume()
+	if err := c.waitIfPaused(context.Background()); err != nil {
+		t.Errorf("expected the run to resume, got %v", err)
+	}
+
+	var nilControl *Control
+	if err := nilControl.waitIfPaused(context.Background()); err != nil || nilControl.stopRequested() || nilControl.takeExtension() != 0 {
+		t.Error("expected a nil control to steer nothing")
+	}
+}
+
+func TestGenerate_PRApprovalDeclined(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := initGitRepo(t)
+	commandContext = mockCommandContext("Done "+DefaultCompletionSignal, 0)
+
+	rec := &eventRecorder{}
+	control := NewControl()
+	control.RequirePRApproval = true
+	cc := New(WithDir(dir)).WithQuiet(true).WithPR(true)
+	cc.Observe(func(e Event) {
+		rec.handle(e)
+		if e.Type == EventPRApproval {
+			control.Approve(false)
+		}
+	}, control)
+
+	if _, err := cc.Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Contains(rec.types(), EventPRApproval) {
+		t.Error("expected the pull request to be submitted for approval")
+	}
+	if !rec.logged("Skipped pull request: not approved") {
+		t.Error("expected the declined pull request to be skipped")
+	}
+}
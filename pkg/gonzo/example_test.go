@@ -0,0 +1,58 @@
+package gonzo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"github.com/andybarilla/gonzo/pkg/gonzo/gonzotest"
+	"log"
+	"time"
+)
+
+// Run the loop on a repository, without creating a branch, and print Claude's final response.
+// The run needs the Claude CLI, so the example is not run by go test.
+func ExampleClaudeConfig_Generate() {
+	cc := gonzo.New(
+		gonzo.WithWorkingDir("/path/to/repo"),
+		gonzo.WithModel(gonzo.ClaudeSonnet),
+		gonzo.WithMaxIterations(5),
+		gonzo.WithNoBranch(true),
+	)
+
+	response, err := cc.Generate(context.Background(), "Add a --verbose flag to the CLI")
+	if err != nil {
+		log.Fatalf("run failed with exit code %d: %v", gonzo.ExitCode(err), err)
+	}
+	fmt.Println(response)
+}
+
+func ExampleClaudeConfig_Validate() {
+	cc := gonzo.New(gonzo.WithMaxIterations(0), gonzo.WithRetries(-1))
+
+	fmt.Println(cc.Validate())
+	// Output:
+	// max iterations must be at least 1, got 0
+	// retries must not be negative, got -1
+}
+
+// Retry failed runs and count them, here around a fake runner failing once.
+func ExampleChain() {
+	calls := 0
+	runner := &gonzotest.FakeRunner{GenerateFunc: func(ctx context.Context, feature string) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", errors.New("claude failed")
+		}
+		return "Done: " + feature, nil
+	}}
+	var metrics gonzo.Metrics
+	chain := gonzo.Chain(runner, metrics.Middleware, gonzo.Retry(2, time.Millisecond))
+
+	response, err := chain.Generate(context.Background(), "Fix the typo")
+	fmt.Println(response, err)
+	fmt.Printf("%d run, %d calls\n", metrics.Snapshot().Runs, calls)
+	// Output:
+	// Done: Fix the typo <nil>
+	// 1 run, 2 calls
+}
@@ -0,0 +1,88 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Exit codes of gonzo, by outcome of the run, so that scripts and CI can tell them apart.
+const (
+	// ExitSuccess is the exit code of a run that completed.
+	ExitSuccess = 0
+	// ExitFailure is the exit code of any other failure.
+	ExitFailure = 1
+	// ExitIncomplete is the exit code of a run that used up its iterations without completing.
+	ExitIncomplete = 2
+	// ExitCLIFailure is the exit code of a run stopped by a failed call to the Claude CLI.
+	ExitCLIFailure = 3
+	// ExitBudgetExceeded is the exit code of a run stopped by its max duration.
+	ExitBudgetExceeded = 4
+	// ExitAborted is the exit code of a run stopped by the user.
+	ExitAborted = 5
+	// ExitPreflight is the exit code of a run that failed before its first iteration, e.g. for
+	// missing forge credentials or a broken prompt template.
+	ExitPreflight = 6
+)
+
+// Reasons a run stopped without completing, recorded in RunState.StopReason.
+const (
+	StopReasonMaxIterations = "max-iterations"
+	StopReasonMaxDuration   = "max-duration"
+	StopReasonMaxCost       = "max-cost"
+)
+
+// ExitError is an error along with the exit code gonzo exits with because of it.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// withExitCode returns err with the given exit code, or nil when err is nil.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// ExitCode returns the code gonzo exits with for err: the code of an *ExitError, ExitAborted
+// when the run was interrupted, ExitFailure for any other error and ExitSuccess for none.
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+		return ExitSuccess
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.Is(err, context.Canceled):
+		return ExitAborted
+	}
+	return ExitFailure
+}
+
+// RunOutcome returns an *ExitError for a run that finished without error but did not complete:
+// it used up its iterations, its max duration or its max cost, or was aborted. It returns nil otherwise.
+func RunOutcome(run *RunState) error {
+	switch run.Status {
+	case RunStatusIncomplete:
+		switch run.StopReason {
+		case StopReasonMaxDuration:
+			return &ExitError{Code: ExitBudgetExceeded, Err: fmt.Errorf("run %s reached its max duration after %d iteration(s) without completing", run.ID, run.Iterations)}
+		case StopReasonMaxCost:
+			return &ExitError{Code: ExitBudgetExceeded, Err: fmt.Errorf("run %s reached its max cost after %d iteration(s) without completing", run.ID, run.Iterations)}
+		}
+		return &ExitError{Code: ExitIncomplete, Err: fmt.Errorf("run %s reached its max iterations (%d) without completing", run.ID, run.Iterations)}
+	case RunStatusAborted:
+		return &ExitError{Code: ExitAborted, Err: fmt.Errorf("run %s was aborted after %d iteration(s)", run.ID, run.Iterations)}
+	}
+	return nil
+}
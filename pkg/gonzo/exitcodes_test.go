@@ -0,0 +1,63 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, ExitSuccess},
+		{"other error", errors.New("boom"), ExitFailure},
+		{"exit error", withExitCode(ExitCLIFailure, errors.New("claude: not found")), ExitCLIFailure},
+		{"wrapped exit error", fmt.Errorf("run failed: %w", withExitCode(ExitPreflight, errors.New("no token"))), ExitPreflight},
+		{"interrupted", fmt.Errorf("Claude CLI call failed: %w", context.Canceled), ExitAborted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunOutcome(t *testing.T) {
+	tests := []struct {
+		name string
+		run  RunState
+		want int
+	}{
+		{"completed", RunState{Status: RunStatusCompleted}, ExitSuccess},
+		{"max iterations", RunState{Status: RunStatusIncomplete, StopReason: StopReasonMaxIterations}, ExitIncomplete},
+		{"max duration", RunState{Status: RunStatusIncomplete, StopReason: StopReasonMaxDuration}, ExitBudgetExceeded},
+		{"aborted", RunState{Status: RunStatusAborted}, ExitAborted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(RunOutcome(&tt.run)); got != tt.want {
+				t.Errorf("expected exit code %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGenerate_CLIFailureExitCode(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("", 1)
+
+	_, err := New(WithDir(dir)).WithQuiet(true).Generate(context.Background(), "test prompt")
+	if code := ExitCode(err); code != ExitCLIFailure {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitCLIFailure, code, err)
+	}
+}
@@ -0,0 +1,74 @@
+package gonzo
+
+import (
+	"context"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"github.com/andybarilla/gonzo/pkg/github"
+	"github.com/andybarilla/gonzo/pkg/gitlab"
+	"github.com/andybarilla/gonzo/pkg/keyring"
+	"strings"
+)
+
+// TokenSourceKeyring is the source reported by Secret and ForgeToken for secrets stored in the keyring.
+const TokenSourceKeyring = "keyring"
+
+// newForge creates the client for the forge hosting the repository in dir. Replaceable for testing.
+var newForge = func(ctx context.Context, dir string, name string) forge.Forge {
+	name = ResolveForge(ctx, dir, name)
+	token, _ := ForgeToken(ctx, name)
+	return NewForgeClient(dir, name, token)
+}
+
+// lookupKeyring reads a secret from the system keyring. Replaceable for testing.
+var lookupKeyring = keyring.Get
+
+// OpenForge returns the client for the forge hosting the repository in dir. An empty name or
+// forge.Auto selects the forge from the origin remote URL.
+func OpenForge(ctx context.Context, dir string, name string) forge.Forge {
+	return newForge(ctx, dir, name)
+}
+
+// NewForgeClient returns the client for the named forge authenticating with token, or with the
+// forge CLI's own login when token is empty. Unknown names get the GitHub client.
+func NewForgeClient(dir string, name string, token string) forge.Forge {
+	switch name {
+	case forge.GitLab:
+		return &gitlab.Client{Dir: dir, Token: token}
+	default:
+		return &github.Client{Dir: dir, Token: token}
+	}
+}
+
+// ResolveForge returns name, or the forge detected from the origin remote URL when name
+// is empty or forge.Auto.
+func ResolveForge(ctx context.Context, dir string, name string) string {
+	if name != "" && name != forge.Auto {
+		return name
+	}
+	url, _ := remoteURL(ctx, dir, "origin")
+	return forge.Detect(url)
+}
+
+// ForgeToken returns the access token for the forge and where it came from: the system keyring,
+// where `gonzo auth login` stores it, or one of the forge's token environment variables.
+// Both are empty when there is no token, leaving authentication to the forge CLI's own login.
+func ForgeToken(ctx context.Context, name string) (token string, source string) {
+	return Secret(ctx, name)
+}
+
+// checkForgeAuth verifies that pull requests can be opened before the run starts, so a missing
+// or expired token does not surface only after all iterations are done. Repositories without an
+// origin remote are not checked; opening the pull request reports that instead.
+func (cc *ClaudeConfig) checkForgeAuth(ctx context.Context, dir string) error {
+	if _, err := remoteURL(ctx, dir, "origin"); err != nil {
+		return nil
+	}
+	f := newForge(ctx, dir, cc.forge)
+	if err := f.AuthStatus(ctx); err != nil {
+		return fmt.Errorf("not authenticated with %s, which is needed to open the pull request: %w\n"+
+			"Run `gonzo auth login`, set %s, or disable pull requests with --pr=false",
+			f.Name(), err, strings.Join(forge.TokenEnv[f.Name()], " or "))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"github.com/andybarilla/gonzo/pkg/keyring"
+	"strings"
+	"testing"
+)
+
+// withKeyring replaces the keyring lookup with the given secrets for the duration of the test.
+func withKeyring(t *testing.T, secrets map[string]string) {
+	t.Helper()
+	original := lookupKeyring
+	lookupKeyring = func(ctx context.Context, account string) (string, error) {
+		if secret, ok := secrets[account]; ok {
+			return secret, nil
+		}
+		return "", keyring.ErrNotFound
+	}
+	t.Cleanup(func() { lookupKeyring = original })
+}
+
+func TestForgeToken(t *testing.T) {
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "from-env")
+	t.Setenv("GITLAB_TOKEN", "")
+
+	withKeyring(t, map[string]string{forge.GitLab: "from-keyring"})
+
+	if token, source := ForgeToken(context.Background(), forge.GitLab); token != "from-keyring" || source != TokenSourceKeyring {
+		t.Errorf("expected the keyring token, got %q from %q", token, source)
+	}
+	if token, source := ForgeToken(context.Background(), forge.GitHub); token != "from-env" || source != "GITHUB_TOKEN" {
+		t.Errorf("expected the environment token, got %q from %q", token, source)
+	}
+
+	withKeyring(t, nil)
+	if token, source := ForgeToken(context.Background(), forge.GitLab); token != "" || source != "" {
+		t.Errorf("expected no token, got %q from %q", token, source)
+	}
+}
+
+func TestCheckForgeAuth(t *testing.T) {
+	dir := initGitRepo(t)
+	fake := &fakeForge{authErr: errors.New("gh auth status: exit status 1")}
+	withFakeForge(t, fake)
+
+	// Without an origin remote there is nothing to check
+	if err := New().checkForgeAuth(context.Background(), dir); err != nil {
+		t.Errorf("expected no check without an origin remote, got %v", err)
+	}
+
+	addOrigin(t, dir)
+	err := New().checkForgeAuth(context.Background(), dir)
+	if err == nil {
+		t.Fatal("expected an error when the forge is not authenticated")
+	}
+	for _, want := range []string{"github", "gonzo auth login", "GH_TOKEN", "--pr=false"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got %q", want, err)
+		}
+	}
+
+	fake.authErr = nil
+	if err := New().checkForgeAuth(context.Background(), dir); err != nil {
+		t.Errorf("expected no error when authenticated, got %v", err)
+	}
+}
+
+func TestGenerate_ForgeAuthPreflight(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := initGitRepo(t)
+	addOrigin(t, dir)
+	withFakeForge(t, &fakeForge{authErr: errors.New("not logged in")})
+	commandContext = mockCommandContext("mocked response", 0)
+
+	if _, err := New(WithDir(dir)).WithQuiet(true).WithPR(true).Generate(context.Background(), "add a feature"); err == nil {
+		t.Fatal("expected the run to fail before starting")
+	}
+	if _, err := LatestRunState(dir); err == nil {
+		t.Error("expected no run to be started")
+	}
+}
@@ -0,0 +1,75 @@
+package gonzo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// frontMatterDelimiter opens and closes the front matter of a feature file.
+const frontMatterDelimiter = "---"
+
+// FrontMatter is the configuration a feature file may start with, between two --- lines:
+//
+//	---
+//	model: claude-sonnet-4-5
+//	max-iterations: 5
+//	branch-name: feature/login
+//	playbook: feature
+//	---
+//	Add a login button to the header
+//
+// It overrides every other configuration source for the run of that feature.
+// When BranchName is set, the run happens on that branch instead of one named by the agent.
+type FrontMatter struct {
+	Model         string `yaml:"model,omitempty"`
+	MaxIterations int    `yaml:"max-iterations,omitempty"`
+	BranchName    string `yaml:"branch-name,omitempty"`
+	Playbook      string `yaml:"playbook,omitempty"`
+}
+
+// ParseFrontMatter splits the content of a feature file into its front matter and the feature.
+// The front matter is nil when the content does not start with one.
+func ParseFrontMatter(content string) (*FrontMatter, string, error) {
+	front, body, ok, err := splitFrontMatter(content)
+	if err != nil || !ok {
+		return nil, body, err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(front)))
+	decoder.KnownFields(true)
+
+	var fm FrontMatter
+	if err := decoder.Decode(&fm); err != nil && !errors.Is(err, io.EOF) {
+		return nil, "", fmt.Errorf("failed to parse front matter: %w", err)
+	}
+	if fm.MaxIterations < 0 {
+		return nil, "", errors.New("front matter has a negative max-iterations")
+	}
+	return &fm, body, nil
+}
+
+// splitFrontMatter splits content into the YAML of the front matter it starts with, between two
+// --- lines, and the trimmed rest. ok is false, and body the content, when it has none.
+func splitFrontMatter(content string) (front string, body string, ok bool, err error) {
+	lines := strings.Split(content, "\n")
+	if strings.TrimSpace(lines[0]) != frontMatterDelimiter {
+		return "", content, false, nil
+	}
+
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == frontMatterDelimiter {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return "", "", false, errors.New("front matter is not closed by a --- line")
+	}
+	return strings.Join(lines[1:end], "\n"), strings.TrimSpace(strings.Join(lines[end+1:], "\n")), true, nil
+}
@@ -0,0 +1,41 @@
+package gonzo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	fm, feature, err := ParseFrontMatter("---\nmodel: claude-sonnet-4-5\nmax-iterations: 3\nbranch-name: feature/login\nplaybook: feature\n---\n\nAdd a login button\n")
+	if err != nil {
+		t.Fatalf("ParseFrontMatter() returned error: %v", err)
+	}
+	want := FrontMatter{Model: "claude-sonnet-4-5", MaxIterations: 3, BranchName: "feature/login", Playbook: "feature"}
+	if fm == nil || *fm != want {
+		t.Errorf("expected front matter %+v, got %+v", want, fm)
+	}
+	if feature != "Add a login button" {
+		t.Errorf("expected the feature without its front matter, got %q", feature)
+	}
+
+	fm, feature, err = ParseFrontMatter("Add a login button\n---\nmodel: x\n---")
+	if err != nil || fm != nil || feature != "Add a login button\n---\nmodel: x\n---" {
+		t.Errorf("expected a feature without front matter to be left as is, got %+v, %q, %v", fm, feature, err)
+	}
+
+	fm, feature, err = ParseFrontMatter("---\n---\nAdd a login button")
+	if err != nil || fm == nil || *fm != (FrontMatter{}) || feature != "Add a login button" {
+		t.Errorf("expected an empty front matter, got %+v, %q, %v", fm, feature, err)
+	}
+
+	tests := map[string]string{
+		"---\nmodel: x\nAdd a login button":         "not closed",
+		"---\nmodle: x\n---\nAdd a login button":    "field modle not found",
+		"---\nmax-iterations: -1\n---\nAdd a login": "negative max-iterations",
+	}
+	for content, want := range tests {
+		if _, _, err := ParseFrontMatter(content); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseFrontMatter(%q) error = %v, want %q", content, err, want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package gonzo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// gitCommandContext is a variable that wraps exec.CommandContext for git calls so tests can replace it.
+var gitCommandContext = exec.CommandContext
+
+// git runs a git subcommand in dir and returns its trimmed stdout.
+// On failure the returned error includes git's stderr.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := gitCommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+		}
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, msg)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// headSHA returns the commit SHA currently checked out in dir.
+func headSHA(ctx context.Context, dir string) (string, error) {
+	return git(ctx, dir, "rev-parse", "HEAD")
+}
+
+// currentBranch returns the name of the branch checked out in dir,
+// or an empty string when HEAD is detached.
+func currentBranch(ctx context.Context, dir string) (string, error) {
+	branch, err := git(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if branch == "HEAD" {
+		return "", nil
+	}
+	return branch, nil
+}
+
+// remoteURL returns the URL of the named remote.
+func remoteURL(ctx context.Context, dir string, remote string) (string, error) {
+	return git(ctx, dir, "remote", "get-url", remote)
+}
+
+// pushBranch pushes branch to origin and sets it as the upstream.
+func pushBranch(ctx context.Context, dir string, branch string) error {
+	_, err := git(ctx, dir, "push", "--set-upstream", "origin", branch)
+	return err
+}
+
+// changedFiles returns the files changed between from and HEAD.
+func changedFiles(ctx context.Context, dir string, from string) ([]string, error) {
+	out, err := git(ctx, dir, "diff", "--name-only", from, "HEAD")
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// commitSubjects returns the subjects of the commits made since from, oldest first.
+func commitSubjects(ctx context.Context, dir string, from string) ([]string, error) {
+	out, err := git(ctx, dir, "log", "--reverse", "--format=%s", from+"..HEAD")
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// diffSince returns the changes in the working tree of dir, committed or not, relative to the given commit.
+func diffSince(ctx context.Context, dir string, sha string) (string, error) {
+	return git(ctx, dir, "diff", sha)
+}
+
+// UncommittedChanges returns the `git status --porcelain` lines for the working tree in dir.
+// It fails when dir is not inside a git repository.
+func UncommittedChanges(ctx context.Context, dir string) ([]string, error) {
+	out, err := git(ctx, dir, "status", "--porcelain")
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// repoRoot returns the top-level directory of the repository dir is in, where the paths relative
+// to the repository resolve.
+func repoRoot(ctx context.Context, dir string) (string, error) {
+	return git(ctx, dir, "rev-parse", "--show-toplevel")
+}
+
+// dirtyPaths returns the slash-separated paths, relative to the repository, of the files of dir
+// with uncommitted changes, untracked ones included.
+func dirtyPaths(ctx context.Context, dir string) (map[string]bool, error) {
+	// The second version of the format starts each entry with its type, which trimming keeps
+	out, err := git(ctx, dir, "status", "--porcelain=v2", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	dirty := map[string]bool{}
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		switch {
+		case strings.HasPrefix(entry, "1 "):
+			dirty[strings.SplitN(entry, " ", 9)[8]] = true
+		case strings.HasPrefix(entry, "2 "):
+			dirty[strings.SplitN(entry, " ", 10)[9]] = true
+			// A rename or copy is followed by the path it came from
+			if i++; i < len(entries) {
+				dirty[entries[i]] = true
+			}
+		case strings.HasPrefix(entry, "u "):
+			dirty[strings.SplitN(entry, " ", 11)[10]] = true
+		case strings.HasPrefix(entry, "? "):
+			dirty[entry[2:]] = true
+		}
+	}
+	return dirty, nil
+}
+
+// diffStat summarizes the changes in the working tree of dir, committed or not, relative to the given commit,
+// e.g. "3 files changed, 10 insertions(+), 2 deletions(-)".
+func diffStat(ctx context.Context, dir string, sha string) (string, error) {
+	return git(ctx, dir, "diff", "--shortstat", sha)
+}
@@ -0,0 +1,148 @@
+package gonzotest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeCLIVersion is the version the fake Claude CLI reports, one gonzo supports.
+const fakeCLIVersion = "2.0.14 (Claude Code)"
+
+// fakeCLIScript is the fake Claude CLI. It answers --version, records the arguments of every
+// other call and replays the scripted reply of the call, the last one once they run out.
+const fakeCLIScript = `#!/bin/sh
+dir='%s'
+if [ "$1" = "--version" ]; then
+	echo '%s'
+	exit 0
+fi
+n=$(($(cat "$dir/count") + 1))
+echo "$n" > "$dir/count"
+printf '%%s\0' "$@" > "$dir/call-$n.args"
+reply=$n
+[ -f "$dir/reply-$reply.code" ] || reply=%d
+cat "$dir/reply-$reply.out"
+cat "$dir/reply-$reply.err" >&2
+exit "$(cat "$dir/reply-$reply.code")"
+`
+
+// Reply is what the fake Claude CLI answers a call with.
+type Reply struct {
+	// Output is written to stdout, Claude's response.
+	Output string
+	// Stderr is written to stderr, such as the error of a failed call.
+	Stderr string
+	// ExitCode is the exit status of the call.
+	ExitCode int
+}
+
+// Replies returns replies with the outputs, one per call.
+func Replies(outputs ...string) []Reply {
+	replies := make([]Reply, len(outputs))
+	for i, output := range outputs {
+		replies[i] = Reply{Output: output}
+	}
+	return replies
+}
+
+// FakeCLI is a scripted Claude CLI, installed as claude ahead of the PATH of a test so that a
+// real run of gonzo calls it instead of Claude. It answers each call, one per iteration, with the
+// next of its replies.
+type FakeCLI struct {
+	dir string
+}
+
+// NewFakeCLI installs a fake Claude CLI answering with replies for the duration of the test.
+// As it changes the PATH, the test must not be parallel. It needs a POSIX shell, and skips the
+// test on Windows.
+func NewFakeCLI(t testing.TB, replies ...Reply) *FakeCLI {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake Claude CLI needs a POSIX shell")
+	}
+	if len(replies) == 0 {
+		replies = []Reply{{}}
+	}
+
+	dir := t.TempDir()
+	script := fmt.Sprintf(fakeCLIScript, strings.ReplaceAll(dir, "'", `'\''`), fakeCLIVersion, len(replies))
+	files := map[string]string{
+		"claude": script,
+		"count":  "0\n",
+	}
+	for i, r := range replies {
+		files[fmt.Sprintf("reply-%d.out", i+1)] = r.Output
+		files[fmt.Sprintf("reply-%d.err", i+1)] = r.Stderr
+		files[fmt.Sprintf("reply-%d.code", i+1)] = strconv.Itoa(r.ExitCode)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o755); err != nil {
+			t.Fatalf("failed to write the fake Claude CLI: %v", err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return &FakeCLI{dir: dir}
+}
+
+// Call is a call of the fake Claude CLI.
+type Call struct {
+	// Args are the arguments gonzo passed to the CLI.
+	Args []string
+}
+
+// Prompt returns the prompt of the call, its last argument.
+func (c Call) Prompt() string {
+	if len(c.Args) == 0 {
+		return ""
+	}
+	return c.Args[len(c.Args)-1]
+}
+
+// SystemPrompt returns the system prompt gonzo appended to Claude's, or passed instead of it.
+func (c Call) SystemPrompt() string {
+	for i, arg := range c.Args[:max(len(c.Args)-1, 0)] {
+		if arg == "--append-system-prompt" || arg == "--system-prompt" {
+			return c.Args[i+1]
+		}
+	}
+	return ""
+}
+
+// Calls returns the calls of the CLI so far, in order, without those asking for its version.
+func (f *FakeCLI) Calls() []Call {
+	var calls []Call
+	for n := 1; ; n++ {
+		data, err := os.ReadFile(filepath.Join(f.dir, fmt.Sprintf("call-%d.args", n)))
+		if err != nil {
+			return calls
+		}
+		args := strings.Split(string(bytes.TrimSuffix(data, []byte{0})), "\x00")
+		calls = append(calls, Call{Args: args})
+	}
+}
+
+// AssertCalls fails the test unless the CLI was called n times.
+func (f *FakeCLI) AssertCalls(t testing.TB, n int) {
+	t.Helper()
+	if got := len(f.Calls()); got != n {
+		t.Errorf("expected %d calls of the Claude CLI, got %d", n, got)
+	}
+}
+
+// AssertPromptContains fails the test unless the prompt of call n, counting from 1 like the
+// iterations, contains each of want.
+func (f *FakeCLI) AssertPromptContains(t testing.TB, n int, want ...string) {
+	t.Helper()
+	calls := f.Calls()
+	if n < 1 || n > len(calls) {
+		t.Errorf("expected call %d of the Claude CLI, got %d calls", n, len(calls))
+		return
+	}
+	AssertContains(t, calls[n-1].Prompt(), want...)
+}
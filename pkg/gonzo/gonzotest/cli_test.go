@@ -0,0 +1,41 @@
+package gonzotest
+
+import (
+	"context"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"strings"
+	"testing"
+)
+
+func TestFakeCLI_Generate(t *testing.T) {
+	cli := NewFakeCLI(t, Replies("still working", "Done "+gonzo.DefaultCompletionSignal)...)
+
+	cc := gonzo.New(gonzo.WithDir(t.TempDir()), gonzo.WithQuiet(true), gonzo.WithMaxIterations(3), gonzo.WithNoBranch(true))
+	out, err := cc.Generate(context.Background(), "add a login button")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out, "Done") {
+		t.Errorf("expected the second reply, got %q", out)
+	}
+
+	cli.AssertCalls(t, 2)
+	cli.AssertPromptContains(t, 1, "add a login button")
+	calls := cli.Calls()
+	if calls[0].SystemPrompt() == "" {
+		t.Errorf("expected a system prompt, got args %q", calls[0].Args)
+	}
+}
+
+func TestFakeCLI_Failure(t *testing.T) {
+	cli := NewFakeCLI(t, Reply{Stderr: "Invalid API key", ExitCode: 1})
+
+	cc := gonzo.New(gonzo.WithDir(t.TempDir()), gonzo.WithQuiet(true), gonzo.WithMaxIterations(1), gonzo.WithNoBranch(true))
+	_, err := cc.Generate(context.Background(), "add a login button")
+	if err == nil || !strings.Contains(err.Error(), "Invalid API key") {
+		t.Errorf("expected the failure of the CLI, got %v", err)
+	}
+	if len(cli.Calls()) == 0 {
+		t.Error("expected the CLI to be called")
+	}
+}
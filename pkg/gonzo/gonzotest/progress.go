@@ -0,0 +1,62 @@
+package gonzotest
+
+import (
+	"context"
+	"errors"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"sync"
+)
+
+// MemoryProgressStore is a gonzo.ProgressStore keeping the progress log in memory, so that a
+// test can tell what a run recorded without reading the files of the repository.
+type MemoryProgressStore struct {
+	mu       sync.Mutex
+	progress *gonzo.Progress
+}
+
+var _ gonzo.ProgressStore = (*MemoryProgressStore)(nil)
+
+// EnsureExists keeps a copy of initial unless the log exists.
+func (s *MemoryProgressStore) EnsureExists(ctx context.Context, initial *gonzo.Progress) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.progress == nil {
+		p := *initial
+		p.Entries = append([]gonzo.ProgressEntry(nil), initial.Entries...)
+		s.progress = &p
+	}
+	return nil
+}
+
+// Append adds the record to the log.
+func (s *MemoryProgressStore) Append(ctx context.Context, entry gonzo.ProgressEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.progress == nil {
+		return errors.New("progress log does not exist")
+	}
+	s.progress.Entries = append(s.progress.Entries, entry)
+	return nil
+}
+
+// Read returns a copy of the log.
+func (s *MemoryProgressStore) Read(ctx context.Context) (*gonzo.Progress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.progress == nil {
+		return nil, errors.New("progress log does not exist")
+	}
+	p := *s.progress
+	p.Entries = append([]gonzo.ProgressEntry(nil), s.progress.Entries...)
+	return &p, nil
+}
+
+// Compact drops the records but the last keep ones.
+func (s *MemoryProgressStore) Compact(ctx context.Context, keep int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.progress != nil && len(s.progress.Entries) > keep {
+		s.progress.Entries = s.progress.Entries[len(s.progress.Entries)-max(keep, 0):]
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package gonzotest
+
+import (
+	"context"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"testing"
+)
+
+func TestMemoryProgressStore_Generate(t *testing.T) {
+	NewFakeCLI(t, Replies("Added the button", "Done "+gonzo.DefaultCompletionSignal)...)
+
+	store := &MemoryProgressStore{}
+	cc := gonzo.New(gonzo.WithDir(t.TempDir()), gonzo.WithQuiet(true), gonzo.WithProgressStore(store))
+	if _, err := cc.Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p, err := store.Read(context.Background())
+	if err != nil {
+		t.Fatalf("Read() returned error: %v", err)
+	}
+	if len(p.Entries) != 2 || p.Entries[0].Summary != "Added the button" {
+		t.Fatalf("expected both iterations to be recorded, got %+v", p.Entries)
+	}
+	if err := store.Compact(context.Background(), 1); err != nil {
+		t.Fatalf("Compact() returned error: %v", err)
+	}
+	if p, _ := store.Read(context.Background()); len(p.Entries) != 1 || p.Entries[0].Iteration != 2 {
+		t.Errorf("expected the last iteration only, got %+v", p.Entries)
+	}
+}
@@ -0,0 +1,99 @@
+// Package gonzotest provides fakes for testing programs that embed gonzo: a FakeRunner standing
+// in for a whole run, a FakeCLI standing in for the Claude CLI under a real run, a
+// MemoryProgressStore keeping its progress log, and helpers asserting the prompts they were given.
+package gonzotest
+
+import (
+	"context"
+	"github.com/andybarilla/gonzo/pkg/gonzo"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// FakeRunner is a gonzo.Runner and gonzo.Planner that records the prompts it is given and
+// answers with canned responses, without calling Claude.
+type FakeRunner struct {
+	// Responses are returned by successive calls, the last one repeated once they run out.
+	Responses []string
+	// Err is returned by every call, along with the response.
+	Err error
+	// GenerateFunc, when set, answers the calls instead of Responses and Err.
+	GenerateFunc func(ctx context.Context, feature string) (string, error)
+
+	mu      sync.Mutex
+	prompts []string
+}
+
+var (
+	_ gonzo.Runner  = (*FakeRunner)(nil)
+	_ gonzo.Planner = (*FakeRunner)(nil)
+)
+
+// Generate records the feature and returns the next response.
+func (r *FakeRunner) Generate(ctx context.Context, feature string) (string, error) {
+	r.mu.Lock()
+	r.prompts = append(r.prompts, feature)
+	n := len(r.prompts)
+	r.mu.Unlock()
+
+	if r.GenerateFunc != nil {
+		return r.GenerateFunc(ctx, feature)
+	}
+	if len(r.Responses) == 0 {
+		return "", r.Err
+	}
+	return r.Responses[min(n, len(r.Responses))-1], r.Err
+}
+
+// Plan answers like Generate.
+func (r *FakeRunner) Plan(ctx context.Context, feature string) (string, error) {
+	return r.Generate(ctx, feature)
+}
+
+// Prompts returns the features the runner was given, in order.
+func (r *FakeRunner) Prompts() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.prompts...)
+}
+
+// AssertCalls fails the test unless the runner was called n times.
+func (r *FakeRunner) AssertCalls(t testing.TB, n int) {
+	t.Helper()
+	if got := len(r.Prompts()); got != n {
+		t.Errorf("expected %d calls of the runner, got %d", n, got)
+	}
+}
+
+// AssertPromptContains fails the test unless the last prompt the runner was given contains each
+// of want.
+func (r *FakeRunner) AssertPromptContains(t testing.TB, want ...string) {
+	t.Helper()
+	prompts := r.Prompts()
+	if len(prompts) == 0 {
+		t.Errorf("expected the runner to be called")
+		return
+	}
+	AssertContains(t, prompts[len(prompts)-1], want...)
+}
+
+// AssertContains fails the test unless prompt contains each of want.
+func AssertContains(t testing.TB, prompt string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(prompt, w) {
+			t.Errorf("expected the prompt to contain %q, got:\n%s", w, prompt)
+		}
+	}
+}
+
+// AssertNotContains fails the test if prompt contains any of unwanted.
+func AssertNotContains(t testing.TB, prompt string, unwanted ...string) {
+	t.Helper()
+	for _, u := range unwanted {
+		if strings.Contains(prompt, u) {
+			t.Errorf("expected the prompt not to contain %q, got:\n%s", u, prompt)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package gonzotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFakeRunner(t *testing.T) {
+	r := &FakeRunner{Responses: []string{"first", "second"}}
+	var got []string
+	for _, feature := range []string{"add a login button", "add a logout button", "add a profile page"} {
+		out, err := r.Generate(context.Background(), feature)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, out)
+	}
+	if want := []string{"first", "second", "second"}; !slices.Equal(got, want) {
+		t.Errorf("expected responses %v, got %v", want, got)
+	}
+	r.AssertCalls(t, 3)
+	r.AssertPromptContains(t, "profile page")
+	AssertNotContains(t, r.Prompts()[0], "logout")
+
+	failing := &FakeRunner{Err: errors.New("boom")}
+	if _, err := failing.Plan(context.Background(), "add a login button"); err == nil || err.Error() != "boom" {
+		t.Errorf("expected the canned error, got %v", err)
+	}
+
+	custom := &FakeRunner{GenerateFunc: func(ctx context.Context, feature string) (string, error) {
+		return "done: " + feature, nil
+	}}
+	if out, _ := custom.Generate(context.Background(), "x"); out != "done: x" {
+		t.Errorf("expected GenerateFunc to answer, got %q", out)
+	}
+}
@@ -0,0 +1,150 @@
+package gonzo
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Guards stop an iteration as soon as Claude does what the run does not allow, from the events
+// the Claude CLI streams while it works, rather than once the iteration is over.
+type Guards struct {
+	// ProtectedPaths are globs of the files Claude must not change, relative to the repository,
+	// e.g. ".github/**" or "go.sum". A glob without a slash matches the file name in any
+	// directory. Only the file editing tools are checked, not the commands Claude runs.
+	ProtectedPaths []string
+	// MaxCost is the cost in US dollars the run may reach, zero for no limit. It is estimated
+	// from the usage of the messages of an iteration at the list prices of the model, until the
+	// Claude CLI reports the cost of the iteration.
+	MaxCost float64
+}
+
+// WithGuards sets the guards checked while Claude works.
+func WithGuards(guards Guards) Option {
+	return func(cc *ClaudeConfig) {
+		cc.guards = guards
+	}
+}
+
+// WithGuards applies the WithGuards option to cc.
+//
+// Deprecated: Pass WithGuards to New instead.
+func (cc *ClaudeConfig) WithGuards(guards Guards) *ClaudeConfig {
+	WithGuards(guards)(cc)
+	return cc
+}
+
+// errMaxCost reports that the run reached its max cost.
+var errMaxCost = errors.New("max cost reached")
+
+// ProtectedPathError reports Claude changing a file matching a protected path.
+type ProtectedPathError struct {
+	Tool    string
+	Path    string
+	Pattern string
+}
+
+func (e *ProtectedPathError) Error() string {
+	return fmt.Sprintf("%s of protected path %s (matches %s)", e.Tool, e.Path, e.Pattern)
+}
+
+// isGuardError reports whether err was returned by a guard, so the call is not retried.
+func isGuardError(err error) bool {
+	var protected *ProtectedPathError
+	return errors.Is(err, errMaxCost) || errors.As(err, &protected)
+}
+
+// fileEditTools are the tools of Claude Code changing the file they are given.
+var fileEditTools = []string{"Edit", "MultiEdit", "Write", "NotebookEdit"}
+
+// iterationGuard checks the tools Claude uses and the usage of its messages during an iteration
+// against the guards of the run.
+type iterationGuard struct {
+	guards Guards
+	// spent is the cost of the run before the iteration.
+	spent float64
+	// pricing is the list price of the model, nil when unknown.
+	pricing *ModelPricing
+}
+
+// newIterationGuard returns the guard of the next iteration of run, or nil when no guard is set.
+func (cc *ClaudeConfig) newIterationGuard(run *RunState) *iterationGuard {
+	if len(cc.guards.ProtectedPaths) == 0 && cc.guards.MaxCost <= 0 {
+		return nil
+	}
+	g := &iterationGuard{guards: cc.guards, spent: run.CostUSD}
+	if p, ok := Pricing[cc.model]; ok {
+		g.pricing = &p
+	}
+	return g
+}
+
+// checkToolUse returns a *ProtectedPathError when the tool changes a protected path.
+func (g *iterationGuard) checkToolUse(t toolUse) error {
+	if t.Path == "" || !slices.Contains(fileEditTools, t.Name) {
+		return nil
+	}
+	for _, pattern := range g.guards.ProtectedPaths {
+		if matchPath(pattern, t.Path) {
+			return &ProtectedPathError{Tool: t.Name, Path: t.Path, Pattern: pattern}
+		}
+	}
+	return nil
+}
+
+// checkUsage returns errMaxCost when the usage of the iteration so far brings the run to its max
+// cost.
+func (g *iterationGuard) checkUsage(u Usage) error {
+	if g.guards.MaxCost <= 0 || g.pricing == nil {
+		return nil
+	}
+	if g.spent+g.pricing.usageCost(u) >= g.guards.MaxCost {
+		return errMaxCost
+	}
+	return nil
+}
+
+// usageCost estimates the cost of the usage, with writes to the prompt cache at 1.25 times the
+// input price and reads from it at a tenth of it.
+func (p ModelPricing) usageCost(u Usage) float64 {
+	input := float64(u.InputTokens) + 1.25*float64(u.CacheCreationInputTokens) + 0.1*float64(u.CacheReadInputTokens)
+	return (input*p.Input + float64(u.OutputTokens)*p.Output) / 1_000_000
+}
+
+// matchPath reports whether the slash-separated path, relative to the repository, matches the
+// glob. "**" matches any number of directories, and a glob without a slash matches the file
+// name in any directory.
+func matchPath(pattern string, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(name); i >= 0; i-- {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,104 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"testing"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"go.sum", "go.sum", true},
+		{"go.sum", "tools/go.sum", true},
+		{"*.lock", "web/yarn.lock", true},
+		{".github/**", ".github/workflows/ci.yml", true},
+		{".github/", ".github/CODEOWNERS", true},
+		{".github/**", "docs/.github/ci.yml", false},
+		{"**/testdata/*.json", "pkg/api/testdata/user.json", true},
+		{"**/testdata/*.json", "testdata/user.json", true},
+		{"pkg/*/api.go", "pkg/users/api.go", true},
+		{"pkg/*/api.go", "pkg/users/v2/api.go", false},
+		{"./migrations/*.sql", "migrations/001_init.sql", true},
+	}
+	for _, tt := range tests {
+		if got := matchPath(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIterationGuard(t *testing.T) {
+	g := New().WithGuards(Guards{ProtectedPaths: []string{"go.sum"}, MaxCost: 1}).newIterationGuard(&RunState{CostUSD: 0.9})
+	var protected *ProtectedPathError
+	if err := g.checkToolUse(toolUse{Name: "Write", Path: "go.sum"}); !errors.As(err, &protected) {
+		t.Errorf("expected writing go.sum to be refused, got %v", err)
+	}
+	if err := g.checkToolUse(toolUse{Name: "Read", Path: "go.sum"}); err != nil {
+		t.Errorf("expected reading go.sum to be allowed, got %v", err)
+	}
+	// $0.05 of input and $0.025 of output at the prices of Claude Opus
+	if err := g.checkUsage(Usage{InputTokens: 10_000, OutputTokens: 1_000}); err != nil {
+		t.Errorf("expected $0.975 to be within the max cost, got %v", err)
+	}
+	if err := g.checkUsage(Usage{InputTokens: 10_000, OutputTokens: 5_000}); !errors.Is(err, errMaxCost) {
+		t.Errorf("expected $1.075 to reach the max cost, got %v", err)
+	}
+
+	if New().newIterationGuard(&RunState{}) != nil {
+		t.Error("expected no guard without protected paths or max cost")
+	}
+}
+
+func TestGenerate_Guards(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	output := `{"type":"assistant","message":{"id":"msg_1","content":[{"type":"tool_use","name":"Edit","input":{"file_path":"go.sum"}}],"usage":{"input_tokens":1000,"output_tokens":100}}}
+{"type":"result","result":"Done","total_cost_usd":0.0075}
+`
+	calls := 0
+	mock := mockCommandContext(output, 0)
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if slices.Contains(args, "--print") {
+			calls++
+		}
+		return mock(ctx, name, args...)
+	}
+
+	_, err := New(WithDir(dir)).WithQuiet(true).WithRetries(2).WithGuards(Guards{ProtectedPaths: []string{"go.sum"}}).Generate(context.Background(), "add a login button")
+	var protected *ProtectedPathError
+	if !errors.As(err, &protected) || protected.Path != "go.sum" {
+		t.Errorf("expected the edit of go.sum to fail the run, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected the call not to be retried, got %d calls", calls)
+	}
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("LatestRunState() returned error: %v", err)
+	}
+	if run.Status != RunStatusFailed {
+		t.Errorf("expected the run to fail, got %s", run.Status)
+	}
+
+	// $0.0075 at the prices of Claude Opus
+	calls = 0
+	_, err = New(WithDir(dir)).WithQuiet(true).WithRetries(2).WithGuards(Guards{MaxCost: 0.005}).Generate(context.Background(), "add a login button")
+	if ExitCode(err) != ExitBudgetExceeded || calls != 1 {
+		t.Errorf("expected the run to stop at its max cost after one call, got %v after %d calls", err, calls)
+	}
+	if run, err = LatestRunState(dir); err != nil {
+		t.Fatalf("LatestRunState() returned error: %v", err)
+	}
+	if run.Status != RunStatusIncomplete || run.StopReason != StopReasonMaxCost || run.CostUSD != 0.0075 {
+		t.Errorf("expected the run to stop at its max cost, got %+v", run)
+	}
+}
@@ -0,0 +1,58 @@
+package gonzo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Modes of adding the project guidance files to the system prompt.
+const (
+	// GuidanceInline adds the content of the guidance files, up to their maximum size.
+	GuidanceInline = "inline"
+	// GuidanceReference names the guidance files and asks the agent to read them.
+	GuidanceReference = "reference"
+	// GuidanceOff leaves the guidance files out.
+	GuidanceOff = "off"
+)
+
+// GuidanceOptions select the project guidance files, such as CLAUDE.md or CONTRIBUTING.md,
+// added to the system prompt so that the conventions of the repository are always in scope.
+type GuidanceOptions struct {
+	Mode  string
+	Files []string
+	// MaxSize is the size in bytes above which an inlined file is referenced instead. Zero
+	// leaves the size unlimited.
+	MaxSize int
+}
+
+// GuidanceFile is a project guidance file found in the repository.
+type GuidanceFile struct {
+	Path string
+	// Content is the content of an inlined file, empty when the file is only referenced.
+	Content string
+}
+
+// guidanceFiles returns the guidance files that exist in dir, in the configured order.
+func (cc *ClaudeConfig) guidanceFiles(dir string) []GuidanceFile {
+	if cc.guidance.Mode == GuidanceOff {
+		return nil
+	}
+
+	var files []GuidanceFile
+	for _, name := range cc.guidance.Files {
+		path := filepath.Join(dir, name)
+		if !fileExists(path) {
+			continue
+		}
+		file := GuidanceFile{Path: filepath.ToSlash(name)}
+		if cc.guidance.Mode == GuidanceInline {
+			data, err := os.ReadFile(path)
+			if err == nil && (cc.guidance.MaxSize <= 0 || len(data) <= cc.guidance.MaxSize) {
+				file.Content = strings.TrimSpace(string(data))
+			}
+		}
+		files = append(files, file)
+	}
+	return files
+}
@@ -0,0 +1,44 @@
+package gonzo
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// startHeartbeat prints a line every heartbeat interval while an iteration that started at
+// start runs, so that CI systems killing silent jobs let it finish. The status line already
+// shows progress on terminals, so no heartbeat is printed alongside it. When quiet, heartbeats
+// go to stderr to leave stdout to the final result. The returned function stops the heartbeat.
+func (cc *ClaudeConfig) startHeartbeat(iteration int, start time.Time) (stop func()) {
+	if cc.heartbeat <= 0 || cc.status != nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(cc.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				msg := fmt.Sprintf("  Iteration %d still running, %s elapsed", iteration, now.Sub(start).Round(time.Second))
+				if cc.quiet && cc.out != nil {
+					fmt.Fprintln(cc.out, msg)
+				} else if cc.quiet {
+					fmt.Fprintln(os.Stderr, msg)
+				} else {
+					cc.logInfo("%s", msg)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,43 @@
+package gonzo
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerate_Heartbeat(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		cmd := mockCommandContext("Done "+DefaultCompletionSignal, 0)(ctx, name, args...)
+		if slices.Contains(args, "--print") {
+			cmd.Env = append(cmd.Env, "GO_HELPER_SLEEP=300ms")
+		}
+		return cmd
+	}
+
+	output := captureStdout(t, func() {
+		if _, err := New(WithDir(dir)).WithHeartbeat(50*time.Millisecond).Generate(context.Background(), "add a login button"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !strings.Contains(output, "  Iteration 1 still running, ") {
+		t.Errorf("expected heartbeats while Claude runs, got %q", output)
+	}
+
+	output = captureStdout(t, func() {
+		if _, err := New(WithDir(dir)).Generate(context.Background(), "add a login button"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if strings.Contains(output, "still running") {
+		t.Errorf("expected no heartbeat by default, got %q", output)
+	}
+}
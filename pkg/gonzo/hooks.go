@@ -0,0 +1,51 @@
+package gonzo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// shellCommandContext is a variable that wraps exec.CommandContext for testing.
+var shellCommandContext = exec.CommandContext
+
+// Hooks are shell commands run at fixed points of a run. They run in the repository with
+// GONZO_RUN_ID, GONZO_ITERATION and GONZO_STATUS set in their environment.
+type Hooks struct {
+	// BeforeRun runs before the first iteration; the run fails if one of them fails.
+	BeforeRun []string
+	// AfterIteration runs after every iteration; failures are only logged.
+	AfterIteration []string
+	// AfterRun runs once the run is over, whatever its status; failures are only logged.
+	AfterRun []string
+}
+
+// runShell runs a command with sh in dir and returns its combined output.
+func runShell(ctx context.Context, dir string, command string, run *RunState, iteration int, status string) (string, error) {
+	cmd := shellCommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GONZO_RUN_ID="+run.ID,
+		"GONZO_ITERATION="+strconv.Itoa(iteration),
+		"GONZO_STATUS="+status)
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// runHooks runs the commands of a hook in order, stopping at the first one that fails.
+func (cc *ClaudeConfig) runHooks(ctx context.Context, dir string, run *RunState, name string, commands []string, iteration int, status string) error {
+	for _, command := range commands {
+		cc.logInfo("Running %s hook: %s", name, command)
+		out, err := runShell(ctx, dir, command, run, iteration, status)
+		if out = strings.TrimSpace(out); out != "" {
+			cc.logInfo("%s", out)
+		}
+		if err != nil {
+			return fmt.Errorf("%s hook %q failed: %w", name, command, err)
+		}
+	}
+	return nil
+}
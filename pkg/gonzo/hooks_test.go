@@ -0,0 +1,59 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate_Hooks(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("still working", 0)
+
+	cc := New(WithWorkingDir(dir)).WithQuiet(true).WithMaxIterations(2).WithWrapUpIterations(0).WithHooks(Hooks{
+		BeforeRun:      []string{`echo "before $GONZO_ITERATION" >> hooks.log`},
+		AfterIteration: []string{`echo "iteration $GONZO_ITERATION" >> hooks.log`, "exit 1"},
+		AfterRun:       []string{`echo "after $GONZO_STATUS" >> hooks.log`},
+	})
+	if _, err := cc.Generate(context.Background(), "test prompt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "hooks.log"))
+	if err != nil {
+		t.Fatalf("expected the hooks to run: %v", err)
+	}
+	expected := "before 0\niteration 1\niteration 2\nafter " + RunStatusIncomplete + "\n"
+	if string(data) != expected {
+		t.Errorf("expected hooks log %q, got %q", expected, string(data))
+	}
+}
+
+func TestGenerate_BeforeRunHookFails(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext(DefaultCompletionSignal, 0)
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithHooks(Hooks{BeforeRun: []string{"exit 3"}})
+	_, err := cc.Generate(context.Background(), "test prompt")
+	if err == nil || !strings.Contains(err.Error(), `before-run hook "exit 3" failed`) {
+		t.Errorf("expected the failing hook to fail the run, got %v", err)
+	}
+
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("expected run state to be recorded: %v", err)
+	}
+	if run.Status != RunStatusFailed || run.Iterations != 0 {
+		t.Errorf("expected a failed run without iterations, got status %q at iteration %d", run.Status, run.Iterations)
+	}
+}
@@ -0,0 +1,101 @@
+package gonzo
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultCloseKeyword is the keyword placed before linked issues in pull request bodies.
+const DefaultCloseKeyword = "Closes"
+
+var (
+	// issueNumberPattern matches same-repository references such as "#12", but not "owner/repo#12".
+	issueNumberPattern = regexp.MustCompile(`(?:^|[\s(\[,])#(\d+)\b`)
+	// issueURLPattern matches GitHub and GitLab issue URLs.
+	issueURLPattern = regexp.MustCompile(`https?://[^\s)>\]]+?/issues/(\d+)\b`)
+)
+
+// IssueRef is an issue a run is linked to.
+type IssueRef struct {
+	Number int
+	// URL is set when the issue was referenced by URL, which also works across repositories.
+	URL string
+}
+
+// String returns the reference as written in pull request bodies: the URL when known, else "#N".
+func (r IssueRef) String() string {
+	if r.URL != "" {
+		return r.URL
+	}
+	return "#" + strconv.Itoa(r.Number)
+}
+
+// ReferencedIssues returns the issues referenced in text by number or URL, in order of
+// appearance and without duplicates.
+func ReferencedIssues(text string) []IssueRef {
+	type match struct {
+		pos int
+		ref IssueRef
+	}
+	var matches []match
+
+	for _, m := range issueURLPattern.FindAllStringSubmatchIndex(text, -1) {
+		n, _ := strconv.Atoi(text[m[2]:m[3]])
+		matches = append(matches, match{m[0], IssueRef{Number: n, URL: text[m[0]:m[1]]}})
+	}
+	for _, m := range issueNumberPattern.FindAllStringSubmatchIndex(text, -1) {
+		n, _ := strconv.Atoi(text[m[2]:m[3]])
+		matches = append(matches, match{m[0], IssueRef{Number: n}})
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].pos < matches[j].pos })
+
+	// Keep appearance order, preferring the URL form when an issue is referenced both ways
+	var refs []IssueRef
+	index := map[int]int{}
+	for _, m := range matches {
+		ref := m.ref
+		if i, ok := index[ref.Number]; ok {
+			if refs[i].URL == "" {
+				refs[i].URL = ref.URL
+			}
+			continue
+		}
+		index[ref.Number] = len(refs)
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// linkedIssues returns the issues the run is linked to: the issue it was started from,
+// followed by the issues referenced in the feature text.
+func (cc *ClaudeConfig) linkedIssues(feature string) []IssueRef {
+	var refs []IssueRef
+	seen := map[int]bool{}
+	if cc.issue != nil {
+		refs = append(refs, IssueRef{Number: cc.issue.Number, URL: cc.issue.URL})
+		seen[cc.issue.Number] = true
+	}
+	for _, ref := range ReferencedIssues(feature) {
+		if !seen[ref.Number] {
+			refs = append(refs, ref)
+			seen[ref.Number] = true
+		}
+	}
+	return refs
+}
+
+// issueTitlePrefix returns the pull request title prefix for the linked issues, e.g. "[#12] ".
+func issueTitlePrefix(refs []IssueRef) string {
+	if len(refs) == 0 {
+		return ""
+	}
+	numbers := make([]string, len(refs))
+	for i, ref := range refs {
+		numbers[i] = fmt.Sprintf("#%d", ref.Number)
+	}
+	return "[" + strings.Join(numbers, ", ") + "] "
+}
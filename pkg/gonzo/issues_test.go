@@ -0,0 +1,48 @@
+package gonzo
+
+import (
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"reflect"
+	"testing"
+)
+
+func TestReferencedIssues(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []IssueRef
+	}{
+		{"none", "add a login button", nil},
+		{"numbers", "fix #12 and (#14), see #12 again", []IssueRef{{Number: 12}, {Number: 14}}},
+		{"start of text", "#3 is broken", []IssueRef{{Number: 3}}},
+		{"other repository", "like o/r#5 and a#6", nil},
+		{"url", "see https://github.com/o/r/issues/7.", []IssueRef{{Number: 7, URL: "https://github.com/o/r/issues/7"}}},
+		{"gitlab url", "https://gitlab.com/o/r/-/issues/8", []IssueRef{{Number: 8, URL: "https://gitlab.com/o/r/-/issues/8"}}},
+		{"number then url", "#9 (https://github.com/o/r/issues/9)", []IssueRef{{Number: 9, URL: "https://github.com/o/r/issues/9"}}},
+		{"pull request url", "https://github.com/o/r/pull/10", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReferencedIssues(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReferencedIssues(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkedIssues(t *testing.T) {
+	cc := New().WithIssue(&forge.Issue{Number: 12, URL: "https://github.com/o/r/issues/12"})
+	got := cc.linkedIssues("Add login\n\nBlocked by #3\n\n(Issue #12: https://github.com/o/r/issues/12)")
+	want := []IssueRef{{Number: 12, URL: "https://github.com/o/r/issues/12"}, {Number: 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	if prefix := issueTitlePrefix(got); prefix != "[#12, #3] " {
+		t.Errorf("unexpected title prefix %q", prefix)
+	}
+	if prefix := issueTitlePrefix(nil); prefix != "" {
+		t.Errorf("expected no prefix without issues, got %q", prefix)
+	}
+}
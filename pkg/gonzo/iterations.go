@@ -0,0 +1,159 @@
+package gonzo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// IterationsDir is the directory inside a run's directory holding one subdirectory per iteration.
+const IterationsDir = "iterations"
+
+// Artifacts recorded for each iteration.
+const (
+	IterationPromptArtifact       = "prompt.txt"
+	IterationResponseArtifact     = "response.txt"
+	IterationDiffArtifact         = "diff.patch"
+	IterationStderrArtifact       = "stderr.txt"
+	IterationUsageArtifact        = "usage.json"
+	IterationVerificationArtifact = "verification.txt"
+)
+
+// Iteration is the record of a single iteration of a run, as shown by `gonzo show`.
+type Iteration struct {
+	Number   int
+	Prompt   string
+	Response string
+	// Stderr is what the Claude CLI wrote to stderr when the iteration failed.
+	Stderr string
+	// Diff holds the changes made during the iteration, committed or not.
+	Diff string
+	// Verification is the output of the verification commands, when any were run.
+	Verification string
+	// Usage is the tokens used and the price of the iteration, when the Claude CLI reported them.
+	Usage *Usage
+}
+
+// iterationDir returns the directory holding the artifacts of an iteration of the run.
+func iterationDir(dir string, id string, iteration int) string {
+	return filepath.Join(RunDir(dir, id), IterationsDir, fmt.Sprintf("%03d", iteration))
+}
+
+// SaveIterationArtifact writes a file into the directory of an iteration of the run.
+func (s *RunState) SaveIterationArtifact(dir string, iteration int, name string, data []byte) error {
+	iterDir := iterationDir(dir, s.ID, iteration)
+	if err := os.MkdirAll(iterDir, 0755); err != nil {
+		return fmt.Errorf("failed to create iteration directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(iterDir, name), data, 0644); err != nil {
+		return fmt.Errorf("failed to write iteration artifact %s: %w", name, err)
+	}
+	return nil
+}
+
+// LoadIterations reads the recorded iterations of a run, in order.
+// Runs recorded before iterations were kept have none.
+func LoadIterations(dir string, s *RunState) ([]Iteration, error) {
+	entries, err := os.ReadDir(filepath.Join(RunDir(dir, s.ID), IterationsDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list iterations: %w", err)
+	}
+
+	var iterations []Iteration
+	for _, e := range entries {
+		n, err := strconv.Atoi(e.Name())
+		if !e.IsDir() || err != nil {
+			continue
+		}
+		read := func(name string) string {
+			data, _ := os.ReadFile(filepath.Join(iterationDir(dir, s.ID, n), name))
+			return string(data)
+		}
+		it := Iteration{
+			Number:       n,
+			Prompt:       read(IterationPromptArtifact),
+			Response:     read(IterationResponseArtifact),
+			Stderr:       read(IterationStderrArtifact),
+			Diff:         read(IterationDiffArtifact),
+			Verification: read(IterationVerificationArtifact),
+		}
+		var usage Usage
+		if data := read(IterationUsageArtifact); data != "" && json.Unmarshal([]byte(data), &usage) == nil {
+			it.Usage = &usage
+		}
+		iterations = append(iterations, it)
+	}
+
+	sort.Slice(iterations, func(i, j int) bool { return iterations[i].Number < iterations[j].Number })
+	return iterations, nil
+}
+
+// recordIteration saves the prompt and response of an iteration, its usage, what the Claude CLI
+// wrote to stderr when the call failed with callErr, and the changes made since the commit
+// checked out when it started.
+func (cc *ClaudeConfig) recordIteration(ctx context.Context, dir string, run *RunState, iteration int, startSHA string, prompt string, response string, usage Usage, callErr error) {
+	cc.swallow(run.SaveIterationArtifact(dir, iteration, IterationPromptArtifact, []byte(prompt)))
+	cc.swallow(run.SaveIterationArtifact(dir, iteration, IterationResponseArtifact, []byte(response)))
+	if !usage.IsZero() {
+		if data, err := json.Marshal(usage); err == nil {
+			cc.swallow(run.SaveIterationArtifact(dir, iteration, IterationUsageArtifact, append(data, '\n')))
+		}
+	}
+	var cliErr *CLIError
+	if errors.As(callErr, &cliErr) && cliErr.Stderr != "" {
+		cc.swallow(run.SaveIterationArtifact(dir, iteration, IterationStderrArtifact, []byte(cliErr.Stderr)))
+	}
+	if startSHA == "" {
+		return
+	}
+	if diff, err := diffSince(ctx, dir, startSHA); err == nil && diff != "" {
+		cc.swallow(run.SaveIterationArtifact(dir, iteration, IterationDiffArtifact, []byte(diff+"\n")))
+	}
+}
+
+// RenderTranscript renders a readable transcript of a run and its iterations.
+// A non-zero only limits the transcript to that iteration.
+func RenderTranscript(run *RunState, iterations []Iteration, only int) (string, error) {
+	if only != 0 {
+		var selected []Iteration
+		for _, it := range iterations {
+			if it.Number == only {
+				selected = append(selected, it)
+			}
+		}
+		if len(selected) == 0 {
+			return "", fmt.Errorf("run %s has no recorded iteration %d", run.ID, only)
+		}
+		iterations = selected
+	}
+
+	t, err := template.New("transcript.tmpl").Funcs(templateFuncs).ParseFS(promptLib, "prompts/transcript.tmpl")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse transcript template: %w", err)
+	}
+
+	var b strings.Builder
+	err = t.Execute(&b, struct {
+		Run        *RunState
+		Iterations []Iteration
+		Only       int
+	}{
+		Run:        run,
+		Iterations: iterations,
+		Only:       only,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute transcript template: %w", err)
+	}
+	return b.String(), nil
+}
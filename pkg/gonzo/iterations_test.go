@@ -0,0 +1,150 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadIterations(t *testing.T) {
+	dir := t.TempDir()
+	run := &RunState{ID: "run-1", Feature: "add login", Status: RunStatusCompleted, Iterations: 2, StartedAt: time.Now()}
+	if err := run.Save(dir); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+
+	iterations, err := LoadIterations(dir, run)
+	if err != nil || iterations != nil {
+		t.Fatalf("expected no iterations for a run without records, got %v, %v", iterations, err)
+	}
+
+	for _, a := range []struct {
+		iteration int
+		name      string
+		data      string
+	}{
+		{2, IterationPromptArtifact, "wrap up"},
+		{1, IterationPromptArtifact, "add login"},
+		{1, IterationResponseArtifact, "added the form"},
+		{1, IterationDiffArtifact, "+login\n"},
+		{1, IterationVerificationArtifact, "ok"},
+	} {
+		if err := run.SaveIterationArtifact(dir, a.iteration, a.name, []byte(a.data)); err != nil {
+			t.Fatalf("SaveIterationArtifact() returned error: %v", err)
+		}
+	}
+
+	iterations, err = LoadIterations(dir, run)
+	if err != nil {
+		t.Fatalf("LoadIterations() returned error: %v", err)
+	}
+	if len(iterations) != 2 || iterations[0].Number != 1 || iterations[1].Number != 2 {
+		t.Fatalf("expected iterations 1 and 2 in order, got %+v", iterations)
+	}
+	want := Iteration{Number: 1, Prompt: "add login", Response: "added the form", Diff: "+login\n", Verification: "ok"}
+	if iterations[0] != want {
+		t.Errorf("expected %+v, got %+v", want, iterations[0])
+	}
+}
+
+func TestRenderTranscript(t *testing.T) {
+	run := &RunState{ID: "run-1", Feature: "add login", Model: ClaudeSonnet, Status: RunStatusCompleted, Iterations: 2, Branch: "add-login"}
+	iterations := []Iteration{
+		{Number: 1, Prompt: "add login", Response: "added the form", Diff: "+login\n", Verification: "PASS"},
+		{Number: 2, Prompt: "wrap up", Response: "done"},
+	}
+
+	out, err := RenderTranscript(run, iterations, 0)
+	if err != nil {
+		t.Fatalf("RenderTranscript() returned error: %v", err)
+	}
+	for _, want := range []string{"Run run-1: completed after 2 iteration(s)", "Branch:  add-login", "Iteration 1", "added the form", "+login", "--- Verification ---\nPASS", "Iteration 2", "(no changes)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected transcript to contain %q, got %q", want, out)
+		}
+	}
+
+	out, err = RenderTranscript(run, iterations, 2)
+	if err != nil {
+		t.Fatalf("RenderTranscript() returned error: %v", err)
+	}
+	if strings.Contains(out, "Iteration 1") || !strings.Contains(out, "Iteration 2") {
+		t.Errorf("expected only iteration 2, got %q", out)
+	}
+
+	if _, err := RenderTranscript(run, iterations, 3); err == nil {
+		t.Error("expected error for an iteration that was not recorded")
+	}
+
+	out, _ = RenderTranscript(run, nil, 0)
+	if !strings.Contains(out, "No iterations were recorded") {
+		t.Errorf("expected a note for runs without iterations, got %q", out)
+	}
+}
+
+func TestGenerate_RecordsIterations(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := initGitRepo(t)
+	commandContext = mockCommandContext("still working", 0)
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithMaxIterations(2).WithWrapUpIterations(0)
+	if _, err := cc.Generate(context.Background(), "add login"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("expected run state to be recorded: %v", err)
+	}
+	iterations, err := LoadIterations(dir, run)
+	if err != nil {
+		t.Fatalf("LoadIterations() returned error: %v", err)
+	}
+	if len(iterations) != 2 {
+		t.Fatalf("expected 2 recorded iterations, got %d", len(iterations))
+	}
+	if iterations[0].Prompt != "add login" || iterations[0].Response != "still working" {
+		t.Errorf("unexpected first iteration %+v", iterations[0])
+	}
+}
+
+func TestGenerate_RecordsStderr(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	stderr := strings.Repeat("retrying request\n", 20) + "Error: invalid API key"
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		cmd := mockCommandContext("", 1)(ctx, name, args...)
+		cmd.Env = append(cmd.Env, "GO_HELPER_STDERR="+stderr)
+		return cmd
+	}
+
+	_, err := New(WithDir(dir)).WithQuiet(true).Generate(context.Background(), "add login")
+	var cliErr *CLIError
+	if !errors.As(err, &cliErr) || cliErr.Stderr != stderr {
+		t.Fatalf("expected a CLIError with the full stderr, got %v", err)
+	}
+	if !strings.HasSuffix(err.Error(), "exit status 1: ...\n"+strings.Repeat("retrying request\n", 9)+"Error: invalid API key") {
+		t.Errorf("expected the error to end with the last lines of stderr, got %q", err.Error())
+	}
+
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("expected run state to be recorded: %v", err)
+	}
+	iterations, err := LoadIterations(dir, run)
+	if err != nil || len(iterations) != 1 {
+		t.Fatalf("expected 1 recorded iteration, got %d, %v", len(iterations), err)
+	}
+	if iterations[0].Stderr != stderr {
+		t.Errorf("expected the full stderr to be recorded, got %q", iterations[0].Stderr)
+	}
+}
@@ -0,0 +1,157 @@
+package gonzo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VerificationResult is the outcome of a verification command.
+type VerificationResult struct {
+	Command  string
+	Output   string
+	Passed   bool
+	Duration time.Duration
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitSeconds formats a duration as the seconds JUnit reports expect.
+func junitSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// JUnitReport renders the outcome of a run as a JUnit XML report, for CI systems to show with
+// their test results: a "run" suite whose test case fails unless the run completed, and a
+// "verification" suite with a test case per verification command of the last verification.
+func JUnitReport(run *RunState, verification []VerificationResult) ([]byte, error) {
+	var duration time.Duration
+	if run.FinishedAt != nil {
+		duration = run.FinishedAt.Sub(run.StartedAt)
+	}
+
+	runCase := junitTestCase{
+		Name:      firstLine(run.Feature),
+		Classname: "gonzo.run",
+		Time:      junitSeconds(duration),
+		SystemOut: run.Feature,
+	}
+	if run.Status != RunStatusCompleted {
+		runCase.Failure = &junitFailure{
+			Message: fmt.Sprintf("run %s after %d iteration(s)", run.Status, run.Iterations),
+			Type:    run.Status,
+		}
+	}
+	runSuite := junitTestSuite{
+		Name:      "run",
+		Tests:     1,
+		Time:      runCase.Time,
+		Timestamp: run.StartedAt.UTC().Format(time.RFC3339),
+		Properties: []junitProperty{
+			{Name: "run-id", Value: run.ID},
+			{Name: "model", Value: run.Model},
+			{Name: "status", Value: run.Status},
+			{Name: "iterations", Value: strconv.Itoa(run.Iterations)},
+			{Name: "branch", Value: run.Branch},
+			{Name: "pr-url", Value: run.PRURL},
+			{Name: "cost-usd", Value: strconv.FormatFloat(run.CostUSD, 'f', 4, 64)},
+		},
+		Cases: []junitTestCase{runCase},
+	}
+	if runCase.Failure != nil {
+		runSuite.Failures = 1
+	}
+
+	verifySuite := junitTestSuite{Name: "verification", Tests: len(verification)}
+	var verifyTime time.Duration
+	for _, result := range verification {
+		c := junitTestCase{
+			Name:      result.Command,
+			Classname: "gonzo.verification",
+			Time:      junitSeconds(result.Duration),
+		}
+		if result.Passed {
+			c.SystemOut = result.Output
+		} else {
+			c.Failure = &junitFailure{Message: "verification command failed", Type: "verification", Text: result.Output}
+			verifySuite.Failures++
+		}
+		verifyTime += result.Duration
+		verifySuite.Cases = append(verifySuite.Cases, c)
+	}
+	verifySuite.Time = junitSeconds(verifyTime)
+
+	report := junitTestSuites{
+		Name:     "gonzo",
+		Tests:    runSuite.Tests + verifySuite.Tests,
+		Failures: runSuite.Failures + verifySuite.Failures,
+		Time:     junitSeconds(duration),
+		Suites:   []junitTestSuite{runSuite, verifySuite},
+	}
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render JUnit report: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// writeJUnitReport writes the JUnit report of the run, when enabled, relative to dir unless
+// its path is absolute.
+func (cc *ClaudeConfig) writeJUnitReport(dir string, run *RunState) {
+	path := strings.TrimSpace(cc.junitReport)
+	if path == "" {
+		return
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	report, err := JUnitReport(run, cc.verification)
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = os.WriteFile(path, report, 0644)
+		}
+	}
+	if err != nil {
+		cc.logWarn("Failed to write JUnit report: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package gonzo
+
+import (
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerate_JUnitReport(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext(DefaultCompletionSignal, 0)
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithMaxIterations(2).WithJUnitReport(filepath.Join("reports", "gonzo.xml")).
+		WithVerifyCommands([]string{"echo ok", "echo '2 tests failed'; exit 1"})
+	if _, err := cc.Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "reports", "gonzo.xml"))
+	if err != nil {
+		t.Fatalf("failed to read JUnit report: %v", err)
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(data, &report); err != nil {
+		t.Fatalf("failed to parse JUnit report: %v\n%s", err, data)
+	}
+	if report.Tests != 3 || report.Failures != 2 || len(report.Suites) != 2 {
+		t.Fatalf("expected 3 tests with 2 failures in 2 suites, got %+v", report)
+	}
+
+	run := report.Suites[0].Cases[0]
+	if run.Name != "add a login button" || run.Failure == nil || run.Failure.Type != RunStatusIncomplete {
+		t.Errorf("expected the incomplete run to fail, got %+v", run)
+	}
+	verification := report.Suites[1].Cases
+	if verification[0].Name != "echo ok" || verification[0].Failure != nil || verification[0].SystemOut != "ok\n" {
+		t.Errorf("expected the first command to pass, got %+v", verification[0])
+	}
+	if verification[1].Failure == nil || verification[1].Failure.Text != "2 tests failed\n" {
+		t.Errorf("expected the second command to fail with its output, got %+v", verification[1])
+	}
+}
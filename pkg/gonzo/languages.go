@@ -0,0 +1,109 @@
+package gonzo
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Values of the language setting besides the names of languages.
+const (
+	// LanguageAuto detects the language of the repository from its toolchain files.
+	LanguageAuto = "auto"
+	// LanguageNone leaves the language guidance out of the system prompt.
+	LanguageNone = "none"
+)
+
+// Languages shipped with gonzo, each adding the guidance of its toolchain to the system prompt.
+const (
+	LanguageGo     = "go"
+	LanguageNode   = "node"
+	LanguagePython = "python"
+)
+
+// languageMarkers are the files identifying the toolchain of a repository, by order of
+// precedence when several are found.
+var languageMarkers = []struct {
+	file     string
+	language string
+}{
+	{"go.mod", LanguageGo},
+	{"package.json", LanguageNode},
+	{"pyproject.toml", LanguagePython},
+}
+
+// languageTemplate returns the name of the prompt template of a language. A local template of
+// that name overrides the embedded one, or adds a language of its own.
+func languageTemplate(name string) string {
+	return "language_" + name + ".tmpl"
+}
+
+// DetectLanguage returns the primary language of the repository in dir from the toolchain
+// files at its root, or an empty string when none is found.
+func DetectLanguage(dir string) string {
+	for _, marker := range languageMarkers {
+		if fileExists(filepath.Join(dir, marker.file)) {
+			return marker.language
+		}
+	}
+	return ""
+}
+
+// Languages returns the names of the languages available in dir, sorted: the embedded ones and
+// the ones defined by a local template in its prompts directory.
+func Languages(dir string) []string {
+	return New(WithPrompts(os.DirFS(PromptsPath(dir)))).languages()
+}
+
+// languages returns the names of the embedded and local languages, sorted.
+func (cc *ClaudeConfig) languages() []string {
+	names, _ := fs.Glob(cc.promptSet(), languageTemplate("*"))
+	if local := cc.localPrompts(); local != nil {
+		localNames, _ := fs.Glob(local, languageTemplate("*"))
+		names = append(names, localNames...)
+	}
+
+	var languages []string
+	for _, name := range names {
+		language := strings.TrimSuffix(strings.TrimPrefix(path.Base(name), "language_"), ".tmpl")
+		if !slices.Contains(languages, language) {
+			languages = append(languages, language)
+		}
+	}
+	slices.Sort(languages)
+	return languages
+}
+
+// languagePrompt renders the system prompt section of the language of the repository in dir
+// with data, the data of the system prompt. It is empty when the language is turned off, or
+// detected automatically but not found or without guidance.
+func (cc *ClaudeConfig) languagePrompt(dir string, data interface{}) (string, error) {
+	language := cc.language
+	switch language {
+	case "", LanguageNone:
+		return "", nil
+	case LanguageAuto:
+		language = DetectLanguage(dir)
+		if !slices.Contains(cc.languages(), language) {
+			return "", nil
+		}
+	default:
+		if languages := cc.languages(); !slices.Contains(languages, language) {
+			return "", fmt.Errorf("unknown language %q (available: %s, or %s or %s)", language, strings.Join(languages, ", "), LanguageAuto, LanguageNone)
+		}
+	}
+
+	t, err := cc.parsePrompt(languageTemplate(language))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse language template: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to execute language template: %w", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,333 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogsDir is the directory inside GonzoDir holding a copy of the log of each run.
+const LogsDir = "logs"
+
+// logsGitignore keeps the logs out of commits made by the agent; it ignores itself too.
+const logsGitignore = "*\n"
+
+// Formats of the log.
+const (
+	// LogFormatText prints plain messages to the console and key=value lines to the log file.
+	LogFormatText = "text"
+	// LogFormatJSON prints JSON lines to the console and the log file.
+	LogFormatJSON = "json"
+)
+
+// VerbosityDebug is the verbosity printing the rendered prompts, the Claude CLI commands and
+// their timing, as debug messages, on top of the banners and summaries of the iterations.
+const VerbosityDebug = 2
+
+// LogOptions configure the log of a run, printed to the console unless quiet and copied to
+// .gonzo/logs/run-<id>.log.
+type LogOptions struct {
+	// Format is LogFormatText or LogFormatJSON.
+	Format string
+	// Level is the least severe level logged: debug, info, warn or error.
+	Level string
+}
+
+// level returns the least severe level logged, info when it is not set or unknown.
+func (o LogOptions) level() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(o.Level)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// logLevel returns the least severe level logged: the level of the log options, lowered to
+// debug with VerbosityDebug.
+func (cc *ClaudeConfig) logLevel() slog.Level {
+	level := cc.logging.level()
+	if cc.verbosity >= VerbosityDebug && level > slog.LevelDebug {
+		return slog.LevelDebug
+	}
+	return level
+}
+
+// formatCommand returns a command line as it would be typed in a shell, quoting the arguments
+// that need it.
+func formatCommand(name string, args []string) string {
+	parts := []string{name}
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$`|&;<>()*?[]#~") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// LogFilePath returns the path of the copy of the log of a run in dir.
+func LogFilePath(dir string, id string) string {
+	return filepath.Join(dir, GonzoDir, LogsDir, "run-"+id+".log")
+}
+
+// openLogFile opens the copy of the log of a run for appending.
+func openLogFile(dir string, run *RunState) (*os.File, error) {
+	logsDir := filepath.Join(dir, GonzoDir, LogsDir)
+	if err := os.MkdirAll(logsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create logs directory: %w", err)
+	}
+	ignore := filepath.Join(logsDir, ".gitignore")
+	if _, err := os.Stat(ignore); errors.Is(err, os.ErrNotExist) {
+		if err := os.WriteFile(ignore, []byte(logsGitignore), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write logs .gitignore: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(LogFilePath(dir, run.ID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return f, nil
+}
+
+// WithWriter prints the progress of the run, and Claude's replies when streamed, to w instead of
+// stdout, or of stderr with OutputJSON.
+func WithWriter(w io.Writer) Option {
+	return func(cc *ClaudeConfig) {
+		cc.out = w
+	}
+}
+
+// WithLogger sends the messages about the run to logger instead of printing them, unless quiet.
+// The log files of the run are still written, and Claude's replies still streamed to the output
+// of WithWriter.
+func WithLogger(logger *slog.Logger) Option {
+	return func(cc *ClaudeConfig) {
+		cc.log = logger
+	}
+}
+
+// logWriter returns where the progress of the run is printed.
+func (cc *ClaudeConfig) logWriter() io.Writer {
+	if cc.out != nil {
+		return cc.out
+	}
+	if cc.output == OutputJSON {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// logger returns the logger of the run: it prints to the console unless quiet, and records to
+// the log files of the run in progress, if any.
+func (cc *ClaudeConfig) logger() *slog.Logger {
+	handler := cc.fileHandler()
+	if !cc.quiet {
+		handler = append(handler, cc.consoleHandler())
+	}
+	return slog.New(handler)
+}
+
+// consoleHandler returns the handler printing to the console, in the log format, or the handler
+// of the logger of WithLogger.
+func (cc *ClaudeConfig) consoleHandler() slog.Handler {
+	if cc.log != nil {
+		return cc.log.Handler().WithAttrs(cc.runAttrs())
+	}
+	opts := &slog.HandlerOptions{Level: cc.logLevel(), ReplaceAttr: dropStyle}
+	if cc.logging.Format == LogFormatJSON {
+		return slog.NewJSONHandler(cc.console(), opts).WithAttrs(cc.runAttrs())
+	}
+	return &consoleHandler{w: cc.console(), level: opts.Level, color: cc.colorEnabled()}
+}
+
+// fileHandler returns the handler recording to the log files of the run in progress: the run
+// log read by `gonzo logs`, and its copy in the logs directory.
+func (cc *ClaudeConfig) fileHandler() multiHandler {
+	var handler multiHandler
+	opts := &slog.HandlerOptions{Level: cc.logLevel(), ReplaceAttr: dropStyle}
+	if cc.runLog != nil {
+		handler = append(handler, &runLogHandler{w: cc.runLog, level: opts.Level})
+	}
+	if cc.logFile != nil {
+		if cc.logging.Format == LogFormatJSON {
+			handler = append(handler, slog.NewJSONHandler(cc.logFile, opts).WithAttrs(cc.runAttrs()))
+		} else {
+			handler = append(handler, slog.NewTextHandler(cc.logFile, opts).WithAttrs(cc.runAttrs()))
+		}
+	}
+	return handler
+}
+
+// runAttrs returns the attributes of the structured logs identifying the run in progress, if
+// any, so the logs of runs collected together can be told apart.
+func (cc *ClaudeConfig) runAttrs() []slog.Attr {
+	if cc.runID == "" {
+		return nil
+	}
+	return []slog.Attr{slog.String("run_id", cc.runID)}
+}
+
+// logDebug logs a message only shown at the debug level.
+func (cc *ClaudeConfig) logDebug(format string, args ...interface{}) {
+	cc.logger().Debug(fmt.Sprintf(format, args...))
+}
+
+// logInfo prints a message unless quiet, and records it in the log of the run in progress.
+func (cc *ClaudeConfig) logInfo(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	cc.logger().Info(msg)
+	cc.emit(Event{Type: EventLog, Message: msg})
+}
+
+// logWarn is logInfo for a problem that does not stop the run.
+func (cc *ClaudeConfig) logWarn(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	cc.logger().Warn(msg)
+	cc.emit(Event{Type: EventLog, Message: msg})
+}
+
+// timeOfDay is the layout of the times printed in the messages about a run.
+const timeOfDay = "15:04:05"
+
+// logTimed logs a message about a step of the run that went from start to end. The start, end
+// and duration of the step are recorded along with attrs as attributes of the structured logs,
+// while the text console only prints the message, which tells them itself.
+func (cc *ClaudeConfig) logTimed(level slog.Level, msg string, start time.Time, end time.Time, attrs ...slog.Attr) {
+	attrs = append(attrs, slog.Time("start", start), slog.Time("end", end), slog.Duration("duration", end.Sub(start)))
+	ctx := context.Background()
+	slog.New(cc.fileHandler()).LogAttrs(ctx, level, msg, attrs...)
+	if !cc.quiet {
+		if cc.logging.Format == LogFormatJSON || cc.log != nil {
+			slog.New(cc.consoleHandler()).LogAttrs(ctx, level, msg, attrs...)
+		} else {
+			slog.New(cc.consoleHandler()).Log(ctx, level, msg)
+		}
+	}
+	cc.emit(Event{Type: EventLog, Message: msg})
+}
+
+// roundDuration rounds a duration for people: to the second, or to the millisecond below one.
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Second)
+}
+
+// multiHandler sends records to each of its handlers.
+type multiHandler []slog.Handler
+
+func (h multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, r.Level) {
+			errs = append(errs, handler.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (h multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
+
+// consoleHandler prints the message of records as it is, followed by their attributes as
+// key=value pairs, for people watching the run. With color, messages are colored by their
+// style or level.
+type consoleHandler struct {
+	w     io.Writer
+	level slog.Leveler
+	color bool
+	attrs []slog.Attr
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var attrs strings.Builder
+	style := ""
+	appendAttr := func(a slog.Attr) bool {
+		if a.Key == styleKey {
+			style = a.Value.String()
+		} else {
+			fmt.Fprintf(&attrs, " %s=%v", a.Key, a.Value)
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		appendAttr(a)
+	}
+	r.Attrs(appendAttr)
+
+	var b strings.Builder
+	if h.color {
+		b.WriteString(colorize(r.Message, style, r.Level))
+	} else {
+		b.WriteString(r.Message)
+	}
+	b.WriteString(attrs.String())
+	b.WriteString("\n")
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &consoleHandler{w: h.w, level: h.level, color: h.color, attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...)}
+}
+
+func (h *consoleHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// runLogHandler records messages as the LogEntry lines of a run's log.
+type runLogHandler struct {
+	w     io.Writer
+	level slog.Leveler
+}
+
+func (h *runLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *runLogHandler) Handle(_ context.Context, r slog.Record) error {
+	return writeLogEntry(h.w, strings.ToLower(r.Level.String()), r.Message)
+}
+
+func (h *runLogHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *runLogHandler) WithGroup(string) slog.Handler {
+	return h
+}
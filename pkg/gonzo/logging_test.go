@@ -0,0 +1,224 @@
+package gonzo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConsoleHandler(t *testing.T) {
+	var b bytes.Buffer
+	logger := slog.New(&consoleHandler{w: &b, level: LogOptions{Level: "warn"}.level()})
+	logger.Info("hidden")
+	logger.Warn("Verification failed", "command", "make test")
+	logger.With("iteration", 2).Error("Claude CLI call failed")
+
+	want := "Verification failed command=make test\nClaude CLI call failed iteration=2\n"
+	if b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
+
+func TestLogOptions_Level(t *testing.T) {
+	for level, want := range map[string]slog.Level{
+		"":      slog.LevelInfo,
+		"debug": slog.LevelDebug,
+		"WARN":  slog.LevelWarn,
+		"error": slog.LevelError,
+		"loud":  slog.LevelInfo,
+	} {
+		if got := (LogOptions{Level: level}).level(); got != want {
+			t.Errorf("level(%q) = %v, want %v", level, got, want)
+		}
+	}
+}
+
+func TestGenerate_LogFile(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("Done "+DefaultCompletionSignal, 0)
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithLogging(LogOptions{Format: LogFormatJSON, Level: "info"})
+	if _, err := cc.Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("LatestRunState() returned error: %v", err)
+	}
+	data, err := os.ReadFile(LogFilePath(dir, run.ID))
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var first, last struct {
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+		RunID string `json:"run_id"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("expected JSON lines, got %s", data)
+	}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil {
+		t.Fatalf("expected JSON lines, got %s", data)
+	}
+	if first.Level != "INFO" || !strings.Contains(first.Msg, "Starting Gonzo") {
+		t.Errorf("expected the run to start the log, got %+v", first)
+	}
+	if last.Msg != "Run "+run.ID+" completed" {
+		t.Errorf("expected the run to end the log, got %+v", last)
+	}
+	if first.RunID != run.ID || last.RunID != run.ID {
+		t.Errorf("expected the lines to carry the run ID %s, got %+v and %+v", run.ID, first, last)
+	}
+	if strings.Contains(string(data), `"`+styleKey+`"`) {
+		t.Errorf("expected the styles of the console to be left out, got %s", data)
+	}
+}
+
+func TestFormatCommand(t *testing.T) {
+	got := formatCommand("claude", []string{"--print", "--model", ClaudeSonnet, "--system-prompt", "Be brief.\nNo $HOME", ""})
+	want := `claude --print --model claude-sonnet-4-5 --system-prompt "Be brief.\nNo $HOME" ""`
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestGenerate_Verbosity(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("Done "+DefaultCompletionSignal, 0)
+
+	output := captureStdout(t, func() {
+		if _, err := New(WithDir(dir)).Generate(context.Background(), "add a login button"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !strings.Contains(output, "Iteration 1 of") || strings.Contains(output, "Running: claude") {
+		t.Errorf("expected the banners only by default, got %s", output)
+	}
+
+	output = captureStdout(t, func() {
+		if _, err := New(WithDir(dir)).WithVerbosity(VerbosityDebug).Generate(context.Background(), "add a login button"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	for _, want := range []string{"System prompt:\n# Gonzo", "Prompt:\nadd a login button", "Running: claude ", "--model claude-opus-4-5 --output-format stream-json --verbose --system-prompt \"# Gonzo"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q with VerbosityDebug, got %s", want, output)
+		}
+	}
+}
+
+func TestGenerate_Timing(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("Done "+DefaultCompletionSignal, 0)
+
+	cc := New(WithDir(dir)).WithVerifyCommands([]string{"true"}).WithLogging(LogOptions{Format: LogFormatJSON, Level: "info"})
+	output := captureStdout(t, func() {
+		if _, err := cc.Generate(context.Background(), "add a login button"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	for _, want := range []string{`"msg":"  Iteration 1 of 10, started at `, `"msg":"  Iteration 1 finished at `, `"msg":"Verification passed at `} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q on the console, got %s", want, output)
+		}
+	}
+
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("LatestRunState() returned error: %v", err)
+	}
+	data, err := os.ReadFile(LogFilePath(dir, run.ID))
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	var timed []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("expected JSON lines, got %s", data)
+		}
+		if _, ok := record["duration"]; ok {
+			timed = append(timed, record)
+		}
+	}
+	if len(timed) != 2 || timed[0]["iteration"] != 1.0 || timed[1]["command"] != "true" {
+		t.Fatalf("expected the iteration and the verification command to be timed, got %v", timed)
+	}
+	for _, record := range timed {
+		if record["start"] == nil || record["end"] == nil {
+			t.Errorf("expected the start and end of %v", record)
+		}
+	}
+}
+
+func TestGenerate_OutputAndLogger(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("Done "+DefaultCompletionSignal, 0)
+
+	var out bytes.Buffer
+	stdout := captureStdout(t, func() {
+		if _, err := New(WithDir(dir), WithWriter(&out)).Generate(context.Background(), "add a login button"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if stdout != "" {
+		t.Errorf("expected nothing on stdout, got %s", stdout)
+	}
+	if !strings.Contains(out.String(), "Iteration 1 of") || !strings.Contains(out.String(), "Task completed!") {
+		t.Errorf("expected the progress on the output, got %s", out.String())
+	}
+
+	var logged bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logged, nil))
+	stdout = captureStdout(t, func() {
+		if _, err := New(WithDir(dir), WithLogger(logger), WithWriter(io.Discard)).Generate(context.Background(), "add a login button"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if stdout != "" {
+		t.Errorf("expected nothing on stdout, got %s", stdout)
+	}
+	for _, want := range []string{`"msg":"Task completed!"`, `"iteration":1`, `"run_id":"`} {
+		if !strings.Contains(logged.String(), want) {
+			t.Errorf("expected %q in the logger, got %s", want, logged.String())
+		}
+	}
+}
+
+func TestClaudeConfig_Swallow(t *testing.T) {
+	var logged bytes.Buffer
+	cc := New(WithLogger(slog.New(slog.NewJSONHandler(&logged, nil))))
+
+	cc.swallow(nil)
+	if logged.Len() != 0 {
+		t.Errorf("expected nothing logged without an error, got %s", logged.String())
+	}
+	cc.swallow(errors.New("failed to close the log"))
+	if !strings.Contains(logged.String(), `"level":"WARN","msg":"failed to close the log"`) {
+		t.Errorf("expected the error as a warning of the run, got %s", logged.String())
+	}
+}
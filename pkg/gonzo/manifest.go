@@ -0,0 +1,147 @@
+package gonzo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ManifestArtifact is the run artifact describing everything needed to replay the run.
+const ManifestArtifact = "manifest.json"
+
+// ManifestFormatVersion is the current version of the run manifest format.
+const ManifestFormatVersion = 1
+
+// Version is the gonzo version recorded in run manifests. It is set by the CLI at startup.
+var Version = "dev"
+
+// Manifest records the inputs of a run so it can be reproduced with `gonzo rerun`.
+type Manifest struct {
+	FormatVersion int                    `json:"format_version"`
+	RunID         string                 `json:"run_id"`
+	CreatedAt     time.Time              `json:"created_at"`
+	Feature       string                 `json:"feature"`
+	SpecHash      string                 `json:"spec_hash"`
+	Model         string                 `json:"model"`
+	Config        map[string]interface{} `json:"config,omitempty"`
+	GonzoVersion  string                 `json:"gonzo_version"`
+	ClaudeVersion string                 `json:"claude_version,omitempty"`
+	BaseCommit    string                 `json:"base_commit,omitempty"`
+	BaseBranch    string                 `json:"base_branch,omitempty"`
+}
+
+// SpecHash returns the hash identifying a feature description in manifests.
+func SpecHash(feature string) string {
+	sum := sha256.Sum256([]byte(feature))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Verify checks that the manifest is complete and that its feature matches the recorded hash.
+func (m *Manifest) Verify() error {
+	if m.FormatVersion > ManifestFormatVersion {
+		return fmt.Errorf("manifest format version %d is newer than supported version %d", m.FormatVersion, ManifestFormatVersion)
+	}
+	if m.Feature == "" {
+		return errors.New("manifest has no feature")
+	}
+	if m.SpecHash != SpecHash(m.Feature) {
+		return errors.New("manifest feature does not match its spec hash")
+	}
+	return nil
+}
+
+// LoadManifest reads a manifest from path. When path is not a file it is treated
+// as a run ID and the manifest of that run in dir is loaded instead.
+func LoadManifest(dir string, path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !strings.ContainsAny(path, `/\`) {
+		id := path
+		if s, err := ResolveRunState(dir, path); err == nil {
+			id = s.ID
+		}
+		data, err = os.ReadFile(filepath.Join(RunDir(dir, id), ManifestArtifact))
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("manifest %q not found", path)
+		}
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest %q: %w", path, err)
+	}
+	return &m, nil
+}
+
+// RerunBranch returns the name of the fresh branch a manifest is replayed on.
+func RerunBranch(m *Manifest) string {
+	return "github.com/andybarilla/gonzo/rerun-" + m.RunID
+}
+
+// PrepareRerun checks out a fresh branch at the manifest's base commit in dir and returns its name.
+// The working tree must be clean so nothing is carried over into the replay.
+func PrepareRerun(ctx context.Context, dir string, m *Manifest) (string, error) {
+	if m.BaseCommit == "" {
+		return "", errors.New("manifest has no base commit; the original run was not in a git repository")
+	}
+
+	changes, err := UncommittedChanges(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	if len(changes) > 0 {
+		return "", errors.New("working tree has uncommitted changes; commit or stash them before rerunning")
+	}
+
+	branch := RerunBranch(m)
+	if _, err := git(ctx, dir, "checkout", "--quiet", "-b", branch, m.BaseCommit); err != nil {
+		return "", fmt.Errorf("failed to create rerun branch: %w", err)
+	}
+	return branch, nil
+}
+
+// writeManifest records the run's inputs in its run directory.
+func (cc *ClaudeConfig) writeManifest(ctx context.Context, dir string, run *RunState) error {
+	claudeVersion := ""
+	if cc.cli != nil {
+		claudeVersion = cc.cli.version
+	}
+
+	m := &Manifest{
+		FormatVersion: ManifestFormatVersion,
+		RunID:         run.ID,
+		CreatedAt:     run.StartedAt,
+		Feature:       run.Feature,
+		SpecHash:      SpecHash(run.Feature),
+		Model:         cc.model,
+		Config:        cc.settings,
+		GonzoVersion:  Version,
+		ClaudeVersion: claudeVersion,
+		BaseCommit:    run.StartSHA,
+		BaseBranch:    run.StartBranch,
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return run.SaveArtifact(dir, ManifestArtifact, append(data, '\n'))
+}
+
+// ClaudeVersion returns the version reported by the Claude CLI.
+func ClaudeVersion(ctx context.Context) (string, error) {
+	out, err := commandContext(ctx, ClaudeCodeCli, "--version").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,115 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStartRun_WritesManifest(t *testing.T) {
+	dir := initGitRepo(t)
+	base := runGit(t, dir, "rev-parse", "HEAD")
+
+	cc := New().WithModel(ClaudeHaiku).WithSettings(map[string]interface{}{"max-iterations": 3})
+	run, err := cc.startRun(context.Background(), dir, "add a login button")
+	if err != nil {
+		t.Fatalf("startRun() returned error: %v", err)
+	}
+
+	m, err := LoadManifest(dir, run.ID)
+	if err != nil {
+		t.Fatalf("LoadManifest() returned error: %v", err)
+	}
+	if err := m.Verify(); err != nil {
+		t.Errorf("Verify() returned error: %v", err)
+	}
+	if m.RunID != run.ID || m.Model != ClaudeHaiku || m.Feature != "add a login button" {
+		t.Errorf("unexpected manifest: %+v", m)
+	}
+	if m.BaseCommit != base || m.BaseBranch != "main" {
+		t.Errorf("expected base %s on main, got %s on %s", base, m.BaseCommit, m.BaseBranch)
+	}
+	if m.GonzoVersion != Version {
+		t.Errorf("expected gonzo version %q, got %q", Version, m.GonzoVersion)
+	}
+	if m.Config["max-iterations"] != float64(3) {
+		t.Errorf("expected resolved config to be recorded, got %v", m.Config)
+	}
+}
+
+func TestLoadManifest_Path(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.json")
+	writeFile(t, path, `{"run_id": "r1", "feature": "f", "spec_hash": "`+SpecHash("f")+`"}`)
+
+	m, err := LoadManifest(t.TempDir(), path)
+	if err != nil {
+		t.Fatalf("LoadManifest() returned error: %v", err)
+	}
+	if m.RunID != "r1" {
+		t.Errorf("expected run r1, got %q", m.RunID)
+	}
+
+	if _, err := LoadManifest(dir, "missing-run"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestManifestVerify(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest Manifest
+		wantErr  bool
+	}{
+		{"valid", Manifest{Feature: "f", SpecHash: SpecHash("f")}, false},
+		{"no feature", Manifest{}, true},
+		{"edited feature", Manifest{Feature: "g", SpecHash: SpecHash("f")}, true},
+		{"newer format", Manifest{FormatVersion: ManifestFormatVersion + 1, Feature: "f", SpecHash: SpecHash("f")}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.manifest.Verify(); (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrepareRerun(t *testing.T) {
+	dir := initGitRepo(t)
+	base := runGit(t, dir, "rev-parse", "HEAD")
+	writeAndCommit(t, dir, "later.txt", "later\n", "later commit")
+
+	m := &Manifest{RunID: "20260101-000000-abcd", BaseCommit: base}
+	branch, err := PrepareRerun(context.Background(), dir, m)
+	if err != nil {
+		t.Fatalf("PrepareRerun() returned error: %v", err)
+	}
+
+	if got := runGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); got != branch || branch != RerunBranch(m) {
+		t.Errorf("expected to be on %s, got %s", RerunBranch(m), got)
+	}
+	if got := runGit(t, dir, "rev-parse", "HEAD"); got != base {
+		t.Errorf("expected rerun branch at %s, got %s", base, got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "later.txt")); !os.IsNotExist(err) {
+		t.Error("expected files from later commits to be absent")
+	}
+}
+
+func TestPrepareRerun_Errors(t *testing.T) {
+	dir := initGitRepo(t)
+	base := runGit(t, dir, "rev-parse", "HEAD")
+
+	if _, err := PrepareRerun(context.Background(), dir, &Manifest{RunID: "r1"}); err == nil {
+		t.Error("expected error for a manifest without base commit")
+	}
+
+	writeFile(t, filepath.Join(dir, "README.md"), "dirty\n")
+	if _, err := PrepareRerun(context.Background(), dir, &Manifest{RunID: "r1", BaseCommit: base}); err == nil {
+		t.Error("expected error for a dirty working tree")
+	}
+}
@@ -0,0 +1,21 @@
+package gonzo
+
+import (
+	"regexp"
+	"strings"
+)
+
+// controlMarkerPattern matches the <promise>…</promise> tags the agent uses to signal gonzo.
+var controlMarkerPattern = regexp.MustCompile(`(?s)<promise>.*?</promise>`)
+
+// StripControlMarkers removes the completion signal, any other given markers and all
+// <promise> control tags from the agent output, so it can be shown to users or used in PR bodies.
+func StripControlMarkers(out string, markers ...string) string {
+	for _, m := range markers {
+		if m != "" {
+			out = strings.ReplaceAll(out, m, "")
+		}
+	}
+	out = controlMarkerPattern.ReplaceAllString(out, "")
+	return strings.TrimSpace(out)
+}
@@ -0,0 +1,27 @@
+package gonzo
+
+import "testing"
+
+func TestStripControlMarkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		markers  []string
+		expected string
+	}{
+		{"no markers", "all done", nil, "all done"},
+		{"completion signal", "all done\n<promise>COMPLETE</promise>\n", []string{DefaultCompletionSignal}, "all done"},
+		{"other promise tags", "<promise>BLOCKED</promise> waiting on review", nil, "waiting on review"},
+		{"multiline promise", "done <promise>\nCOMPLETE\n</promise>", nil, "done"},
+		{"custom marker", "finished [[DONE]]", []string{"[[DONE]]"}, "finished"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := StripControlMarkers(tt.input, tt.markers...)
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package gonzo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// WithMCPConfig forwards MCP server definitions to every Claude CLI call, so Claude can use the
+// tools of those servers. Each entry is the path of a JSON file with an mcpServers object, as
+// accepted by the --mcp-config flag of the Claude CLI, or such an object inline.
+func WithMCPConfig(mcpConfig []string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.mcpConfig = mcpConfig
+	}
+}
+
+// WithMCPConfig applies the WithMCPConfig option to cc.
+//
+// Deprecated: Pass WithMCPConfig to New instead.
+func (cc *ClaudeConfig) WithMCPConfig(mcpConfig []string) *ClaudeConfig {
+	WithMCPConfig(mcpConfig)(cc)
+	return cc
+}
+
+// mcpArgs returns the flags of the Claude CLI loading the MCP servers, if any, with the server of
+// the permission proxy of the run in progress.
+func (cc *ClaudeConfig) mcpArgs() []string {
+	config := cc.mcpConfig
+	if cc.proxy != nil {
+		config = append(slices.Clone(config), cc.proxy.mcpConfig())
+	}
+	if len(config) == 0 {
+		return nil
+	}
+	return append([]string{"--mcp-config"}, config...)
+}
+
+// checkMCPConfig reports MCP configurations that cannot be read or are not valid JSON before the
+// run starts, rather than having every Claude CLI call fail on them.
+func (cc *ClaudeConfig) checkMCPConfig(dir string) error {
+	for _, entry := range cc.mcpConfig {
+		data := []byte(entry)
+		if !strings.HasPrefix(strings.TrimSpace(entry), "{") {
+			path := entry
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			var err error
+			if data, err = os.ReadFile(path); err != nil {
+				return fmt.Errorf("failed to read MCP config: %w", err)
+			}
+		}
+		var servers struct {
+			MCPServers map[string]json.RawMessage `json:"mcpServers"`
+		}
+		if err := json.Unmarshal(data, &servers); err != nil {
+			return fmt.Errorf("invalid MCP config %s: %w", firstLine(entry), err)
+		}
+		if len(servers.MCPServers) == 0 {
+			return fmt.Errorf("MCP config %s defines no mcpServers", firstLine(entry))
+		}
+	}
+	return nil
+}
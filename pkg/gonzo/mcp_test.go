@@ -0,0 +1,78 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testMCPConfig = `{"mcpServers":{"tickets":{"command":"tickets-mcp","args":["--stdio"]}}}`
+
+func TestCheckMCPConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "mcp.json"), []byte(testMCPConfig), 0644); err != nil {
+		t.Fatalf("failed to write MCP config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "empty.json"), []byte(`{}`), 0644); err != nil {
+		t.Fatalf("failed to write MCP config: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		config  []string
+		wantErr string
+	}{
+		{"none", nil, ""},
+		{"file", []string{"mcp.json"}, ""},
+		{"inline", []string{testMCPConfig}, ""},
+		{"missing file", []string{"missing.json"}, "failed to read MCP config"},
+		{"invalid inline", []string{`{"mcpServers":`}, "invalid MCP config"},
+		{"no servers", []string{"empty.json"}, "defines no mcpServers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New().WithMCPConfig(tt.config).checkMCPConfig(dir)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerate_MCPConfig(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "mcp.json"), []byte(testMCPConfig), 0644); err != nil {
+		t.Fatalf("failed to write MCP config: %v", err)
+	}
+	var calls [][]string
+	mock := mockCommandContext("Done "+DefaultCompletionSignal, 0)
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if slices.Contains(args, "--print") {
+			calls = append(calls, args)
+		}
+		return mock(ctx, name, args...)
+	}
+
+	if _, err := New(WithDir(dir)).WithQuiet(true).WithMCPConfig([]string{"mcp.json"}).Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 1 || !strings.Contains(strings.Join(calls[0], " "), "--mcp-config mcp.json --print") {
+		t.Errorf("expected the MCP config to be forwarded, got %q", calls)
+	}
+
+	_, err := New(WithDir(dir)).WithQuiet(true).WithMCPConfig([]string{"missing.json"}).Generate(context.Background(), "add a login button")
+	if ExitCode(err) != ExitPreflight {
+		t.Errorf("expected a missing MCP config to fail the preflight, got %v", err)
+	}
+}
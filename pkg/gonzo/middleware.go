@@ -0,0 +1,180 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Middleware wraps a Runner with a concern that cuts across runs, such as retrying them,
+// enforcing a budget, logging or measuring them, leaving Generate to the loop itself.
+type Middleware func(Runner) Runner
+
+// RunnerFunc is a Runner calling itself for Generate.
+type RunnerFunc func(ctx context.Context, feature string) (string, error)
+
+// Generate calls f.
+func (f RunnerFunc) Generate(ctx context.Context, feature string) (string, error) {
+	return f(ctx, feature)
+}
+
+// Chain wraps runner with middlewares, the first one outermost: it sees each run before and
+// after the others.
+func Chain(runner Runner, middlewares ...Middleware) Runner {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		runner = middlewares[i](runner)
+	}
+	return runner
+}
+
+// wrappedRunner is a Runner made by a Middleware, which remembers the runner it wraps.
+type wrappedRunner struct {
+	inner    Runner
+	generate RunnerFunc
+}
+
+// Wrap returns a Runner generating with generate in place of inner, for middlewares. RunnerAs
+// still finds the capabilities of inner, e.g. Planner, through the returned runner.
+func Wrap(inner Runner, generate RunnerFunc) Runner {
+	return wrappedRunner{inner: inner, generate: generate}
+}
+
+func (w wrappedRunner) Generate(ctx context.Context, feature string) (string, error) {
+	return w.generate(ctx, feature)
+}
+
+// Unwrap returns the runner wrapped.
+func (w wrappedRunner) Unwrap() Runner {
+	return w.inner
+}
+
+// RunnerAs returns runner as a T, such as a Planner or an Observer, or else the first runner it
+// wraps, through Wrap, that is one. Only Generate goes through the middlewares.
+func RunnerAs[T any](runner Runner) (T, bool) {
+	for runner != nil {
+		if t, ok := runner.(T); ok {
+			return t, true
+		}
+		wrapper, ok := runner.(interface{ Unwrap() Runner })
+		if !ok {
+			break
+		}
+		runner = wrapper.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// Retry runs a failed run again, up to retries times, waiting delay before the first retry and
+// twice as long before each next one. Runs that failed before their first iteration or were
+// interrupted are not retried, nor are runs that ended without completing, which return no error.
+func Retry(retries int, delay time.Duration) Middleware {
+	return func(next Runner) Runner {
+		return Wrap(next, func(ctx context.Context, feature string) (string, error) {
+			wait := delay
+			for attempt := 0; ; attempt++ {
+				response, err := next.Generate(ctx, feature)
+				if err == nil || attempt >= retries {
+					return response, err
+				}
+				if code := ExitCode(err); code == ExitPreflight || code == ExitAborted {
+					return response, err
+				}
+				select {
+				case <-ctx.Done():
+					return response, errors.Join(err, ctx.Err())
+				case <-time.After(wait):
+				}
+				wait *= 2
+			}
+		})
+	}
+}
+
+// ErrBudgetExceeded is returned by runners wrapped with Budget once their runs cost the budget.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Budget refuses to start a run once the runs recorded in the repository at dir since Budget was
+// called cost maxCost US dollars or more, across Generate calls and concurrent runs. The max cost
+// of the runs themselves, WithGuards, still stops a run that goes over.
+func Budget(dir string, maxCost float64) Middleware {
+	since := time.Now()
+	return func(next Runner) Runner {
+		return Wrap(next, func(ctx context.Context, feature string) (string, error) {
+			states, err := ListRunStates(dir)
+			if err != nil {
+				return "", withExitCode(ExitPreflight, fmt.Errorf("failed to read the cost of the runs: %w", err))
+			}
+			var spent float64
+			for _, state := range states {
+				if !state.StartedAt.Before(since) {
+					spent += state.CostUSD
+				}
+			}
+			if spent >= maxCost {
+				return "", withExitCode(ExitBudgetExceeded,
+					fmt.Errorf("%w: the runs cost $%.2f of the $%.2f budget", ErrBudgetExceeded, spent, maxCost))
+			}
+			return next.Generate(ctx, feature)
+		})
+	}
+}
+
+// Logging logs the start and end of each run to logger, with its duration and exit code.
+func Logging(logger *slog.Logger) Middleware {
+	return func(next Runner) Runner {
+		return Wrap(next, func(ctx context.Context, feature string) (string, error) {
+			start := time.Now()
+			logger.InfoContext(ctx, "run started", slog.String("feature", firstLine(feature)))
+			response, err := next.Generate(ctx, feature)
+			attrs := []slog.Attr{slog.Duration("duration", time.Since(start)), slog.Int("exit_code", ExitCode(err))}
+			if err != nil {
+				logger.LogAttrs(ctx, slog.LevelError, "run failed", append(attrs, slog.String("error", err.Error()))...)
+			} else {
+				logger.LogAttrs(ctx, slog.LevelInfo, "run finished", attrs...)
+			}
+			return response, err
+		})
+	}
+}
+
+// RunMetrics are the figures of the runs measured by a Metrics.
+type RunMetrics struct {
+	Runs     int
+	Failures int
+	// Duration is the time spent in the runs, summed over concurrent ones.
+	Duration time.Duration
+}
+
+// Metrics measures the runs of the runners wrapped with its Middleware. Its zero value is ready
+// to use.
+type Metrics struct {
+	mu      sync.Mutex
+	metrics RunMetrics
+}
+
+// Middleware counts the runs of the runner it wraps, their failures and their duration.
+func (m *Metrics) Middleware(next Runner) Runner {
+	return Wrap(next, func(ctx context.Context, feature string) (string, error) {
+		start := time.Now()
+		response, err := next.Generate(ctx, feature)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.metrics.Runs++
+		if err != nil {
+			m.metrics.Failures++
+		}
+		m.metrics.Duration += time.Since(start)
+		return response, err
+	})
+}
+
+// Snapshot returns the figures of the runs so far.
+func (m *Metrics) Snapshot() RunMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.metrics
+}
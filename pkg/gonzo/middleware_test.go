@@ -0,0 +1,176 @@
+package gonzo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Runner) Runner {
+			return Wrap(next, func(ctx context.Context, feature string) (string, error) {
+				calls = append(calls, name+" before")
+				response, err := next.Generate(ctx, feature)
+				calls = append(calls, name+" after")
+				return response, err
+			})
+		}
+	}
+	runner := Chain(RunnerFunc(func(ctx context.Context, feature string) (string, error) {
+		calls = append(calls, "run")
+		return "done", nil
+	}), record("outer"), record("inner"))
+
+	if _, err := runner.Generate(context.Background(), "feature"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "outer before,inner before,run,inner after,outer after"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("expected calls %q, got %q", want, got)
+	}
+}
+
+func TestRunnerAs(t *testing.T) {
+	cc := New()
+	var metrics Metrics
+	runner := Chain(cc, Logging(slog.New(slog.DiscardHandler)), metrics.Middleware)
+
+	if _, ok := runner.(Planner); ok {
+		t.Fatal("expected the chain not to be a Planner itself")
+	}
+	planner, ok := RunnerAs[Planner](runner)
+	if !ok || planner != Planner(cc) {
+		t.Errorf("expected the wrapped ClaudeConfig as Planner, got %v", planner)
+	}
+	if _, ok := RunnerAs[ProgressStore](runner); ok {
+		t.Error("expected no ProgressStore in the chain")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{"success", nil, 1},
+		{"failure", errors.New("claude failed"), 3},
+		{"preflight", withExitCode(ExitPreflight, errors.New("no credentials")), 1},
+		{"aborted", context.Canceled, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			runner := Chain(RunnerFunc(func(ctx context.Context, feature string) (string, error) {
+				calls++
+				return "", tt.err
+			}), Retry(2, time.Millisecond))
+
+			_, err := runner.Generate(context.Background(), "feature")
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected error %v, got %v", tt.err, err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestRetry_SucceedsAfterFailure(t *testing.T) {
+	calls := 0
+	runner := Chain(RunnerFunc(func(ctx context.Context, feature string) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", errors.New("claude failed")
+		}
+		return "done", nil
+	}), Retry(3, time.Millisecond))
+
+	response, err := runner.Generate(context.Background(), "feature")
+	if err != nil || response != "done" {
+		t.Errorf("expected the response of the retry, got %q, %v", response, err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	dir := t.TempDir()
+	old := &RunState{ID: "old", Status: RunStatusCompleted, CostUSD: 10, StartedAt: time.Now().Add(-time.Hour)}
+	if err := old.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	runner := Chain(RunnerFunc(func(ctx context.Context, feature string) (string, error) {
+		calls++
+		run := &RunState{ID: NewRunID() + feature, Status: RunStatusCompleted, CostUSD: 0.75, StartedAt: time.Now()}
+		return "done", run.Save(dir)
+	}), Budget(dir, 1))
+
+	// Runs older than the budget do not count against it
+	for _, feature := range []string{"a", "b"} {
+		if _, err := runner.Generate(context.Background(), feature); err != nil {
+			t.Fatalf("run %s: unexpected error: %v", feature, err)
+		}
+	}
+	_, err := runner.Generate(context.Background(), "c")
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	if code := ExitCode(err); code != ExitBudgetExceeded {
+		t.Errorf("expected exit code %d, got %d", ExitBudgetExceeded, code)
+	}
+	if !strings.Contains(err.Error(), "$1.50 of the $1.00 budget") {
+		t.Errorf("expected the spending in the error, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 runs, got %d", calls)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	runner := Chain(RunnerFunc(func(ctx context.Context, feature string) (string, error) {
+		return "", errors.New("claude failed")
+	}), Logging(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	_, _ = runner.Generate(context.Background(), "Add a feature\n\nwith details")
+	for _, want := range []string{`msg="run started" feature="Add a feature"`, `msg="run failed"`, "exit_code=1", `error="claude failed"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected log to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	var metrics Metrics
+	fail := false
+	runner := Chain(RunnerFunc(func(ctx context.Context, feature string) (string, error) {
+		time.Sleep(time.Millisecond)
+		if fail {
+			return "", errors.New("claude failed")
+		}
+		return "done", nil
+	}), metrics.Middleware)
+
+	_, _ = runner.Generate(context.Background(), "feature")
+	fail = true
+	_, _ = runner.Generate(context.Background(), "feature")
+
+	got := metrics.Snapshot()
+	if got.Runs != 2 || got.Failures != 1 {
+		t.Errorf("expected 2 runs and 1 failure, got %+v", got)
+	}
+	if got.Duration < 2*time.Millisecond {
+		t.Errorf("expected the duration of both runs, got %s", got.Duration)
+	}
+}
@@ -0,0 +1,151 @@
+package gonzo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Run events notifications are sent for.
+const (
+	NotifyStart    = "start"
+	NotifyComplete = "complete"
+	NotifyFail     = "fail"
+	NotifyBudget   = "budget"
+)
+
+// DefaultNotifyEvents are the events notifications are sent for unless configured otherwise.
+var DefaultNotifyEvents = []string{NotifyComplete, NotifyFail, NotifyBudget}
+
+// webhookTimeout bounds the time a notification may take to be delivered.
+const webhookTimeout = 10 * time.Second
+
+// Notifications selects where and when the outcome of runs is posted.
+type Notifications struct {
+	// WebhookURL is the Slack, Discord or other incoming webhook notifications are posted to.
+	// When empty, the webhook-url secret is used, if set.
+	WebhookURL string
+	// Email sends the notifications by email as well, for networks without chat webhooks.
+	Email EmailOptions
+	// Events lists the events notified: NotifyStart, NotifyComplete, NotifyFail and NotifyBudget.
+	Events []string
+}
+
+// webhookPayload is the JSON body posted to a webhook. Slack reads Text, Discord reads Content,
+// and other webhooks get the summary of the run along with the message.
+type webhookPayload struct {
+	Text    string      `json:"text,omitempty"`
+	Content string      `json:"content,omitempty"`
+	Event   string      `json:"event,omitempty"`
+	Run     *RunSummary `json:"run,omitempty"`
+}
+
+// notifyEvent returns the event a finished run is notified as: NotifyComplete, NotifyBudget when
+// it reached its max duration or max cost, and NotifyFail otherwise.
+func notifyEvent(run *RunState) string {
+	switch {
+	case run.Status == RunStatusCompleted:
+		return NotifyComplete
+	case run.Status == RunStatusIncomplete && (run.StopReason == StopReasonMaxDuration || run.StopReason == StopReasonMaxCost):
+		return NotifyBudget
+	}
+	return NotifyFail
+}
+
+// notify posts a notification of the event to the webhook, when one is set, and emails it,
+// when emails are enabled, provided the event is selected. Failures are only logged.
+func (cc *ClaudeConfig) notify(ctx context.Context, dir string, run *RunState, event string) {
+	if !slices.Contains(cc.notifications.Events, event) {
+		return
+	}
+	message := notificationMessage(run, event)
+
+	webhookURL := cc.notifications.WebhookURL
+	if webhookURL == "" {
+		webhookURL, _ = Secret(ctx, SecretWebhookURL)
+	}
+	if webhookURL != "" {
+		payload := newWebhookPayload(webhookURL, event, message, NewRunSummary(dir, run.Feature, run, "", nil))
+		if err := postWebhook(ctx, webhookURL, payload); err != nil {
+			cc.logWarn("Failed to send %s notification: %s", event, Redact(err.Error(), []string{webhookURL}))
+		}
+	}
+
+	if cc.notifications.Email.enabled() {
+		if err := cc.sendNotificationEmail(ctx, dir, run, message); err != nil {
+			cc.logWarn("Failed to email %s notification: %v", event, err)
+		}
+	}
+}
+
+// notificationMessage returns the text of the notification of an event of the run.
+func notificationMessage(run *RunState, event string) string {
+	var b strings.Builder
+	switch event {
+	case NotifyStart:
+		fmt.Fprintf(&b, "gonzo run %s started", run.ID)
+	case NotifyComplete:
+		fmt.Fprintf(&b, "gonzo run %s completed after %d iteration(s)", run.ID, run.Iterations)
+	case NotifyBudget:
+		fmt.Fprintf(&b, "gonzo run %s reached its max duration after %d iteration(s)", run.ID, run.Iterations)
+	default:
+		fmt.Fprintf(&b, "gonzo run %s %s after %d iteration(s)", run.ID, run.Status, run.Iterations)
+	}
+	fmt.Fprintf(&b, ": %s", firstLine(run.Feature))
+	if run.Branch != "" {
+		fmt.Fprintf(&b, "\nBranch: %s", run.Branch)
+	}
+	if run.PRURL != "" {
+		fmt.Fprintf(&b, "\nPull request: %s", run.PRURL)
+	}
+	if run.CostUSD > 0 {
+		fmt.Fprintf(&b, "\nCost: $%.2f", run.CostUSD)
+	}
+	return b.String()
+}
+
+// newWebhookPayload returns the payload of a notification in the format of the webhook, told
+// apart by its host.
+func newWebhookPayload(webhookURL string, event string, message string, summary *RunSummary) webhookPayload {
+	host := ""
+	if u, err := url.Parse(webhookURL); err == nil {
+		host = u.Hostname()
+	}
+	switch {
+	case host == "hooks.slack.com":
+		return webhookPayload{Text: message}
+	case host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com"):
+		return webhookPayload{Content: message}
+	}
+	return webhookPayload{Text: message, Event: event, Run: summary}
+}
+
+// postWebhook posts the payload to the webhook as JSON.
+func postWebhook(ctx context.Context, webhookURL string, payload webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package gonzo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewWebhookPayload(t *testing.T) {
+	summary := &RunSummary{ID: "20260201-202613-a1b2c3"}
+	tests := []struct {
+		url  string
+		want webhookPayload
+	}{
+		{"https://hooks.slack.com/services/T0/B0/x", webhookPayload{Text: "done"}},
+		{"https://discord.com/api/webhooks/1/x", webhookPayload{Content: "done"}},
+		{"https://ci.example.com/gonzo", webhookPayload{Text: "done", Event: NotifyComplete, Run: summary}},
+	}
+	for _, tt := range tests {
+		if got := newWebhookPayload(tt.url, NotifyComplete, "done", summary); got != tt.want {
+			t.Errorf("newWebhookPayload(%q) = %+v, want %+v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestNotifyEvent(t *testing.T) {
+	tests := []struct {
+		run  RunState
+		want string
+	}{
+		{RunState{Status: RunStatusCompleted}, NotifyComplete},
+		{RunState{Status: RunStatusIncomplete, StopReason: StopReasonMaxDuration}, NotifyBudget},
+		{RunState{Status: RunStatusIncomplete, StopReason: StopReasonMaxIterations}, NotifyFail},
+		{RunState{Status: RunStatusFailed}, NotifyFail},
+	}
+	for _, tt := range tests {
+		if got := notifyEvent(&tt.run); got != tt.want {
+			t.Errorf("notifyEvent(%s, %s) = %q, want %q", tt.run.Status, tt.run.StopReason, got, tt.want)
+		}
+	}
+}
+
+func TestGenerate_Notifications(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var mu sync.Mutex
+	var payloads []webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode notification: %v", err)
+		}
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("Done "+DefaultCompletionSignal, 0)
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithNotifications(Notifications{WebhookURL: server.URL, Events: []string{NotifyStart, NotifyComplete}})
+	if _, err := cc.Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(payloads) != 2 || payloads[0].Event != NotifyStart || payloads[1].Event != NotifyComplete {
+		t.Fatalf("expected start and complete notifications, got %+v", payloads)
+	}
+	if !strings.Contains(payloads[1].Text, "completed after 1 iteration(s): add a login button") {
+		t.Errorf("unexpected message %q", payloads[1].Text)
+	}
+	if payloads[1].Run == nil || payloads[1].Run.Status != RunStatusCompleted || payloads[1].Run.ID == "" {
+		t.Errorf("expected the summary of the completed run, got %+v", payloads[1].Run)
+	}
+}
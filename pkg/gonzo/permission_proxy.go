@@ -0,0 +1,390 @@
+package gonzo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// PermissionPromptTool is the MCP tool the Claude CLI asks for permission in PermissionModeProxy:
+// the approve tool of the gonzo server that ServePermissionPrompts runs.
+const PermissionPromptTool = "mcp__gonzo__approve"
+
+// Decisions of a permission policy.
+const (
+	permissionAllow = "allow"
+	permissionAsk   = "ask"
+	permissionDeny  = "deny"
+)
+
+// PermissionPolicy decides the permission prompts of the Claude CLI in PermissionModeProxy. Rules
+// are in the format of --allowedTools: a tool name such as "WebFetch", "mcp__tickets" for all the
+// tools of an MCP server, "Bash(go test:*)" for the commands starting with "go test", or
+// "Edit(src/**)" for the files matching a glob relative to the repository; Edit rules apply to
+// all the file editing tools. Allow rules never match a compound shell command or a file outside
+// the repository.
+type PermissionPolicy struct {
+	// Allow lists the tools allowed without asking.
+	Allow []string
+	// Ask lists the tools asked about on the terminal, as are the tools no rule matches.
+	Ask []string
+	// Deny lists the tools denied, whatever the other rules.
+	Deny []string
+}
+
+// decide returns whether the tool is allowed, asked about or denied, with the rule deciding it:
+// deny rules first, then allow rules, then ask rules. Allow rules never match a compound shell
+// command or a path outside the repository, which are asked about unless denied.
+func (p PermissionPolicy) decide(t toolUse) (string, string) {
+	for _, rules := range []struct {
+		decision string
+		rules    []string
+	}{{permissionDeny, p.Deny}, {permissionAllow, p.Allow}, {permissionAsk, p.Ask}} {
+		if rules.decision == permissionAllow && !t.confined() {
+			continue
+		}
+		for _, rule := range rules.rules {
+			if matchPermissionRule(rule, t) {
+				return rules.decision, rule
+			}
+		}
+	}
+	return permissionAsk, ""
+}
+
+// shellOperators chain, substitute or redirect shell commands, which makes a command do more than
+// the rule matching its start allows.
+var shellOperators = []string{";", "&", "|", "`", "$(", "<", ">", "\n", "\r"}
+
+// confined reports whether the tool use is a single shell command, without shell operators, or
+// on a path inside the repository, if any.
+func (t toolUse) confined() bool {
+	for _, op := range shellOperators {
+		if strings.Contains(t.Command, op) {
+			return false
+		}
+	}
+	if t.Path == "" {
+		return true
+	}
+	clean := path.Clean(filepath.ToSlash(t.Path))
+	return !path.IsAbs(clean) && !filepath.IsAbs(t.Path) && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// commandParts returns the commands making up a compound shell command, split at its shell
+// operators, with the spaces around them trimmed.
+func commandParts(command string) []string {
+	for _, op := range shellOperators[1:] {
+		command = strings.ReplaceAll(command, op, shellOperators[0])
+	}
+	var parts []string
+	for _, part := range strings.Split(strings.ReplaceAll(command, ")", ";"), ";") {
+		// Subshells and groups start with their opening bracket
+		if part = strings.TrimSpace(strings.TrimLeft(part, "({! \t")); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// matchPermissionRule reports whether the rule of a PermissionPolicy matches the tool use. A
+// command rule matches a compound command when it matches any of its parts.
+func matchPermissionRule(rule string, t toolUse) bool {
+	name, spec, scoped := strings.Cut(rule, "(")
+	spec, closed := strings.CutSuffix(spec, ")")
+	if scoped && !closed {
+		return false
+	}
+	switch {
+	case name == t.Name:
+	case name == "Edit" && slices.Contains(fileEditTools, t.Name):
+	case strings.HasPrefix(name, "mcp__") && strings.Count(name, "__") == 1 && strings.HasPrefix(t.Name, name+"__"):
+	default:
+		if ok, _ := path.Match(name, t.Name); !ok {
+			return false
+		}
+	}
+	if !scoped || spec == "*" {
+		return true
+	}
+	if t.Command != "" {
+		return slices.ContainsFunc(append(commandParts(t.Command), t.Command), func(command string) bool {
+			if prefix, ok := strings.CutSuffix(spec, ":*"); ok {
+				return strings.HasPrefix(command, prefix)
+			}
+			return command == spec
+		})
+	}
+	return t.Path != "" && matchPath(spec, t.Path)
+}
+
+// permissionRequest is a permission prompt of the Claude CLI, as passed to the approve tool.
+type permissionRequest struct {
+	ToolName string          `json:"tool_name"`
+	Input    json.RawMessage `json:"input"`
+}
+
+// permissionDecision is the answer of the approve tool to a permission prompt, in the format the
+// Claude CLI expects.
+type permissionDecision struct {
+	Behavior     string          `json:"behavior"`
+	UpdatedInput json.RawMessage `json:"updatedInput,omitempty"`
+	Message      string          `json:"message,omitempty"`
+}
+
+// permissionInput is where the answers to the permission prompts asked on the terminal are read
+// from. It is a variable for testing.
+var permissionInput io.Reader = os.Stdin
+
+// permissionProxy decides the permission prompts relayed by the approve tool over a Unix socket
+// during a run in PermissionModeProxy.
+type permissionProxy struct {
+	cc  *ClaudeConfig
+	dir string
+	// executable is the gonzo binary the Claude CLI runs the MCP server of the approve tool with.
+	executable string
+	tmp        string
+	socket     string
+	listener   net.Listener
+	// interactive is whether prompts are asked on the terminal, else they are denied.
+	interactive bool
+
+	// mu serializes the questions asked on the terminal.
+	mu     sync.Mutex
+	answer *bufio.Reader
+	// always are the tools allowed for the rest of the run on the terminal.
+	always map[string]bool
+
+	wg sync.WaitGroup
+}
+
+// startPermissionProxy listens for the permission prompts of the Claude CLI until Close, when the
+// permission mode is PermissionModeProxy, or returns nil.
+func (cc *ClaudeConfig) startPermissionProxy(dir string) (*permissionProxy, error) {
+	if cc.permissions.mode() != PermissionModeProxy {
+		return nil, nil
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate gonzo for the permission proxy: %w", err)
+	}
+	tmp, err := os.MkdirTemp("", "gonzo-permissions-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the permission proxy: %w", err)
+	}
+	socket := filepath.Join(tmp, "proxy.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		Swallow(os.RemoveAll(tmp))
+		return nil, fmt.Errorf("failed to start the permission proxy: %w", err)
+	}
+	p := &permissionProxy{
+		cc:          cc,
+		dir:         dir,
+		executable:  executable,
+		tmp:         tmp,
+		socket:      socket,
+		listener:    listener,
+		interactive: !cc.quiet && cc.events == nil && isTerminal(os.Stdin),
+		answer:      bufio.NewReader(permissionInput),
+		always:      map[string]bool{},
+	}
+	p.wg.Add(1)
+	go p.serve()
+	return p, nil
+}
+
+// serve answers the connections of the approve tool, one permission prompt each.
+func (p *permissionProxy) serve() {
+	defer p.wg.Done()
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer func() { p.cc.swallow(conn.Close()) }()
+			var req permissionRequest
+			if err := json.NewDecoder(conn).Decode(&req); err != nil {
+				return
+			}
+			p.cc.swallow(json.NewEncoder(conn).Encode(p.decide(req)))
+		}()
+	}
+}
+
+// decide answers a permission prompt from the policy, asking on the terminal when it says so.
+func (p *permissionProxy) decide(req permissionRequest) permissionDecision {
+	t := newToolUse(req.ToolName, req.Input, p.dir)
+	decision, rule := p.cc.permissions.Policy.decide(t)
+	if decision == permissionAsk {
+		decision, rule = p.ask(t)
+	}
+	if decision == permissionAllow {
+		p.cc.logDebug("  Permission: allowed %s (%s)", t, rule)
+		input := req.Input
+		if len(input) == 0 {
+			input = json.RawMessage("{}")
+		}
+		return permissionDecision{Behavior: permissionAllow, UpdatedInput: input}
+	}
+	p.cc.logWarn("  Permission: denied %s (%s)", t, rule)
+	return permissionDecision{Behavior: permissionDeny, Message: fmt.Sprintf("Permission to use %s was denied (%s).", t, rule)}
+}
+
+// ask asks on the terminal whether to allow the tool, and returns the answer with its reason.
+func (p *permissionProxy) ask(t toolUse) (string, string) {
+	if !p.interactive {
+		return permissionDeny, "no terminal to ask on"
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.always[t.Name] {
+		return permissionAllow, "always allowed on the terminal"
+	}
+	for {
+		_, _ = fmt.Fprintf(p.cc.console(), "  Allow %s? [y]es, [n]o, [a]lways for %s: ", t, t.Name)
+		line, err := p.answer.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			return permissionAllow, "allowed on the terminal"
+		case "a", "always":
+			p.always[t.Name] = true
+			return permissionAllow, "always allowed on the terminal"
+		case "n", "no":
+			return permissionDeny, "denied on the terminal"
+		}
+		if err != nil {
+			return permissionDeny, "no answer on the terminal"
+		}
+	}
+}
+
+// mcpConfig returns the inline MCP config of the server of the approve tool.
+func (p *permissionProxy) mcpConfig() string {
+	config, _ := json.Marshal(map[string]any{
+		"mcpServers": map[string]any{
+			"gonzo": map[string]any{
+				"command": p.executable,
+				"args":    []string{"permission-server", "--socket", p.socket},
+			},
+		},
+	})
+	return string(config)
+}
+
+// Close stops listening and removes the socket.
+func (p *permissionProxy) Close() error {
+	err := p.listener.Close()
+	p.wg.Wait()
+	return errors.Join(err, os.RemoveAll(p.tmp))
+}
+
+// mcpProtocolVersion is the version of the Model Context Protocol ServePermissionPrompts speaks
+// when the client does not ask for one.
+const mcpProtocolVersion = "2024-11-05"
+
+// ServePermissionPrompts runs the MCP server of the approve tool over stdio, reading JSON-RPC
+// messages from in and writing the replies to out until in ends. The Claude CLI calls the tool
+// with its permission prompts in PermissionModeProxy; each is relayed to the permission proxy of
+// the run listening on socket, and its decision returned.
+func ServePermissionPrompts(ctx context.Context, in io.Reader, out io.Writer, socket string) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	enc := json.NewEncoder(out)
+	for scanner.Scan() {
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		// Notifications are not answered
+		if len(msg.ID) == 0 {
+			continue
+		}
+		reply := map[string]any{"jsonrpc": "2.0", "id": msg.ID}
+		switch msg.Method {
+		case "initialize":
+			var params struct {
+				ProtocolVersion string `json:"protocolVersion"`
+			}
+			Swallow(json.Unmarshal(msg.Params, &params))
+			if params.ProtocolVersion == "" {
+				params.ProtocolVersion = mcpProtocolVersion
+			}
+			reply["result"] = map[string]any{
+				"protocolVersion": params.ProtocolVersion,
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "gonzo", "version": Version},
+			}
+		case "ping":
+			reply["result"] = map[string]any{}
+		case "tools/list":
+			reply["result"] = map[string]any{"tools": []any{map[string]any{
+				"name":        "approve",
+				"description": "Asks gonzo whether Claude may use a tool",
+				"inputSchema": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"tool_name":   map[string]any{"type": "string"},
+						"input":       map[string]any{"type": "object"},
+						"tool_use_id": map[string]any{"type": "string"},
+					},
+					"required": []string{"tool_name", "input"},
+				},
+			}}}
+		case "tools/call":
+			var params struct {
+				Name      string            `json:"name"`
+				Arguments permissionRequest `json:"arguments"`
+			}
+			if err := json.Unmarshal(msg.Params, &params); err != nil || params.Name != "approve" {
+				reply["error"] = map[string]any{"code": -32602, "message": "unknown tool or invalid arguments"}
+				break
+			}
+			decision, err := relayPermissionPrompt(ctx, socket, params.Arguments)
+			if err != nil {
+				decision = permissionDecision{Behavior: permissionDeny, Message: "gonzo could not decide: " + err.Error()}
+			}
+			text, _ := json.Marshal(decision)
+			reply["result"] = map[string]any{"content": []any{map[string]any{"type": "text", "text": string(text)}}}
+		default:
+			reply["error"] = map[string]any{"code": -32601, "message": "method not found: " + msg.Method}
+		}
+		if err := enc.Encode(reply); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// relayPermissionPrompt asks the permission proxy listening on socket to decide the prompt.
+func relayPermissionPrompt(ctx context.Context, socket string, req permissionRequest) (permissionDecision, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socket)
+	if err != nil {
+		return permissionDecision{}, fmt.Errorf("failed to reach the permission proxy: %w", err)
+	}
+	defer func() { Swallow(conn.Close()) }()
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return permissionDecision{}, fmt.Errorf("failed to reach the permission proxy: %w", err)
+	}
+	var decision permissionDecision
+	if err := json.NewDecoder(conn).Decode(&decision); err != nil {
+		return permissionDecision{}, fmt.Errorf("no answer from the permission proxy: %w", err)
+	}
+	return decision, nil
+}
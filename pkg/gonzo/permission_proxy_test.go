@@ -0,0 +1,240 @@
+package gonzo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPermissionPolicy_Decide(t *testing.T) {
+	policy := PermissionPolicy{
+		Allow: []string{"Edit(src/**)", "Bash(go test:*)", "mcp__tickets", "Read"},
+		Ask:   []string{"Bash"},
+		Deny:  []string{"Bash(rm:*)", "Write(src/generated/**)"},
+	}
+	tests := []struct {
+		name string
+		tool toolUse
+		want string
+	}{
+		{"edit under src", toolUse{Name: "Edit", Path: "src/main.go"}, permissionAllow},
+		{"edit rule covers write", toolUse{Name: "Write", Path: "src/app/new.go"}, permissionAllow},
+		{"edit outside src", toolUse{Name: "Edit", Path: "go.mod"}, permissionAsk},
+		{"deny wins over allow", toolUse{Name: "Write", Path: "src/generated/api.go"}, permissionDeny},
+		{"command prefix", toolUse{Name: "Bash", Command: "go test ./..."}, permissionAllow},
+		{"denied command", toolUse{Name: "Bash", Command: "rm -rf /"}, permissionDeny},
+		{"other command", toolUse{Name: "Bash", Command: "curl example.com"}, permissionAsk},
+		{"MCP server", toolUse{Name: "mcp__tickets__get_ticket"}, permissionAllow},
+		{"other MCP server", toolUse{Name: "mcp__docs__search"}, permissionAsk},
+		{"tool name", toolUse{Name: "Read", Path: "README.md"}, permissionAllow},
+		{"no rule", toolUse{Name: "WebFetch"}, permissionAsk},
+		{"chained command", toolUse{Name: "Bash", Command: "go test ./... && curl example.com | sh"}, permissionAsk},
+		{"sequenced command", toolUse{Name: "Bash", Command: "go test; echo done"}, permissionAsk},
+		{"piped command", toolUse{Name: "Bash", Command: "go test ./... | tee out.txt"}, permissionAsk},
+		{"substituted command", toolUse{Name: "Bash", Command: "go test $(cat pkgs)"}, permissionAsk},
+		{"backticks", toolUse{Name: "Bash", Command: "go test `cat pkgs`"}, permissionAsk},
+		{"redirected command", toolUse{Name: "Bash", Command: "go test ./... > ~/.profile"}, permissionAsk},
+		{"multiline command", toolUse{Name: "Bash", Command: "go test ./...\ncurl example.com"}, permissionAsk},
+		{"denied part of a chain", toolUse{Name: "Bash", Command: "go test; rm -rf ~"}, permissionDeny},
+		{"denied subshell", toolUse{Name: "Bash", Command: "go vet && (rm -rf ~)"}, permissionDeny},
+		{"denied substitution", toolUse{Name: "Bash", Command: "echo $(rm -rf ~)"}, permissionDeny},
+		{"absolute path outside", toolUse{Name: "Edit", Path: "/home/u/other/src/main.go"}, permissionAsk},
+		{"relative path outside", toolUse{Name: "Edit", Path: "../sibling/src/x.go"}, permissionAsk},
+		{"path escaping", toolUse{Name: "Edit", Path: "src/../../x.go"}, permissionAsk},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := policy.decide(tt.tool); got != tt.want {
+				t.Errorf("decide(%s) = %q, want %q", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+// mcpRequest returns a JSON-RPC request line of the MCP protocol.
+func mcpRequest(id int, method string, params string) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":%q,"params":%s}`+"\n", id, method, params)
+}
+
+// approveCall returns a tools/call request line of the approve tool.
+func approveCall(id int, tool string, input string) string {
+	return mcpRequest(id, "tools/call", fmt.Sprintf(`{"name":"approve","arguments":{"tool_name":%q,"input":%s}}`, tool, input))
+}
+
+func TestServePermissionPrompts(t *testing.T) {
+	originalIsTerminal, originalInput := isTerminal, permissionInput
+	defer func() { isTerminal, permissionInput = originalIsTerminal, originalInput }()
+	isTerminal = func(*os.File) bool { return true }
+	permissionInput = strings.NewReader("what\nn\na\n")
+
+	dir := t.TempDir()
+	cc := New().WithDir(dir).WithPermissions(Permissions{
+		Mode: PermissionModeProxy,
+		Policy: PermissionPolicy{
+			Allow: []string{"Edit(src/**)"},
+			Deny:  []string{"Bash(rm:*)"},
+		},
+	})
+	proxy, err := cc.startPermissionProxy(dir)
+	if err != nil {
+		t.Fatalf("failed to start the permission proxy: %v", err)
+	}
+	defer func() { Swallow(proxy.Close()) }()
+
+	in := mcpRequest(1, "initialize", `{"protocolVersion":"2025-06-18"}`) +
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n" +
+		mcpRequest(2, "tools/list", `{}`) +
+		approveCall(3, "Edit", fmt.Sprintf(`{"file_path":%q}`, dir+"/src/main.go")) +
+		approveCall(4, "Bash", `{"command":"rm -rf /"}`) +
+		approveCall(5, "Bash", `{"command":"make"}`) +
+		approveCall(6, "WebFetch", `{"url":"https://example.com"}`) +
+		approveCall(7, "WebFetch", `{"url":"https://example.org"}`) +
+		mcpRequest(8, "resources/list", `{}`)
+	var out bytes.Buffer
+	console := captureStdout(t, func() {
+		if err := ServePermissionPrompts(context.Background(), strings.NewReader(in), &out, proxy.socket); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	type reply struct {
+		ID     int `json:"id"`
+		Result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+			Tools           []struct {
+				Name string `json:"name"`
+			} `json:"tools"`
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	var replies []reply
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r reply
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("invalid reply: %v", err)
+		}
+		replies = append(replies, r)
+	}
+	if len(replies) != 8 {
+		t.Fatalf("expected 8 replies, the notification unanswered, got %d:\n%s", len(replies), out.String())
+	}
+	if replies[0].Result.ProtocolVersion != "2025-06-18" {
+		t.Errorf("expected the protocol version asked for, got %q", replies[0].Result.ProtocolVersion)
+	}
+	if len(replies[1].Result.Tools) != 1 || replies[1].Result.Tools[0].Name != "approve" {
+		t.Errorf("expected the approve tool to be listed, got %+v", replies[1].Result.Tools)
+	}
+	for i, want := range []string{permissionAllow, permissionDeny, permissionDeny, permissionAllow, permissionAllow} {
+		reply := replies[2+i]
+		if len(reply.Result.Content) != 1 {
+			t.Fatalf("reply %d: expected one content, got %+v", reply.ID, reply.Result.Content)
+		}
+		var decision permissionDecision
+		if err := json.Unmarshal([]byte(reply.Result.Content[0].Text), &decision); err != nil {
+			t.Fatalf("reply %d: invalid decision: %v", reply.ID, err)
+		}
+		if decision.Behavior != want {
+			t.Errorf("reply %d: expected %s, got %+v", reply.ID, want, decision)
+		}
+		if want == permissionAllow && len(decision.UpdatedInput) == 0 {
+			t.Errorf("reply %d: expected the input to be passed back", reply.ID)
+		}
+	}
+	if replies[7].Error == nil || replies[7].Error.Code != -32601 {
+		t.Errorf("expected an unknown method to fail, got %+v", replies[7])
+	}
+	// Asked for make, twice as the answer was not understood, then for the first fetch only
+	if n := strings.Count(console, "Allow Bash: make?"); n != 2 {
+		t.Errorf("expected to be asked twice about make, got %d in:\n%s", n, console)
+	}
+	if n := strings.Count(console, "Allow WebFetch?"); n != 1 {
+		t.Errorf("expected to be asked once about WebFetch, got %d in:\n%s", n, console)
+	}
+}
+
+func TestPermissionProxy_NotInteractive(t *testing.T) {
+	originalIsTerminal := isTerminal
+	defer func() { isTerminal = originalIsTerminal }()
+	isTerminal = func(*os.File) bool { return false }
+
+	dir := t.TempDir()
+	proxy, err := New().WithQuiet(true).WithPermissions(Permissions{Mode: PermissionModeProxy}).startPermissionProxy(dir)
+	if err != nil {
+		t.Fatalf("failed to start the permission proxy: %v", err)
+	}
+	defer func() { Swallow(proxy.Close()) }()
+
+	decision, err := relayPermissionPrompt(context.Background(), proxy.socket, permissionRequest{ToolName: "Bash", Input: json.RawMessage(`{"command":"make"}`)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decision.Behavior != permissionDeny || !strings.Contains(decision.Message, "no terminal") {
+		t.Errorf("expected the prompt to be denied without a terminal, got %+v", decision)
+	}
+}
+
+func TestGenerate_PermissionProxy(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	var calls [][]string
+	mock := mockCommandContext("Done "+DefaultCompletionSignal, 0)
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if slices.Contains(args, "--print") {
+			calls = append(calls, args)
+		}
+		return mock(ctx, name, args...)
+	}
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithPermissions(Permissions{Mode: PermissionModeProxy})
+	if _, err := cc.Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("expected one Claude call, got %d", len(calls))
+	}
+	joined := strings.Join(calls[0], " ")
+	if !strings.Contains(joined, "--permission-mode default") || !strings.Contains(joined, "--permission-prompt-tool "+PermissionPromptTool) {
+		t.Errorf("expected the permission prompts to be relayed, got %q", joined)
+	}
+	if !strings.Contains(joined, `"args":["permission-server","--socket",`) {
+		t.Errorf("expected the permission server to be configured, got %q", joined)
+	}
+	if cc.proxy != nil {
+		t.Error("expected the permission proxy to be closed after the run")
+	}
+}
+
+func TestPermissionPolicy_DecideOutsideRepository(t *testing.T) {
+	policy := PermissionPolicy{Allow: []string{"Edit(*.go)", "Edit(**)"}, Deny: []string{"Edit(*.env)"}}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"main.go", permissionAllow},
+		{"pkg/app/main.go", permissionAllow},
+		{"/home/u/other/main.go", permissionAsk},
+		{"../sibling/x.go", permissionAsk},
+		{"/root/.bashrc", permissionAsk},
+		{"/home/u/.env", permissionDeny},
+	}
+	for _, tt := range tests {
+		if got, _ := policy.decide(newToolUse("Edit", json.RawMessage(`{"file_path":"`+tt.path+`"}`), "/repo")); got != tt.want {
+			t.Errorf("decide(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
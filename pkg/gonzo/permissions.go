@@ -0,0 +1,81 @@
+package gonzo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Permission modes of the Claude CLI, deciding which tools Claude may use without asking. Print
+// mode cannot ask, so the tools a mode does not allow are denied.
+const (
+	// PermissionModeDefault only allows the read-only tools and the allowed tools.
+	PermissionModeDefault = "default"
+	// PermissionModeAcceptEdits also allows editing files in the repository.
+	PermissionModeAcceptEdits = "acceptEdits"
+	// PermissionModePlan only allows reading and planning, without changes.
+	PermissionModePlan = "plan"
+	// PermissionModeBypass allows every tool, with --dangerously-skip-permissions. Only use it
+	// in a sandbox, such as a container or a throwaway VM.
+	PermissionModeBypass = "bypassPermissions"
+	// PermissionModeProxy relays the permission prompts of PermissionModeDefault to gonzo, which
+	// decides them with the PermissionPolicy, asking on the terminal when it says so.
+	PermissionModeProxy = "proxy"
+)
+
+// DefaultPermissionMode is the permission mode of runs unless configured otherwise.
+const DefaultPermissionMode = PermissionModeAcceptEdits
+
+// PermissionModes are the permission modes runs may use.
+var PermissionModes = []string{PermissionModeDefault, PermissionModeAcceptEdits, PermissionModePlan, PermissionModeBypass, PermissionModeProxy}
+
+// DefaultAllowedTools are the tools allowed on top of the permission mode unless configured
+// otherwise: git, and the toolchains of the languages gonzo has guidance for, to build and test.
+var DefaultAllowedTools = []string{
+	"Bash(git:*)",
+	"Bash(go:*)", "Bash(gofmt:*)",
+	"Bash(npm:*)", "Bash(npx:*)", "Bash(yarn:*)", "Bash(pnpm:*)",
+	"Bash(python:*)", "Bash(python3:*)", "Bash(pytest:*)", "Bash(uv:*)", "Bash(poetry:*)", "Bash(ruff:*)",
+	"Bash(make:*)",
+}
+
+// Permissions select what Claude may do in the iterations of a run.
+type Permissions struct {
+	// Mode is one of PermissionModes.
+	Mode string
+	// AllowedTools are the tools allowed on top of the mode, as accepted by the --allowedTools
+	// flag of the Claude CLI, e.g. "Bash(git:*)" or "WebFetch". Unused with PermissionModeBypass.
+	AllowedTools []string
+	// Policy decides the tools Claude asks to use in PermissionModeProxy.
+	Policy PermissionPolicy
+}
+
+// mode returns the permission mode, DefaultPermissionMode when it is not set.
+func (p Permissions) mode() string {
+	if p.Mode == "" {
+		return DefaultPermissionMode
+	}
+	return p.Mode
+}
+
+// args returns the flags of the Claude CLI granting the permissions.
+func (p Permissions) args() ([]string, error) {
+	mode := p.mode()
+	if !slices.Contains(PermissionModes, mode) {
+		return nil, fmt.Errorf("unknown permission mode %q, expected one of %s", mode, strings.Join(PermissionModes, ", "))
+	}
+	if mode == PermissionModeBypass {
+		return []string{"--dangerously-skip-permissions"}, nil
+	}
+	if mode == PermissionModeProxy {
+		mode = PermissionModeDefault
+	}
+	args := []string{"--permission-mode", mode}
+	if len(p.AllowedTools) > 0 {
+		args = append(args, "--allowedTools", strings.Join(p.AllowedTools, ","))
+	}
+	if p.mode() == PermissionModeProxy {
+		args = append(args, "--permission-prompt-tool", PermissionPromptTool)
+	}
+	return args, nil
+}
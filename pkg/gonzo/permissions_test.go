@@ -0,0 +1,72 @@
+package gonzo
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPermissions_Args(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions Permissions
+		want        string
+	}{
+		{"default mode", Permissions{}, "--permission-mode acceptEdits"},
+		{"allowed tools", Permissions{Mode: PermissionModeDefault, AllowedTools: []string{"Bash(git:*)", "WebFetch"}}, "--permission-mode default --allowedTools Bash(git:*),WebFetch"},
+		{"bypass", Permissions{Mode: PermissionModeBypass, AllowedTools: []string{"Bash(git:*)"}}, "--dangerously-skip-permissions"},
+		{"proxy", Permissions{Mode: PermissionModeProxy, AllowedTools: []string{"Bash(git:*)"}}, "--permission-mode default --allowedTools Bash(git:*) --permission-prompt-tool mcp__gonzo__approve"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := tt.permissions.args()
+			if err != nil {
+				t.Fatalf("args() returned error: %v", err)
+			}
+			if got := strings.Join(args, " "); got != tt.want {
+				t.Errorf("args() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (Permissions{Mode: "yolo"}).args(); err == nil {
+		t.Error("expected error for an unknown permission mode")
+	}
+}
+
+func TestGenerate_Permissions(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	var calls [][]string
+	mock := mockCommandContext("Done "+DefaultCompletionSignal, 0)
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if slices.Contains(args, "--print") {
+			calls = append(calls, args)
+		}
+		return mock(ctx, name, args...)
+	}
+
+	if _, err := New(WithDir(dir)).WithQuiet(true).Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("expected one Claude call, got %d", len(calls))
+	}
+	joined := strings.Join(calls[0], " ")
+	if !strings.HasPrefix(joined, "--permission-mode acceptEdits --allowedTools Bash(git:*),") || strings.Contains(joined, "--dangerously-skip-permissions") {
+		t.Errorf("expected edits and the default tools to be allowed, got %q", joined)
+	}
+
+	_, err := New(WithDir(dir)).WithQuiet(true).WithPermissions(Permissions{Mode: "yolo"}).Generate(context.Background(), "add a login button")
+	if err == nil || ExitCode(err) != ExitPreflight {
+		t.Errorf("expected an unknown permission mode to fail the preflight, got %v", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("expected no Claude call with an unknown permission mode, got %d", len(calls))
+	}
+}
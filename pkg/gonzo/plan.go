@@ -0,0 +1,85 @@
+package gonzo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PlanFile is the file, inside GonzoDir, where `gonzo plan` writes the plan.
+const PlanFile = "plan.md"
+
+// Planner writes an implementation plan for a feature without changing the repository.
+type Planner interface {
+	Plan(ctx context.Context, feature string) (string, error)
+}
+
+var _ Planner = (*ClaudeConfig)(nil)
+
+// PlanFilePath returns the path of the plan file in the repository at dir.
+func PlanFilePath(dir string) string {
+	return filepath.Join(dir, GonzoDir, PlanFile)
+}
+
+// planData is the data of the plan template.
+type planData struct {
+	Tests        bool
+	ProgressFile string
+}
+
+// Plan runs a single planning invocation of the Claude CLI in plan permission mode, so it can read
+// the repository but not change it, and writes the resulting plan to .gonzo/plan.md.
+func (cc *ClaudeConfig) Plan(ctx context.Context, feature string) (string, error) {
+	return cc.session().plan(ctx, feature)
+}
+
+// plan runs the planning invocation of Plan.
+func (cc *ClaudeConfig) plan(ctx context.Context, feature string) (string, error) {
+	dir, err := cc.workDir()
+	if err != nil {
+		return "", err
+	}
+
+	plan, err := cc.runPlanning(ctx, dir, []string{"--permission-mode", "plan"}, feature)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, GonzoDir), 0755); err != nil {
+		return "", fmt.Errorf("failed to create .gonzo directory: %w", err)
+	}
+	if err := os.WriteFile(PlanFilePath(dir), []byte(plan), 0644); err != nil {
+		return "", fmt.Errorf("failed to write plan: %w", err)
+	}
+	cc.logInfo("Plan written to %s", filepath.Join(GonzoDir, PlanFile))
+	return plan, nil
+}
+
+// runPlanning runs the Claude CLI with the planning system prompt and the given permission flags
+// on feature, and returns the plan it replies with.
+func (cc *ClaudeConfig) runPlanning(ctx context.Context, dir string, flags []string, feature string) (string, error) {
+	t, err := cc.parsePrompt("plan.tmpl")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse plan template: %w", err)
+	}
+	var systemPrompt strings.Builder
+	err = t.Execute(&systemPrompt, planData{
+		Tests:        !cc.noNewTests,
+		ProgressFile: filepath.ToSlash(progressFilePath(dir)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute plan template: %w", err)
+	}
+
+	if err := cc.checkClaudeCLI(ctx); err != nil {
+		return "", err
+	}
+	cc.logInfo("Planning with %s", cc.model)
+	out, _, err := cc.execClaudeCLI(ctx, flags, systemPrompt.String(), feature, nil)
+	if err != nil {
+		//noinspection GoErrorStringFormatInspection
+		return "", fmt.Errorf("Claude CLI call failed: %w", err)
+	}
+	return strings.TrimSpace(string(out)) + "\n", nil
+}
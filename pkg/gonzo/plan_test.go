@@ -0,0 +1,52 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+
+	var args []string
+	mock := mockCommandContext("## Summary\nAdd the button.\n", 0)
+	commandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		args = arg
+		return mock(ctx, name, arg...)
+	}
+
+	plan, err := New(WithDir(dir)).WithQuiet(true).Plan(context.Background(), "add a login button")
+	if err != nil {
+		t.Fatalf("Plan() returned error: %v", err)
+	}
+	if plan != "## Summary\nAdd the button.\n" {
+		t.Errorf("unexpected plan %q", plan)
+	}
+
+	written, err := os.ReadFile(PlanFilePath(dir))
+	if err != nil {
+		t.Fatalf("expected the plan to be written: %v", err)
+	}
+	if string(written) != plan {
+		t.Errorf("expected the plan file to hold the plan, got %q", written)
+	}
+
+	joined := strings.Join(args, " ")
+	if !strings.HasPrefix(joined, "--permission-mode plan --print") || strings.Contains(joined, "--dangerously-skip-permissions") {
+		t.Errorf("expected a read-only invocation, got %q", joined)
+	}
+	if args[len(args)-1] != "add a login button" {
+		t.Errorf("expected the feature as the prompt, got %q", args[len(args)-1])
+	}
+
+	if _, err := LatestRunState(dir); err == nil {
+		t.Error("expected planning not to record a run")
+	}
+}
@@ -0,0 +1,72 @@
+package gonzo
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Playbooks shipped with gonzo, each adding task-specific guidance to the system prompt.
+const (
+	PlaybookBugfix    = "bugfix"
+	PlaybookFeature   = "feature"
+	PlaybookRefactor  = "refactor"
+	PlaybookDocs      = "docs"
+	PlaybookMigration = "migration"
+)
+
+// playbookTemplate returns the name of the prompt template of a playbook. A local template
+// of that name overrides the embedded one, or defines a playbook of its own.
+func playbookTemplate(name string) string {
+	return "playbook_" + name + ".tmpl"
+}
+
+// Playbooks returns the names of the playbooks available in dir, sorted: the embedded ones and
+// the ones defined by a local template in its prompts directory.
+func Playbooks(dir string) []string {
+	return New(WithPrompts(os.DirFS(PromptsPath(dir)))).playbooks()
+}
+
+// playbooks returns the names of the embedded and local playbooks, sorted.
+func (cc *ClaudeConfig) playbooks() []string {
+	names, _ := fs.Glob(cc.promptSet(), playbookTemplate("*"))
+	if local := cc.localPrompts(); local != nil {
+		localNames, _ := fs.Glob(local, playbookTemplate("*"))
+		names = append(names, localNames...)
+	}
+
+	var playbooks []string
+	for _, name := range names {
+		playbook := strings.TrimSuffix(strings.TrimPrefix(path.Base(name), "playbook_"), ".tmpl")
+		if !slices.Contains(playbooks, playbook) {
+			playbooks = append(playbooks, playbook)
+		}
+	}
+	slices.Sort(playbooks)
+	return playbooks
+}
+
+// playbookPrompt renders the system prompt section of the selected playbook with data, the
+// data of the system prompt. It is empty when no playbook is selected.
+func (cc *ClaudeConfig) playbookPrompt(data interface{}) (string, error) {
+	if cc.playbook == "" {
+		return "", nil
+	}
+	playbooks := cc.playbooks()
+	if !slices.Contains(playbooks, cc.playbook) {
+		return "", fmt.Errorf("unknown playbook %q (available: %s)", cc.playbook, strings.Join(playbooks, ", "))
+	}
+
+	t, err := cc.parsePrompt(playbookTemplate(cc.playbook))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse playbook template: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to execute playbook template: %w", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,252 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// PROptions controls how pull requests opened by gonzo land in the triage workflow.
+type PROptions struct {
+	Draft     bool
+	Labels    []string
+	Reviewers []string
+	Assignees []string
+	// Comment posts a summary of the run on the pull request it created or updated.
+	Comment bool
+	// CloseKeyword is placed before each linked issue in the body, e.g. "Closes #12".
+	// Empty leaves linked issues out of the body.
+	CloseKeyword string
+	// TitleIssuePrefix prefixes the title with the linked issue numbers, e.g. "[#12] ".
+	TitleIssuePrefix bool
+}
+
+// openPullRequest pushes the run's branch and opens a pull request for it, unless one is
+// already open. It returns the pull request URL.
+func (cc *ClaudeConfig) openPullRequest(ctx context.Context, dir string, run *RunState, output string) (string, error) {
+	branch, err := currentBranch(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	if branch == "" {
+		return "", errors.New("cannot open a pull request from a detached HEAD")
+	}
+
+	head, err := headSHA(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	if head == run.StartSHA {
+		return "", errors.New("the run made no commits")
+	}
+
+	client := newForge(ctx, dir, cc.forge)
+
+	base := run.StartBranch
+	if base == "" || base == branch {
+		if base, err = client.DefaultBranch(ctx); err != nil {
+			return "", fmt.Errorf("failed to determine base branch: %w", err)
+		}
+	}
+	if base == branch {
+		return "", fmt.Errorf("changes were committed directly to the base branch %s", base)
+	}
+
+	if err := pushBranch(ctx, dir, branch); err != nil {
+		return "", fmt.Errorf("failed to push branch: %w", err)
+	}
+
+	if existing, err := client.FindPullRequest(ctx, branch); err == nil {
+		return existing.URL, nil
+	} else if !errors.Is(err, forge.ErrNotFound) {
+		return "", err
+	}
+
+	title, body, err := cc.pullRequestContent(ctx, dir, run, output)
+	if err != nil {
+		return "", err
+	}
+
+	pr, err := client.CreatePullRequest(ctx, forge.PullRequestOptions{
+		Title:     title,
+		Body:      body,
+		Base:      base,
+		Head:      branch,
+		Draft:     cc.prOptions.Draft,
+		Labels:    cc.prOptions.Labels,
+		Reviewers: cc.prOptions.Reviewers,
+		Assignees: cc.prOptions.Assignees,
+	})
+	if err != nil {
+		return "", err
+	}
+	return pr.URL, nil
+}
+
+// PRTemplateFile is the repository-local pull request body template inside GonzoDir.
+const PRTemplateFile = "pr_template.md"
+
+// repoPRTemplates are the locations GitHub looks for a repository's pull request template.
+var repoPRTemplates = []string{
+	".github/PULL_REQUEST_TEMPLATE.md",
+	".github/pull_request_template.md",
+	"PULL_REQUEST_TEMPLATE.md",
+	"pull_request_template.md",
+	"docs/PULL_REQUEST_TEMPLATE.md",
+	"docs/pull_request_template.md",
+}
+
+// PRTemplateData holds the values available to pull request body templates.
+type PRTemplateData struct {
+	Feature       string
+	Summary       string
+	Commits       []string
+	RunID         string
+	Iterations    int
+	MaxIterations int
+	Status        string
+	// TestResults is the output of the verification commands, when any were run.
+	TestResults string
+	// Cost is the total cost of the run in US dollars, when known.
+	Cost float64
+	// Issue is the issue the run was started from, if any.
+	Issue *forge.Issue
+	// LinkedIssues are the issue the run was started from and those referenced in the feature.
+	LinkedIssues []IssueRef
+	// CloseKeyword is the keyword to place before each linked issue, or empty.
+	CloseKeyword string
+}
+
+// pullRequestContent generates the pull request title from the run's changes, in the same
+// format as commits made by gonzo, and renders the body with renderPRBody.
+func (cc *ClaudeConfig) pullRequestContent(ctx context.Context, dir string, run *RunState, output string) (string, string, error) {
+	files, err := changedFiles(ctx, dir, run.StartSHA)
+	if err != nil {
+		return "", "", err
+	}
+
+	title, err := cc.commitMessage(run.Feature, files)
+	if err != nil {
+		return "", "", err
+	}
+	if cc.prOptions.TitleIssuePrefix {
+		title = issueTitlePrefix(cc.linkedIssues(run.Feature)) + title
+	}
+
+	data, err := cc.prTemplateData(ctx, dir, run, output)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := renderPRBody(dir, cc.promptSet(), data)
+	if err != nil {
+		return "", "", err
+	}
+	return title, body, nil
+}
+
+// prTemplateData collects the values describing the run for pull request bodies and comments.
+func (cc *ClaudeConfig) prTemplateData(ctx context.Context, dir string, run *RunState, output string) (PRTemplateData, error) {
+	commits, err := commitSubjects(ctx, dir, run.StartSHA)
+	if err != nil {
+		return PRTemplateData{}, err
+	}
+
+	return PRTemplateData{
+		Feature:       run.Feature,
+		Summary:       StripControlMarkers(output, cc.completionSignal),
+		Commits:       commits,
+		RunID:         run.ID,
+		Iterations:    run.Iterations,
+		MaxIterations: cc.maxIterations,
+		Status:        run.Status,
+		Cost:          run.CostUSD,
+		TestResults:   lastVerification(dir, run),
+		Issue:         cc.issue,
+		LinkedIssues:  cc.linkedIssues(run.Feature),
+		CloseKeyword:  cc.prOptions.CloseKeyword,
+	}, nil
+}
+
+// commentOnPullRequest posts a summary of the run on the pull request at url.
+func (cc *ClaudeConfig) commentOnPullRequest(ctx context.Context, dir string, run *RunState, output string, url string) error {
+	data, err := cc.prTemplateData(ctx, dir, run, output)
+	if err != nil {
+		return err
+	}
+
+	t, err := cc.parsePrompt("pr_comment.tmpl")
+	if err != nil {
+		return fmt.Errorf("failed to parse pull request comment template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return fmt.Errorf("failed to execute pull request comment template: %w", err)
+	}
+	return newForge(ctx, dir, cc.forge).CommentOnPullRequest(ctx, url, strings.TrimSpace(b.String()))
+}
+
+// renderPRBody renders the pull request body. A .gonzo/pr_template.md in dir is used when
+// present. Otherwise the repository's own PULL_REQUEST_TEMPLATE.md, if any, is placed above
+// the gonzo summary of prompts so reviewers still get their usual checklist.
+func renderPRBody(dir string, prompts fs.FS, data PRTemplateData) (string, error) {
+	if content, err := os.ReadFile(filepath.Join(dir, GonzoDir, PRTemplateFile)); err == nil {
+		return executePRTemplate(PRTemplateFile, string(content), data)
+	}
+
+	embedded, err := fs.ReadFile(prompts, "pr_body.tmpl")
+	if err != nil {
+		return "", fmt.Errorf("failed to read pull request template: %w", err)
+	}
+	body, err := executePRTemplate("pr_body.tmpl", string(embedded), data)
+	if err != nil {
+		return "", err
+	}
+
+	for _, name := range repoPRTemplates {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		// The repository template is plain markdown, but may use the same variables
+		repoBody, err := executePRTemplate(name, string(content), data)
+		if err != nil {
+			repoBody = string(content)
+		}
+		return strings.TrimSpace(repoBody) + "\n\n" + body, nil
+	}
+	return body, nil
+}
+
+func executePRTemplate(name string, text string, data PRTemplateData) (string, error) {
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse pull request template %s: %w", name, err)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to execute pull request template %s: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// commentOnIssue reports the outcome of the run back on the issue it was started from.
+func (cc *ClaudeConfig) commentOnIssue(ctx context.Context, dir string, run *RunState) error {
+	t, err := cc.parsePrompt("issue_comment.tmpl")
+	if err != nil {
+		return fmt.Errorf("failed to parse issue comment template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, run); err != nil {
+		return fmt.Errorf("failed to execute issue comment template: %w", err)
+	}
+	return newForge(ctx, dir, cc.forge).CommentOnIssue(ctx, cc.issue.Number, strings.TrimSpace(b.String()))
+}
@@ -0,0 +1,333 @@
+package gonzo
+
+import (
+	"context"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeForge records pull requests and comments instead of talking to a forge.
+type fakeForge struct {
+	existing   *forge.PullRequest
+	created    *forge.PullRequestOptions
+	comments   map[int][]string
+	prComments map[string][]string
+	authErr    error
+}
+
+func (f *fakeForge) Name() string {
+	return forge.GitHub
+}
+
+func (f *fakeForge) AuthStatus(ctx context.Context) error {
+	return f.authErr
+}
+
+func (f *fakeForge) FindPullRequest(ctx context.Context, head string) (*forge.PullRequest, error) {
+	if f.existing != nil {
+		return f.existing, nil
+	}
+	return nil, forge.ErrNotFound
+}
+
+func (f *fakeForge) CreatePullRequest(ctx context.Context, opts forge.PullRequestOptions) (*forge.PullRequest, error) {
+	f.created = &opts
+	return &forge.PullRequest{Number: 1, URL: "https://github.com/o/r/pull/1"}, nil
+}
+
+func (f *fakeForge) DefaultBranch(ctx context.Context) (string, error) {
+	return "main", nil
+}
+
+func (f *fakeForge) GetIssue(ctx context.Context, ref string) (*forge.Issue, error) {
+	return nil, forge.ErrNotFound
+}
+
+func (f *fakeForge) CommentOnIssue(ctx context.Context, number int, body string) error {
+	if f.comments == nil {
+		f.comments = map[int][]string{}
+	}
+	f.comments[number] = append(f.comments[number], body)
+	return nil
+}
+
+func (f *fakeForge) CommentOnPullRequest(ctx context.Context, ref string, body string) error {
+	if f.prComments == nil {
+		f.prComments = map[string][]string{}
+	}
+	f.prComments[ref] = append(f.prComments[ref], body)
+	return nil
+}
+
+// withFakeForge replaces the forge client for the duration of the test.
+func withFakeForge(t *testing.T, fake *fakeForge) {
+	t.Helper()
+	original := newForge
+	newForge = func(ctx context.Context, dir string, name string) forge.Forge { return fake }
+	t.Cleanup(func() { newForge = original })
+}
+
+// addOrigin gives the repository in dir a bare clone as its origin remote.
+func addOrigin(t *testing.T, dir string) {
+	t.Helper()
+	remote := t.TempDir()
+	runGit(t, remote, "init", "-q", "--bare")
+	runGit(t, dir, "remote", "add", "origin", remote)
+}
+
+func TestOpenPullRequest(t *testing.T) {
+	dir := initGitRepo(t)
+	addOrigin(t, dir)
+	fake := &fakeForge{}
+	withFakeForge(t, fake)
+
+	run := simulateRun(t, dir)
+	run.Iterations = 2
+	url, err := New().openPullRequest(context.Background(), dir, run, "Implemented it\n"+DefaultCompletionSignal)
+	if err != nil {
+		t.Fatalf("openPullRequest() returned error: %v", err)
+	}
+	if url != "https://github.com/o/r/pull/1" {
+		t.Errorf("expected PR URL, got %q", url)
+	}
+
+	if fake.created == nil {
+		t.Fatal("expected a pull request to be created")
+	}
+	if fake.created.Base != "main" || fake.created.Head != "feature-branch" {
+		t.Errorf("expected feature-branch -> main, got %s -> %s", fake.created.Head, fake.created.Base)
+	}
+	if fake.created.Title != "feat: feature" {
+		t.Errorf("expected conventional title, got %q", fake.created.Title)
+	}
+	for _, want := range []string{"Implemented it", "- add feature", run.ID} {
+		if !strings.Contains(fake.created.Body, want) {
+			t.Errorf("expected body to contain %q, got %q", want, fake.created.Body)
+		}
+	}
+	if strings.Contains(fake.created.Body, DefaultCompletionSignal) {
+		t.Error("expected completion signal to be stripped from the body")
+	}
+
+	if remote := runGit(t, dir, "ls-remote", "--heads", "origin", "feature-branch"); remote == "" {
+		t.Error("expected the branch to be pushed")
+	}
+}
+
+func TestOpenPullRequest_Existing(t *testing.T) {
+	dir := initGitRepo(t)
+	addOrigin(t, dir)
+	fake := &fakeForge{existing: &forge.PullRequest{URL: "https://github.com/o/r/pull/9"}}
+	withFakeForge(t, fake)
+
+	run := simulateRun(t, dir)
+	url, err := New().openPullRequest(context.Background(), dir, run, "")
+	if err != nil {
+		t.Fatalf("openPullRequest() returned error: %v", err)
+	}
+	if url != "https://github.com/o/r/pull/9" || fake.created != nil {
+		t.Errorf("expected the existing PR to be reused, got %q", url)
+	}
+}
+
+func TestOpenPullRequest_LinkedIssues(t *testing.T) {
+	dir := initGitRepo(t)
+	addOrigin(t, dir)
+	fake := &fakeForge{}
+	withFakeForge(t, fake)
+
+	run := simulateRun(t, dir)
+	run.Feature = "Handle the crash reported in #14"
+	cc := New().WithPROptions(PROptions{CloseKeyword: "Fixes", TitleIssuePrefix: true})
+	if _, err := cc.openPullRequest(context.Background(), dir, run, ""); err != nil {
+		t.Fatalf("openPullRequest() returned error: %v", err)
+	}
+
+	if !strings.HasPrefix(fake.created.Title, "[#14] ") {
+		t.Errorf("expected the title to be prefixed with the issue, got %q", fake.created.Title)
+	}
+	if !strings.Contains(fake.created.Body, "Fixes #14\n") {
+		t.Errorf("expected the body to close the issue, got %q", fake.created.Body)
+	}
+}
+
+func TestOpenPullRequest_NoCommits(t *testing.T) {
+	dir := initGitRepo(t)
+	withFakeForge(t, &fakeForge{})
+
+	run, err := New().startRun(context.Background(), dir, "feature")
+	if err != nil {
+		t.Fatalf("startRun() returned error: %v", err)
+	}
+	if _, err := New().openPullRequest(context.Background(), dir, run, ""); err == nil {
+		t.Error("expected error when the run made no commits")
+	}
+}
+
+func TestRenderPRBody(t *testing.T) {
+	data := PRTemplateData{
+		Feature:    "Add a login button",
+		Summary:    "Added the button",
+		Commits:    []string{"feat(ui): add login button"},
+		RunID:      "run-1",
+		Iterations: 3,
+		Status:     RunStatusCompleted,
+		Cost:       1.5,
+	}
+
+	t.Run("embedded default", func(t *testing.T) {
+		body, err := renderPRBody(t.TempDir(), embeddedPrompts(), data)
+		if err != nil {
+			t.Fatalf("renderPRBody() returned error: %v", err)
+		}
+		for _, want := range []string{"Add a login button", "Added the button", "- feat(ui): add login button", "3 iteration(s)", "$1.50", "run-1"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected body to contain %q, got %q", want, body)
+			}
+		}
+	})
+
+	t.Run("linked issue", func(t *testing.T) {
+		data := data
+		data.Issue = &forge.Issue{Number: 12, URL: "https://github.com/o/r/issues/12"}
+		body, err := renderPRBody(t.TempDir(), embeddedPrompts(), data)
+		if err != nil {
+			t.Fatalf("renderPRBody() returned error: %v", err)
+		}
+		if !strings.Contains(body, "Requested in https://github.com/o/r/issues/12") {
+			t.Errorf("expected body to link the issue, got %q", body)
+		}
+	})
+
+	t.Run("close keyword", func(t *testing.T) {
+		data := data
+		data.LinkedIssues = []IssueRef{{Number: 12}, {Number: 14, URL: "https://github.com/o/r/issues/14"}}
+		data.CloseKeyword = "Closes"
+		body, err := renderPRBody(t.TempDir(), embeddedPrompts(), data)
+		if err != nil {
+			t.Fatalf("renderPRBody() returned error: %v", err)
+		}
+		if !strings.Contains(body, "Closes #12\nCloses https://github.com/o/r/issues/14\n") {
+			t.Errorf("expected close lines for the linked issues, got %q", body)
+		}
+
+		data.CloseKeyword = ""
+		if body, _ := renderPRBody(t.TempDir(), embeddedPrompts(), data); strings.Contains(body, "#12") {
+			t.Errorf("expected no close lines without a keyword, got %q", body)
+		}
+	})
+
+	t.Run("gonzo template", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, GonzoDir, PRTemplateFile), "Task: {{ .Feature }} ({{ .Iterations }}/{{ .MaxIterations }})")
+
+		data := data
+		data.MaxIterations = 10
+		body, err := renderPRBody(dir, embeddedPrompts(), data)
+		if err != nil {
+			t.Fatalf("renderPRBody() returned error: %v", err)
+		}
+		if body != "Task: Add a login button (3/10)" {
+			t.Errorf("expected custom template to be rendered, got %q", body)
+		}
+	})
+
+	t.Run("repository template", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, ".github", "PULL_REQUEST_TEMPLATE.md"), "## Checklist\n- [ ] Reviewed")
+
+		body, err := renderPRBody(dir, embeddedPrompts(), data)
+		if err != nil {
+			t.Fatalf("renderPRBody() returned error: %v", err)
+		}
+		if !strings.HasPrefix(body, "## Checklist") || !strings.Contains(body, "Added the button") {
+			t.Errorf("expected repository template followed by the gonzo summary, got %q", body)
+		}
+	})
+
+	t.Run("invalid gonzo template", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, GonzoDir, PRTemplateFile), "{{ .Feature")
+
+		if _, err := renderPRBody(dir, embeddedPrompts(), data); err == nil {
+			t.Error("expected error for an invalid template")
+		}
+	})
+}
+
+func TestCommentOnIssue(t *testing.T) {
+	fake := &fakeForge{}
+	withFakeForge(t, fake)
+
+	cc := New().WithIssue(&forge.Issue{Number: 12})
+	run := &RunState{ID: "run-1", Status: RunStatusCompleted, Iterations: 2, PRURL: "https://github.com/o/r/pull/1"}
+	if err := cc.commentOnIssue(context.Background(), t.TempDir(), run); err != nil {
+		t.Fatalf("commentOnIssue() returned error: %v", err)
+	}
+
+	if len(fake.comments[12]) != 1 {
+		t.Fatalf("expected one comment on issue #12, got %v", fake.comments)
+	}
+	for _, want := range []string{"finished working on this issue in 2 iteration(s)", "https://github.com/o/r/pull/1", "run-1"} {
+		if !strings.Contains(fake.comments[12][0], want) {
+			t.Errorf("expected comment to contain %q, got %q", want, fake.comments[12][0])
+		}
+	}
+}
+
+func TestCommentOnPullRequest(t *testing.T) {
+	dir := initGitRepo(t)
+	fake := &fakeForge{}
+	withFakeForge(t, fake)
+
+	run := simulateRun(t, dir)
+	run.Status = RunStatusCompleted
+	run.Iterations = 2
+	run.CostUSD = 1.234
+	cc := New().WithMaxIterations(5)
+
+	url := "https://github.com/o/r/pull/1"
+	if err := cc.commentOnPullRequest(context.Background(), dir, run, "done", url); err != nil {
+		t.Fatalf("commentOnPullRequest() returned error: %v", err)
+	}
+
+	if len(fake.prComments[url]) != 1 {
+		t.Fatalf("expected one comment on the pull request, got %v", fake.prComments)
+	}
+	for _, want := range []string{run.ID, "| Status | completed |", "| Iterations | 2 of 5 |", "| Cost | $1.23 |", "### Changelog", "- add feature"} {
+		if !strings.Contains(fake.prComments[url][0], want) {
+			t.Errorf("expected comment to contain %q, got %q", want, fake.prComments[url][0])
+		}
+	}
+}
+
+// writeFile creates a file and its parent directories.
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestResolveForge(t *testing.T) {
+	dir := initGitRepo(t)
+	ctx := context.Background()
+
+	if got := ResolveForge(ctx, dir, forge.GitLab); got != forge.GitLab {
+		t.Errorf("expected the configured forge, got %q", got)
+	}
+	if got := ResolveForge(ctx, dir, forge.Auto); got != forge.GitHub {
+		t.Errorf("expected GitHub without an origin remote, got %q", got)
+	}
+
+	runGit(t, dir, "remote", "add", "origin", "git@gitlab.com:o/r.git")
+	if got := ResolveForge(ctx, dir, ""); got != forge.GitLab {
+		t.Errorf("expected GitLab from the origin remote, got %q", got)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package gonzo
+
+import "os/exec"
+
+// setProcessGroup leaves cmd as is: without process groups, canceling its context only kills
+// cmd itself.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup does nothing without process groups.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return nil
+}
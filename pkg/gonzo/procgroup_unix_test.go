@@ -0,0 +1,67 @@
+//go:build unix
+
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestExecClaudeCLI_KillsProcessGroup(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	pidFile, tmpFile := filepath.Join(dir, "pid"), filepath.Join(dir, "tmp")
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		// A tool left running in the background, as the Bash tool may
+		script := `touch "$TMPDIR/scratch"; echo "$TMPDIR" > "` + tmpFile + `"; sleep 60 & echo $! > "` + pidFile + `"; wait`
+		return exec.CommandContext(ctx, "sh", "-c", script)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, _, err := New().WithQuiet(true).execClaudeCLI(ctx, nil, "system", "prompt", nil); err == nil {
+		t.Fatal("expected the canceled call to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the call to stop right away, took %s", elapsed)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("failed to read the PID of the background tool: %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatalf("invalid PID %q: %v", data, err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for !errors.Is(syscall.Kill(pid, 0), syscall.ESRCH) {
+		if time.Now().After(deadline) {
+			Swallow(syscall.Kill(pid, syscall.SIGKILL))
+			t.Fatal("expected the background tool to be killed with the CLI")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	data, err = os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to read the temporary directory of the call: %v", err)
+	}
+	if tmp := strings.TrimSpace(string(data)); tmp == "" || tmp == os.TempDir() {
+		t.Errorf("expected a temporary directory of the call, got %q", tmp)
+	} else if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary directory of the call to be removed, got %v", err)
+	}
+}
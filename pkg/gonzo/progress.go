@@ -0,0 +1,388 @@
+package gonzo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LegacyProgressFile is the free-form progress log used before the structured format.
+//
+// Deprecated: new repositories should use ProgressFile; run `gonzo migrate-state` to convert.
+const LegacyProgressFile = "progress.txt"
+
+// ProgressFile is the structured progress log inside GonzoDir.
+const ProgressFile = "progress.md"
+
+// ProgressFormatVersion is the current version of the structured progress format.
+const ProgressFormatVersion = 1
+
+const progressMarkerPrefix = "<!-- gonzo:progress v"
+
+// Section headings of the structured progress format.
+const (
+	progressPatternsHeading   = "## Codebase Patterns"
+	progressIterationsHeading = "## Iterations"
+	progressNotesHeading      = "## Notes"
+)
+
+// Progress is the parsed content of a structured progress log.
+//
+// The file is markdown so the agent can keep reading and writing its notes,
+// while the Iterations section holds one JSON object per line appended by gonzo.
+type Progress struct {
+	Version  int
+	Started  string
+	Patterns string
+	Entries  []ProgressEntry
+	Notes    string
+}
+
+// ProgressEntry is a structured record of a single iteration.
+type ProgressEntry struct {
+	Time      time.Time `json:"time"`
+	RunID     string    `json:"run_id,omitempty"`
+	Iteration int       `json:"iteration"`
+	Summary   string    `json:"summary,omitempty"`
+	Commit    string    `json:"commit,omitempty"`
+}
+
+// Render returns the progress log in the structured markdown format.
+func (p *Progress) Render() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s%d -->\n", progressMarkerPrefix, ProgressFormatVersion)
+	b.WriteString("# Gonzo Progress Log\n")
+	if p.Started != "" {
+		fmt.Fprintf(&b, "Started: %s\n", p.Started)
+	}
+
+	b.WriteString("\n" + progressPatternsHeading + "\n")
+	if p.Patterns != "" {
+		b.WriteString(p.Patterns + "\n")
+	}
+
+	b.WriteString("\n" + progressIterationsHeading + "\n")
+	b.WriteString(progressIterationsComment + "\n")
+	for _, e := range p.Entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+		b.WriteString("- " + string(data) + "\n")
+	}
+
+	b.WriteString("\n" + progressNotesHeading + "\n")
+	if p.Notes != "" {
+		b.WriteString(p.Notes + "\n")
+	}
+
+	return b.String()
+}
+
+// IsStructuredProgress reports whether content is a structured progress log.
+func IsStructuredProgress(content string) bool {
+	return strings.HasPrefix(strings.TrimSpace(content), progressMarkerPrefix)
+}
+
+// ErrNewerProgressFormat is returned when a progress log was written by a newer version of gonzo.
+var ErrNewerProgressFormat = errors.New("progress log was written by a newer version of gonzo")
+
+// progressIterationsComment introduces the records of the Iterations section.
+const progressIterationsComment = "<!-- Maintained by gonzo, one JSON record per line. Do not edit. -->"
+
+// ParseProgress parses a structured progress log. The Notes section is kept verbatim, headings
+// included, and lines found outside of the sections, e.g. written by the agent in the middle of
+// the Iterations section, are moved to the notes so that nothing is lost when gonzo rewrites it.
+func ParseProgress(content string) (*Progress, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !IsStructuredProgress(content) {
+		return nil, errors.New("progress log is not in the structured format")
+	}
+	marker, rest, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	version, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(marker, progressMarkerPrefix), "-->")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid progress log version marker %q", marker)
+	}
+	if version > ProgressFormatVersion {
+		return nil, fmt.Errorf("%w (version %d, this gonzo supports %d)", ErrNewerProgressFormat, version, ProgressFormatVersion)
+	}
+
+	p := &Progress{Version: version}
+	var patterns, stray, notes []string
+	section := ""
+	for i, line := range strings.Split(rest, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if section != progressNotesHeading {
+			switch trimmed {
+			case progressPatternsHeading, progressIterationsHeading, progressNotesHeading:
+				section = trimmed
+				continue
+			}
+		}
+
+		switch section {
+		case "":
+			switch {
+			case i == 0 && strings.HasPrefix(trimmed, "# "):
+			case strings.HasPrefix(trimmed, "Started:") && p.Started == "":
+				p.Started = strings.TrimSpace(strings.TrimPrefix(trimmed, "Started:"))
+			default:
+				stray = append(stray, line)
+			}
+		case progressPatternsHeading:
+			patterns = append(patterns, line)
+		case progressIterationsHeading:
+			var entry ProgressEntry
+			switch {
+			case trimmed == "" || trimmed == progressIterationsComment:
+			case strings.HasPrefix(trimmed, "- {") && json.Unmarshal([]byte(strings.TrimPrefix(trimmed, "- ")), &entry) == nil:
+				p.Entries = append(p.Entries, entry)
+			default:
+				stray = append(stray, line)
+			}
+		default:
+			notes = append(notes, line)
+		}
+	}
+
+	p.Patterns = strings.TrimSpace(strings.Join(patterns, "\n"))
+	p.Notes = strings.TrimSpace(strings.Join(append(stray, notes...), "\n"))
+	return p, nil
+}
+
+// AppendProgressEntry appends an iteration record to the structured progress log at path,
+// leaving the rest of it as it is.
+func AppendProgressEntry(path string, entry ProgressEntry) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read progress log: %w", err)
+	}
+	p, err := ParseProgress(string(content))
+	if err != nil {
+		return err
+	}
+	p.Entries = append(p.Entries, entry)
+	if err := os.WriteFile(path, []byte(p.Render()), 0644); err != nil {
+		return fmt.Errorf("failed to write progress log: %w", err)
+	}
+	return nil
+}
+
+// ParseLegacyProgress converts a free-form progress.txt into a Progress.
+// The "Started:" header line and the Codebase Patterns section are lifted into
+// their own fields; everything else is kept verbatim as notes.
+func ParseLegacyProgress(content string) *Progress {
+	p := &Progress{Version: ProgressFormatVersion}
+
+	var notes, patterns []string
+	inPatterns := false
+	inHeader := true
+
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if inHeader {
+			switch {
+			case strings.HasPrefix(trimmed, "# "):
+				continue
+			case strings.HasPrefix(trimmed, "Started:"):
+				p.Started = strings.TrimSpace(strings.TrimPrefix(trimmed, "Started:"))
+				continue
+			case trimmed == "" || trimmed == "---":
+				continue
+			}
+			inHeader = false
+		}
+
+		if trimmed == progressPatternsHeading {
+			inPatterns = true
+			continue
+		}
+		if inPatterns {
+			if trimmed == "---" || strings.HasPrefix(trimmed, "## ") {
+				inPatterns = false
+				if trimmed == "---" {
+					continue
+				}
+			} else {
+				patterns = append(patterns, line)
+				continue
+			}
+		}
+
+		notes = append(notes, line)
+	}
+
+	p.Patterns = strings.TrimSpace(strings.Join(patterns, "\n"))
+	p.Notes = strings.TrimSpace(strings.Join(notes, "\n"))
+	return p
+}
+
+// progressFilePath returns the path of the progress log gonzo should use in dir,
+// relative to dir. The structured log is used unless only a legacy file exists, until migrated.
+func progressFilePath(dir string) string {
+	structured := filepath.Join(GonzoDir, ProgressFile)
+	legacy := filepath.Join(GonzoDir, LegacyProgressFile)
+	if !fileExists(filepath.Join(dir, structured)) && fileExists(filepath.Join(dir, legacy)) {
+		return legacy
+	}
+	return structured
+}
+
+// progressSummaryLength bounds the summary of an iteration taken from Claude's response.
+const progressSummaryLength = 120
+
+// recordProgress appends the record of an iteration to the structured progress log of dir. The
+// iteration is summarized by the subjects of the commits it made, or else by the first line of
+// Claude's response. Legacy progress logs are left alone.
+func (cc *ClaudeConfig) recordProgress(ctx context.Context, dir string, progressFile string, run *RunState, iteration int, startSHA string, response string) {
+	if cc.progress == nil && filepath.Base(progressFile) != ProgressFile {
+		return
+	}
+
+	entry := ProgressEntry{Time: time.Now(), RunID: run.ID, Iteration: iteration}
+	if startSHA != "" {
+		if sha, err := headSHA(ctx, dir); err == nil && sha != startSHA {
+			entry.Commit = sha
+			subjects, _ := commitSubjects(ctx, dir, startSHA)
+			entry.Summary = strings.Join(subjects, "; ")
+		}
+	}
+	if entry.Summary == "" {
+		entry.Summary = firstLine(StripControlMarkers(response, cc.completionSignal))
+	}
+	if runes := []rune(entry.Summary); len(runes) > progressSummaryLength {
+		entry.Summary = string(runes[:progressSummaryLength-3]) + "..."
+	}
+
+	if err := cc.progressStore(dir).Append(ctx, entry); err != nil {
+		cc.logWarn("Note: failed to record iteration %d in the progress log: %v", iteration, err)
+	}
+}
+
+// MigrationResult describes what MigrateProgress changed.
+type MigrationResult struct {
+	From            string
+	To              string
+	UpdatedRefs     []string
+	RemovedOriginal bool
+}
+
+// MigrateOptions controls MigrateProgress.
+type MigrateOptions struct {
+	// KeepLegacy leaves the legacy progress.txt in place after conversion.
+	KeepLegacy bool
+}
+
+// ErrAlreadyMigrated is returned when a repository already uses the structured progress log.
+var ErrAlreadyMigrated = errors.New("progress log is already in the structured format")
+
+// progressRefFiles are the files whose references to the legacy progress log are rewritten.
+var progressRefFiles = map[string]bool{
+	"CLAUDE.md":  true,
+	"AGENTS.md":  true,
+	".gitignore": true,
+}
+
+// MigrateProgress converts the legacy .gonzo/progress.txt in dir into the structured
+// .gonzo/progress.md and rewrites references to the old path in CLAUDE.md, AGENTS.md
+// and .gitignore files throughout the repository.
+func MigrateProgress(dir string, opts MigrateOptions) (*MigrationResult, error) {
+	legacyPath := filepath.Join(dir, GonzoDir, LegacyProgressFile)
+	structuredPath := filepath.Join(dir, GonzoDir, ProgressFile)
+
+	if _, err := os.Stat(structuredPath); err == nil {
+		return nil, ErrAlreadyMigrated
+	}
+
+	content, err := os.ReadFile(legacyPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no legacy progress log found at %s", legacyPath)
+		}
+		return nil, fmt.Errorf("failed to read legacy progress log: %w", err)
+	}
+
+	// A log already converted in place by hand only needs the new file name
+	out := string(content)
+	if !IsStructuredProgress(out) {
+		out = ParseLegacyProgress(out).Render()
+	}
+	if err := os.WriteFile(structuredPath, []byte(out), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write structured progress log: %w", err)
+	}
+
+	result := &MigrationResult{From: legacyPath, To: structuredPath}
+
+	refs, err := updateProgressRefs(dir)
+	if err != nil {
+		return nil, err
+	}
+	result.UpdatedRefs = refs
+
+	if !opts.KeepLegacy {
+		if err := os.Remove(legacyPath); err != nil {
+			return nil, fmt.Errorf("failed to remove legacy progress log: %w", err)
+		}
+		result.RemovedOriginal = true
+	}
+
+	return result, nil
+}
+
+// updateProgressRefs rewrites mentions of the legacy progress log path and returns the files changed.
+func updateProgressRefs(dir string) ([]string, error) {
+	oldRef := GonzoDir + "/" + LegacyProgressFile
+	newRef := GonzoDir + "/" + ProgressFile
+
+	var updated []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && (d.Name() == ".git" || d.Name() == GonzoDir || d.Name() == "node_modules" || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !progressRefFiles[d.Name()] {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(content), oldRef) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(strings.ReplaceAll(string(content), oldRef, newRef)), info.Mode().Perm()); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		updated = append(updated, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update progress log references: %w", err)
+	}
+	return updated, nil
+}
@@ -0,0 +1,103 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ProgressStore keeps the progress log of a repository: the notes carried from an iteration to
+// the next and the records of the iterations gonzo appends. The file in GonzoDir is the default
+// store; Claude always reads and writes its notes there, so another store only holds what gonzo
+// records itself.
+type ProgressStore interface {
+	// EnsureExists creates the progress log with initial, unless it exists.
+	EnsureExists(ctx context.Context, initial *Progress) error
+	// Append adds the record of an iteration to the progress log.
+	Append(ctx context.Context, entry ProgressEntry) error
+	// Read returns the progress log.
+	Read(ctx context.Context) (*Progress, error)
+	// Compact drops the records of the iterations but the last keep ones.
+	Compact(ctx context.Context, keep int) error
+}
+
+// WithProgressStore keeps the progress log in store instead of the file of the repository.
+func WithProgressStore(store ProgressStore) Option {
+	return func(cc *ClaudeConfig) {
+		cc.progress = store
+	}
+}
+
+// progressStore returns the store of the progress log of the repository in dir.
+func (cc *ClaudeConfig) progressStore(dir string) ProgressStore {
+	if cc.progress != nil {
+		return cc.progress
+	}
+	return NewFileProgressStore(filepath.Join(dir, progressFilePath(dir)))
+}
+
+// FileProgressStore is the ProgressStore of a progress log file, in the structured format or,
+// until migrated, the legacy one, which it reads but does not record iterations in.
+type FileProgressStore struct {
+	Path string
+}
+
+var _ ProgressStore = (*FileProgressStore)(nil)
+
+// NewFileProgressStore returns the store of the progress log file at path.
+func NewFileProgressStore(path string) *FileProgressStore {
+	return &FileProgressStore{Path: path}
+}
+
+// EnsureExists writes initial to the file unless it exists, creating its directory.
+func (s *FileProgressStore) EnsureExists(ctx context.Context, initial *Progress) error {
+	if _, err := os.Stat(s.Path); !errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create progress log directory: %w", err)
+	}
+	if err := os.WriteFile(s.Path, []byte(initial.Render()), 0644); err != nil {
+		return fmt.Errorf("failed to write to progress file: %w", err)
+	}
+	return nil
+}
+
+// Append appends the record to the Iterations section of the file, leaving the rest of it as it is.
+func (s *FileProgressStore) Append(ctx context.Context, entry ProgressEntry) error {
+	return AppendProgressEntry(s.Path, entry)
+}
+
+// Read parses the file, converting a legacy progress log.
+func (s *FileProgressStore) Read(ctx context.Context) (*Progress, error) {
+	content, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read progress log: %w", err)
+	}
+	if !IsStructuredProgress(string(content)) {
+		return ParseLegacyProgress(string(content)), nil
+	}
+	return ParseProgress(string(content))
+}
+
+// Compact rewrites the file with the last keep records of the iterations.
+func (s *FileProgressStore) Compact(ctx context.Context, keep int) error {
+	content, err := os.ReadFile(s.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read progress log: %w", err)
+	}
+	p, err := ParseProgress(string(content))
+	if err != nil {
+		return err
+	}
+	if len(p.Entries) <= keep {
+		return nil
+	}
+	p.Entries = p.Entries[len(p.Entries)-max(keep, 0):]
+	if err := os.WriteFile(s.Path, []byte(p.Render()), 0644); err != nil {
+		return fmt.Errorf("failed to write progress log: %w", err)
+	}
+	return nil
+}
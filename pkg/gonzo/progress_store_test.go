@@ -0,0 +1,54 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileProgressStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileProgressStore(filepath.Join(t.TempDir(), GonzoDir, ProgressFile))
+
+	if err := store.EnsureExists(ctx, &Progress{Started: "2026-02-01 20:26:13", Notes: "Use tabs"}); err != nil {
+		t.Fatalf("EnsureExists() returned error: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		if err := store.Append(ctx, ProgressEntry{Iteration: i, Summary: "step"}); err != nil {
+			t.Fatalf("Append() returned error: %v", err)
+		}
+	}
+	// An existing log is left as it is
+	if err := store.EnsureExists(ctx, &Progress{}); err != nil {
+		t.Fatalf("EnsureExists() returned error: %v", err)
+	}
+	if err := store.Compact(ctx, 2); err != nil {
+		t.Fatalf("Compact() returned error: %v", err)
+	}
+
+	p, err := store.Read(ctx)
+	if err != nil {
+		t.Fatalf("Read() returned error: %v", err)
+	}
+	if p.Started != "2026-02-01 20:26:13" || p.Notes != "Use tabs" {
+		t.Errorf("expected the initial log to be kept, got %+v", p)
+	}
+	if len(p.Entries) != 2 || p.Entries[0].Iteration != 2 || p.Entries[1].Iteration != 3 {
+		t.Errorf("expected the last two iterations, got %+v", p.Entries)
+	}
+}
+
+func TestFileProgressStore_Legacy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LegacyProgressFile)
+	if err := os.WriteFile(path, []byte(legacyProgress), 0644); err != nil {
+		t.Fatalf("failed to write legacy progress: %v", err)
+	}
+	p, err := NewFileProgressStore(path).Read(context.Background())
+	if err != nil {
+		t.Fatalf("Read() returned error: %v", err)
+	}
+	if p.Started != "2026-02-01 20:26:13" {
+		t.Errorf("expected the legacy log to be converted, got %+v", p)
+	}
+}
@@ -0,0 +1,218 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const legacyProgress = `# Gonzo Progress Log
+Started: 2026-02-01 20:26:13
+---
+
+## Codebase Patterns
+- Use the builder pattern for options
+- Templates live in prompts/
+
+---
+
+## 2026-02-01 - Added install script
+- Created install.sh
+---
+`
+
+func TestParseLegacyProgress(t *testing.T) {
+	p := ParseLegacyProgress(legacyProgress)
+
+	if p.Started != "2026-02-01 20:26:13" {
+		t.Errorf("expected Started to be parsed, got %q", p.Started)
+	}
+	expectedPatterns := "- Use the builder pattern for options\n- Templates live in prompts/"
+	if p.Patterns != expectedPatterns {
+		t.Errorf("expected patterns %q, got %q", expectedPatterns, p.Patterns)
+	}
+	if !strings.HasPrefix(p.Notes, "## 2026-02-01 - Added install script") {
+		t.Errorf("expected notes to keep the progress entries, got %q", p.Notes)
+	}
+	if strings.Contains(p.Notes, "Codebase Patterns") {
+		t.Errorf("expected patterns to be removed from notes, got %q", p.Notes)
+	}
+}
+
+func TestProgressRender(t *testing.T) {
+	out := ParseLegacyProgress(legacyProgress).Render()
+
+	if !IsStructuredProgress(out) {
+		t.Errorf("expected rendered progress to carry the version marker, got %q", out)
+	}
+	for _, heading := range []string{progressPatternsHeading, progressIterationsHeading, progressNotesHeading} {
+		if !strings.Contains(out, heading) {
+			t.Errorf("expected rendered progress to contain %q", heading)
+		}
+	}
+	if strings.Index(out, progressNotesHeading) < strings.Index(out, progressIterationsHeading) {
+		t.Error("expected notes to be the last section so agent appends land there")
+	}
+}
+
+func TestMigrateProgress(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, GonzoDir), 0755); err != nil {
+		t.Fatalf("failed to create .gonzo directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, GonzoDir, LegacyProgressFile), []byte(legacyProgress), 0644); err != nil {
+		t.Fatalf("failed to write legacy progress: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "pkg"), 0755); err != nil {
+		t.Fatalf("failed to create pkg directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pkg", "CLAUDE.md"), []byte("See .gonzo/progress.txt for notes\n"), 0644); err != nil {
+		t.Fatalf("failed to write CLAUDE.md: %v", err)
+	}
+
+	result, err := MigrateProgress(dir, MigrateOptions{})
+	if err != nil {
+		t.Fatalf("MigrateProgress() returned error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, GonzoDir, ProgressFile))
+	if err != nil {
+		t.Fatalf("expected structured progress to be written: %v", err)
+	}
+	if !IsStructuredProgress(string(content)) {
+		t.Errorf("expected structured progress, got %q", string(content))
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, GonzoDir, LegacyProgressFile)); !os.IsNotExist(err) {
+		t.Error("expected legacy progress to be removed")
+	}
+
+	claude, _ := os.ReadFile(filepath.Join(dir, "pkg", "CLAUDE.md"))
+	if string(claude) != "See .gonzo/progress.md for notes\n" {
+		t.Errorf("expected CLAUDE.md reference to be updated, got %q", string(claude))
+	}
+	if len(result.UpdatedRefs) != 1 || result.UpdatedRefs[0] != filepath.Join("pkg", "CLAUDE.md") {
+		t.Errorf("expected updated refs [pkg/CLAUDE.md], got %v", result.UpdatedRefs)
+	}
+
+	if progressFilePath(dir) != filepath.Join(GonzoDir, ProgressFile) {
+		t.Errorf("expected structured progress to be preferred, got %q", progressFilePath(dir))
+	}
+
+	if _, err := MigrateProgress(dir, MigrateOptions{}); !errors.Is(err, ErrAlreadyMigrated) {
+		t.Errorf("expected ErrAlreadyMigrated on second run, got %v", err)
+	}
+}
+
+func TestMigrateProgress_KeepLegacy(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, GonzoDir), 0755); err != nil {
+		t.Fatalf("failed to create .gonzo directory: %v", err)
+	}
+	legacyPath := filepath.Join(dir, GonzoDir, LegacyProgressFile)
+	if err := os.WriteFile(legacyPath, []byte(legacyProgress), 0644); err != nil {
+		t.Fatalf("failed to write legacy progress: %v", err)
+	}
+
+	if _, err := MigrateProgress(dir, MigrateOptions{KeepLegacy: true}); err != nil {
+		t.Fatalf("MigrateProgress() returned error: %v", err)
+	}
+	if _, err := os.Stat(legacyPath); err != nil {
+		t.Errorf("expected legacy progress to be kept: %v", err)
+	}
+}
+
+func TestMigrateProgress_NoLegacyFile(t *testing.T) {
+	if _, err := MigrateProgress(t.TempDir(), MigrateOptions{}); err == nil {
+		t.Error("expected error when there is no legacy progress log")
+	}
+}
+
+func TestParseProgress(t *testing.T) {
+	p := ParseLegacyProgress(legacyProgress)
+	p.Entries = []ProgressEntry{{Time: time.Date(2026, 2, 1, 20, 30, 0, 0, time.UTC), RunID: "run-1", Iteration: 1, Summary: "feat: add install script", Commit: "abc123"}}
+	// The agent appends its notes at the end and sometimes writes inside the Iterations section
+	content := strings.Replace(p.Render(), progressIterationsComment+"\n", progressIterationsComment+"\nStray note\n", 1) +
+		"\n## 2026-02-02 - Added uninstall script\n- Created uninstall.sh\n"
+
+	parsed, err := ParseProgress(content)
+	if err != nil {
+		t.Fatalf("ParseProgress() returned error: %v", err)
+	}
+	if parsed.Version != ProgressFormatVersion || parsed.Started != p.Started || parsed.Patterns != p.Patterns {
+		t.Errorf("expected the header and patterns to round-trip, got %+v", parsed)
+	}
+	if len(parsed.Entries) != 1 || parsed.Entries[0] != p.Entries[0] {
+		t.Errorf("expected the iteration entry to round-trip, got %+v", parsed.Entries)
+	}
+	for _, want := range []string{"Stray note", "## 2026-02-01 - Added install script", "## 2026-02-02 - Added uninstall script"} {
+		if !strings.Contains(parsed.Notes, want) {
+			t.Errorf("expected %q in the notes, got %q", want, parsed.Notes)
+		}
+	}
+
+	if _, err := ParseProgress(legacyProgress); err == nil {
+		t.Error("expected an error for a legacy progress log")
+	}
+	newer := strings.Replace(content, progressMarkerPrefix+"1", progressMarkerPrefix+"2", 1)
+	if _, err := ParseProgress(newer); !errors.Is(err, ErrNewerProgressFormat) {
+		t.Errorf("expected ErrNewerProgressFormat, got %v", err)
+	}
+}
+
+func TestRecordProgress(t *testing.T) {
+	dir := initGitRepo(t)
+	ctx := context.Background()
+	path := filepath.Join(dir, GonzoDir, ProgressFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create .gonzo directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(ParseLegacyProgress(legacyProgress).Render()), 0644); err != nil {
+		t.Fatalf("failed to write progress log: %v", err)
+	}
+
+	cc := New()
+	run := &RunState{ID: "run-1"}
+	startSHA := runGit(t, dir, "rev-parse", "HEAD")
+	writeAndCommit(t, dir, "feature.txt", "feature\n", "feat: add feature")
+	cc.recordProgress(ctx, dir, filepath.Join(GonzoDir, ProgressFile), run, 1, startSHA, "Added the feature")
+	cc.recordProgress(ctx, dir, filepath.Join(GonzoDir, ProgressFile), run, 2, runGit(t, dir, "rev-parse", "HEAD"), "\nLooked around\n"+DefaultCompletionSignal)
+
+	content, _ := os.ReadFile(path)
+	p, err := ParseProgress(string(content))
+	if err != nil {
+		t.Fatalf("ParseProgress() returned error: %v", err)
+	}
+	if len(p.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", p.Entries)
+	}
+	if e := p.Entries[0]; e.RunID != "run-1" || e.Iteration != 1 || e.Summary != "feat: add feature" || e.Commit != runGit(t, dir, "rev-parse", "HEAD") {
+		t.Errorf("expected the first entry to record the commit, got %+v", e)
+	}
+	if e := p.Entries[1]; e.Summary != "Looked around" || e.Commit != "" {
+		t.Errorf("expected the second entry to be summarized by the response, got %+v", e)
+	}
+	if !strings.Contains(p.Notes, "Added install script") {
+		t.Errorf("expected the notes to be kept, got %q", p.Notes)
+	}
+}
+
+func TestProgressFilePath(t *testing.T) {
+	dir := t.TempDir()
+	if got := progressFilePath(dir); got != filepath.Join(GonzoDir, ProgressFile) {
+		t.Errorf("expected new repositories to use the structured log, got %q", got)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, GonzoDir), 0755); err != nil {
+		t.Fatalf("failed to create .gonzo directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, GonzoDir, LegacyProgressFile), []byte(legacyProgress), 0644); err != nil {
+		t.Fatalf("failed to write legacy progress: %v", err)
+	}
+	if got := progressFilePath(dir); got != filepath.Join(GonzoDir, LegacyProgressFile) {
+		t.Errorf("expected the legacy log to be used until migrated, got %q", got)
+	}
+}
@@ -0,0 +1,281 @@
+package gonzo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/andybarilla/gonzo/pkg/forge"
+)
+
+// PromptLint is the outcome of linting a local prompt template.
+type PromptLint struct {
+	Name string
+	// Err is why the template would fail a run, if it would.
+	Err error
+	// Warning is set when gonzo never reads the template.
+	Warning string
+}
+
+// sampleProgressFile is the progress file of the sample data templates are linted with.
+const sampleProgressFile = GonzoDir + "/" + ProgressFile
+
+// promptSamples returns sample data of the type each overridable template is rendered with, by
+// template name. Playbook and language templates are rendered with the data of the system prompt.
+func promptSamples() map[string]interface{} {
+	now := time.Now()
+	run := &RunState{
+		ID:         "20260101-120000-abcdef",
+		Feature:    "Add a login button",
+		Model:      ClaudeSonnet,
+		Branch:     "add-login-button",
+		PRURL:      "https://github.com/example/app/pull/1",
+		Status:     RunStatusCompleted,
+		Iterations: 2,
+		StartedAt:  now,
+		FinishedAt: &now,
+	}
+	return map[string]interface{}{
+		"system_prompt.tmpl": systemPromptData{
+			Branch:           true,
+			Tests:            true,
+			PR:               true,
+			CommitAuthor:     DefaultCommitAuthor,
+			ProgressFile:     sampleProgressFile,
+			CompletionSignal: DefaultCompletionSignal,
+			Vars:             map[string]string{"project": "app"},
+			Playbook:         "## Playbook: Feature",
+			Language:         "## Language: Go",
+			Guidance:         []GuidanceFile{{Path: "CLAUDE.md", Content: "Run make check"}, {Path: "CONTRIBUTING.md"}},
+			RepoMap:          "- `pkg/app/` (2 files): Package app serves the API.",
+			Agents:           []Agent{{Name: "tester", Description: "Writes and runs the tests of a change"}},
+			WriteScope:       []string{"pkg/", "internal/"},
+		},
+		"wrap_up.tmpl": wrapUpData{
+			Feature:          run.Feature,
+			Iteration:        1,
+			WrapUpIterations: DefaultWrapUpIterations,
+			Tests:            true,
+			PR:               true,
+			CommitAuthor:     DefaultCommitAuthor,
+			ProgressFile:     sampleProgressFile,
+		},
+		"verify_failed.tmpl": verifyFailedData{
+			Feature:      run.Feature,
+			Output:       "--- FAIL: TestLogin",
+			CommitAuthor: DefaultCommitAuthor,
+			ProgressFile: sampleProgressFile,
+		},
+		"write_scope.tmpl": writeScopeData{
+			Scope:    []string{"pkg/", "internal/"},
+			Reverted: []string{"go.mod"},
+		},
+		"progress.tmpl": progressData{Now: now, Branch: true, Vars: map[string]string{"project": "app"}},
+		"plan.tmpl":     planData{Tests: true, ProgressFile: sampleProgressFile},
+		"pr_comment.tmpl": PRTemplateData{
+			Feature:       run.Feature,
+			Summary:       "Added a login button to the header",
+			Commits:       []string{"feat: add login button"},
+			RunID:         run.ID,
+			Iterations:    run.Iterations,
+			MaxIterations: DefaultMaxIterations,
+			Status:        run.Status,
+			TestResults:   "ok",
+			Issue:         &forge.Issue{Number: 12, Title: run.Feature, URL: "https://github.com/example/app/issues/12"},
+			LinkedIssues:  []IssueRef{{Number: 12}},
+			CloseKeyword:  DefaultCloseKeyword,
+		},
+		"issue_comment.tmpl": run,
+	}
+}
+
+// LintPrompts checks the local prompt templates of dir, so that a broken template fails before
+// a run rather than in the middle of it: each must parse, reference only the fields of the data
+// gonzo renders it with, and render with sample data. Local templates gonzo does not read get a
+// warning instead. The templates are returned sorted by name.
+func LintPrompts(dir string) ([]PromptLint, error) {
+	paths, err := filepath.Glob(filepath.Join(PromptsPath(dir), "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list local prompt templates: %w", err)
+	}
+	sort.Strings(paths)
+
+	samples := promptSamples()
+	lints := make([]PromptLint, 0, len(paths))
+	for _, path := range paths {
+		name := filepath.Base(path)
+		lint := PromptLint{Name: name}
+
+		data, ok := samples[name]
+		if strings.HasPrefix(name, "playbook_") || strings.HasPrefix(name, "language_") {
+			data, ok = samples["system_prompt.tmpl"], true
+		}
+		text, err := os.ReadFile(path)
+		switch {
+		case err != nil:
+			lint.Err = fmt.Errorf("failed to read %s: %w", name, err)
+		case !ok:
+			if _, err := EmbeddedPrompt(name); err == nil {
+				lint.Warning = "only the embedded version is used, the local copy is ignored"
+			} else {
+				lint.Warning = "not a template gonzo uses"
+			}
+			if _, err := template.New(name).Funcs(templateFuncs).Parse(string(text)); err != nil {
+				lint.Err = err
+			}
+		default:
+			lint.Err = lintTemplate(name, string(text), data)
+		}
+		lints = append(lints, lint)
+	}
+	return lints, nil
+}
+
+// lintTemplate parses a template, checks the fields it references against the type of data
+// and renders it with data.
+func lintTemplate(name string, text string, data interface{}) error {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	typ := reflect.TypeOf(data)
+	for _, field := range unknownFields(t.Root, typ, typ) {
+		errs = append(errs, fmt.Errorf("%s: unknown field %s", name, field))
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if err := t.Execute(&strings.Builder{}, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+// unknownFields returns the fields referenced by node that the data does not have, given the
+// type of dot and of the root data ($). Fields are not checked where the type of dot is
+// unknown, e.g. within the range of a value of an interface type.
+func unknownFields(node parse.Node, dot reflect.Type, root reflect.Type) []string {
+	var unknown []string
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			unknown = append(unknown, unknownFields(child, dot, root)...)
+		}
+	case *parse.ActionNode:
+		unknown = unknownFields(n.Pipe, dot, root)
+	case *parse.TemplateNode:
+		unknown = unknownFields(n.Pipe, dot, root)
+	case *parse.IfNode:
+		unknown = append(unknownFields(n.Pipe, dot, root), unknownFields(n.List, dot, root)...)
+		unknown = append(unknown, unknownFields(n.ElseList, dot, root)...)
+	case *parse.WithNode:
+		unknown = append(unknownFields(n.Pipe, dot, root), unknownFields(n.List, pipeType(n.Pipe, dot, root), root)...)
+		unknown = append(unknown, unknownFields(n.ElseList, dot, root)...)
+	case *parse.RangeNode:
+		unknown = append(unknownFields(n.Pipe, dot, root), unknownFields(n.List, elemType(pipeType(n.Pipe, dot, root)), root)...)
+		unknown = append(unknown, unknownFields(n.ElseList, dot, root)...)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, cmd := range n.Cmds {
+			for _, arg := range cmd.Args {
+				unknown = append(unknown, unknownFields(arg, dot, root)...)
+			}
+		}
+	case *parse.FieldNode:
+		if _, err := fieldType(dot, n.Ident); err != nil {
+			unknown = append(unknown, n.String())
+		}
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			if _, err := fieldType(root, n.Ident[1:]); err != nil {
+				unknown = append(unknown, n.String())
+			}
+		}
+	case *parse.ChainNode:
+		unknown = unknownFields(n.Node, dot, root)
+	}
+	return unknown
+}
+
+// pipeType returns the type of the value of a pipeline made of a single field, or nil.
+func pipeType(pipe *parse.PipeNode, dot reflect.Type, root reflect.Type) reflect.Type {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil
+	}
+	var typ reflect.Type
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode:
+		typ, _ = fieldType(dot, arg.Ident)
+	case *parse.VariableNode:
+		if arg.Ident[0] == "$" {
+			typ, _ = fieldType(root, arg.Ident[1:])
+		}
+	case *parse.DotNode:
+		typ = dot
+	}
+	return typ
+}
+
+// elemType returns the type of the elements ranged over in a value of type typ, or nil.
+func elemType(typ reflect.Type) reflect.Type {
+	if typ == nil {
+		return nil
+	}
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return typ.Elem()
+	}
+	return nil
+}
+
+// fieldType returns the type of the value reached by following the fields or methods idents
+// from a value of type typ. The type is nil when it cannot be known, e.g. past an interface.
+func fieldType(typ reflect.Type, idents []string) (reflect.Type, error) {
+	for _, ident := range idents {
+		if typ == nil {
+			return nil, nil
+		}
+		if method, ok := typ.MethodByName(ident); ok {
+			typ = nil
+			if method.Type.NumOut() > 0 {
+				typ = method.Type.Out(0)
+			}
+			continue
+		}
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		switch typ.Kind() {
+		case reflect.Struct:
+			field, ok := typ.FieldByName(ident)
+			if !ok || !field.IsExported() {
+				return nil, fmt.Errorf("%s has no field %s", typ, ident)
+			}
+			typ = field.Type
+		case reflect.Map:
+			typ = typ.Elem()
+		case reflect.Interface:
+			typ = nil
+		default:
+			return nil, fmt.Errorf("%s has no field %s", typ, ident)
+		}
+	}
+	return typ, nil
+}
@@ -0,0 +1,289 @@
+package gonzo
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// PromptsDir is the directory inside GonzoDir holding local copies of the embedded prompt templates.
+const PromptsDir = "prompts"
+
+// promptDescriptions describes what each embedded template is used for.
+var promptDescriptions = map[string]string{
+	"address_reviews.tmpl":    "Prompt for addressing review comments on a pull request",
+	"ci_summary.tmpl":         "Job summary written in CI",
+	"issue_comment.tmpl":      "Comment posted on the issue a run implements",
+	"plan.tmpl":               "System prompt of gonzo plan",
+	"language_go.tmpl":        "System prompt section of Go projects",
+	"language_node.tmpl":      "System prompt section of Node.js projects",
+	"language_python.tmpl":    "System prompt section of Python projects",
+	"playbook_bugfix.tmpl":    "System prompt section of the bugfix playbook",
+	"playbook_docs.tmpl":      "System prompt section of the docs playbook",
+	"playbook_feature.tmpl":   "System prompt section of the feature playbook",
+	"playbook_migration.tmpl": "System prompt section of the migration playbook",
+	"playbook_refactor.tmpl":  "System prompt section of the refactor playbook",
+	"pr_body.tmpl":            "Default pull request description",
+	"pr_comment.tmpl":         "Run summary posted on the pull request",
+	"progress.tmpl":           "Initial content of the progress log",
+	"system_prompt.tmpl":      "System prompt of every iteration",
+	"transcript.tmpl":         "Run transcript printed by gonzo show",
+	"verify_failed.tmpl":      "Prompt of the iteration after failed verification commands",
+	"wrap_up.tmpl":            "Prompt of the wrap-up iterations",
+	"write_scope.tmpl":        "Note prepended to the prompt of the iteration after changes outside the write scope",
+}
+
+// Prompt describes an embedded prompt template.
+type Prompt struct {
+	Name        string
+	Description string
+	// Local is the path of the repository's copy of the template, if any.
+	Local string
+}
+
+// PromptsPath returns the directory holding the local copies of the prompt templates in dir.
+func PromptsPath(dir string) string {
+	return filepath.Join(dir, GonzoDir, PromptsDir)
+}
+
+// ListPrompts returns the embedded prompt templates, sorted by name.
+func ListPrompts(dir string) ([]Prompt, error) {
+	names, err := fs.Glob(promptLib, "prompts/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+	sort.Strings(names)
+
+	prompts := make([]Prompt, len(names))
+	for i, name := range names {
+		p := Prompt{Name: path.Base(name), Description: promptDescriptions[path.Base(name)]}
+		if local := filepath.Join(PromptsPath(dir), p.Name); fileExists(local) {
+			p.Local = local
+		}
+		prompts[i] = p
+	}
+	return prompts, nil
+}
+
+// EmbeddedPrompt returns the content of the named embedded template.
+func EmbeddedPrompt(name string) (string, error) {
+	data, err := promptLib.ReadFile("prompts/" + name)
+	if err != nil {
+		return "", fmt.Errorf("unknown prompt template %q", name)
+	}
+	return string(data), nil
+}
+
+// ExportPrompts copies the named embedded templates, or all of them when names is empty,
+// into the repository's prompts directory for local editing. Existing copies are only
+// replaced when force is set. It returns the paths written.
+func ExportPrompts(dir string, names []string, force bool) ([]string, error) {
+	if len(names) == 0 {
+		prompts, err := ListPrompts(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range prompts {
+			names = append(names, p.Name)
+		}
+	}
+
+	if err := os.MkdirAll(PromptsPath(dir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create prompts directory: %w", err)
+	}
+
+	var written []string
+	var errs []error
+	for _, name := range names {
+		content, err := EmbeddedPrompt(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		target := filepath.Join(PromptsPath(dir), name)
+		if !force && fileExists(target) {
+			errs = append(errs, fmt.Errorf("%s already exists; use --force to replace it", target))
+			continue
+		}
+		if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write %s: %w", target, err))
+			continue
+		}
+		written = append(written, target)
+	}
+	return written, errors.Join(errs...)
+}
+
+// DiffPrompt returns a unified diff from the named embedded template to the repository's copy,
+// or an empty string when they are identical.
+func DiffPrompt(dir string, name string) (string, error) {
+	embedded, err := EmbeddedPrompt(name)
+	if err != nil {
+		return "", err
+	}
+	local, err := os.ReadFile(filepath.Join(PromptsPath(dir), name))
+	if err != nil {
+		return "", fmt.Errorf("failed to read local copy of %s: %w", name, err)
+	}
+	return unifiedDiff("embedded/"+name, filepath.ToSlash(filepath.Join(GonzoDir, PromptsDir, name)), embedded, string(local)), nil
+}
+
+// parsePrompt parses the named prompt template from the local prompt templates, or from the
+// embedded ones when there is no local copy.
+func (cc *ClaudeConfig) parsePrompt(name string) (*template.Template, error) {
+	if local := cc.localPrompts(); local != nil {
+		text, err := fs.ReadFile(local, name)
+		if err == nil {
+			return template.New(name).Funcs(templateFuncs).Parse(string(text))
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read local copy of %s: %w", name, err)
+		}
+	}
+	return template.New(name).Funcs(templateFuncs).ParseFS(cc.promptSet(), name)
+}
+
+// promptSet returns the file system holding the prompt templates local copies override: the
+// one set by WithPromptFS or the embedded templates.
+func (cc *ClaudeConfig) promptSet() fs.FS {
+	if cc.promptFS != nil {
+		return cc.promptFS
+	}
+	return embeddedPrompts()
+}
+
+// embeddedPrompts returns the file system holding the embedded prompt templates at its root.
+func embeddedPrompts() fs.FS {
+	prompts, err := fs.Sub(promptLib, "prompts")
+	if err != nil {
+		panic(err)
+	}
+	return prompts
+}
+
+// localPrompts returns the file system holding the local prompt templates, if any.
+func (cc *ClaudeConfig) localPrompts() fs.FS {
+	if cc.prompts != nil {
+		return cc.prompts
+	}
+	if dir, err := cc.workDir(); err == nil {
+		return os.DirFS(PromptsPath(dir))
+	}
+	return nil
+}
+
+// fileExists reports whether path names an existing file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// diffContext is the number of unchanged lines shown around changes in a unified diff.
+const diffContext = 3
+
+// unifiedDiff returns a unified diff of the lines of a and b, or an empty string when they are equal.
+// It uses a longest common subsequence, which is fine for files the size of prompt templates.
+func unifiedDiff(aName string, bName string, a string, b string) string {
+	if a == b {
+		return ""
+	}
+	al := splitLines(a)
+	bl := splitLines(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of al[i:] and bl[j:]
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	// Walk the table into a list of edits
+	type edit struct {
+		op   byte
+		line string
+		ai   int
+		bi   int
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			edits = append(edits, edit{' ', al[i], i, j})
+			i++
+			j++
+		case i < len(al) && (j == len(bl) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', al[i], i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', bl[j], i, j})
+			j++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for start := 0; start < len(edits); {
+		// Find the next change and the extent of its hunk
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		end := first
+		for k := first; k < len(edits); k++ {
+			if edits[k].op != ' ' {
+				end = k + 1
+			} else if k-end >= 2*diffContext {
+				break
+			}
+		}
+		from := max(first-diffContext, start)
+		to := min(end+diffContext, len(edits))
+
+		aCount, bCount := 0, 0
+		for _, e := range edits[from:to] {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", edits[from].ai+1, aCount, edits[from].bi+1, bCount)
+		for _, e := range edits[from:to] {
+			out.WriteByte(e.op)
+			out.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// splitLines splits s into lines, keeping their line endings.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,379 @@
+package gonzo
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestListPrompts(t *testing.T) {
+	dir := t.TempDir()
+	prompts, err := ListPrompts(dir)
+	if err != nil {
+		t.Fatalf("ListPrompts() returned error: %v", err)
+	}
+	if len(prompts) == 0 {
+		t.Fatal("expected embedded prompts")
+	}
+	for _, p := range prompts {
+		if p.Description == "" {
+			t.Errorf("expected a description for %s", p.Name)
+		}
+		if p.Local != "" {
+			t.Errorf("expected no local copy of %s", p.Name)
+		}
+	}
+}
+
+func TestExportAndDiffPrompts(t *testing.T) {
+	dir := t.TempDir()
+
+	written, err := ExportPrompts(dir, []string{"wrap_up.tmpl"}, false)
+	if err != nil {
+		t.Fatalf("ExportPrompts() returned error: %v", err)
+	}
+	local := filepath.Join(PromptsPath(dir), "wrap_up.tmpl")
+	if len(written) != 1 || written[0] != local {
+		t.Errorf("expected %s to be written, got %v", local, written)
+	}
+
+	if _, err := ExportPrompts(dir, []string{"wrap_up.tmpl"}, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("expected existing copies to be kept, got %v", err)
+	}
+	if _, err := ExportPrompts(dir, []string{"nope.tmpl"}, false); err == nil {
+		t.Error("expected an error for an unknown template")
+	}
+
+	if diff, err := DiffPrompt(dir, "wrap_up.tmpl"); err != nil || diff != "" {
+		t.Errorf("expected no diff for an unchanged copy, got %q, %v", diff, err)
+	}
+
+	embedded, _ := EmbeddedPrompt("wrap_up.tmpl")
+	edited := strings.Replace(embedded, "# Wrap-Up Iteration", "# Last Iteration", 1)
+	if err := os.WriteFile(local, []byte(edited), 0644); err != nil {
+		t.Fatalf("failed to edit copy: %v", err)
+	}
+	diff, err := DiffPrompt(dir, "wrap_up.tmpl")
+	if err != nil {
+		t.Fatalf("DiffPrompt() returned error: %v", err)
+	}
+	for _, want := range []string{"--- embedded/wrap_up.tmpl", "+++ .gonzo/prompts/wrap_up.tmpl", "-# Wrap-Up Iteration\n", "+# Last Iteration\n"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("expected %q in the diff, got %q", want, diff)
+		}
+	}
+
+	prompts, _ := ListPrompts(dir)
+	for _, p := range prompts {
+		if (p.Local != "") != (p.Name == "wrap_up.tmpl") {
+			t.Errorf("unexpected local copy %q for %s", p.Local, p.Name)
+		}
+	}
+}
+
+func TestSystemPrompt_BranchRunID(t *testing.T) {
+	cc := New().WithBranchRunID(true)
+	cc.runID = "20260201-202613-a1b2c3"
+
+	prompt, err := cc.systemPrompt(".gonzo/progress.md")
+	if err != nil {
+		t.Fatalf("systemPrompt() returned error: %v", err)
+	}
+	if !strings.Contains(prompt, "ending with `-a1b2c3`") {
+		t.Errorf("expected the branch name to end with the short run ID, got %q", prompt)
+	}
+
+	if prompt, _ := cc.WithBranchRunID(false).systemPrompt(".gonzo/progress.md"); strings.Contains(prompt, "a1b2c3") {
+		t.Errorf("expected the short run ID to be left out by default, got %q", prompt)
+	}
+}
+
+func TestParsePrompt_LocalOverride(t *testing.T) {
+	cc := New().WithPrompts(fstest.MapFS{
+		"system_prompt.tmpl": {Data: []byte("Local prompt for {{ .CommitAuthor }}")},
+	})
+
+	prompt, err := cc.systemPrompt(".gonzo/progress.md")
+	if err != nil {
+		t.Fatalf("systemPrompt() returned error: %v", err)
+	}
+	if prompt != "Local prompt for "+DefaultCommitAuthor {
+		t.Errorf("expected the local template to be used, got %q", prompt)
+	}
+
+	// Templates without a local copy fall back to the embedded ones
+	prompt, err = cc.wrapUpPrompt("Add a login button", ".gonzo/progress.md", 9)
+	if err != nil {
+		t.Fatalf("wrapUpPrompt() returned error: %v", err)
+	}
+	if !strings.Contains(prompt, "# Wrap-Up Iteration") {
+		t.Errorf("expected the embedded template to be used, got %q", prompt)
+	}
+
+	cc.WithPrompts(fstest.MapFS{"system_prompt.tmpl": {Data: []byte("{{ .Nope")}})
+	if _, err := cc.systemPrompt(".gonzo/progress.md"); err == nil {
+		t.Error("expected an error for an invalid local template")
+	}
+}
+
+func TestSystemPrompt_Vars(t *testing.T) {
+	cc := New().WithVars(map[string]string{"project": "gonzo", "language": "Go"})
+	prompt, err := cc.systemPrompt(".gonzo/progress.md")
+	if err != nil {
+		t.Fatalf("systemPrompt() returned error: %v", err)
+	}
+	for _, want := range []string{"## Project Context", "- language: Go\n", "- project: gonzo\n"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in the system prompt, got %q", want, prompt)
+		}
+	}
+
+	cc.WithPrompts(fstest.MapFS{"system_prompt.tmpl": {Data: []byte("Write {{ .Vars.language }} for {{ .Vars.project }}")}})
+	if prompt, err := cc.systemPrompt(".gonzo/progress.md"); err != nil || prompt != "Write Go for gonzo" {
+		t.Errorf("expected the variables in the local template, got %q, %v", prompt, err)
+	}
+
+	if prompt, _ := New().systemPrompt(".gonzo/progress.md"); strings.Contains(prompt, "## Project Context") {
+		t.Error("expected no project context without variables")
+	}
+}
+
+func TestSystemPrompt_PromptFS(t *testing.T) {
+	prompts := fstest.MapFS{
+		"system_prompt.tmpl":  {Data: []byte("Work on {{ .ProgressFile }}")},
+		"playbook_ops.tmpl":   {Data: []byte("## Playbook: Ops")},
+		"language_cobol.tmpl": {Data: []byte("## Language: COBOL")},
+	}
+	cc := New(WithDir(t.TempDir()), WithPromptFS(prompts))
+
+	if got := cc.playbooks(); !slices.Equal(got, []string{"ops"}) {
+		t.Errorf("expected only the playbooks of the prompt set, got %v", got)
+	}
+	if got := cc.languages(); !slices.Equal(got, []string{"cobol"}) {
+		t.Errorf("expected only the languages of the prompt set, got %v", got)
+	}
+	tmpl, err := cc.parsePrompt("system_prompt.tmpl")
+	if err != nil {
+		t.Fatalf("parsePrompt() returned error: %v", err)
+	}
+	if tmpl.Lookup("system_prompt.tmpl") == nil {
+		t.Error("expected the system prompt of the prompt set")
+	}
+	if _, err := cc.parsePrompt("wrap_up.tmpl"); err == nil {
+		t.Error("expected templates missing from the prompt set not to fall back to the embedded ones")
+	}
+}
+
+func TestSystemPrompt_Playbook(t *testing.T) {
+	local := fstest.MapFS{
+		"playbook_bugfix.tmpl":   {Data: []byte("## Local bug fixes")},
+		"playbook_security.tmpl": {Data: []byte("## Playbook: Security{{ if .Tests }} with tests{{ end }}")},
+	}
+	cc := New().WithPrompts(local)
+
+	if got := cc.playbooks(); !slices.Equal(got, []string{"bugfix", "docs", "feature", "migration", "refactor", "security"}) {
+		t.Errorf("unexpected playbooks %v", got)
+	}
+
+	tests := map[string]string{
+		PlaybookDocs:   "## Playbook: Documentation",
+		PlaybookBugfix: "## Local bug fixes",
+		"security":     "## Playbook: Security with tests",
+	}
+	for playbook, want := range tests {
+		prompt, err := cc.WithPlaybook(playbook).systemPrompt(".gonzo/progress.md")
+		if err != nil {
+			t.Fatalf("systemPrompt() with playbook %s returned error: %v", playbook, err)
+		}
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in the system prompt of playbook %s, got %q", want, playbook, prompt)
+		}
+	}
+
+	if _, err := cc.WithPlaybook("nope").systemPrompt(".gonzo/progress.md"); err == nil || !strings.Contains(err.Error(), "available: bugfix") {
+		t.Errorf("expected an error for an unknown playbook, got %v", err)
+	}
+	if prompt, _ := New().systemPrompt(".gonzo/progress.md"); strings.Contains(prompt, "## Playbook") {
+		t.Error("expected no playbook section without a playbook")
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13"
+
+	want := `--- a
++++ b
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+\ No newline at end of file
+`
+	if got := unifiedDiff("a", "b", a, b); got != want {
+		t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+	if got := unifiedDiff("a", "b", a, a); got != "" {
+		t.Errorf("expected no diff for equal content, got %q", got)
+	}
+}
+
+func TestSystemPrompt_Guidance(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "CLAUDE.md"), []byte("Run make check before committing\n"), 0644); err != nil {
+		t.Fatalf("failed to write CLAUDE.md: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "CONTRIBUTING.md"), []byte(strings.Repeat("Be nice. ", 100)), 0644); err != nil {
+		t.Fatalf("failed to write CONTRIBUTING.md: %v", err)
+	}
+	files := []string{"CLAUDE.md", "AGENTS.md", "CONTRIBUTING.md"}
+
+	cc := New(WithWorkingDir(dir)).WithGuidance(GuidanceOptions{Mode: GuidanceInline, Files: files, MaxSize: 100})
+	prompt, err := cc.systemPrompt(".gonzo/progress.md")
+	if err != nil {
+		t.Fatalf("systemPrompt() returned error: %v", err)
+	}
+	for _, want := range []string{"## Project Guidance", "### CLAUDE.md\n\nRun make check before committing\n", "- Read `CONTRIBUTING.md`"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in the system prompt, got %q", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "AGENTS.md") || strings.Contains(prompt, "Be nice") {
+		t.Error("expected missing files to be left out and files over the maximum size to be referenced")
+	}
+
+	cc.WithGuidance(GuidanceOptions{Mode: GuidanceReference, Files: files})
+	if prompt, _ := cc.systemPrompt(".gonzo/progress.md"); !strings.Contains(prompt, "- Read `CLAUDE.md`") || strings.Contains(prompt, "Run make check") {
+		t.Errorf("expected CLAUDE.md to be referenced only, got %q", prompt)
+	}
+
+	cc.WithGuidance(GuidanceOptions{Mode: GuidanceOff, Files: files})
+	if prompt, _ := cc.systemPrompt(".gonzo/progress.md"); strings.Contains(prompt, "## Project Guidance") {
+		t.Error("expected no project guidance when turned off")
+	}
+}
+
+func TestSystemPrompt_Language(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("failed to write package.json: %v", err)
+	}
+	if got := DetectLanguage(dir); got != LanguageNode {
+		t.Errorf("expected node to be detected, got %q", got)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	if got := DetectLanguage(dir); got != LanguageGo {
+		t.Errorf("expected go.mod to take precedence, got %q", got)
+	}
+
+	cc := New(WithWorkingDir(dir)).WithPrompts(fstest.MapFS{
+		"language_rust.tmpl": {Data: []byte("## Language: Rust{{ if .Tests }} with tests{{ end }}")},
+	})
+	for language, want := range map[string]string{
+		LanguageAuto:   "## Language: Go",
+		LanguagePython: "## Language: Python",
+		"rust":         "## Language: Rust with tests",
+	} {
+		prompt, err := cc.WithLanguage(language).systemPrompt(".gonzo/progress.md")
+		if err != nil {
+			t.Fatalf("systemPrompt() returned error for %s: %v", language, err)
+		}
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in the system prompt of %s, got %q", want, language, prompt)
+		}
+	}
+	if _, err := cc.WithLanguage("cobol").systemPrompt(".gonzo/progress.md"); err == nil || !strings.Contains(err.Error(), "available: go, node, python, rust") {
+		t.Errorf("expected an error for an unknown language, got %v", err)
+	}
+	if prompt, _ := cc.WithLanguage(LanguageNone).systemPrompt(".gonzo/progress.md"); strings.Contains(prompt, "## Language") {
+		t.Error("expected no language guidance when turned off")
+	}
+
+	if err := os.Remove(filepath.Join(dir, "go.mod")); err != nil {
+		t.Fatalf("failed to remove go.mod: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "package.json")); err != nil {
+		t.Fatalf("failed to remove package.json: %v", err)
+	}
+	if prompt, err := cc.WithLanguage(LanguageAuto).systemPrompt(".gonzo/progress.md"); err != nil || strings.Contains(prompt, "## Language") {
+		t.Errorf("expected no language guidance when none is detected, got %v", err)
+	}
+}
+
+func TestLintPrompts(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := ExportPrompts(dir, nil, false); err != nil {
+		t.Fatalf("ExportPrompts() returned error: %v", err)
+	}
+	lints, err := LintPrompts(dir)
+	if err != nil {
+		t.Fatalf("LintPrompts() returned error: %v", err)
+	}
+	for _, lint := range lints {
+		if lint.Err != nil {
+			t.Errorf("expected the embedded %s to lint cleanly, got %v", lint.Name, lint.Err)
+		}
+		if lint.Name == "pr_body.tmpl" && !strings.Contains(lint.Warning, "ignored") {
+			t.Errorf("expected a warning for the ignored local pr_body.tmpl, got %q", lint.Warning)
+		}
+	}
+
+	templates := map[string]string{
+		"wrap_up.tmpl":       "{{ .Feature }} {{ .Iterations }}",
+		"plan.tmpl":          "{{ if .Tests }}{{ .ProgressFile",
+		"system_prompt.tmpl": "{{ range .Guidance }}{{ .Path }} {{ .Size }}{{ end }}{{ .Vars.project }}",
+		"progress.tmpl":      "{{ .Now.Format \"2006\" }} {{ $.Branch }} {{ $.Remote }}",
+		"playbook_docs.tmpl": "{{ with .Playbook }}{{ . }}{{ end }}",
+		"issue_comment.tmpl": "{{ .Feature }} {{ .Summary }}",
+		"verify_failed.tmpl": "{{ .Output | printf \"%q\" }}",
+		"notes.tmpl":         "{{ .Anything }}",
+		"pr_comment.tmpl":    "{{ .Issue.Title }} {{ range .LinkedIssues }}{{ .Number }}{{ end }}",
+	}
+	for name, text := range templates {
+		if err := os.WriteFile(filepath.Join(PromptsPath(dir), name), []byte(text), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	lints, err = LintPrompts(dir)
+	if err != nil {
+		t.Fatalf("LintPrompts() returned error: %v", err)
+	}
+	results := map[string]PromptLint{}
+	for _, lint := range lints {
+		results[lint.Name] = lint
+	}
+
+	for name, want := range map[string]string{
+		"wrap_up.tmpl":       "unknown field .Iterations",
+		"plan.tmpl":          "unclosed action",
+		"system_prompt.tmpl": "unknown field .Size",
+		"progress.tmpl":      "unknown field $.Remote",
+		"issue_comment.tmpl": "unknown field .Summary",
+	} {
+		if err := results[name].Err; err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q for %s, got %v", want, name, err)
+		}
+	}
+	for _, name := range []string{"playbook_docs.tmpl", "verify_failed.tmpl", "pr_comment.tmpl", "notes.tmpl"} {
+		if err := results[name].Err; err != nil {
+			t.Errorf("expected %s to lint cleanly, got %v", name, err)
+		}
+	}
+	if results["notes.tmpl"].Warning != "not a template gonzo uses" {
+		t.Errorf("expected a warning for notes.tmpl, got %q", results["notes.tmpl"].Warning)
+	}
+}
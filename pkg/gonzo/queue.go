@@ -0,0 +1,87 @@
+package gonzo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Subdirectories of a watched queue directory that processed feature files are moved to.
+const (
+	QueueDoneDir   = "done"
+	QueueFailedDir = "failed"
+)
+
+// QueueErrorSuffix is appended to the name of a failed feature file to name the file holding its error.
+const QueueErrorSuffix = ".error"
+
+// PendingFeatureFiles returns the paths of the feature files waiting in a queue directory,
+// oldest first. Hidden files are left alone, so producers can write a file under a hidden
+// name and rename it once complete.
+func PendingFeatureFiles(queueDir string) ([]string, error) {
+	entries, err := os.ReadDir(queueDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue directory: %w", err)
+	}
+
+	type pending struct {
+		path    string
+		modTime time.Time
+	}
+	var files []pending
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// Removed since the directory was read
+			continue
+		}
+		files = append(files, pending{filepath.Join(queueDir, entry.Name()), info.ModTime()})
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths, nil
+}
+
+// FinishFeatureFile moves a processed feature file to the done subdirectory of its queue,
+// or to the failed one when runErr is not nil, next to a file holding the error.
+// It returns the new path of the feature file.
+func FinishFeatureFile(path string, runErr error) (string, error) {
+	sub := QueueDoneDir
+	if runErr != nil {
+		sub = QueueFailedDir
+	}
+	targetDir := filepath.Join(filepath.Dir(path), sub)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s directory: %w", sub, err)
+	}
+
+	// Keep earlier files of the same name
+	target := filepath.Join(targetDir, filepath.Base(path))
+	if _, err := os.Stat(target); err == nil {
+		target = filepath.Join(targetDir, time.Now().Format("20060102-150405")+"-"+filepath.Base(path))
+	}
+
+	if err := os.Rename(path, target); err != nil {
+		return "", fmt.Errorf("failed to move %s to %s: %w", path, sub, err)
+	}
+
+	if runErr != nil {
+		if err := os.WriteFile(target+QueueErrorSuffix, []byte(runErr.Error()+"\n"), 0644); err != nil {
+			return target, fmt.Errorf("failed to write error file: %w", err)
+		}
+	}
+	return target, nil
+}
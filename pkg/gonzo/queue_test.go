@@ -0,0 +1,71 @@
+package gonzo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPendingFeatureFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"b.md", "a.md", ".partial.md"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("feature"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		modTime := now.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("failed to set times of %s: %v", name, err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, QueueDoneDir), 0755); err != nil {
+		t.Fatalf("failed to create done directory: %v", err)
+	}
+
+	paths, err := PendingFeatureFiles(dir)
+	if err != nil {
+		t.Fatalf("PendingFeatureFiles() returned error: %v", err)
+	}
+	if len(paths) != 2 || filepath.Base(paths[0]) != "b.md" || filepath.Base(paths[1]) != "a.md" {
+		t.Errorf("expected b.md then a.md, got %v", paths)
+	}
+}
+
+func TestFinishFeatureFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("feature"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	target, err := FinishFeatureFile(write("ok.md"), nil)
+	if err != nil {
+		t.Fatalf("FinishFeatureFile() returned error: %v", err)
+	}
+	if target != filepath.Join(dir, QueueDoneDir, "ok.md") {
+		t.Errorf("unexpected target %q", target)
+	}
+
+	// A second file of the same name does not replace the first
+	again, err := FinishFeatureFile(write("ok.md"), nil)
+	if err != nil || again == target {
+		t.Errorf("expected a distinct target, got %q, %v", again, err)
+	}
+
+	target, err = FinishFeatureFile(write("bad.md"), errors.New("claude failed"))
+	if err != nil {
+		t.Fatalf("FinishFeatureFile() returned error: %v", err)
+	}
+	if target != filepath.Join(dir, QueueFailedDir, "bad.md") {
+		t.Errorf("unexpected target %q", target)
+	}
+	if data, err := os.ReadFile(target + QueueErrorSuffix); err != nil || string(data) != "claude failed\n" {
+		t.Errorf("expected the error next to the file, got %q, %v", data, err)
+	}
+}
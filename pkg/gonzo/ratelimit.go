@@ -0,0 +1,122 @@
+package gonzo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRateLimitWait is how long an iteration waits in total for rate limits to lift unless
+// configured otherwise.
+const DefaultRateLimitWait = 30 * time.Minute
+
+// rateLimitDelay is the pause before retrying a rate limited Claude CLI call that gives no
+// hint of when to retry; it doubles with every such wait, up to maxRateLimitDelay. It is a
+// variable for testing.
+var rateLimitDelay = 30 * time.Second
+
+// maxRateLimitDelay bounds the pause before retrying a rate limited call without a hint.
+const maxRateLimitDelay = 5 * time.Minute
+
+// RateLimitError is the error of a Claude CLI call refused because the API is rate limited or
+// overloaded.
+type RateLimitError struct {
+	Err error
+	// RetryAfter is how long the CLI asked to wait before retrying, zero when it did not say.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited, retry after %s: %v", e.RetryAfter, e.Err)
+	}
+	return fmt.Sprintf("rate limited: %v", e.Err)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// rateLimitPattern matches the errors of the Claude CLI and the API it calls telling that
+	// the call was refused for being over the rate limits or the API being overloaded.
+	rateLimitPattern = regexp.MustCompile(`(?i)rate[ _-]?limit|overloaded|usage limit reached|too many requests|API Error: (429|529)\b|status(?: code)?:? (429|529)\b`)
+	// retryAfterPattern matches a hint of how long to wait, e.g. "retry-after: 30" or "try
+	// again in 2 minutes".
+	retryAfterPattern = regexp.MustCompile(`(?i)(?:retry[ _-]after|try again in)"?\s*[:=]?\s*"?(\d+(?:\.\d+)?)\s*(ms|milliseconds?|s|secs?|seconds?|m|mins?|minutes?|h|hours?)?\b`)
+	// usageLimitResetPattern matches the Unix time a usage limit resets at, as printed by the
+	// Claude CLI, e.g. "Claude AI usage limit reached|1767225600".
+	usageLimitResetPattern = regexp.MustCompile(`(?i)usage limit reached\|(\d{9,})`)
+)
+
+// detectRateLimit reports whether the error output of a Claude CLI call tells it was rate
+// limited, and how long it asks to wait before retrying, if it says.
+func detectRateLimit(text string) (bool, time.Duration) {
+	if !rateLimitPattern.MatchString(text) {
+		return false, 0
+	}
+	if m := usageLimitResetPattern.FindStringSubmatch(text); m != nil {
+		if reset, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+			return true, max(time.Until(time.Unix(reset, 0)).Round(time.Second), 0)
+		}
+	}
+	m := retryAfterPattern.FindStringSubmatch(text)
+	if m == nil {
+		return true, 0
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return true, 0
+	}
+	unit := time.Second
+	switch s := strings.ToLower(m[2]); {
+	case s == "ms" || strings.HasPrefix(s, "milli"):
+		unit = time.Millisecond
+	case strings.HasPrefix(s, "m"):
+		unit = time.Minute
+	case strings.HasPrefix(s, "h"):
+		unit = time.Hour
+	}
+	return true, time.Duration(n * float64(unit))
+}
+
+// cliErrorResult returns the text of the result object of a failed call, from the output of
+// the Claude CLI in one of its JSON output formats, or "" when the call did not fail.
+func cliErrorResult(out []byte) string {
+	lines := bytes.Split(bytes.TrimSpace(out), []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		var result struct {
+			Type    string `json:"type"`
+			IsError bool   `json:"is_error"`
+			Result  string `json:"result"`
+		}
+		if json.Unmarshal(lines[i], &result) == nil && result.Type == "result" {
+			if result.IsError {
+				return result.Result
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// WithRateLimitWait sets how long, in total, an iteration waits for the rate limits of the API
+// to lift, retrying a rate limited or overloaded call after the delay the CLI asks for. Those
+// retries are not counted against WithRetries. Zero fails rate limited calls like any other.
+func WithRateLimitWait(rateLimitWait time.Duration) Option {
+	return func(cc *ClaudeConfig) {
+		cc.rateLimitWait = rateLimitWait
+	}
+}
+
+// WithRateLimitWait applies the WithRateLimitWait option to cc.
+//
+// Deprecated: Pass WithRateLimitWait to New instead.
+func (cc *ClaudeConfig) WithRateLimitWait(rateLimitWait time.Duration) *ClaudeConfig {
+	WithRateLimitWait(rateLimitWait)(cc)
+	return cc
+}
@@ -0,0 +1,86 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectRateLimit(t *testing.T) {
+	reset := time.Now().Add(90 * time.Minute).Unix()
+	tests := []struct {
+		name        string
+		text        string
+		wantLimited bool
+		wantWait    time.Duration
+	}{
+		{"429 with retry-after", `API Error: 429 {"type":"error","error":{"type":"rate_limit_error"}} retry-after: 30`, true, 30 * time.Second},
+		{"header in json", `{"headers":{"retry-after":"12"},"error":"rate_limit_error"}`, true, 12 * time.Second},
+		{"try again in minutes", "Rate limit exceeded, please try again in 2 minutes", true, 2 * time.Minute},
+		{"overloaded", `API Error: 529 {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, true, 0},
+		{"other error", "Error: invalid model claude-opus-9", false, 0},
+		{"not found", "HTTP 404 Not Found", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limited, wait := detectRateLimit(tt.text)
+			if limited != tt.wantLimited || wait != tt.wantWait {
+				t.Errorf("detectRateLimit(%q) = %v, %s, want %v, %s", tt.text, limited, wait, tt.wantLimited, tt.wantWait)
+			}
+		})
+	}
+
+	// Usage limits reset at a given time
+	limited, wait := detectRateLimit(fmt.Sprintf("Claude AI usage limit reached|%d", reset))
+	if !limited || wait < 89*time.Minute || wait > 90*time.Minute {
+		t.Errorf("expected to wait until the usage limit resets, got %v, %s", limited, wait)
+	}
+}
+
+func TestGenerate_RateLimitWait(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	originalRateLimitDelay := rateLimitDelay
+	defer func() { commandContext, rateLimitDelay = originalCommandContext, originalRateLimitDelay }()
+
+	dir := t.TempDir()
+	rateLimitDelay = time.Millisecond
+	calls := 0
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if !slices.Contains(args, "--print") {
+			return mockCommandContext("", 0)(ctx, name, args...)
+		}
+		calls++
+		if calls <= 2 {
+			// Refused with an error result, then with an error exit
+			cmd := mockCommandContext(`{"type":"result","is_error":true,"result":"API Error: 529 Overloaded"}`, calls-1)(ctx, name, args...)
+			return cmd
+		}
+		return mockCommandContext("Done "+DefaultCompletionSignal, 0)(ctx, name, args...)
+	}
+
+	var result string
+	var err error
+	output := captureStdout(t, func() {
+		result, err = New(WithDir(dir)).Generate(context.Background(), "add a login button")
+	})
+	if err != nil || result != "Done" || calls != 3 {
+		t.Fatalf("expected the iteration to go on once the rate limit lifted, got %q, %v after %d calls", result, err, calls)
+	}
+	if !strings.Contains(output, "Claude CLI call rate limited; waiting 1ms before retrying") || !strings.Contains(output, "waiting 2ms") {
+		t.Errorf("expected the waits to be logged, got %s", output)
+	}
+
+	// Without waiting, a rate limited call fails like any other
+	calls = 0
+	_, err = New(WithDir(dir)).WithQuiet(true).WithRateLimitWait(0).Generate(context.Background(), "add a login button")
+	var limited *RateLimitError
+	if !errors.As(err, &limited) || calls != 1 {
+		t.Errorf("expected the rate limited call to fail the run, got %v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,255 @@
+package gonzo
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// CacheDir is the directory inside GonzoDir holding data cached between runs.
+const CacheDir = "cache"
+
+// repoMapCacheFile is the file inside CacheDir holding the last repository map.
+const repoMapCacheFile = "repomap.json"
+
+// repoMapMaxDirs bounds the number of directories listed in a repository map.
+const repoMapMaxDirs = 150
+
+// repoMapMaxRootFiles bounds the number of files at the root of the repository listed by name.
+const repoMapMaxRootFiles = 30
+
+// cacheGitignore keeps cached data out of commits made by the agent; it ignores itself too.
+const cacheGitignore = "*\n"
+
+// repoMapCache is the repository map cached in .gonzo/cache/repomap.json, along with the key
+// of the files it was built from.
+type repoMapCache struct {
+	Key string `json:"key"`
+	Map string `json:"map"`
+}
+
+// RepoMap returns a compact map of the repository in dir, to orient the agent: the module
+// of its go.mod, the files at its root and its directories with their file count and the
+// synopsis of their Go package. The map is cached until the commit checked out or the list
+// of files changes.
+func RepoMap(ctx context.Context, dir string) (string, error) {
+	files, err := repoFiles(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	head, _ := headSHA(ctx, dir)
+	sum := sha256.Sum256([]byte(head + "\n" + strings.Join(files, "\n")))
+	key := hex.EncodeToString(sum[:])
+
+	cacheFile := filepath.Join(dir, GonzoDir, CacheDir, repoMapCacheFile)
+	var cached repoMapCache
+	if data, err := os.ReadFile(cacheFile); err == nil && json.Unmarshal(data, &cached) == nil && cached.Key == key {
+		return cached.Map, nil
+	}
+
+	repoMap := buildRepoMap(dir, files)
+	if err := writeCache(dir, repoMapCacheFile, repoMapCache{Key: key, Map: repoMap}); err != nil {
+		return "", err
+	}
+	return repoMap, nil
+}
+
+// writeCache writes value as JSON to the named file of the cache directory in dir.
+func writeCache(dir string, name string, value interface{}) error {
+	cacheDir := filepath.Join(dir, GonzoDir, CacheDir)
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	ignore := filepath.Join(cacheDir, ".gitignore")
+	if _, err := os.Stat(ignore); errors.Is(err, os.ErrNotExist) {
+		if err := os.WriteFile(ignore, []byte(cacheGitignore), 0644); err != nil {
+			return fmt.Errorf("failed to write cache .gitignore: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	if err := os.WriteFile(filepath.Join(cacheDir, name), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// repoFiles returns the slash-separated paths of the files of the repository in dir, sorted:
+// the tracked and untracked files git does not ignore, outside of gonzo's own directories, or
+// every file outside of hidden and dependency directories when dir is not a git repository.
+func repoFiles(ctx context.Context, dir string) ([]string, error) {
+	if out, err := git(ctx, dir, "ls-files", "--cached", "--others", "--exclude-standard"); err == nil {
+		var files []string
+		for _, file := range strings.Split(out, "\n") {
+			if file != "" && !slices.Contains(strings.Split(file, "/"), GonzoDir) {
+				files = append(files, file)
+			}
+		}
+		sort.Strings(files)
+		return files, nil
+	}
+
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rel, err := filepath.Rel(dir, p); err == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repository files: %w", err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// buildRepoMap renders the map of the repository in dir made of files.
+func buildRepoMap(dir string, files []string) string {
+	var b strings.Builder
+	if module := goModSummary(filepath.Join(dir, "go.mod")); module != "" {
+		b.WriteString(module + "\n\n")
+	}
+
+	var rootFiles []string
+	counts := map[string]int{}
+	goDirs := map[string]bool{}
+	for _, file := range files {
+		d := path.Dir(file)
+		if d == "." {
+			rootFiles = append(rootFiles, file)
+			continue
+		}
+		counts[d]++
+		if strings.HasSuffix(file, ".go") && !strings.HasSuffix(file, "_test.go") {
+			goDirs[d] = true
+		}
+	}
+
+	if len(rootFiles) > 0 {
+		if len(rootFiles) > repoMapMaxRootFiles {
+			rootFiles = append(rootFiles[:repoMapMaxRootFiles], fmt.Sprintf("and %d more", len(rootFiles)-repoMapMaxRootFiles))
+		}
+		b.WriteString("Files at the root: " + strings.Join(rootFiles, ", ") + "\n\n")
+	}
+
+	dirs := make([]string, 0, len(counts))
+	for d := range counts {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+	for i, d := range dirs {
+		if i == repoMapMaxDirs {
+			fmt.Fprintf(&b, "- and %d more directories\n", len(dirs)-repoMapMaxDirs)
+			break
+		}
+		fmt.Fprintf(&b, "- `%s/` (%s)", d, plural(counts[d], "file"))
+		if goDirs[d] {
+			if synopsis := packageSynopsis(filepath.Join(dir, filepath.FromSlash(d))); synopsis != "" {
+				b.WriteString(": " + synopsis)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// plural returns n followed by noun, in the plural unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// goModSummary summarizes the go.mod file at path: its module, Go version and direct
+// requirements. It is empty when there is no go.mod file.
+func goModSummary(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() { Swallow(f.Close()) }()
+
+	var module, version string
+	var requires []string
+	inRequire := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "module "):
+			module = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+		case strings.HasPrefix(line, "go "):
+			version = strings.TrimSpace(strings.TrimPrefix(line, "go "))
+		case line == "require (":
+			inRequire = true
+		case inRequire && line == ")":
+			inRequire = false
+		case inRequire || strings.HasPrefix(line, "require "):
+			fields := strings.Fields(strings.TrimPrefix(line, "require "))
+			if len(fields) > 0 && !strings.Contains(line, "// indirect") {
+				requires = append(requires, fields[0])
+			}
+		}
+	}
+	if module == "" {
+		return ""
+	}
+
+	summary := fmt.Sprintf("Go module `%s`", module)
+	if version != "" {
+		summary += " (go " + version + ")"
+	}
+	if len(requires) > 0 {
+		summary += ", requiring " + strings.Join(requires, ", ")
+	}
+	return summary
+}
+
+// packageSynopsis returns the first sentence of the doc comment of the Go package in dir.
+func packageSynopsis(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil || file.Doc == nil {
+			continue
+		}
+		return new(doc.Package).Synopsis(file.Doc.Text())
+	}
+	return ""
+}
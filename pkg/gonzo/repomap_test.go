@@ -0,0 +1,94 @@
+package gonzo
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRepoFile(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+func TestRepoMap(t *testing.T) {
+	dir := t.TempDir()
+	writeRepoFile(t, dir, "go.mod", "module example.com/app\n\ngo 1.25\n\nrequire (\n\tgithub.com/spf13/cobra v1.10.2\n\tgolang.org/x/sys v0.36.0 // indirect\n)\n")
+	writeRepoFile(t, dir, "README.md", "# App\n")
+	writeRepoFile(t, dir, "pkg/store/store.go", "// Package store persists the orders. It uses SQLite.\npackage store\n")
+	writeRepoFile(t, dir, "pkg/store/store_test.go", "package store\n")
+	writeRepoFile(t, dir, "node_modules/left-pad/index.js", "")
+
+	repoMap, err := RepoMap(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("RepoMap() returned error: %v", err)
+	}
+	for _, want := range []string{
+		"Go module `example.com/app` (go 1.25), requiring github.com/spf13/cobra\n",
+		"Files at the root: README.md, go.mod\n",
+		"- `pkg/store/` (2 files): Package store persists the orders.",
+	} {
+		if !strings.Contains(repoMap, want) {
+			t.Errorf("expected %q in the repository map, got %q", want, repoMap)
+		}
+	}
+	if strings.Contains(repoMap, "node_modules") || strings.Contains(repoMap, "golang.org/x/sys") {
+		t.Errorf("expected dependencies to be left out, got %q", repoMap)
+	}
+
+	// The map is read from the cache while the files do not change
+	cacheFile := filepath.Join(dir, GonzoDir, CacheDir, repoMapCacheFile)
+	data, err := os.ReadFile(cacheFile)
+	if err != nil {
+		t.Fatalf("expected the repository map to be cached: %v", err)
+	}
+	var cached repoMapCache
+	if err := json.Unmarshal(data, &cached); err != nil {
+		t.Fatalf("failed to decode the cache: %v", err)
+	}
+	cached.Map = "cached map"
+	if err := writeCache(dir, repoMapCacheFile, cached); err != nil {
+		t.Fatalf("failed to write the cache: %v", err)
+	}
+	if repoMap, _ := RepoMap(context.Background(), dir); repoMap != "cached map" {
+		t.Errorf("expected the cached repository map, got %q", repoMap)
+	}
+
+	writeRepoFile(t, dir, "cmd/app/main.go", "package main\n")
+	repoMap, err = RepoMap(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("RepoMap() returned error: %v", err)
+	}
+	if !strings.Contains(repoMap, "- `cmd/app/` (1 file)") {
+		t.Errorf("expected a new file to refresh the repository map, got %q", repoMap)
+	}
+}
+
+func TestSystemPrompt_RepoMap(t *testing.T) {
+	dir := t.TempDir()
+	writeRepoFile(t, dir, "main.go", "package main\n")
+
+	cc := New(WithDir(dir))
+	cc.loadRepoMap(context.Background(), dir)
+	prompt, err := cc.systemPrompt(".gonzo/progress.md")
+	if err != nil {
+		t.Fatalf("systemPrompt() returned error: %v", err)
+	}
+	if !strings.Contains(prompt, "## Repository Map") || !strings.Contains(prompt, "Files at the root: main.go") {
+		t.Errorf("expected the repository map in the system prompt, got %q", prompt)
+	}
+
+	cc.WithNoRepoMap(true).loadRepoMap(context.Background(), dir)
+	if prompt, _ := cc.systemPrompt(".gonzo/progress.md"); strings.Contains(prompt, "## Repository Map") {
+		t.Error("expected no repository map with --no-repomap")
+	}
+}
@@ -0,0 +1,51 @@
+package gonzo
+
+import (
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"github.com/andybarilla/gonzo/pkg/github"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// reviewReplyPattern matches the <reply id="…">…</reply> blocks the agent writes for each review comment.
+var reviewReplyPattern = regexp.MustCompile(`(?s)<reply id="(\d+)">(.*?)</reply>`)
+
+// ReviewPrompt builds the feature prompt asking the agent to address the given review comments.
+func ReviewPrompt(pr *forge.PullRequest, comments []github.ReviewComment) (string, error) {
+	t, err := template.ParseFS(promptLib, "prompts/address_reviews.tmpl")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse review prompt template: %w", err)
+	}
+
+	var b strings.Builder
+	err = t.Execute(&b, struct {
+		PullRequest *forge.PullRequest
+		Comments    []github.ReviewComment
+	}{
+		PullRequest: pr,
+		Comments:    comments,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute review prompt template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// ParseReviewReplies extracts the per-comment replies from the agent output, keyed by
+// review comment ID, and returns the output with the reply blocks removed.
+func ParseReviewReplies(out string) (map[int64]string, string) {
+	replies := map[int64]string{}
+	for _, m := range reviewReplyPattern.FindAllStringSubmatch(out, -1) {
+		id, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if reply := strings.TrimSpace(m[2]); reply != "" {
+			replies[id] = reply
+		}
+	}
+	return replies, strings.TrimSpace(reviewReplyPattern.ReplaceAllString(out, ""))
+}
@@ -0,0 +1,42 @@
+package gonzo
+
+import (
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"github.com/andybarilla/gonzo/pkg/github"
+	"strings"
+	"testing"
+)
+
+func TestReviewPrompt(t *testing.T) {
+	pr := &forge.PullRequest{Number: 7, Title: "Add login"}
+	comments := []github.ReviewComment{
+		{ID: 11, Path: "auth.go", Line: 42, Author: "octocat", Body: "Handle the error here"},
+		{ID: 12, Author: "hubot", Body: "Add a changelog entry"},
+	}
+
+	prompt, err := ReviewPrompt(pr, comments)
+	if err != nil {
+		t.Fatalf("ReviewPrompt() returned error: %v", err)
+	}
+	for _, want := range []string{"pull request #7 (Add login)", "### Comment 11 on `auth.go:42` by @octocat", "Handle the error here",
+		"### Comment 12 by @hubot", `<reply id="COMMENT_ID">`} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected prompt to contain %q, got %q", want, prompt)
+		}
+	}
+}
+
+func TestParseReviewReplies(t *testing.T) {
+	out := "All comments addressed.\n<reply id=\"11\">Now returning the error.</reply>\n<reply id=\"12\">\n  Added an entry.\n</reply>\n<reply id=\"13\"></reply>"
+
+	replies, rest := ParseReviewReplies(out)
+	if len(replies) != 2 {
+		t.Fatalf("expected 2 replies, got %v", replies)
+	}
+	if replies[11] != "Now returning the error." || replies[12] != "Added an entry." {
+		t.Errorf("unexpected replies %v", replies)
+	}
+	if rest != "All comments addressed." {
+		t.Errorf("expected reply blocks to be removed, got %q", rest)
+	}
+}
@@ -0,0 +1,135 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RollbackOptions controls how Rollback undoes a run.
+type RollbackOptions struct {
+	// DeleteBranch deletes the branch the run created, if it differs from the starting branch.
+	DeleteBranch bool
+	// Revert adds revert commits on the run's branch instead of resetting it,
+	// which keeps history intact for branches that were already pushed.
+	Revert bool
+}
+
+// Rollback undoes everything the given run did to the repository in dir.
+// By default the run's commits are dropped: the starting branch is checked out again when the run
+// worked on a branch of its own, or else the branch is hard reset to the commit recorded when the
+// run started. It refuses to when the working tree has uncommitted changes, another branch than
+// the run's is checked out or commits were made on the run's branch after it.
+func Rollback(ctx context.Context, dir string, state *RunState, opts RollbackOptions) error {
+	if state.StartSHA == "" {
+		return fmt.Errorf("run %s has no recorded starting commit", state.ID)
+	}
+	if state.Status == RunStatusRolledBack {
+		return fmt.Errorf("run %s has already been rolled back", state.ID)
+	}
+	if opts.Revert && opts.DeleteBranch {
+		return errors.New("cannot both revert and delete the run branch")
+	}
+
+	separateBranch := state.Branch != "" && state.Branch != state.StartBranch
+	if err := checkRollback(ctx, dir, state, separateBranch); err != nil {
+		return err
+	}
+
+	if opts.Revert {
+		if err := revertRun(ctx, dir, state, separateBranch); err != nil {
+			return err
+		}
+	} else {
+		if err := resetRun(ctx, dir, state, separateBranch, opts.DeleteBranch); err != nil {
+			return err
+		}
+	}
+
+	return state.finish(dir, RunStatusRolledBack)
+}
+
+// checkRollback refuses to roll back a run when that would lose work it did not do: uncommitted
+// changes, a checkout of another branch than the run's, or commits made after the run on its
+// branch.
+func checkRollback(ctx context.Context, dir string, state *RunState, separateBranch bool) error {
+	changes, err := UncommittedChanges(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if len(changes) > 0 {
+		return errors.New("the working tree has uncommitted changes: commit or stash them before rolling back")
+	}
+
+	branch, err := currentBranch(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if branch != state.Branch && (!separateBranch || branch != state.StartBranch) {
+		return fmt.Errorf("run %s worked on %s, check it out before rolling back", state.ID, branchName(state.Branch))
+	}
+
+	if state.EndSHA == "" {
+		return fmt.Errorf("run %s recorded no final commit, so the commits made after it cannot be told apart", state.ID)
+	}
+	tip, err := git(ctx, dir, "rev-parse", "HEAD")
+	if separateBranch {
+		tip, err = git(ctx, dir, "rev-parse", "refs/heads/"+state.Branch)
+	}
+	if err != nil {
+		return err
+	}
+	if tip != state.EndSHA {
+		return fmt.Errorf("%s has commits made after run %s, which rolling back would lose", branchName(state.Branch), state.ID)
+	}
+	return nil
+}
+
+// branchName names branch in messages, where an empty one is a detached HEAD.
+func branchName(branch string) string {
+	if branch == "" {
+		return "a detached HEAD"
+	}
+	return "branch " + branch
+}
+
+func resetRun(ctx context.Context, dir string, state *RunState, separateBranch bool, deleteBranch bool) error {
+	if separateBranch {
+		// The commits of the run are on its own branch: returning to where it started is enough,
+		// and leaves the starting branch as it is now. A detached start is restored by commit.
+		start := state.StartBranch
+		if start == "" {
+			start = state.StartSHA
+		}
+		if _, err := git(ctx, dir, "checkout", "--quiet", start); err != nil {
+			return fmt.Errorf("failed to check out starting branch: %w", err)
+		}
+		if deleteBranch {
+			if _, err := git(ctx, dir, "branch", "-D", state.Branch); err != nil {
+				return fmt.Errorf("failed to delete branch %s: %w", state.Branch, err)
+			}
+		}
+		return nil
+	}
+
+	if _, err := git(ctx, dir, "reset", "--hard", state.StartSHA); err != nil {
+		return fmt.Errorf("failed to reset to starting commit: %w", err)
+	}
+	return nil
+}
+
+func revertRun(ctx context.Context, dir string, state *RunState, separateBranch bool) error {
+	if separateBranch {
+		if _, err := git(ctx, dir, "checkout", "--quiet", state.Branch); err != nil {
+			return fmt.Errorf("failed to check out run branch: %w", err)
+		}
+	}
+
+	if state.EndSHA == state.StartSHA {
+		return nil
+	}
+	if _, err := git(ctx, dir, "revert", "--no-edit", state.StartSHA+".."+state.EndSHA); err != nil {
+		return fmt.Errorf("failed to revert run commits: %w", err)
+	}
+	return nil
+}
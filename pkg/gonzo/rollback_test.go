@@ -0,0 +1,229 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// initGitRepo creates a git repository in a temp directory with a single commit.
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("Skipping test - git is not available")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	runGit(t, dir, "config", "user.name", "Test")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	writeAndCommit(t, dir, "README.md", "initial\n", "initial commit")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := git(context.Background(), dir, args...)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return out
+}
+
+func writeAndCommit(t *testing.T, dir string, name string, content string, msg string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "-q", "-m", msg)
+}
+
+// simulateRun records a run starting at the current HEAD, then creates a branch with one commit,
+// the way the agent would.
+func simulateRun(t *testing.T, dir string) *RunState {
+	t.Helper()
+	ctx := context.Background()
+	cc := New()
+
+	run, err := cc.startRun(ctx, dir, "feature")
+	if err != nil {
+		t.Fatalf("startRun() returned error: %v", err)
+	}
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature-branch")
+	writeAndCommit(t, dir, "feature.txt", "feature\n", "add feature")
+
+	cc.finishRun(ctx, dir, run, RunStatusCompleted)
+	return run
+}
+
+func TestRollback_Reset(t *testing.T) {
+	dir := initGitRepo(t)
+	run := simulateRun(t, dir)
+
+	if run.Branch != "feature-branch" {
+		t.Fatalf("expected run branch to be recorded, got %q", run.Branch)
+	}
+
+	if err := Rollback(context.Background(), dir, run, RollbackOptions{DeleteBranch: true}); err != nil {
+		t.Fatalf("Rollback() returned error: %v", err)
+	}
+
+	if got := runGit(t, dir, "rev-parse", "HEAD"); got != run.StartSHA {
+		t.Errorf("expected HEAD %s, got %s", run.StartSHA, got)
+	}
+	if got := runGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); got != "main" {
+		t.Errorf("expected to be back on main, got %s", got)
+	}
+	if out := runGit(t, dir, "branch", "--list", "feature-branch"); out != "" {
+		t.Errorf("expected feature-branch to be deleted, got %q", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "feature.txt")); !os.IsNotExist(err) {
+		t.Error("expected the changes of the run to be gone")
+	}
+
+	loaded, err := LoadRunState(dir, run.ID)
+	if err != nil {
+		t.Fatalf("LoadRunState() returned error: %v", err)
+	}
+	if loaded.Status != RunStatusRolledBack {
+		t.Errorf("expected status %q, got %q", RunStatusRolledBack, loaded.Status)
+	}
+}
+
+func TestRollback_KeepsBranchByDefault(t *testing.T) {
+	dir := initGitRepo(t)
+	run := simulateRun(t, dir)
+
+	if err := Rollback(context.Background(), dir, run, RollbackOptions{}); err != nil {
+		t.Fatalf("Rollback() returned error: %v", err)
+	}
+
+	if out := runGit(t, dir, "branch", "--list", "feature-branch"); out == "" {
+		t.Error("expected feature-branch to be kept")
+	}
+}
+
+func TestRollback_Revert(t *testing.T) {
+	dir := initGitRepo(t)
+	run := simulateRun(t, dir)
+
+	if err := Rollback(context.Background(), dir, run, RollbackOptions{Revert: true}); err != nil {
+		t.Fatalf("Rollback() returned error: %v", err)
+	}
+
+	if got := runGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); got != "feature-branch" {
+		t.Errorf("expected to stay on feature-branch, got %s", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "feature.txt")); !os.IsNotExist(err) {
+		t.Error("expected feature.txt to be removed by the revert")
+	}
+	if out := runGit(t, dir, "diff", "--stat", run.StartSHA, "HEAD"); out != "" {
+		t.Errorf("expected no differences from the starting commit, got %q", out)
+	}
+}
+
+func TestRollback_Errors(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	if err := Rollback(ctx, dir, &RunState{ID: "x"}, RollbackOptions{}); err == nil {
+		t.Error("expected error when no starting commit was recorded")
+	}
+	if err := Rollback(ctx, dir, &RunState{ID: "x", StartSHA: "abc", Status: RunStatusRolledBack}, RollbackOptions{}); err == nil {
+		t.Error("expected error when the run was already rolled back")
+	}
+	if err := Rollback(ctx, dir, &RunState{ID: "x", StartSHA: "abc"}, RollbackOptions{Revert: true, DeleteBranch: true}); err == nil {
+		t.Error("expected error when combining revert and delete-branch")
+	}
+}
+
+func TestRollback_KeepsStartBranch(t *testing.T) {
+	dir := initGitRepo(t)
+	run := simulateRun(t, dir)
+
+	// Work on the starting branch after the run, e.g. merging the run branch
+	runGit(t, dir, "checkout", "-q", "main")
+	writeAndCommit(t, dir, "later.txt", "later\n", "later work")
+	head := runGit(t, dir, "rev-parse", "HEAD")
+
+	if err := Rollback(context.Background(), dir, run, RollbackOptions{DeleteBranch: true}); err != nil {
+		t.Fatalf("Rollback() returned error: %v", err)
+	}
+	if got := runGit(t, dir, "rev-parse", "HEAD"); got != head {
+		t.Errorf("expected main to be left at %s, got %s", head, got)
+	}
+	if out := runGit(t, dir, "branch", "--list", "feature-branch"); out != "" {
+		t.Errorf("expected feature-branch to be deleted, got %q", out)
+	}
+}
+
+func TestRollback_Refuses(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, dir string)
+		opts  RollbackOptions
+	}{
+		{"uncommitted changes", func(t *testing.T, dir string) {
+			if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("user work\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}, RollbackOptions{}},
+		{"untracked file", func(t *testing.T, dir string) {
+			if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("user notes\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}, RollbackOptions{Revert: true}},
+		{"other branch", func(t *testing.T, dir string) {
+			runGit(t, dir, "checkout", "-q", "-b", "other")
+		}, RollbackOptions{}},
+		{"later commits on the run branch", func(t *testing.T, dir string) {
+			writeAndCommit(t, dir, "later.txt", "later\n", "later work")
+		}, RollbackOptions{DeleteBranch: true}},
+		{"later commits reverted", func(t *testing.T, dir string) {
+			writeAndCommit(t, dir, "later.txt", "later\n", "later work")
+		}, RollbackOptions{Revert: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := initGitRepo(t)
+			run := simulateRun(t, dir)
+			tt.setup(t, dir)
+			head := runGit(t, dir, "rev-parse", "HEAD")
+			status := runGit(t, dir, "status", "--porcelain")
+
+			if err := Rollback(context.Background(), dir, run, tt.opts); err == nil {
+				t.Fatal("expected the rollback to be refused")
+			}
+			if got := runGit(t, dir, "rev-parse", "HEAD"); got != head {
+				t.Errorf("expected HEAD to be left at %s, got %s", head, got)
+			}
+			if got := runGit(t, dir, "status", "--porcelain"); got != status {
+				t.Errorf("expected the working tree to be left alone, got %q", got)
+			}
+		})
+	}
+}
+
+func TestRollback_SameBranchLaterCommits(t *testing.T) {
+	dir := initGitRepo(t)
+	ctx := context.Background()
+	cc := New()
+	run, err := cc.startRun(ctx, dir, "feature")
+	if err != nil {
+		t.Fatalf("startRun() returned error: %v", err)
+	}
+	writeAndCommit(t, dir, "feature.txt", "feature\n", "add feature")
+	cc.finishRun(ctx, dir, run, RunStatusCompleted)
+	writeAndCommit(t, dir, "later.txt", "later\n", "later work")
+
+	if err := Rollback(ctx, dir, run, RollbackOptions{}); err == nil {
+		t.Fatal("expected the rollback to be refused")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "later.txt")); err != nil {
+		t.Errorf("expected the later work to be kept: %v", err)
+	}
+}
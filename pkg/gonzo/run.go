@@ -0,0 +1,217 @@
+package gonzo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GonzoDir is the directory, relative to the repository root, where gonzo keeps its runtime files.
+const GonzoDir = ".gonzo"
+
+// RunsDir is the directory inside GonzoDir holding one subdirectory per run.
+const RunsDir = "runs"
+
+const runStateFile = "state.json"
+
+// RawOutputArtifact is the run artifact holding the unmodified output of the final iteration,
+// including control markers that are stripped from what gonzo prints.
+const RawOutputArtifact = "output.raw.txt"
+
+// runsGitignore keeps run state out of commits made by the agent; it ignores itself too.
+const runsGitignore = "*\n"
+
+// Run statuses recorded in RunState.Status.
+const (
+	RunStatusRunning    = "running"
+	RunStatusCompleted  = "completed"
+	RunStatusIncomplete = "incomplete"
+	RunStatusFailed     = "failed"
+	RunStatusRolledBack = "rolled-back"
+	RunStatusAborted    = "aborted"
+)
+
+// ErrNoRuns is returned when no recorded runs exist in a repository.
+var ErrNoRuns = errors.New("no recorded gonzo runs")
+
+// RunState is the persisted record of a single gonzo run, stored in .gonzo/runs/<id>/state.json.
+type RunState struct {
+	ID          string `json:"id"`
+	Feature     string `json:"feature"`
+	Model       string `json:"model"`
+	StartSHA    string `json:"start_sha,omitempty"`
+	StartBranch string `json:"start_branch,omitempty"`
+	EndSHA      string `json:"end_sha,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	PRURL       string `json:"pr_url,omitempty"`
+	Status      string `json:"status"`
+	// StopReason is why an incomplete run stopped: StopReasonMaxIterations, StopReasonMaxDuration
+	// or StopReasonMaxCost.
+	StopReason string  `json:"stop_reason,omitempty"`
+	Iterations int     `json:"iterations"`
+	PID        int     `json:"pid,omitempty"`
+	CostUSD    float64 `json:"cost_usd,omitempty"`
+	// InputTokens and OutputTokens are the tokens used by the run, as reported by the Claude CLI.
+	InputTokens  int `json:"input_tokens,omitempty"`
+	OutputTokens int `json:"output_tokens,omitempty"`
+	// SessionID is the Claude CLI session of the last iteration, to resume it with claude --resume.
+	SessionID  string     `json:"session_id,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// NewRunID returns a short, sortable, unique identifier for a run.
+func NewRunID() string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+// ShortRunID returns the random suffix of a run ID, enough to tell runs apart where the full ID
+// is too long, as in branch names.
+func ShortRunID(id string) string {
+	return id[strings.LastIndex(id, "-")+1:]
+}
+
+// RunDir returns the directory holding the state and artifacts of the run with the given ID.
+func RunDir(dir string, id string) string {
+	return filepath.Join(dir, GonzoDir, RunsDir, id)
+}
+
+// Save writes the run state to .gonzo/runs/<id>/state.json under dir.
+func (s *RunState) Save(dir string) error {
+	runDir := RunDir(dir, s.ID)
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		return fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	ignore := filepath.Join(dir, GonzoDir, RunsDir, ".gitignore")
+	if _, err := os.Stat(ignore); errors.Is(err, os.ErrNotExist) {
+		if err := os.WriteFile(ignore, []byte(runsGitignore), 0644); err != nil {
+			return fmt.Errorf("failed to write runs .gitignore: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run state: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(runDir, runStateFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write run state: %w", err)
+	}
+	return nil
+}
+
+// LoadRunState reads the state of the run with the given ID from dir.
+func LoadRunState(dir string, id string) (*RunState, error) {
+	data, err := os.ReadFile(filepath.Join(RunDir(dir, id), runStateFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("run %q not found", id)
+		}
+		return nil, fmt.Errorf("failed to read run state: %w", err)
+	}
+
+	var s RunState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode run state for %q: %w", id, err)
+	}
+	return &s, nil
+}
+
+// ResolveRunState reads the state of the run ref refers to: its full ID, the start of its ID or
+// its short ID, as long as a single recorded run matches.
+func ResolveRunState(dir string, ref string) (*RunState, error) {
+	if s, err := LoadRunState(dir, ref); err == nil || ref == "" {
+		return s, err
+	}
+
+	states, err := ListRunStates(dir)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	var match *RunState
+	for _, s := range states {
+		if strings.HasPrefix(s.ID, ref) || ShortRunID(s.ID) == ref {
+			matches = append(matches, s.ID)
+			match = s
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("run %q not found", ref)
+	case 1:
+		return match, nil
+	}
+	return nil, fmt.Errorf("run %q is ambiguous, it matches runs %s", ref, strings.Join(matches, ", "))
+}
+
+// ListRunStates returns all recorded runs in dir, oldest first.
+func ListRunStates(dir string) ([]*RunState, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, GonzoDir, RunsDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+
+	var states []*RunState
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		s, err := LoadRunState(dir, e.Name())
+		if err != nil {
+			// Directories without a readable state file are not runs
+			continue
+		}
+		states = append(states, s)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].StartedAt.Before(states[j].StartedAt)
+	})
+	return states, nil
+}
+
+// LatestRunState returns the most recently started run in dir.
+func LatestRunState(dir string) (*RunState, error) {
+	states, err := ListRunStates(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, ErrNoRuns
+	}
+	return states[len(states)-1], nil
+}
+
+// SaveArtifact writes a file into the run's directory.
+func (s *RunState) SaveArtifact(dir string, name string, data []byte) error {
+	runDir := RunDir(dir, s.ID)
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		return fmt.Errorf("failed to create run directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(runDir, name), data, 0644); err != nil {
+		return fmt.Errorf("failed to write run artifact %s: %w", name, err)
+	}
+	return nil
+}
+
+// finish marks the run as finished with the given status and persists it.
+func (s *RunState) finish(dir string, status string) error {
+	now := time.Now()
+	s.Status = status
+	s.FinishedAt = &now
+	return s.Save(dir)
+}
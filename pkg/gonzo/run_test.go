@@ -0,0 +1,131 @@
+package gonzo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunState_SaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	state := &RunState{
+		ID:          "20260101-120000-abcd",
+		Feature:     "add a login button",
+		Model:       ClaudeSonnet,
+		StartSHA:    "0123456789abcdef",
+		StartBranch: "main",
+		Status:      RunStatusRunning,
+		StartedAt:   time.Now().Truncate(time.Second),
+	}
+	if err := state.Save(dir); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+
+	loaded, err := LoadRunState(dir, state.ID)
+	if err != nil {
+		t.Fatalf("LoadRunState() returned error: %v", err)
+	}
+	if loaded.StartSHA != state.StartSHA {
+		t.Errorf("expected StartSHA %q, got %q", state.StartSHA, loaded.StartSHA)
+	}
+	if loaded.StartBranch != state.StartBranch {
+		t.Errorf("expected StartBranch %q, got %q", state.StartBranch, loaded.StartBranch)
+	}
+	if !loaded.StartedAt.Equal(state.StartedAt) {
+		t.Errorf("expected StartedAt %v, got %v", state.StartedAt, loaded.StartedAt)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, GonzoDir, RunsDir, ".gitignore")); err != nil {
+		t.Errorf("expected runs directory to be git-ignored: %v", err)
+	}
+}
+
+func TestLoadRunState_NotFound(t *testing.T) {
+	if _, err := LoadRunState(t.TempDir(), "missing"); err == nil {
+		t.Error("expected error for unknown run ID")
+	}
+}
+
+func TestLatestRunState(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := LatestRunState(dir); !errors.Is(err, ErrNoRuns) {
+		t.Fatalf("expected ErrNoRuns, got %v", err)
+	}
+
+	now := time.Now()
+	for _, s := range []*RunState{
+		{ID: "newest", StartedAt: now},
+		{ID: "oldest", StartedAt: now.Add(-2 * time.Hour)},
+		{ID: "middle", StartedAt: now.Add(-time.Hour)},
+	} {
+		if err := s.Save(dir); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+
+	latest, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("LatestRunState() returned error: %v", err)
+	}
+	if latest.ID != "newest" {
+		t.Errorf("expected latest run %q, got %q", "newest", latest.ID)
+	}
+}
+
+func TestResolveRunState(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for _, s := range []*RunState{
+		{ID: "20260201-202613-a1b2c3", StartedAt: now.Add(-time.Hour)},
+		{ID: "20260201-211500-d4e5f6", StartedAt: now},
+	} {
+		if err := s.Save(dir); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+
+	for _, tt := range []struct {
+		ref, want string
+	}{
+		{"20260201-202613-a1b2c3", "20260201-202613-a1b2c3"},
+		{"20260201-2115", "20260201-211500-d4e5f6"},
+		{"a1b2c3", "20260201-202613-a1b2c3"},
+	} {
+		s, err := ResolveRunState(dir, tt.ref)
+		if err != nil {
+			t.Fatalf("ResolveRunState(%q) returned error: %v", tt.ref, err)
+		}
+		if s.ID != tt.want {
+			t.Errorf("ResolveRunState(%q) = %q, want %q", tt.ref, s.ID, tt.want)
+		}
+	}
+
+	if _, err := ResolveRunState(dir, "20260201"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected an ambiguous prefix to be rejected, got %v", err)
+	}
+	if _, err := ResolveRunState(dir, "ffffff"); err == nil {
+		t.Error("expected error for unknown run ID")
+	}
+}
+
+func TestShortRunID(t *testing.T) {
+	if got := ShortRunID("20260201-202613-a1b2c3"); got != "a1b2c3" {
+		t.Errorf("ShortRunID() = %q, want %q", got, "a1b2c3")
+	}
+}
+
+func TestNewRunID_Unique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		id := NewRunID()
+		if seen[id] {
+			t.Fatalf("duplicate run ID %q", id)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,95 @@
+package gonzo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LogArtifact is the run artifact holding the run's log, one JSON LogEntry per line.
+const LogArtifact = "log.jsonl"
+
+// Log levels recorded in LogEntry.Level.
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// LogEntry is a line of a run's log.
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"msg"`
+}
+
+// String formats the entry for display.
+func (e LogEntry) String() string {
+	if e.Time.IsZero() {
+		return e.Message
+	}
+	return e.Time.Format("15:04:05") + " " + e.Message
+}
+
+// openRunLog opens the run's log for appending.
+func openRunLog(dir string, run *RunState) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(RunDir(dir, run.ID), LogArtifact), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run log: %w", err)
+	}
+	return f, nil
+}
+
+// writeLogEntry appends an entry to a run's log.
+func writeLogEntry(w io.Writer, level string, msg string) error {
+	data, err := json.Marshal(LogEntry{Time: time.Now(), Level: level, Message: msg})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadRunLog returns the entries of a run's log written after offset, in bytes, and the offset
+// to read the next entries from. A line still being written is left for the next read, so the
+// log can be followed while the run is in progress. A run without a log has no entries.
+func ReadRunLog(dir string, id string, offset int64) ([]LogEntry, int64, error) {
+	f, err := os.Open(filepath.Join(RunDir(dir, id), LogArtifact))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, offset, nil
+		}
+		return nil, offset, fmt.Errorf("failed to open run log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, fmt.Errorf("failed to read run log: %w", err)
+	}
+
+	var entries []LogEntry
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			// EOF, possibly in the middle of a line
+			break
+		}
+		offset += int64(len(line))
+
+		var e LogEntry
+		if err := json.Unmarshal(bytes.TrimSpace(line), &e); err != nil {
+			// Not written by gonzo; keep it as it is
+			e = LogEntry{Message: string(bytes.TrimSpace(line))}
+		}
+		entries = append(entries, e)
+	}
+	return entries, offset, nil
+}
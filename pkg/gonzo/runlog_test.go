@@ -0,0 +1,81 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadRunLog(t *testing.T) {
+	dir := t.TempDir()
+	run := &RunState{ID: "20260101-000000-aaaaaa"}
+	if err := run.Save(dir); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+
+	if entries, offset, err := ReadRunLog(dir, run.ID, 0); err != nil || len(entries) != 0 || offset != 0 {
+		t.Errorf("expected no entries without a log, got %v, %d, %v", entries, offset, err)
+	}
+
+	f, err := openRunLog(dir, run)
+	if err != nil {
+		t.Fatalf("openRunLog() returned error: %v", err)
+	}
+	defer f.Close()
+	if err := writeLogEntry(f, LogLevelInfo, "first"); err != nil {
+		t.Fatalf("writeLogEntry() returned error: %v", err)
+	}
+	// A line still being written
+	if _, err := f.WriteString(`{"msg":"sec`); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	entries, offset, err := ReadRunLog(dir, run.ID, 0)
+	if err != nil {
+		t.Fatalf("ReadRunLog() returned error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Message != "first" || entries[0].Level != LogLevelInfo {
+		t.Errorf("expected the complete entry only, got %+v", entries)
+	}
+
+	if _, err := f.WriteString("ond\"}\nnot json\n"); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	entries, _, err = ReadRunLog(dir, run.ID, offset)
+	if err != nil {
+		t.Fatalf("ReadRunLog() returned error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Message != "second" || entries[1].String() != "not json" {
+		t.Errorf("expected the entries written since, got %+v", entries)
+	}
+}
+
+func TestGenerate_RunLog(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("Done "+DefaultCompletionSignal, 0)
+
+	// The log is written in quiet mode too
+	if _, err := New(WithDir(dir)).WithQuiet(true).Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("LatestRunState() returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(RunDir(dir, run.ID), LogArtifact))
+	if err != nil {
+		t.Fatalf("failed to read run log: %v", err)
+	}
+	for _, want := range []string{"Starting Gonzo", "Run ID: " + run.ID, "Iteration 1 of", "Run " + run.ID + " completed"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in the run log, got %s", want, data)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Sandboxes the gonzo command can run a loop in.
+const (
+	// SandboxNone runs the loop on the host.
+	SandboxNone = "none"
+	// SandboxDocker runs the loop in a Docker container, with the repository mounted.
+	SandboxDocker = "docker"
+)
+
+// DefaultSandboxImage is the image of the Docker sandbox, built from the Dockerfile in the
+// sandbox directory of the gonzo repository.
+const DefaultSandboxImage = "gonzo-sandbox"
+
+// Paths of the Docker sandbox, inside the container.
+const (
+	// SandboxWorkdir is where the repository is mounted.
+	SandboxWorkdir = "/workspace"
+	// SandboxGonzo is where the gonzo binary of the host is mounted, in place of the image's.
+	SandboxGonzo = "/usr/local/bin/gonzo"
+	// SandboxConfig is where the resolved configuration of the run is mounted.
+	SandboxConfig = "/etc/gonzo/gonzo.yaml"
+	// sandboxHome is the home directory of the user of the container, which has none.
+	sandboxHome = "/tmp"
+)
+
+// sandboxReadOnly are the files of the git directory of the repository mounted read-only in the
+// Docker sandbox: the ones whose code or settings git runs on the host afterwards, such as hooks,
+// core.hooksPath or core.fsmonitor.
+var sandboxReadOnly = []string{"hooks", "config"}
+
+// SandboxEnv lists the variables of the host's environment passed to the Docker sandbox: the
+// credentials of the Claude CLI. Nothing else of the host's environment gets in.
+var SandboxEnv = []string{"ANTHROPIC_API_KEY", "CLAUDE_CODE_OAUTH_TOKEN"}
+
+// DockerSandbox runs gonzo in a Docker container holding only the repository, the configuration
+// of the run and the credentials of the Claude CLI: neither the home directory, the SSH agent,
+// the git credentials, the forge tokens nor the Docker socket of the host are reachable from it.
+// The hooks and config of the git directory are read-only, so that the container cannot plant
+// code the git commands of the host would run.
+type DockerSandbox struct {
+	// Image is the image of the container, which has the Claude CLI, git and the tools of the
+	// repository. DefaultSandboxImage when empty.
+	Image string
+	// Dir is the repository, mounted at SandboxWorkdir.
+	Dir string
+	// Gonzo is the gonzo binary mounted at SandboxGonzo, when not empty. It has to be built for
+	// Linux; the image's gonzo runs otherwise.
+	Gonzo string
+	// Config is the configuration file mounted at SandboxConfig, when not empty.
+	Config string
+	// Env lists NAME=value variables set in the container, e.g. the git identity of the commits.
+	// Their values are handed to docker through its environment, not its command line.
+	Env []string
+	// TTY allocates a terminal for the container, when gonzo runs in one.
+	TTY bool
+}
+
+// Command returns the docker command running gonzo with args in the sandbox.
+func (s DockerSandbox) Command(ctx context.Context, args []string) (*exec.Cmd, error) {
+	if s.Dir == "" {
+		return nil, errors.New("the docker sandbox needs the repository to mount")
+	}
+	image := s.Image
+	if image == "" {
+		image = DefaultSandboxImage
+	}
+
+	dockerArgs := []string{"run", "--rm", "--interactive", "--init",
+		"--volume", s.Dir + ":" + SandboxWorkdir,
+		"--workdir", SandboxWorkdir,
+		"--env", "HOME=" + sandboxHome,
+	}
+	gitDir := filepath.Join(s.Dir, ".git")
+	if info, err := os.Stat(gitDir); err == nil && info.IsDir() {
+		// Mounted even when missing, so that the container cannot create them
+		if err := os.MkdirAll(filepath.Join(gitDir, "hooks"), 0755); err != nil {
+			return nil, fmt.Errorf("failed to protect the git hooks: %w", err)
+		}
+		for _, name := range sandboxReadOnly {
+			if _, err := os.Stat(filepath.Join(gitDir, name)); err == nil {
+				dockerArgs = append(dockerArgs, "--volume", filepath.Join(gitDir, name)+":"+SandboxWorkdir+"/.git/"+name+":ro")
+			}
+		}
+	}
+	if s.TTY {
+		dockerArgs = append(dockerArgs, "--tty")
+	}
+	// Files written to the repository belong to the user of the host, not root
+	if uid, gid := os.Getuid(), os.Getgid(); uid > 0 {
+		dockerArgs = append(dockerArgs, "--user", strconv.Itoa(uid)+":"+strconv.Itoa(gid))
+	}
+	if s.Gonzo != "" {
+		dockerArgs = append(dockerArgs, "--volume", s.Gonzo+":"+SandboxGonzo+":ro")
+	}
+	if s.Config != "" {
+		dockerArgs = append(dockerArgs, "--volume", s.Config+":"+SandboxConfig+":ro")
+	}
+
+	env := os.Environ()
+	for _, name := range SandboxEnv {
+		if os.Getenv(name) != "" {
+			dockerArgs = append(dockerArgs, "--env", name)
+		}
+	}
+	for _, kv := range s.Env {
+		name, _, ok := strings.Cut(kv, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid sandbox variable %q: expected NAME=value", kv)
+		}
+		dockerArgs = append(dockerArgs, "--env", name)
+		env = append(env, kv)
+	}
+
+	dockerArgs = append(dockerArgs, image, "gonzo")
+	if s.Config != "" {
+		dockerArgs = append(dockerArgs, "--config", SandboxConfig)
+	}
+	cmd := exec.CommandContext(ctx, "docker", append(dockerArgs, args...)...)
+	cmd.Env = env
+	return cmd, nil
+}
@@ -0,0 +1,91 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDockerSandbox_Command(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "sk-secret")
+	t.Setenv("CLAUDE_CODE_OAUTH_TOKEN", "")
+	t.Setenv("GH_TOKEN", "gh-secret")
+	sandbox := DockerSandbox{
+		Dir:    "/src/app",
+		Gonzo:  "/usr/bin/gonzo",
+		Config: "/tmp/gonzo.yaml",
+		Env:    []string{"GIT_AUTHOR_NAME=Jane Doe"},
+	}
+
+	cmd, err := sandbox.Command(context.Background(), []string{"--", "add a login button"})
+	if err != nil {
+		t.Fatalf("Command() returned error: %v", err)
+	}
+	args := strings.Join(cmd.Args, " ")
+	for _, want := range []string{
+		"docker run --rm --interactive --init",
+		"--volume /src/app:" + SandboxWorkdir + " --workdir " + SandboxWorkdir,
+		"--volume /usr/bin/gonzo:" + SandboxGonzo + ":ro",
+		"--volume /tmp/gonzo.yaml:" + SandboxConfig + ":ro",
+		"--env ANTHROPIC_API_KEY --env GIT_AUTHOR_NAME",
+		DefaultSandboxImage + " gonzo --config " + SandboxConfig + " -- add a login button",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("expected %q in the docker command, got %q", want, args)
+		}
+	}
+	// Values stay off the command line, and nothing else of the environment gets in
+	for _, unwanted := range []string{"sk-secret", "Jane Doe", "GH_TOKEN", "CLAUDE_CODE_OAUTH_TOKEN", "--tty"} {
+		if strings.Contains(args, unwanted) {
+			t.Errorf("expected no %q in the docker command, got %q", unwanted, args)
+		}
+	}
+	if !slices.Contains(cmd.Env, "GIT_AUTHOR_NAME=Jane Doe") {
+		t.Error("expected the variables of the sandbox in the environment of docker")
+	}
+}
+
+func TestDockerSandbox_CommandErrors(t *testing.T) {
+	if _, err := (DockerSandbox{}).Command(context.Background(), nil); err == nil {
+		t.Error("expected an error without a repository")
+	}
+	if _, err := (DockerSandbox{Dir: "/src/app", Env: []string{"NOVALUE"}}).Command(context.Background(), nil); err == nil {
+		t.Error("expected an error for a variable without a value")
+	}
+
+	cmd, err := (DockerSandbox{Dir: "/src/app", Image: "golang:1.25", TTY: true}).Command(context.Background(), []string{"fix it"})
+	if err != nil {
+		t.Fatalf("Command() returned error: %v", err)
+	}
+	if args := strings.Join(cmd.Args, " "); !strings.Contains(args, "--tty") || !strings.HasSuffix(args, "golang:1.25 gonzo fix it") {
+		t.Errorf("unexpected docker command %q", args)
+	}
+}
+
+func TestDockerSandbox_CommandGitReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".git", "config"), []byte("[core]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, err := (DockerSandbox{Dir: dir}).Command(context.Background(), []string{"fix it"})
+	if err != nil {
+		t.Fatalf("Command() returned error: %v", err)
+	}
+	args := strings.Join(cmd.Args, " ")
+	for _, name := range []string{"hooks", "config"} {
+		want := "--volume " + filepath.Join(dir, ".git", name) + ":" + SandboxWorkdir + "/.git/" + name + ":ro"
+		if !strings.Contains(args, want) {
+			t.Errorf("expected %q in the docker command, got %q", want, args)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dir, ".git", "hooks")); err != nil || !info.IsDir() {
+		t.Errorf("expected the missing hooks directory to be created, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package gonzo
+
+import (
+	"context"
+	"github.com/andybarilla/gonzo/pkg/forge"
+	"os"
+	"sort"
+)
+
+// Secrets gonzo reads from the system keyring, besides the forge tokens stored under the
+// forge's name (forge.GitHub, forge.GitLab).
+const (
+	// SecretAnthropicAPIKey is the API key handed to the Claude CLI as ANTHROPIC_API_KEY.
+	SecretAnthropicAPIKey = "anthropic-api-key"
+	// SecretWebhookURL is the URL run notifications are posted to.
+	SecretWebhookURL = "webhook-url"
+	// SecretSMTPPassword is the password of the SMTP server notification emails are sent through.
+	SecretSMTPPassword = "smtp-password"
+)
+
+// SecretEnv lists, per secret, the environment variables it is read from when the keyring
+// holds none, in order of precedence.
+var SecretEnv = map[string][]string{
+	forge.GitHub:          forge.TokenEnv[forge.GitHub],
+	forge.GitLab:          forge.TokenEnv[forge.GitLab],
+	SecretAnthropicAPIKey: {"ANTHROPIC_API_KEY"},
+	SecretWebhookURL:      {"GONZO_WEBHOOK_URL"},
+	SecretSMTPPassword:    {"GONZO_SMTP_PASSWORD"},
+}
+
+// Secrets returns the names of the secrets gonzo knows about, sorted.
+func Secrets() []string {
+	names := make([]string, 0, len(SecretEnv))
+	for name := range SecretEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsSecret returns whether name is one of the secrets gonzo knows about.
+func IsSecret(name string) bool {
+	_, ok := SecretEnv[name]
+	return ok
+}
+
+// Secret returns the named secret and where it came from: the system keyring, where
+// `gonzo secrets set` stores it, or one of its environment variables. Both are empty when
+// the secret is not set anywhere.
+func Secret(ctx context.Context, name string) (value string, source string) {
+	if value, err := lookupKeyring(ctx, name); err == nil {
+		return value, TokenSourceKeyring
+	}
+	for _, env := range SecretEnv[name] {
+		if value := os.Getenv(env); value != "" {
+			return value, env
+		}
+	}
+	return "", ""
+}
+
+// claudeEnv returns the variables added to the environment of the Claude CLI: the API key
+// from the keyring, when the environment does not set one. The keyring is read once.
+func (cc *ClaudeConfig) claudeEnv(ctx context.Context) []string {
+	if !cc.claudeEnvResolved {
+		cc.claudeEnvResolved = true
+		if os.Getenv("ANTHROPIC_API_KEY") == "" {
+			if key, err := lookupKeyring(ctx, SecretAnthropicAPIKey); err == nil {
+				cc.claudeEnvVars = []string{"ANTHROPIC_API_KEY=" + key}
+			}
+		}
+	}
+	return cc.claudeEnvVars
+}
@@ -0,0 +1,65 @@
+package gonzo
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	t.Setenv("GONZO_WEBHOOK_URL", "https://hooks.example.com/env")
+	t.Setenv("ANTHROPIC_API_KEY", "from-env")
+
+	withKeyring(t, map[string]string{SecretAnthropicAPIKey: "from-keyring"})
+
+	if value, source := Secret(context.Background(), SecretAnthropicAPIKey); value != "from-keyring" || source != TokenSourceKeyring {
+		t.Errorf("expected the keyring to take precedence, got %q from %q", value, source)
+	}
+	if value, source := Secret(context.Background(), SecretWebhookURL); value != "https://hooks.example.com/env" || source != "GONZO_WEBHOOK_URL" {
+		t.Errorf("expected the environment fallback, got %q from %q", value, source)
+	}
+	if value, source := Secret(context.Background(), "unknown"); value != "" || source != "" {
+		t.Errorf("expected no value for an unknown secret, got %q from %q", value, source)
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	names := Secrets()
+	if !slices.IsSorted(names) || !slices.Contains(names, SecretAnthropicAPIKey) || !slices.Contains(names, "github") {
+		t.Errorf("unexpected secrets %v", names)
+	}
+	if !IsSecret(SecretWebhookURL) || IsSecret("password") {
+		t.Error("expected IsSecret to report the known secrets only")
+	}
+}
+
+func TestExecClaudeCLI_APIKeyFromKeyring(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	var cmd *exec.Cmd
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		cmd = mockCommandContext("ok", 0)(ctx, name, args...)
+		return cmd
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	withKeyring(t, map[string]string{SecretAnthropicAPIKey: "sk-keyring"})
+
+	cc := New()
+	if _, _, err := cc.callClaudeCLI(context.Background(), "system", "prompt", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Contains(cmd.Env, "ANTHROPIC_API_KEY=sk-keyring") {
+		t.Errorf("expected the API key from the keyring in the environment, got %v", cmd.Env)
+	}
+
+	// The environment takes precedence over the keyring
+	t.Setenv("ANTHROPIC_API_KEY", "sk-env")
+	cc = New()
+	if vars := cc.claudeEnv(context.Background()); len(vars) != 0 {
+		t.Errorf("expected nothing to be added when ANTHROPIC_API_KEY is set, got %v", vars)
+	}
+}
@@ -0,0 +1,112 @@
+package gonzo
+
+import (
+	"sort"
+	"time"
+)
+
+// StatsOverall is the Stats.Model value of the statistics over all models.
+const StatsOverall = "all"
+
+// Stats aggregates the outcome of finished runs. Runs still in progress are left out.
+type Stats struct {
+	Model       string  `json:"model"`
+	Runs        int     `json:"runs"`
+	Completed   int     `json:"completed"`
+	Incomplete  int     `json:"incomplete"`
+	Failed      int     `json:"failed"`
+	Aborted     int     `json:"aborted"`
+	RolledBack  int     `json:"rolled_back"`
+	SuccessRate float64 `json:"success_rate"`
+
+	// AvgIterationsToCompletion is the average number of iterations of the completed runs.
+	AvgIterationsToCompletion float64 `json:"avg_iterations_to_completion"`
+	AvgDurationSeconds        float64 `json:"avg_duration_seconds"`
+
+	// CostedRuns is the number of runs that recorded their cost, which TotalCost and AvgCost cover.
+	CostedRuns int     `json:"costed_runs"`
+	TotalCost  float64 `json:"total_cost_usd"`
+	AvgCost    float64 `json:"avg_cost_usd"`
+}
+
+// ComputeStats returns the statistics over all finished runs, followed by the statistics
+// of every model, sorted by name.
+func ComputeStats(states []*RunState) []Stats {
+	overall := &statsAccumulator{Stats: Stats{Model: StatsOverall}}
+	byModel := map[string]*statsAccumulator{}
+
+	for _, s := range states {
+		if s.Status == RunStatusRunning {
+			continue
+		}
+		overall.add(s)
+		m, ok := byModel[s.Model]
+		if !ok {
+			m = &statsAccumulator{Stats: Stats{Model: s.Model}}
+			byModel[s.Model] = m
+		}
+		m.add(s)
+	}
+
+	result := []Stats{overall.result()}
+	models := make([]string, 0, len(byModel))
+	for model := range byModel {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		result = append(result, byModel[model].result())
+	}
+	return result
+}
+
+// statsAccumulator sums up runs into Stats.
+type statsAccumulator struct {
+	Stats
+	completedIterations int
+	totalDuration       time.Duration
+	timedRuns           int
+}
+
+func (a *statsAccumulator) add(s *RunState) {
+	a.Runs++
+	switch s.Status {
+	case RunStatusCompleted:
+		a.Completed++
+		a.completedIterations += s.Iterations
+	case RunStatusIncomplete:
+		a.Incomplete++
+	case RunStatusFailed:
+		a.Failed++
+	case RunStatusAborted:
+		a.Aborted++
+	case RunStatusRolledBack:
+		a.RolledBack++
+	}
+
+	if s.FinishedAt != nil {
+		a.totalDuration += s.FinishedAt.Sub(s.StartedAt)
+		a.timedRuns++
+	}
+	if s.CostUSD > 0 {
+		a.CostedRuns++
+		a.TotalCost += s.CostUSD
+	}
+}
+
+func (a *statsAccumulator) result() Stats {
+	s := a.Stats
+	if s.Runs > 0 {
+		s.SuccessRate = float64(s.Completed) / float64(s.Runs)
+	}
+	if s.Completed > 0 {
+		s.AvgIterationsToCompletion = float64(a.completedIterations) / float64(s.Completed)
+	}
+	if a.timedRuns > 0 {
+		s.AvgDurationSeconds = (a.totalDuration / time.Duration(a.timedRuns)).Seconds()
+	}
+	if s.CostedRuns > 0 {
+		s.AvgCost = s.TotalCost / float64(s.CostedRuns)
+	}
+	return s
+}
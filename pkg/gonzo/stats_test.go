@@ -0,0 +1,44 @@
+package gonzo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeStats(t *testing.T) {
+	start := time.Now()
+	finished := func(d time.Duration) *time.Time {
+		end := start.Add(d)
+		return &end
+	}
+	states := []*RunState{
+		{Model: ClaudeOpus, Status: RunStatusCompleted, Iterations: 2, StartedAt: start, FinishedAt: finished(2 * time.Minute), CostUSD: 1.5},
+		{Model: ClaudeOpus, Status: RunStatusCompleted, Iterations: 4, StartedAt: start, FinishedAt: finished(4 * time.Minute)},
+		{Model: ClaudeOpus, Status: RunStatusIncomplete, Iterations: 10, StartedAt: start, FinishedAt: finished(6 * time.Minute), CostUSD: 4.5},
+		{Model: ClaudeHaiku, Status: RunStatusFailed, Iterations: 1, StartedAt: start, FinishedAt: finished(time.Minute)},
+		{Model: ClaudeHaiku, Status: RunStatusRunning, Iterations: 1, StartedAt: start},
+	}
+
+	stats := ComputeStats(states)
+	if len(stats) != 3 || stats[0].Model != StatsOverall || stats[1].Model != ClaudeHaiku || stats[2].Model != ClaudeOpus {
+		t.Fatalf("expected overall, haiku and opus stats, got %+v", stats)
+	}
+
+	all := stats[0]
+	if all.Runs != 4 || all.Completed != 2 || all.Incomplete != 1 || all.Failed != 1 {
+		t.Errorf("unexpected counts %+v", all)
+	}
+	if all.SuccessRate != 0.5 || all.AvgIterationsToCompletion != 3 {
+		t.Errorf("unexpected rates %+v", all)
+	}
+	if all.AvgDurationSeconds != 195 {
+		t.Errorf("expected an average of 3m15s, got %vs", all.AvgDurationSeconds)
+	}
+	if all.CostedRuns != 2 || all.TotalCost != 6 || all.AvgCost != 3 {
+		t.Errorf("unexpected costs %+v", all)
+	}
+
+	if opus := stats[2]; opus.Runs != 3 || opus.SuccessRate != 2.0/3.0 {
+		t.Errorf("unexpected opus stats %+v", opus)
+	}
+}
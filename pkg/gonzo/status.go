@@ -0,0 +1,195 @@
+package gonzo
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// statusInterval is how often the status line is redrawn.
+const statusInterval = 100 * time.Millisecond
+
+// spinnerFrames are drawn in turn at the start of the status line.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// clearLine moves the cursor back to the start of the line and erases it.
+const clearLine = "\r\033[K"
+
+// statusLine is a line kept at the bottom of the terminal during a run, showing a spinner, the
+// iteration in progress, the time elapsed and an estimate of the time left from the average
+// duration of the iterations so far. What is written through it is printed above it.
+type statusLine struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+
+	start          time.Time
+	iteration      int
+	maxIterations  int
+	iterationStart time.Time
+	// finished and total are the number of iterations finished and how long they took
+	finished int
+	total    time.Duration
+
+	frame int
+	// drawn is whether the status line is on screen, midLine whether a line written through
+	// it is unfinished, in which case the status line waits for it to end
+	drawn   bool
+	midLine bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newStatusLine returns a status line drawn on w, not yet animated.
+func newStatusLine(w io.Writer, now func() time.Time) *statusLine {
+	return &statusLine{w: w, now: now, start: now()}
+}
+
+// animate redraws the status line every interval, advancing its spinner, until Close.
+func (s *statusLine) animate(interval time.Duration) {
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				s.frame = (s.frame + 1) % len(spinnerFrames)
+				s.draw()
+				s.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// startIteration shows an iteration as in progress.
+func (s *statusLine) startIteration(iteration int, maxIterations int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.iteration, s.maxIterations = iteration, maxIterations
+	s.iterationStart = s.now()
+	s.draw()
+}
+
+// endIteration counts the iteration in progress in the estimate of the time left.
+func (s *statusLine) endIteration() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finished++
+	s.total += s.now().Sub(s.iterationStart)
+}
+
+// render returns the text of the status line.
+func (s *statusLine) render() string {
+	now := s.now()
+	line := fmt.Sprintf("%s Iteration %d/%d · %s elapsed", spinnerFrames[s.frame], s.iteration, s.maxIterations,
+		now.Sub(s.start).Round(time.Second))
+	if s.finished > 0 {
+		average := s.total / time.Duration(s.finished)
+		left := average*time.Duration(s.maxIterations-s.iteration+1) - now.Sub(s.iterationStart)
+		line += fmt.Sprintf(" · ~%s left", max(left, 0).Round(time.Second))
+	}
+	return line
+}
+
+// draw replaces the status line on screen, unless a line written through it is unfinished.
+func (s *statusLine) draw() {
+	if s.midLine || s.iteration == 0 {
+		return
+	}
+	_, _ = io.WriteString(s.w, clearLine+s.render())
+	s.drawn = true
+}
+
+// Write prints p above the status line.
+func (s *statusLine) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if s.drawn && !s.midLine {
+		if _, err := io.WriteString(s.w, clearLine); err != nil {
+			return 0, err
+		}
+		s.drawn = false
+	}
+	n, err := s.w.Write(p)
+	s.midLine = p[len(p)-1] != '\n'
+	s.draw()
+	return n, err
+}
+
+// Close stops the animation and erases the status line.
+func (s *statusLine) Close() error {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.drawn && !s.midLine {
+		s.drawn = false
+		_, err := io.WriteString(s.w, clearLine)
+		return err
+	}
+	return nil
+}
+
+// startStatusLine shows the status line of the run, when progress is printed to a terminal
+// as text.
+func (cc *ClaudeConfig) startStatusLine() {
+	f, ok := cc.logWriter().(*os.File)
+	if cc.quiet || cc.output == OutputJSON || cc.logging.Format == LogFormatJSON || cc.log != nil || !ok || !isTerminal(f) {
+		return
+	}
+	cc.status = newStatusLine(f, time.Now)
+	cc.status.animate(statusInterval)
+}
+
+// stopStatusLine erases the status line of the run, if shown.
+func (cc *ClaudeConfig) stopStatusLine() {
+	if cc.status != nil {
+		err := cc.status.Close()
+		cc.status = nil
+		cc.swallow(err)
+	}
+}
+
+// console returns where messages about the run are printed: above the status line when it is
+// shown, else the log writer.
+func (cc *ClaudeConfig) console() io.Writer {
+	if cc.status != nil {
+		return cc.status
+	}
+	return cc.logWriter()
+}
+
+// logIterationStart shows the start of an iteration on the status line when shown, and
+// otherwise prints a banner with the time it started.
+func (cc *ClaudeConfig) logIterationStart(iteration int, maxIterations int, wrapUp bool, start time.Time) {
+	title := fmt.Sprintf("Iteration %d of %d", iteration, maxIterations)
+	if wrapUp {
+		title += " (wrap-up)"
+	}
+	title += ", started at " + start.Format(timeOfDay)
+	if cc.status == nil {
+		cc.logStyled(styleBanner, "===============================================================")
+		cc.logStyled(styleBanner, "  %s", title)
+		cc.logStyled(styleBanner, "===============================================================")
+		return
+	}
+	cc.status.startIteration(iteration, maxIterations)
+	// The status line replaces the banner on the console, the log files still record it
+	slog.New(cc.fileHandler()).Info("  " + title)
+	cc.emit(Event{Type: EventLog, Message: "  " + title})
+}
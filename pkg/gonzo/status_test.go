@@ -0,0 +1,78 @@
+package gonzo
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusLine(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var b bytes.Buffer
+	s := newStatusLine(&b, func() time.Time { return now })
+
+	// Nothing is drawn before the first iteration
+	if _, err := s.Write([]byte("Starting Gonzo\n")); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	s.startIteration(1, 4)
+	now = now.Add(2 * time.Minute)
+	s.endIteration()
+	s.startIteration(2, 4)
+	now = now.Add(30 * time.Second)
+
+	if got, want := s.render(), "⠋ Iteration 2/4 · 2m30s elapsed · ~5m30s left"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	b.Reset()
+	for _, chunk := range []string{"[2] Reading", " the code\n"} {
+		if _, err := s.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write() returned error: %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+	// The status line is erased before the line is written, and drawn again once it is complete
+	want := clearLine + "[2] Reading the code\n" + clearLine + s.render() + clearLine
+	if b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
+
+func TestGenerate_StatusLine(t *testing.T) {
+	// Save originals and restore after test
+	originalCommandContext := commandContext
+	originalIsTerminal := isTerminal
+	defer func() {
+		commandContext = originalCommandContext
+		isTerminal = originalIsTerminal
+	}()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("Done "+DefaultCompletionSignal, 0)
+	isTerminal = func(*os.File) bool { return true }
+	t.Setenv("NO_COLOR", "1")
+
+	output := captureStdout(t, func() {
+		if _, err := New(WithDir(dir)).Generate(context.Background(), "add a login button"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if strings.Contains(output, "=====") {
+		t.Errorf("expected the status line to replace the banners, got %q", output)
+	}
+	if !strings.Contains(output, "Iteration 1/10") || !strings.Contains(output, "[1] Done") {
+		t.Errorf("expected the status line and the output of Claude, got %q", output)
+	}
+	if !strings.Contains(output, "Completed at iteration 1 of 10\n") {
+		t.Errorf("expected the completion of the run, got %q", output)
+	}
+	if summary := output[strings.LastIndex(output, clearLine)+len(clearLine):]; strings.Contains(summary, "Iteration 1/10") || !strings.Contains(summary, "  Status:       completed\n") {
+		t.Errorf("expected the status line to be erased before the summary of the run, got %q", output)
+	}
+}
@@ -0,0 +1,242 @@
+package gonzo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// prefixWriter writes to w with a prefix at the start of every line, so that the output of
+// the Claude CLI streamed during an iteration stands apart from gonzo's own messages.
+type prefixWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix []byte
+	// midLine is set while the last line written is not terminated yet
+	midLine bool
+}
+
+// newPrefixWriter returns a writer prefixing every line written to w with prefix.
+func newPrefixWriter(w io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{w: w, prefix: []byte(prefix)}
+}
+
+// Write writes p to the underlying writer, prefixing the lines it starts.
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	var b bytes.Buffer
+	for rest := p; len(rest) > 0; {
+		if !pw.midLine {
+			b.Write(pw.prefix)
+		}
+		line, after, found := bytes.Cut(rest, []byte("\n"))
+		b.Write(line)
+		if found {
+			b.WriteByte('\n')
+		}
+		pw.midLine = !found
+		rest = after
+	}
+	if _, err := pw.w.Write(b.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close ends the last line when it was left unterminated, so that the next message starts on
+// a line of its own.
+func (pw *prefixWriter) Close() error {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if !pw.midLine {
+		return nil
+	}
+	pw.midLine = false
+	_, err := pw.w.Write([]byte("\n"))
+	return err
+}
+
+// iterationStream returns the writer the output of the Claude CLI is streamed to during an
+// iteration, prefixed with the number of the iteration, or nil when the run is quiet.
+func (cc *ClaudeConfig) iterationStream(iteration int) io.WriteCloser {
+	if cc.quiet {
+		return nil
+	}
+	return newPrefixWriter(cc.console(), fmt.Sprintf("[%d] ", iteration))
+}
+
+// cliStreamWriter parses the stream-json output of the Claude CLI as it comes: it writes the
+// text Claude replies with and the files it edits and commands it runs to w, unless w is nil,
+// and reports the tools Claude uses and the usage of the call so far to its callbacks. Lines
+// that are not JSON are written as they are.
+type cliStreamWriter struct {
+	w io.Writer
+	// dir is the repository the paths of the tools are made relative to.
+	dir string
+	buf []byte
+	// onToolUse and onUsage, when set, are called with every tool Claude uses and with the usage
+	// of the call after every message. The first error they return is kept in err, and the
+	// output that follows is ignored.
+	onToolUse func(toolUse) error
+	onUsage   func(Usage) error
+	err       error
+	// usage sums the usage of the messages seen, counted once per message ID.
+	usage    Usage
+	messages map[string]bool
+}
+
+func newCLIStreamWriter(w io.Writer, dir string) *cliStreamWriter {
+	return &cliStreamWriter{w: w, dir: dir, messages: map[string]bool{}}
+}
+
+func (cw *cliStreamWriter) Write(p []byte) (int, error) {
+	cw.buf = append(cw.buf, p...)
+	for {
+		i := bytes.IndexByte(cw.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := cw.buf[:i]
+		cw.buf = cw.buf[i+1:]
+		if err := cw.writeLine(line); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes what is left of an unfinished last line.
+func (cw *cliStreamWriter) Flush() error {
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	line := cw.buf
+	cw.buf = nil
+	return cw.writeLine(line)
+}
+
+// streamMessage is a line of the stream-json output of the Claude CLI.
+type streamMessage struct {
+	Type    string `json:"type"`
+	Message struct {
+		ID      string `json:"id"`
+		Content []struct {
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+		Usage Usage `json:"usage"`
+	} `json:"message"`
+}
+
+func (cw *cliStreamWriter) writeLine(line []byte) error {
+	if cw.err != nil {
+		return nil
+	}
+	var message streamMessage
+	if err := json.Unmarshal(line, &message); err != nil {
+		return cw.write(string(line) + "\n")
+	}
+	if message.Type != "assistant" {
+		return nil
+	}
+	for _, content := range message.Message.Content {
+		switch content.Type {
+		case "text":
+			if content.Text == "" {
+				continue
+			}
+			text := content.Text
+			if !strings.HasSuffix(text, "\n") {
+				text += "\n"
+			}
+			if err := cw.write(text); err != nil {
+				return err
+			}
+		case "tool_use":
+			t := newToolUse(content.Name, content.Input, cw.dir)
+			if t.Command != "" || slices.Contains(fileEditTools, t.Name) {
+				if err := cw.write(t.String() + "\n"); err != nil {
+					return err
+				}
+			}
+			if cw.onToolUse != nil {
+				if cw.err = cw.onToolUse(t); cw.err != nil {
+					return nil
+				}
+			}
+		}
+	}
+	id := message.Message.ID
+	if message.Message.Usage.IsZero() || cw.messages[id] {
+		return nil
+	}
+	// The messages of a reply with several content blocks share their ID and usage
+	if id != "" {
+		cw.messages[id] = true
+	}
+	cw.usage.Add(message.Message.Usage)
+	if cw.onUsage != nil {
+		cw.err = cw.onUsage(cw.usage)
+	}
+	return nil
+}
+
+func (cw *cliStreamWriter) write(s string) error {
+	if cw.w == nil {
+		return nil
+	}
+	_, err := io.WriteString(cw.w, s)
+	return err
+}
+
+// toolUse is a tool Claude used, as streamed by the Claude CLI.
+type toolUse struct {
+	Name string
+	// Path is the file the tool reads or changes, relative to the repository when in it.
+	Path string
+	// Command is the shell command of the Bash tool.
+	Command string
+}
+
+func (t toolUse) String() string {
+	switch {
+	case t.Command != "":
+		return t.Name + ": " + firstLine(t.Command)
+	case t.Path != "":
+		return t.Name + ": " + t.Path
+	}
+	return t.Name
+}
+
+// newToolUse returns the tool of the given name used with the given input, with the path it
+// reads or changes made relative to the repository in dir when in it.
+func newToolUse(name string, input json.RawMessage, dir string) toolUse {
+	var params struct {
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+		Command      string `json:"command"`
+	}
+	Swallow(json.Unmarshal(input, &params))
+	t := toolUse{Name: name, Path: params.FilePath, Command: params.Command}
+	if t.Path == "" {
+		t.Path = params.NotebookPath
+	}
+	if t.Path != "" && dir != "" {
+		abs := t.Path
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(dir, abs)
+		}
+		if rel, err := filepath.Rel(dir, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			t.Path = filepath.ToSlash(rel)
+		}
+	}
+	return t
+}
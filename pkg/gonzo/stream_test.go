@@ -0,0 +1,97 @@
+package gonzo
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var b bytes.Buffer
+	pw := newPrefixWriter(&b, "[2] ")
+	for _, chunk := range []string{"Reading", " the code\nWriting", " tests\n\n", "Done"} {
+		if n, err := pw.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Write() returned %d, %v", n, err)
+		}
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+
+	want := "[2] Reading the code\n[2] Writing tests\n[2] \n[2] Done\n"
+	if b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
+
+func TestExecClaudeCLI_Stream(t *testing.T) {
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+	commandContext = mockCommandContext("first line\nsecond line", 0)
+
+	var b bytes.Buffer
+	stream := newPrefixWriter(&b, "[1] ")
+	out, _, err := New().callClaudeCLI(context.Background(), "system", "prompt", stream)
+	if err != nil {
+		t.Fatalf("callClaudeCLI() returned error: %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+
+	if string(out) != "first line\nsecond line" {
+		t.Errorf("expected the output to be captured, got %q", string(out))
+	}
+	if b.String() != "[1] first line\n[1] second line\n" {
+		t.Errorf("expected the output to be streamed with a prefix, got %q", b.String())
+	}
+}
+
+// cliToolStreamOutput is the stream-json output of a Claude CLI call editing a file and running
+// a command, each message streamed along with its usage.
+const cliToolStreamOutput = `{"type":"assistant","message":{"id":"msg_1","content":[{"type":"text","text":"Adding the button"}],"usage":{"input_tokens":1000,"output_tokens":100}}}
+{"type":"assistant","message":{"id":"msg_1","content":[{"type":"tool_use","name":"Edit","input":{"file_path":"/repo/ui/button.go","old_string":"a","new_string":"b"}}],"usage":{"input_tokens":1000,"output_tokens":100}}}
+{"type":"user","message":{"content":[{"type":"tool_result","content":"ok"}]}}
+{"type":"assistant","message":{"id":"msg_2","content":[{"type":"tool_use","name":"Read","input":{"file_path":"/repo/README.md"}},{"type":"tool_use","name":"Bash","input":{"command":"go test ./...\ngo vet ./..."}}],"usage":{"input_tokens":20,"cache_read_input_tokens":1100,"output_tokens":50}}}
+`
+
+func TestCLIStreamWriter_ToolUse(t *testing.T) {
+	var b bytes.Buffer
+	cw := newCLIStreamWriter(&b, "/repo")
+	var tools []string
+	cw.onToolUse = func(tu toolUse) error {
+		tools = append(tools, tu.String())
+		return nil
+	}
+	if _, err := cw.Write([]byte(cliToolStreamOutput)); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+
+	// Only the edits and commands are shown
+	if want := "Adding the button\nEdit: ui/button.go\nBash: go test ./...\n"; b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+	if want := []string{"Edit: ui/button.go", "Read: README.md", "Bash: go test ./..."}; !slices.Equal(tools, want) {
+		t.Errorf("expected the tools %q, got %q", want, tools)
+	}
+	// The usage of a message is counted once, whatever the number of its content blocks
+	if want := (Usage{InputTokens: 1020, CacheReadInputTokens: 1100, OutputTokens: 150}); cw.usage != want {
+		t.Errorf("expected usage %+v, got %+v", want, cw.usage)
+	}
+}
+
+func TestCLIStreamWriter_Stop(t *testing.T) {
+	cw := newCLIStreamWriter(nil, "/repo")
+	calls := 0
+	cw.onToolUse = func(tu toolUse) error {
+		calls++
+		return errMaxCost
+	}
+	if _, err := cw.Write([]byte(cliToolStreamOutput)); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	if cw.err != errMaxCost || calls != 1 {
+		t.Errorf("expected the output after the first error to be ignored, got %v after %d calls", cw.err, calls)
+	}
+}
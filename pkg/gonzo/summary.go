@@ -0,0 +1,140 @@
+package gonzo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Formats of the output of a run.
+const (
+	// OutputText prints the progress of the run and Claude's final response.
+	OutputText = "text"
+	// OutputJSON prints the progress of the run to stderr and a RunSummary to stdout.
+	OutputJSON = "json"
+)
+
+// RunSummary is the machine-readable summary of a run printed by `gonzo --output json`.
+type RunSummary struct {
+	ID              string          `json:"id,omitempty"`
+	Feature         string          `json:"feature"`
+	Status          string          `json:"status"`
+	Model           string          `json:"model,omitempty"`
+	Iterations      int             `json:"iterations"`
+	Branch          string          `json:"branch,omitempty"`
+	PRURL           string          `json:"pr_url,omitempty"`
+	CostUSD         float64         `json:"cost_usd"`
+	InputTokens     int             `json:"input_tokens"`
+	OutputTokens    int             `json:"output_tokens"`
+	StartedAt       *time.Time      `json:"started_at,omitempty"`
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"`
+	DurationSeconds float64         `json:"duration_seconds"`
+	Response        string          `json:"response,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	Records         []IterationInfo `json:"records"`
+}
+
+// IterationInfo is the summary of an iteration of a run.
+type IterationInfo struct {
+	Iteration int        `json:"iteration"`
+	Time      *time.Time `json:"time,omitempty"`
+	Summary   string     `json:"summary,omitempty"`
+	Commit    string     `json:"commit,omitempty"`
+	// Changed reports whether the iteration changed files, committed or not.
+	Changed bool `json:"changed"`
+	// Usage is the tokens used and the price of the iteration, when known.
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// NewRunSummary summarizes the run of feature recorded in dir, which returned response and
+// err. The run is nil when its state was not recorded, e.g. when it failed before starting. Iterations are summarized
+// by their record in the structured progress log, or else by the first line of their response.
+func NewRunSummary(dir string, feature string, run *RunState, response string, err error) *RunSummary {
+	summary := &RunSummary{Feature: feature, Status: RunStatusCompleted, Response: response, Records: []IterationInfo{}}
+	if err != nil {
+		summary.Status = RunStatusFailed
+		summary.Error = err.Error()
+	}
+	if run == nil {
+		return summary
+	}
+
+	summary.ID = run.ID
+	summary.Feature = run.Feature
+	summary.Status = run.Status
+	summary.Model = run.Model
+	summary.Iterations = run.Iterations
+	summary.Branch = run.Branch
+	summary.PRURL = run.PRURL
+	summary.CostUSD = run.CostUSD
+	summary.InputTokens = run.InputTokens
+	summary.OutputTokens = run.OutputTokens
+	summary.StartedAt = &run.StartedAt
+	summary.FinishedAt = run.FinishedAt
+	end := time.Now()
+	if run.FinishedAt != nil {
+		end = *run.FinishedAt
+	}
+	summary.DurationSeconds = end.Sub(run.StartedAt).Round(time.Second).Seconds()
+
+	entries := map[int]ProgressEntry{}
+	if content, err := os.ReadFile(filepath.Join(dir, GonzoDir, ProgressFile)); err == nil {
+		if p, err := ParseProgress(string(content)); err == nil {
+			for _, e := range p.Entries {
+				if e.RunID == run.ID {
+					entries[e.Iteration] = e
+				}
+			}
+		}
+	}
+
+	iterations, _ := LoadIterations(dir, run)
+	for _, it := range iterations {
+		info := IterationInfo{
+			Iteration: it.Number,
+			Summary:   firstLine(StripControlMarkers(it.Response)),
+			Changed:   it.Diff != "",
+			Usage:     it.Usage,
+		}
+		if e, ok := entries[it.Number]; ok {
+			info.Time = &e.Time
+			info.Summary = e.Summary
+			info.Commit = e.Commit
+		}
+		summary.Records = append(summary.Records, info)
+	}
+	return summary
+}
+
+// logRunSummary prints the summary of a run ending with status: its status, iterations, duration,
+// commits, changes, cost, branch and pull request. The JSON output has its own summary instead.
+func (cc *ClaudeConfig) logRunSummary(ctx context.Context, dir string, run *RunState, status string) {
+	if cc.output == OutputJSON {
+		return
+	}
+
+	cc.logStyled(styleBanner, "Run %s summary", run.ID)
+	cc.logInfo("  Status:       %s", status)
+	cc.logInfo("  Iterations:   %d", run.Iterations)
+	cc.logInfo("  Duration:     %s", time.Since(run.StartedAt).Round(time.Second))
+	if run.StartSHA != "" {
+		commits, _ := commitSubjects(ctx, dir, run.StartSHA)
+		cc.logInfo("  Commits:      %d", len(commits))
+		changes, _ := diffStat(ctx, dir, run.StartSHA)
+		if changes == "" {
+			changes = "none"
+		}
+		cc.logInfo("  Changes:      %s", changes)
+	}
+	if run.CostUSD > 0 || run.InputTokens > 0 || run.OutputTokens > 0 {
+		cc.logInfo("  Cost:         %s", fmt.Sprintf("$%.4f (%d input tokens, %d output tokens)", run.CostUSD, run.InputTokens, run.OutputTokens))
+	}
+	if run.Branch != "" {
+		cc.logInfo("  Branch:       %s", run.Branch)
+	}
+	if run.PRURL != "" {
+		cc.logInfo("  Pull request: %s", run.PRURL)
+	}
+}
@@ -0,0 +1,95 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestNewRunSummary(t *testing.T) {
+	dir := t.TempDir()
+	started := time.Date(2026, 2, 1, 20, 0, 0, 0, time.UTC)
+	finished := started.Add(90 * time.Second)
+	run := &RunState{
+		ID:         "run-1",
+		Feature:    "Add a login button",
+		Model:      ClaudeSonnet,
+		Branch:     "add-login-button",
+		PRURL:      "https://github.com/example/app/pull/1",
+		Status:     RunStatusCompleted,
+		Iterations: 2,
+		CostUSD:    0.42,
+		StartedAt:  started,
+		FinishedAt: &finished,
+	}
+	if err := run.Save(dir); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	for i, response := range []string{"Added the button", "All done\n" + DefaultCompletionSignal} {
+		if err := run.SaveIterationArtifact(dir, i+1, IterationResponseArtifact, []byte(response)); err != nil {
+			t.Fatalf("SaveIterationArtifact() returned error: %v", err)
+		}
+	}
+	if err := run.SaveIterationArtifact(dir, 1, IterationDiffArtifact, []byte("+button\n")); err != nil {
+		t.Fatalf("SaveIterationArtifact() returned error: %v", err)
+	}
+	progress := &Progress{Entries: []ProgressEntry{
+		{Time: started, RunID: "run-0", Iteration: 1, Summary: "an earlier run"},
+		{Time: started.Add(time.Minute), RunID: "run-1", Iteration: 1, Summary: "feat: add login button", Commit: "abc123"},
+	}}
+	if err := os.WriteFile(filepath.Join(dir, GonzoDir, ProgressFile), []byte(progress.Render()), 0644); err != nil {
+		t.Fatalf("failed to write progress log: %v", err)
+	}
+
+	summary := NewRunSummary(dir, "ignored", run, "All done", nil)
+	if summary.ID != "run-1" || summary.Feature != run.Feature || summary.Status != RunStatusCompleted || summary.PRURL != run.PRURL {
+		t.Errorf("expected the run's state in the summary, got %+v", summary)
+	}
+	if summary.DurationSeconds != 90 || summary.CostUSD != 0.42 || summary.Error != "" {
+		t.Errorf("expected a duration of 90s, the cost and no error, got %+v", summary)
+	}
+	if len(summary.Records) != 2 {
+		t.Fatalf("expected 2 iteration records, got %+v", summary.Records)
+	}
+	if r := summary.Records[0]; r.Summary != "feat: add login button" || r.Commit != "abc123" || !r.Changed || r.Time == nil {
+		t.Errorf("expected the first iteration from the progress log, got %+v", r)
+	}
+	if r := summary.Records[1]; r.Summary != "All done" || r.Commit != "" || r.Changed {
+		t.Errorf("expected the second iteration summarized by its response, got %+v", r)
+	}
+
+	failed := NewRunSummary(dir, "Add a login button", nil, "", errors.New("claude not found"))
+	if failed.Status != RunStatusFailed || failed.Error != "claude not found" || failed.Feature != "Add a login button" || failed.Records == nil {
+		t.Errorf("expected a failed summary without a run, got %+v", failed)
+	}
+}
+
+func TestGenerate_RunSummaryBlock(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	t.Setenv("NO_COLOR", "1")
+	commandContext = mockCommandContext(`{"type":"result","result":"Done `+DefaultCompletionSignal+`","total_cost_usd":0.42,"usage":{"input_tokens":1200,"output_tokens":300}}`, 0)
+
+	output := captureStdout(t, func() {
+		if _, err := New(WithDir(dir)).Generate(context.Background(), "add a login button"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("LatestRunState() returned error: %v", err)
+	}
+	// The duration depends on how long the run took
+	want := regexp.MustCompile(regexp.QuoteMeta("Run "+run.ID+" summary\n  Status:       completed\n  Iterations:   1\n") +
+		`  Duration:     [0-9hms.]+\n` + regexp.QuoteMeta("  Cost:         $0.4200 (1200 input tokens, 300 output tokens)\n"))
+	if !want.MatchString(output) {
+		t.Errorf("expected the summary of the run to match %q, got %q", want, output)
+	}
+}
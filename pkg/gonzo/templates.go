@@ -0,0 +1,57 @@
+package gonzo
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions available to gonzo's embedded templates.
+var templateFuncs = template.FuncMap{
+	"trim": strings.TrimSpace,
+}
+
+// ValidateTemplates parses gonzo's embedded prompt templates, the repository's local copies of
+// them and pull request template in dir, and the given commit template, returning all syntax
+// errors found.
+func ValidateTemplates(dir string, commitTemplate string) error {
+	var errs []error
+
+	names, err := fs.Glob(promptLib, "prompts/*.tmpl")
+	if err != nil {
+		return fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+	for _, name := range names {
+		if _, err := template.New(filepath.Base(name)).Funcs(templateFuncs).ParseFS(promptLib, name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	local, _ := filepath.Glob(filepath.Join(PromptsPath(dir), "*.tmpl"))
+	for _, path := range local {
+		if text, err := os.ReadFile(path); err == nil {
+			if _, err := template.New(path).Funcs(templateFuncs).Parse(string(text)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	prTemplate := filepath.Join(dir, GonzoDir, PRTemplateFile)
+	if text, err := os.ReadFile(prTemplate); err == nil {
+		if _, err := template.New(prTemplate).Parse(string(text)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if commitTemplate != "" {
+		if _, err := template.New("commit-template").Parse(commitTemplate); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
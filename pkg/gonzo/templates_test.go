@@ -0,0 +1,26 @@
+package gonzo
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateTemplates(t *testing.T) {
+	dir := t.TempDir()
+	if err := ValidateTemplates(dir, "{{ .Type }}: {{ .Subject }}"); err != nil {
+		t.Errorf("expected the embedded templates to be valid, got %v", err)
+	}
+
+	writeFile(t, filepath.Join(dir, GonzoDir, PRTemplateFile), "{{ .Feature")
+	writeFile(t, filepath.Join(PromptsPath(dir), "wrap_up.tmpl"), "{{ end }}")
+	err := ValidateTemplates(dir, "{{ if .Scope }}")
+	if err == nil {
+		t.Fatal("expected errors for invalid templates")
+	}
+	for _, want := range []string{PRTemplateFile, "wrap_up.tmpl", "commit-template"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected an error for %s, got %v", want, err)
+		}
+	}
+}
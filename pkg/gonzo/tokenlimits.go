@@ -0,0 +1,66 @@
+package gonzo
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Variables of the environment of the Claude CLI bounding the tokens of the responses.
+const (
+	maxOutputTokensEnv = "CLAUDE_CODE_MAX_OUTPUT_TOKENS"
+	thinkingBudgetEnv  = "MAX_THINKING_TOKENS"
+)
+
+// MinThinkingBudget is the smallest extended-thinking budget the API accepts.
+const MinThinkingBudget = 1024
+
+// TokenLimits bound the tokens of the responses of the model in every iteration, trading quality
+// for cost and latency. Zero leaves the default of the Claude CLI.
+type TokenLimits struct {
+	// MaxOutputTokens is the number of tokens a response of the model may reach.
+	MaxOutputTokens int
+	// ThinkingBudget is the number of tokens the model may think for before responding, with
+	// extended thinking; at least MinThinkingBudget and below MaxOutputTokens.
+	ThinkingBudget int
+}
+
+// WithTokenLimits bounds the output and thinking tokens of the model in every iteration.
+func WithTokenLimits(limits TokenLimits) Option {
+	return func(cc *ClaudeConfig) {
+		cc.tokenLimits = limits
+	}
+}
+
+// WithTokenLimits applies the WithTokenLimits option to cc.
+//
+// Deprecated: Pass WithTokenLimits to New instead.
+func (cc *ClaudeConfig) WithTokenLimits(limits TokenLimits) *ClaudeConfig {
+	WithTokenLimits(limits)(cc)
+	return cc
+}
+
+// check reports limits the API would refuse, before the run starts.
+func (l TokenLimits) check() error {
+	if l.MaxOutputTokens < 0 || l.ThinkingBudget < 0 {
+		return fmt.Errorf("token limits must not be negative")
+	}
+	if l.ThinkingBudget > 0 && l.ThinkingBudget < MinThinkingBudget {
+		return fmt.Errorf("thinking budget %d is below the minimum of %d tokens", l.ThinkingBudget, MinThinkingBudget)
+	}
+	if l.ThinkingBudget > 0 && l.MaxOutputTokens > 0 && l.ThinkingBudget >= l.MaxOutputTokens {
+		return fmt.Errorf("thinking budget %d must be below the max output tokens %d", l.ThinkingBudget, l.MaxOutputTokens)
+	}
+	return nil
+}
+
+// environ returns the variables of the environment of the Claude CLI setting the limits.
+func (l TokenLimits) environ() []string {
+	var env []string
+	if l.MaxOutputTokens > 0 {
+		env = append(env, maxOutputTokensEnv+"="+strconv.Itoa(l.MaxOutputTokens))
+	}
+	if l.ThinkingBudget > 0 {
+		env = append(env, thinkingBudgetEnv+"="+strconv.Itoa(l.ThinkingBudget))
+	}
+	return env
+}
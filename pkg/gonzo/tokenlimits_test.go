@@ -0,0 +1,67 @@
+package gonzo
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTokenLimits_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  TokenLimits
+		wantErr string
+	}{
+		{"defaults", TokenLimits{}, ""},
+		{"both", TokenLimits{MaxOutputTokens: 16000, ThinkingBudget: 8000}, ""},
+		{"thinking only", TokenLimits{ThinkingBudget: 4096}, ""},
+		{"negative", TokenLimits{MaxOutputTokens: -1}, "must not be negative"},
+		{"thinking below minimum", TokenLimits{ThinkingBudget: 512}, "below the minimum"},
+		{"thinking above output", TokenLimits{MaxOutputTokens: 4096, ThinkingBudget: 4096}, "must be below the max output tokens"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.check()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerate_TokenLimits(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	var call *exec.Cmd
+	mock := mockCommandContext("Done "+DefaultCompletionSignal, 0)
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		cmd := mock(ctx, name, args...)
+		if slices.Contains(args, "--print") {
+			call = cmd
+		}
+		return cmd
+	}
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithTokenLimits(TokenLimits{MaxOutputTokens: 16000, ThinkingBudget: 8000})
+	if _, err := cc.Generate(context.Background(), "add a login button"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"CLAUDE_CODE_MAX_OUTPUT_TOKENS=16000", "MAX_THINKING_TOKENS=8000"} {
+		if !slices.Contains(call.Env, want) {
+			t.Errorf("expected %s in the environment of the Claude CLI, got %v", want, call.Env)
+		}
+	}
+
+	_, err := New(WithDir(dir)).WithQuiet(true).WithTokenLimits(TokenLimits{ThinkingBudget: 100}).Generate(context.Background(), "add a login button")
+	if ExitCode(err) != ExitPreflight {
+		t.Errorf("expected an invalid thinking budget to fail the preflight, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TranscriptsDir is the directory inside GonzoDir holding, per run, the transcript of each
+// iteration: exactly what the agent was told and what it answered.
+const TranscriptsDir = "transcripts"
+
+// transcriptsGitignore keeps the transcripts out of commits made by the agent; it ignores itself too.
+const transcriptsGitignore = "*\n"
+
+// Redacted replaces the secrets found in transcripts.
+const Redacted = "[REDACTED]"
+
+// minSecretLength is the length under which a secret value is too short to be redacted
+// without mangling unrelated text.
+const minSecretLength = 8
+
+// secretPatterns match the tokens and keys redacted from transcripts even when gonzo does not
+// hold them: Anthropic API keys, GitHub and GitLab tokens.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-ant-[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{20,}`),
+	regexp.MustCompile(`github_pat_[A-Za-z0-9_]{20,}`),
+	regexp.MustCompile(`glpat-[A-Za-z0-9_-]{20,}`),
+}
+
+// TranscriptPath returns the path of the transcript of an iteration of a run in dir.
+func TranscriptPath(dir string, id string, iteration int) string {
+	return filepath.Join(dir, GonzoDir, TranscriptsDir, id, fmt.Sprintf("%03d.md", iteration))
+}
+
+// Redact replaces with Redacted the given secret values, and the tokens matching the known
+// formats of API keys, in text.
+func Redact(text string, secrets []string) string {
+	// Longer secrets first, so that a secret containing another is replaced whole
+	sorted := append([]string(nil), secrets...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, secret := range sorted {
+		if len(secret) >= minSecretLength {
+			text = strings.ReplaceAll(text, secret, Redacted)
+		}
+	}
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllString(text, Redacted)
+	}
+	return text
+}
+
+// secretValues returns the values of the secrets gonzo knows about, from the keyring or the
+// environment. They are read once.
+func (cc *ClaudeConfig) secretValues(ctx context.Context) []string {
+	if !cc.secretsResolved {
+		cc.secretsResolved = true
+		for _, name := range Secrets() {
+			if value, _ := Secret(ctx, name); value != "" {
+				cc.secrets = append(cc.secrets, value)
+			}
+		}
+	}
+	return cc.secrets
+}
+
+// renderIterationTranscript renders the transcript of an iteration as Markdown.
+func renderIterationTranscript(run *RunState, iteration int, systemPrompt string, prompt string, response string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Run %s, iteration %d\n\n", run.ID, iteration)
+	fmt.Fprintf(&b, "Model: %s\n", run.Model)
+	for _, section := range []struct{ title, text string }{
+		{"System Prompt", systemPrompt},
+		{"Prompt", prompt},
+		{"Output", response},
+	} {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", section.title, strings.TrimRight(section.text, "\n"))
+	}
+	return b.String()
+}
+
+// recordTranscript writes the transcript of an iteration, with the secrets redacted, when
+// transcripts are enabled.
+func (cc *ClaudeConfig) recordTranscript(ctx context.Context, dir string, run *RunState, iteration int, systemPrompt string, prompt string, response string) {
+	if !cc.transcripts {
+		return
+	}
+	path := TranscriptPath(dir, run.ID, iteration)
+	if err := writeTranscript(path, Redact(renderIterationTranscript(run, iteration, systemPrompt, prompt, response), cc.secretValues(ctx))); err != nil {
+		cc.logWarn("Failed to write the transcript of iteration %d: %v", iteration, err)
+	}
+}
+
+// writeTranscript writes a transcript, creating the transcripts directory, ignored by git.
+func writeTranscript(path string, transcript string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create transcripts directory: %w", err)
+	}
+	ignore := filepath.Join(filepath.Dir(filepath.Dir(path)), ".gitignore")
+	if _, err := os.Stat(ignore); errors.Is(err, os.ErrNotExist) {
+		if err := os.WriteFile(ignore, []byte(transcriptsGitignore), 0644); err != nil {
+			return fmt.Errorf("failed to write transcripts .gitignore: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(transcript), 0644); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
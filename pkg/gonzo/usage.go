@@ -0,0 +1,76 @@
+package gonzo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// cliOutputStreamJSON is the output format of the Claude CLI printing the messages of the
+// conversation as JSON lines as they come, ending with the result object.
+const cliOutputStreamJSON = "stream-json"
+
+// Usage is the tokens used and the price of Claude CLI calls, as reported by the CLI.
+type Usage struct {
+	InputTokens              int     `json:"input_tokens"`
+	CacheCreationInputTokens int     `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int     `json:"cache_read_input_tokens,omitempty"`
+	OutputTokens             int     `json:"output_tokens"`
+	CostUSD                  float64 `json:"cost_usd"`
+}
+
+// Add adds the usage of another call.
+func (u *Usage) Add(other Usage) {
+	u.InputTokens += other.InputTokens
+	u.CacheCreationInputTokens += other.CacheCreationInputTokens
+	u.CacheReadInputTokens += other.CacheReadInputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CostUSD += other.CostUSD
+}
+
+// TotalInputTokens returns the tokens of the prompts, whether read from the prompt cache or not.
+func (u Usage) TotalInputTokens() int {
+	return u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+// IsZero reports whether no usage was reported.
+func (u Usage) IsZero() bool {
+	return u == Usage{}
+}
+
+func (u Usage) String() string {
+	return fmt.Sprintf("%d input tokens, %d output tokens, $%.4f", u.TotalInputTokens(), u.OutputTokens, u.CostUSD)
+}
+
+// addUsage adds the usage of a Claude CLI call to the totals of the run.
+func (s *RunState) addUsage(u Usage) {
+	s.InputTokens += u.TotalInputTokens()
+	s.OutputTokens += u.OutputTokens
+	s.CostUSD += u.CostUSD
+}
+
+// cliResult is the result object printed by the Claude CLI at the end of a call in the JSON
+// output formats.
+type cliResult struct {
+	Type         string  `json:"type"`
+	Result       string  `json:"result"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+	Usage        Usage   `json:"usage"`
+	SessionID    string  `json:"session_id"`
+}
+
+// parseCLIOutput returns the final response, the usage and the session of a call from the output
+// of the Claude CLI in one of its JSON output formats. Output without a result object, e.g. from
+// an older CLI, is returned as is, without usage or session.
+func parseCLIOutput(out []byte) ([]byte, Usage, string) {
+	lines := bytes.Split(bytes.TrimSpace(out), []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		var result cliResult
+		if json.Unmarshal(lines[i], &result) == nil && result.Type == "result" {
+			usage := result.Usage
+			usage.CostUSD = result.TotalCostUSD
+			return []byte(result.Result), usage, result.SessionID
+		}
+	}
+	return out, Usage{}, ""
+}
@@ -0,0 +1,88 @@
+package gonzo
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// cliStreamOutput is the stream-json output of a Claude CLI call replying text, ending with
+// its result.
+const cliStreamOutput = `{"type":"system","subtype":"init","model":"claude-opus-4-5"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Adding the button"},{"type":"tool_use","name":"Edit"}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","content":"ok"}]}}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Done <promise>COMPLETE</promise>"}]}}
+{"type":"result","subtype":"success","is_error":false,"result":"Done <promise>COMPLETE</promise>","total_cost_usd":0.125,"usage":{"input_tokens":1200,"cache_creation_input_tokens":300,"cache_read_input_tokens":500,"output_tokens":450},"session_id":"3f2a9c1e-7b4d-4e8a-9c21-5d6e7f8a9b0c"}
+`
+
+func TestParseCLIOutput(t *testing.T) {
+	response, usage, session := parseCLIOutput([]byte(cliStreamOutput))
+	if string(response) != "Done <promise>COMPLETE</promise>" {
+		t.Errorf("expected the result, got %q", response)
+	}
+	if session != "3f2a9c1e-7b4d-4e8a-9c21-5d6e7f8a9b0c" {
+		t.Errorf("expected the session of the call, got %q", session)
+	}
+	want := Usage{InputTokens: 1200, CacheCreationInputTokens: 300, CacheReadInputTokens: 500, OutputTokens: 450, CostUSD: 0.125}
+	if usage != want {
+		t.Errorf("expected %+v, got %+v", want, usage)
+	}
+	if got := usage.String(); got != "2000 input tokens, 450 output tokens, $0.1250" {
+		t.Errorf("unexpected String() %q", got)
+	}
+
+	// Output of an older CLI, without a result
+	response, usage, session = parseCLIOutput([]byte("plain text"))
+	if string(response) != "plain text" || !usage.IsZero() || session != "" {
+		t.Errorf("expected the output as is without usage or session, got %q, %+v, %q", response, usage, session)
+	}
+}
+
+func TestCLIStreamWriter(t *testing.T) {
+	var b bytes.Buffer
+	cw := newCLIStreamWriter(&b, "")
+	// Written in chunks splitting lines
+	for _, chunk := range []string{cliStreamOutput[:40], cliStreamOutput[40:200], cliStreamOutput[200:]} {
+		if _, err := cw.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write() returned error: %v", err)
+		}
+	}
+	if err := cw.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+	if want := "Adding the button\nEdit\nDone <promise>COMPLETE</promise>\n"; b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
+
+func TestGenerate_Usage(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext(cliStreamOutput, 0)
+
+	output := captureStdout(t, func() {
+		result, err := New(WithDir(dir)).Generate(context.Background(), "add a login button")
+		if err != nil || result != "Done" {
+			t.Fatalf("expected the result of the call, got %q, %v", result, err)
+		}
+	})
+	if !strings.Contains(output, "[1] Adding the button") || !strings.Contains(output, "Usage: 2000 input tokens, 450 output tokens, $0.1250") {
+		t.Errorf("expected the replies and usage to be printed, got %s", output)
+	}
+
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("LatestRunState() returned error: %v", err)
+	}
+	if run.InputTokens != 2000 || run.OutputTokens != 450 || run.CostUSD != 0.125 {
+		t.Errorf("expected the usage to be recorded, got %+v", run)
+	}
+	summary := NewRunSummary(dir, run.Feature, run, "Done", nil)
+	if summary.InputTokens != 2000 || len(summary.Records) != 1 || summary.Records[0].Usage == nil || summary.Records[0].Usage.OutputTokens != 450 {
+		t.Errorf("expected the usage in the summary, got %+v", summary)
+	}
+}
@@ -1,6 +1,9 @@
 package gonzo
 
-import "log"
+import (
+	"log"
+	"strings"
+)
 
 func SwallowVal[T any](val T, err error) T {
 	Swallow(err)
@@ -12,3 +15,17 @@ func Swallow(err error) {
 		log.Printf("%+v", err)
 	}
 }
+
+// swallow is Swallow during a run: it logs err, if any, as a warning of the run, so it reaches
+// the logger, the log files and the events of the run.
+func (cc *ClaudeConfig) swallow(err error) {
+	if err != nil {
+		cc.logWarn("%+v", err)
+	}
+}
+
+// firstLine returns the first line of s, ignoring leading blank lines.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,59 @@
+package gonzo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andybarilla/gonzo/pkg/config"
+	"slices"
+)
+
+// Validator is implemented by runners that can check their configuration before a run starts.
+type Validator interface {
+	Validate() error
+}
+
+var _ Validator = (*ClaudeConfig)(nil)
+
+// Validate checks the configuration for values out of range and options that conflict, returning
+// all the problems at once. Generate fails before its first iteration when it finds any.
+func (cc *ClaudeConfig) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	errs = append(errs, config.CheckModel(cc.model))
+	check(cc.maxIterations >= 1, "max iterations must be at least 1, got %d", cc.maxIterations)
+	check(cc.wrapUpIterations >= 0, "wrap-up iterations must not be negative, got %d", cc.wrapUpIterations)
+	check(cc.retries >= 0, "retries must not be negative, got %d", cc.retries)
+	check(cc.iterationTimeout >= 0, "iteration timeout must not be negative, got %s", cc.iterationTimeout)
+	check(cc.maxDuration >= 0, "max duration must not be negative, got %s", cc.maxDuration)
+	check(cc.heartbeat >= 0, "heartbeat must not be negative, got %s", cc.heartbeat)
+	check(cc.rateLimitWait >= 0, "rate limit wait must not be negative, got %s", cc.rateLimitWait)
+	check(cc.guards.MaxCost >= 0, "max cost must not be negative, got $%.2f", cc.guards.MaxCost)
+	check(cc.completionSignal != "", "completion signal must not be empty")
+	check(slices.Contains([]string{"", SessionFresh, SessionContinue}, cc.sessionMode),
+		"unknown session mode %q: expected %s or %s", cc.sessionMode, SessionFresh, SessionContinue)
+	check(slices.Contains([]string{"", OutputText, OutputJSON}, cc.output),
+		"unknown output %q: expected %s or %s", cc.output, OutputText, OutputJSON)
+	check(slices.Contains([]string{"", LogFormatText, LogFormatJSON}, cc.logging.Format),
+		"unknown log format %q: expected %s or %s", cc.logging.Format, LogFormatText, LogFormatJSON)
+
+	_, err := cc.permissions.args()
+	errs = append(errs, err, cc.tokenLimits.check())
+	if cc.detector == nil {
+		_, err = cc.newCompletionDetector()
+		errs = append(errs, err)
+	}
+
+	// Options that do not go together
+	check(cc.maxDuration == 0 || cc.iterationTimeout <= cc.maxDuration,
+		"iteration timeout %s exceeds the max duration %s of the run", cc.iterationTimeout, cc.maxDuration)
+	check(!cc.pr || !cc.noBranch || cc.currentPR,
+		"a pull request needs the branch of the run, which no-branch turns off")
+	check(cc.completionPattern == "" || cc.completion == CompletionRegex || cc.detector != nil,
+		"a completion pattern is only used with the %s completion, got %q", CompletionRegex, cc.completion)
+	return errors.Join(errs...)
+}
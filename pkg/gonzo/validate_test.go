@@ -0,0 +1,71 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		wantErrs []string
+	}{
+		{"defaults", nil, nil},
+		{"regex completion", []Option{WithCompletion(CompletionRegex, `(?i)all done`)}, nil},
+		{"max iterations", []Option{WithMaxIterations(0)}, []string{"max iterations must be at least 1"}},
+		{"unknown model", []Option{WithModel("gpt")}, []string{"gpt"}},
+		{"session mode", []Option{WithSessionMode("resume")}, []string{`unknown session mode "resume"`}},
+		{"timeout beyond duration", []Option{WithIterationTimeout(time.Hour), WithMaxDuration(time.Minute)},
+			[]string{"iteration timeout 1h0m0s exceeds the max duration 1m0s"}},
+		{"pattern without regex", []Option{WithCompletion(CompletionMarker, "done")},
+			[]string{"a completion pattern is only used with the regex completion"}},
+		{"pr without branch", []Option{WithPR(true), WithNoBranch(true)},
+			[]string{"a pull request needs the branch of the run"}},
+		{"pr on current branch", []Option{WithPR(true), WithNoBranch(true), WithPROnCurrentBranch(true)}, nil},
+		{"regex without pattern", []Option{WithCompletion(CompletionRegex, "")}, []string{"needs a completion pattern"}},
+		{"all at once", []Option{WithMaxIterations(0), WithRetries(-1), WithModel("gpt"), WithGuards(Guards{MaxCost: -1})},
+			[]string{"max iterations", "retries must not be negative", "gpt", "max cost must not be negative"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(tt.opts...).Validate()
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors %q, got nil", tt.wantErrs)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error containing %q, got %v", want, err)
+				}
+			}
+			var joined interface{ Unwrap() []error }
+			if errors.As(err, &joined) && len(joined.Unwrap()) != len(tt.wantErrs) {
+				t.Errorf("expected %d errors, got %d: %v", len(tt.wantErrs), len(joined.Unwrap()), err)
+			}
+		})
+	}
+}
+
+func TestGenerate_InvalidConfig(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+	commandContext = mockCommandContext("Done "+DefaultCompletionSignal, 0)
+
+	_, err := New(WithDir(t.TempDir()), WithMaxIterations(0)).Generate(context.Background(), "Test feature")
+	if err == nil || !strings.Contains(err.Error(), "max iterations") {
+		t.Fatalf("expected a configuration error, got %v", err)
+	}
+	if code := ExitCode(err); code != ExitPreflight {
+		t.Errorf("expected exit code %d, got %d", ExitPreflight, code)
+	}
+}
@@ -0,0 +1,84 @@
+package gonzo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// verify runs the verification commands once the agent reports completion, and saves their
+// output with the iteration. It returns the output and whether every command passed.
+func (cc *ClaudeConfig) verify(ctx context.Context, dir string, run *RunState, iteration int) (string, bool) {
+	if len(cc.verifyCommands) == 0 {
+		return "", true
+	}
+
+	var b strings.Builder
+	passed := true
+	cc.verification = nil
+	for _, command := range cc.verifyCommands {
+		start := time.Now()
+		cc.logInfo("Verifying: %s (started at %s)", command, start.Format(timeOfDay))
+		out, err := runShell(ctx, dir, command, run, iteration, RunStatusRunning)
+		end := time.Now()
+		cc.verification = append(cc.verification, VerificationResult{Command: command, Output: out, Passed: err == nil, Duration: end.Sub(start)})
+		fmt.Fprintf(&b, "$ %s\n%s", command, out)
+		if out != "" && !strings.HasSuffix(out, "\n") {
+			b.WriteString("\n")
+		}
+		timing := fmt.Sprintf("at %s, after %s", end.Format(timeOfDay), roundDuration(end.Sub(start)))
+		attrs := []slog.Attr{slog.String("command", command), slog.Int("iteration", iteration)}
+		if err == nil {
+			cc.logTimed(slog.LevelInfo, fmt.Sprintf("Verification passed %s: %s", timing, command), start, end, attrs...)
+		} else {
+			cc.logTimed(slog.LevelWarn, fmt.Sprintf("Verification failed %s: %s: %v", timing, command, err), start, end, attrs...)
+			fmt.Fprintf(&b, "(failed: %v)\n", err)
+			passed = false
+		}
+	}
+
+	cc.swallow(run.SaveIterationArtifact(dir, iteration, IterationVerificationArtifact, []byte(b.String())))
+	return b.String(), passed
+}
+
+// lastVerification returns the output of the latest verification commands run, if any.
+func lastVerification(dir string, run *RunState) string {
+	for i := run.Iterations; i > 0; i-- {
+		if data, err := os.ReadFile(filepath.Join(iterationDir(dir, run.ID, i), IterationVerificationArtifact)); err == nil {
+			return strings.TrimSpace(string(data))
+		}
+	}
+	return ""
+}
+
+// verifyFailedData is the data of the verification failure template.
+type verifyFailedData struct {
+	Feature      string
+	Output       string
+	CommitAuthor string
+	ProgressFile string
+}
+
+// verifyFailedPrompt renders the prompt of the iteration following a failed verification.
+func (cc *ClaudeConfig) verifyFailedPrompt(feature string, progressFile string, output string) (string, error) {
+	t, err := cc.parsePrompt("verify_failed.tmpl")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse verification template: %w", err)
+	}
+
+	var b strings.Builder
+	err = t.Execute(&b, verifyFailedData{
+		Feature:      feature,
+		Output:       strings.TrimSpace(output),
+		CommitAuthor: cc.commitAuthor,
+		ProgressFile: filepath.ToSlash(progressFile),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute verification template: %w", err)
+	}
+	return b.String(), nil
+}
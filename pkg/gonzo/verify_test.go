@@ -0,0 +1,71 @@
+package gonzo
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGenerate_VerifyFailureContinuesRun(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext("Feature implemented\n"+DefaultCompletionSignal, 0)
+
+	// Fails the first time only
+	verify := `if [ -f verified ]; then echo ok; else touch verified; echo "2 tests failed"; exit 1; fi`
+	cc := New(WithDir(dir)).WithQuiet(true).WithVerifyCommands([]string{verify})
+	result, err := cc.Generate(context.Background(), "test prompt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "Feature implemented" {
+		t.Errorf("unexpected result %q", result)
+	}
+
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("expected run state to be recorded: %v", err)
+	}
+	if run.Status != RunStatusCompleted || run.Iterations != 2 {
+		t.Errorf("expected run to complete at iteration 2, got status %q at iteration %d", run.Status, run.Iterations)
+	}
+
+	iterations, err := LoadIterations(dir, run)
+	if err != nil || len(iterations) != 2 {
+		t.Fatalf("expected 2 recorded iterations, got %d (%v)", len(iterations), err)
+	}
+	if !strings.Contains(iterations[0].Verification, "2 tests failed") {
+		t.Errorf("expected the failed verification to be recorded, got %q", iterations[0].Verification)
+	}
+	if !strings.Contains(iterations[1].Prompt, "Verification Failed") || !strings.Contains(iterations[1].Prompt, "2 tests failed") {
+		t.Errorf("expected the next prompt to report the failure, got %q", iterations[1].Prompt)
+	}
+	if !strings.Contains(lastVerification(dir, run), "ok") {
+		t.Errorf("expected the passing verification to be the latest, got %q", lastVerification(dir, run))
+	}
+}
+
+func TestGenerate_VerifyNeverPasses(t *testing.T) {
+	// Save original and restore after test
+	originalCommandContext := commandContext
+	defer func() { commandContext = originalCommandContext }()
+
+	dir := t.TempDir()
+	commandContext = mockCommandContext(DefaultCompletionSignal, 0)
+
+	cc := New(WithDir(dir)).WithQuiet(true).WithMaxIterations(2).WithVerifyCommands([]string{"exit 1"})
+	if _, err := cc.Generate(context.Background(), "test prompt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	run, err := LatestRunState(dir)
+	if err != nil {
+		t.Fatalf("expected run state to be recorded: %v", err)
+	}
+	if run.Status != RunStatusIncomplete || run.Iterations != 2 {
+		t.Errorf("expected an incomplete run after 2 iterations, got status %q at iteration %d", run.Status, run.Iterations)
+	}
+}
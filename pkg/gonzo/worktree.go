@@ -0,0 +1,143 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// PatchArtifact is the run artifact holding the changes of a run made in a worktree, as a patch
+// series to apply with git am.
+const PatchArtifact = "changes.patch"
+
+// Worktree is a linked git worktree of a repository, checked out at its HEAD with a detached
+// HEAD, where a run changes the code without touching the checkout of the repository until its
+// changes are merged back.
+type Worktree struct {
+	// Repo is the repository the worktree belongs to.
+	Repo string
+	// Dir is the directory of the worktree.
+	Dir string
+	// BaseSHA is the commit the worktree was created at.
+	BaseSHA string
+}
+
+// NewWorktree creates a worktree of the repository at repo in a new temporary directory.
+func NewWorktree(ctx context.Context, repo string) (*Worktree, error) {
+	base, err := headSHA(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	tmp, err := os.MkdirTemp("", "gonzo-worktree-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the worktree: %w", err)
+	}
+	dir := filepath.Join(tmp, filepath.Base(repo))
+	if _, err := git(ctx, repo, "worktree", "add", "--quiet", "--detach", dir, base); err != nil {
+		Swallow(os.RemoveAll(tmp))
+		return nil, fmt.Errorf("failed to create the worktree: %w", err)
+	}
+	return &Worktree{Repo: repo, Dir: dir, BaseSHA: base}, nil
+}
+
+// HasChanges reports whether changes were made in the worktree since it was created, committed
+// or not.
+func (w *Worktree) HasChanges(ctx context.Context) (bool, error) {
+	head, err := headSHA(ctx, w.Dir)
+	if err != nil || head != w.BaseSHA {
+		return head != w.BaseSHA, err
+	}
+	changes, err := UncommittedChanges(ctx, w.Dir)
+	return len(changes) > 0, err
+}
+
+// CommitAll commits the changes left uncommitted in the worktree with msg, e.g. by a run that
+// failed before committing them, so that Diff, Patch and Merge include them.
+func (w *Worktree) CommitAll(ctx context.Context, msg string) error {
+	changes, err := UncommittedChanges(ctx, w.Dir)
+	if err != nil || len(changes) == 0 {
+		return err
+	}
+	if _, err := git(ctx, w.Dir, "add", "--all"); err != nil {
+		return fmt.Errorf("failed to commit the uncommitted changes: %w", err)
+	}
+	if _, err := git(ctx, w.Dir, "commit", "--quiet", "-m", msg); err != nil {
+		return fmt.Errorf("failed to commit the uncommitted changes: %w", err)
+	}
+	return nil
+}
+
+// Diff returns the changes committed in the worktree, as a diffstat followed by the patch.
+func (w *Worktree) Diff(ctx context.Context) (string, error) {
+	return git(ctx, w.Dir, "diff", "--stat", "--patch", w.BaseSHA, "HEAD")
+}
+
+// Patch returns the commits made in the worktree as a patch series, in the format of git
+// format-patch.
+func (w *Worktree) Patch(ctx context.Context) (string, error) {
+	patch, err := git(ctx, w.Dir, "format-patch", "--stdout", w.BaseSHA+"..HEAD")
+	if err != nil || patch == "" {
+		return "", err
+	}
+	return patch + "\n", nil
+}
+
+// Merge merges the commits made in the worktree into the branch checked out in the repository:
+// fast-forwarding it when it has not moved since the worktree was created, or else with a merge
+// commit. A merge that fails, e.g. on conflicts, is aborted and leaves the branch as it was.
+func (w *Worktree) Merge(ctx context.Context) error {
+	head, err := headSHA(ctx, w.Dir)
+	if err != nil {
+		return err
+	}
+	if _, err := git(ctx, w.Repo, "merge", "--quiet", "--no-edit", head); err != nil {
+		// Fails when git refused to start the merge, leaving nothing to abort
+		_, _ = git(ctx, w.Repo, "merge", "--abort")
+		return fmt.Errorf("failed to merge the changes: %w", err)
+	}
+	return nil
+}
+
+// KeepArtifacts copies the run states, logs and transcripts recorded in the worktree to the
+// repository, which would otherwise be lost with it.
+func (w *Worktree) KeepArtifacts() error {
+	for _, name := range []string{RunsDir, LogsDir, TranscriptsDir} {
+		src := filepath.Join(w.Dir, GonzoDir, name)
+		dst := filepath.Join(w.Repo, GonzoDir, name)
+		err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(src, path)
+			if err != nil {
+				return err
+			}
+			target := filepath.Join(dst, rel)
+			if _, err := os.Stat(target); err == nil {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			return os.WriteFile(target, data, 0644)
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to keep the artifacts of the worktree: %w", err)
+		}
+	}
+	return nil
+}
+
+// Remove deletes the worktree, along with the changes left in it.
+func (w *Worktree) Remove(ctx context.Context) error {
+	_, err := git(ctx, w.Repo, "worktree", "remove", "--force", w.Dir)
+	Swallow(os.RemoveAll(filepath.Dir(w.Dir)))
+	return err
+}
@@ -0,0 +1,126 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorktree(t *testing.T) {
+	repo := initGitRepo(t)
+	ctx := context.Background()
+
+	wt, err := NewWorktree(ctx, repo)
+	if err != nil {
+		t.Fatalf("NewWorktree() returned error: %v", err)
+	}
+	if changed, err := wt.HasChanges(ctx); err != nil || changed {
+		t.Fatalf("expected a new worktree to have no changes, got %v (%v)", changed, err)
+	}
+	if err := os.WriteFile(filepath.Join(wt.Dir, "draft.txt"), []byte("draft\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := wt.HasChanges(ctx); err != nil || !changed {
+		t.Fatalf("expected an uncommitted file to be a change, got %v (%v)", changed, err)
+	}
+	if err := os.Remove(filepath.Join(wt.Dir, "draft.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	writeAndCommit(t, wt.Dir, "feature.go", "package feature\n", "add feature")
+	if changed, err := wt.HasChanges(ctx); err != nil || !changed {
+		t.Fatalf("expected the commit to be a change, got %v (%v)", changed, err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "feature.go")); !os.IsNotExist(err) {
+		t.Fatalf("expected the repository to be left alone, got %v", err)
+	}
+
+	// Changes left uncommitted count as well, and are committed on top
+	if err := os.WriteFile(filepath.Join(wt.Dir, "draft.go"), []byte("package draft\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.CommitAll(ctx, "uncommitted changes"); err != nil {
+		t.Fatalf("CommitAll() returned error: %v", err)
+	}
+	if out := runGit(t, wt.Dir, "status", "--porcelain"); out != "" {
+		t.Errorf("expected no uncommitted changes left, got %q", out)
+	}
+
+	diff, err := wt.Diff(ctx)
+	if err != nil || !strings.Contains(diff, "feature.go | 1 +") || !strings.Contains(diff, "+package feature") {
+		t.Errorf("expected the diff to hold the stat and patch of the change, got %q (%v)", diff, err)
+	}
+	patch, err := wt.Patch(ctx)
+	if err != nil || !strings.Contains(patch, "Subject: [PATCH 1/2] add feature") || !strings.Contains(patch, "+package draft") {
+		t.Errorf("expected a patch of the commits, got %q (%v)", patch, err)
+	}
+
+	if err := wt.Merge(ctx); err != nil {
+		t.Fatalf("Merge() returned error: %v", err)
+	}
+	if out := runGit(t, repo, "log", "-1", "--format=%s"); out != "uncommitted changes" {
+		t.Errorf("expected the branch to be fast-forwarded to the change, got %q", out)
+	}
+
+	if err := wt.Remove(ctx); err != nil {
+		t.Fatalf("Remove() returned error: %v", err)
+	}
+	if _, err := os.Stat(wt.Dir); !os.IsNotExist(err) {
+		t.Errorf("expected the worktree to be removed, got %v", err)
+	}
+	if out := runGit(t, repo, "worktree", "list"); strings.Count(out, "\n") != 0 {
+		t.Errorf("expected the worktree to be pruned, got %q", out)
+	}
+}
+
+func TestWorktree_MergeConflict(t *testing.T) {
+	repo := initGitRepo(t)
+	ctx := context.Background()
+
+	wt, err := NewWorktree(ctx, repo)
+	if err != nil {
+		t.Fatalf("NewWorktree() returned error: %v", err)
+	}
+	defer func() { Swallow(wt.Remove(ctx)) }()
+	writeAndCommit(t, wt.Dir, "README.md", "from the run\n", "change readme in the run")
+	writeAndCommit(t, repo, "README.md", "from the user\n", "change readme meanwhile")
+	head := runGit(t, repo, "rev-parse", "HEAD")
+
+	if err := wt.Merge(ctx); err == nil {
+		t.Fatal("expected the conflicting merge to fail")
+	}
+	if out := runGit(t, repo, "rev-parse", "HEAD"); out != head {
+		t.Errorf("expected the branch to be left alone, got %s", out)
+	}
+	if out := runGit(t, repo, "status", "--porcelain"); out != "" {
+		t.Errorf("expected the merge to be aborted, got %q", out)
+	}
+}
+
+func TestWorktree_KeepArtifacts(t *testing.T) {
+	repo := initGitRepo(t)
+	ctx := context.Background()
+
+	wt, err := NewWorktree(ctx, repo)
+	if err != nil {
+		t.Fatalf("NewWorktree() returned error: %v", err)
+	}
+	defer func() { Swallow(wt.Remove(ctx)) }()
+	run := &RunState{ID: NewRunID(), Feature: "feature", Status: RunStatusCompleted}
+	if err := run.Save(wt.Dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wt.KeepArtifacts(); err != nil {
+		t.Fatalf("KeepArtifacts() returned error: %v", err)
+	}
+	kept, err := LatestRunState(repo)
+	if err != nil || kept.ID != run.ID {
+		t.Fatalf("expected the run state to be kept, got %v (%v)", kept, err)
+	}
+	if out := runGit(t, repo, "status", "--porcelain"); out != "" {
+		t.Errorf("expected the artifacts to be ignored by git, got %q", out)
+	}
+}
@@ -0,0 +1,126 @@
+package gonzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// WithWriteScope confines Claude to the files matching scope, globs relative to the repository
+// such as "pkg/" or "internal/**/*.go", in the format of Guards.ProtectedPaths. After each
+// iteration, gonzo reverts the changes to any other file, committed or not, and tells Claude in
+// the prompt of the next iteration. The progress log is always in scope.
+func WithWriteScope(scope []string) Option {
+	return func(cc *ClaudeConfig) {
+		cc.writeScope = scope
+	}
+}
+
+// inWriteScope reports whether Claude may change the slash-separated path, relative to the
+// repository.
+func (cc *ClaudeConfig) inWriteScope(path string) bool {
+	if len(cc.writeScope) == 0 || strings.HasPrefix(path, GonzoDir+"/") {
+		return true
+	}
+	return slices.ContainsFunc(cc.writeScope, func(pattern string) bool {
+		return matchPath(pattern, path)
+	})
+}
+
+// enforceWriteScope restores the files outside the write scope changed since sha to their content
+// at sha, removing the ones it did not have, and returns their paths. The changes already
+// committed are undone by a commit of their own. The files in dirty, which had uncommitted changes
+// before the iteration, are left alone: what of them is the user's work cannot be told apart.
+func (cc *ClaudeConfig) enforceWriteScope(ctx context.Context, dir string, sha string, dirty map[string]bool) ([]string, error) {
+	if len(cc.writeScope) == 0 || sha == "" {
+		return nil, nil
+	}
+	// The paths are relative to the repository, whichever of its directories gonzo runs in
+	dir, err := repoRoot(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := git(ctx, dir, "diff", "-z", "--name-only", "--no-renames", sha)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(ctx, dir, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []string
+	for _, path := range strings.Split(changed+"\x00"+untracked, "\x00") {
+		if path == "" || dirty[path] || cc.inWriteScope(path) || slices.Contains(reverted, path) {
+			continue
+		}
+		if _, err := git(ctx, dir, "cat-file", "-e", sha+":"+path); err == nil {
+			_, err = git(ctx, dir, "checkout", sha, "--", path)
+			if err != nil {
+				return reverted, err
+			}
+		} else {
+			if _, err := git(ctx, dir, "rm", "--quiet", "--cached", "--force", "--ignore-unmatch", "--", path); err != nil {
+				return reverted, err
+			}
+			if err := os.Remove(filepath.Join(dir, filepath.FromSlash(path))); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return reverted, fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
+		reverted = append(reverted, path)
+	}
+	if len(reverted) == 0 {
+		return nil, nil
+	}
+
+	staged, err := git(ctx, dir, "diff", "-z", "--cached", "--name-only", "--no-renames")
+	if err != nil {
+		return reverted, err
+	}
+	var commit []string
+	for _, path := range strings.Split(staged, "\x00") {
+		if slices.Contains(reverted, path) {
+			commit = append(commit, path)
+		}
+	}
+	if len(commit) == 0 {
+		return reverted, nil
+	}
+	msg := "Revert changes outside the write scope"
+	if cc.conventionalCommits {
+		msg = "revert: changes outside the write scope"
+	}
+	args := []string{"commit", "--quiet", "-m", msg}
+	if cc.commitAuthor != "" {
+		args = append(args, "--author="+cc.commitAuthor)
+	}
+	if _, err := git(ctx, dir, append(append(args, "--"), commit...)...); err != nil {
+		return reverted, err
+	}
+	return reverted, nil
+}
+
+// writeScopeData is the data of the write scope template.
+type writeScopeData struct {
+	Scope    []string
+	Reverted []string
+}
+
+// writeScopePrompt renders the note telling Claude the changes outside the write scope were
+// reverted, prepended to the prompt of the next iteration.
+func (cc *ClaudeConfig) writeScopePrompt(reverted []string) (string, error) {
+	t, err := cc.parsePrompt("write_scope.tmpl")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse write scope template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, writeScopeData{Scope: cc.writeScope, Reverted: reverted}); err != nil {
+		return "", fmt.Errorf("failed to execute write scope template: %w", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
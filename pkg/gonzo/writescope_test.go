@@ -0,0 +1,192 @@
+package gonzo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInWriteScope(t *testing.T) {
+	cc := New(WithWriteScope([]string{"pkg/", "*.md"}))
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"pkg/app/main.go", true},
+		{"docs/guide.md", true},
+		{GonzoDir + "/progress.md", true},
+		{"go.mod", false},
+		{"internal/app.go", false},
+	}
+	for _, tt := range tests {
+		if got := cc.inWriteScope(tt.path); got != tt.want {
+			t.Errorf("inWriteScope(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if !New().inWriteScope("go.mod") {
+		t.Error("expected any path to be in an empty write scope")
+	}
+}
+
+func TestEnforceWriteScope(t *testing.T) {
+	dir := initGitRepo(t)
+	ctx := context.Background()
+	if err := os.MkdirAll(filepath.Join(dir, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeAndCommit(t, dir, "go.mod", "module app\n", "add go.mod")
+	sha := runGit(t, dir, "rev-parse", "HEAD")
+
+	// Committed, uncommitted and untracked changes, in and out of the scope
+	writeAndCommit(t, dir, "go.mod", "module changed\n", "change go.mod")
+	writeAndCommit(t, dir, "pkg/app.go", "package app\n", "add app")
+	for name, content := range map[string]string{
+		"README.md":   "changed\n",
+		"notes.txt":   "notes\n",
+		"pkg/util.go": "package app\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cc := New(WithWriteScope([]string{"pkg/"}))
+	reverted, err := cc.enforceWriteScope(ctx, dir, sha, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	slices.Sort(reverted)
+	if want := []string{"README.md", "go.mod", "notes.txt"}; !slices.Equal(reverted, want) {
+		t.Errorf("expected %v to be reverted, got %v", want, reverted)
+	}
+
+	for name, want := range map[string]string{"go.mod": "module app\n", "README.md": "initial\n", "pkg/util.go": "package app\n"} {
+		if data, _ := os.ReadFile(filepath.Join(dir, name)); string(data) != want {
+			t.Errorf("expected %s to hold %q, got %q", name, want, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected the untracked file outside the scope to be removed, got %v", err)
+	}
+	if out := runGit(t, dir, "log", "-1", "--format=%s"); out != "revert: changes outside the write scope" {
+		t.Errorf("expected the committed change to be reverted by a commit, got %q", out)
+	}
+	if out := runGit(t, dir, "show", "HEAD:pkg/app.go"); out != "package app" {
+		t.Errorf("expected the committed change in the scope to remain, got %q", out)
+	}
+	if out := runGit(t, dir, "status", "--porcelain"); out != "?? pkg/util.go" {
+		t.Errorf("expected only the change in the scope to be left, got %q", out)
+	}
+}
+
+func TestEnforceWriteScope_NoScope(t *testing.T) {
+	dir := initGitRepo(t)
+	sha := runGit(t, dir, "rev-parse", "HEAD")
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := New().enforceWriteScope(context.Background(), dir, sha, nil)
+	if err != nil || reverted != nil {
+		t.Fatalf("expected nothing to be reverted without a scope, got %v (%v)", reverted, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "README.md")); string(data) != "changed\n" {
+		t.Errorf("expected the change to remain, got %q", data)
+	}
+}
+
+func TestEnforceWriteScope_DirtyBefore(t *testing.T) {
+	dir := initGitRepo(t)
+	ctx := context.Background()
+	sha := runGit(t, dir, "rev-parse", "HEAD")
+
+	// The user's work outside the scope, there before the iteration
+	for name, content := range map[string]string{"README.md": "user edit\n", "scratch.txt": "user notes\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dirty, err := dirtyPaths(ctx, dir)
+	if err != nil || !dirty["README.md"] || !dirty["scratch.txt"] {
+		t.Fatalf("expected the changes to be listed as dirty, got %v (%v)", dirty, err)
+	}
+	// The change of the iteration outside the scope
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := New(WithWriteScope([]string{"pkg/"})).enforceWriteScope(ctx, dir, sha, dirty)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(reverted, []string{"notes.txt"}) {
+		t.Errorf("expected only the change of the iteration to be reverted, got %v", reverted)
+	}
+	for name, want := range map[string]string{"README.md": "user edit\n", "scratch.txt": "user notes\n"} {
+		if data, _ := os.ReadFile(filepath.Join(dir, name)); string(data) != want {
+			t.Errorf("expected the user's %s to be kept, got %q", name, data)
+		}
+	}
+}
+
+func TestEnforceWriteScope_Subdirectory(t *testing.T) {
+	dir := initGitRepo(t)
+	ctx := context.Background()
+	sub := filepath.Join(dir, "sub")
+	if err := os.MkdirAll(filepath.Join(sub, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeAndCommit(t, dir, "sub/go.mod", "module app\n", "add go.mod")
+	sha := runGit(t, dir, "rev-parse", "HEAD")
+
+	// Changes outside the scope, in and out of the subdirectory gonzo runs in
+	for name, content := range map[string]string{"sub/go.mod": "module changed\n", "sub/notes.txt": "notes\n", "README.md": "changed\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reverted, err := New(WithWriteScope([]string{"sub/pkg/"})).enforceWriteScope(ctx, sub, sha, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	slices.Sort(reverted)
+	if want := []string{"README.md", "sub/go.mod", "sub/notes.txt"}; !slices.Equal(reverted, want) {
+		t.Errorf("expected %v to be reverted, got %v", want, reverted)
+	}
+	for name, want := range map[string]string{"sub/go.mod": "module app\n", "README.md": "initial\n"} {
+		if data, _ := os.ReadFile(filepath.Join(dir, name)); string(data) != want {
+			t.Errorf("expected %s to hold %q, got %q", name, want, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(sub, "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected the untracked file outside the scope to be removed, got %v", err)
+	}
+}
+
+func TestWriteScopePrompt(t *testing.T) {
+	cc := New(WithWriteScope([]string{"pkg/"}))
+	prompt, err := cc.writeScopePrompt([]string{"go.mod"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"Changes Reverted", "`go.mod`", "`pkg/`"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected the prompt to contain %q, got %q", want, prompt)
+		}
+	}
+}
+
+func TestSystemPrompt_WriteScope(t *testing.T) {
+	prompt, err := New(WithDir(t.TempDir()), WithWriteScope([]string{"internal/"})).systemPrompt(GonzoDir + "/progress.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(prompt, "## Write Scope") || !strings.Contains(prompt, "`internal/`") {
+		t.Errorf("expected the system prompt to list the write scope, got %q", prompt)
+	}
+}
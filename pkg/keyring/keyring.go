@@ -0,0 +1,122 @@
+// Package keyring stores secrets in the operating system's keyring by shelling out to
+// the platform's CLI: security on macOS and secret-tool (libsecret) on Linux.
+package keyring
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Service is the keyring service secrets are stored under.
+const Service = "gonzo"
+
+// Platform CLIs.
+const (
+	SecurityCli   = "security"
+	SecretToolCli = "secret-tool"
+)
+
+// securityNotFound is the exit code of security when the item does not exist.
+const securityNotFound = 44
+
+var (
+	// ErrNotFound is returned when no secret is stored for the account.
+	ErrNotFound = errors.New("secret not found in keyring")
+	// ErrUnsupported is returned on platforms without a supported keyring CLI.
+	ErrUnsupported = errors.New("no supported keyring on this platform")
+)
+
+// commandContext is a variable that wraps exec.CommandContext for testing.
+var commandContext = exec.CommandContext
+
+// goos is the platform to use the keyring of, replaceable for testing.
+var goos = runtime.GOOS
+
+// Get returns the secret stored for account, or ErrNotFound.
+func Get(ctx context.Context, account string) (string, error) {
+	var name string
+	var args []string
+	switch goos {
+	case "darwin":
+		name, args = SecurityCli, []string{"find-generic-password", "-s", Service, "-a", account, "-w"}
+	case "linux":
+		name, args = SecretToolCli, []string{"lookup", "service", Service, "account", account}
+	default:
+		return "", ErrUnsupported
+	}
+
+	out, err := run(ctx, "", name, args...)
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return "", ErrNotFound
+	}
+	return out, nil
+}
+
+// Set stores secret for account, replacing any existing secret.
+func Set(ctx context.Context, account string, secret string) error {
+	var err error
+	switch goos {
+	case "darwin":
+		_, err = run(ctx, "", SecurityCli, "add-generic-password", "-U", "-s", Service, "-a", account, "-w", secret)
+	case "linux":
+		// secret-tool reads the secret from stdin, keeping it off the command line
+		_, err = run(ctx, secret, SecretToolCli, "store", "--label", Service+" "+account, "service", Service, "account", account)
+	default:
+		return ErrUnsupported
+	}
+	return err
+}
+
+// Delete removes the secret stored for account. Deleting a missing secret is not an error.
+func Delete(ctx context.Context, account string) error {
+	var err error
+	switch goos {
+	case "darwin":
+		_, err = run(ctx, "", SecurityCli, "delete-generic-password", "-s", Service, "-a", account)
+	case "linux":
+		_, err = run(ctx, "", SecretToolCli, "clear", "service", Service, "account", account)
+	default:
+		return ErrUnsupported
+	}
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// run runs a keyring CLI with stdin as its input and returns its trimmed stdout.
+func run(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+	cmd := commandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("%w: %s is not installed", ErrUnsupported, name)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// secret-tool exits 1 without output when nothing matches
+			if (name == SecurityCli && exitErr.ExitCode() == securityNotFound) ||
+				(name == SecretToolCli && len(out) == 0 && stderr.Len() == 0) {
+				return "", ErrNotFound
+			}
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s %s: %w: %s", name, args[0], err, msg)
+		}
+		return "", fmt.Errorf("%s %s: %w", name, args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
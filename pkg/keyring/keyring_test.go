@@ -0,0 +1,107 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// mockCommandContext creates a mock exec.Cmd that calls TestHelperProcess instead of the keyring CLI.
+// The arguments of every call are recorded in calls.
+func mockCommandContext(stdout string, exitCode int, calls *[]string) func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+	return func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		if calls != nil {
+			*calls = append(*calls, strings.Join(append([]string{name}, arg...), " "))
+		}
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cs = append(cs, arg...)
+		cmd := exec.CommandContext(ctx, os.Args[0], cs...)
+		cmd.Env = []string{
+			"GO_WANT_HELPER_PROCESS=1",
+			fmt.Sprintf("GO_HELPER_STDOUT=%s", stdout),
+			fmt.Sprintf("GO_HELPER_EXIT_CODE=%d", exitCode),
+		}
+		return cmd
+	}
+}
+
+// TestHelperProcess is not a real test. It's used as a mock process for exec.Command tests.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	exitCode := 0
+	fmt.Sscanf(os.Getenv("GO_HELPER_EXIT_CODE"), "%d", &exitCode)
+	fmt.Fprint(os.Stdout, os.Getenv("GO_HELPER_STDOUT"))
+	os.Exit(exitCode)
+}
+
+// withPlatform replaces the platform and keyring CLI for the duration of the test.
+func withPlatform(t *testing.T, platform string, stdout string, exitCode int, calls *[]string) {
+	t.Helper()
+	originalGOOS, originalCommandContext := goos, commandContext
+	goos = platform
+	commandContext = mockCommandContext(stdout, exitCode, calls)
+	t.Cleanup(func() { goos, commandContext = originalGOOS, originalCommandContext })
+}
+
+func TestGet(t *testing.T) {
+	var calls []string
+	withPlatform(t, "linux", "s3cret\n", 0, &calls)
+	secret, err := Get(context.Background(), "github")
+	if err != nil {
+		t.Fatalf("Get() returned error: %v", err)
+	}
+	if secret != "s3cret" {
+		t.Errorf("expected s3cret, got %q", secret)
+	}
+	if calls[0] != "secret-tool lookup service gonzo account github" {
+		t.Errorf("unexpected arguments %q", calls[0])
+	}
+
+	withPlatform(t, "linux", "", 1, nil)
+	if _, err := Get(context.Background(), "github"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound from secret-tool, got %v", err)
+	}
+
+	withPlatform(t, "darwin", "", securityNotFound, nil)
+	if _, err := Get(context.Background(), "github"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound from security, got %v", err)
+	}
+
+	withPlatform(t, "windows", "", 0, nil)
+	if _, err := Get(context.Background(), "github"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	var calls []string
+	withPlatform(t, "darwin", "", 0, &calls)
+	if err := Set(context.Background(), "gitlab", "tok"); err != nil {
+		t.Fatalf("Set() returned error: %v", err)
+	}
+	if calls[0] != "security add-generic-password -U -s gonzo -a gitlab -w tok" {
+		t.Errorf("unexpected arguments %q", calls[0])
+	}
+
+	calls = nil
+	withPlatform(t, "linux", "", 0, &calls)
+	if err := Set(context.Background(), "gitlab", "tok"); err != nil {
+		t.Fatalf("Set() returned error: %v", err)
+	}
+	if strings.Contains(calls[0], "tok") {
+		t.Errorf("expected the secret to be passed on stdin, got %q", calls[0])
+	}
+}
+
+func TestDelete(t *testing.T) {
+	withPlatform(t, "linux", "", 1, nil)
+	if err := Delete(context.Background(), "github"); err != nil {
+		t.Errorf("expected deleting a missing secret to succeed, got %v", err)
+	}
+}